@@ -0,0 +1,304 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/handler"
+	"github.com/Toshik1978/go-rest-api/handler/account"
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service"
+	"github.com/Toshik1978/go-rest-api/service/money"
+	"github.com/Toshik1978/go-rest-api/service/server"
+	"go.uber.org/zap"
+)
+
+const (
+	accountsCommand = "accounts"
+	transferCommand = "transfer"
+	exportCommand   = "export"
+
+	jsonOutput  = "json"
+	tableOutput = "table"
+
+	adminFailedCode = 1
+
+	// exportPageLimit define page size to iterate over all payments or accounts
+	exportPageLimit = 1000
+)
+
+// adminCommand define admin subcommand, it parses own arguments and uses admin to access accounts
+type adminCommand func(a *admin, args []string) error
+
+// adminCommands define admin subcommands by name
+var adminCommands = map[string]adminCommand{
+	accountsCommand:  (*admin).accounts,
+	transferCommand:  (*admin).transfer,
+	exportCommand:    (*admin).export,
+	reconcileCommand: (*admin).reconcile,
+}
+
+// admin define environment of admin subcommands. Account manager is created on first use,
+// so wrong arguments are reported without connecting to database
+type admin struct {
+	stdout            io.Writer
+	output            string
+	accountManager    handler.AccountManager
+	newAccountManager func() handler.AccountManager
+}
+
+// runAdmin runs admin subcommand with the same configuration and account manager as the service
+// and return exit code
+func runAdmin(logger *zap.Logger, name string, command adminCommand, args []string) int {
+	var dbClient service.DBClient
+	defer func() {
+		if dbClient != nil {
+			dbClient.Stop()
+		}
+	}()
+
+	a := &admin{
+		stdout: os.Stdout,
+		newAccountManager: func() handler.AccountManager {
+			vars := server.LoadConfig(logger)
+			var repositoryFactory repository.Factory
+			repositoryFactory, dbClient = initializeStorage(logger, vars)
+			currencyRegistry := initializeCurrencies(logger, vars)
+			fxRateProvider := initializeFX(logger, vars)
+			globals := initializeGlobals(logger, vars, repositoryFactory, currencyRegistry, fxRateProvider)
+			return account.NewAccountManager(globals)
+		},
+	}
+	err := command(a, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	if errors.Is(err, errBalanceDrift) {
+		return reconcileDriftCode
+	}
+	if err != nil {
+		logger.Error("Failed to run "+name+" command", zap.Error(err))
+		return adminFailedCode
+	}
+	return 0
+}
+
+// manager return account manager, it's created on the first call
+func (a *admin) manager() handler.AccountManager {
+	if a.accountManager == nil {
+		a.accountManager = a.newAccountManager()
+	}
+	return a.accountManager
+}
+
+// parse parses arguments of the command with the common output flag
+func (a *admin) parse(flags *flag.FlagSet, args []string) error {
+	flags.StringVar(&a.output, "output", jsonOutput, "output format: json or table")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if a.output != jsonOutput && a.output != tableOutput {
+		return fmt.Errorf("unknown output format %q", a.output)
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments %v", flags.Args())
+	}
+	return nil
+}
+
+// accounts runs accounts subcommands: create and list
+func (a *admin) accounts(args []string) error {
+	if len(args) == 0 {
+		return errors.New("accounts command should be create or list")
+	}
+	switch args[0] {
+	case "create":
+		return a.createAccount(args[1:])
+	case "list":
+		return a.listAccounts(args[1:])
+	}
+	return fmt.Errorf("unknown accounts command %q", args[0])
+}
+
+// createAccount creates new account
+func (a *admin) createAccount(args []string) error {
+	flags := flag.NewFlagSet(accountsCommand+" create", flag.ContinueOnError)
+	uid := flags.String("uid", "", "UID of the new account")
+	currency := flags.String("currency", "", "currency of the new account")
+	balance := flags.String("balance", "0", "opening balance of the new account")
+	idempotencyKey := flags.String("idempotency-key", "", "idempotency key, repeated command returns the same account")
+	if err := a.parse(flags, args); err != nil {
+		return err
+	}
+	amount, err := money.Parse(*balance)
+	if err != nil {
+		return fmt.Errorf("invalid balance %q", *balance)
+	}
+
+	created, err := a.manager().AccountBuilder().
+		SetUID(*uid).
+		SetCurrency(*currency).
+		SetBalance(amount).
+		SetIdempotencyKey(*idempotencyKey).
+		Build(context.Background())
+	if err != nil {
+		return err
+	}
+	return a.write(created, accountsTable([]handler.Account{*created}))
+}
+
+// listAccounts prints page of accounts or all of them
+func (a *admin) listAccounts(args []string) error {
+	flags := flag.NewFlagSet(accountsCommand+" list", flag.ContinueOnError)
+	limit := flags.Int("limit", 0, "page size, default page size is used if not set")
+	cursor := flags.String("cursor", "", "cursor of the page, the first page is printed if not set")
+	all := flags.Bool("all", false, "print all accounts instead of the single page")
+	if err := a.parse(flags, args); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if !*all {
+		list, err := a.manager().AllAccounts(ctx, handler.PageRequest{Limit: *limit, Cursor: *cursor})
+		if err != nil {
+			return err
+		}
+		return a.write(list, accountsTable(list.Accounts))
+	}
+
+	result := &handler.AccountList{Accounts: make([]handler.Account, 0)}
+	page := handler.PageRequest{Limit: exportPageLimit, Cursor: *cursor}
+	for {
+		list, err := a.manager().AllAccounts(ctx, page)
+		if err != nil {
+			return err
+		}
+		result.Accounts = append(result.Accounts, list.Accounts...)
+		if list.NextCursor == "" {
+			break
+		}
+		page.Cursor = list.NextCursor
+	}
+	return a.write(result, accountsTable(result.Accounts))
+}
+
+// transfer creates payment between accounts
+func (a *admin) transfer(args []string) error {
+	flags := flag.NewFlagSet(transferCommand, flag.ContinueOnError)
+	from := flags.String("from", "", "UID of the payer's account")
+	to := flags.String("to", "", "UID of the recipient's account")
+	amountFlag := flags.String("amount", "", "amount in payer's currency")
+	idempotencyKey := flags.String("idempotency-key", "", "idempotency key, repeated command returns the same payment")
+	if err := a.parse(flags, args); err != nil {
+		return err
+	}
+	amount, err := money.Parse(*amountFlag)
+	if err != nil {
+		return fmt.Errorf("invalid amount %q", *amountFlag)
+	}
+
+	payment, err := a.manager().PaymentBuilder().
+		SetPayer(*from).
+		SetRecipient(*to).
+		SetAmount(amount).
+		SetIdempotencyKey(*idempotencyKey).
+		Build(context.Background())
+	if err != nil {
+		return err
+	}
+	return a.write(payment, paymentsTable([]handler.Payment{*payment}))
+}
+
+// export prints all payments of the system or of the single account
+func (a *admin) export(args []string) error {
+	flags := flag.NewFlagSet(exportCommand, flag.ContinueOnError)
+	uid := flags.String("account", "", "UID of the account, all payments are exported if not set")
+	if err := a.parse(flags, args); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	payments := make([]handler.Payment, 0)
+	page := handler.PageRequest{Limit: exportPageLimit}
+	for {
+		var list *handler.PaymentList
+		var err error
+		if *uid == "" {
+			list, err = a.manager().AllPayments(ctx, page)
+		} else {
+			list, err = a.manager().AccountPayments(ctx, *uid, handler.PaymentFilter{}, page)
+		}
+		if err != nil {
+			return err
+		}
+		payments = append(payments, list.Payments...)
+		if list.NextCursor == "" {
+			break
+		}
+		page.Cursor = list.NextCursor
+	}
+	return a.write(payments, paymentsTable(payments))
+}
+
+// write prints value as indented JSON or as the table, depending on output format
+func (a *admin) write(value interface{}, table [][]string) error {
+	if a.output == tableOutput {
+		writer := tabwriter.NewWriter(a.stdout, 0, 0, 2, ' ', 0)
+		for _, row := range table {
+			if _, err := fmt.Fprintln(writer, strings.Join(row, "\t")); err != nil {
+				return err
+			}
+		}
+		return writer.Flush()
+	}
+
+	encoder := json.NewEncoder(a.stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// accountsTable return table of accounts with header
+func accountsTable(accounts []handler.Account) [][]string {
+	table := [][]string{{"UID", "CURRENCY", "BALANCE", "STATUS", "CREATED_AT"}}
+	for _, a := range accounts {
+		table = append(table, []string{
+			a.UID,
+			a.Currency,
+			a.Balance.String(),
+			a.Status,
+			a.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	return table
+}
+
+// paymentsTable return table of payments with header
+func paymentsTable(payments []handler.Payment) [][]string {
+	table := [][]string{{"ACCOUNT", "DIRECTION", "AMOUNT", "CURRENCY", "COUNTERPARTY", "CREATED_AT"}}
+	for _, payment := range payments {
+		counterparty := ""
+		if payment.SourceUID != nil {
+			counterparty = *payment.SourceUID
+		}
+		if payment.TargetUID != nil {
+			counterparty = *payment.TargetUID
+		}
+		table = append(table, []string{
+			payment.UID,
+			payment.Direction,
+			payment.Amount.String(),
+			payment.Currency,
+			counterparty,
+			payment.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	return table
+}
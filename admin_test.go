@@ -0,0 +1,319 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"strings"
+
+	"github.com/Toshik1978/go-rest-api/handler"
+	"github.com/Toshik1978/go-rest-api/mock"
+	"github.com/Toshik1978/go-rest-api/service/money"
+	"github.com/Toshik1978/go-rest-api/service/testutil"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+)
+
+type adminTestSuite struct {
+	suite.Suite
+}
+
+// newAdmin creates admin with the given account manager, which prints to the returned buffer
+func (s *adminTestSuite) newAdmin(accountManager handler.AccountManager) (*admin, *bytes.Buffer) {
+	stdout := &bytes.Buffer{}
+	return &admin{
+		stdout: stdout,
+		newAccountManager: func() handler.AccountManager {
+			s.NotNil(accountManager, "account manager is not expected to be used")
+			return accountManager
+		},
+	}, stdout
+}
+
+func (s *adminTestSuite) TestUnknownOutputFailed() {
+	a, stdout := s.newAdmin(nil)
+	err := a.accounts([]string{"list", "-output", "xml"})
+
+	s.Error(err)
+	s.Empty(stdout.String())
+}
+
+func (s *adminTestSuite) TestUnexpectedArgumentsFailed() {
+	a, stdout := s.newAdmin(nil)
+	err := a.export([]string{"toshik1978"})
+
+	s.Error(err)
+	s.Empty(stdout.String())
+}
+
+func (s *adminTestSuite) TestUnknownAccountsCommandFailed() {
+	a, _ := s.newAdmin(nil)
+
+	s.Error(a.accounts(nil))
+	s.Error(a.accounts([]string{"delete"}))
+}
+
+func (s *adminTestSuite) TestHelpSucceeded() {
+	a, _ := s.newAdmin(nil)
+	err := a.transfer([]string{"-h"})
+
+	s.True(errors.Is(err, flag.ErrHelp))
+}
+
+func (s *adminTestSuite) TestCreateAccountInvalidBalanceFailed() {
+	a, _ := s.newAdmin(nil)
+	err := a.accounts([]string{"create", "-uid", "toshik1978", "-currency", "USD", "-balance", "1O0"})
+
+	s.Error(err)
+}
+
+func (s *adminTestSuite) TestCreateAccountFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	accountBuilder := mock.NewMockAccountBuilder(ctrl)
+	accountBuilder.
+		EXPECT().
+		SetUID(gomock.Eq("toshik1978")).
+		Return(accountBuilder)
+	accountBuilder.
+		EXPECT().
+		SetCurrency(gomock.Eq("USD")).
+		Return(accountBuilder)
+	accountBuilder.
+		EXPECT().
+		SetBalance(gomock.Eq(money.FromMinorUnits(10000, 2))).
+		Return(accountBuilder)
+	accountBuilder.
+		EXPECT().
+		SetIdempotencyKey(gomock.Eq("")).
+		Return(accountBuilder)
+	accountBuilder.
+		EXPECT().
+		Build(gomock.Any()).
+		Return(nil, handler.NewError("account already exists", handler.ConflictError))
+	accountManager := mock.NewMockAccountManager(ctrl)
+	accountManager.
+		EXPECT().
+		AccountBuilder().
+		Return(accountBuilder)
+
+	a, stdout := s.newAdmin(accountManager)
+	err := a.accounts([]string{"create", "-uid", "toshik1978", "-currency", "USD", "-balance", "100.00"})
+
+	s.Error(err)
+	s.Empty(stdout.String())
+}
+
+func (s *adminTestSuite) TestCreateAccountSucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	account := testutil.AccountResponse()
+	accountBuilder := mock.NewMockAccountBuilder(ctrl)
+	accountBuilder.
+		EXPECT().
+		SetUID(gomock.Eq("toshik1978")).
+		Return(accountBuilder)
+	accountBuilder.
+		EXPECT().
+		SetCurrency(gomock.Eq("USD")).
+		Return(accountBuilder)
+	accountBuilder.
+		EXPECT().
+		SetBalance(gomock.Eq(money.FromMinorUnits(10000, 2))).
+		Return(accountBuilder)
+	accountBuilder.
+		EXPECT().
+		SetIdempotencyKey(gomock.Eq("key")).
+		Return(accountBuilder)
+	accountBuilder.
+		EXPECT().
+		Build(gomock.Any()).
+		Return(&account, nil)
+	accountManager := mock.NewMockAccountManager(ctrl)
+	accountManager.
+		EXPECT().
+		AccountBuilder().
+		Return(accountBuilder)
+
+	a, stdout := s.newAdmin(accountManager)
+	err := a.accounts([]string{"create",
+		"-uid", "toshik1978", "-currency", "USD", "-balance", "100.00", "-idempotency-key", "key"})
+
+	s.NoError(err)
+	var created handler.Account
+	s.NoError(json.Unmarshal(stdout.Bytes(), &created))
+	s.Equal(account.UID, created.UID)
+	s.Equal(account.Balance, created.Balance)
+}
+
+func (s *adminTestSuite) TestListAllAccountsSucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	account1 := testutil.AccountResponse()
+	account2 := testutil.AccountResponse()
+	account2.UID = "toshik1979"
+	accountManager := mock.NewMockAccountManager(ctrl)
+	gomock.InOrder(
+		accountManager.
+			EXPECT().
+			AllAccounts(gomock.Any(), gomock.Eq(handler.PageRequest{Limit: exportPageLimit})).
+			Return(&handler.AccountList{Accounts: []handler.Account{account1}, NextCursor: "next"}, nil),
+		accountManager.
+			EXPECT().
+			AllAccounts(gomock.Any(), gomock.Eq(handler.PageRequest{Limit: exportPageLimit, Cursor: "next"})).
+			Return(&handler.AccountList{Accounts: []handler.Account{account2}}, nil),
+	)
+
+	a, stdout := s.newAdmin(accountManager)
+	err := a.accounts([]string{"list", "-all", "-output", "table"})
+
+	s.NoError(err)
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	s.Len(lines, 3)
+	s.True(strings.HasPrefix(lines[0], "UID"))
+	s.True(strings.HasPrefix(lines[1], "toshik1978"))
+	s.True(strings.HasPrefix(lines[2], "toshik1979"))
+}
+
+func (s *adminTestSuite) TestTransferInvalidAmountFailed() {
+	a, _ := s.newAdmin(nil)
+	err := a.transfer([]string{"-from", "toshik1978", "-to", "toshik1979"})
+
+	s.Error(err)
+}
+
+func (s *adminTestSuite) TestTransferSucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	payment := testutil.PaymentResponse()
+	paymentBuilder := mock.NewMockPaymentBuilder(ctrl)
+	paymentBuilder.
+		EXPECT().
+		SetPayer(gomock.Eq("toshik1978")).
+		Return(paymentBuilder)
+	paymentBuilder.
+		EXPECT().
+		SetRecipient(gomock.Eq("toshik1979")).
+		Return(paymentBuilder)
+	paymentBuilder.
+		EXPECT().
+		SetAmount(gomock.Eq(money.FromMinorUnits(10000, 2))).
+		Return(paymentBuilder)
+	paymentBuilder.
+		EXPECT().
+		SetIdempotencyKey(gomock.Eq("")).
+		Return(paymentBuilder)
+	paymentBuilder.
+		EXPECT().
+		Build(gomock.Any()).
+		Return(&payment, nil)
+	accountManager := mock.NewMockAccountManager(ctrl)
+	accountManager.
+		EXPECT().
+		PaymentBuilder().
+		Return(paymentBuilder)
+
+	a, stdout := s.newAdmin(accountManager)
+	err := a.transfer([]string{"-from", "toshik1978", "-to", "toshik1979", "-amount", "100.00", "-output", "table"})
+
+	s.NoError(err)
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	s.Len(lines, 2)
+	s.Equal([]string{"toshik1978", "outgoing", "100.00", "USD", "toshik1979"}, strings.Fields(lines[1])[:5])
+}
+
+func (s *adminTestSuite) TestExportFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	accountManager := mock.NewMockAccountManager(ctrl)
+	accountManager.
+		EXPECT().
+		AccountPayments(gomock.Any(), gomock.Eq("toshik1978"), gomock.Any(), gomock.Any()).
+		Return(nil, handler.NewError("account not found", handler.NotFoundError))
+
+	a, stdout := s.newAdmin(accountManager)
+	err := a.export([]string{"-account", "toshik1978"})
+
+	s.Error(err)
+	s.Empty(stdout.String())
+}
+
+func (s *adminTestSuite) TestExportSucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	payment := testutil.PaymentResponse()
+	accountManager := mock.NewMockAccountManager(ctrl)
+	gomock.InOrder(
+		accountManager.
+			EXPECT().
+			AllPayments(gomock.Any(), gomock.Eq(handler.PageRequest{Limit: exportPageLimit})).
+			Return(&handler.PaymentList{Payments: []handler.Payment{payment}, NextCursor: "next"}, nil),
+		accountManager.
+			EXPECT().
+			AllPayments(gomock.Any(), gomock.Eq(handler.PageRequest{Limit: exportPageLimit, Cursor: "next"})).
+			Return(&handler.PaymentList{Payments: []handler.Payment{payment}}, nil),
+	)
+
+	a, stdout := s.newAdmin(accountManager)
+	err := a.export(nil)
+
+	s.NoError(err)
+	var payments []handler.Payment
+	s.NoError(json.Unmarshal(stdout.Bytes(), &payments))
+	s.Len(payments, 2)
+}
+
+func (s *adminTestSuite) TestReconcileDriftFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	accountManager := mock.NewMockAccountManager(ctrl)
+	accountManager.
+		EXPECT().
+		Reconcile(gomock.Any(), gomock.Eq(handler.ReconciliationRequest{})).
+		Return(&handler.Reconciliation{
+			Drifts: []handler.Drift{{
+				UID:             "toshik1978",
+				Currency:        "USD",
+				ExpectedBalance: money.FromMinorUnits(10000, 2),
+				ActualBalance:   money.FromMinorUnits(9000, 2),
+			}},
+		}, nil)
+
+	a, stdout := s.newAdmin(accountManager)
+	err := a.reconcile(nil)
+
+	s.True(errors.Is(err, errBalanceDrift))
+	s.Contains(stdout.String(), "toshik1978")
+}
+
+func (s *adminTestSuite) TestReconcileAdjustSucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	accountManager := mock.NewMockAccountManager(ctrl)
+	accountManager.
+		EXPECT().
+		Reconcile(gomock.Any(), gomock.Eq(handler.ReconciliationRequest{Adjust: true, Reason: "incident"})).
+		Return(&handler.Reconciliation{
+			Drifts: []handler.Drift{{
+				UID:             "toshik1978",
+				Currency:        "USD",
+				ExpectedBalance: money.FromMinorUnits(10000, 2),
+				ActualBalance:   money.FromMinorUnits(9000, 2),
+			}},
+			Adjusted: true,
+		}, nil)
+
+	a, _ := s.newAdmin(accountManager)
+	err := a.reconcile([]string{"-adjust", "-reason", "incident", "-output", "table"})
+
+	s.NoError(err)
+}
@@ -0,0 +1,4 @@
+ALTER TABLE payments DROP COLUMN currency;
+ALTER TABLE payments ALTER COLUMN amount TYPE INT;
+
+ALTER TABLE accounts ALTER COLUMN balance TYPE INT;
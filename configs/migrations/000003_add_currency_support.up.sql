@@ -0,0 +1,9 @@
+ALTER TABLE accounts ALTER COLUMN balance TYPE BIGINT;
+
+ALTER TABLE payments ALTER COLUMN amount TYPE BIGINT;
+ALTER TABLE payments ADD COLUMN currency VARCHAR(16);
+UPDATE payments
+SET currency = accounts.currency
+FROM accounts
+WHERE accounts.uid = payments.payer_account_uid;
+ALTER TABLE payments ALTER COLUMN currency SET NOT NULL;
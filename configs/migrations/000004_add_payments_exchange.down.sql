@@ -0,0 +1,5 @@
+ALTER TABLE payments DROP COLUMN rate;
+ALTER TABLE payments DROP COLUMN target_currency;
+ALTER TABLE payments DROP COLUMN target_amount;
+ALTER TABLE payments DROP COLUMN source_currency;
+ALTER TABLE payments DROP COLUMN source_amount;
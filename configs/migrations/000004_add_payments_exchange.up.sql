@@ -0,0 +1,18 @@
+ALTER TABLE payments ADD COLUMN source_amount BIGINT;
+ALTER TABLE payments ADD COLUMN source_currency VARCHAR(16);
+ALTER TABLE payments ADD COLUMN target_amount BIGINT;
+ALTER TABLE payments ADD COLUMN target_currency VARCHAR(16);
+ALTER TABLE payments ADD COLUMN rate NUMERIC(30, 15);
+
+UPDATE payments
+SET source_amount = ABS(amount),
+    source_currency = currency,
+    target_amount = ABS(amount),
+    target_currency = currency,
+    rate = 1;
+
+ALTER TABLE payments ALTER COLUMN source_amount SET NOT NULL;
+ALTER TABLE payments ALTER COLUMN source_currency SET NOT NULL;
+ALTER TABLE payments ALTER COLUMN target_amount SET NOT NULL;
+ALTER TABLE payments ALTER COLUMN target_currency SET NOT NULL;
+ALTER TABLE payments ALTER COLUMN rate SET NOT NULL;
@@ -0,0 +1,9 @@
+CREATE TABLE idempotency_keys(
+                         operation VARCHAR(32) NOT NULL,
+                         key VARCHAR(256) NOT NULL,
+                         fingerprint VARCHAR(64) NOT NULL,
+                         response TEXT NOT NULL,
+                         created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+                         expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+                         PRIMARY KEY (operation, key)
+);
@@ -0,0 +1,4 @@
+DROP TABLE account_transitions;
+
+ALTER TABLE accounts DROP COLUMN status_changed_at;
+ALTER TABLE accounts DROP COLUMN status;
@@ -0,0 +1,17 @@
+ALTER TABLE accounts ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'active';
+ALTER TABLE accounts ADD CHECK (status IN ('active', 'frozen', 'closed'));
+ALTER TABLE accounts ADD COLUMN status_changed_at TIMESTAMP WITH TIME ZONE;
+UPDATE accounts
+SET status_changed_at = created_at;
+ALTER TABLE accounts ALTER COLUMN status_changed_at SET NOT NULL;
+
+CREATE TABLE account_transitions(
+                         id BIGSERIAL PRIMARY KEY,
+                         account_uid VARCHAR(256) NOT NULL,
+                         from_status VARCHAR(16) NOT NULL,
+                         to_status VARCHAR(16) NOT NULL,
+                         created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+                         FOREIGN KEY (account_uid) REFERENCES accounts(uid)
+);
+
+CREATE INDEX ON account_transitions(account_uid);
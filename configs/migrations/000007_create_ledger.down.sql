@@ -0,0 +1,40 @@
+CREATE TABLE payments(
+                         id BIGSERIAL PRIMARY KEY,
+                         amount BIGINT NOT NULL,
+                         currency VARCHAR(16) NOT NULL,
+                         payer_account_uid VARCHAR(256) NOT NULL,
+                         recipient_account_uid VARCHAR(256) NOT NULL,
+                         source_amount BIGINT NOT NULL,
+                         source_currency VARCHAR(16) NOT NULL,
+                         target_amount BIGINT NOT NULL,
+                         target_currency VARCHAR(16) NOT NULL,
+                         rate NUMERIC(30, 15) NOT NULL,
+                         created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+                         FOREIGN KEY (payer_account_uid) REFERENCES accounts(uid),
+                         FOREIGN KEY (recipient_account_uid) REFERENCES accounts(uid)
+);
+
+CREATE INDEX ON payments(payer_account_uid);
+CREATE INDEX ON payments(recipient_account_uid);
+
+INSERT INTO payments
+    (amount, currency, payer_account_uid, recipient_account_uid,
+    source_amount, source_currency, target_amount, target_currency, rate, created_at)
+SELECT amount, currency, payer_account_uid, recipient_account_uid,
+       source_amount, source_currency, target_amount, target_currency, rate, created_at
+FROM (
+    SELECT id, 1 AS n, source_amount AS amount, source_currency AS currency,
+           payer_account_uid, recipient_account_uid,
+           source_amount, source_currency, target_amount, target_currency, rate, created_at
+    FROM transfers
+    UNION ALL
+    SELECT id, 2, -target_amount, target_currency,
+           recipient_account_uid, payer_account_uid,
+           source_amount, source_currency, target_amount, target_currency, rate, created_at
+    FROM transfers
+) payments
+ORDER BY id, n;
+
+DROP TABLE postings;
+DROP TABLE transfers;
+DROP FUNCTION check_transfer_balance();
@@ -0,0 +1,84 @@
+CREATE TABLE transfers(
+                         id BIGSERIAL PRIMARY KEY,
+                         payer_account_uid VARCHAR(256) NOT NULL,
+                         recipient_account_uid VARCHAR(256) NOT NULL,
+                         source_amount BIGINT NOT NULL,
+                         source_currency VARCHAR(16) NOT NULL,
+                         target_amount BIGINT NOT NULL,
+                         target_currency VARCHAR(16) NOT NULL,
+                         rate NUMERIC(30, 15) NOT NULL,
+                         created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+                         FOREIGN KEY (payer_account_uid) REFERENCES accounts(uid),
+                         FOREIGN KEY (recipient_account_uid) REFERENCES accounts(uid)
+);
+
+-- Postings don't reference accounts, because internal accounts (e.g. @fx) are not stored there
+CREATE TABLE postings(
+                         id BIGSERIAL PRIMARY KEY,
+                         transfer_id BIGINT NOT NULL,
+                         account_uid VARCHAR(256) NOT NULL,
+                         amount BIGINT NOT NULL,
+                         currency VARCHAR(16) NOT NULL,
+                         created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+                         FOREIGN KEY (transfer_id) REFERENCES transfers(id)
+);
+
+CREATE INDEX ON postings(transfer_id);
+CREATE INDEX ON postings(account_uid);
+
+-- Zero-sum invariant: postings of every transfer are balanced in each currency.
+-- Trigger is deferred, so it checks all postings of the transfer at commit
+CREATE FUNCTION check_transfer_balance() RETURNS TRIGGER AS $$
+BEGIN
+    IF EXISTS (
+        SELECT 1
+        FROM postings
+        WHERE transfer_id = NEW.transfer_id
+        GROUP BY currency
+        HAVING SUM(amount) <> 0
+    ) THEN
+        RAISE EXCEPTION 'transfer % is not balanced', NEW.transfer_id USING ERRCODE = 'check_violation';
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER postings_balance
+    AFTER INSERT OR UPDATE ON postings
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE PROCEDURE check_transfer_balance();
+
+-- Every payment was stored twice: outgoing record of payer and incoming (negative) record of recipient.
+-- Outgoing records become transfers, incoming ones are just mirrors
+INSERT INTO transfers
+    (id, payer_account_uid, recipient_account_uid,
+    source_amount, source_currency, target_amount, target_currency, rate, created_at)
+SELECT id, payer_account_uid, recipient_account_uid,
+       source_amount, source_currency, target_amount, target_currency, rate, created_at
+FROM payments
+WHERE amount >= 0
+ORDER BY id;
+SELECT setval(pg_get_serial_sequence('transfers', 'id'), COALESCE(MAX(id), 1)) FROM transfers;
+
+INSERT INTO postings
+    (transfer_id, account_uid, amount, currency, created_at)
+SELECT transfer_id, account_uid, amount, currency, created_at
+FROM (
+    SELECT id AS transfer_id, 1 AS n, payer_account_uid AS account_uid,
+           -source_amount AS amount, source_currency AS currency, created_at
+    FROM transfers
+    UNION ALL
+    SELECT id, 2, '@fx', source_amount, source_currency, created_at
+    FROM transfers
+    WHERE source_currency <> target_currency
+    UNION ALL
+    SELECT id, 3, '@fx', -target_amount, target_currency, created_at
+    FROM transfers
+    WHERE source_currency <> target_currency
+    UNION ALL
+    SELECT id, 4, recipient_account_uid, target_amount, target_currency, created_at
+    FROM transfers
+) ledger
+ORDER BY transfer_id, n;
+
+DROP TABLE payments;
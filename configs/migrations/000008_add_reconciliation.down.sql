@@ -0,0 +1,3 @@
+DROP TABLE balance_adjustments;
+
+ALTER TABLE accounts DROP COLUMN opening_balance;
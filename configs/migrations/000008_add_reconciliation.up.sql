@@ -0,0 +1,17 @@
+-- Balance of existing account is trusted at this point: opening balance is what isn't explained by its postings
+ALTER TABLE accounts ADD COLUMN opening_balance BIGINT NOT NULL DEFAULT 0;
+UPDATE accounts a
+SET opening_balance = a.balance - COALESCE((SELECT SUM(p.amount) FROM postings p WHERE p.account_uid = a.uid), 0);
+
+CREATE TABLE balance_adjustments(
+                         id BIGSERIAL PRIMARY KEY,
+                         account_uid VARCHAR(256) NOT NULL,
+                         expected_balance BIGINT NOT NULL,
+                         actual_balance BIGINT NOT NULL,
+                         amount BIGINT NOT NULL,
+                         reason VARCHAR(256) NOT NULL,
+                         created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+                         FOREIGN KEY (account_uid) REFERENCES accounts(uid)
+);
+
+CREATE INDEX ON balance_adjustments(account_uid);
@@ -0,0 +1,39 @@
+-- Amount is given in payer's currency, cron expression is set for cron recurrence only
+CREATE TABLE schedules(
+                         id BIGSERIAL PRIMARY KEY,
+                         payer_account_uid VARCHAR(256) NOT NULL,
+                         recipient_account_uid VARCHAR(256) NOT NULL,
+                         amount BIGINT NOT NULL CHECK (amount > 0),
+                         currency VARCHAR(16) NOT NULL,
+                         recurrence VARCHAR(16) NOT NULL CHECK (recurrence IN ('daily', 'weekly', 'monthly', 'cron')),
+                         cron VARCHAR(128) NOT NULL DEFAULT '',
+                         on_insufficient_funds VARCHAR(16) NOT NULL CHECK (on_insufficient_funds IN ('skip', 'retry')),
+                         status VARCHAR(16) NOT NULL CHECK (status IN ('active', 'cancelled', 'completed')),
+                         start_at TIMESTAMP WITH TIME ZONE NOT NULL,
+                         next_run_at TIMESTAMP WITH TIME ZONE NOT NULL,
+                         created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+                         updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+                         FOREIGN KEY (payer_account_uid) REFERENCES accounts(uid),
+                         FOREIGN KEY (recipient_account_uid) REFERENCES accounts(uid)
+);
+
+CREATE INDEX ON schedules(payer_account_uid);
+-- Scheduler reads active schedules only, so other ones aren't indexed
+CREATE INDEX ON schedules(next_run_at) WHERE status = 'active';
+
+-- Occurrence of the schedule is run once
+CREATE TABLE schedule_runs(
+                         id BIGSERIAL PRIMARY KEY,
+                         schedule_id BIGINT NOT NULL,
+                         scheduled_at TIMESTAMP WITH TIME ZONE NOT NULL,
+                         status VARCHAR(16) NOT NULL CHECK (status IN ('pending', 'succeeded', 'skipped', 'failed')),
+                         attempts INT NOT NULL DEFAULT 0,
+                         next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL,
+                         error TEXT NOT NULL DEFAULT '',
+                         created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+                         updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+                         UNIQUE (schedule_id, scheduled_at),
+                         FOREIGN KEY (schedule_id) REFERENCES schedules(id)
+);
+
+CREATE INDEX ON schedule_runs(next_attempt_at) WHERE status = 'pending';
@@ -0,0 +1,10 @@
+-- Refund is a transfer back from recipient to payer of the refunded transfer.
+-- Transfers are never changed, so refunds are linked to refunded transfers by separate table
+CREATE TABLE refunds(
+                         transfer_id BIGINT PRIMARY KEY,
+                         refunded_transfer_id BIGINT NOT NULL,
+                         FOREIGN KEY (transfer_id) REFERENCES transfers(id),
+                         FOREIGN KEY (refunded_transfer_id) REFERENCES transfers(id)
+);
+
+CREATE INDEX ON refunds(refunded_transfer_id);
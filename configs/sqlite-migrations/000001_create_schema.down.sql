@@ -0,0 +1,6 @@
+DROP TABLE idempotency_keys;
+DROP TABLE balance_adjustments;
+DROP TABLE postings;
+DROP TABLE transfers;
+DROP TABLE account_transitions;
+DROP TABLE accounts;
@@ -0,0 +1,95 @@
+-- SQLite schema matches PostgreSQL one after all its migrations.
+-- Timestamps are stored as text in UTC, so they are compared as strings. Rates are stored as text to keep precision
+CREATE TABLE accounts(
+                         id INTEGER PRIMARY KEY AUTOINCREMENT,
+                         uid VARCHAR(256) NOT NULL UNIQUE,
+                         currency VARCHAR(16) NOT NULL,
+                         balance BIGINT NOT NULL CHECK (balance >= 0),
+                         opening_balance BIGINT NOT NULL DEFAULT 0,
+                         status VARCHAR(16) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'frozen', 'closed')),
+                         status_changed_at TIMESTAMP NOT NULL,
+                         created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE account_transitions(
+                         id INTEGER PRIMARY KEY AUTOINCREMENT,
+                         account_uid VARCHAR(256) NOT NULL,
+                         from_status VARCHAR(16) NOT NULL,
+                         to_status VARCHAR(16) NOT NULL,
+                         created_at TIMESTAMP NOT NULL,
+                         FOREIGN KEY (account_uid) REFERENCES accounts(uid)
+);
+
+CREATE INDEX account_transitions_account_uid_idx ON account_transitions(account_uid);
+
+-- Status change is recorded by trigger, so it's atomic without transaction
+CREATE TRIGGER accounts_status_transition
+    AFTER UPDATE OF status ON accounts
+    WHEN OLD.status <> NEW.status
+BEGIN
+    INSERT INTO account_transitions
+        (account_uid, from_status, to_status, created_at)
+    VALUES
+        (NEW.uid, OLD.status, NEW.status, NEW.status_changed_at);
+END;
+
+CREATE TABLE transfers(
+                         id INTEGER PRIMARY KEY AUTOINCREMENT,
+                         payer_account_uid VARCHAR(256) NOT NULL,
+                         recipient_account_uid VARCHAR(256) NOT NULL,
+                         source_amount BIGINT NOT NULL,
+                         source_currency VARCHAR(16) NOT NULL,
+                         target_amount BIGINT NOT NULL,
+                         target_currency VARCHAR(16) NOT NULL,
+                         rate TEXT NOT NULL,
+                         created_at TIMESTAMP NOT NULL,
+                         FOREIGN KEY (payer_account_uid) REFERENCES accounts(uid),
+                         FOREIGN KEY (recipient_account_uid) REFERENCES accounts(uid)
+);
+
+-- Postings don't reference accounts, because internal accounts (e.g. @fx) are not stored there.
+-- SQLite has no deferred triggers, so zero-sum invariant of transfer is checked by the code
+CREATE TABLE postings(
+                         id INTEGER PRIMARY KEY AUTOINCREMENT,
+                         transfer_id BIGINT NOT NULL,
+                         account_uid VARCHAR(256) NOT NULL,
+                         amount BIGINT NOT NULL,
+                         currency VARCHAR(16) NOT NULL,
+                         created_at TIMESTAMP NOT NULL,
+                         FOREIGN KEY (transfer_id) REFERENCES transfers(id)
+);
+
+CREATE INDEX postings_transfer_id_idx ON postings(transfer_id);
+CREATE INDEX postings_account_uid_idx ON postings(account_uid);
+
+CREATE TABLE balance_adjustments(
+                         id INTEGER PRIMARY KEY AUTOINCREMENT,
+                         account_uid VARCHAR(256) NOT NULL,
+                         expected_balance BIGINT NOT NULL,
+                         actual_balance BIGINT NOT NULL,
+                         amount BIGINT NOT NULL,
+                         reason VARCHAR(256) NOT NULL,
+                         created_at TIMESTAMP NOT NULL,
+                         FOREIGN KEY (account_uid) REFERENCES accounts(uid)
+);
+
+CREATE INDEX balance_adjustments_account_uid_idx ON balance_adjustments(account_uid);
+
+-- Balance is adjusted by trigger, so adjustment is atomic without transaction
+CREATE TRIGGER balance_adjustments_apply
+    AFTER INSERT ON balance_adjustments
+BEGIN
+    UPDATE accounts
+    SET balance = balance + NEW.amount
+    WHERE uid = NEW.account_uid;
+END;
+
+CREATE TABLE idempotency_keys(
+                         operation VARCHAR(32) NOT NULL,
+                         key VARCHAR(256) NOT NULL,
+                         fingerprint VARCHAR(64) NOT NULL,
+                         response TEXT NOT NULL,
+                         created_at TIMESTAMP NOT NULL,
+                         expires_at TIMESTAMP NOT NULL,
+                         PRIMARY KEY (operation, key)
+);
@@ -0,0 +1,13 @@
+CREATE TABLE outbox_events(
+                         id INTEGER PRIMARY KEY AUTOINCREMENT,
+                         type VARCHAR(64) NOT NULL,
+                         payload TEXT NOT NULL,
+                         created_at TIMESTAMP NOT NULL,
+                         attempts INT NOT NULL DEFAULT 0,
+                         next_attempt_at TIMESTAMP NOT NULL,
+                         last_error TEXT NOT NULL DEFAULT '',
+                         dispatched_at TIMESTAMP
+);
+
+-- Dispatcher reads pending events only, so dispatched ones aren't indexed
+CREATE INDEX outbox_events_next_attempt_at_idx ON outbox_events(next_attempt_at) WHERE dispatched_at IS NULL;
@@ -0,0 +1,41 @@
+-- Empty event type or account means webhook is subscribed to any of them
+CREATE TABLE webhooks(
+                         id INTEGER PRIMARY KEY AUTOINCREMENT,
+                         url TEXT NOT NULL,
+                         secret VARCHAR(128) NOT NULL,
+                         event_type VARCHAR(64) NOT NULL DEFAULT '',
+                         account_uid VARCHAR(256) NOT NULL DEFAULT '',
+                         created_at TIMESTAMP NOT NULL
+);
+
+-- Event is copied into delivery, because outbox doesn't keep events forever
+CREATE TABLE webhook_deliveries(
+                         id INTEGER PRIMARY KEY AUTOINCREMENT,
+                         webhook_id BIGINT NOT NULL,
+                         event_id BIGINT NOT NULL,
+                         event_type VARCHAR(64) NOT NULL,
+                         payload TEXT NOT NULL,
+                         event_time TIMESTAMP NOT NULL,
+                         status VARCHAR(16) NOT NULL CHECK (status IN ('pending', 'succeeded', 'failed')),
+                         attempts INT NOT NULL DEFAULT 0,
+                         next_attempt_at TIMESTAMP NOT NULL,
+                         created_at TIMESTAMP NOT NULL,
+                         updated_at TIMESTAMP NOT NULL,
+                         UNIQUE (webhook_id, event_id),
+                         FOREIGN KEY (webhook_id) REFERENCES webhooks(id) ON DELETE CASCADE
+);
+
+-- Dispatcher reads pending deliveries only, so other ones aren't indexed
+CREATE INDEX webhook_deliveries_next_attempt_at_idx ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+
+CREATE TABLE webhook_delivery_attempts(
+                         id INTEGER PRIMARY KEY AUTOINCREMENT,
+                         delivery_id BIGINT NOT NULL,
+                         status_code INT NOT NULL DEFAULT 0,
+                         error TEXT NOT NULL DEFAULT '',
+                         duration_ms BIGINT NOT NULL,
+                         created_at TIMESTAMP NOT NULL,
+                         FOREIGN KEY (delivery_id) REFERENCES webhook_deliveries(id) ON DELETE CASCADE
+);
+
+CREATE INDEX webhook_delivery_attempts_delivery_id_idx ON webhook_delivery_attempts(delivery_id);
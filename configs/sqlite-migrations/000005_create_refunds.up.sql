@@ -0,0 +1,10 @@
+-- Refund is a transfer back from recipient to payer of the refunded transfer.
+-- Transfers are never changed, so refunds are linked to refunded transfers by separate table
+CREATE TABLE refunds(
+                         transfer_id INTEGER PRIMARY KEY,
+                         refunded_transfer_id INTEGER NOT NULL,
+                         FOREIGN KEY (transfer_id) REFERENCES transfers(id),
+                         FOREIGN KEY (refunded_transfer_id) REFERENCES transfers(id)
+);
+
+CREATE INDEX refunds_refunded_transfer_id_idx ON refunds(refunded_transfer_id);
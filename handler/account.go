@@ -1,34 +1,141 @@
 package handler
 
-import "time"
+import (
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/service/money"
+)
 
 // AccountRequest define request to create new account
 type AccountRequest struct {
-	UID      string  `json:"uid"`
-	Currency string  `json:"currency"`
-	Balance  float64 `json:"balance"`
+	UID      string       `json:"uid"`
+	Currency string       `json:"currency"`
+	Balance  money.Amount `json:"balance"`
+}
+
+// AccountUpdateRequest define request to change account's status
+type AccountUpdateRequest struct {
+	Status string `json:"status"`
 }
 
 // Account define account description
 type Account struct {
-	UID       string    `json:"uid"`
-	Currency  string    `json:"currency"`
-	Balance   float64   `json:"balance"`
-	CreatedAt time.Time `json:"created_at"`
+	UID             string       `json:"uid"`
+	Currency        string       `json:"currency"`
+	Balance         money.Amount `json:"balance"`
+	Status          string       `json:"status"`
+	StatusChangedAt time.Time    `json:"status_changed_at"`
+	CreatedAt       time.Time    `json:"created_at"`
 }
 
 // PaymentRequest define request to create new payment
 type PaymentRequest struct {
-	RecipientUID string  `json:"recipient"`
-	Amount       float64 `json:"amount"`
+	RecipientUID string       `json:"recipient"`
+	Amount       money.Amount `json:"amount"`
 }
 
-// Payment define payment description
+// RefundRequest define request to refund payment. Amount is given in payer's currency of the refunded payment,
+// empty amount means the whole amount, which isn't refunded yet
+type RefundRequest struct {
+	Amount *money.Amount `json:"amount"`
+}
+
+// Payment define payment description. ID identifies payment, it's the same for payer's and recipient's payments.
+// RefundOf is ID of the payment, refunded by this one. RefundStatus and RefundedAmount are given for refunded
+// payment only, refunded amount is given in account's currency
 type Payment struct {
-	UID       string    `json:"account"`
-	SourceUID *string   `json:"from_account,omitempty"`
-	TargetUID *string   `json:"to_account,omitempty"`
-	Direction string    `json:"direction"`
-	Amount    float64   `json:"amount"`
-	CreatedAt time.Time `json:"created_at"`
+	ID             int64         `json:"id"`
+	UID            string        `json:"account"`
+	SourceUID      *string       `json:"from_account,omitempty"`
+	TargetUID      *string       `json:"to_account,omitempty"`
+	Direction      string        `json:"direction"`
+	Amount         money.Amount  `json:"amount"`
+	Currency       string        `json:"currency"`
+	Exchange       *Exchange     `json:"exchange,omitempty"`
+	RefundOf       *int64        `json:"refund_of,omitempty"`
+	RefundStatus   string        `json:"refund_status,omitempty"`
+	RefundedAmount *money.Amount `json:"refunded_amount,omitempty"`
+	CreatedAt      time.Time     `json:"created_at"`
+}
+
+// Exchange define currency exchange details for cross-currency payment
+type Exchange struct {
+	Rate           string       `json:"rate"`
+	SourceAmount   money.Amount `json:"source_amount"`
+	SourceCurrency string       `json:"source_currency"`
+	TargetAmount   money.Amount `json:"target_amount"`
+	TargetCurrency string       `json:"target_currency"`
+}
+
+// PaymentEvent define data of payment.completed and payment.refunded events. Amount is given in payer's currency,
+// exchange details are given for cross-currency payment, RefundOf is given for refund
+type PaymentEvent struct {
+	ID           int64        `json:"id"`
+	PayerUID     string       `json:"payer"`
+	RecipientUID string       `json:"recipient"`
+	Amount       money.Amount `json:"amount"`
+	Currency     string       `json:"currency"`
+	Exchange     *Exchange    `json:"exchange,omitempty"`
+	RefundOf     *int64       `json:"refund_of,omitempty"`
+	CreatedAt    time.Time    `json:"created_at"`
+}
+
+// AccountEvent define event of the account's event stream: payment or balance change. Data is event's payload,
+// ID is empty for events, the stream can't be resumed from
+type AccountEvent struct {
+	ID   string
+	Type string
+	Data interface{}
+}
+
+// ReconciliationRequest define request to reconcile accounts' balances with the ledger.
+// Adjust corrects balances of drifted accounts, Reason is required for adjustment and recorded for audit
+type ReconciliationRequest struct {
+	Adjust bool   `json:"adjust"`
+	Reason string `json:"reason"`
+}
+
+// Reconciliation define report of reconciliation: accounts, which balance doesn't match the ledger
+type Reconciliation struct {
+	Drifts    []Drift   `json:"drifts"`
+	Adjusted  bool      `json:"adjusted"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Drift define drifted account. Expected balance is opening balance plus net of account's payments
+type Drift struct {
+	UID             string       `json:"account"`
+	Currency        string       `json:"currency"`
+	ExpectedBalance money.Amount `json:"expected_balance"`
+	ActualBalance   money.Amount `json:"actual_balance"`
+}
+
+// PageRequest define request of the single page of listing.
+// Zero limit means default page size, empty cursor means the first page
+type PageRequest struct {
+	Limit  int
+	Cursor string
+}
+
+// PaymentFilter define filter of the account's payments. Empty fields mean no filtering by the field.
+// Direction is "incoming" or "outgoing", time range is half-open [From, To), amount range is inclusive
+type PaymentFilter struct {
+	Counterparty string
+	Direction    string
+	From         *time.Time
+	To           *time.Time
+	MinAmount    *money.Amount
+	MaxAmount    *money.Amount
+}
+
+// AccountList define single page of accounts
+type AccountList struct {
+	Accounts   []Account `json:"accounts"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
+
+// PaymentList define single page of payments
+type PaymentList struct {
+	Payments   []Payment `json:"payments"`
+	NextCursor string    `json:"next_cursor,omitempty"`
 }
@@ -2,11 +2,16 @@ package account
 
 import (
 	"context"
+	"errors"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/Toshik1978/go-rest-api/handler"
 	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service"
+	"github.com/Toshik1978/go-rest-api/service/errutil"
+	"github.com/Toshik1978/go-rest-api/service/money"
 	"github.com/Toshik1978/go-rest-api/service/server"
 	"github.com/Toshik1978/go-rest-api/service/validator"
 	"go.uber.org/zap"
@@ -16,18 +21,29 @@ import (
 type accountBuilder struct {
 	logger            *zap.Logger
 	repositoryFactory repository.Factory
+	currencyRegistry  service.CurrencyRegistry
+	idempotencyTTL    time.Duration
 
-	account repository.Account
-	v       *validator.Validator
+	account        repository.Account
+	balance        money.Amount
+	idempotencyKey string
+	v              *validator.Validator
 }
 
 // newAccountBuilder creates new AccountBuilder implementation
 func newAccountBuilder(globals server.Globals) handler.AccountBuilder {
+	createdAt := time.Now()
 	return &accountBuilder{
 		logger:            globals.Logger,
 		repositoryFactory: globals.RepositoryFactory,
-		account:           repository.Account{CreatedAt: time.Now()},
-		v:                 validator.NewValidator(),
+		currencyRegistry:  globals.CurrencyRegistry,
+		idempotencyTTL:    globals.IdempotencyTTL,
+		account: repository.Account{
+			Status:          repository.ActiveAccount,
+			StatusChangedAt: createdAt,
+			CreatedAt:       createdAt,
+		},
+		v: validator.NewValidator(),
 	}
 }
 
@@ -36,8 +52,8 @@ func (b *accountBuilder) SetUID(uid string) handler.AccountBuilder {
 	return b
 }
 
-func (b *accountBuilder) SetBalance(balance float64) handler.AccountBuilder {
-	b.account.Balance = int64(balance * 100)
+func (b *accountBuilder) SetBalance(balance money.Amount) handler.AccountBuilder {
+	b.balance = balance
 	return b
 }
 
@@ -46,17 +62,69 @@ func (b *accountBuilder) SetCurrency(currency string) handler.AccountBuilder {
 	return b
 }
 
+func (b *accountBuilder) SetIdempotencyKey(key string) handler.AccountBuilder {
+	b.idempotencyKey = key
+	return b
+}
+
 func (b *accountBuilder) Build(ctx context.Context) (*handler.Account, error) {
+	exponent := currencyExponent(b.currencyRegistry, b.account.Currency)
 	b.v.
 		ValidateUID("uid", b.account.UID).
-		ValidateBalance(float64(b.account.Balance) / 100).
-		ValidateCurrency(b.account.Currency)
+		ValidateBalance(b.balance).
+		ValidatePrecision("balance", b.balance, exponent).
+		ValidateCurrency(b.account.Currency, b.currencyRegistry).
+		ValidateIdempotencyKey(b.idempotencyKey)
 	if err := b.v.Error(); err != nil {
 		return nil, handler.WrapError(err, "failed to validate account", handler.ClientError)
 	}
+	balance, err := b.balance.MinorUnits(exponent)
+	if err != nil {
+		return nil, handler.WrapError(err, "failed to validate account", handler.ClientError)
+	}
+	b.account.Balance = balance
+	b.account.OpeningBalance = balance
+
+	scope := b.repositoryFactory.Scope()
+	ctx, err = scope.WithContext(ctx)
+	if err != nil {
+		return nil, errutil.Wrap(err, "failed to start repository scope")
+	}
+	// Here we can defer Cancel operation, because it's safe
+	defer func() { _ = scope.Cancel(ctx) }()
+
+	// Repeated request with the same idempotency key gets the original response
+	idempotency := newIdempotency(b.repositoryFactory, b.idempotencyTTL, accountOperation, b.idempotencyKey,
+		b.account.UID, b.account.Currency, strconv.FormatInt(b.account.Balance, 10))
+	var replayed handler.Account
+	ok, err := idempotency.replay(ctx, &replayed)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return &replayed, nil
+	}
 
-	if err := b.repositoryFactory.AccountRepository().Store(ctx, &b.account); err != nil {
+	err = b.repositoryFactory.AccountRepository().Store(ctx, &b.account)
+	if errors.Is(err, repository.ErrAccountExists) {
+		return nil, handler.WrapError(err, "account "+b.account.UID+" already exists", handler.ConflictError)
+	}
+	if err != nil {
 		return nil, handler.WrapError(err, "failed to create account", handler.ServerError)
 	}
-	return mapRepositoryAccount(b.account), nil
+	account := mapRepositoryAccount(b.account, b.currencyRegistry)
+	if err := storeEvent(ctx, b.repositoryFactory,
+		repository.AccountCreatedEvent, account, b.account.CreatedAt); err != nil {
+
+		return nil, err
+	}
+	if err := idempotency.store(ctx, account); err != nil {
+		return nil, err
+	}
+
+	// Complete scope
+	if err := scope.Complete(ctx); err != nil {
+		return nil, errutil.Wrap(err, "failed to complete repository scope")
+	}
+	return account, nil
 }
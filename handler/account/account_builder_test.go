@@ -3,9 +3,12 @@ package account
 import (
 	"context"
 	"errors"
+	"time"
 
+	"github.com/Toshik1978/go-rest-api/handler"
 	"github.com/Toshik1978/go-rest-api/mock"
 	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service/money"
 	"github.com/Toshik1978/go-rest-api/service/server"
 	"github.com/Toshik1978/go-rest-api/service/testutil"
 	"github.com/golang/mock/gomock"
@@ -31,12 +34,55 @@ func (s *accountBuilderTestSuite) TestAccountBuilderValidationFailed() {
 
 	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
 	builder := newAccountBuilder(server.Globals{
-		Logger: zap.New(zapCore),
+		Logger:           zap.New(zapCore),
+		CurrencyRegistry: testutil.CurrencyRegistry(),
 	})
 
 	account, err := builder.
 		SetCurrency(s.account.Currency).
-		SetBalance(float64(s.account.Balance) / 100).
+		SetBalance(money.FromMinorUnits(s.account.Balance, 2)).
+		Build(context.Background())
+
+	s.Error(err)
+	s.Nil(account)
+	s.Equal(0, zapRecorded.Len())
+}
+
+func (s *accountBuilderTestSuite) TestAccountBuilderDisabledCurrencyFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	builder := newAccountBuilder(server.Globals{
+		Logger:           zap.New(zapCore),
+		CurrencyRegistry: testutil.CurrencyRegistry(),
+	})
+
+	account, err := builder.
+		SetUID(s.account.UID).
+		SetCurrency("GBP").
+		SetBalance(money.FromMinorUnits(s.account.Balance, 2)).
+		Build(context.Background())
+
+	s.Error(err)
+	s.Nil(account)
+	s.Equal(0, zapRecorded.Len())
+}
+
+func (s *accountBuilderTestSuite) TestAccountBuilderPrecisionFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	builder := newAccountBuilder(server.Globals{
+		Logger:           zap.New(zapCore),
+		CurrencyRegistry: testutil.CurrencyRegistry(),
+	})
+
+	account, err := builder.
+		SetUID(s.account.UID).
+		SetCurrency("JPY").
+		SetBalance(money.MustParse("0.5")).
 		Build(context.Background())
 
 	s.Error(err)
@@ -48,12 +94,26 @@ func (s *accountBuilderTestSuite) TestAccountBuilderStoreFailed() {
 	ctrl := gomock.NewController(s.T())
 	defer ctrl.Finish()
 
+	scope := mock.NewMockScope(ctrl)
+	scope.
+		EXPECT().
+		WithContext(gomock.Any()).
+		Return(context.Background(), nil)
+	scope.
+		EXPECT().
+		Cancel(gomock.Any()).
+		Return(nil)
+
 	repository := mock.NewMockAccountRepository(ctrl)
 	repository.
 		EXPECT().
 		Store(gomock.Any(), testutil.EqualRepositoryAccount(s.account)).
 		Return(errors.New("fail"))
 	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		Scope().
+		Return(scope)
 	factory.
 		EXPECT().
 		AccountRepository().
@@ -62,16 +122,126 @@ func (s *accountBuilderTestSuite) TestAccountBuilderStoreFailed() {
 	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
 	builder := newAccountBuilder(server.Globals{
 		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		RepositoryFactory: factory,
+	})
+
+	account, err := builder.
+		SetUID(s.account.UID).
+		SetCurrency(s.account.Currency).
+		SetBalance(money.FromMinorUnits(s.account.Balance, 2)).
+		Build(context.Background())
+
+	s.Error(err)
+	s.Nil(account)
+	s.Equal(0, zapRecorded.Len())
+}
+
+func (s *accountBuilderTestSuite) TestAccountBuilderStoreEventFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	scope := mock.NewMockScope(ctrl)
+	scope.
+		EXPECT().
+		WithContext(gomock.Any()).
+		Return(context.Background(), nil)
+	scope.
+		EXPECT().
+		Cancel(gomock.Any()).
+		Return(nil)
+
+	outboxRepository := mock.NewMockOutboxRepository(ctrl)
+	outboxRepository.
+		EXPECT().
+		Store(gomock.Any(), gomock.Any()).
+		Return(errors.New("fail"))
+	repository := mock.NewMockAccountRepository(ctrl)
+	repository.
+		EXPECT().
+		Store(gomock.Any(), testutil.EqualRepositoryAccount(s.account)).
+		Return(nil)
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		Scope().
+		Return(scope)
+	factory.
+		EXPECT().
+		AccountRepository().
+		Return(repository)
+	factory.
+		EXPECT().
+		OutboxRepository().
+		Return(outboxRepository)
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	builder := newAccountBuilder(server.Globals{
+		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		RepositoryFactory: factory,
+	})
+
+	account, err := builder.
+		SetUID(s.account.UID).
+		SetCurrency(s.account.Currency).
+		SetBalance(money.FromMinorUnits(s.account.Balance, 2)).
+		Build(context.Background())
+
+	var handlerError *handler.Error
+	s.Error(err)
+	s.True(errors.As(err, &handlerError))
+	s.Equal(handler.ServerError, handlerError.Kind)
+	s.Nil(account)
+	s.Equal(0, zapRecorded.Len())
+}
+
+func (s *accountBuilderTestSuite) TestAccountBuilderExistsFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	scope := mock.NewMockScope(ctrl)
+	scope.
+		EXPECT().
+		WithContext(gomock.Any()).
+		Return(context.Background(), nil)
+	scope.
+		EXPECT().
+		Cancel(gomock.Any()).
+		Return(nil)
+
+	accountRepository := mock.NewMockAccountRepository(ctrl)
+	accountRepository.
+		EXPECT().
+		Store(gomock.Any(), testutil.EqualRepositoryAccount(s.account)).
+		Return(repository.ErrAccountExists)
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		Scope().
+		Return(scope)
+	factory.
+		EXPECT().
+		AccountRepository().
+		Return(accountRepository)
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	builder := newAccountBuilder(server.Globals{
+		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
 		RepositoryFactory: factory,
 	})
 
 	account, err := builder.
 		SetUID(s.account.UID).
 		SetCurrency(s.account.Currency).
-		SetBalance(float64(s.account.Balance) / 100).
+		SetBalance(money.FromMinorUnits(s.account.Balance, 2)).
 		Build(context.Background())
 
+	var handlerError *handler.Error
 	s.Error(err)
+	s.True(errors.As(err, &handlerError))
+	s.Equal(handler.ConflictError, handlerError.Kind)
 	s.Nil(account)
 	s.Equal(0, zapRecorded.Len())
 }
@@ -80,12 +250,43 @@ func (s *accountBuilderTestSuite) TestAccountBuilderSucceeded() {
 	ctrl := gomock.NewController(s.T())
 	defer ctrl.Finish()
 
+	scope := mock.NewMockScope(ctrl)
+	scope.
+		EXPECT().
+		WithContext(gomock.Any()).
+		Return(context.Background(), nil)
+	scope.
+		EXPECT().
+		Complete(gomock.Any()).
+		Return(nil)
+	scope.
+		EXPECT().
+		Cancel(gomock.Any()).
+		Return(nil)
+
+	outboxRepository := mock.NewMockOutboxRepository(ctrl)
+	outboxRepository.
+		EXPECT().
+		Store(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, event *repository.Event) error {
+			s.Equal(repository.AccountCreatedEvent, event.Type)
+			s.Equal(event.CreatedAt, event.NextAttemptAt)
+			return nil
+		})
 	repository := mock.NewMockAccountRepository(ctrl)
 	repository.
 		EXPECT().
 		Store(gomock.Any(), testutil.EqualRepositoryAccount(s.account)).
 		Return(nil)
 	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		OutboxRepository().
+		Return(outboxRepository)
+	factory.
+		EXPECT().
+		Scope().
+		Return(scope)
 	factory.
 		EXPECT().
 		AccountRepository().
@@ -94,13 +295,14 @@ func (s *accountBuilderTestSuite) TestAccountBuilderSucceeded() {
 	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
 	builder := newAccountBuilder(server.Globals{
 		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
 		RepositoryFactory: factory,
 	})
 
 	account, err := builder.
 		SetUID(s.account.UID).
 		SetCurrency(s.account.Currency).
-		SetBalance(float64(s.account.Balance) / 100).
+		SetBalance(money.FromMinorUnits(s.account.Balance, 2)).
 		Build(context.Background())
 
 	s.NoError(err)
@@ -109,3 +311,157 @@ func (s *accountBuilderTestSuite) TestAccountBuilderSucceeded() {
 	s.Equal(s.account.Currency, account.Currency)
 	s.Equal(0, zapRecorded.Len())
 }
+
+func (s *accountBuilderTestSuite) TestAccountBuilderMinorUnitsSucceeded() {
+	for _, test := range []struct {
+		currency string
+		balance  string
+		minor    int64
+	}{
+		{currency: "JPY", balance: "1234", minor: 1234},
+		{currency: "BHD", balance: "1.234", minor: 1234},
+		{currency: "USD", balance: "0.29", minor: 29},
+	} {
+		ctrl := gomock.NewController(s.T())
+
+		expected := s.account
+		expected.Currency = test.currency
+		expected.Balance = test.minor
+		expected.OpeningBalance = test.minor
+
+		scope := mock.NewMockScope(ctrl)
+		scope.
+			EXPECT().
+			WithContext(gomock.Any()).
+			Return(context.Background(), nil)
+		scope.
+			EXPECT().
+			Complete(gomock.Any()).
+			Return(nil)
+		scope.
+			EXPECT().
+			Cancel(gomock.Any()).
+			Return(nil)
+
+		repository := mock.NewMockAccountRepository(ctrl)
+		repository.
+			EXPECT().
+			Store(gomock.Any(), testutil.EqualRepositoryAccount(expected)).
+			Return(nil)
+		outboxRepository := mock.NewMockOutboxRepository(ctrl)
+		outboxRepository.
+			EXPECT().
+			Store(gomock.Any(), gomock.Any()).
+			Return(nil)
+		factory := mock.NewMockFactory(ctrl)
+		factory.
+			EXPECT().
+			OutboxRepository().
+			Return(outboxRepository)
+		factory.
+			EXPECT().
+			Scope().
+			Return(scope)
+		factory.
+			EXPECT().
+			AccountRepository().
+			Return(repository)
+
+		builder := newAccountBuilder(server.Globals{
+			Logger:            zap.NewNop(),
+			CurrencyRegistry:  testutil.CurrencyRegistry(),
+			RepositoryFactory: factory,
+		})
+
+		account, err := builder.
+			SetUID(expected.UID).
+			SetCurrency(test.currency).
+			SetBalance(money.MustParse(test.balance)).
+			Build(context.Background())
+
+		s.NoError(err)
+		s.NotNil(account)
+		s.Equal(test.balance, account.Balance.String())
+		s.Equal(test.currency, account.Currency)
+		ctrl.Finish()
+	}
+}
+
+func (s *accountBuilderTestSuite) TestAccountBuilderIdempotencySucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	key := testutil.RepositoryIdempotencyKey()
+
+	scope := mock.NewMockScope(ctrl)
+	scope.
+		EXPECT().
+		WithContext(gomock.Any()).
+		Return(context.Background(), nil)
+	scope.
+		EXPECT().
+		Complete(gomock.Any()).
+		Return(nil)
+	scope.
+		EXPECT().
+		Cancel(gomock.Any()).
+		Return(nil)
+
+	repository := mock.NewMockAccountRepository(ctrl)
+	repository.
+		EXPECT().
+		Store(gomock.Any(), testutil.EqualRepositoryAccount(s.account)).
+		Return(nil)
+	idempotencyRepository := mock.NewMockIdempotencyRepository(ctrl)
+	idempotencyRepository.
+		EXPECT().
+		Get(gomock.Any(), gomock.Eq(accountOperation), gomock.Eq(key.Key), gomock.Any()).
+		Return(nil, nil)
+	idempotencyRepository.
+		EXPECT().
+		Store(gomock.Any(), gomock.Any()).
+		Return(nil)
+	outboxRepository := mock.NewMockOutboxRepository(ctrl)
+	outboxRepository.
+		EXPECT().
+		Store(gomock.Any(), gomock.Any()).
+		Return(nil)
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		OutboxRepository().
+		Return(outboxRepository)
+	factory.
+		EXPECT().
+		Scope().
+		Return(scope)
+	factory.
+		EXPECT().
+		AccountRepository().
+		Return(repository)
+	factory.
+		EXPECT().
+		IdempotencyRepository().
+		Return(idempotencyRepository).
+		Times(2)
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	builder := newAccountBuilder(server.Globals{
+		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		RepositoryFactory: factory,
+		IdempotencyTTL:    time.Hour,
+	})
+
+	account, err := builder.
+		SetUID(s.account.UID).
+		SetCurrency(s.account.Currency).
+		SetBalance(money.FromMinorUnits(s.account.Balance, 2)).
+		SetIdempotencyKey(key.Key).
+		Build(context.Background())
+
+	s.NoError(err)
+	s.NotNil(account)
+	s.Equal(s.account.UID, account.UID)
+	s.Equal(0, zapRecorded.Len())
+}
@@ -2,12 +2,16 @@ package account
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/Toshik1978/go-rest-api/service/errutil"
 
 	"github.com/Toshik1978/go-rest-api/handler"
 	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service"
 	"github.com/Toshik1978/go-rest-api/service/server"
+	"github.com/Toshik1978/go-rest-api/service/validator"
 	"go.uber.org/zap"
 )
 
@@ -15,6 +19,10 @@ import (
 type accountManager struct {
 	logger            *zap.Logger
 	repositoryFactory repository.Factory
+	currencyRegistry  service.CurrencyRegistry
+	fxRateProvider    service.FXRateProvider
+	eventBroker       service.EventBroker
+	idempotencyTTL    time.Duration
 }
 
 // NewAccountManager creates new implementation of AccountManager interface
@@ -22,29 +30,235 @@ func NewAccountManager(globals server.Globals) handler.AccountManager {
 	return &accountManager{
 		logger:            globals.Logger,
 		repositoryFactory: globals.RepositoryFactory,
+		currencyRegistry:  globals.CurrencyRegistry,
+		fxRateProvider:    globals.FXRateProvider,
+		eventBroker:       globals.EventBroker,
+		idempotencyTTL:    globals.IdempotencyTTL,
 	}
 }
 
-func (m *accountManager) AllAccounts(ctx context.Context) ([]handler.Account, error) {
-	accounts, err := m.repositoryFactory.AccountRepository().GetAll(ctx)
+func (m *accountManager) Account(ctx context.Context, uid string) (*handler.Account, error) {
+	account, err := m.getAccount(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	return mapRepositoryAccount(*account, m.currencyRegistry), nil
+}
+
+func (m *accountManager) UpdateAccountStatus(ctx context.Context, uid string, status string) (*handler.Account, error) {
+	account, err := m.getAccount(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	transition, err := accountTransition(*account, status)
+	if err != nil {
+		return nil, err
+	}
+	// Account already has the status, nothing to change
+	if transition == nil {
+		return mapRepositoryAccount(*account, m.currencyRegistry), nil
+	}
+
+	// Status is changed only if account is not changed since it was read
+	err = m.repositoryFactory.AccountRepository().UpdateStatus(ctx, transition)
+	if errors.Is(err, repository.ErrAccountChanged) {
+		return nil, handler.WrapError(err, "account "+uid+" was changed concurrently", handler.ConflictError)
+	}
+	if err != nil {
+		return nil, handler.WrapError(err, "failed to update account status", handler.ServerError)
+	}
+	account.Status = transition.ToStatus
+	account.StatusChangedAt = transition.CreatedAt
+	return mapRepositoryAccount(*account, m.currencyRegistry), nil
+}
+
+func (m *accountManager) AllAccounts(ctx context.Context, page handler.PageRequest) (*handler.AccountList, error) {
+	repositoryPage, err := repositoryPage(page)
+	if err != nil {
+		return nil, err
+	}
+	accounts, err := m.repositoryFactory.AccountRepository().GetAll(ctx, repositoryPage)
 	if err != nil {
 		return nil, errutil.Wrap(err, "failed to get accounts")
 	}
-	return mapRepositoryAccounts(accounts), nil
+
+	count, more := pageSize(repositoryPage, len(accounts))
+	list := &handler.AccountList{
+		Accounts: mapRepositoryAccounts(accounts[:count], m.currencyRegistry),
+	}
+	if more {
+		list.NextCursor = encodeCursor(accounts[count-1].ID)
+	}
+	return list, nil
 }
 
-func (m *accountManager) AllPayments(ctx context.Context) ([]handler.Payment, error) {
-	payments, err := m.repositoryFactory.PaymentRepository().GetAll(ctx)
+func (m *accountManager) AllPayments(ctx context.Context, page handler.PageRequest) (*handler.PaymentList, error) {
+	repositoryPage, err := repositoryPage(page)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := m.repositoryFactory.LedgerRepository().GetAll(ctx, repositoryPage)
+	if err != nil {
+		return nil, errutil.Wrap(err, "failed to get payments")
+	}
+
+	count, more := pageSize(repositoryPage, len(entries))
+	list := &handler.PaymentList{
+		Payments: mapRepositoryEntries(entries[:count], m.currencyRegistry),
+	}
+	if more {
+		list.NextCursor = encodeCursor(entries[count-1].Posting.ID)
+	}
+	return list, nil
+}
+
+func (m *accountManager) AccountPayments(ctx context.Context,
+	uid string, filter handler.PaymentFilter, page handler.PageRequest) (*handler.PaymentList, error) {
+
+	repositoryPage, err := repositoryPage(page)
+	if err != nil {
+		return nil, err
+	}
+	// Amounts in filter are given in account's currency
+	account, err := m.getAccount(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	exponent := currencyExponent(m.currencyRegistry, account.Currency)
+	repositoryFilter, err := repositoryPaymentFilter(uid, filter, exponent)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := m.repositoryFactory.LedgerRepository().GetByAccount(ctx, repositoryFilter, repositoryPage)
 	if err != nil {
 		return nil, errutil.Wrap(err, "failed to get payments")
 	}
-	return mapRepositoryPayments(payments), nil
+
+	count, more := pageSize(repositoryPage, len(entries))
+	list := &handler.PaymentList{
+		Payments: mapRepositoryEntries(entries[:count], m.currencyRegistry),
+	}
+	if more {
+		list.NextCursor = encodeCursor(entries[count-1].Posting.ID)
+	}
+	return list, nil
+}
+
+func (m *accountManager) AccountEvents(
+	ctx context.Context, uid string, lastEventID string) (<-chan handler.AccountEvent, error) {
+
+	afterID, ok := decodeCursor(lastEventID)
+	if !ok {
+		v := validator.NewValidator()
+		v.AddField("last_event_id", lastEventID, "ID of the received event")
+		return nil, handler.WrapError(v.Error(), "failed to validate event stream", handler.ClientError)
+	}
+
+	// Subscription is started before the account is read, so no balance change can be missed
+	subscription := m.eventBroker.Subscribe(uid)
+	account, err := m.getAccount(ctx, uid)
+	if err != nil {
+		subscription.Cancel()
+		return nil, err
+	}
+
+	stream := newEventStream(m.logger, m.repositoryFactory, m.currencyRegistry, subscription)
+	events := make(chan handler.AccountEvent)
+	go stream.run(ctx, *account, afterID, events)
+	return events, nil
+}
+
+func (m *accountManager) Reconcile(
+	ctx context.Context, request handler.ReconciliationRequest) (*handler.Reconciliation, error) {
+
+	if request.Adjust {
+		if err := validator.NewValidator().ValidateReason(request.Reason).Error(); err != nil {
+			return nil, handler.WrapError(err, "failed to validate reconciliation", handler.ClientError)
+		}
+	}
+
+	// Drifts and adjustments should be based on the same snapshot of balances and the ledger,
+	// so reconciliation is re-run from scratch, if concurrent payment changed any of them
+	var reconciliation *handler.Reconciliation
+	err := m.repositoryFactory.Retry(ctx, func(ctx context.Context) error {
+		var err error
+		reconciliation, err = m.reconcile(ctx, request)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reconciliation, nil
+}
+
+// reconcile checks balances and adjusts drifted ones in the new scope
+func (m *accountManager) reconcile(
+	ctx context.Context, request handler.ReconciliationRequest) (*handler.Reconciliation, error) {
+
+	scope := m.repositoryFactory.Scope(repository.WithIsolation(repository.RepeatableRead))
+	ctx, err := scope.WithContext(ctx)
+	if err != nil {
+		return nil, errutil.Wrap(err, "failed to start repository scope")
+	}
+	// Here we can defer Cancel operation, because it's safe
+	defer func() { _ = scope.Cancel(ctx) }()
+
+	checkedAt := time.Now()
+	drifts, err := m.repositoryFactory.ReconciliationRepository().GetDrifts(ctx)
+	if err != nil {
+		return nil, errutil.Wrap(err, "failed to get drifted accounts")
+	}
+	for _, drift := range drifts {
+		m.logger.Warn("Balance drift detected",
+			zap.String("account_uid", drift.AccountUID),
+			zap.Int64("expected_balance", drift.ExpectedBalance),
+			zap.Int64("actual_balance", drift.ActualBalance))
+	}
+
+	// Ledger is the source of truth, so drifted balance is corrected to match it.
+	// Balance is incremented by difference, so concurrent payments don't break the correction
+	if request.Adjust {
+		for _, drift := range drifts {
+			adjustment := repository.Adjustment{
+				AccountUID:      drift.AccountUID,
+				ExpectedBalance: drift.ExpectedBalance,
+				ActualBalance:   drift.ActualBalance,
+				Amount:          drift.ExpectedBalance - drift.ActualBalance,
+				Reason:          request.Reason,
+				CreatedAt:       checkedAt,
+			}
+			err := m.repositoryFactory.ReconciliationRepository().StoreAdjustment(ctx, &adjustment)
+			if errors.Is(err, repository.ErrInsufficientFunds) {
+				return nil, handler.WrapError(err,
+					"balance of account "+drift.AccountUID+" can't become negative", handler.UnprocessableError)
+			}
+			if err != nil {
+				return nil, handler.WrapError(err, "failed to adjust balance", handler.ServerError)
+			}
+			m.logger.Info("Balance adjusted",
+				zap.String("account_uid", drift.AccountUID),
+				zap.Int64("amount", adjustment.Amount),
+				zap.String("reason", adjustment.Reason))
+		}
+	}
+
+	// Complete scope
+	if err := scope.Complete(ctx); err != nil {
+		return nil, errutil.Wrap(err, "failed to complete repository scope")
+	}
+	return &handler.Reconciliation{
+		Drifts:    mapRepositoryDrifts(drifts, m.currencyRegistry),
+		Adjusted:  request.Adjust && len(drifts) > 0,
+		CheckedAt: checkedAt,
+	}, nil
 }
 
 func (m *accountManager) AccountBuilder() handler.AccountBuilder {
 	return newAccountBuilder(server.Globals{
 		Logger:            m.logger,
 		RepositoryFactory: m.repositoryFactory,
+		CurrencyRegistry:  m.currencyRegistry,
+		IdempotencyTTL:    m.idempotencyTTL,
 	})
 }
 
@@ -52,5 +266,31 @@ func (m *accountManager) PaymentBuilder() handler.PaymentBuilder {
 	return newPaymentBuilder(server.Globals{
 		Logger:            m.logger,
 		RepositoryFactory: m.repositoryFactory,
+		CurrencyRegistry:  m.currencyRegistry,
+		FXRateProvider:    m.fxRateProvider,
+		EventBroker:       m.eventBroker,
+		IdempotencyTTL:    m.idempotencyTTL,
 	})
 }
+
+func (m *accountManager) RefundBuilder() handler.RefundBuilder {
+	return newRefundBuilder(server.Globals{
+		Logger:            m.logger,
+		RepositoryFactory: m.repositoryFactory,
+		CurrencyRegistry:  m.currencyRegistry,
+		EventBroker:       m.eventBroker,
+		IdempotencyTTL:    m.idempotencyTTL,
+	})
+}
+
+// getAccount return account with the given UID
+func (m *accountManager) getAccount(ctx context.Context, uid string) (*repository.Account, error) {
+	account, err := m.repositoryFactory.AccountRepository().GetByUID(ctx, uid)
+	if errors.Is(err, repository.ErrAccountNotFound) {
+		return nil, handler.WrapError(err, "failed to get account "+uid, handler.NotFoundError)
+	}
+	if err != nil {
+		return nil, handler.WrapError(err, "failed to get account", handler.ServerError)
+	}
+	return account, nil
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 
+	"github.com/AlekSi/pointer"
+	"github.com/Toshik1978/go-rest-api/handler"
 	"github.com/Toshik1978/go-rest-api/mock"
 	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service/money"
 	"github.com/Toshik1978/go-rest-api/service/server"
 	"github.com/Toshik1978/go-rest-api/service/testutil"
 	"github.com/golang/mock/gomock"
@@ -19,7 +22,7 @@ type accountManagerTestSuite struct {
 	suite.Suite
 
 	accounts []repository.Account
-	payments []repository.Payment
+	entries  []repository.Entry
 }
 
 func (s *accountManagerTestSuite) SetupSuite() {
@@ -28,35 +31,329 @@ func (s *accountManagerTestSuite) SetupSuite() {
 	account2.UID = "toshik1979"
 	s.accounts = []repository.Account{account1, account2}
 
-	payment1 := testutil.RepositoryPayment()
-	payment2 := payment1
-	payment2.PayerAccountUID = payment1.RecipientAccountUID
-	payment2.RecipientAccountUID = payment1.PayerAccountUID
-	payment2.Amount = -payment1.Amount
-	s.payments = []repository.Payment{payment1, payment2}
+	transfer := testutil.RepositoryTransfer()
+	entry1 := testutil.RepositoryEntry()
+	entry2 := entry1
+	entry2.Posting = transfer.Postings[1]
+	s.entries = []repository.Entry{entry1, entry2}
+}
+
+func (s *accountManagerTestSuite) TestAccountNotFoundFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	accountRepository := mock.NewMockAccountRepository(ctrl)
+	accountRepository.
+		EXPECT().
+		GetByUID(gomock.Any(), gomock.Eq(s.accounts[0].UID)).
+		Return(nil, repository.ErrAccountNotFound)
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		AccountRepository().
+		Return(accountRepository)
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	accountManager := NewAccountManager(server.Globals{
+		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		RepositoryFactory: factory,
+	})
+	account, err := accountManager.Account(context.Background(), s.accounts[0].UID)
+
+	var handlerError *handler.Error
+	s.Error(err)
+	s.True(errors.As(err, &handlerError))
+	s.Equal(handler.NotFoundError, handlerError.Kind)
+	s.Nil(account)
+	s.Equal(0, zapRecorded.Len())
+}
+
+func (s *accountManagerTestSuite) TestAccountFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	accountRepository := mock.NewMockAccountRepository(ctrl)
+	accountRepository.
+		EXPECT().
+		GetByUID(gomock.Any(), gomock.Eq(s.accounts[0].UID)).
+		Return(nil, errors.New("fail"))
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		AccountRepository().
+		Return(accountRepository)
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	accountManager := NewAccountManager(server.Globals{
+		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		RepositoryFactory: factory,
+	})
+	account, err := accountManager.Account(context.Background(), s.accounts[0].UID)
+
+	var handlerError *handler.Error
+	s.Error(err)
+	s.True(errors.As(err, &handlerError))
+	s.Equal(handler.ServerError, handlerError.Kind)
+	s.Nil(account)
+	s.Equal(0, zapRecorded.Len())
+}
+
+func (s *accountManagerTestSuite) TestAccountSucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	accountRepository := mock.NewMockAccountRepository(ctrl)
+	accountRepository.
+		EXPECT().
+		GetByUID(gomock.Any(), gomock.Eq(s.accounts[0].UID)).
+		Return(&s.accounts[0], nil)
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		AccountRepository().
+		Return(accountRepository)
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	accountManager := NewAccountManager(server.Globals{
+		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		RepositoryFactory: factory,
+	})
+	account, err := accountManager.Account(context.Background(), s.accounts[0].UID)
+
+	s.NoError(err)
+	s.NotNil(account)
+	s.Equal(s.accounts[0].UID, account.UID)
+	s.Equal("100.00", account.Balance.String())
+	s.Equal(0, zapRecorded.Len())
+}
+
+func (s *accountManagerTestSuite) TestUpdateAccountStatusNotFoundFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	accountRepository := mock.NewMockAccountRepository(ctrl)
+	accountRepository.
+		EXPECT().
+		GetByUID(gomock.Any(), gomock.Eq(s.accounts[0].UID)).
+		Return(nil, repository.ErrAccountNotFound)
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		AccountRepository().
+		Return(accountRepository)
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	accountManager := NewAccountManager(server.Globals{
+		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		RepositoryFactory: factory,
+	})
+	account, err := accountManager.UpdateAccountStatus(context.Background(), s.accounts[0].UID, "frozen")
+
+	var handlerError *handler.Error
+	s.Error(err)
+	s.True(errors.As(err, &handlerError))
+	s.Equal(handler.NotFoundError, handlerError.Kind)
+	s.Nil(account)
+	s.Equal(0, zapRecorded.Len())
+}
+
+func (s *accountManagerTestSuite) TestUpdateAccountStatusBadStatusFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	accountRepository := mock.NewMockAccountRepository(ctrl)
+	accountRepository.
+		EXPECT().
+		GetByUID(gomock.Any(), gomock.Eq(s.accounts[0].UID)).
+		Return(&s.accounts[0], nil)
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		AccountRepository().
+		Return(accountRepository)
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	accountManager := NewAccountManager(server.Globals{
+		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		RepositoryFactory: factory,
+	})
+	account, err := accountManager.UpdateAccountStatus(context.Background(), s.accounts[0].UID, "deleted")
+
+	var handlerError *handler.Error
+	s.Error(err)
+	s.True(errors.As(err, &handlerError))
+	s.Equal(handler.ClientError, handlerError.Kind)
+	s.Nil(account)
+	s.Equal(0, zapRecorded.Len())
+}
+
+func (s *accountManagerTestSuite) TestUpdateAccountStatusChangedFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	stored := s.accounts[0]
+	accountRepository := mock.NewMockAccountRepository(ctrl)
+	accountRepository.
+		EXPECT().
+		GetByUID(gomock.Any(), gomock.Eq(s.accounts[0].UID)).
+		Return(&stored, nil)
+	accountRepository.
+		EXPECT().
+		UpdateStatus(gomock.Any(), gomock.Any()).
+		Return(repository.ErrAccountChanged)
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		AccountRepository().
+		Return(accountRepository).
+		Times(2)
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	accountManager := NewAccountManager(server.Globals{
+		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		RepositoryFactory: factory,
+	})
+	account, err := accountManager.UpdateAccountStatus(context.Background(), s.accounts[0].UID, "frozen")
+
+	var handlerError *handler.Error
+	s.Error(err)
+	s.True(errors.As(err, &handlerError))
+	s.Equal(handler.ConflictError, handlerError.Kind)
+	s.Nil(account)
+	s.Equal(0, zapRecorded.Len())
+}
+
+func (s *accountManagerTestSuite) TestUpdateAccountStatusFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	stored := s.accounts[0]
+	accountRepository := mock.NewMockAccountRepository(ctrl)
+	accountRepository.
+		EXPECT().
+		GetByUID(gomock.Any(), gomock.Eq(s.accounts[0].UID)).
+		Return(&stored, nil)
+	accountRepository.
+		EXPECT().
+		UpdateStatus(gomock.Any(), gomock.Any()).
+		Return(errors.New("fail"))
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		AccountRepository().
+		Return(accountRepository).
+		Times(2)
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	accountManager := NewAccountManager(server.Globals{
+		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		RepositoryFactory: factory,
+	})
+	account, err := accountManager.UpdateAccountStatus(context.Background(), s.accounts[0].UID, "frozen")
+
+	var handlerError *handler.Error
+	s.Error(err)
+	s.True(errors.As(err, &handlerError))
+	s.Equal(handler.ServerError, handlerError.Kind)
+	s.Nil(account)
+	s.Equal(0, zapRecorded.Len())
+}
+
+func (s *accountManagerTestSuite) TestUpdateAccountStatusSameSucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	accountRepository := mock.NewMockAccountRepository(ctrl)
+	accountRepository.
+		EXPECT().
+		GetByUID(gomock.Any(), gomock.Eq(s.accounts[0].UID)).
+		Return(&s.accounts[0], nil)
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		AccountRepository().
+		Return(accountRepository)
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	accountManager := NewAccountManager(server.Globals{
+		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		RepositoryFactory: factory,
+	})
+	account, err := accountManager.UpdateAccountStatus(context.Background(), s.accounts[0].UID, "active")
+
+	s.NoError(err)
+	s.NotNil(account)
+	s.Equal("active", account.Status)
+	s.Equal(s.accounts[0].StatusChangedAt, account.StatusChangedAt)
+	s.Equal(0, zapRecorded.Len())
+}
+
+func (s *accountManagerTestSuite) TestUpdateAccountStatusSucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	stored := s.accounts[0]
+	accountRepository := mock.NewMockAccountRepository(ctrl)
+	accountRepository.
+		EXPECT().
+		GetByUID(gomock.Any(), gomock.Eq(s.accounts[0].UID)).
+		Return(&stored, nil)
+	accountRepository.
+		EXPECT().
+		UpdateStatus(gomock.Any(), gomock.Any()).
+		Return(nil)
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		AccountRepository().
+		Return(accountRepository).
+		Times(2)
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	accountManager := NewAccountManager(server.Globals{
+		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		RepositoryFactory: factory,
+	})
+	account, err := accountManager.UpdateAccountStatus(context.Background(), s.accounts[0].UID, "frozen")
+
+	s.NoError(err)
+	s.NotNil(account)
+	s.Equal("frozen", account.Status)
+	s.False(account.StatusChangedAt.Equal(s.accounts[0].StatusChangedAt))
+	s.Equal(0, zapRecorded.Len())
 }
 
 func (s *accountManagerTestSuite) TestAllAccountsFailed() {
 	ctrl := gomock.NewController(s.T())
 	defer ctrl.Finish()
 
-	repository := mock.NewMockAccountRepository(ctrl)
-	repository.
+	accountRepository := mock.NewMockAccountRepository(ctrl)
+	accountRepository.
 		EXPECT().
-		GetAll(gomock.Any()).
+		GetAll(gomock.Any(), gomock.Eq(repository.Page{Limit: defaultPageLimit + 1})).
 		Return(nil, errors.New("fail"))
 	factory := mock.NewMockFactory(ctrl)
 	factory.
 		EXPECT().
 		AccountRepository().
-		Return(repository)
+		Return(accountRepository)
 
 	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
 	accountManager := NewAccountManager(server.Globals{
 		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
 		RepositoryFactory: factory,
 	})
-	accounts, err := accountManager.AllAccounts(context.Background())
+	accounts, err := accountManager.AllAccounts(context.Background(), handler.PageRequest{})
 
 	s.Error(err)
 	s.Nil(accounts)
@@ -67,26 +364,81 @@ func (s *accountManagerTestSuite) TestAllAccountsSucceeded() {
 	ctrl := gomock.NewController(s.T())
 	defer ctrl.Finish()
 
-	repository := mock.NewMockAccountRepository(ctrl)
-	repository.
+	accountRepository := mock.NewMockAccountRepository(ctrl)
+	accountRepository.
 		EXPECT().
-		GetAll(gomock.Any()).
+		GetAll(gomock.Any(), gomock.Eq(repository.Page{Limit: defaultPageLimit + 1})).
 		Return(s.accounts, nil)
 	factory := mock.NewMockFactory(ctrl)
 	factory.
 		EXPECT().
 		AccountRepository().
-		Return(repository)
+		Return(accountRepository)
 
 	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
 	accountManager := NewAccountManager(server.Globals{
 		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
 		RepositoryFactory: factory,
 	})
-	accounts, err := accountManager.AllAccounts(context.Background())
+	accounts, err := accountManager.AllAccounts(context.Background(), handler.PageRequest{})
 
 	s.NoError(err)
-	s.Len(accounts, 2)
+	s.Len(accounts.Accounts, 2)
+	s.Empty(accounts.NextCursor)
+	s.Equal(0, zapRecorded.Len())
+}
+
+func (s *accountManagerTestSuite) TestAllAccountsBadPageFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	factory := mock.NewMockFactory(ctrl)
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	accountManager := NewAccountManager(server.Globals{
+		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		RepositoryFactory: factory,
+	})
+	accounts, err := accountManager.AllAccounts(context.Background(), handler.PageRequest{Cursor: "?"})
+
+	var handlerError *handler.Error
+	s.Error(err)
+	s.True(errors.As(err, &handlerError))
+	s.Equal(handler.ClientError, handlerError.Kind)
+	s.Nil(accounts)
+	s.Equal(0, zapRecorded.Len())
+}
+
+func (s *accountManagerTestSuite) TestAllAccountsNextPageSucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	accountRepository := mock.NewMockAccountRepository(ctrl)
+	accountRepository.
+		EXPECT().
+		GetAll(gomock.Any(), gomock.Eq(repository.Page{AfterID: 1000, Limit: 2})).
+		Return(s.accounts, nil)
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		AccountRepository().
+		Return(accountRepository)
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	accountManager := NewAccountManager(server.Globals{
+		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		RepositoryFactory: factory,
+	})
+	accounts, err := accountManager.AllAccounts(context.Background(),
+		handler.PageRequest{Limit: 1, Cursor: encodeCursor(1000)})
+
+	s.NoError(err)
+	s.Len(accounts.Accounts, 1)
+	s.Equal(s.accounts[0].UID, accounts.Accounts[0].UID)
+	s.Equal(encodeCursor(s.accounts[0].ID), accounts.NextCursor)
 	s.Equal(0, zapRecorded.Len())
 }
 
@@ -94,23 +446,24 @@ func (s *accountManagerTestSuite) TestAllPaymentsFailed() {
 	ctrl := gomock.NewController(s.T())
 	defer ctrl.Finish()
 
-	repository := mock.NewMockPaymentRepository(ctrl)
-	repository.
+	ledgerRepository := mock.NewMockLedgerRepository(ctrl)
+	ledgerRepository.
 		EXPECT().
-		GetAll(gomock.Any()).
+		GetAll(gomock.Any(), gomock.Eq(repository.Page{Limit: defaultPageLimit + 1})).
 		Return(nil, errors.New("fail"))
 	factory := mock.NewMockFactory(ctrl)
 	factory.
 		EXPECT().
-		PaymentRepository().
-		Return(repository)
+		LedgerRepository().
+		Return(ledgerRepository)
 
 	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
 	accountManager := NewAccountManager(server.Globals{
 		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
 		RepositoryFactory: factory,
 	})
-	payments, err := accountManager.AllPayments(context.Background())
+	payments, err := accountManager.AllPayments(context.Background(), handler.PageRequest{})
 
 	s.Error(err)
 	s.Nil(payments)
@@ -121,37 +474,445 @@ func (s *accountManagerTestSuite) TestAllPaymentsSucceeded() {
 	ctrl := gomock.NewController(s.T())
 	defer ctrl.Finish()
 
-	repository := mock.NewMockPaymentRepository(ctrl)
-	repository.
+	ledgerRepository := mock.NewMockLedgerRepository(ctrl)
+	ledgerRepository.
 		EXPECT().
-		GetAll(gomock.Any()).
-		Return(s.payments, nil)
+		GetAll(gomock.Any(), gomock.Eq(repository.Page{Limit: defaultPageLimit + 1})).
+		Return(s.entries, nil)
 	factory := mock.NewMockFactory(ctrl)
 	factory.
 		EXPECT().
-		PaymentRepository().
-		Return(repository)
+		LedgerRepository().
+		Return(ledgerRepository)
 
 	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
 	accountManager := NewAccountManager(server.Globals{
 		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
 		RepositoryFactory: factory,
 	})
-	payments, err := accountManager.AllPayments(context.Background())
+	list, err := accountManager.AllPayments(context.Background(), handler.PageRequest{})
 
 	s.NoError(err)
+	s.Empty(list.NextCursor)
+	payments := list.Payments
 	s.Len(payments, 2)
-	s.Equal(s.payments[0].PayerAccountUID, payments[0].UID)
+	s.Equal(s.entries[0].Transfer.PayerAccountUID, payments[0].UID)
 	s.NotNil(payments[0].TargetUID)
-	s.Equal(s.payments[0].RecipientAccountUID, *payments[0].TargetUID)
+	s.Equal(s.entries[0].Transfer.RecipientAccountUID, *payments[0].TargetUID)
 	s.Equal(outgoingPayment, payments[0].Direction)
-	s.Equal(s.payments[1].PayerAccountUID, payments[1].UID)
+	s.Equal(s.entries[1].Transfer.RecipientAccountUID, payments[1].UID)
 	s.NotNil(payments[1].SourceUID)
-	s.Equal(s.payments[1].RecipientAccountUID, *payments[1].SourceUID)
+	s.Equal(s.entries[1].Transfer.PayerAccountUID, *payments[1].SourceUID)
 	s.Equal(incomingPayment, payments[1].Direction)
 	s.Equal(0, zapRecorded.Len())
 }
 
+func (s *accountManagerTestSuite) TestAccountPaymentsGetAccountFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	accountRepository := mock.NewMockAccountRepository(ctrl)
+	accountRepository.
+		EXPECT().
+		GetByUID(gomock.Any(), gomock.Eq(s.accounts[0].UID)).
+		Return(nil, errors.New("fail"))
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		AccountRepository().
+		Return(accountRepository)
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	accountManager := NewAccountManager(server.Globals{
+		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		RepositoryFactory: factory,
+	})
+	payments, err := accountManager.AccountPayments(context.Background(),
+		s.accounts[0].UID, handler.PaymentFilter{}, handler.PageRequest{})
+
+	s.Error(err)
+	s.Nil(payments)
+	s.Equal(0, zapRecorded.Len())
+}
+
+func (s *accountManagerTestSuite) TestAccountPaymentsBadFilterFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	accountRepository := mock.NewMockAccountRepository(ctrl)
+	accountRepository.
+		EXPECT().
+		GetByUID(gomock.Any(), gomock.Eq(s.accounts[0].UID)).
+		Return(&s.accounts[0], nil)
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		AccountRepository().
+		Return(accountRepository)
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	accountManager := NewAccountManager(server.Globals{
+		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		RepositoryFactory: factory,
+	})
+	payments, err := accountManager.AccountPayments(context.Background(),
+		s.accounts[0].UID, handler.PaymentFilter{Direction: "sideways"}, handler.PageRequest{})
+
+	var handlerError *handler.Error
+	s.Error(err)
+	s.True(errors.As(err, &handlerError))
+	s.Equal(handler.ClientError, handlerError.Kind)
+	s.Nil(payments)
+	s.Equal(0, zapRecorded.Len())
+}
+
+func (s *accountManagerTestSuite) TestAccountPaymentsFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	accountRepository := mock.NewMockAccountRepository(ctrl)
+	accountRepository.
+		EXPECT().
+		GetByUID(gomock.Any(), gomock.Eq(s.accounts[0].UID)).
+		Return(&s.accounts[0], nil)
+	ledgerRepository := mock.NewMockLedgerRepository(ctrl)
+	ledgerRepository.
+		EXPECT().
+		GetByAccount(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, errors.New("fail"))
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		AccountRepository().
+		Return(accountRepository)
+	factory.
+		EXPECT().
+		LedgerRepository().
+		Return(ledgerRepository)
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	accountManager := NewAccountManager(server.Globals{
+		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		RepositoryFactory: factory,
+	})
+	payments, err := accountManager.AccountPayments(context.Background(),
+		s.accounts[0].UID, handler.PaymentFilter{}, handler.PageRequest{})
+
+	s.Error(err)
+	s.Nil(payments)
+	s.Equal(0, zapRecorded.Len())
+}
+
+func (s *accountManagerTestSuite) TestAccountPaymentsSucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	minAmount := money.MustParse("1.5")
+	expected := repository.PaymentFilter{
+		AccountUID: s.accounts[0].UID,
+		Direction:  repository.OutgoingDirection,
+		MinAmount:  pointer.ToInt64(150),
+	}
+
+	accountRepository := mock.NewMockAccountRepository(ctrl)
+	accountRepository.
+		EXPECT().
+		GetByUID(gomock.Any(), gomock.Eq(s.accounts[0].UID)).
+		Return(&s.accounts[0], nil)
+	ledgerRepository := mock.NewMockLedgerRepository(ctrl)
+	ledgerRepository.
+		EXPECT().
+		GetByAccount(gomock.Any(), gomock.Eq(expected), gomock.Eq(repository.Page{Limit: 2})).
+		Return(s.entries[:1], nil)
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		AccountRepository().
+		Return(accountRepository)
+	factory.
+		EXPECT().
+		LedgerRepository().
+		Return(ledgerRepository)
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	accountManager := NewAccountManager(server.Globals{
+		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		RepositoryFactory: factory,
+	})
+	payments, err := accountManager.AccountPayments(context.Background(), s.accounts[0].UID,
+		handler.PaymentFilter{Direction: outgoingPayment, MinAmount: &minAmount}, handler.PageRequest{Limit: 1})
+
+	s.NoError(err)
+	s.Len(payments.Payments, 1)
+	s.Equal(s.entries[0].Transfer.PayerAccountUID, payments.Payments[0].UID)
+	s.Empty(payments.NextCursor)
+	s.Equal(0, zapRecorded.Len())
+}
+
+func (s *accountManagerTestSuite) TestReconcileBadReasonFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	factory := mock.NewMockFactory(ctrl)
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	accountManager := NewAccountManager(server.Globals{
+		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		RepositoryFactory: factory,
+	})
+	reconciliation, err := accountManager.Reconcile(context.Background(),
+		handler.ReconciliationRequest{Adjust: true, Reason: " "})
+
+	var handlerError *handler.Error
+	s.Error(err)
+	s.True(errors.As(err, &handlerError))
+	s.Equal(handler.ClientError, handlerError.Kind)
+	s.Nil(reconciliation)
+	s.Equal(0, zapRecorded.Len())
+}
+
+func (s *accountManagerTestSuite) TestReconcileFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	scope := mock.NewMockScope(ctrl)
+	scope.
+		EXPECT().
+		WithContext(gomock.Any()).
+		Return(context.Background(), nil)
+	scope.
+		EXPECT().
+		Cancel(gomock.Any()).
+		Return(nil)
+
+	reconciliationRepository := mock.NewMockReconciliationRepository(ctrl)
+	reconciliationRepository.
+		EXPECT().
+		GetDrifts(gomock.Any()).
+		Return(nil, errors.New("fail"))
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		Retry(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, work func(ctx context.Context) error) error {
+			return work(ctx)
+		})
+	factory.
+		EXPECT().
+		Scope(gomock.Any()).
+		Return(scope)
+	factory.
+		EXPECT().
+		ReconciliationRepository().
+		Return(reconciliationRepository)
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	accountManager := NewAccountManager(server.Globals{
+		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		RepositoryFactory: factory,
+	})
+	reconciliation, err := accountManager.Reconcile(context.Background(), handler.ReconciliationRequest{})
+
+	s.Error(err)
+	s.Nil(reconciliation)
+	s.Equal(0, zapRecorded.Len())
+}
+
+func (s *accountManagerTestSuite) TestReconcileSucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	drift := testutil.RepositoryDrift()
+
+	scope := mock.NewMockScope(ctrl)
+	scope.
+		EXPECT().
+		WithContext(gomock.Any()).
+		Return(context.Background(), nil)
+	scope.
+		EXPECT().
+		Complete(gomock.Any()).
+		Return(nil)
+	scope.
+		EXPECT().
+		Cancel(gomock.Any()).
+		Return(nil)
+
+	reconciliationRepository := mock.NewMockReconciliationRepository(ctrl)
+	reconciliationRepository.
+		EXPECT().
+		GetDrifts(gomock.Any()).
+		Return([]repository.Drift{drift}, nil)
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		Retry(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, work func(ctx context.Context) error) error {
+			return work(ctx)
+		})
+	factory.
+		EXPECT().
+		Scope(gomock.Any()).
+		Return(scope)
+	factory.
+		EXPECT().
+		ReconciliationRepository().
+		Return(reconciliationRepository)
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	accountManager := NewAccountManager(server.Globals{
+		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		RepositoryFactory: factory,
+	})
+	reconciliation, err := accountManager.Reconcile(context.Background(), handler.ReconciliationRequest{})
+
+	s.NoError(err)
+	s.NotNil(reconciliation)
+	s.False(reconciliation.Adjusted)
+	s.Len(reconciliation.Drifts, 1)
+	s.Equal(drift.AccountUID, reconciliation.Drifts[0].UID)
+	s.Equal("100.00", reconciliation.Drifts[0].ExpectedBalance.String())
+	s.Equal("123.45", reconciliation.Drifts[0].ActualBalance.String())
+	s.Equal(1, zapRecorded.Len())
+	s.Equal("Balance drift detected", zapRecorded.All()[0].Message)
+}
+
+func (s *accountManagerTestSuite) TestReconcileAdjustNegativeFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	drift := testutil.RepositoryDrift()
+	drift.ExpectedBalance = -100
+
+	scope := mock.NewMockScope(ctrl)
+	scope.
+		EXPECT().
+		WithContext(gomock.Any()).
+		Return(context.Background(), nil)
+	scope.
+		EXPECT().
+		Cancel(gomock.Any()).
+		Return(nil)
+
+	reconciliationRepository := mock.NewMockReconciliationRepository(ctrl)
+	reconciliationRepository.
+		EXPECT().
+		GetDrifts(gomock.Any()).
+		Return([]repository.Drift{drift}, nil)
+	reconciliationRepository.
+		EXPECT().
+		StoreAdjustment(gomock.Any(), gomock.Any()).
+		Return(repository.ErrInsufficientFunds)
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		Retry(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, work func(ctx context.Context) error) error {
+			return work(ctx)
+		})
+	factory.
+		EXPECT().
+		Scope(gomock.Any()).
+		Return(scope)
+	factory.
+		EXPECT().
+		ReconciliationRepository().
+		Return(reconciliationRepository).
+		Times(2)
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	accountManager := NewAccountManager(server.Globals{
+		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		RepositoryFactory: factory,
+	})
+	reconciliation, err := accountManager.Reconcile(context.Background(),
+		handler.ReconciliationRequest{Adjust: true, Reason: "incident 42"})
+
+	var handlerError *handler.Error
+	s.Error(err)
+	s.True(errors.As(err, &handlerError))
+	s.Equal(handler.UnprocessableError, handlerError.Kind)
+	s.Nil(reconciliation)
+	s.Equal(1, zapRecorded.Len())
+}
+
+func (s *accountManagerTestSuite) TestReconcileAdjustSucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	drift := testutil.RepositoryDrift()
+
+	scope := mock.NewMockScope(ctrl)
+	scope.
+		EXPECT().
+		WithContext(gomock.Any()).
+		Return(context.Background(), nil)
+	scope.
+		EXPECT().
+		Complete(gomock.Any()).
+		Return(nil)
+	scope.
+		EXPECT().
+		Cancel(gomock.Any()).
+		Return(nil)
+
+	reconciliationRepository := mock.NewMockReconciliationRepository(ctrl)
+	reconciliationRepository.
+		EXPECT().
+		GetDrifts(gomock.Any()).
+		Return([]repository.Drift{drift}, nil)
+	reconciliationRepository.
+		EXPECT().
+		StoreAdjustment(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, adjustment *repository.Adjustment) error {
+			s.Equal(drift.AccountUID, adjustment.AccountUID)
+			s.Equal(drift.ExpectedBalance-drift.ActualBalance, adjustment.Amount)
+			s.Equal("incident 42", adjustment.Reason)
+			return nil
+		})
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		Retry(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, work func(ctx context.Context) error) error {
+			return work(ctx)
+		})
+	factory.
+		EXPECT().
+		Scope(gomock.Any()).
+		Return(scope)
+	factory.
+		EXPECT().
+		ReconciliationRepository().
+		Return(reconciliationRepository).
+		Times(2)
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	accountManager := NewAccountManager(server.Globals{
+		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		RepositoryFactory: factory,
+	})
+	reconciliation, err := accountManager.Reconcile(context.Background(),
+		handler.ReconciliationRequest{Adjust: true, Reason: "incident 42"})
+
+	s.NoError(err)
+	s.NotNil(reconciliation)
+	s.True(reconciliation.Adjusted)
+	s.Len(reconciliation.Drifts, 1)
+	s.Equal(2, zapRecorded.Len())
+	s.Equal("Balance adjusted", zapRecorded.All()[1].Message)
+}
+
 func (s *accountManagerTestSuite) TestAccountBuilderSucceeded() {
 	accountManager := NewAccountManager(server.Globals{})
 	builder := accountManager.AccountBuilder()
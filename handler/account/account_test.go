@@ -10,4 +10,16 @@ func TestAccount(t *testing.T) {
 	suite.Run(t, new(accountManagerTestSuite))
 	suite.Run(t, new(accountBuilderTestSuite))
 	suite.Run(t, new(paymentBuilderTestSuite))
+	suite.Run(t, new(mappingTestSuite))
+	suite.Run(t, new(idempotencyTestSuite))
+	suite.Run(t, new(paginationTestSuite))
+	suite.Run(t, new(filterTestSuite))
+	suite.Run(t, new(statusTestSuite))
+	suite.Run(t, new(concurrencyTestSuite))
+	suite.Run(t, new(webhookManagerTestSuite))
+	suite.Run(t, new(eventStreamTestSuite))
+	suite.Run(t, new(scheduleManagerTestSuite))
+	suite.Run(t, new(schedulerTestSuite))
+	suite.Run(t, new(recurrenceTestSuite))
+	suite.Run(t, new(refundBuilderTestSuite))
 }
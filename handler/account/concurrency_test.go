@@ -0,0 +1,271 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/handler"
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/repository/memoryengine"
+	"github.com/Toshik1978/go-rest-api/repository/repositoryengine"
+	"github.com/Toshik1978/go-rest-api/service/broker"
+	"github.com/Toshik1978/go-rest-api/service/fx"
+	"github.com/Toshik1978/go-rest-api/service/money"
+	"github.com/Toshik1978/go-rest-api/service/server"
+	"github.com/Toshik1978/go-rest-api/service/sqlite"
+	"github.com/Toshik1978/go-rest-api/service/testutil"
+	_ "github.com/jackc/pgx/v4/stdlib" // PostgreSQL driver
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+const (
+	// testDBEnv define environment variable with DSN of the migrated test database
+	testDBEnv = "GO_REST_API_TEST_DB"
+
+	concurrentWorkers  = 8
+	concurrentPayments = 50
+	concurrentBalance  = 1000
+)
+
+// concurrencyTestSuite runs payments against real PostgreSQL, it's skipped without test database,
+// and against in-memory and SQLite storages
+type concurrencyTestSuite struct {
+	suite.Suite
+
+	db      *sqlx.DB
+	manager handler.AccountManager
+	factory repository.Factory
+}
+
+func (s *concurrencyTestSuite) SetupSuite() {
+	dsn := os.Getenv(testDBEnv)
+	if dsn == "" {
+		return
+	}
+	db, err := sqlx.Connect("pgx", dsn)
+	if err != nil {
+		s.FailNow("failed to connect to test database", err.Error())
+	}
+
+	s.db = db
+	s.factory = repositoryengine.NewRepositoryFactory(zap.NewNop(), db)
+	s.manager = newConcurrencyTestManager(s.factory)
+}
+
+func (s *concurrencyTestSuite) TearDownSuite() {
+	if s.db != nil {
+		_ = s.db.Close()
+	}
+}
+
+func (s *concurrencyTestSuite) TestCrossPaymentsSucceeded() {
+	if s.db == nil {
+		s.T().Skip(testDBEnv + " is not set, skip concurrency test")
+	}
+	s.crossPayments(s.factory, s.manager)
+}
+
+func (s *concurrencyTestSuite) TestInMemoryCrossPaymentsSucceeded() {
+	factory := memoryengine.NewRepositoryFactory()
+	s.crossPayments(factory, newConcurrencyTestManager(factory))
+}
+
+func (s *concurrencyTestSuite) TestSQLiteCrossPaymentsSucceeded() {
+	client, err := sqlite.NewSQLiteClient(zap.NewNop(), server.Vars{DB: ":memory:"})
+	s.Require().NoError(err)
+	defer client.Stop()
+	s.Require().NoError(sqlite.NewMigrator(zap.NewNop(), client.GetConnection()).Up(context.Background(), 0))
+
+	factory := repositoryengine.NewSQLiteRepositoryFactory(zap.NewNop(), client.GetConnection())
+	s.crossPayments(factory, newConcurrencyTestManager(factory))
+}
+
+func (s *concurrencyTestSuite) TestPagesDuringPaymentsSucceeded() {
+	if s.db == nil {
+		s.T().Skip(testDBEnv + " is not set, skip concurrency test")
+	}
+	s.pagesDuringPayments(s.manager)
+}
+
+func (s *concurrencyTestSuite) TestInMemoryPagesDuringPaymentsSucceeded() {
+	s.pagesDuringPayments(newConcurrencyTestManager(memoryengine.NewRepositoryFactory()))
+}
+
+func (s *concurrencyTestSuite) TestSQLitePagesDuringPaymentsSucceeded() {
+	client, err := sqlite.NewSQLiteClient(zap.NewNop(), server.Vars{DB: ":memory:"})
+	s.Require().NoError(err)
+	defer client.Stop()
+	s.Require().NoError(sqlite.NewMigrator(zap.NewNop(), client.GetConnection()).Up(context.Background(), 0))
+
+	factory := repositoryengine.NewSQLiteRepositoryFactory(zap.NewNop(), client.GetConnection())
+	s.pagesDuringPayments(newConcurrencyTestManager(factory))
+}
+
+// newConcurrencyTestManager creates account manager with the given repositories
+func newConcurrencyTestManager(factory repository.Factory) handler.AccountManager {
+	fxRateProvider, _ := fx.NewFileRateProvider(server.Vars{})
+	return NewAccountManager(server.Globals{
+		Logger:            zap.NewNop(),
+		RepositoryFactory: factory,
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		FXRateProvider:    fxRateProvider,
+		EventBroker:       broker.NewBroker(server.Vars{}),
+	})
+}
+
+// crossPayments runs concurrent payments between two new accounts and checks, that money isn't lost
+func (s *concurrencyTestSuite) crossPayments(factory repository.Factory, manager handler.AccountManager) {
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 10)
+	uids := []string{"concurrency-a-" + suffix, "concurrency-b-" + suffix}
+	for _, uid := range uids {
+		_, err := manager.AccountBuilder().
+			SetUID(uid).
+			SetCurrency("USD").
+			SetBalance(money.FromMinorUnits(concurrentBalance, 2)).
+			Build(context.Background())
+		s.Require().NoError(err)
+	}
+
+	// Half of workers pay A→B, other half pay B→A, so without ordered locks they deadlock
+	var wg sync.WaitGroup
+	errs := make(chan error, concurrentWorkers*concurrentPayments)
+	for i := 0; i < concurrentWorkers; i++ {
+		payer, recipient := uids[i%2], uids[(i+1)%2]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < concurrentPayments; j++ {
+				_, err := manager.PaymentBuilder().
+					SetPayer(payer).
+					SetRecipient(recipient).
+					SetAmount(money.FromMinorUnits(int64(1+j%10), 2)).
+					Build(context.Background())
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	// Only insufficient funds is expected, any other error (deadlock, serialization failure) fails the test
+	for err := range errs {
+		if err != nil {
+			var handlerError *handler.Error
+			s.True(errors.As(err, &handlerError), err.Error())
+			s.True(errors.Is(err, repository.ErrInsufficientFunds), err.Error())
+		}
+	}
+
+	var total int64
+	for _, uid := range uids {
+		account, err := factory.AccountRepository().GetByUID(context.Background(), uid)
+		s.Require().NoError(err)
+		s.True(account.Balance >= 0)
+		total += account.Balance
+	}
+	s.Equal(int64(2*concurrentBalance), total)
+
+	drifts, err := factory.ReconciliationRepository().GetDrifts(context.Background())
+	s.Require().NoError(err)
+	for _, drift := range drifts {
+		s.NotContains(uids, drift.AccountUID)
+	}
+}
+
+// pagesDuringPayments walks global listings, while accounts and payments are inserted concurrently, and checks
+// what pagination guarantees in every storage: pages never repeat records and never skip records, which were
+// committed before the walk. Records, which inserts are in flight during the walk, may be skipped
+func (s *concurrencyTestSuite) pagesDuringPayments(manager handler.AccountManager) {
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 10)
+	uids := []string{"paging-a-" + suffix, "paging-b-" + suffix}
+	for _, uid := range uids {
+		_, err := manager.AccountBuilder().
+			SetUID(uid).
+			SetCurrency("USD").
+			SetBalance(money.FromMinorUnits(concurrentBalance, 2)).
+			Build(context.Background())
+		s.Require().NoError(err)
+	}
+	var committed []int64
+	for i := 0; i < concurrentWorkers; i++ {
+		payment, err := manager.PaymentBuilder().
+			SetPayer(uids[i%2]).
+			SetRecipient(uids[(i+1)%2]).
+			SetAmount(money.FromMinorUnits(1, 2)).
+			Build(context.Background())
+		s.Require().NoError(err)
+		committed = append(committed, payment.ID)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, concurrentWorkers*concurrentPayments)
+	for i := 0; i < concurrentWorkers; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < concurrentPayments/5; j++ {
+				_, err := manager.AccountBuilder().
+					SetUID("paging-" + strconv.Itoa(i) + "-" + strconv.Itoa(j) + "-" + suffix).
+					SetCurrency("USD").
+					Build(context.Background())
+				errs <- err
+				_, err = manager.PaymentBuilder().
+					SetPayer(uids[i%2]).
+					SetRecipient(uids[(i+1)%2]).
+					SetAmount(money.FromMinorUnits(1, 2)).
+					Build(context.Background())
+				errs <- err
+			}
+		}()
+	}
+
+	accounts := make(map[string]bool)
+	for page := (handler.PageRequest{Limit: 10}); ; {
+		list, err := manager.AllAccounts(context.Background(), page)
+		s.Require().NoError(err)
+		for _, account := range list.Accounts {
+			s.False(accounts[account.UID], "account %s is repeated", account.UID)
+			accounts[account.UID] = true
+		}
+		if list.NextCursor == "" {
+			break
+		}
+		page.Cursor = list.NextCursor
+	}
+	// Payment is listed twice: as payer's outgoing and recipient's incoming one
+	payments := make(map[string]bool)
+	for page := (handler.PageRequest{Limit: 10}); ; {
+		list, err := manager.AllPayments(context.Background(), page)
+		s.Require().NoError(err)
+		for _, payment := range list.Payments {
+			key := strconv.FormatInt(payment.ID, 10) + "/" + payment.UID
+			s.False(payments[key], "payment %s is repeated", key)
+			payments[key] = true
+		}
+		if list.NextCursor == "" {
+			break
+		}
+		page.Cursor = list.NextCursor
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		s.NoError(err)
+	}
+	for _, uid := range uids {
+		s.True(accounts[uid], "account %s is skipped", uid)
+	}
+	for i, id := range committed {
+		for _, uid := range []string{uids[i%2], uids[(i+1)%2]} {
+			s.True(payments[strconv.FormatInt(id, 10)+"/"+uid], "payment %d of %s is skipped", id, uid)
+		}
+	}
+}
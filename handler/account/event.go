@@ -0,0 +1,59 @@
+package account
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/handler"
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service"
+	"go.uber.org/zap"
+)
+
+const (
+	// paymentStreamEvent is event of the account's stream with the account's payment
+	paymentStreamEvent = "payment"
+	// balanceStreamEvent is event of the account's stream with the account's new balance
+	balanceStreamEvent = "balance"
+)
+
+// storeEvent saves domain event with JSON encoded data in the outbox. Event should be stored
+// in the same repository scope as the change it describes, so it's dispatched only if the change is committed
+func storeEvent(ctx context.Context, repositoryFactory repository.Factory,
+	eventType repository.EventType, data interface{}, createdAt time.Time) error {
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return handler.WrapError(err, "failed to encode event", handler.ServerError)
+	}
+	event := repository.Event{
+		Type:          eventType,
+		Payload:       string(payload),
+		CreatedAt:     createdAt,
+		NextAttemptAt: createdAt,
+	}
+	if err := repositoryFactory.OutboxRepository().Store(ctx, &event); err != nil {
+		return handler.WrapError(err, "failed to store event", handler.ServerError)
+	}
+	return nil
+}
+
+// publishEvent publishes event with JSON encoded data to subscribers of the account's event stream.
+// Event should be published only after the change it describes is committed. Payment events are identified
+// by ID of the account's posting, other events have zero ID
+func publishEvent(logger *zap.Logger, eventBroker service.EventBroker,
+	accountUID string, eventType string, id int64, data interface{}) {
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		logger.Error("Failed to encode account event", zap.Error(err), zap.String("type", eventType))
+		return
+	}
+	eventBroker.Publish(service.AccountEvent{
+		ID:         id,
+		AccountUID: accountUID,
+		Type:       eventType,
+		Data:       payload,
+	})
+}
@@ -0,0 +1,115 @@
+package account
+
+import (
+	"context"
+
+	"github.com/Toshik1978/go-rest-api/handler"
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service"
+	"go.uber.org/zap"
+)
+
+// eventStream streams activity of the single account: payments, missed by the client, from the ledger,
+// then the current balance and then events published by payments in real time
+type eventStream struct {
+	logger            *zap.Logger
+	repositoryFactory repository.Factory
+	currencyRegistry  service.CurrencyRegistry
+	subscription      service.EventSubscription
+}
+
+// newEventStream creates new stream of the subscribed account's events
+func newEventStream(logger *zap.Logger, repositoryFactory repository.Factory,
+	currencyRegistry service.CurrencyRegistry, subscription service.EventSubscription) *eventStream {
+
+	return &eventStream{
+		logger:            logger,
+		repositoryFactory: repositoryFactory,
+		currencyRegistry:  currencyRegistry,
+		subscription:      subscription,
+	}
+}
+
+// run sends account's events to the channel till context is done or subscription is closed.
+// Channel is closed and subscription is cancelled on return
+func (s *eventStream) run(ctx context.Context,
+	account repository.Account, afterID int64, events chan<- handler.AccountEvent) {
+
+	defer close(events)
+	defer s.subscription.Cancel()
+
+	lastID := afterID
+	if afterID > 0 {
+		var err error
+		if lastID, err = s.replay(ctx, account.UID, afterID, events); err != nil {
+			// Client is gone, if context is done, it's not an error
+			if ctx.Err() == nil {
+				s.logger.Error("Failed to replay account events", zap.Error(err), zap.String("account", account.UID))
+			}
+			return
+		}
+	}
+	if !s.send(ctx, events, handler.AccountEvent{
+		Type: balanceStreamEvent,
+		Data: mapRepositoryAccount(account, s.currencyRegistry),
+	}) {
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-s.subscription.Events():
+			if !ok {
+				// Subscription is overflowed, client should reconnect and resume from the last event
+				s.logger.Warn("Account event stream overflowed", zap.String("account", account.UID))
+				return
+			}
+			// Payment could be replayed already, if it's committed after subscription is started
+			if event.ID != 0 && event.ID <= lastID {
+				continue
+			}
+			if !s.send(ctx, events, mapServiceAccountEvent(event)) {
+				return
+			}
+		}
+	}
+}
+
+// replay sends account's payments after the given one from the ledger and return ID of the last sent payment
+func (s *eventStream) replay(ctx context.Context,
+	uid string, afterID int64, events chan<- handler.AccountEvent) (int64, error) {
+
+	page := repository.Page{AfterID: afterID, Limit: defaultPageLimit}
+	for {
+		entries, err := s.repositoryFactory.LedgerRepository().
+			GetByAccount(ctx, repository.PaymentFilter{AccountUID: uid}, page)
+		if err != nil {
+			return 0, err
+		}
+		for _, entry := range entries {
+			if !s.send(ctx, events, handler.AccountEvent{
+				ID:   encodeCursor(entry.Posting.ID),
+				Type: paymentStreamEvent,
+				Data: mapRepositoryEntry(entry, s.currencyRegistry),
+			}) {
+				return 0, ctx.Err()
+			}
+			page.AfterID = entry.Posting.ID
+		}
+		if len(entries) < page.Limit {
+			return page.AfterID, nil
+		}
+	}
+}
+
+// send sends event to the channel and return false, if context is done before event is received
+func (s *eventStream) send(ctx context.Context, events chan<- handler.AccountEvent, event handler.AccountEvent) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case events <- event:
+		return true
+	}
+}
@@ -0,0 +1,153 @@
+package account
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/handler"
+	"github.com/Toshik1978/go-rest-api/repository/memoryengine"
+	"github.com/Toshik1978/go-rest-api/service/broker"
+	"github.com/Toshik1978/go-rest-api/service/fx"
+	"github.com/Toshik1978/go-rest-api/service/money"
+	"github.com/Toshik1978/go-rest-api/service/server"
+	"github.com/Toshik1978/go-rest-api/service/testutil"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+// eventTimeout limits waiting of the stream's event in tests
+const eventTimeout = 5 * time.Second
+
+type eventStreamTestSuite struct {
+	suite.Suite
+
+	manager handler.AccountManager
+}
+
+func (s *eventStreamTestSuite) SetupTest() {
+	fxRateProvider, _ := fx.NewFileRateProvider(server.Vars{})
+	s.manager = NewAccountManager(server.Globals{
+		Logger:            zap.NewNop(),
+		RepositoryFactory: memoryengine.NewRepositoryFactory(),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		FXRateProvider:    fxRateProvider,
+		EventBroker:       broker.NewBroker(server.Vars{EventsBufferSize: 10}),
+	})
+	for _, uid := range []string{"toshik1978", "toshik1979"} {
+		_, err := s.manager.AccountBuilder().
+			SetUID(uid).
+			SetCurrency("USD").
+			SetBalance(money.MustParse("100.00")).
+			Build(context.Background())
+		s.Require().NoError(err)
+	}
+}
+
+func (s *eventStreamTestSuite) TestAccountEventsInvalidLastEventIDFailed() {
+	events, err := s.manager.AccountEvents(context.Background(), "toshik1978", "invalid")
+
+	var handlerError *handler.Error
+	s.True(errors.As(err, &handlerError))
+	s.Equal(handler.ClientError, handlerError.Kind)
+	s.Nil(events)
+}
+
+func (s *eventStreamTestSuite) TestAccountEventsAccountNotFoundFailed() {
+	events, err := s.manager.AccountEvents(context.Background(), "unknown", "")
+
+	var handlerError *handler.Error
+	s.True(errors.As(err, &handlerError))
+	s.Equal(handler.NotFoundError, handlerError.Kind)
+	s.Nil(events)
+}
+
+func (s *eventStreamTestSuite) TestAccountEventsSucceeded() {
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := s.manager.AccountEvents(ctx, "toshik1979", "")
+	s.Require().NoError(err)
+
+	// Stream starts with the current balance
+	s.assertBalance(s.receive(events), "100.00")
+
+	s.pay("10.00")
+	payment := s.receive(events)
+	s.Equal(paymentStreamEvent, payment.Type)
+	s.NotEmpty(payment.ID)
+	s.Contains(s.encode(payment.Data), `"direction":"incoming","amount":"10.00"`)
+	s.assertBalance(s.receive(events), "110.00")
+
+	// Stream is finished, when client is gone
+	cancel()
+	select {
+	case _, ok := <-events:
+		s.False(ok)
+	case <-time.After(eventTimeout):
+		s.Fail("stream is not closed")
+	}
+}
+
+func (s *eventStreamTestSuite) TestAccountEventsResumeSucceeded() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := s.manager.AccountEvents(ctx, "toshik1978", "")
+	s.Require().NoError(err)
+	s.receive(events)
+	s.pay("10.00")
+	lastEventID := s.receive(events).ID
+	s.receive(events)
+	cancel()
+
+	// Payments after the last received one are replayed from the ledger before the current balance
+	s.pay("20.00")
+	s.pay("30.00")
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	events, err = s.manager.AccountEvents(ctx, "toshik1978", lastEventID)
+	s.Require().NoError(err)
+
+	for _, amount := range []string{"20.00", "30.00"} {
+		payment := s.receive(events)
+		s.Equal(paymentStreamEvent, payment.Type)
+		s.Contains(s.encode(payment.Data), `"direction":"outgoing","amount":"`+amount+`"`)
+	}
+	s.assertBalance(s.receive(events), "40.00")
+}
+
+// pay makes payment from the first account to the second one
+func (s *eventStreamTestSuite) pay(amount string) {
+	_, err := s.manager.PaymentBuilder().
+		SetPayer("toshik1978").
+		SetRecipient("toshik1979").
+		SetAmount(money.MustParse(amount)).
+		Build(context.Background())
+	s.Require().NoError(err)
+}
+
+// receive return the next event of the stream
+func (s *eventStreamTestSuite) receive(events <-chan handler.AccountEvent) handler.AccountEvent {
+	select {
+	case event, ok := <-events:
+		s.Require().True(ok, "stream is closed")
+		return event
+	case <-time.After(eventTimeout):
+		s.Require().Fail("no event received")
+		return handler.AccountEvent{}
+	}
+}
+
+// assertBalance checks, that event is balance event with the given balance
+func (s *eventStreamTestSuite) assertBalance(event handler.AccountEvent, balance string) {
+	s.Equal(balanceStreamEvent, event.Type)
+	s.Empty(event.ID)
+	s.Contains(s.encode(event.Data), `"balance":"`+balance+`"`)
+}
+
+// encode return JSON encoded event's data
+func (s *eventStreamTestSuite) encode(data interface{}) string {
+	payload, err := json.Marshal(data)
+	s.Require().NoError(err)
+	return string(payload)
+}
@@ -0,0 +1,63 @@
+package account
+
+import (
+	"github.com/Toshik1978/go-rest-api/handler"
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service/money"
+	"github.com/Toshik1978/go-rest-api/service/validator"
+)
+
+// repositoryPaymentFilter validates filter of the account's payments and converts it to repository filter.
+// Amounts are converted to minor units of the account's currency
+func repositoryPaymentFilter(
+	uid string, filter handler.PaymentFilter, exponent int) (repository.PaymentFilter, error) {
+
+	v := validator.NewValidator()
+	result := repository.PaymentFilter{
+		AccountUID:      uid,
+		CounterpartyUID: filter.Counterparty,
+		From:            filter.From,
+		To:              filter.To,
+	}
+
+	switch filter.Direction {
+	case "":
+		result.Direction = repository.AnyDirection
+	case outgoingPayment:
+		result.Direction = repository.OutgoingDirection
+	case incomingPayment:
+		result.Direction = repository.IncomingDirection
+	default:
+		v.AddField("direction", filter.Direction, "one of ["+outgoingPayment+", "+incomingPayment+"]")
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		v.AddField("to", filter.To.String(), "after "+filter.From.String())
+	}
+	result.MinAmount = filterAmount(v, "min_amount", filter.MinAmount, exponent)
+	result.MaxAmount = filterAmount(v, "max_amount", filter.MaxAmount, exponent)
+	if result.MinAmount != nil && result.MaxAmount != nil && *result.MinAmount > *result.MaxAmount {
+		v.AddField("max_amount", filter.MaxAmount.String(), ">= "+filter.MinAmount.String())
+	}
+
+	if err := v.Error(); err != nil {
+		return repository.PaymentFilter{}, handler.WrapError(err, "failed to validate filter", handler.ClientError)
+	}
+	return result, nil
+}
+
+// filterAmount validates amount of the filter and converts it to minor units
+func filterAmount(v *validator.Validator, field string, amount *money.Amount, exponent int) *int64 {
+	if amount == nil {
+		return nil
+	}
+	if amount.Sign() < 0 {
+		v.AddField(field, amount.String(), ">= 0")
+		return nil
+	}
+	minor, err := amount.MinorUnits(exponent)
+	if err != nil {
+		v.ValidatePrecision(field, *amount, exponent)
+		return nil
+	}
+	return &minor
+}
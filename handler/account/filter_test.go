@@ -0,0 +1,64 @@
+package account
+
+import (
+	"time"
+
+	"github.com/AlekSi/pointer"
+	"github.com/Toshik1978/go-rest-api/handler"
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service/money"
+	"github.com/stretchr/testify/suite"
+)
+
+type filterTestSuite struct {
+	suite.Suite
+}
+
+func (s *filterTestSuite) TestRepositoryPaymentFilterFailed() {
+	now := time.Now()
+	negative := money.MustParse("-1")
+	precise := money.MustParse("0.001")
+	low := money.MustParse("1")
+	high := money.MustParse("10")
+
+	for _, filter := range []handler.PaymentFilter{
+		{Direction: "sideways"},
+		{From: &now, To: &now},
+		{MinAmount: &negative},
+		{MaxAmount: &precise},
+		{MinAmount: &high, MaxAmount: &low},
+	} {
+		_, err := repositoryPaymentFilter("toshik1978", filter, 2)
+		s.Error(err)
+	}
+}
+
+func (s *filterTestSuite) TestRepositoryPaymentFilterSucceeded() {
+	from := time.Now()
+	to := from.Add(time.Hour)
+	low := money.MustParse("1")
+	high := money.MustParse("10.5")
+
+	filter, err := repositoryPaymentFilter("toshik1978", handler.PaymentFilter{}, 2)
+	s.NoError(err)
+	s.Equal(repository.PaymentFilter{AccountUID: "toshik1978"}, filter)
+
+	filter, err = repositoryPaymentFilter("toshik1978", handler.PaymentFilter{
+		Counterparty: "toshik1979",
+		Direction:    incomingPayment,
+		From:         &from,
+		To:           &to,
+		MinAmount:    &low,
+		MaxAmount:    &high,
+	}, 3)
+	s.NoError(err)
+	s.Equal(repository.PaymentFilter{
+		AccountUID:      "toshik1978",
+		CounterpartyUID: "toshik1979",
+		Direction:       repository.IncomingDirection,
+		From:            &from,
+		To:              &to,
+		MinAmount:       pointer.ToInt64(1000),
+		MaxAmount:       pointer.ToInt64(10500),
+	}, filter)
+}
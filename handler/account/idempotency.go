@@ -0,0 +1,97 @@
+package account
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/handler"
+	"github.com/Toshik1978/go-rest-api/repository"
+)
+
+const (
+	accountOperation = "account"
+	paymentOperation = "payment"
+	refundOperation  = "refund"
+)
+
+// idempotency replays results of the operation, executed with the same idempotency key.
+// Key should be stored in the same repository scope as the operation itself
+type idempotency struct {
+	repositoryFactory repository.Factory
+	ttl               time.Duration
+
+	operation   string
+	key         string
+	fingerprint string
+}
+
+// newIdempotency creates new idempotency for the given operation. Fields identify request of the operation
+func newIdempotency(repositoryFactory repository.Factory, ttl time.Duration,
+	operation string, key string, fields ...string) *idempotency {
+
+	hash := sha256.Sum256([]byte(strings.Join(fields, "\n")))
+	return &idempotency{
+		repositoryFactory: repositoryFactory,
+		ttl:               ttl,
+		operation:         operation,
+		key:               key,
+		fingerprint:       hex.EncodeToString(hash[:]),
+	}
+}
+
+// replay looks for the stored response and decodes it into response.
+// false returned, if there is nothing to replay
+func (i *idempotency) replay(ctx context.Context, response interface{}) (bool, error) {
+	if i.key == "" {
+		return false, nil
+	}
+
+	stored, err := i.repositoryFactory.IdempotencyRepository().Get(ctx, i.operation, i.key, time.Now())
+	if err != nil {
+		return false, handler.WrapError(err, "failed to get idempotency key", handler.ServerError)
+	}
+	if stored == nil {
+		return false, nil
+	}
+	if stored.Fingerprint != i.fingerprint {
+		return false, handler.NewError(
+			"idempotency key is already used for another request", handler.UnprocessableError)
+	}
+	if err := json.Unmarshal([]byte(stored.Response), response); err != nil {
+		return false, handler.WrapError(err, "failed to decode stored response", handler.ServerError)
+	}
+	return true, nil
+}
+
+// store saves response of the operation to replay it later
+func (i *idempotency) store(ctx context.Context, response interface{}) error {
+	if i.key == "" {
+		return nil
+	}
+
+	payload, err := json.Marshal(response)
+	if err != nil {
+		return handler.WrapError(err, "failed to encode response", handler.ServerError)
+	}
+	now := time.Now()
+	err = i.repositoryFactory.IdempotencyRepository().Store(ctx, &repository.IdempotencyKey{
+		Operation:   i.operation,
+		Key:         i.key,
+		Fingerprint: i.fingerprint,
+		Response:    string(payload),
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(i.ttl),
+	})
+	if errors.Is(err, repository.ErrIdempotencyKeyExists) {
+		return handler.WrapError(err, "request with the same idempotency key is in progress", handler.ConflictError)
+	}
+	if err != nil {
+		return handler.WrapError(err, "failed to store idempotency key", handler.ServerError)
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/handler"
+	"github.com/Toshik1978/go-rest-api/mock"
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service/testutil"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+)
+
+type idempotencyTestSuite struct {
+	suite.Suite
+
+	key repository.IdempotencyKey
+}
+
+func (s *idempotencyTestSuite) SetupSuite() {
+	s.key = testutil.RepositoryIdempotencyKey()
+	s.key.Fingerprint = newIdempotency(nil, 0, s.key.Operation, s.key.Key, "toshik1978").fingerprint
+}
+
+func (s *idempotencyTestSuite) TestFingerprintSucceeded() {
+	fingerprint1 := newIdempotency(nil, 0, paymentOperation, s.key.Key, "toshik1978", "toshik1979", "100").fingerprint
+	fingerprint2 := newIdempotency(nil, 0, paymentOperation, s.key.Key, "toshik1978", "toshik1979", "100").fingerprint
+	fingerprint3 := newIdempotency(nil, 0, paymentOperation, s.key.Key, "toshik1978", "toshik1979", "10").fingerprint
+
+	s.Len(fingerprint1, 64)
+	s.Equal(fingerprint1, fingerprint2)
+	s.NotEqual(fingerprint1, fingerprint3)
+}
+
+func (s *idempotencyTestSuite) TestReplayNoKeySucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	factory := mock.NewMockFactory(ctrl)
+
+	var response handler.Account
+	ok, err := newIdempotency(factory, time.Hour, s.key.Operation, "", "toshik1978").
+		replay(context.Background(), &response)
+
+	s.NoError(err)
+	s.False(ok)
+}
+
+func (s *idempotencyTestSuite) TestReplayGetFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	idempotencyRepository := mock.NewMockIdempotencyRepository(ctrl)
+	idempotencyRepository.
+		EXPECT().
+		Get(gomock.Any(), gomock.Eq(s.key.Operation), gomock.Eq(s.key.Key), gomock.Any()).
+		Return(nil, errors.New("fail"))
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		IdempotencyRepository().
+		Return(idempotencyRepository)
+
+	var response handler.Account
+	ok, err := newIdempotency(factory, time.Hour, s.key.Operation, s.key.Key, "toshik1978").
+		replay(context.Background(), &response)
+
+	s.Error(err)
+	s.False(ok)
+}
+
+func (s *idempotencyTestSuite) TestReplayMismatchFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	idempotencyRepository := mock.NewMockIdempotencyRepository(ctrl)
+	idempotencyRepository.
+		EXPECT().
+		Get(gomock.Any(), gomock.Eq(s.key.Operation), gomock.Eq(s.key.Key), gomock.Any()).
+		Return(&s.key, nil)
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		IdempotencyRepository().
+		Return(idempotencyRepository)
+
+	var response handler.Account
+	ok, err := newIdempotency(factory, time.Hour, s.key.Operation, s.key.Key, "toshik1979").
+		replay(context.Background(), &response)
+
+	var handlerError *handler.Error
+	s.Error(err)
+	s.True(errors.As(err, &handlerError))
+	s.Equal(handler.UnprocessableError, handlerError.Kind)
+	s.False(ok)
+}
+
+func (s *idempotencyTestSuite) TestReplayNotFoundSucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	idempotencyRepository := mock.NewMockIdempotencyRepository(ctrl)
+	idempotencyRepository.
+		EXPECT().
+		Get(gomock.Any(), gomock.Eq(s.key.Operation), gomock.Eq(s.key.Key), gomock.Any()).
+		Return(nil, nil)
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		IdempotencyRepository().
+		Return(idempotencyRepository)
+
+	var response handler.Account
+	ok, err := newIdempotency(factory, time.Hour, s.key.Operation, s.key.Key, "toshik1978").
+		replay(context.Background(), &response)
+
+	s.NoError(err)
+	s.False(ok)
+}
+
+func (s *idempotencyTestSuite) TestReplaySucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	idempotencyRepository := mock.NewMockIdempotencyRepository(ctrl)
+	idempotencyRepository.
+		EXPECT().
+		Get(gomock.Any(), gomock.Eq(s.key.Operation), gomock.Eq(s.key.Key), gomock.Any()).
+		Return(&s.key, nil)
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		IdempotencyRepository().
+		Return(idempotencyRepository)
+
+	var response handler.Payment
+	ok, err := newIdempotency(factory, time.Hour, s.key.Operation, s.key.Key, "toshik1978").
+		replay(context.Background(), &response)
+
+	s.NoError(err)
+	s.True(ok)
+	s.Equal("toshik1978", response.UID)
+}
+
+func (s *idempotencyTestSuite) TestStoreNoKeySucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	factory := mock.NewMockFactory(ctrl)
+
+	err := newIdempotency(factory, time.Hour, s.key.Operation, "", "toshik1978").
+		store(context.Background(), handler.Payment{UID: "toshik1978"})
+
+	s.NoError(err)
+}
+
+func (s *idempotencyTestSuite) TestStoreExistsFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	idempotencyRepository := mock.NewMockIdempotencyRepository(ctrl)
+	idempotencyRepository.
+		EXPECT().
+		Store(gomock.Any(), gomock.Any()).
+		Return(repository.ErrIdempotencyKeyExists)
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		IdempotencyRepository().
+		Return(idempotencyRepository)
+
+	err := newIdempotency(factory, time.Hour, s.key.Operation, s.key.Key, "toshik1978").
+		store(context.Background(), handler.Payment{UID: "toshik1978"})
+
+	var handlerError *handler.Error
+	s.Error(err)
+	s.True(errors.As(err, &handlerError))
+	s.Equal(handler.ConflictError, handlerError.Kind)
+}
+
+func (s *idempotencyTestSuite) TestStoreFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	idempotencyRepository := mock.NewMockIdempotencyRepository(ctrl)
+	idempotencyRepository.
+		EXPECT().
+		Store(gomock.Any(), gomock.Any()).
+		Return(errors.New("fail"))
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		IdempotencyRepository().
+		Return(idempotencyRepository)
+
+	err := newIdempotency(factory, time.Hour, s.key.Operation, s.key.Key, "toshik1978").
+		store(context.Background(), handler.Payment{UID: "toshik1978"})
+
+	var handlerError *handler.Error
+	s.Error(err)
+	s.True(errors.As(err, &handlerError))
+	s.Equal(handler.ServerError, handlerError.Kind)
+}
+
+func (s *idempotencyTestSuite) TestStoreSucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	expected := s.key
+	expected.Response = `{"id":0,"account":"toshik1978","direction":"","amount":"0","currency":"",` +
+		`"created_at":"0001-01-01T00:00:00Z"}`
+
+	idempotencyRepository := mock.NewMockIdempotencyRepository(ctrl)
+	idempotencyRepository.
+		EXPECT().
+		Store(gomock.Any(), testutil.EqualRepositoryIdempotencyKey(expected)).
+		Return(nil)
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		IdempotencyRepository().
+		Return(idempotencyRepository)
+
+	err := newIdempotency(factory, 24*time.Hour, s.key.Operation, s.key.Key, "toshik1978").
+		store(context.Background(), handler.Payment{UID: "toshik1978"})
+
+	s.NoError(err)
+}
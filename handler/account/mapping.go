@@ -1,62 +1,337 @@
 package account
 
 import (
+	"errors"
+	"math/big"
+	"strings"
+
 	"github.com/AlekSi/pointer"
 	"github.com/Toshik1978/go-rest-api/handler"
 	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service"
+	"github.com/Toshik1978/go-rest-api/service/money"
 )
 
 const (
 	outgoingPayment = "outgoing"
 	incomingPayment = "incoming"
+
+	partiallyRefundedPayment = "partially_refunded"
+	refundedPayment          = "refunded"
+
+	// defaultExponent used for currencies, which are absent in registry
+	defaultExponent = 2
+	// rateScale define precision of the stored exchange rate
+	rateScale = 15
 )
 
+// currencyExponent return minor units exponent for the given currency
+func currencyExponent(registry service.CurrencyRegistry, code string) int {
+	if currency, ok := registry.Currency(code); ok {
+		return currency.Exponent
+	}
+	return defaultExponent
+}
+
+// exchangeMinorUnits converts minor units of source currency to minor units of target currency by the given rate.
+// Big rationals used to avoid any precision loss, result is rounded half away from zero
+func exchangeMinorUnits(amount int64, rate *big.Rat, sourceExponent int, targetExponent int) (int64, error) {
+	result := new(big.Rat).Mul(new(big.Rat).SetInt64(amount), rate)
+	if targetExponent > sourceExponent {
+		result.Mul(result, new(big.Rat).SetInt(pow10(targetExponent-sourceExponent)))
+	} else if targetExponent < sourceExponent {
+		result.Quo(result, new(big.Rat).SetInt(pow10(sourceExponent-targetExponent)))
+	}
+
+	// Round half away from zero: trunc(|x| + 1/2) with sign restored
+	abs := new(big.Rat).Abs(result)
+	abs.Add(abs, big.NewRat(1, 2))
+	rounded := new(big.Int).Quo(abs.Num(), abs.Denom())
+	if result.Sign() < 0 {
+		rounded.Neg(rounded)
+	}
+	if !rounded.IsInt64() {
+		return 0, errors.New("exchanged amount overflow")
+	}
+	return rounded.Int64(), nil
+}
+
+// proportionalAmount return part of the total, proportional to part of the whole, rounded down.
+// Whole should be positive
+func proportionalAmount(total int64, part int64, whole int64) int64 {
+	result := new(big.Int).Mul(big.NewInt(total), big.NewInt(part))
+	return result.Quo(result, big.NewInt(whole)).Int64()
+}
+
+// pow10 return 10^n as big integer
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// formatRate formats exchange rate to store it
+func formatRate(rate *big.Rat) string {
+	return trimDecimal(rate.FloatString(rateScale))
+}
+
+// trimDecimal trims insignificant zeros of the decimal string
+func trimDecimal(value string) string {
+	if !strings.Contains(value, ".") {
+		return value
+	}
+	return strings.TrimSuffix(strings.TrimRight(value, "0"), ".")
+}
+
 // mapRepositoryAccount maps repository account model to API
-func mapRepositoryAccount(account repository.Account) *handler.Account {
+func mapRepositoryAccount(account repository.Account, registry service.CurrencyRegistry) *handler.Account {
 	return &handler.Account{
-		UID:       account.UID,
-		Currency:  account.Currency,
-		Balance:   float64(account.Balance) / 100,
-		CreatedAt: account.CreatedAt,
+		UID:             account.UID,
+		Currency:        account.Currency,
+		Balance:         money.FromMinorUnits(account.Balance, currencyExponent(registry, account.Currency)),
+		Status:          string(account.Status),
+		StatusChangedAt: account.StatusChangedAt,
+		CreatedAt:       account.CreatedAt,
 	}
 }
 
 // mapRepositoryAccounts maps multiple repository account models to API
-func mapRepositoryAccounts(accounts []repository.Account) []handler.Account {
+func mapRepositoryAccounts(accounts []repository.Account, registry service.CurrencyRegistry) []handler.Account {
 	results := make([]handler.Account, 0, len(accounts))
 	for _, account := range accounts {
-		results = append(results, *mapRepositoryAccount(account))
+		results = append(results, *mapRepositoryAccount(account, registry))
+	}
+	return results
+}
+
+// mapRepositoryDrift maps repository drift model to API
+func mapRepositoryDrift(drift repository.Drift, registry service.CurrencyRegistry) *handler.Drift {
+	exponent := currencyExponent(registry, drift.Currency)
+	return &handler.Drift{
+		UID:             drift.AccountUID,
+		Currency:        drift.Currency,
+		ExpectedBalance: money.FromMinorUnits(drift.ExpectedBalance, exponent),
+		ActualBalance:   money.FromMinorUnits(drift.ActualBalance, exponent),
+	}
+}
+
+// mapRepositoryDrifts maps multiple repository drift models to API
+func mapRepositoryDrifts(drifts []repository.Drift, registry service.CurrencyRegistry) []handler.Drift {
+	results := make([]handler.Drift, 0, len(drifts))
+	for _, drift := range drifts {
+		results = append(results, *mapRepositoryDrift(drift, registry))
 	}
 	return results
 }
 
-// mapRepositoryPayment maps repository payment model to API
-func mapRepositoryPayment(payment repository.Payment) *handler.Payment {
-	if payment.Amount >= 0 {
-		return &handler.Payment{
-			UID:       payment.PayerAccountUID,
-			SourceUID: nil,
-			TargetUID: pointer.ToString(payment.RecipientAccountUID),
-			Direction: outgoingPayment,
-			Amount:    float64(payment.Amount) / 100,
-			CreatedAt: payment.CreatedAt,
-		}
+// mapRepositoryExchange maps exchange details of repository transfer model to API
+func mapRepositoryExchange(transfer repository.Transfer, registry service.CurrencyRegistry) *handler.Exchange {
+	if transfer.SourceCurrency == transfer.TargetCurrency {
+		return nil
+	}
+	sourceExponent := currencyExponent(registry, transfer.SourceCurrency)
+	targetExponent := currencyExponent(registry, transfer.TargetCurrency)
+	return &handler.Exchange{
+		Rate:           trimDecimal(transfer.Rate),
+		SourceAmount:   money.FromMinorUnits(transfer.SourceAmount, sourceExponent),
+		SourceCurrency: transfer.SourceCurrency,
+		TargetAmount:   money.FromMinorUnits(transfer.TargetAmount, targetExponent),
+		TargetCurrency: transfer.TargetCurrency,
+	}
+}
+
+// mapRepositoryTransfer maps repository transfer model to data of API event
+func mapRepositoryTransfer(transfer repository.Transfer, registry service.CurrencyRegistry) *handler.PaymentEvent {
+	return &handler.PaymentEvent{
+		ID:           transfer.ID,
+		PayerUID:     transfer.PayerAccountUID,
+		RecipientUID: transfer.RecipientAccountUID,
+		Amount:       money.FromMinorUnits(transfer.SourceAmount, currencyExponent(registry, transfer.SourceCurrency)),
+		Currency:     transfer.SourceCurrency,
+		Exchange:     mapRepositoryExchange(transfer, registry),
+		RefundOf:     transfer.RefundOf,
+		CreatedAt:    transfer.CreatedAt,
+	}
+}
+
+// mapRepositoryEntry maps repository ledger entry to API payment.
+// Debit posting is outgoing payment of the payer, credit posting is incoming payment of the recipient
+func mapRepositoryEntry(entry repository.Entry, registry service.CurrencyRegistry) *handler.Payment {
+	posting := entry.Posting
+	exponent := currencyExponent(registry, posting.Currency)
+	payment := &handler.Payment{
+		ID:        entry.Transfer.ID,
+		UID:       posting.AccountUID,
+		Currency:  posting.Currency,
+		Exchange:  mapRepositoryExchange(entry.Transfer, registry),
+		RefundOf:  entry.Transfer.RefundOf,
+		CreatedAt: entry.Transfer.CreatedAt,
+	}
+	if posting.Amount < 0 {
+		payment.TargetUID = pointer.ToString(entry.Transfer.RecipientAccountUID)
+		payment.Direction = outgoingPayment
+		payment.Amount = money.FromMinorUnits(-posting.Amount, exponent)
+	} else {
+		payment.SourceUID = pointer.ToString(entry.Transfer.PayerAccountUID)
+		payment.Direction = incomingPayment
+		payment.Amount = money.FromMinorUnits(posting.Amount, exponent)
+	}
+	if entry.Transfer.RefundedAmount > 0 {
+		mapRefund(payment, entry, exponent)
+	}
+	return payment
+}
+
+// mapRefund maps refund status and refunded amount of the refunded entry. Refunded amount is stored
+// in payer's currency, recipient gets back the proportional part of its amount
+func mapRefund(payment *handler.Payment, entry repository.Entry, exponent int) {
+	transfer := entry.Transfer
+	payment.RefundStatus = partiallyRefundedPayment
+	if transfer.RefundedAmount >= transfer.SourceAmount {
+		payment.RefundStatus = refundedPayment
 	}
-	return &handler.Payment{
-		UID:       payment.PayerAccountUID,
-		SourceUID: pointer.ToString(payment.RecipientAccountUID),
-		TargetUID: nil,
-		Direction: incomingPayment,
-		Amount:    -float64(payment.Amount) / 100,
-		CreatedAt: payment.CreatedAt,
+	refundedAmount := transfer.RefundedAmount
+	if entry.Posting.Amount >= 0 {
+		refundedAmount = proportionalAmount(transfer.TargetAmount, refundedAmount, transfer.SourceAmount)
 	}
+	amount := money.FromMinorUnits(refundedAmount, exponent)
+	payment.RefundedAmount = &amount
 }
 
-// mapRepositoryPayments maps repository payment model to API
-func mapRepositoryPayments(payments []repository.Payment) []handler.Payment {
-	results := make([]handler.Payment, 0, len(payments))
-	for _, payment := range payments {
-		results = append(results, *mapRepositoryPayment(payment))
+// mapRepositoryEntries maps multiple repository ledger entries to API payments
+func mapRepositoryEntries(entries []repository.Entry, registry service.CurrencyRegistry) []handler.Payment {
+	results := make([]handler.Payment, 0, len(entries))
+	for _, entry := range entries {
+		results = append(results, *mapRepositoryEntry(entry, registry))
 	}
 	return results
 }
+
+// mapRepositoryWebhook maps repository webhook model to API. Secret is never mapped
+func mapRepositoryWebhook(webhook repository.Webhook) *handler.Webhook {
+	return &handler.Webhook{
+		ID:         webhook.ID,
+		URL:        webhook.URL,
+		EventType:  string(webhook.EventType),
+		AccountUID: webhook.AccountUID,
+		CreatedAt:  webhook.CreatedAt,
+	}
+}
+
+// mapRepositoryWebhooks maps multiple repository webhook models to API
+func mapRepositoryWebhooks(webhooks []repository.Webhook) []handler.Webhook {
+	results := make([]handler.Webhook, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		results = append(results, *mapRepositoryWebhook(webhook))
+	}
+	return results
+}
+
+// mapRepositoryDelivery maps repository delivery model to API
+func mapRepositoryDelivery(delivery repository.Delivery) *handler.Delivery {
+	result := &handler.Delivery{
+		ID:        delivery.ID,
+		WebhookID: delivery.WebhookID,
+		EventID:   delivery.EventID,
+		EventType: string(delivery.EventType),
+		Status:    string(delivery.Status),
+		Attempts:  delivery.Attempts,
+		CreatedAt: delivery.CreatedAt,
+		UpdatedAt: delivery.UpdatedAt,
+	}
+	if delivery.Status == repository.PendingDelivery {
+		result.NextAttemptAt = pointer.ToTime(delivery.NextAttemptAt)
+	}
+	return result
+}
+
+// mapRepositoryDeliveries maps multiple repository delivery models to API
+func mapRepositoryDeliveries(deliveries []repository.Delivery) []handler.Delivery {
+	results := make([]handler.Delivery, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		results = append(results, *mapRepositoryDelivery(delivery))
+	}
+	return results
+}
+
+// mapRepositoryDeliveryAttempts maps repository delivery attempt models to API
+func mapRepositoryDeliveryAttempts(attempts []repository.DeliveryAttempt) []handler.DeliveryAttempt {
+	results := make([]handler.DeliveryAttempt, 0, len(attempts))
+	for _, attempt := range attempts {
+		results = append(results, handler.DeliveryAttempt{
+			StatusCode: attempt.StatusCode,
+			Error:      attempt.Error,
+			DurationMS: attempt.DurationMS,
+			CreatedAt:  attempt.CreatedAt,
+		})
+	}
+	return results
+}
+
+// mapRepositorySchedule maps repository schedule model to API. Amount is given in payer's currency
+func mapRepositorySchedule(schedule repository.Schedule, registry service.CurrencyRegistry) *handler.Schedule {
+	result := &handler.Schedule{
+		ID:                  schedule.ID,
+		UID:                 schedule.PayerAccountUID,
+		RecipientUID:        schedule.RecipientAccountUID,
+		Amount:              money.FromMinorUnits(schedule.Amount, currencyExponent(registry, schedule.Currency)),
+		Currency:            schedule.Currency,
+		Recurrence:          string(schedule.Recurrence),
+		Cron:                schedule.Cron,
+		OnInsufficientFunds: string(schedule.OnInsufficientFunds),
+		Status:              string(schedule.Status),
+		StartAt:             schedule.StartAt,
+		CreatedAt:           schedule.CreatedAt,
+		UpdatedAt:           schedule.UpdatedAt,
+	}
+	if schedule.Status == repository.ActiveSchedule {
+		result.NextRunAt = pointer.ToTime(schedule.NextRunAt)
+	}
+	return result
+}
+
+// mapRepositorySchedules maps multiple repository schedule models to API
+func mapRepositorySchedules(schedules []repository.Schedule, registry service.CurrencyRegistry) []handler.Schedule {
+	results := make([]handler.Schedule, 0, len(schedules))
+	for _, schedule := range schedules {
+		results = append(results, *mapRepositorySchedule(schedule, registry))
+	}
+	return results
+}
+
+// mapRepositoryScheduleRun maps repository schedule run model to API
+func mapRepositoryScheduleRun(run repository.ScheduleRun) *handler.ScheduleRun {
+	result := &handler.ScheduleRun{
+		ID:          run.ID,
+		ScheduleID:  run.ScheduleID,
+		ScheduledAt: run.ScheduledAt,
+		Status:      string(run.Status),
+		Attempts:    run.Attempts,
+		Error:       run.Error,
+		CreatedAt:   run.CreatedAt,
+		UpdatedAt:   run.UpdatedAt,
+	}
+	if run.Status == repository.PendingRun {
+		result.NextAttemptAt = pointer.ToTime(run.NextAttemptAt)
+	}
+	return result
+}
+
+// mapRepositoryScheduleRuns maps multiple repository schedule run models to API
+func mapRepositoryScheduleRuns(runs []repository.ScheduleRun) []handler.ScheduleRun {
+	results := make([]handler.ScheduleRun, 0, len(runs))
+	for _, run := range runs {
+		results = append(results, *mapRepositoryScheduleRun(run))
+	}
+	return results
+}
+
+// mapServiceAccountEvent maps published account's event to API
+func mapServiceAccountEvent(event service.AccountEvent) handler.AccountEvent {
+	result := handler.AccountEvent{
+		Type: event.Type,
+		Data: event.Data,
+	}
+	if event.ID != 0 {
+		result.ID = encodeCursor(event.ID)
+	}
+	return result
+}
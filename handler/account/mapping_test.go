@@ -0,0 +1,156 @@
+package account
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service"
+	"github.com/Toshik1978/go-rest-api/service/testutil"
+	"github.com/stretchr/testify/suite"
+)
+
+type mappingTestSuite struct {
+	suite.Suite
+}
+
+func (s *mappingTestSuite) TestExchangeMinorUnitsSucceeded() {
+	for _, test := range []struct {
+		amount         int64
+		rate           *big.Rat
+		sourceExponent int
+		targetExponent int
+		expected       int64
+	}{
+		{amount: 10000, rate: big.NewRat(9, 10), sourceExponent: 2, targetExponent: 2, expected: 9000},
+		{amount: 10000, rate: big.NewRat(217, 2), sourceExponent: 2, targetExponent: 0, expected: 10850},
+		{amount: 1085, rate: big.NewRat(2, 217), sourceExponent: 0, targetExponent: 3, expected: 10000},
+		{amount: 1, rate: big.NewRat(1, 2), sourceExponent: 2, targetExponent: 2, expected: 1},
+		{amount: 1, rate: big.NewRat(1, 3), sourceExponent: 2, targetExponent: 2, expected: 0},
+		{amount: -1, rate: big.NewRat(1, 2), sourceExponent: 2, targetExponent: 2, expected: -1},
+	} {
+		actual, err := exchangeMinorUnits(test.amount, test.rate, test.sourceExponent, test.targetExponent)
+
+		s.NoError(err)
+		s.Equal(test.expected, actual)
+	}
+}
+
+func (s *mappingTestSuite) TestExchangeMinorUnitsOverflowFailed() {
+	_, err := exchangeMinorUnits(1<<62, big.NewRat(4, 1), 2, 2)
+
+	s.Error(err)
+}
+
+func (s *mappingTestSuite) TestFormatRateSucceeded() {
+	s.Equal("1", formatRate(big.NewRat(1, 1)))
+	s.Equal("0.9", formatRate(big.NewRat(9, 10)))
+	s.Equal("1.111111111111111", formatRate(big.NewRat(10, 9)))
+	s.Equal("108.5", trimDecimal("108.500000000000000"))
+	s.Equal("100", trimDecimal("100"))
+}
+
+func (s *mappingTestSuite) TestMapRepositoryEntrySucceeded() {
+	transfer := testutil.RepositoryTransfer()
+	entry := testutil.RepositoryEntry()
+
+	payment := mapRepositoryEntry(entry, testutil.CurrencyRegistry())
+
+	s.Equal(transfer.PayerAccountUID, payment.UID)
+	s.NotNil(payment.TargetUID)
+	s.Equal(transfer.RecipientAccountUID, *payment.TargetUID)
+	s.Nil(payment.SourceUID)
+	s.Equal(outgoingPayment, payment.Direction)
+	s.Equal("100.00", payment.Amount.String())
+	s.Nil(payment.Exchange)
+
+	entry.Posting = transfer.Postings[1]
+	payment = mapRepositoryEntry(entry, testutil.CurrencyRegistry())
+
+	s.Equal(transfer.RecipientAccountUID, payment.UID)
+	s.NotNil(payment.SourceUID)
+	s.Equal(transfer.PayerAccountUID, *payment.SourceUID)
+	s.Nil(payment.TargetUID)
+	s.Equal(incomingPayment, payment.Direction)
+	s.Equal("100.00", payment.Amount.String())
+	s.Empty(payment.RefundStatus)
+	s.Nil(payment.RefundedAmount)
+}
+
+func (s *mappingTestSuite) TestMapRepositoryEntryRefundedSucceeded() {
+	entry := testutil.RepositoryEntry()
+	entry.Transfer.SourceAmount = 1000
+	entry.Transfer.TargetCurrency = "JPY"
+	entry.Transfer.TargetAmount = 1085
+	entry.Transfer.RefundedAmount = 333
+
+	payment := mapRepositoryEntry(entry, testutil.CurrencyRegistry())
+
+	s.Equal(entry.Transfer.ID, payment.ID)
+	s.Equal(partiallyRefundedPayment, payment.RefundStatus)
+	s.Require().NotNil(payment.RefundedAmount)
+	s.Equal("3.33", payment.RefundedAmount.String())
+
+	entry.Posting = repository.Posting{AccountUID: entry.Transfer.RecipientAccountUID, Amount: 1085, Currency: "JPY"}
+	entry.Transfer.RefundedAmount = 1000
+	payment = mapRepositoryEntry(entry, testutil.CurrencyRegistry())
+
+	s.Equal(refundedPayment, payment.RefundStatus)
+	s.Require().NotNil(payment.RefundedAmount)
+	s.Equal("1085", payment.RefundedAmount.String())
+}
+
+func (s *mappingTestSuite) TestProportionalAmountSucceeded() {
+	s.Equal(int64(361), proportionalAmount(1085, 333, 1000))
+	s.Equal(int64(1085), proportionalAmount(1085, 1000, 1000))
+	s.Equal(int64(0), proportionalAmount(9, 1, 10))
+}
+
+func (s *mappingTestSuite) TestMapServiceAccountEventSucceeded() {
+	event := service.AccountEvent{
+		ID:         7,
+		AccountUID: "toshik1978",
+		Type:       paymentStreamEvent,
+		Data:       json.RawMessage(`{"amount":"100.00"}`),
+	}
+
+	result := mapServiceAccountEvent(event)
+
+	s.Equal(encodeCursor(7), result.ID)
+	s.Equal(paymentStreamEvent, result.Type)
+	s.Equal(event.Data, result.Data)
+
+	event.ID = 0
+	s.Empty(mapServiceAccountEvent(event).ID)
+}
+
+func (s *mappingTestSuite) TestMapRepositoryScheduleSucceeded() {
+	schedule := testutil.RepositorySchedule()
+
+	result := mapRepositorySchedule(schedule, testutil.CurrencyRegistry())
+
+	s.Equal(schedule.PayerAccountUID, result.UID)
+	s.Equal(schedule.RecipientAccountUID, result.RecipientUID)
+	s.Equal("10.00", result.Amount.String())
+	s.Equal("monthly", result.Recurrence)
+	s.Require().NotNil(result.NextRunAt)
+	s.True(schedule.NextRunAt.Equal(*result.NextRunAt))
+
+	// Inactive schedule has no next run
+	schedule.Status = repository.CancelledSchedule
+	s.Nil(mapRepositorySchedule(schedule, testutil.CurrencyRegistry()).NextRunAt)
+}
+
+func (s *mappingTestSuite) TestMapRepositoryScheduleRunSucceeded() {
+	run := testutil.RepositoryScheduleRun()
+
+	result := mapRepositoryScheduleRun(run)
+
+	s.Equal("pending", result.Status)
+	s.Require().NotNil(result.NextAttemptAt)
+	s.True(run.NextAttemptAt.Equal(*result.NextAttemptAt))
+
+	// Finished run isn't attempted again
+	run.Status = repository.SucceededRun
+	s.Nil(mapRepositoryScheduleRun(run).NextAttemptAt)
+}
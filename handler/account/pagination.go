@@ -0,0 +1,68 @@
+package account
+
+import (
+	"encoding/base64"
+	"strconv"
+
+	"github.com/Toshik1978/go-rest-api/handler"
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service/validator"
+)
+
+const (
+	// defaultPageLimit used, if page request doesn't declare limit
+	defaultPageLimit = 100
+	// maxPageLimit define the max allowed page size
+	maxPageLimit = 1000
+)
+
+// encodeCursor creates opaque cursor, which points to the record after the given one
+func encodeCursor(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(id, 10)))
+}
+
+// decodeCursor return ID of the record, cursor points after
+func decodeCursor(cursor string) (int64, bool) {
+	if cursor == "" {
+		return 0, true
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(string(payload), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return id, true
+}
+
+// repositoryPage validates page request and converts it to repository page.
+// Repository page requests one record more, than required, to detect existence of the next page
+func repositoryPage(page handler.PageRequest) (repository.Page, error) {
+	v := validator.NewValidator().ValidateLimit(page.Limit, maxPageLimit)
+	afterID, ok := decodeCursor(page.Cursor)
+	if !ok {
+		v.AddField("cursor", page.Cursor, "cursor from the previous page")
+	}
+	if err := v.Error(); err != nil {
+		return repository.Page{}, handler.WrapError(err, "failed to validate page", handler.ClientError)
+	}
+
+	limit := page.Limit
+	if limit == 0 {
+		limit = defaultPageLimit
+	}
+	return repository.Page{
+		AfterID: afterID,
+		Limit:   limit + 1,
+	}, nil
+}
+
+// pageSize return number of records to return and whether the next page exists
+func pageSize(page repository.Page, count int) (int, bool) {
+	if count < page.Limit {
+		return count, false
+	}
+	return page.Limit - 1, true
+}
@@ -0,0 +1,55 @@
+package account
+
+import (
+	"github.com/Toshik1978/go-rest-api/handler"
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/stretchr/testify/suite"
+)
+
+type paginationTestSuite struct {
+	suite.Suite
+}
+
+func (s *paginationTestSuite) TestDecodeCursorFailed() {
+	for _, cursor := range []string{"?", "YWJj", "LTE", "MA"} {
+		_, ok := decodeCursor(cursor)
+		s.False(ok, cursor)
+	}
+}
+
+func (s *paginationTestSuite) TestDecodeCursorSucceeded() {
+	id, ok := decodeCursor("")
+	s.True(ok)
+	s.Equal(int64(0), id)
+
+	id, ok = decodeCursor(encodeCursor(1234))
+	s.True(ok)
+	s.Equal(int64(1234), id)
+}
+
+func (s *paginationTestSuite) TestRepositoryPageFailed() {
+	for _, page := range []handler.PageRequest{{Limit: -1}, {Limit: maxPageLimit + 1}, {Cursor: "?"}} {
+		_, err := repositoryPage(page)
+		s.Error(err)
+	}
+}
+
+func (s *paginationTestSuite) TestRepositoryPageSucceeded() {
+	page, err := repositoryPage(handler.PageRequest{})
+	s.NoError(err)
+	s.Equal(repository.Page{Limit: defaultPageLimit + 1}, page)
+
+	page, err = repositoryPage(handler.PageRequest{Limit: 10, Cursor: encodeCursor(1234)})
+	s.NoError(err)
+	s.Equal(repository.Page{AfterID: 1234, Limit: 11}, page)
+}
+
+func (s *paginationTestSuite) TestPageSizeSucceeded() {
+	count, more := pageSize(repository.Page{Limit: 11}, 5)
+	s.Equal(5, count)
+	s.False(more)
+
+	count, more = pageSize(repository.Page{Limit: 11}, 11)
+	s.Equal(10, count)
+	s.True(more)
+}
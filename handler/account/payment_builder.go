@@ -2,12 +2,15 @@ package account
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/Toshik1978/go-rest-api/service/errutil"
 
 	"github.com/Toshik1978/go-rest-api/handler"
 	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service"
+	"github.com/Toshik1978/go-rest-api/service/money"
 	"github.com/Toshik1978/go-rest-api/service/server"
 	"github.com/Toshik1978/go-rest-api/service/validator"
 	"go.uber.org/zap"
@@ -17,9 +20,17 @@ import (
 type paymentBuilder struct {
 	logger            *zap.Logger
 	repositoryFactory repository.Factory
+	currencyRegistry  service.CurrencyRegistry
+	fxRateProvider    service.FXRateProvider
+	eventBroker       service.EventBroker
+	idempotencyTTL    time.Duration
 
-	payment repository.Payment
-	v       *validator.Validator
+	transfer       repository.Transfer
+	payer          *repository.Account
+	recipient      *repository.Account
+	amount         money.Amount
+	idempotencyKey string
+	v              *validator.Validator
 }
 
 // newPaymentBuilder creates new PaymentBuilder implementation
@@ -27,35 +38,60 @@ func newPaymentBuilder(globals server.Globals) handler.PaymentBuilder {
 	return &paymentBuilder{
 		logger:            globals.Logger,
 		repositoryFactory: globals.RepositoryFactory,
-		payment:           repository.Payment{CreatedAt: time.Now()},
+		currencyRegistry:  globals.CurrencyRegistry,
+		fxRateProvider:    globals.FXRateProvider,
+		eventBroker:       globals.EventBroker,
+		idempotencyTTL:    globals.IdempotencyTTL,
+		transfer:          repository.Transfer{CreatedAt: time.Now()},
 		v:                 validator.NewValidator(),
 	}
 }
 
-func (b *paymentBuilder) SetAmount(amount float64) handler.PaymentBuilder {
-	b.payment.Amount = int64(amount * 100)
+func (b *paymentBuilder) SetAmount(amount money.Amount) handler.PaymentBuilder {
+	b.amount = amount
 	return b
 }
 
 func (b *paymentBuilder) SetPayer(uid string) handler.PaymentBuilder {
-	b.payment.PayerAccountUID = uid
+	b.transfer.PayerAccountUID = uid
 	return b
 }
 
 func (b *paymentBuilder) SetRecipient(uid string) handler.PaymentBuilder {
-	b.payment.RecipientAccountUID = uid
+	b.transfer.RecipientAccountUID = uid
+	return b
+}
+
+func (b *paymentBuilder) SetIdempotencyKey(key string) handler.PaymentBuilder {
+	b.idempotencyKey = key
 	return b
 }
 
 func (b *paymentBuilder) Build(ctx context.Context) (*handler.Payment, error) {
 	b.v.
-		ValidateAmount(float64(b.payment.Amount)/100).
-		ValidateUID("payer_uid", b.payment.PayerAccountUID).
-		ValidateUID("recipient_uid", b.payment.RecipientAccountUID)
+		ValidateAmount(b.amount).
+		ValidateUID("payer_uid", b.transfer.PayerAccountUID).
+		ValidateUID("recipient_uid", b.transfer.RecipientAccountUID).
+		ValidateIdempotencyKey(b.idempotencyKey)
 	if err := b.v.Error(); err != nil {
 		return nil, handler.WrapError(err, "failed to validate payment", handler.ClientError)
 	}
 
+	// Payment is re-run from scratch, if it's failed because of concurrent payments
+	var payment *handler.Payment
+	err := b.repositoryFactory.Retry(ctx, func(ctx context.Context) error {
+		var err error
+		payment, err = b.build(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+// build creates payment in the new scope
+func (b *paymentBuilder) build(ctx context.Context) (*handler.Payment, error) {
 	scope := b.repositoryFactory.Scope()
 	ctx, err := scope.WithContext(ctx)
 	if err != nil {
@@ -64,56 +100,213 @@ func (b *paymentBuilder) Build(ctx context.Context) (*handler.Payment, error) {
 	// Here we can defer Cancel operation, because it's safe
 	defer func() { _ = scope.Cancel(ctx) }()
 
-	// We should create new payment and update balance for accounts
-	if err := b.storePayment(ctx); err != nil {
-		return nil, handler.WrapError(err, "failed to create payment", handler.ServerError)
+	// Repeated request with the same idempotency key gets the original response
+	idempotency := newIdempotency(b.repositoryFactory, b.idempotencyTTL, paymentOperation, b.idempotencyKey,
+		b.transfer.PayerAccountUID, b.transfer.RecipientAccountUID, trimDecimal(b.amount.String()))
+	var replayed handler.Payment
+	ok, err := idempotency.replay(ctx, &replayed)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return &replayed, nil
+	}
+
+	// Amount is always in payer's currency, recipient receives amount in it's own currency
+	if err := b.resolveAmounts(ctx); err != nil {
+		return nil, err
+	}
+	if err := b.storeTransfer(ctx, repository.PaymentCompletedEvent); err != nil {
+		return nil, err
 	}
-	if err := b.updateBalance(ctx); err != nil {
-		return nil, handler.WrapError(err, "failed to update balance", handler.ServerError)
+	// Payer's debit posting describes payment from the payer's point of view
+	payment := mapRepositoryEntry(repository.Entry{
+		Posting:  b.transfer.Postings[0],
+		Transfer: b.transfer,
+	}, b.currencyRegistry)
+	if err := idempotency.store(ctx, payment); err != nil {
+		return nil, err
 	}
 
 	// Complete scope
 	if err := scope.Complete(ctx); err != nil {
 		return nil, errutil.Wrap(err, "failed to complete repository scope")
 	}
-	return mapRepositoryPayment(b.payment), nil
+	// Subscribers should never see payment, which isn't committed
+	b.publishEvents()
+	return payment, nil
 }
 
-// storePayment store payments in storage
-// For finance purpose is not bad idea to store always 2 transactions per payment:
-// 1. Incoming - payment to recipient with positive amount
-// 2. Outgoing - recipient to payment with negative amount
-// It cause more simple balance calculation operations
-func (b *paymentBuilder) storePayment(ctx context.Context) error {
-	if err := b.repositoryFactory.PaymentRepository().Store(ctx, &b.payment); err != nil {
+// storeTransfer creates new transfer with postings, updates balance for accounts
+// and stores event of the given type about it
+func (b *paymentBuilder) storeTransfer(ctx context.Context, eventType repository.EventType) error {
+	b.transfer.Postings = b.postings()
+	err := b.repositoryFactory.LedgerRepository().Store(ctx, &b.transfer)
+	if errors.Is(err, repository.ErrAccountNotFound) {
+		return handler.WrapError(err, "failed to create payment", handler.NotFoundError)
+	}
+	if err != nil {
+		return handler.WrapError(err, "failed to create payment", handler.ServerError)
+	}
+	err = b.updateBalance(ctx)
+	if errors.Is(err, repository.ErrInsufficientFunds) {
+		return handler.WrapError(err,
+			"insufficient funds on account "+b.transfer.PayerAccountUID, handler.UnprocessableError)
+	}
+	if errors.Is(err, repository.ErrAccountNotActive) {
+		return handler.WrapError(err, "account of the payment is not active", handler.UnprocessableError)
+	}
+	if err != nil {
+		return handler.WrapError(err, "failed to update balance", handler.ServerError)
+	}
+	return storeEvent(ctx, b.repositoryFactory, eventType,
+		mapRepositoryTransfer(b.transfer, b.currencyRegistry), b.transfer.CreatedAt)
+}
+
+// resolveAmounts locks accounts of the payment, scales payment's amount to minor units of payer's currency
+// and converts it to recipient's currency, if currencies are different
+func (b *paymentBuilder) resolveAmounts(ctx context.Context) error {
+	payer, recipient, err := b.lockAccounts(ctx)
+	if err != nil {
 		return err
 	}
-	if err := b.repositoryFactory.PaymentRepository().Store(ctx, b.reversePayment()); err != nil {
+	if err := activeAccount(*payer, "payer"); err != nil {
 		return err
 	}
+	if err := activeAccount(*recipient, "recipient"); err != nil {
+		return err
+	}
+	b.payer, b.recipient = payer, recipient
+
+	sourceExponent := currencyExponent(b.currencyRegistry, payer.Currency)
+	targetExponent := currencyExponent(b.currencyRegistry, recipient.Currency)
+
+	// Precision of amount depends on payer's currency, so we can check it only here
+	if err := validator.NewValidator().ValidatePrecision("amount", b.amount, sourceExponent).Error(); err != nil {
+		return handler.WrapError(err, "failed to validate payment", handler.ClientError)
+	}
+	amount, err := b.amount.MinorUnits(sourceExponent)
+	if err != nil {
+		return handler.WrapError(err, "failed to validate payment", handler.ClientError)
+	}
+	// Payer is locked, so balance can't be changed concurrently till the end of the scope
+	if payer.Balance < amount {
+		return handler.WrapError(repository.ErrInsufficientFunds,
+			"insufficient funds on account "+payer.UID, handler.UnprocessableError)
+	}
+
+	rate, err := b.fxRateProvider.Rate(ctx, payer.Currency, recipient.Currency)
+	if errors.Is(err, service.ErrRateNotFound) {
+		return handler.WrapError(err,
+			"failed to exchange "+payer.Currency+" to "+recipient.Currency, handler.ClientError)
+	}
+	if err != nil {
+		return handler.WrapError(err, "failed to get exchange rate", handler.ServerError)
+	}
+
+	b.transfer.SourceAmount = amount
+	b.transfer.SourceCurrency = payer.Currency
+	b.transfer.TargetCurrency = recipient.Currency
+	b.transfer.Rate = formatRate(rate)
+	b.transfer.TargetAmount, err = exchangeMinorUnits(amount, rate, sourceExponent, targetExponent)
+	if err != nil {
+		return handler.WrapError(err, "failed to exchange amount", handler.ClientError)
+	}
+	// Recipient should get something, otherwise payer loses money
+	if b.transfer.TargetAmount <= 0 {
+		v := validator.NewValidator()
+		v.AddField("amount", b.amount.String(), "amount worth at least one minor unit of "+recipient.Currency)
+		return handler.WrapError(v.Error(), "amount too small to exchange to "+recipient.Currency, handler.ClientError)
+	}
 	return nil
 }
 
-// reversePayment create reverse payment from current payment
-func (b *paymentBuilder) reversePayment() *repository.Payment {
-	payment := b.payment
-	payment.PayerAccountUID = b.payment.RecipientAccountUID
-	payment.RecipientAccountUID = b.payment.PayerAccountUID
-	payment.Amount = -b.payment.Amount
-	return &payment
+// lockAccounts locks payer's and recipient's accounts till the end of the scope and return them.
+// Accounts are locked in UID order, so concurrent payments in opposite directions can't deadlock
+func (b *paymentBuilder) lockAccounts(ctx context.Context) (*repository.Account, *repository.Account, error) {
+	accounts, err := b.repositoryFactory.AccountRepository().
+		LockByUIDs(ctx, []string{b.transfer.PayerAccountUID, b.transfer.RecipientAccountUID})
+	if err != nil {
+		return nil, nil, handler.WrapError(err, "failed to lock accounts", handler.ServerError)
+	}
+
+	var payer, recipient *repository.Account
+	for i := range accounts {
+		if accounts[i].UID == b.transfer.PayerAccountUID {
+			payer = &accounts[i]
+		}
+		if accounts[i].UID == b.transfer.RecipientAccountUID {
+			recipient = &accounts[i]
+		}
+	}
+	if payer == nil {
+		return nil, nil, handler.WrapError(repository.ErrAccountNotFound,
+			"failed to get payer account "+b.transfer.PayerAccountUID, handler.NotFoundError)
+	}
+	if recipient == nil {
+		return nil, nil, handler.WrapError(repository.ErrAccountNotFound,
+			"failed to get recipient account "+b.transfer.RecipientAccountUID, handler.NotFoundError)
+	}
+	return payer, recipient, nil
+}
+
+// postings creates balanced postings of the transfer: payer is debited in payer's currency
+// and recipient is credited in recipient's currency. Currency exchange is done through internal FX account,
+// which takes payer's currency and gives recipient's one, so postings are balanced in each currency
+func (b *paymentBuilder) postings() []repository.Posting {
+	postings := []repository.Posting{{
+		AccountUID: b.transfer.PayerAccountUID,
+		Amount:     -b.transfer.SourceAmount,
+		Currency:   b.transfer.SourceCurrency,
+	}}
+	if b.transfer.SourceCurrency != b.transfer.TargetCurrency {
+		postings = append(postings, repository.Posting{
+			AccountUID: repository.FXAccountUID,
+			Amount:     b.transfer.SourceAmount,
+			Currency:   b.transfer.SourceCurrency,
+		}, repository.Posting{
+			AccountUID: repository.FXAccountUID,
+			Amount:     -b.transfer.TargetAmount,
+			Currency:   b.transfer.TargetCurrency,
+		})
+	}
+	return append(postings, repository.Posting{
+		AccountUID: b.transfer.RecipientAccountUID,
+		Amount:     b.transfer.TargetAmount,
+		Currency:   b.transfer.TargetCurrency,
+	})
 }
 
 // updateBalance updates balances in storage
 func (b *paymentBuilder) updateBalance(ctx context.Context) error {
 	if err := b.repositoryFactory.AccountRepository().
-		UpdateBalance(ctx, b.payment.PayerAccountUID, -b.payment.Amount); err != nil {
+		UpdateBalance(ctx, b.transfer.PayerAccountUID, -b.transfer.SourceAmount); err != nil {
 
 		return err
 	}
 	if err := b.repositoryFactory.AccountRepository().
-		UpdateBalance(ctx, b.payment.RecipientAccountUID, b.payment.Amount); err != nil {
+		UpdateBalance(ctx, b.transfer.RecipientAccountUID, b.transfer.TargetAmount); err != nil {
 
 		return err
 	}
 	return nil
 }
+
+// publishEvents publishes payment and new balance to event streams of payer and recipient.
+// Accounts were locked till the end of the scope, so their new balances are known exactly
+func (b *paymentBuilder) publishEvents() {
+	b.payer.Balance -= b.transfer.SourceAmount
+	b.recipient.Balance += b.transfer.TargetAmount
+
+	// Payer's debit and recipient's credit postings are the first and the last ones
+	postings := []repository.Posting{b.transfer.Postings[0], b.transfer.Postings[len(b.transfer.Postings)-1]}
+	for i, account := range []*repository.Account{b.payer, b.recipient} {
+		payment := mapRepositoryEntry(repository.Entry{
+			Posting:  postings[i],
+			Transfer: b.transfer,
+		}, b.currencyRegistry)
+		publishEvent(b.logger, b.eventBroker, account.UID, paymentStreamEvent, postings[i].ID, payment)
+		publishEvent(b.logger, b.eventBroker, account.UID, balanceStreamEvent, 0,
+			mapRepositoryAccount(*account, b.currencyRegistry))
+	}
+}
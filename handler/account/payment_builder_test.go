@@ -3,11 +3,18 @@ package account
 import (
 	"context"
 	"errors"
+	"math/big"
+	"time"
 
+	"github.com/Toshik1978/go-rest-api/handler"
 	"github.com/Toshik1978/go-rest-api/mock"
 	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service"
+	"github.com/Toshik1978/go-rest-api/service/fx"
+	"github.com/Toshik1978/go-rest-api/service/money"
 	"github.com/Toshik1978/go-rest-api/service/server"
 	"github.com/Toshik1978/go-rest-api/service/testutil"
+	"github.com/Toshik1978/go-rest-api/service/validator"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/suite"
 	"go.uber.org/zap"
@@ -18,16 +25,18 @@ import (
 type paymentBuilderTestSuite struct {
 	suite.Suite
 
-	payments []repository.Payment
+	payer          repository.Account
+	recipient      repository.Account
+	transfer       repository.Transfer
+	fxRateProvider service.FXRateProvider
 }
 
 func (s *paymentBuilderTestSuite) SetupSuite() {
-	payment1 := testutil.RepositoryPayment()
-	payment2 := payment1
-	payment2.PayerAccountUID = payment1.RecipientAccountUID
-	payment2.RecipientAccountUID = payment1.PayerAccountUID
-	payment2.Amount = -payment1.Amount
-	s.payments = []repository.Payment{payment1, payment2}
+	s.payer = testutil.RepositoryAccount()
+	s.recipient = testutil.RepositoryAccount()
+	s.recipient.UID = "toshik1979"
+	s.fxRateProvider, _ = fx.NewFileRateProvider(server.Vars{})
+	s.transfer = testutil.RepositoryTransfer()
 }
 
 func (s *paymentBuilderTestSuite) TestPaymentBuilderValidationFailed() {
@@ -36,12 +45,13 @@ func (s *paymentBuilderTestSuite) TestPaymentBuilderValidationFailed() {
 
 	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
 	builder := newPaymentBuilder(server.Globals{
-		Logger: zap.New(zapCore),
+		Logger:           zap.New(zapCore),
+		CurrencyRegistry: testutil.CurrencyRegistry(),
 	})
 
 	payment, err := builder.
-		SetRecipient(s.payments[0].RecipientAccountUID).
-		SetAmount(float64(s.payments[0].Amount) / 100).
+		SetRecipient(s.transfer.RecipientAccountUID).
+		SetAmount(money.FromMinorUnits(s.transfer.SourceAmount, 2)).
 		Build(context.Background())
 
 	s.Error(err)
@@ -60,21 +70,590 @@ func (s *paymentBuilderTestSuite) TestPaymentBuilderWithContextFailed() {
 		Return(nil, errors.New("fail"))
 
 	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		Retry(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, work func(ctx context.Context) error) error {
+			return work(ctx)
+		})
+	factory.
+		EXPECT().
+		Scope().
+		Return(scope)
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	builder := newPaymentBuilder(server.Globals{
+		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		FXRateProvider:    s.fxRateProvider,
+		RepositoryFactory: factory,
+	})
+
+	payment, err := builder.
+		SetPayer(s.transfer.PayerAccountUID).
+		SetRecipient(s.transfer.RecipientAccountUID).
+		SetAmount(money.FromMinorUnits(s.transfer.SourceAmount, 2)).
+		Build(context.Background())
+
+	s.Error(err)
+	s.Nil(payment)
+	s.Equal(0, zapRecorded.Len())
+}
+
+func (s *paymentBuilderTestSuite) TestPaymentBuilderLockAccountsFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	scope := mock.NewMockScope(ctrl)
+	scope.
+		EXPECT().
+		WithContext(gomock.Any()).
+		Return(context.Background(), nil)
+	scope.
+		EXPECT().
+		Cancel(gomock.Any()).
+		Return(nil)
+
+	accountRepository := mock.NewMockAccountRepository(ctrl)
+	accountRepository.
+		EXPECT().
+		LockByUIDs(gomock.Any(), gomock.Eq([]string{s.transfer.PayerAccountUID, s.transfer.RecipientAccountUID})).
+		Return(nil, errors.New("fail"))
+
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		Retry(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, work func(ctx context.Context) error) error {
+			return work(ctx)
+		})
+	factory.
+		EXPECT().
+		Scope().
+		Return(scope)
+	factory.
+		EXPECT().
+		AccountRepository().
+		Return(accountRepository)
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	builder := newPaymentBuilder(server.Globals{
+		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		FXRateProvider:    s.fxRateProvider,
+		RepositoryFactory: factory,
+	})
+
+	payment, err := builder.
+		SetPayer(s.transfer.PayerAccountUID).
+		SetRecipient(s.transfer.RecipientAccountUID).
+		SetAmount(money.FromMinorUnits(s.transfer.SourceAmount, 2)).
+		Build(context.Background())
+
+	s.Error(err)
+	s.Nil(payment)
+	s.Equal(0, zapRecorded.Len())
+}
+
+func (s *paymentBuilderTestSuite) TestPaymentBuilderRecipientNotFoundFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	scope := mock.NewMockScope(ctrl)
+	scope.
+		EXPECT().
+		WithContext(gomock.Any()).
+		Return(context.Background(), nil)
+	scope.
+		EXPECT().
+		Cancel(gomock.Any()).
+		Return(nil)
+
+	accountRepository := mock.NewMockAccountRepository(ctrl)
+	accountRepository.
+		EXPECT().
+		LockByUIDs(gomock.Any(), gomock.Eq([]string{s.transfer.PayerAccountUID, s.transfer.RecipientAccountUID})).
+		Return([]repository.Account{s.payer}, nil)
+
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		Retry(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, work func(ctx context.Context) error) error {
+			return work(ctx)
+		})
+	factory.
+		EXPECT().
+		Scope().
+		Return(scope)
+	factory.
+		EXPECT().
+		AccountRepository().
+		Return(accountRepository)
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	builder := newPaymentBuilder(server.Globals{
+		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		FXRateProvider:    s.fxRateProvider,
+		RepositoryFactory: factory,
+	})
+
+	payment, err := builder.
+		SetPayer(s.transfer.PayerAccountUID).
+		SetRecipient(s.transfer.RecipientAccountUID).
+		SetAmount(money.FromMinorUnits(s.transfer.SourceAmount, 2)).
+		Build(context.Background())
+
+	var handlerError *handler.Error
+	s.Error(err)
+	s.True(errors.As(err, &handlerError))
+	s.Equal(handler.NotFoundError, handlerError.Kind)
+	s.Nil(payment)
+	s.Equal(0, zapRecorded.Len())
+}
+
+func (s *paymentBuilderTestSuite) TestPaymentBuilderRecipientFrozenFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	recipient := s.recipient
+	recipient.Status = repository.FrozenAccount
+
+	scope := mock.NewMockScope(ctrl)
+	scope.
+		EXPECT().
+		WithContext(gomock.Any()).
+		Return(context.Background(), nil)
+	scope.
+		EXPECT().
+		Cancel(gomock.Any()).
+		Return(nil)
+
+	accountRepository := mock.NewMockAccountRepository(ctrl)
+	accountRepository.
+		EXPECT().
+		LockByUIDs(gomock.Any(), gomock.Eq([]string{s.transfer.PayerAccountUID, s.transfer.RecipientAccountUID})).
+		Return([]repository.Account{s.payer, recipient}, nil)
+
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		Retry(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, work func(ctx context.Context) error) error {
+			return work(ctx)
+		})
+	factory.
+		EXPECT().
+		Scope().
+		Return(scope)
+	factory.
+		EXPECT().
+		AccountRepository().
+		Return(accountRepository)
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	builder := newPaymentBuilder(server.Globals{
+		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		FXRateProvider:    s.fxRateProvider,
+		RepositoryFactory: factory,
+	})
+
+	payment, err := builder.
+		SetPayer(s.transfer.PayerAccountUID).
+		SetRecipient(s.transfer.RecipientAccountUID).
+		SetAmount(money.FromMinorUnits(s.transfer.SourceAmount, 2)).
+		Build(context.Background())
+
+	var handlerError *handler.Error
+	s.Error(err)
+	s.True(errors.As(err, &handlerError))
+	s.Equal(handler.UnprocessableError, handlerError.Kind)
+	s.Nil(payment)
+	s.Equal(0, zapRecorded.Len())
+}
+
+func (s *paymentBuilderTestSuite) TestPaymentBuilderPrecisionFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	scope := mock.NewMockScope(ctrl)
+	scope.
+		EXPECT().
+		WithContext(gomock.Any()).
+		Return(context.Background(), nil)
+	scope.
+		EXPECT().
+		Cancel(gomock.Any()).
+		Return(nil)
+
+	accountRepository := mock.NewMockAccountRepository(ctrl)
+	accountRepository.
+		EXPECT().
+		LockByUIDs(gomock.Any(), gomock.Eq([]string{s.transfer.PayerAccountUID, s.transfer.RecipientAccountUID})).
+		Return([]repository.Account{s.payer, s.recipient}, nil)
+
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		Retry(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, work func(ctx context.Context) error) error {
+			return work(ctx)
+		})
+	factory.
+		EXPECT().
+		Scope().
+		Return(scope)
+	factory.
+		EXPECT().
+		AccountRepository().
+		Return(accountRepository)
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	builder := newPaymentBuilder(server.Globals{
+		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		FXRateProvider:    s.fxRateProvider,
+		RepositoryFactory: factory,
+	})
+
+	payment, err := builder.
+		SetPayer(s.transfer.PayerAccountUID).
+		SetRecipient(s.transfer.RecipientAccountUID).
+		SetAmount(money.MustParse("0.295")).
+		Build(context.Background())
+
+	s.Error(err)
+	s.Nil(payment)
+	s.Equal(0, zapRecorded.Len())
+}
+
+func (s *paymentBuilderTestSuite) TestPaymentBuilderNoRateFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	recipient := s.recipient
+	recipient.Currency = "EUR"
+
+	scope := mock.NewMockScope(ctrl)
+	scope.
+		EXPECT().
+		WithContext(gomock.Any()).
+		Return(context.Background(), nil)
+	scope.
+		EXPECT().
+		Cancel(gomock.Any()).
+		Return(nil)
+
+	accountRepository := mock.NewMockAccountRepository(ctrl)
+	accountRepository.
+		EXPECT().
+		LockByUIDs(gomock.Any(), gomock.Eq([]string{s.transfer.PayerAccountUID, s.transfer.RecipientAccountUID})).
+		Return([]repository.Account{s.payer, recipient}, nil)
+
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		Retry(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, work func(ctx context.Context) error) error {
+			return work(ctx)
+		})
+	factory.
+		EXPECT().
+		Scope().
+		Return(scope)
+	factory.
+		EXPECT().
+		AccountRepository().
+		Return(accountRepository)
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	builder := newPaymentBuilder(server.Globals{
+		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		FXRateProvider:    s.fxRateProvider,
+		RepositoryFactory: factory,
+	})
+
+	payment, err := builder.
+		SetPayer(s.transfer.PayerAccountUID).
+		SetRecipient(s.transfer.RecipientAccountUID).
+		SetAmount(money.FromMinorUnits(s.transfer.SourceAmount, 2)).
+		Build(context.Background())
+
+	var handlerError *handler.Error
+	s.Error(err)
+	s.True(errors.As(err, &handlerError))
+	s.Equal(handler.ClientError, handlerError.Kind)
+	s.Nil(payment)
+	s.Equal(0, zapRecorded.Len())
+}
+
+func (s *paymentBuilderTestSuite) TestPaymentBuilderTooSmallToExchangeFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	recipient := s.recipient
+	recipient.Currency = "JPY"
+
+	scope := mock.NewMockScope(ctrl)
+	scope.
+		EXPECT().
+		WithContext(gomock.Any()).
+		Return(context.Background(), nil)
+	scope.
+		EXPECT().
+		Cancel(gomock.Any()).
+		Return(nil)
+
+	// 0.01 USD are exchanged to 0.01 JPY, which is rounded to zero
+	fxRateProvider := mock.NewMockFXRateProvider(ctrl)
+	fxRateProvider.
+		EXPECT().
+		Rate(gomock.Any(), gomock.Eq("USD"), gomock.Eq("JPY")).
+		Return(big.NewRat(1, 1), nil)
+
+	accountRepository := mock.NewMockAccountRepository(ctrl)
+	accountRepository.
+		EXPECT().
+		LockByUIDs(gomock.Any(), gomock.Eq([]string{s.transfer.PayerAccountUID, s.transfer.RecipientAccountUID})).
+		Return([]repository.Account{s.payer, recipient}, nil)
+
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		Retry(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, work func(ctx context.Context) error) error {
+			return work(ctx)
+		})
+	factory.
+		EXPECT().
+		Scope().
+		Return(scope)
+	factory.
+		EXPECT().
+		AccountRepository().
+		Return(accountRepository)
+
+	builder := newPaymentBuilder(server.Globals{
+		Logger:            zap.NewNop(),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		FXRateProvider:    fxRateProvider,
+		RepositoryFactory: factory,
+	})
+
+	payment, err := builder.
+		SetPayer(s.transfer.PayerAccountUID).
+		SetRecipient(s.transfer.RecipientAccountUID).
+		SetAmount(money.MustParse("0.01")).
+		Build(context.Background())
+
+	var handlerError *handler.Error
+	var validationError *validator.Error
+	s.True(errors.As(err, &handlerError))
+	s.Equal(handler.ClientError, handlerError.Kind)
+	s.True(errors.As(err, &validationError))
+	s.Equal("amount", validationError.Fields[0].Field)
+	s.Nil(payment)
+}
+
+func (s *paymentBuilderTestSuite) TestPaymentStoreFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	scope := mock.NewMockScope(ctrl)
+	scope.
+		EXPECT().
+		WithContext(gomock.Any()).
+		Return(context.Background(), nil)
+	scope.
+		EXPECT().
+		Cancel(gomock.Any()).
+		Return(nil)
+
+	accountRepository := mock.NewMockAccountRepository(ctrl)
+	accountRepository.
+		EXPECT().
+		LockByUIDs(gomock.Any(), gomock.Eq([]string{s.transfer.PayerAccountUID, s.transfer.RecipientAccountUID})).
+		Return([]repository.Account{s.payer, s.recipient}, nil)
+
+	ledgerRepository := mock.NewMockLedgerRepository(ctrl)
+	ledgerRepository.
+		EXPECT().
+		Store(gomock.Any(), testutil.EqualRepositoryTransfer(s.transfer)).
+		Return(errors.New("fail"))
+
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		Retry(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, work func(ctx context.Context) error) error {
+			return work(ctx)
+		})
+	factory.
+		EXPECT().
+		Scope().
+		Return(scope)
+	factory.
+		EXPECT().
+		AccountRepository().
+		Return(accountRepository)
+	factory.
+		EXPECT().
+		LedgerRepository().
+		Return(ledgerRepository)
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	builder := newPaymentBuilder(server.Globals{
+		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		FXRateProvider:    s.fxRateProvider,
+		RepositoryFactory: factory,
+	})
+
+	payment, err := builder.
+		SetPayer(s.transfer.PayerAccountUID).
+		SetRecipient(s.transfer.RecipientAccountUID).
+		SetAmount(money.FromMinorUnits(s.transfer.SourceAmount, 2)).
+		Build(context.Background())
+
+	s.Error(err)
+	s.Nil(payment)
+	s.Equal(0, zapRecorded.Len())
+}
+
+func (s *paymentBuilderTestSuite) TestPaymentBuilderStoreAccountNotFoundFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	scope := mock.NewMockScope(ctrl)
+	scope.
+		EXPECT().
+		WithContext(gomock.Any()).
+		Return(context.Background(), nil)
+	scope.
+		EXPECT().
+		Cancel(gomock.Any()).
+		Return(nil)
+
+	accountRepository := mock.NewMockAccountRepository(ctrl)
+	accountRepository.
+		EXPECT().
+		LockByUIDs(gomock.Any(), gomock.Eq([]string{s.transfer.PayerAccountUID, s.transfer.RecipientAccountUID})).
+		Return([]repository.Account{s.payer, s.recipient}, nil)
+
+	ledgerRepository := mock.NewMockLedgerRepository(ctrl)
+	ledgerRepository.
+		EXPECT().
+		Store(gomock.Any(), testutil.EqualRepositoryTransfer(s.transfer)).
+		Return(repository.ErrAccountNotFound)
+
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		Retry(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, work func(ctx context.Context) error) error {
+			return work(ctx)
+		})
+	factory.
+		EXPECT().
+		Scope().
+		Return(scope)
+	factory.
+		EXPECT().
+		AccountRepository().
+		Return(accountRepository)
+	factory.
+		EXPECT().
+		LedgerRepository().
+		Return(ledgerRepository)
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	builder := newPaymentBuilder(server.Globals{
+		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		FXRateProvider:    s.fxRateProvider,
+		RepositoryFactory: factory,
+	})
+
+	payment, err := builder.
+		SetPayer(s.transfer.PayerAccountUID).
+		SetRecipient(s.transfer.RecipientAccountUID).
+		SetAmount(money.FromMinorUnits(s.transfer.SourceAmount, 2)).
+		Build(context.Background())
+
+	var handlerError *handler.Error
+	s.Error(err)
+	s.True(errors.As(err, &handlerError))
+	s.Equal(handler.NotFoundError, handlerError.Kind)
+	s.Nil(payment)
+	s.Equal(0, zapRecorded.Len())
+}
+
+func (s *paymentBuilderTestSuite) TestPaymentBuilderUpdatePayerBalanceFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	scope := mock.NewMockScope(ctrl)
+	scope.
+		EXPECT().
+		WithContext(gomock.Any()).
+		Return(context.Background(), nil)
+	scope.
+		EXPECT().
+		Cancel(gomock.Any()).
+		Return(nil)
+
+	accountRepository := mock.NewMockAccountRepository(ctrl)
+	accountRepository.
+		EXPECT().
+		LockByUIDs(gomock.Any(), gomock.Eq([]string{s.transfer.PayerAccountUID, s.transfer.RecipientAccountUID})).
+		Return([]repository.Account{s.payer, s.recipient}, nil)
+	accountRepository.
+		EXPECT().
+		UpdateBalance(gomock.Any(), gomock.Eq(s.transfer.PayerAccountUID), gomock.Eq(-s.transfer.SourceAmount)).
+		Return(errors.New("fail"))
+
+	ledgerRepository := mock.NewMockLedgerRepository(ctrl)
+	ledgerRepository.
+		EXPECT().
+		Store(gomock.Any(), testutil.EqualRepositoryTransfer(s.transfer)).
+		Return(nil)
+
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		Retry(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, work func(ctx context.Context) error) error {
+			return work(ctx)
+		})
 	factory.
 		EXPECT().
 		Scope().
 		Return(scope)
+	factory.
+		EXPECT().
+		AccountRepository().
+		Return(accountRepository).
+		Times(2)
+	factory.
+		EXPECT().
+		LedgerRepository().
+		Return(ledgerRepository)
 
 	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
 	builder := newPaymentBuilder(server.Globals{
 		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		FXRateProvider:    s.fxRateProvider,
 		RepositoryFactory: factory,
 	})
 
 	payment, err := builder.
-		SetPayer(s.payments[0].PayerAccountUID).
-		SetRecipient(s.payments[0].RecipientAccountUID).
-		SetAmount(float64(s.payments[0].Amount) / 100).
+		SetPayer(s.transfer.PayerAccountUID).
+		SetRecipient(s.transfer.RecipientAccountUID).
+		SetAmount(money.FromMinorUnits(s.transfer.SourceAmount, 2)).
 		Build(context.Background())
 
 	s.Error(err)
@@ -82,7 +661,7 @@ func (s *paymentBuilderTestSuite) TestPaymentBuilderWithContextFailed() {
 	s.Equal(0, zapRecorded.Len())
 }
 
-func (s *paymentBuilderTestSuite) TestPaymentStoreFailed() {
+func (s *paymentBuilderTestSuite) TestPaymentBuilderInsufficientFundsFailed() {
 	ctrl := gomock.NewController(s.T())
 	defer ctrl.Finish()
 
@@ -96,40 +675,66 @@ func (s *paymentBuilderTestSuite) TestPaymentStoreFailed() {
 		Cancel(gomock.Any()).
 		Return(nil)
 
-	paymentRepository := mock.NewMockPaymentRepository(ctrl)
-	paymentRepository.
+	accountRepository := mock.NewMockAccountRepository(ctrl)
+	accountRepository.
+		EXPECT().
+		LockByUIDs(gomock.Any(), gomock.Eq([]string{s.transfer.PayerAccountUID, s.transfer.RecipientAccountUID})).
+		Return([]repository.Account{s.payer, s.recipient}, nil)
+	accountRepository.
+		EXPECT().
+		UpdateBalance(gomock.Any(), gomock.Eq(s.transfer.PayerAccountUID), gomock.Eq(-s.transfer.SourceAmount)).
+		Return(repository.ErrInsufficientFunds)
+
+	ledgerRepository := mock.NewMockLedgerRepository(ctrl)
+	ledgerRepository.
 		EXPECT().
-		Store(gomock.Any(), testutil.EqualRepositoryPayment(s.payments[0])).
-		Return(errors.New("fail"))
+		Store(gomock.Any(), testutil.EqualRepositoryTransfer(s.transfer)).
+		Return(nil)
 
 	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		Retry(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, work func(ctx context.Context) error) error {
+			return work(ctx)
+		})
 	factory.
 		EXPECT().
 		Scope().
 		Return(scope)
 	factory.
 		EXPECT().
-		PaymentRepository().
-		Return(paymentRepository)
+		AccountRepository().
+		Return(accountRepository).
+		Times(2)
+	factory.
+		EXPECT().
+		LedgerRepository().
+		Return(ledgerRepository)
 
 	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
 	builder := newPaymentBuilder(server.Globals{
 		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		FXRateProvider:    s.fxRateProvider,
 		RepositoryFactory: factory,
 	})
 
 	payment, err := builder.
-		SetPayer(s.payments[0].PayerAccountUID).
-		SetRecipient(s.payments[0].RecipientAccountUID).
-		SetAmount(float64(s.payments[0].Amount) / 100).
+		SetPayer(s.transfer.PayerAccountUID).
+		SetRecipient(s.transfer.RecipientAccountUID).
+		SetAmount(money.FromMinorUnits(s.transfer.SourceAmount, 2)).
 		Build(context.Background())
 
+	var handlerError *handler.Error
 	s.Error(err)
+	s.True(errors.As(err, &handlerError))
+	s.Equal(handler.UnprocessableError, handlerError.Kind)
 	s.Nil(payment)
 	s.Equal(0, zapRecorded.Len())
 }
 
-func (s *paymentBuilderTestSuite) TestPaymentBuilderStoreReverseFailed() {
+func (s *paymentBuilderTestSuite) TestPaymentBuilderInsufficientBalanceFailed() {
 	ctrl := gomock.NewController(s.T())
 	defer ctrl.Finish()
 
@@ -143,45 +748,127 @@ func (s *paymentBuilderTestSuite) TestPaymentBuilderStoreReverseFailed() {
 		Cancel(gomock.Any()).
 		Return(nil)
 
-	paymentRepository := mock.NewMockPaymentRepository(ctrl)
-	paymentRepository.
+	payer := s.payer
+	payer.Balance = s.transfer.SourceAmount - 1
+	accountRepository := mock.NewMockAccountRepository(ctrl)
+	accountRepository.
+		EXPECT().
+		LockByUIDs(gomock.Any(), gomock.Eq([]string{s.transfer.PayerAccountUID, s.transfer.RecipientAccountUID})).
+		Return([]repository.Account{payer, s.recipient}, nil)
+
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		Retry(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, work func(ctx context.Context) error) error {
+			return work(ctx)
+		})
+	factory.
+		EXPECT().
+		Scope().
+		Return(scope)
+	factory.
+		EXPECT().
+		AccountRepository().
+		Return(accountRepository)
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	builder := newPaymentBuilder(server.Globals{
+		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		FXRateProvider:    s.fxRateProvider,
+		RepositoryFactory: factory,
+	})
+
+	payment, err := builder.
+		SetPayer(s.transfer.PayerAccountUID).
+		SetRecipient(s.transfer.RecipientAccountUID).
+		SetAmount(money.FromMinorUnits(s.transfer.SourceAmount, 2)).
+		Build(context.Background())
+
+	var handlerError *handler.Error
+	s.Error(err)
+	s.True(errors.As(err, &handlerError))
+	s.Equal(handler.UnprocessableError, handlerError.Kind)
+	s.True(errors.Is(err, repository.ErrInsufficientFunds))
+	s.Nil(payment)
+	s.Equal(0, zapRecorded.Len())
+}
+
+func (s *paymentBuilderTestSuite) TestPaymentBuilderNotActiveFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	scope := mock.NewMockScope(ctrl)
+	scope.
 		EXPECT().
-		Store(gomock.Any(), testutil.EqualRepositoryPayment(s.payments[0])).
+		WithContext(gomock.Any()).
+		Return(context.Background(), nil)
+	scope.
+		EXPECT().
+		Cancel(gomock.Any()).
 		Return(nil)
-	paymentRepository.
+
+	accountRepository := mock.NewMockAccountRepository(ctrl)
+	accountRepository.
 		EXPECT().
-		Store(gomock.Any(), testutil.EqualRepositoryPayment(s.payments[1])).
-		Return(errors.New("fail"))
+		LockByUIDs(gomock.Any(), gomock.Eq([]string{s.transfer.PayerAccountUID, s.transfer.RecipientAccountUID})).
+		Return([]repository.Account{s.payer, s.recipient}, nil)
+	accountRepository.
+		EXPECT().
+		UpdateBalance(gomock.Any(), gomock.Eq(s.transfer.PayerAccountUID), gomock.Eq(-s.transfer.SourceAmount)).
+		Return(repository.ErrAccountNotActive)
+
+	ledgerRepository := mock.NewMockLedgerRepository(ctrl)
+	ledgerRepository.
+		EXPECT().
+		Store(gomock.Any(), testutil.EqualRepositoryTransfer(s.transfer)).
+		Return(nil)
 
 	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		Retry(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, work func(ctx context.Context) error) error {
+			return work(ctx)
+		})
 	factory.
 		EXPECT().
 		Scope().
 		Return(scope)
 	factory.
 		EXPECT().
-		PaymentRepository().
-		Return(paymentRepository).
+		AccountRepository().
+		Return(accountRepository).
 		Times(2)
+	factory.
+		EXPECT().
+		LedgerRepository().
+		Return(ledgerRepository)
 
 	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
 	builder := newPaymentBuilder(server.Globals{
 		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		FXRateProvider:    s.fxRateProvider,
 		RepositoryFactory: factory,
 	})
 
 	payment, err := builder.
-		SetPayer(s.payments[0].PayerAccountUID).
-		SetRecipient(s.payments[0].RecipientAccountUID).
-		SetAmount(float64(s.payments[0].Amount) / 100).
+		SetPayer(s.transfer.PayerAccountUID).
+		SetRecipient(s.transfer.RecipientAccountUID).
+		SetAmount(money.FromMinorUnits(s.transfer.SourceAmount, 2)).
 		Build(context.Background())
 
+	var handlerError *handler.Error
 	s.Error(err)
+	s.True(errors.As(err, &handlerError))
+	s.Equal(handler.UnprocessableError, handlerError.Kind)
 	s.Nil(payment)
 	s.Equal(0, zapRecorded.Len())
 }
 
-func (s *paymentBuilderTestSuite) TestPaymentBuilderUpdatePayerBalanceFailed() {
+func (s *paymentBuilderTestSuite) TestPaymentBuilderUpdateRecepientBalanceFailed() {
 	ctrl := gomock.NewController(s.T())
 	defer ctrl.Finish()
 
@@ -198,20 +885,30 @@ func (s *paymentBuilderTestSuite) TestPaymentBuilderUpdatePayerBalanceFailed() {
 	accountRepository := mock.NewMockAccountRepository(ctrl)
 	accountRepository.
 		EXPECT().
-		UpdateBalance(gomock.Any(), gomock.Eq(s.payments[0].PayerAccountUID), gomock.Eq(-s.payments[0].Amount)).
-		Return(errors.New("fail"))
-
-	paymentRepository := mock.NewMockPaymentRepository(ctrl)
-	paymentRepository.
+		LockByUIDs(gomock.Any(), gomock.Eq([]string{s.transfer.PayerAccountUID, s.transfer.RecipientAccountUID})).
+		Return([]repository.Account{s.payer, s.recipient}, nil)
+	accountRepository.
 		EXPECT().
-		Store(gomock.Any(), testutil.EqualRepositoryPayment(s.payments[0])).
+		UpdateBalance(gomock.Any(), gomock.Eq(s.transfer.PayerAccountUID), gomock.Eq(-s.transfer.SourceAmount)).
 		Return(nil)
-	paymentRepository.
+	accountRepository.
+		EXPECT().
+		UpdateBalance(gomock.Any(), gomock.Eq(s.transfer.RecipientAccountUID), gomock.Eq(s.transfer.TargetAmount)).
+		Return(errors.New("fail"))
+
+	ledgerRepository := mock.NewMockLedgerRepository(ctrl)
+	ledgerRepository.
 		EXPECT().
-		Store(gomock.Any(), testutil.EqualRepositoryPayment(s.payments[1])).
+		Store(gomock.Any(), testutil.EqualRepositoryTransfer(s.transfer)).
 		Return(nil)
 
 	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		Retry(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, work func(ctx context.Context) error) error {
+			return work(ctx)
+		})
 	factory.
 		EXPECT().
 		Scope().
@@ -219,23 +916,25 @@ func (s *paymentBuilderTestSuite) TestPaymentBuilderUpdatePayerBalanceFailed() {
 	factory.
 		EXPECT().
 		AccountRepository().
-		Return(accountRepository)
+		Return(accountRepository).
+		Times(3)
 	factory.
 		EXPECT().
-		PaymentRepository().
-		Return(paymentRepository).
-		Times(2)
+		LedgerRepository().
+		Return(ledgerRepository)
 
 	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
 	builder := newPaymentBuilder(server.Globals{
 		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		FXRateProvider:    s.fxRateProvider,
 		RepositoryFactory: factory,
 	})
 
 	payment, err := builder.
-		SetPayer(s.payments[0].PayerAccountUID).
-		SetRecipient(s.payments[0].RecipientAccountUID).
-		SetAmount(float64(s.payments[0].Amount) / 100).
+		SetPayer(s.transfer.PayerAccountUID).
+		SetRecipient(s.transfer.RecipientAccountUID).
+		SetAmount(money.FromMinorUnits(s.transfer.SourceAmount, 2)).
 		Build(context.Background())
 
 	s.Error(err)
@@ -243,7 +942,7 @@ func (s *paymentBuilderTestSuite) TestPaymentBuilderUpdatePayerBalanceFailed() {
 	s.Equal(0, zapRecorded.Len())
 }
 
-func (s *paymentBuilderTestSuite) TestPaymentBuilderUpdateRecepientBalanceFailed() {
+func (s *paymentBuilderTestSuite) TestPaymentBuilderStoreEventFailed() {
 	ctrl := gomock.NewController(s.T())
 	defer ctrl.Finish()
 
@@ -260,24 +959,36 @@ func (s *paymentBuilderTestSuite) TestPaymentBuilderUpdateRecepientBalanceFailed
 	accountRepository := mock.NewMockAccountRepository(ctrl)
 	accountRepository.
 		EXPECT().
-		UpdateBalance(gomock.Any(), gomock.Eq(s.payments[0].PayerAccountUID), gomock.Eq(-s.payments[0].Amount)).
+		LockByUIDs(gomock.Any(), gomock.Eq([]string{s.transfer.PayerAccountUID, s.transfer.RecipientAccountUID})).
+		Return([]repository.Account{s.payer, s.recipient}, nil)
+	accountRepository.
+		EXPECT().
+		UpdateBalance(gomock.Any(), gomock.Eq(s.transfer.PayerAccountUID), gomock.Eq(-s.transfer.SourceAmount)).
 		Return(nil)
 	accountRepository.
 		EXPECT().
-		UpdateBalance(gomock.Any(), gomock.Eq(s.payments[1].PayerAccountUID), gomock.Eq(-s.payments[1].Amount)).
-		Return(errors.New("fail"))
+		UpdateBalance(gomock.Any(), gomock.Eq(s.transfer.RecipientAccountUID), gomock.Eq(s.transfer.TargetAmount)).
+		Return(nil)
 
-	paymentRepository := mock.NewMockPaymentRepository(ctrl)
-	paymentRepository.
+	ledgerRepository := mock.NewMockLedgerRepository(ctrl)
+	ledgerRepository.
 		EXPECT().
-		Store(gomock.Any(), testutil.EqualRepositoryPayment(s.payments[0])).
+		Store(gomock.Any(), testutil.EqualRepositoryTransfer(s.transfer)).
 		Return(nil)
-	paymentRepository.
+
+	outboxRepository := mock.NewMockOutboxRepository(ctrl)
+	outboxRepository.
 		EXPECT().
-		Store(gomock.Any(), testutil.EqualRepositoryPayment(s.payments[1])).
-		Return(nil)
+		Store(gomock.Any(), gomock.Any()).
+		Return(errors.New("fail"))
 
 	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		Retry(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, work func(ctx context.Context) error) error {
+			return work(ctx)
+		})
 	factory.
 		EXPECT().
 		Scope().
@@ -286,23 +997,28 @@ func (s *paymentBuilderTestSuite) TestPaymentBuilderUpdateRecepientBalanceFailed
 		EXPECT().
 		AccountRepository().
 		Return(accountRepository).
-		Times(2)
+		Times(3)
 	factory.
 		EXPECT().
-		PaymentRepository().
-		Return(paymentRepository).
-		Times(2)
+		LedgerRepository().
+		Return(ledgerRepository)
+	factory.
+		EXPECT().
+		OutboxRepository().
+		Return(outboxRepository)
 
 	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
 	builder := newPaymentBuilder(server.Globals{
 		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		FXRateProvider:    s.fxRateProvider,
 		RepositoryFactory: factory,
 	})
 
 	payment, err := builder.
-		SetPayer(s.payments[0].PayerAccountUID).
-		SetRecipient(s.payments[0].RecipientAccountUID).
-		SetAmount(float64(s.payments[0].Amount) / 100).
+		SetPayer(s.transfer.PayerAccountUID).
+		SetRecipient(s.transfer.RecipientAccountUID).
+		SetAmount(money.FromMinorUnits(s.transfer.SourceAmount, 2)).
 		Build(context.Background())
 
 	s.Error(err)
@@ -331,24 +1047,39 @@ func (s *paymentBuilderTestSuite) TestPaymentBuilderCompleteFailed() {
 	accountRepository := mock.NewMockAccountRepository(ctrl)
 	accountRepository.
 		EXPECT().
-		UpdateBalance(gomock.Any(), gomock.Eq(s.payments[0].PayerAccountUID), gomock.Eq(-s.payments[0].Amount)).
+		LockByUIDs(gomock.Any(), gomock.Eq([]string{s.transfer.PayerAccountUID, s.transfer.RecipientAccountUID})).
+		Return([]repository.Account{s.payer, s.recipient}, nil)
+	accountRepository.
+		EXPECT().
+		UpdateBalance(gomock.Any(), gomock.Eq(s.transfer.PayerAccountUID), gomock.Eq(-s.transfer.SourceAmount)).
 		Return(nil)
 	accountRepository.
 		EXPECT().
-		UpdateBalance(gomock.Any(), gomock.Eq(s.payments[1].PayerAccountUID), gomock.Eq(-s.payments[1].Amount)).
+		UpdateBalance(gomock.Any(), gomock.Eq(s.transfer.RecipientAccountUID), gomock.Eq(s.transfer.TargetAmount)).
 		Return(nil)
 
-	paymentRepository := mock.NewMockPaymentRepository(ctrl)
-	paymentRepository.
+	ledgerRepository := mock.NewMockLedgerRepository(ctrl)
+	ledgerRepository.
 		EXPECT().
-		Store(gomock.Any(), testutil.EqualRepositoryPayment(s.payments[0])).
+		Store(gomock.Any(), testutil.EqualRepositoryTransfer(s.transfer)).
 		Return(nil)
-	paymentRepository.
+
+	outboxRepository := mock.NewMockOutboxRepository(ctrl)
+	outboxRepository.
 		EXPECT().
-		Store(gomock.Any(), testutil.EqualRepositoryPayment(s.payments[1])).
+		Store(gomock.Any(), gomock.Any()).
 		Return(nil)
-
 	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		OutboxRepository().
+		Return(outboxRepository)
+	factory.
+		EXPECT().
+		Retry(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, work func(ctx context.Context) error) error {
+			return work(ctx)
+		})
 	factory.
 		EXPECT().
 		Scope().
@@ -357,23 +1088,24 @@ func (s *paymentBuilderTestSuite) TestPaymentBuilderCompleteFailed() {
 		EXPECT().
 		AccountRepository().
 		Return(accountRepository).
-		Times(2)
+		Times(3)
 	factory.
 		EXPECT().
-		PaymentRepository().
-		Return(paymentRepository).
-		Times(2)
+		LedgerRepository().
+		Return(ledgerRepository)
 
 	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
 	builder := newPaymentBuilder(server.Globals{
 		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		FXRateProvider:    s.fxRateProvider,
 		RepositoryFactory: factory,
 	})
 
 	payment, err := builder.
-		SetPayer(s.payments[0].PayerAccountUID).
-		SetRecipient(s.payments[0].RecipientAccountUID).
-		SetAmount(float64(s.payments[0].Amount) / 100).
+		SetPayer(s.transfer.PayerAccountUID).
+		SetRecipient(s.transfer.RecipientAccountUID).
+		SetAmount(money.FromMinorUnits(s.transfer.SourceAmount, 2)).
 		Build(context.Background())
 
 	s.Error(err)
@@ -402,24 +1134,39 @@ func (s *paymentBuilderTestSuite) TestPaymentBuilderCancelFailedButSucceeded() {
 	accountRepository := mock.NewMockAccountRepository(ctrl)
 	accountRepository.
 		EXPECT().
-		UpdateBalance(gomock.Any(), gomock.Eq(s.payments[0].PayerAccountUID), gomock.Eq(-s.payments[0].Amount)).
+		LockByUIDs(gomock.Any(), gomock.Eq([]string{s.transfer.PayerAccountUID, s.transfer.RecipientAccountUID})).
+		Return([]repository.Account{s.payer, s.recipient}, nil)
+	accountRepository.
+		EXPECT().
+		UpdateBalance(gomock.Any(), gomock.Eq(s.transfer.PayerAccountUID), gomock.Eq(-s.transfer.SourceAmount)).
 		Return(nil)
 	accountRepository.
 		EXPECT().
-		UpdateBalance(gomock.Any(), gomock.Eq(s.payments[1].PayerAccountUID), gomock.Eq(-s.payments[1].Amount)).
+		UpdateBalance(gomock.Any(), gomock.Eq(s.transfer.RecipientAccountUID), gomock.Eq(s.transfer.TargetAmount)).
 		Return(nil)
 
-	paymentRepository := mock.NewMockPaymentRepository(ctrl)
-	paymentRepository.
+	ledgerRepository := mock.NewMockLedgerRepository(ctrl)
+	ledgerRepository.
 		EXPECT().
-		Store(gomock.Any(), testutil.EqualRepositoryPayment(s.payments[0])).
+		Store(gomock.Any(), testutil.EqualRepositoryTransfer(s.transfer)).
 		Return(nil)
-	paymentRepository.
+
+	outboxRepository := mock.NewMockOutboxRepository(ctrl)
+	outboxRepository.
 		EXPECT().
-		Store(gomock.Any(), testutil.EqualRepositoryPayment(s.payments[1])).
+		Store(gomock.Any(), gomock.Any()).
 		Return(nil)
-
 	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		OutboxRepository().
+		Return(outboxRepository)
+	factory.
+		EXPECT().
+		Retry(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, work func(ctx context.Context) error) error {
+			return work(ctx)
+		})
 	factory.
 		EXPECT().
 		Scope().
@@ -428,23 +1175,31 @@ func (s *paymentBuilderTestSuite) TestPaymentBuilderCancelFailedButSucceeded() {
 		EXPECT().
 		AccountRepository().
 		Return(accountRepository).
-		Times(2)
+		Times(3)
 	factory.
 		EXPECT().
-		PaymentRepository().
-		Return(paymentRepository).
-		Times(2)
+		LedgerRepository().
+		Return(ledgerRepository)
+
+	eventBroker := mock.NewMockEventBroker(ctrl)
+	eventBroker.
+		EXPECT().
+		Publish(gomock.Any()).
+		Times(4)
 
 	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
 	builder := newPaymentBuilder(server.Globals{
 		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		FXRateProvider:    s.fxRateProvider,
 		RepositoryFactory: factory,
+		EventBroker:       eventBroker,
 	})
 
 	payment, err := builder.
-		SetPayer(s.payments[0].PayerAccountUID).
-		SetRecipient(s.payments[0].RecipientAccountUID).
-		SetAmount(float64(s.payments[0].Amount) / 100).
+		SetPayer(s.transfer.PayerAccountUID).
+		SetRecipient(s.transfer.RecipientAccountUID).
+		SetAmount(money.FromMinorUnits(s.transfer.SourceAmount, 2)).
 		Build(context.Background())
 
 	s.NoError(err)
@@ -473,24 +1228,290 @@ func (s *paymentBuilderTestSuite) TestPaymentBuilderSucceeded() {
 	accountRepository := mock.NewMockAccountRepository(ctrl)
 	accountRepository.
 		EXPECT().
-		UpdateBalance(gomock.Any(), gomock.Eq(s.payments[0].PayerAccountUID), gomock.Eq(-s.payments[0].Amount)).
+		LockByUIDs(gomock.Any(), gomock.Eq([]string{s.transfer.PayerAccountUID, s.transfer.RecipientAccountUID})).
+		Return([]repository.Account{s.payer, s.recipient}, nil)
+	accountRepository.
+		EXPECT().
+		UpdateBalance(gomock.Any(), gomock.Eq(s.transfer.PayerAccountUID), gomock.Eq(-s.transfer.SourceAmount)).
 		Return(nil)
 	accountRepository.
 		EXPECT().
-		UpdateBalance(gomock.Any(), gomock.Eq(s.payments[1].PayerAccountUID), gomock.Eq(-s.payments[1].Amount)).
+		UpdateBalance(gomock.Any(), gomock.Eq(s.transfer.RecipientAccountUID), gomock.Eq(s.transfer.TargetAmount)).
+		Return(nil)
+
+	ledgerRepository := mock.NewMockLedgerRepository(ctrl)
+	ledgerRepository.
+		EXPECT().
+		Store(gomock.Any(), testutil.EqualRepositoryTransfer(s.transfer)).
+		Return(nil)
+
+	outboxRepository := mock.NewMockOutboxRepository(ctrl)
+	outboxRepository.
+		EXPECT().
+		Store(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, event *repository.Event) error {
+			s.Equal(repository.PaymentCompletedEvent, event.Type)
+			s.Equal(event.CreatedAt, event.NextAttemptAt)
+			return nil
+		})
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		OutboxRepository().
+		Return(outboxRepository)
+	factory.
+		EXPECT().
+		Retry(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, work func(ctx context.Context) error) error {
+			return work(ctx)
+		})
+	factory.
+		EXPECT().
+		Scope().
+		Return(scope)
+	factory.
+		EXPECT().
+		AccountRepository().
+		Return(accountRepository).
+		Times(3)
+	factory.
+		EXPECT().
+		LedgerRepository().
+		Return(ledgerRepository)
+
+	var published []service.AccountEvent
+	eventBroker := mock.NewMockEventBroker(ctrl)
+	eventBroker.
+		EXPECT().
+		Publish(gomock.Any()).
+		Do(func(event service.AccountEvent) {
+			published = append(published, event)
+		}).
+		Times(4)
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	builder := newPaymentBuilder(server.Globals{
+		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		FXRateProvider:    s.fxRateProvider,
+		RepositoryFactory: factory,
+		EventBroker:       eventBroker,
+	})
+
+	payment, err := builder.
+		SetPayer(s.transfer.PayerAccountUID).
+		SetRecipient(s.transfer.RecipientAccountUID).
+		SetAmount(money.FromMinorUnits(s.transfer.SourceAmount, 2)).
+		Build(context.Background())
+
+	s.NoError(err)
+	s.NotNil(payment)
+	s.Equal(0, zapRecorded.Len())
+	// Payer's and recipient's streams get payment and new balance
+	s.Require().Len(published, 4)
+	s.Equal(s.transfer.PayerAccountUID, published[0].AccountUID)
+	s.Equal(paymentStreamEvent, published[0].Type)
+	s.Equal(balanceStreamEvent, published[1].Type)
+	s.Equal(int64(0), published[1].ID)
+	s.Contains(string(published[1].Data), `"balance":"`+
+		money.FromMinorUnits(s.payer.Balance-s.transfer.SourceAmount, 2).String()+`"`)
+	s.Equal(s.transfer.RecipientAccountUID, published[2].AccountUID)
+	s.Equal(paymentStreamEvent, published[2].Type)
+	s.Contains(string(published[3].Data), `"balance":"`+
+		money.FromMinorUnits(s.recipient.Balance+s.transfer.TargetAmount, 2).String()+`"`)
+}
+
+func (s *paymentBuilderTestSuite) TestPaymentBuilderMinorUnitsSucceeded() {
+	for _, test := range []struct {
+		currency string
+		amount   string
+		minor    int64
+	}{
+		{currency: "JPY", amount: "1234", minor: 1234},
+		{currency: "BHD", amount: "1.234", minor: 1234},
+		{currency: "USD", amount: "0.29", minor: 29},
+	} {
+		ctrl := gomock.NewController(s.T())
+
+		payer := s.payer
+		payer.Currency = test.currency
+		recipient := s.recipient
+		recipient.Currency = test.currency
+		expected := s.transfer
+		expected.SourceAmount = test.minor
+		expected.SourceCurrency = test.currency
+		expected.TargetAmount = test.minor
+		expected.TargetCurrency = test.currency
+		expected.Postings = []repository.Posting{
+			{AccountUID: payer.UID, Amount: -test.minor, Currency: test.currency},
+			{AccountUID: recipient.UID, Amount: test.minor, Currency: test.currency},
+		}
+
+		scope := mock.NewMockScope(ctrl)
+		scope.
+			EXPECT().
+			WithContext(gomock.Any()).
+			Return(context.Background(), nil)
+		scope.
+			EXPECT().
+			Complete(gomock.Any()).
+			Return(nil)
+		scope.
+			EXPECT().
+			Cancel(gomock.Any()).
+			Return(nil)
+
+		accountRepository := mock.NewMockAccountRepository(ctrl)
+		accountRepository.
+			EXPECT().
+			LockByUIDs(gomock.Any(), gomock.Eq([]string{payer.UID, recipient.UID})).
+			Return([]repository.Account{payer, recipient}, nil)
+		accountRepository.
+			EXPECT().
+			UpdateBalance(gomock.Any(), gomock.Eq(expected.PayerAccountUID), gomock.Eq(-test.minor)).
+			Return(nil)
+		accountRepository.
+			EXPECT().
+			UpdateBalance(gomock.Any(), gomock.Eq(expected.RecipientAccountUID), gomock.Eq(test.minor)).
+			Return(nil)
+
+		ledgerRepository := mock.NewMockLedgerRepository(ctrl)
+		ledgerRepository.
+			EXPECT().
+			Store(gomock.Any(), testutil.EqualRepositoryTransfer(expected)).
+			Return(nil)
+
+		outboxRepository := mock.NewMockOutboxRepository(ctrl)
+		outboxRepository.
+			EXPECT().
+			Store(gomock.Any(), gomock.Any()).
+			Return(nil)
+		factory := mock.NewMockFactory(ctrl)
+		factory.
+			EXPECT().
+			OutboxRepository().
+			Return(outboxRepository)
+		factory.
+			EXPECT().
+			Retry(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, work func(ctx context.Context) error) error {
+				return work(ctx)
+			})
+		factory.
+			EXPECT().
+			Scope().
+			Return(scope)
+		factory.
+			EXPECT().
+			AccountRepository().
+			Return(accountRepository).
+			Times(3)
+		factory.
+			EXPECT().
+			LedgerRepository().
+			Return(ledgerRepository)
+		eventBroker := mock.NewMockEventBroker(ctrl)
+		eventBroker.
+			EXPECT().
+			Publish(gomock.Any()).
+			Times(4)
+
+		builder := newPaymentBuilder(server.Globals{
+			Logger:            zap.NewNop(),
+			CurrencyRegistry:  testutil.CurrencyRegistry(),
+			FXRateProvider:    s.fxRateProvider,
+			RepositoryFactory: factory,
+			EventBroker:       eventBroker,
+		})
+
+		payment, err := builder.
+			SetPayer(expected.PayerAccountUID).
+			SetRecipient(expected.RecipientAccountUID).
+			SetAmount(money.MustParse(test.amount)).
+			Build(context.Background())
+
+		s.NoError(err)
+		s.NotNil(payment)
+		s.Equal(test.amount, payment.Amount.String())
+		s.Equal(test.currency, payment.Currency)
+		ctrl.Finish()
+	}
+}
+
+func (s *paymentBuilderTestSuite) TestPaymentBuilderCrossCurrencySucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	recipient := s.recipient
+	recipient.Currency = "JPY"
+	expected := s.transfer
+	expected.TargetAmount = 10850
+	expected.TargetCurrency = "JPY"
+	expected.Rate = "108.5"
+	// Currency exchange goes through FX account, so transfer is balanced in both currencies
+	expected.Postings = []repository.Posting{
+		{AccountUID: expected.PayerAccountUID, Amount: -10000, Currency: "USD"},
+		{AccountUID: repository.FXAccountUID, Amount: 10000, Currency: "USD"},
+		{AccountUID: repository.FXAccountUID, Amount: -10850, Currency: "JPY"},
+		{AccountUID: expected.RecipientAccountUID, Amount: 10850, Currency: "JPY"},
+	}
+
+	scope := mock.NewMockScope(ctrl)
+	scope.
+		EXPECT().
+		WithContext(gomock.Any()).
+		Return(context.Background(), nil)
+	scope.
+		EXPECT().
+		Complete(gomock.Any()).
+		Return(nil)
+	scope.
+		EXPECT().
+		Cancel(gomock.Any()).
 		Return(nil)
 
-	paymentRepository := mock.NewMockPaymentRepository(ctrl)
-	paymentRepository.
+	fxRateProvider := mock.NewMockFXRateProvider(ctrl)
+	fxRateProvider.
+		EXPECT().
+		Rate(gomock.Any(), gomock.Eq("USD"), gomock.Eq("JPY")).
+		Return(big.NewRat(217, 2), nil)
+
+	accountRepository := mock.NewMockAccountRepository(ctrl)
+	accountRepository.
+		EXPECT().
+		LockByUIDs(gomock.Any(), gomock.Eq([]string{expected.PayerAccountUID, expected.RecipientAccountUID})).
+		Return([]repository.Account{s.payer, recipient}, nil)
+	accountRepository.
+		EXPECT().
+		UpdateBalance(gomock.Any(), gomock.Eq(expected.PayerAccountUID), gomock.Eq(int64(-10000))).
+		Return(nil)
+	accountRepository.
 		EXPECT().
-		Store(gomock.Any(), testutil.EqualRepositoryPayment(s.payments[0])).
+		UpdateBalance(gomock.Any(), gomock.Eq(expected.RecipientAccountUID), gomock.Eq(int64(10850))).
 		Return(nil)
-	paymentRepository.
+
+	ledgerRepository := mock.NewMockLedgerRepository(ctrl)
+	ledgerRepository.
 		EXPECT().
-		Store(gomock.Any(), testutil.EqualRepositoryPayment(s.payments[1])).
+		Store(gomock.Any(), testutil.EqualRepositoryTransfer(expected)).
 		Return(nil)
 
+	outboxRepository := mock.NewMockOutboxRepository(ctrl)
+	outboxRepository.
+		EXPECT().
+		Store(gomock.Any(), gomock.Any()).
+		Return(nil)
 	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		OutboxRepository().
+		Return(outboxRepository)
+	factory.
+		EXPECT().
+		Retry(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, work func(ctx context.Context) error) error {
+			return work(ctx)
+		})
 	factory.
 		EXPECT().
 		Scope().
@@ -499,26 +1520,102 @@ func (s *paymentBuilderTestSuite) TestPaymentBuilderSucceeded() {
 		EXPECT().
 		AccountRepository().
 		Return(accountRepository).
-		Times(2)
+		Times(3)
 	factory.
 		EXPECT().
-		PaymentRepository().
-		Return(paymentRepository).
-		Times(2)
+		LedgerRepository().
+		Return(ledgerRepository)
+	eventBroker := mock.NewMockEventBroker(ctrl)
+	eventBroker.
+		EXPECT().
+		Publish(gomock.Any()).
+		Times(4)
+
+	builder := newPaymentBuilder(server.Globals{
+		Logger:            zap.NewNop(),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		FXRateProvider:    fxRateProvider,
+		RepositoryFactory: factory,
+		EventBroker:       eventBroker,
+	})
+
+	payment, err := builder.
+		SetPayer(expected.PayerAccountUID).
+		SetRecipient(expected.RecipientAccountUID).
+		SetAmount(money.MustParse("100")).
+		Build(context.Background())
+
+	s.NoError(err)
+	s.NotNil(payment)
+	s.Equal("100.00", payment.Amount.String())
+	s.Equal("USD", payment.Currency)
+	s.NotNil(payment.Exchange)
+	s.Equal("108.5", payment.Exchange.Rate)
+	s.Equal("100.00", payment.Exchange.SourceAmount.String())
+	s.Equal("USD", payment.Exchange.SourceCurrency)
+	s.Equal("10850", payment.Exchange.TargetAmount.String())
+	s.Equal("JPY", payment.Exchange.TargetCurrency)
+}
+
+func (s *paymentBuilderTestSuite) TestPaymentBuilderIdempotencyReplaySucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	stored := testutil.RepositoryIdempotencyKey()
+	stored.Operation = paymentOperation
+	stored.Fingerprint = newIdempotency(nil, 0, paymentOperation, stored.Key,
+		s.transfer.PayerAccountUID, s.transfer.RecipientAccountUID, "100").fingerprint
+
+	scope := mock.NewMockScope(ctrl)
+	scope.
+		EXPECT().
+		WithContext(gomock.Any()).
+		Return(context.Background(), nil)
+	scope.
+		EXPECT().
+		Cancel(gomock.Any()).
+		Return(nil)
+
+	idempotencyRepository := mock.NewMockIdempotencyRepository(ctrl)
+	idempotencyRepository.
+		EXPECT().
+		Get(gomock.Any(), gomock.Eq(paymentOperation), gomock.Eq(stored.Key), gomock.Any()).
+		Return(&stored, nil)
+
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		Retry(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, work func(ctx context.Context) error) error {
+			return work(ctx)
+		})
+	factory.
+		EXPECT().
+		Scope().
+		Return(scope)
+	factory.
+		EXPECT().
+		IdempotencyRepository().
+		Return(idempotencyRepository)
 
 	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
 	builder := newPaymentBuilder(server.Globals{
 		Logger:            zap.New(zapCore),
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		FXRateProvider:    s.fxRateProvider,
 		RepositoryFactory: factory,
+		IdempotencyTTL:    time.Hour,
 	})
 
 	payment, err := builder.
-		SetPayer(s.payments[0].PayerAccountUID).
-		SetRecipient(s.payments[0].RecipientAccountUID).
-		SetAmount(float64(s.payments[0].Amount) / 100).
+		SetPayer(s.transfer.PayerAccountUID).
+		SetRecipient(s.transfer.RecipientAccountUID).
+		SetAmount(money.MustParse("100.00")).
+		SetIdempotencyKey(stored.Key).
 		Build(context.Background())
 
 	s.NoError(err)
 	s.NotNil(payment)
+	s.Equal("toshik1978", payment.UID)
 	s.Equal(0, zapRecorded.Len())
 }
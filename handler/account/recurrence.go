@@ -0,0 +1,99 @@
+package account
+
+import (
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service/cron"
+)
+
+// scheduleRecurrences define recurrences of scheduled payments
+var scheduleRecurrences = []string{
+	string(repository.DailyRecurrence),
+	string(repository.WeeklyRecurrence),
+	string(repository.MonthlyRecurrence),
+	string(repository.CronRecurrence),
+}
+
+// insufficientFundsPolicies define policies of scheduled payments, which payer has insufficient funds
+var insufficientFundsPolicies = []string{
+	string(repository.SkipOnInsufficientFunds),
+	string(repository.RetryOnInsufficientFunds),
+}
+
+// firstRun return the first occurrence of the schedule at or after its start.
+// Zero time returned, if schedule has no occurrences
+func firstRun(schedule repository.Schedule) time.Time {
+	if schedule.Recurrence != repository.CronRecurrence {
+		return schedule.StartAt
+	}
+	return nextRun(schedule, schedule.StartAt.Add(-time.Nanosecond))
+}
+
+// nextRun return the first occurrence of the schedule strictly after the given time.
+// Occurrences are counted from the schedule's start in UTC, monthly occurrence falls on the last day
+// of the shorter month, if it has no start's day. Zero time returned, if there are no more occurrences
+func nextRun(schedule repository.Schedule, after time.Time) time.Time {
+	start := schedule.StartAt.UTC()
+	after = after.UTC()
+	if after.Before(start) {
+		if schedule.Recurrence != repository.CronRecurrence {
+			return start
+		}
+		after = start.Add(-time.Nanosecond)
+	}
+
+	switch schedule.Recurrence {
+	case repository.DailyRecurrence:
+		return nextPeriod(start, after, 1)
+	case repository.WeeklyRecurrence:
+		return nextPeriod(start, after, 7)
+	case repository.MonthlyRecurrence:
+		// Estimation of passed months is at most one month greater than the actual number
+		months := (after.Year()-start.Year())*12 + int(after.Month()-start.Month())
+		for k := maxInt(months-1, 0); ; k++ {
+			if occurrence := monthlyOccurrence(start, k); occurrence.After(after) {
+				return occurrence
+			}
+		}
+	case repository.CronRecurrence:
+		expr, err := cron.Parse(schedule.Cron)
+		if err != nil {
+			return time.Time{}
+		}
+		return expr.Next(after)
+	}
+	return time.Time{}
+}
+
+// nextPeriod return the first occurrence after the given time of the schedule, repeated every given days
+func nextPeriod(start time.Time, after time.Time, days int) time.Time {
+	period := time.Duration(days) * 24 * time.Hour
+	passed := int(after.Sub(start) / period)
+	occurrence := start.AddDate(0, 0, passed*days)
+	for !occurrence.After(after) {
+		occurrence = occurrence.AddDate(0, 0, days)
+	}
+	return occurrence
+}
+
+// monthlyOccurrence return k-th monthly occurrence after the start. Day of the start is clamped
+// to the last day of the month, so occurrences don't drift after the shorter month
+func monthlyOccurrence(start time.Time, k int) time.Time {
+	year, month := start.Year(), start.Month()+time.Month(k)
+	// Zero day of the next month is the last day of the month
+	lastDay := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	day := start.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(year, month, day, start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), time.UTC)
+}
+
+// maxInt return the greatest of two integers
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
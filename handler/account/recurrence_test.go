@@ -0,0 +1,85 @@
+package account
+
+import (
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/stretchr/testify/suite"
+)
+
+type recurrenceTestSuite struct {
+	suite.Suite
+}
+
+func (s *recurrenceTestSuite) TestNextRunSucceeded() {
+	start := time.Date(2020, time.January, 31, 10, 30, 0, 0, time.UTC)
+	for _, test := range []struct {
+		recurrence repository.Recurrence
+		cron       string
+		after      time.Time
+		expected   time.Time
+	}{
+		{
+			recurrence: repository.DailyRecurrence,
+			after:      start.Add(-time.Hour),
+			expected:   start,
+		},
+		{
+			recurrence: repository.DailyRecurrence,
+			after:      start,
+			expected:   time.Date(2020, time.February, 1, 10, 30, 0, 0, time.UTC),
+		},
+		{
+			recurrence: repository.DailyRecurrence,
+			after:      time.Date(2020, time.March, 5, 12, 0, 0, 0, time.UTC),
+			expected:   time.Date(2020, time.March, 6, 10, 30, 0, 0, time.UTC),
+		},
+		{
+			recurrence: repository.WeeklyRecurrence,
+			after:      start,
+			expected:   time.Date(2020, time.February, 7, 10, 30, 0, 0, time.UTC),
+		},
+		{
+			recurrence: repository.MonthlyRecurrence,
+			after:      start,
+			expected:   time.Date(2020, time.February, 29, 10, 30, 0, 0, time.UTC),
+		},
+		{
+			recurrence: repository.MonthlyRecurrence,
+			after:      time.Date(2020, time.February, 29, 10, 30, 0, 0, time.UTC),
+			expected:   time.Date(2020, time.March, 31, 10, 30, 0, 0, time.UTC),
+		},
+		{
+			recurrence: repository.MonthlyRecurrence,
+			after:      time.Date(2021, time.April, 1, 0, 0, 0, 0, time.UTC),
+			expected:   time.Date(2021, time.April, 30, 10, 30, 0, 0, time.UTC),
+		},
+		{
+			recurrence: repository.CronRecurrence,
+			cron:       "0 9 * * 1",
+			after:      start,
+			expected:   time.Date(2020, time.February, 3, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			recurrence: repository.CronRecurrence,
+			cron:       "0 0 30 2 *",
+			after:      start,
+		},
+	} {
+		schedule := repository.Schedule{Recurrence: test.recurrence, Cron: test.cron, StartAt: start}
+
+		actual := nextRun(schedule, test.after)
+
+		s.True(test.expected.Equal(actual), "%s %s after %s: %s", test.recurrence, test.cron, test.after, actual)
+	}
+}
+
+func (s *recurrenceTestSuite) TestFirstRunSucceeded() {
+	start := time.Date(2020, time.January, 31, 10, 30, 0, 0, time.UTC)
+
+	s.True(start.Equal(firstRun(repository.Schedule{Recurrence: repository.DailyRecurrence, StartAt: start})))
+	s.True(time.Date(2020, time.February, 3, 9, 0, 0, 0, time.UTC).Equal(
+		firstRun(repository.Schedule{Recurrence: repository.CronRecurrence, Cron: "0 9 * * 1", StartAt: start})))
+	s.True(start.Equal(
+		firstRun(repository.Schedule{Recurrence: repository.CronRecurrence, Cron: "30 10 * * *", StartAt: start})))
+}
@@ -0,0 +1,242 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/AlekSi/pointer"
+	"github.com/Toshik1978/go-rest-api/handler"
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service/errutil"
+	"github.com/Toshik1978/go-rest-api/service/money"
+	"github.com/Toshik1978/go-rest-api/service/server"
+	"github.com/Toshik1978/go-rest-api/service/validator"
+)
+
+// refundBuilder implements RefundBuilder interface. Refund is the payment back from recipient to payer
+// of the refunded payment, so it's created by payment builder, which amounts are resolved by refund
+type refundBuilder struct {
+	payment *paymentBuilder
+
+	id             int64
+	amount         *money.Amount
+	idempotencyKey string
+	v              *validator.Validator
+}
+
+// newRefundBuilder creates new RefundBuilder implementation
+func newRefundBuilder(globals server.Globals) handler.RefundBuilder {
+	return &refundBuilder{
+		payment: &paymentBuilder{
+			logger:            globals.Logger,
+			repositoryFactory: globals.RepositoryFactory,
+			currencyRegistry:  globals.CurrencyRegistry,
+			eventBroker:       globals.EventBroker,
+			idempotencyTTL:    globals.IdempotencyTTL,
+			transfer:          repository.Transfer{CreatedAt: time.Now()},
+		},
+		v: validator.NewValidator(),
+	}
+}
+
+func (b *refundBuilder) SetPayment(id int64) handler.RefundBuilder {
+	b.id = id
+	return b
+}
+
+func (b *refundBuilder) SetAmount(amount *money.Amount) handler.RefundBuilder {
+	b.amount = amount
+	return b
+}
+
+func (b *refundBuilder) SetIdempotencyKey(key string) handler.RefundBuilder {
+	b.idempotencyKey = key
+	return b
+}
+
+func (b *refundBuilder) Build(ctx context.Context) (*handler.Payment, error) {
+	if b.id <= 0 {
+		b.v.AddField("id", strconv.FormatInt(b.id, 10), "> 0")
+	}
+	if b.amount != nil {
+		b.v.ValidateAmount(*b.amount)
+	}
+	b.v.ValidateIdempotencyKey(b.idempotencyKey)
+	if err := b.v.Error(); err != nil {
+		return nil, handler.WrapError(err, "failed to validate refund", handler.ClientError)
+	}
+
+	// Refund is re-run from scratch, if it's failed because of concurrent payments
+	var payment *handler.Payment
+	err := b.payment.repositoryFactory.Retry(ctx, func(ctx context.Context) error {
+		var err error
+		payment, err = b.build(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+// build creates refund in the new scope
+func (b *refundBuilder) build(ctx context.Context) (*handler.Payment, error) {
+	scope := b.payment.repositoryFactory.Scope()
+	ctx, err := scope.WithContext(ctx)
+	if err != nil {
+		return nil, errutil.Wrap(err, "failed to start repository scope")
+	}
+	// Here we can defer Cancel operation, because it's safe
+	defer func() { _ = scope.Cancel(ctx) }()
+
+	// Repeated request with the same idempotency key gets the original response
+	amount := ""
+	if b.amount != nil {
+		amount = trimDecimal(b.amount.String())
+	}
+	idempotency := newIdempotency(b.payment.repositoryFactory, b.payment.idempotencyTTL, refundOperation,
+		b.idempotencyKey, strconv.FormatInt(b.id, 10), amount)
+	var replayed handler.Payment
+	ok, err := idempotency.replay(ctx, &replayed)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return &replayed, nil
+	}
+
+	// Money goes back from recipient to payer of the refunded payment
+	refunded, err := b.refundedTransfer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	b.payment.transfer.PayerAccountUID = refunded.RecipientAccountUID
+	b.payment.transfer.RecipientAccountUID = refunded.PayerAccountUID
+	b.payment.transfer.RefundOf = pointer.ToInt64(refunded.ID)
+	if err := b.resolveAmounts(ctx); err != nil {
+		return nil, err
+	}
+	if err := b.payment.storeTransfer(ctx, repository.PaymentRefundedEvent); err != nil {
+		return nil, err
+	}
+	// Debit posting describes refund from the point of view of the refunded payment's recipient
+	payment := mapRepositoryEntry(repository.Entry{
+		Posting:  b.payment.transfer.Postings[0],
+		Transfer: b.payment.transfer,
+	}, b.payment.currencyRegistry)
+	if err := idempotency.store(ctx, payment); err != nil {
+		return nil, err
+	}
+
+	// Complete scope
+	if err := scope.Complete(ctx); err != nil {
+		return nil, errutil.Wrap(err, "failed to complete repository scope")
+	}
+	// Subscribers should never see refund, which isn't committed
+	b.payment.publishEvents()
+	return payment, nil
+}
+
+// refundedTransfer return transfer to refund. Refund itself can't be refunded
+func (b *refundBuilder) refundedTransfer(ctx context.Context) (*repository.Transfer, error) {
+	transfer, err := b.payment.repositoryFactory.LedgerRepository().GetByID(ctx, b.id)
+	if errors.Is(err, repository.ErrTransferNotFound) {
+		return nil, handler.WrapError(err,
+			"failed to get payment "+strconv.FormatInt(b.id, 10), handler.NotFoundError)
+	}
+	if err != nil {
+		return nil, handler.WrapError(err, "failed to get payment", handler.ServerError)
+	}
+	if transfer.RefundOf != nil {
+		return nil, handler.NewError(
+			"payment "+strconv.FormatInt(b.id, 10)+" is a refund and can't be refunded", handler.UnprocessableError)
+	}
+	return transfer, nil
+}
+
+// resolveAmounts locks accounts of the refund and resolves its amounts. Refunded amount is read again under locks,
+// so concurrent refunds of the same payment can't exceed its amount. Recipient gives back the part of its amount,
+// proportional to the refunded part, at the rate of the refunded payment
+func (b *refundBuilder) resolveAmounts(ctx context.Context) error {
+	payer, recipient, err := b.payment.lockAccounts(ctx)
+	if err != nil {
+		return err
+	}
+	if err := activeAccount(*payer, "payer"); err != nil {
+		return err
+	}
+	if err := activeAccount(*recipient, "recipient"); err != nil {
+		return err
+	}
+	b.payment.payer, b.payment.recipient = payer, recipient
+
+	refunded, err := b.refundedTransfer(ctx)
+	if err != nil {
+		return err
+	}
+	remaining := refunded.SourceAmount - refunded.RefundedAmount
+	if remaining <= 0 {
+		return handler.NewError(
+			"payment "+strconv.FormatInt(refunded.ID, 10)+" is already refunded", handler.UnprocessableError)
+	}
+	amount, err := b.refundAmount(refunded, remaining)
+	if err != nil {
+		return err
+	}
+
+	// Proportional parts are calculated for the whole refunded amount, so full refund gives back exactly
+	// the amount recipient received, whatever partial refunds were made before
+	sourceAmount := proportionalAmount(refunded.TargetAmount, refunded.RefundedAmount+amount, refunded.SourceAmount) -
+		proportionalAmount(refunded.TargetAmount, refunded.RefundedAmount, refunded.SourceAmount)
+	if sourceAmount <= 0 {
+		v := validator.NewValidator()
+		exponent := currencyExponent(b.payment.currencyRegistry, refunded.SourceCurrency)
+		v.AddField("amount", money.FromMinorUnits(amount, exponent).String(),
+			"amount worth at least one minor unit of "+refunded.TargetCurrency)
+		return handler.WrapError(v.Error(), "failed to validate refund", handler.ClientError)
+	}
+	// Payer is locked, so balance can't be changed concurrently till the end of the scope
+	if payer.Balance < sourceAmount {
+		return handler.WrapError(repository.ErrInsufficientFunds,
+			"insufficient funds on account "+payer.UID, handler.UnprocessableError)
+	}
+
+	rate, ok := new(big.Rat).SetString(refunded.Rate)
+	if !ok || rate.Sign() <= 0 {
+		return handler.NewError("failed to parse exchange rate "+refunded.Rate, handler.ServerError)
+	}
+
+	b.payment.transfer.SourceAmount = sourceAmount
+	b.payment.transfer.SourceCurrency = refunded.TargetCurrency
+	b.payment.transfer.TargetAmount = amount
+	b.payment.transfer.TargetCurrency = refunded.SourceCurrency
+	b.payment.transfer.Rate = formatRate(rate.Inv(rate))
+	return nil
+}
+
+// refundAmount return amount to refund in minor units of refunded payment's source currency.
+// Amount can't exceed the remaining amount, which isn't refunded yet, no amount means the remaining one
+func (b *refundBuilder) refundAmount(refunded *repository.Transfer, remaining int64) (int64, error) {
+	if b.amount == nil {
+		return remaining, nil
+	}
+
+	// Precision of amount depends on currency of the refunded payment, so we can check it only here
+	exponent := currencyExponent(b.payment.currencyRegistry, refunded.SourceCurrency)
+	if err := validator.NewValidator().ValidatePrecision("amount", *b.amount, exponent).Error(); err != nil {
+		return 0, handler.WrapError(err, "failed to validate refund", handler.ClientError)
+	}
+	amount, err := b.amount.MinorUnits(exponent)
+	if err != nil {
+		return 0, handler.WrapError(err, "failed to validate refund", handler.ClientError)
+	}
+	if amount > remaining {
+		return 0, handler.NewError("refund amount exceeds "+
+			money.FromMinorUnits(remaining, exponent).String()+" "+refunded.SourceCurrency+
+			", which isn't refunded yet", handler.UnprocessableError)
+	}
+	return amount, nil
+}
@@ -0,0 +1,314 @@
+package account
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/AlekSi/pointer"
+	"github.com/Toshik1978/go-rest-api/handler"
+	"github.com/Toshik1978/go-rest-api/mock"
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/repository/memoryengine"
+	"github.com/Toshik1978/go-rest-api/service/broker"
+	"github.com/Toshik1978/go-rest-api/service/money"
+	"github.com/Toshik1978/go-rest-api/service/server"
+	"github.com/Toshik1978/go-rest-api/service/testutil"
+	"github.com/Toshik1978/go-rest-api/service/validator"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type refundBuilderTestSuite struct {
+	suite.Suite
+
+	ctrl    *gomock.Controller
+	factory repository.Factory
+	manager handler.AccountManager
+}
+
+func (s *refundBuilderTestSuite) SetupTest() {
+	s.ctrl = gomock.NewController(s.T())
+	fxRateProvider := mock.NewMockFXRateProvider(s.ctrl)
+	fxRateProvider.
+		EXPECT().
+		Rate(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, from string, to string) (*big.Rat, error) {
+			switch {
+			case from == to:
+				return big.NewRat(1, 1), nil
+			case from == "JPY":
+				return big.NewRat(2, 217), nil
+			default:
+				return big.NewRat(217, 2), nil
+			}
+		}).
+		AnyTimes()
+
+	s.factory = memoryengine.NewRepositoryFactory()
+	s.manager = NewAccountManager(server.Globals{
+		Logger:            zap.NewNop(),
+		RepositoryFactory: s.factory,
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		FXRateProvider:    fxRateProvider,
+		EventBroker:       broker.NewBroker(server.Vars{EventsBufferSize: 10}),
+		IdempotencyTTL:    time.Hour,
+	})
+	for uid, currency := range map[string]string{"toshik1978": "USD", "toshik1979": "USD", "toshik1980": "JPY"} {
+		_, err := s.manager.AccountBuilder().
+			SetUID(uid).
+			SetCurrency(currency).
+			SetBalance(money.MustParse("100")).
+			Build(context.Background())
+		s.Require().NoError(err)
+	}
+}
+
+func (s *refundBuilderTestSuite) TearDownTest() {
+	s.ctrl.Finish()
+}
+
+// pay makes payment of the given amount from toshik1978 to the recipient
+func (s *refundBuilderTestSuite) pay(recipient string, amount string) *handler.Payment {
+	payment, err := s.manager.PaymentBuilder().
+		SetPayer("toshik1978").
+		SetRecipient(recipient).
+		SetAmount(money.MustParse(amount)).
+		Build(context.Background())
+	s.Require().NoError(err)
+	return payment
+}
+
+// refund refunds the given amount of the payment, empty amount means the remaining amount
+func (s *refundBuilderTestSuite) refund(id int64, amount string) (*handler.Payment, error) {
+	builder := s.manager.RefundBuilder().SetPayment(id)
+	if amount != "" {
+		parsed := money.MustParse(amount)
+		builder.SetAmount(&parsed)
+	}
+	return builder.Build(context.Background())
+}
+
+// balance return balance of the account
+func (s *refundBuilderTestSuite) balance(uid string) string {
+	account, err := s.manager.Account(context.Background(), uid)
+	s.Require().NoError(err)
+	return account.Balance.String()
+}
+
+// payment return the account's payment with the given ID
+func (s *refundBuilderTestSuite) payment(uid string, id int64) handler.Payment {
+	payments, err := s.manager.AccountPayments(context.Background(), uid,
+		handler.PaymentFilter{}, handler.PageRequest{})
+	s.Require().NoError(err)
+	for _, payment := range payments.Payments {
+		if payment.ID == id {
+			return payment
+		}
+	}
+	s.FailNow("payment not found")
+	return handler.Payment{}
+}
+
+// requireKind checks, that error is handler's error of the given kind
+func (s *refundBuilderTestSuite) requireKind(err error, kind handler.ErrorKind) {
+	var handlerError *handler.Error
+	s.Require().True(errors.As(err, &handlerError), "%v", err)
+	s.Equal(kind, handlerError.Kind, "%v", err)
+}
+
+func (s *refundBuilderTestSuite) TestBuildValidationFailed() {
+	refund, err := s.refund(0, "-1")
+
+	s.requireKind(err, handler.ClientError)
+	s.Nil(refund)
+}
+
+func (s *refundBuilderTestSuite) TestBuildPaymentNotFoundFailed() {
+	refund, err := s.refund(1000, "")
+
+	s.requireKind(err, handler.NotFoundError)
+	s.Nil(refund)
+}
+
+func (s *refundBuilderTestSuite) TestBuildFullSucceeded() {
+	payment := s.pay("toshik1979", "10")
+
+	refund, err := s.refund(payment.ID, "")
+	s.Require().NoError(err)
+	s.NotEqual(payment.ID, refund.ID)
+	s.Equal("toshik1979", refund.UID)
+	s.Equal(pointer.ToString("toshik1978"), refund.TargetUID)
+	s.Equal(outgoingPayment, refund.Direction)
+	s.Equal("10.00", refund.Amount.String())
+	s.Equal(pointer.ToInt64(payment.ID), refund.RefundOf)
+	s.Empty(refund.RefundStatus)
+
+	s.Equal("100.00", s.balance("toshik1978"))
+	s.Equal("100.00", s.balance("toshik1979"))
+	for _, uid := range []string{"toshik1978", "toshik1979"} {
+		refunded := s.payment(uid, payment.ID)
+		s.Equal(refundedPayment, refunded.RefundStatus)
+		s.Equal("10.00", refunded.RefundedAmount.String())
+	}
+
+	refund, err = s.refund(payment.ID, "")
+	s.requireKind(err, handler.UnprocessableError)
+	s.Nil(refund)
+}
+
+func (s *refundBuilderTestSuite) TestBuildPartialSucceeded() {
+	payment := s.pay("toshik1979", "10")
+
+	_, err := s.refund(payment.ID, "3")
+	s.Require().NoError(err)
+	refunded := s.payment("toshik1978", payment.ID)
+	s.Equal(partiallyRefundedPayment, refunded.RefundStatus)
+	s.Equal("3.00", refunded.RefundedAmount.String())
+
+	refund, err := s.refund(payment.ID, "7.01")
+	s.requireKind(err, handler.UnprocessableError)
+	s.Nil(refund)
+
+	refund, err = s.refund(payment.ID, "7")
+	s.Require().NoError(err)
+	s.Equal("7.00", refund.Amount.String())
+	refunded = s.payment("toshik1978", payment.ID)
+	s.Equal(refundedPayment, refunded.RefundStatus)
+	s.Equal("10.00", refunded.RefundedAmount.String())
+	s.Equal("100.00", s.balance("toshik1978"))
+	s.Equal("100.00", s.balance("toshik1979"))
+}
+
+func (s *refundBuilderTestSuite) TestBuildCrossCurrencySucceeded() {
+	// 10 USD are exchanged to 1085 JPY
+	payment := s.pay("toshik1980", "10")
+
+	refund, err := s.refund(payment.ID, "3.33")
+	s.Require().NoError(err)
+	s.Equal("361", refund.Amount.String())
+	s.Equal("JPY", refund.Currency)
+	s.Require().NotNil(refund.Exchange)
+	s.Equal("3.33", refund.Exchange.TargetAmount.String())
+	s.Equal("USD", refund.Exchange.TargetCurrency)
+
+	refund, err = s.refund(payment.ID, "")
+	s.Require().NoError(err)
+	s.Equal("724", refund.Amount.String())
+
+	s.Equal("100.00", s.balance("toshik1978"))
+	s.Equal("100", s.balance("toshik1980"))
+	refunded := s.payment("toshik1980", payment.ID)
+	s.Equal(refundedPayment, refunded.RefundStatus)
+	s.Equal("1085", refunded.RefundedAmount.String())
+}
+
+func (s *refundBuilderTestSuite) TestBuildTooSmallFailed() {
+	// 10 JPY are exchanged to 0.09 USD, so 1 JPY is worth less than one cent
+	payment, err := s.manager.PaymentBuilder().
+		SetPayer("toshik1980").
+		SetRecipient("toshik1978").
+		SetAmount(money.MustParse("10")).
+		Build(context.Background())
+	s.Require().NoError(err)
+
+	refund, err := s.refund(payment.ID, "1")
+	s.requireKind(err, handler.ClientError)
+	s.Nil(refund)
+}
+
+func (s *refundBuilderTestSuite) TestBuildFullTooSmallFailed() {
+	// Payment, which recipient got nothing, could be stored before such payments were rejected
+	transfer := repository.Transfer{
+		PayerAccountUID:     "toshik1980",
+		RecipientAccountUID: "toshik1978",
+		SourceAmount:        1,
+		SourceCurrency:      "JPY",
+		TargetAmount:        0,
+		TargetCurrency:      "USD",
+		Rate:                "0.009216589861751",
+		CreatedAt:           time.Now(),
+		Postings: []repository.Posting{
+			{AccountUID: "toshik1980", Amount: -1, Currency: "JPY"},
+			{AccountUID: repository.FXAccountUID, Amount: 1, Currency: "JPY"},
+			{AccountUID: repository.FXAccountUID, Amount: 0, Currency: "USD"},
+			{AccountUID: "toshik1978", Amount: 0, Currency: "USD"},
+		},
+	}
+	s.Require().NoError(s.factory.LedgerRepository().Store(context.Background(), &transfer))
+
+	refund, err := s.refund(transfer.ID, "")
+	s.requireKind(err, handler.ClientError)
+	var validationError *validator.Error
+	s.Require().True(errors.As(err, &validationError))
+	s.Equal("1", validationError.Fields[0].Actual)
+	s.Nil(refund)
+}
+
+func (s *refundBuilderTestSuite) TestBuildRefundOfRefundFailed() {
+	payment := s.pay("toshik1979", "10")
+	refund, err := s.refund(payment.ID, "1")
+	s.Require().NoError(err)
+
+	refund, err = s.refund(refund.ID, "")
+	s.requireKind(err, handler.UnprocessableError)
+	s.Nil(refund)
+}
+
+func (s *refundBuilderTestSuite) TestBuildInsufficientFundsFailed() {
+	payment := s.pay("toshik1979", "10")
+	_, err := s.manager.PaymentBuilder().
+		SetPayer("toshik1979").
+		SetRecipient("toshik1978").
+		SetAmount(money.MustParse("105")).
+		Build(context.Background())
+	s.Require().NoError(err)
+
+	refund, err := s.refund(payment.ID, "")
+	s.requireKind(err, handler.UnprocessableError)
+	s.True(errors.Is(err, repository.ErrInsufficientFunds))
+	s.Nil(refund)
+}
+
+func (s *refundBuilderTestSuite) TestBuildIdempotencySucceeded() {
+	payment := s.pay("toshik1979", "10")
+
+	amount := money.MustParse("4")
+	first, err := s.manager.RefundBuilder().
+		SetPayment(payment.ID).
+		SetAmount(&amount).
+		SetIdempotencyKey("refund-key").
+		Build(context.Background())
+	s.Require().NoError(err)
+	second, err := s.manager.RefundBuilder().
+		SetPayment(payment.ID).
+		SetAmount(&amount).
+		SetIdempotencyKey("refund-key").
+		Build(context.Background())
+	s.Require().NoError(err)
+
+	s.Equal(first.ID, second.ID)
+	s.Equal("94.00", s.balance("toshik1978"))
+}
+
+func (s *refundBuilderTestSuite) TestBuildEventSucceeded() {
+	payment := s.pay("toshik1979", "10")
+	refund, err := s.refund(payment.ID, "")
+	s.Require().NoError(err)
+
+	events, err := s.factory.OutboxRepository().GetPending(context.Background(), time.Now(), 10)
+	s.Require().NoError(err)
+	s.Require().NotEmpty(events)
+	event := events[len(events)-1]
+	s.Equal(repository.PaymentRefundedEvent, event.Type)
+
+	var data handler.PaymentEvent
+	s.Require().NoError(json.Unmarshal([]byte(event.Payload), &data))
+	s.Equal(refund.ID, data.ID)
+	s.Equal("toshik1979", data.PayerUID)
+	s.Equal("toshik1978", data.RecipientUID)
+	s.Equal(pointer.ToInt64(payment.ID), data.RefundOf)
+}
@@ -0,0 +1,199 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/handler"
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service"
+	"github.com/Toshik1978/go-rest-api/service/errutil"
+	"github.com/Toshik1978/go-rest-api/service/server"
+	"github.com/Toshik1978/go-rest-api/service/validator"
+)
+
+// scheduleManager implements ScheduleManager interface
+type scheduleManager struct {
+	repositoryFactory repository.Factory
+	currencyRegistry  service.CurrencyRegistry
+}
+
+// NewScheduleManager creates new implementation of ScheduleManager interface
+func NewScheduleManager(globals server.Globals) handler.ScheduleManager {
+	return &scheduleManager{
+		repositoryFactory: globals.RepositoryFactory,
+		currencyRegistry:  globals.CurrencyRegistry,
+	}
+}
+
+func (m *scheduleManager) CreateSchedule(
+	ctx context.Context, uid string, request handler.ScheduleRequest) (*handler.Schedule, error) {
+
+	now := time.Now()
+	startAt := now
+	if request.StartAt != nil {
+		startAt = *request.StartAt
+	}
+	err := validator.NewValidator().
+		ValidateUID("recipient", request.RecipientUID).
+		ValidateAmount(request.Amount).
+		ValidateStartAt(startAt, now).
+		ValidateRecurrence(request.Recurrence, scheduleRecurrences).
+		ValidateCron(request.Cron, request.Recurrence == string(repository.CronRecurrence), startAt).
+		ValidateInsufficientFundsPolicy(request.OnInsufficientFunds, insufficientFundsPolicies).
+		Error()
+	if err != nil {
+		return nil, handler.WrapError(err, "failed to validate schedule", handler.ClientError)
+	}
+
+	payer, err := m.getAccount(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if err := activeAccount(*payer, "payer"); err != nil {
+		return nil, err
+	}
+	if _, err := m.getAccount(ctx, request.RecipientUID); err != nil {
+		return nil, err
+	}
+	// Amount is always in payer's currency, so precision can be checked only here
+	exponent := currencyExponent(m.currencyRegistry, payer.Currency)
+	if err := validator.NewValidator().ValidatePrecision("amount", request.Amount, exponent).Error(); err != nil {
+		return nil, handler.WrapError(err, "failed to validate schedule", handler.ClientError)
+	}
+	amount, err := request.Amount.MinorUnits(exponent)
+	if err != nil {
+		return nil, handler.WrapError(err, "failed to validate schedule", handler.ClientError)
+	}
+
+	policy := repository.InsufficientFundsPolicy(request.OnInsufficientFunds)
+	if policy == "" {
+		policy = repository.SkipOnInsufficientFunds
+	}
+	schedule := repository.Schedule{
+		PayerAccountUID:     payer.UID,
+		RecipientAccountUID: request.RecipientUID,
+		Amount:              amount,
+		Currency:            payer.Currency,
+		Recurrence:          repository.Recurrence(request.Recurrence),
+		Cron:                request.Cron,
+		OnInsufficientFunds: policy,
+		Status:              repository.ActiveSchedule,
+		StartAt:             startAt,
+		CreatedAt:           now,
+		UpdatedAt:           now,
+	}
+	schedule.NextRunAt = firstRun(schedule)
+	err = m.repositoryFactory.ScheduleRepository().Store(ctx, &schedule)
+	if errors.Is(err, repository.ErrAccountNotFound) {
+		return nil, handler.WrapError(err, "failed to create schedule", handler.NotFoundError)
+	}
+	if err != nil {
+		return nil, handler.WrapError(err, "failed to store schedule", handler.ServerError)
+	}
+	return mapRepositorySchedule(schedule, m.currencyRegistry), nil
+}
+
+func (m *scheduleManager) Schedule(ctx context.Context, uid string, id int64) (*handler.Schedule, error) {
+	schedule, err := m.getSchedule(ctx, uid, id)
+	if err != nil {
+		return nil, err
+	}
+	return mapRepositorySchedule(*schedule, m.currencyRegistry), nil
+}
+
+func (m *scheduleManager) AccountSchedules(
+	ctx context.Context, uid string, page handler.PageRequest) (*handler.ScheduleList, error) {
+
+	repositoryPage, err := repositoryPage(page)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := m.getAccount(ctx, uid); err != nil {
+		return nil, err
+	}
+	schedules, err := m.repositoryFactory.ScheduleRepository().GetByAccount(ctx, uid, repositoryPage)
+	if err != nil {
+		return nil, errutil.Wrap(err, "failed to get schedules")
+	}
+
+	count, more := pageSize(repositoryPage, len(schedules))
+	list := &handler.ScheduleList{
+		Schedules: mapRepositorySchedules(schedules[:count], m.currencyRegistry),
+	}
+	if more {
+		list.NextCursor = encodeCursor(schedules[count-1].ID)
+	}
+	return list, nil
+}
+
+func (m *scheduleManager) CancelSchedule(ctx context.Context, uid string, id int64) (*handler.Schedule, error) {
+	if _, err := m.getSchedule(ctx, uid, id); err != nil {
+		return nil, err
+	}
+	// Run, which is already claimed, is skipped by the scheduler
+	err := m.repositoryFactory.ScheduleRepository().Cancel(ctx, id, time.Now())
+	if errors.Is(err, repository.ErrScheduleNotFound) {
+		return nil, handler.WrapError(err, "failed to get schedule "+strconv.FormatInt(id, 10),
+			handler.NotFoundError)
+	}
+	if err != nil {
+		return nil, handler.WrapError(err, "failed to cancel schedule", handler.ServerError)
+	}
+	return m.Schedule(ctx, uid, id)
+}
+
+func (m *scheduleManager) ScheduleRuns(ctx context.Context,
+	uid string, id int64, page handler.PageRequest) (*handler.ScheduleRunList, error) {
+
+	repositoryPage, err := repositoryPage(page)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := m.getSchedule(ctx, uid, id); err != nil {
+		return nil, err
+	}
+	runs, err := m.repositoryFactory.ScheduleRunRepository().GetBySchedule(ctx, id, repositoryPage)
+	if err != nil {
+		return nil, errutil.Wrap(err, "failed to get schedule runs")
+	}
+
+	count, more := pageSize(repositoryPage, len(runs))
+	list := &handler.ScheduleRunList{
+		Runs: mapRepositoryScheduleRuns(runs[:count]),
+	}
+	if more {
+		list.NextCursor = encodeCursor(runs[count-1].ID)
+	}
+	return list, nil
+}
+
+// getAccount return account with the given UID
+func (m *scheduleManager) getAccount(ctx context.Context, uid string) (*repository.Account, error) {
+	account, err := m.repositoryFactory.AccountRepository().GetByUID(ctx, uid)
+	if errors.Is(err, repository.ErrAccountNotFound) {
+		return nil, handler.WrapError(err, "failed to get account "+uid, handler.NotFoundError)
+	}
+	if err != nil {
+		return nil, handler.WrapError(err, "failed to get account", handler.ServerError)
+	}
+	return account, nil
+}
+
+// getSchedule return schedule with the given ID, if its payer is the given account
+func (m *scheduleManager) getSchedule(ctx context.Context, uid string, id int64) (*repository.Schedule, error) {
+	schedule, err := m.repositoryFactory.ScheduleRepository().GetByID(ctx, id)
+	if err == nil && schedule.PayerAccountUID != uid {
+		err = repository.ErrScheduleNotFound
+	}
+	if errors.Is(err, repository.ErrScheduleNotFound) {
+		return nil, handler.WrapError(err, "failed to get schedule "+strconv.FormatInt(id, 10),
+			handler.NotFoundError)
+	}
+	if err != nil {
+		return nil, handler.WrapError(err, "failed to get schedule", handler.ServerError)
+	}
+	return schedule, nil
+}
@@ -0,0 +1,205 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/AlekSi/pointer"
+	"github.com/Toshik1978/go-rest-api/handler"
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/repository/memoryengine"
+	"github.com/Toshik1978/go-rest-api/service/money"
+	"github.com/Toshik1978/go-rest-api/service/server"
+	"github.com/Toshik1978/go-rest-api/service/testutil"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type scheduleManagerTestSuite struct {
+	suite.Suite
+
+	factory repository.Factory
+	globals server.Globals
+	request handler.ScheduleRequest
+}
+
+func (s *scheduleManagerTestSuite) SetupTest() {
+	s.factory = memoryengine.NewRepositoryFactory()
+	s.globals = server.Globals{
+		Logger:            zap.NewNop(),
+		RepositoryFactory: s.factory,
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+	}
+
+	manager := NewAccountManager(s.globals)
+	for _, uid := range []string{"toshik1978", "toshik1979", "toshik1980"} {
+		_, err := manager.AccountBuilder().
+			SetUID(uid).
+			SetCurrency("USD").
+			SetBalance(money.FromMinorUnits(10000, 2)).
+			Build(context.Background())
+		s.Require().NoError(err)
+	}
+
+	s.request = handler.ScheduleRequest{
+		RecipientUID: "toshik1979",
+		Amount:       money.FromMinorUnits(1000, 2),
+		StartAt:      pointer.ToTime(time.Now().Add(time.Hour).Round(time.Millisecond)),
+		Recurrence:   string(repository.MonthlyRecurrence),
+	}
+}
+
+func (s *scheduleManagerTestSuite) TestCreateScheduleValidationFailed() {
+	for _, request := range []handler.ScheduleRequest{
+		{RecipientUID: s.request.RecipientUID, Amount: s.request.Amount, Recurrence: "yearly"},
+		{RecipientUID: s.request.RecipientUID, Amount: s.request.Amount, Recurrence: "cron"},
+		{RecipientUID: s.request.RecipientUID, Amount: s.request.Amount, Recurrence: "cron", Cron: "0 0 30 2 *"},
+		{RecipientUID: s.request.RecipientUID, Amount: s.request.Amount, Recurrence: "daily", Cron: "* * * * *"},
+		{
+			RecipientUID:        s.request.RecipientUID,
+			Amount:              s.request.Amount,
+			Recurrence:          "daily",
+			OnInsufficientFunds: "ignore",
+		},
+		{
+			RecipientUID: s.request.RecipientUID,
+			Amount:       s.request.Amount,
+			Recurrence:   "daily",
+			StartAt:      pointer.ToTime(time.Now().Add(-time.Hour)),
+		},
+		{RecipientUID: s.request.RecipientUID, Amount: money.FromMinorUnits(1, 3), Recurrence: "daily"},
+	} {
+		schedule, err := NewScheduleManager(s.globals).CreateSchedule(context.Background(), "toshik1978", request)
+
+		s.requireKind(err, handler.ClientError)
+		s.Nil(schedule)
+	}
+}
+
+func (s *scheduleManagerTestSuite) TestCreateScheduleRecipientNotFoundFailed() {
+	request := s.request
+	request.RecipientUID = "unknown"
+
+	schedule, err := NewScheduleManager(s.globals).CreateSchedule(context.Background(), "toshik1978", request)
+
+	s.requireKind(err, handler.NotFoundError)
+	s.Nil(schedule)
+}
+
+func (s *scheduleManagerTestSuite) TestCreateSchedulePayerFrozenFailed() {
+	_, err := NewAccountManager(s.globals).UpdateAccountStatus(context.Background(), "toshik1978", "frozen")
+	s.Require().NoError(err)
+
+	schedule, err := NewScheduleManager(s.globals).CreateSchedule(context.Background(), "toshik1978", s.request)
+
+	s.requireKind(err, handler.UnprocessableError)
+	s.Nil(schedule)
+}
+
+func (s *scheduleManagerTestSuite) TestCreateScheduleSucceeded() {
+	schedule, err := NewScheduleManager(s.globals).CreateSchedule(context.Background(), "toshik1978", s.request)
+
+	s.Require().NoError(err)
+	s.NotZero(schedule.ID)
+	s.Equal("toshik1978", schedule.UID)
+	s.Equal("10.00", schedule.Amount.String())
+	s.Equal("USD", schedule.Currency)
+	s.Equal("active", schedule.Status)
+	// Policy is skip, if it's not given
+	s.Equal("skip", schedule.OnInsufficientFunds)
+	s.Require().NotNil(schedule.NextRunAt)
+	s.True(s.request.StartAt.Equal(*schedule.NextRunAt))
+}
+
+func (s *scheduleManagerTestSuite) TestCreateScheduleCronSucceeded() {
+	request := s.request
+	request.Recurrence = string(repository.CronRecurrence)
+	request.Cron = "0 9 * * 1"
+	request.OnInsufficientFunds = string(repository.RetryOnInsufficientFunds)
+
+	schedule, err := NewScheduleManager(s.globals).CreateSchedule(context.Background(), "toshik1978", request)
+
+	s.Require().NoError(err)
+	s.Equal("retry", schedule.OnInsufficientFunds)
+	s.Require().NotNil(schedule.NextRunAt)
+	s.Equal(time.Monday, schedule.NextRunAt.UTC().Weekday())
+	s.Equal(9, schedule.NextRunAt.UTC().Hour())
+	s.True(schedule.NextRunAt.After(*request.StartAt))
+}
+
+func (s *scheduleManagerTestSuite) TestScheduleOfOtherAccountFailed() {
+	manager := NewScheduleManager(s.globals)
+	created, err := manager.CreateSchedule(context.Background(), "toshik1978", s.request)
+	s.Require().NoError(err)
+
+	schedule, err := manager.Schedule(context.Background(), "toshik1980", created.ID)
+
+	s.requireKind(err, handler.NotFoundError)
+	s.Nil(schedule)
+}
+
+func (s *scheduleManagerTestSuite) TestAccountSchedulesSucceeded() {
+	manager := NewScheduleManager(s.globals)
+	for i := 0; i < 3; i++ {
+		_, err := manager.CreateSchedule(context.Background(), "toshik1978", s.request)
+		s.Require().NoError(err)
+	}
+
+	list, err := manager.AccountSchedules(context.Background(), "toshik1978", handler.PageRequest{Limit: 2})
+	s.Require().NoError(err)
+	s.Len(list.Schedules, 2)
+	s.NotEmpty(list.NextCursor)
+
+	list, err = manager.AccountSchedules(context.Background(), "toshik1978",
+		handler.PageRequest{Cursor: list.NextCursor, Limit: 2})
+	s.Require().NoError(err)
+	s.Len(list.Schedules, 1)
+	s.Empty(list.NextCursor)
+
+	_, err = manager.AccountSchedules(context.Background(), "unknown", handler.PageRequest{})
+	s.requireKind(err, handler.NotFoundError)
+}
+
+func (s *scheduleManagerTestSuite) TestCancelScheduleSucceeded() {
+	manager := NewScheduleManager(s.globals)
+	created, err := manager.CreateSchedule(context.Background(), "toshik1978", s.request)
+	s.Require().NoError(err)
+
+	schedule, err := manager.CancelSchedule(context.Background(), "toshik1978", created.ID)
+	s.Require().NoError(err)
+	s.Equal("cancelled", schedule.Status)
+	s.Nil(schedule.NextRunAt)
+
+	// Cancelled schedule is cancelled again without error
+	schedule, err = manager.CancelSchedule(context.Background(), "toshik1978", created.ID)
+	s.Require().NoError(err)
+	s.Equal("cancelled", schedule.Status)
+
+	_, err = manager.CancelSchedule(context.Background(), "toshik1980", created.ID)
+	s.requireKind(err, handler.NotFoundError)
+}
+
+func (s *scheduleManagerTestSuite) TestScheduleRunsSucceeded() {
+	manager := NewScheduleManager(s.globals)
+	created, err := manager.CreateSchedule(context.Background(), "toshik1978", s.request)
+	s.Require().NoError(err)
+	run := testutil.RepositoryScheduleRun()
+	run.ScheduleID = created.ID
+	s.Require().NoError(s.factory.ScheduleRunRepository().Store(context.Background(), &run))
+
+	list, err := manager.ScheduleRuns(context.Background(), "toshik1978", created.ID, handler.PageRequest{})
+
+	s.Require().NoError(err)
+	s.Require().Len(list.Runs, 1)
+	s.Equal(run.ID, list.Runs[0].ID)
+	s.Equal("pending", list.Runs[0].Status)
+	s.Empty(list.NextCursor)
+}
+
+// requireKind checks, that error is handler's error of the given kind
+func (s *scheduleManagerTestSuite) requireKind(err error, kind handler.ErrorKind) {
+	var handlerError *handler.Error
+	s.Require().True(errors.As(err, &handlerError), "%v", err)
+	s.Equal(kind, handlerError.Kind)
+}
@@ -0,0 +1,231 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/handler"
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service"
+	"github.com/Toshik1978/go-rest-api/service/errutil"
+	"github.com/Toshik1978/go-rest-api/service/money"
+	"github.com/Toshik1978/go-rest-api/service/poller"
+	"github.com/Toshik1978/go-rest-api/service/server"
+	"go.uber.org/zap"
+)
+
+// scheduleIdempotencyPrefix prefixes idempotency key of the run's payment
+const scheduleIdempotencyPrefix = "schedule-run-"
+
+// scheduler implements Scheduler interface. Every poll it claims due occurrences of active schedules as pending
+// runs and then executes pending runs one by one in order of IDs. Occurrence is claimed together with advance
+// of its schedule, so it's run once even by concurrent instances. Run's payment is made with idempotency key
+// of the run, so payment of the run, which outcome isn't recorded because of stop, is replayed, not repeated
+type scheduler struct {
+	*poller.Poller
+
+	globals           server.Globals
+	logger            *zap.Logger
+	repositoryFactory repository.Factory
+	currencyRegistry  service.CurrencyRegistry
+	batchSize         int
+	retryInterval     time.Duration
+	maxAttempts       int
+}
+
+// NewScheduler creates new implementation of Scheduler interface
+func NewScheduler(globals server.Globals, vars server.Vars) handler.Scheduler {
+	s := &scheduler{
+		globals:           globals,
+		logger:            globals.Logger,
+		repositoryFactory: globals.RepositoryFactory,
+		currencyRegistry:  globals.CurrencyRegistry,
+		batchSize:         vars.ScheduleBatchSize,
+		retryInterval:     vars.ScheduleRetryInterval,
+		maxAttempts:       vars.ScheduleMaxAttempts,
+	}
+	s.Poller = poller.New(globals.Logger, "Scheduler", vars.ScheduleInterval, vars.ScheduleBatchSize, s.poll)
+	return s
+}
+
+// poll claims single batch of due occurrences and executes single batch of pending runs.
+// It return the greatest number of records in the batches, so full batch is polled again immediately
+func (s *scheduler) poll(ctx context.Context) (int, error) {
+	claimed, err := s.claim(ctx)
+	if err != nil {
+		return 0, err
+	}
+	executed, err := s.execute(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return maxInt(claimed, executed), nil
+}
+
+// claim stores pending runs of due occurrences and return number of due schedules in the batch.
+// Missed occurrences, e.g. while the service was stopped, are run once
+func (s *scheduler) claim(ctx context.Context) (int, error) {
+	now := time.Now()
+	schedules, err := s.repositoryFactory.ScheduleRepository().GetDue(ctx, now, s.batchSize)
+	if err != nil {
+		return 0, errutil.Wrap(err, "failed to get due schedules")
+	}
+
+	for _, schedule := range schedules {
+		if ctx.Err() != nil {
+			return len(schedules), nil
+		}
+		err := s.repositoryFactory.Retry(ctx, func(ctx context.Context) error {
+			return s.claimSchedule(ctx, schedule, now)
+		})
+		// Occurrence is claimed by another instance or schedule is cancelled concurrently
+		if errors.Is(err, repository.ErrScheduleChanged) || errors.Is(err, repository.ErrScheduleRunExists) {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(schedules), nil
+}
+
+// claimSchedule advances schedule to the next occurrence after now and stores pending run of the due one
+func (s *scheduler) claimSchedule(ctx context.Context, schedule repository.Schedule, now time.Time) error {
+	scope := s.repositoryFactory.Scope()
+	ctx, err := scope.WithContext(ctx)
+	if err != nil {
+		return errutil.Wrap(err, "failed to start repository scope")
+	}
+	// Here we can defer Cancel operation, because it's safe
+	defer func() { _ = scope.Cancel(ctx) }()
+
+	due := schedule.NextRunAt
+	schedule.NextRunAt = nextRun(schedule, now)
+	schedule.UpdatedAt = now
+	// Schedule without upcoming occurrences keeps its last one as the next run
+	if schedule.NextRunAt.IsZero() {
+		schedule.NextRunAt = due
+		schedule.Status = repository.CompletedSchedule
+	}
+	if err := s.repositoryFactory.ScheduleRepository().Advance(ctx, &schedule, due); err != nil {
+		return errutil.Wrap(err, "failed to advance schedule")
+	}
+	run := repository.ScheduleRun{
+		ScheduleID:    schedule.ID,
+		ScheduledAt:   due,
+		Status:        repository.PendingRun,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := s.repositoryFactory.ScheduleRunRepository().Store(ctx, &run); err != nil {
+		return errutil.Wrap(err, "failed to store schedule run")
+	}
+
+	if err := scope.Complete(ctx); err != nil {
+		return errutil.Wrap(err, "failed to complete repository scope")
+	}
+	return nil
+}
+
+// execute runs single batch of pending runs and return number of runs in the batch.
+// Failed run is recorded, so it doesn't stop execution of other runs
+func (s *scheduler) execute(ctx context.Context) (int, error) {
+	runs, err := s.repositoryFactory.ScheduleRunRepository().GetPending(ctx, time.Now(), s.batchSize)
+	if err != nil {
+		return 0, errutil.Wrap(err, "failed to get pending schedule runs")
+	}
+
+	for _, run := range runs {
+		// Payment in progress isn't interrupted, it's finished before stop
+		if ctx.Err() != nil {
+			return len(runs), nil
+		}
+		if err := s.executeRun(context.Background(), run); err != nil {
+			return 0, err
+		}
+	}
+	return len(runs), nil
+}
+
+// executeRun makes payment of the run and records its outcome
+func (s *scheduler) executeRun(ctx context.Context, run repository.ScheduleRun) error {
+	schedule, err := s.repositoryFactory.ScheduleRepository().GetByID(ctx, run.ScheduleID)
+	if err != nil {
+		return errutil.Wrap(err, "failed to get schedule")
+	}
+
+	run.Attempts++
+	run.UpdatedAt = time.Now()
+	if schedule.Status == repository.CancelledSchedule {
+		run.Status = repository.SkippedRun
+		run.Error = "schedule is cancelled"
+	} else {
+		s.recordOutcome(&run, *schedule, s.pay(ctx, run, *schedule))
+	}
+
+	if err := s.repositoryFactory.ScheduleRunRepository().Update(ctx, &run); err != nil {
+		return errutil.Wrap(err, "failed to update schedule run")
+	}
+	s.logger.Info("Scheduled payment run",
+		zap.Int64("schedule_id", schedule.ID),
+		zap.Int64("run_id", run.ID),
+		zap.String("status", string(run.Status)),
+		zap.Int("attempts", run.Attempts),
+		zap.String("error", run.Error))
+	return nil
+}
+
+// pay makes payment of the run
+func (s *scheduler) pay(ctx context.Context, run repository.ScheduleRun, schedule repository.Schedule) error {
+	_, err := newPaymentBuilder(s.globals).
+		SetPayer(schedule.PayerAccountUID).
+		SetRecipient(schedule.RecipientAccountUID).
+		SetAmount(money.FromMinorUnits(schedule.Amount, currencyExponent(s.currencyRegistry, schedule.Currency))).
+		SetIdempotencyKey(scheduleIdempotencyPrefix + strconv.FormatInt(run.ID, 10)).
+		Build(ctx)
+	return err
+}
+
+// recordOutcome changes run's status by result of the payment. Run is attempted again later,
+// if payer has insufficient funds and schedule's policy is retry or if payment failed because of the service.
+// Payment, rejected for other reasons, is failed at once. Run's error is shown to the client,
+// so only message of handler's error is recorded, cause of the server's error is logged only
+func (s *scheduler) recordOutcome(run *repository.ScheduleRun, schedule repository.Schedule, err error) {
+	if err == nil {
+		run.Status = repository.SucceededRun
+		run.Error = ""
+		return
+	}
+
+	var handlerError *handler.Error
+	if !errors.As(err, &handlerError) {
+		handlerError = &handler.Error{Kind: handler.ServerError, Message: "failed to make payment"}
+	}
+	run.Error = handlerError.Message
+	switch {
+	case errors.Is(err, repository.ErrInsufficientFunds):
+		run.Status = repository.SkippedRun
+		if schedule.OnInsufficientFunds == repository.RetryOnInsufficientFunds && run.Attempts < s.maxAttempts {
+			run.Status = repository.PendingRun
+			run.NextAttemptAt = run.UpdatedAt.Add(s.retryInterval)
+		}
+	case handlerError.Kind != handler.ServerError && handlerError.Kind != handler.ConflictError:
+		run.Status = repository.FailedRun
+	default:
+		// Payment of the same run in progress in another instance conflicts by idempotency key,
+		// it's replayed by the next attempt
+		s.logger.Warn("Failed to make scheduled payment",
+			zap.Int64("schedule_id", schedule.ID),
+			zap.Int64("run_id", run.ID),
+			zap.Int("attempts", run.Attempts),
+			zap.Error(err))
+		run.Status = repository.FailedRun
+		if run.Attempts < s.maxAttempts {
+			run.Status = repository.PendingRun
+			run.NextAttemptAt = run.UpdatedAt.Add(poller.RetryDelay(run.Attempts))
+		}
+	}
+}
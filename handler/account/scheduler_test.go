@@ -0,0 +1,255 @@
+package account
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/repository/memoryengine"
+	"github.com/Toshik1978/go-rest-api/service/broker"
+	"github.com/Toshik1978/go-rest-api/service/fx"
+	"github.com/Toshik1978/go-rest-api/service/money"
+	"github.com/Toshik1978/go-rest-api/service/server"
+	"github.com/Toshik1978/go-rest-api/service/testutil"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type schedulerTestSuite struct {
+	suite.Suite
+
+	factory  repository.Factory
+	globals  server.Globals
+	vars     server.Vars
+	schedule repository.Schedule
+}
+
+func (s *schedulerTestSuite) SetupTest() {
+	fxRateProvider, _ := fx.NewFileRateProvider(server.Vars{})
+	s.factory = memoryengine.NewRepositoryFactory()
+	s.globals = server.Globals{
+		Logger:            zap.NewNop(),
+		RepositoryFactory: s.factory,
+		CurrencyRegistry:  testutil.CurrencyRegistry(),
+		FXRateProvider:    fxRateProvider,
+		EventBroker:       broker.NewBroker(server.Vars{}),
+		IdempotencyTTL:    time.Hour,
+	}
+	s.vars = server.Vars{
+		ScheduleInterval:      time.Hour,
+		ScheduleBatchSize:     10,
+		ScheduleRetryInterval: time.Hour,
+		ScheduleMaxAttempts:   2,
+	}
+
+	manager := NewAccountManager(s.globals)
+	for uid, balance := range map[string]int64{"toshik1978": 1500, "toshik1979": 0} {
+		_, err := manager.AccountBuilder().
+			SetUID(uid).
+			SetCurrency("USD").
+			SetBalance(money.FromMinorUnits(balance, 2)).
+			Build(context.Background())
+		s.Require().NoError(err)
+	}
+
+	s.schedule = testutil.RepositorySchedule()
+	s.schedule.StartAt = time.Now().Add(-time.Hour).Round(time.Millisecond)
+	s.schedule.NextRunAt = s.schedule.StartAt
+}
+
+func (s *schedulerTestSuite) TestStopWithoutStartSucceeded() {
+	scheduler := NewScheduler(s.globals, s.vars)
+	scheduler.Stop()
+}
+
+func (s *schedulerTestSuite) TestStartStopSucceeded() {
+	s.storeSchedule(&s.schedule)
+
+	scheduler := NewScheduler(s.globals, s.vars)
+	scheduler.Start()
+	s.Eventually(func() bool {
+		return len(s.runs(s.schedule.ID)) == 1 && s.runs(s.schedule.ID)[0].Status == repository.SucceededRun
+	}, time.Second, 10*time.Millisecond)
+	scheduler.Stop()
+}
+
+func (s *schedulerTestSuite) TestPollSucceeded() {
+	s.storeSchedule(&s.schedule)
+
+	scheduler := s.newScheduler()
+	_, err := scheduler.poll(context.Background())
+	s.Require().NoError(err)
+
+	runs := s.runs(s.schedule.ID)
+	s.Require().Len(runs, 1)
+	s.Equal(repository.SucceededRun, runs[0].Status)
+	s.Equal(1, runs[0].Attempts)
+	s.True(s.schedule.StartAt.Equal(runs[0].ScheduledAt))
+	s.Equal(int64(500), s.balance("toshik1978"))
+	s.Equal(int64(1000), s.balance("toshik1979"))
+
+	schedule := s.getSchedule(s.schedule.ID)
+	s.Equal(repository.ActiveSchedule, schedule.Status)
+	s.True(s.schedule.StartAt.AddDate(0, 1, 0).Equal(schedule.NextRunAt))
+
+	// The next occurrence isn't due yet, so nothing is paid again
+	_, err = scheduler.poll(context.Background())
+	s.Require().NoError(err)
+	s.Len(s.runs(s.schedule.ID), 1)
+	s.Equal(int64(500), s.balance("toshik1978"))
+}
+
+func (s *schedulerTestSuite) TestPollMissedOccurrencesSucceeded() {
+	s.schedule.Recurrence = repository.DailyRecurrence
+	s.schedule.Amount = 100
+	s.schedule.StartAt = s.schedule.StartAt.AddDate(0, 0, -3)
+	s.schedule.NextRunAt = s.schedule.StartAt
+	s.storeSchedule(&s.schedule)
+
+	_, err := s.newScheduler().poll(context.Background())
+	s.Require().NoError(err)
+
+	// Missed occurrences are run once
+	s.Len(s.runs(s.schedule.ID), 1)
+	s.Equal(int64(1400), s.balance("toshik1978"))
+	schedule := s.getSchedule(s.schedule.ID)
+	s.True(s.schedule.StartAt.AddDate(0, 0, 4).Equal(schedule.NextRunAt))
+}
+
+func (s *schedulerTestSuite) TestPollConcurrentSucceeded() {
+	s.storeSchedule(&s.schedule)
+
+	// Occurrence is claimed by single scheduler, so it's paid once
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		scheduler := s.newScheduler()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := scheduler.poll(context.Background())
+			s.NoError(err)
+		}()
+	}
+	wg.Wait()
+
+	runs := s.runs(s.schedule.ID)
+	s.Require().Len(runs, 1)
+	s.Equal(repository.SucceededRun, runs[0].Status)
+	s.Equal(int64(500), s.balance("toshik1978"))
+}
+
+func (s *schedulerTestSuite) TestPollCompletedSucceeded() {
+	// February 30th never comes, so schedule has no more occurrences
+	s.schedule.Recurrence = repository.CronRecurrence
+	s.schedule.Cron = "0 0 30 2 *"
+	s.storeSchedule(&s.schedule)
+
+	_, err := s.newScheduler().poll(context.Background())
+	s.Require().NoError(err)
+
+	s.Len(s.runs(s.schedule.ID), 1)
+	s.Equal(repository.CompletedSchedule, s.getSchedule(s.schedule.ID).Status)
+}
+
+func (s *schedulerTestSuite) TestPollInsufficientFundsSkipped() {
+	s.schedule.Amount = 2000
+	s.storeSchedule(&s.schedule)
+
+	_, err := s.newScheduler().poll(context.Background())
+	s.Require().NoError(err)
+
+	runs := s.runs(s.schedule.ID)
+	s.Require().Len(runs, 1)
+	s.Equal(repository.SkippedRun, runs[0].Status)
+	s.Equal("insufficient funds on account toshik1978", runs[0].Error)
+	s.Equal(int64(1500), s.balance("toshik1978"))
+}
+
+func (s *schedulerTestSuite) TestPollInsufficientFundsRetried() {
+	s.schedule.Amount = 2000
+	s.schedule.OnInsufficientFunds = repository.RetryOnInsufficientFunds
+	s.storeSchedule(&s.schedule)
+
+	s.vars.ScheduleRetryInterval = 0
+	scheduler := s.newScheduler()
+	_, err := scheduler.poll(context.Background())
+	s.Require().NoError(err)
+
+	runs := s.runs(s.schedule.ID)
+	s.Require().Len(runs, 1)
+	s.Equal(repository.PendingRun, runs[0].Status)
+	s.Equal(1, runs[0].Attempts)
+
+	// The last attempt is skipped
+	_, err = scheduler.poll(context.Background())
+	s.Require().NoError(err)
+
+	runs = s.runs(s.schedule.ID)
+	s.Require().Len(runs, 1)
+	s.Equal(repository.SkippedRun, runs[0].Status)
+	s.Equal(2, runs[0].Attempts)
+}
+
+func (s *schedulerTestSuite) TestPollRecipientFrozenFailed() {
+	s.storeSchedule(&s.schedule)
+	_, err := NewAccountManager(s.globals).UpdateAccountStatus(context.Background(), "toshik1979", "frozen")
+	s.Require().NoError(err)
+
+	_, err = s.newScheduler().poll(context.Background())
+	s.Require().NoError(err)
+
+	runs := s.runs(s.schedule.ID)
+	s.Require().Len(runs, 1)
+	s.Equal(repository.FailedRun, runs[0].Status)
+	s.Equal("recipient account toshik1979 is frozen", runs[0].Error)
+}
+
+func (s *schedulerTestSuite) TestPollCancelledSkipped() {
+	s.storeSchedule(&s.schedule)
+	run := testutil.RepositoryScheduleRun()
+	run.ScheduleID = s.schedule.ID
+	run.NextAttemptAt = s.schedule.StartAt
+	s.Require().NoError(s.factory.ScheduleRunRepository().Store(context.Background(), &run))
+	s.Require().NoError(s.factory.ScheduleRepository().Cancel(context.Background(), s.schedule.ID, time.Now()))
+
+	// Run, claimed before cancellation, isn't paid
+	_, err := s.newScheduler().poll(context.Background())
+	s.Require().NoError(err)
+
+	runs := s.runs(s.schedule.ID)
+	s.Require().Len(runs, 1)
+	s.Equal(repository.SkippedRun, runs[0].Status)
+	s.Equal(int64(1500), s.balance("toshik1978"))
+}
+
+// newScheduler creates scheduler with the suite's storage
+func (s *schedulerTestSuite) newScheduler() *scheduler {
+	return NewScheduler(s.globals, s.vars).(*scheduler)
+}
+
+// storeSchedule stores the given schedule
+func (s *schedulerTestSuite) storeSchedule(schedule *repository.Schedule) {
+	s.Require().NoError(s.factory.ScheduleRepository().Store(context.Background(), schedule))
+}
+
+// getSchedule return schedule with the given ID
+func (s *schedulerTestSuite) getSchedule(id int64) repository.Schedule {
+	schedule, err := s.factory.ScheduleRepository().GetByID(context.Background(), id)
+	s.Require().NoError(err)
+	return *schedule
+}
+
+// runs return runs of the schedule with the given ID
+func (s *schedulerTestSuite) runs(id int64) []repository.ScheduleRun {
+	runs, err := s.factory.ScheduleRunRepository().GetBySchedule(context.Background(), id, repository.Page{Limit: 10})
+	s.Require().NoError(err)
+	return runs
+}
+
+// balance return balance of the account with the given UID
+func (s *schedulerTestSuite) balance(uid string) int64 {
+	account, err := s.factory.AccountRepository().GetByUID(context.Background(), uid)
+	s.Require().NoError(err)
+	return account.Balance
+}
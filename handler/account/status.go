@@ -0,0 +1,63 @@
+package account
+
+import (
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/handler"
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service/validator"
+)
+
+// accountTransitions define statuses, which account can be moved to from the given status.
+// Closed account can't be reopened
+var accountTransitions = map[repository.AccountStatus][]repository.AccountStatus{
+	repository.ActiveAccount: {repository.FrozenAccount, repository.ClosedAccount},
+	repository.FrozenAccount: {repository.ActiveAccount, repository.ClosedAccount},
+}
+
+// accountTransition validates change of account's status and creates transition.
+// nil transition returned, if account already has the given status
+func accountTransition(account repository.Account, status string) (*repository.AccountTransition, error) {
+	to := repository.AccountStatus(status)
+	switch to {
+	case repository.ActiveAccount, repository.FrozenAccount, repository.ClosedAccount:
+	default:
+		v := validator.NewValidator()
+		v.AddField("status", status, "one of ["+string(repository.ActiveAccount)+", "+
+			string(repository.FrozenAccount)+", "+string(repository.ClosedAccount)+"]")
+		return nil, handler.WrapError(v.Error(), "failed to validate account status", handler.ClientError)
+	}
+	if account.Status == to {
+		return nil, nil
+	}
+
+	allowed := false
+	for _, next := range accountTransitions[account.Status] {
+		allowed = allowed || next == to
+	}
+	if !allowed {
+		return nil, handler.NewError(
+			"account "+account.UID+" can't be changed from "+string(account.Status)+" to "+status,
+			handler.UnprocessableError)
+	}
+	if to == repository.ClosedAccount && account.Balance != 0 {
+		return nil, handler.NewError(
+			"account "+account.UID+" with non-zero balance can't be closed", handler.UnprocessableError)
+	}
+
+	return &repository.AccountTransition{
+		AccountUID: account.UID,
+		FromStatus: account.Status,
+		ToStatus:   to,
+		CreatedAt:  time.Now(),
+	}, nil
+}
+
+// activeAccount checks, that account can pay or receive payments. Role describes account's role in payment
+func activeAccount(account repository.Account, role string) error {
+	if account.Status != repository.ActiveAccount {
+		return handler.NewError(
+			role+" account "+account.UID+" is "+string(account.Status), handler.UnprocessableError)
+	}
+	return nil
+}
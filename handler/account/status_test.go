@@ -0,0 +1,101 @@
+package account
+
+import (
+	"errors"
+
+	"github.com/Toshik1978/go-rest-api/handler"
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service/testutil"
+	"github.com/stretchr/testify/suite"
+)
+
+type statusTestSuite struct {
+	suite.Suite
+
+	account repository.Account
+}
+
+func (s *statusTestSuite) SetupSuite() {
+	s.account = testutil.RepositoryAccount()
+}
+
+func (s *statusTestSuite) TestAccountTransitionBadStatusFailed() {
+	transition, err := accountTransition(s.account, "deleted")
+
+	var handlerError *handler.Error
+	s.Error(err)
+	s.True(errors.As(err, &handlerError))
+	s.Equal(handler.ClientError, handlerError.Kind)
+	s.Nil(transition)
+}
+
+func (s *statusTestSuite) TestAccountTransitionFromClosedFailed() {
+	account := s.account
+	account.Balance = 0
+	account.Status = repository.ClosedAccount
+	for _, status := range []string{"active", "frozen"} {
+		transition, err := accountTransition(account, status)
+
+		var handlerError *handler.Error
+		s.Error(err, status)
+		s.True(errors.As(err, &handlerError), status)
+		s.Equal(handler.UnprocessableError, handlerError.Kind, status)
+		s.Nil(transition, status)
+	}
+}
+
+func (s *statusTestSuite) TestAccountTransitionCloseWithBalanceFailed() {
+	transition, err := accountTransition(s.account, "closed")
+
+	var handlerError *handler.Error
+	s.Error(err)
+	s.True(errors.As(err, &handlerError))
+	s.Equal(handler.UnprocessableError, handlerError.Kind)
+	s.Nil(transition)
+}
+
+func (s *statusTestSuite) TestAccountTransitionSameStatusSucceeded() {
+	transition, err := accountTransition(s.account, "active")
+
+	s.NoError(err)
+	s.Nil(transition)
+}
+
+func (s *statusTestSuite) TestAccountTransitionSucceeded() {
+	account := s.account
+	account.Balance = 0
+	for _, test := range []struct {
+		from repository.AccountStatus
+		to   repository.AccountStatus
+	}{
+		{repository.ActiveAccount, repository.FrozenAccount},
+		{repository.FrozenAccount, repository.ActiveAccount},
+		{repository.ActiveAccount, repository.ClosedAccount},
+		{repository.FrozenAccount, repository.ClosedAccount},
+	} {
+		account.Status = test.from
+		transition, err := accountTransition(account, string(test.to))
+
+		s.NoError(err)
+		s.Require().NotNil(transition)
+		s.Equal(account.UID, transition.AccountUID)
+		s.Equal(test.from, transition.FromStatus)
+		s.Equal(test.to, transition.ToStatus)
+		s.False(transition.CreatedAt.IsZero())
+	}
+}
+
+func (s *statusTestSuite) TestActiveAccountFailed() {
+	account := s.account
+	account.Status = repository.FrozenAccount
+	err := activeAccount(account, "payer")
+
+	var handlerError *handler.Error
+	s.Error(err)
+	s.True(errors.As(err, &handlerError))
+	s.Equal(handler.UnprocessableError, handlerError.Kind)
+}
+
+func (s *statusTestSuite) TestActiveAccountSucceeded() {
+	s.NoError(activeAccount(s.account, "payer"))
+}
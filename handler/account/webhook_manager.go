@@ -0,0 +1,222 @@
+package account
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/handler"
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service/errutil"
+	"github.com/Toshik1978/go-rest-api/service/server"
+	"github.com/Toshik1978/go-rest-api/service/validator"
+)
+
+// secretBytes define size of the generated webhook's secret before encoding
+const secretBytes = 32
+
+// webhookEventTypes define event types webhook can subscribe to
+var webhookEventTypes = []string{
+	string(repository.AccountCreatedEvent),
+	string(repository.PaymentCompletedEvent),
+	string(repository.PaymentRefundedEvent),
+}
+
+// webhookManager implements WebhookManager interface
+type webhookManager struct {
+	repositoryFactory repository.Factory
+}
+
+// NewWebhookManager creates new implementation of WebhookManager interface
+func NewWebhookManager(globals server.Globals) handler.WebhookManager {
+	return &webhookManager{
+		repositoryFactory: globals.RepositoryFactory,
+	}
+}
+
+func (m *webhookManager) CreateWebhook(ctx context.Context, request handler.WebhookRequest) (*handler.Webhook, error) {
+	err := validator.NewValidator().
+		ValidateURL(request.URL).
+		ValidateEventType(request.EventType, webhookEventTypes).
+		ValidateSecret(request.Secret).
+		Error()
+	if err != nil {
+		return nil, handler.WrapError(err, "failed to validate webhook", handler.ClientError)
+	}
+
+	if request.AccountUID != "" {
+		_, err := m.repositoryFactory.AccountRepository().GetByUID(ctx, request.AccountUID)
+		if errors.Is(err, repository.ErrAccountNotFound) {
+			return nil, handler.WrapError(err, "failed to get account "+request.AccountUID, handler.NotFoundError)
+		}
+		if err != nil {
+			return nil, handler.WrapError(err, "failed to get account", handler.ServerError)
+		}
+	}
+
+	secret := request.Secret
+	if secret == "" {
+		if secret, err = generateSecret(); err != nil {
+			return nil, handler.WrapError(err, "failed to generate webhook secret", handler.ServerError)
+		}
+	}
+	webhook := repository.Webhook{
+		URL:        request.URL,
+		Secret:     secret,
+		EventType:  repository.EventType(request.EventType),
+		AccountUID: request.AccountUID,
+		CreatedAt:  time.Now(),
+	}
+	if err := m.repositoryFactory.WebhookRepository().Store(ctx, &webhook); err != nil {
+		return nil, handler.WrapError(err, "failed to store webhook", handler.ServerError)
+	}
+
+	// Secret is shown only once, so it should be saved by the client
+	result := mapRepositoryWebhook(webhook)
+	result.Secret = webhook.Secret
+	return result, nil
+}
+
+func (m *webhookManager) Webhook(ctx context.Context, id int64) (*handler.Webhook, error) {
+	webhook, err := m.getWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return mapRepositoryWebhook(*webhook), nil
+}
+
+func (m *webhookManager) AllWebhooks(ctx context.Context, page handler.PageRequest) (*handler.WebhookList, error) {
+	repositoryPage, err := repositoryPage(page)
+	if err != nil {
+		return nil, err
+	}
+	webhooks, err := m.repositoryFactory.WebhookRepository().GetAll(ctx, repositoryPage)
+	if err != nil {
+		return nil, errutil.Wrap(err, "failed to get webhooks")
+	}
+
+	count, more := pageSize(repositoryPage, len(webhooks))
+	list := &handler.WebhookList{
+		Webhooks: mapRepositoryWebhooks(webhooks[:count]),
+	}
+	if more {
+		list.NextCursor = encodeCursor(webhooks[count-1].ID)
+	}
+	return list, nil
+}
+
+func (m *webhookManager) DeleteWebhook(ctx context.Context, id int64) error {
+	err := m.repositoryFactory.WebhookRepository().Delete(ctx, id)
+	if errors.Is(err, repository.ErrWebhookNotFound) {
+		return handler.WrapError(err, "failed to get webhook "+strconv.FormatInt(id, 10), handler.NotFoundError)
+	}
+	if err != nil {
+		return handler.WrapError(err, "failed to delete webhook", handler.ServerError)
+	}
+	return nil
+}
+
+func (m *webhookManager) WebhookDeliveries(
+	ctx context.Context, id int64, page handler.PageRequest) (*handler.DeliveryList, error) {
+
+	repositoryPage, err := repositoryPage(page)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := m.getWebhook(ctx, id); err != nil {
+		return nil, err
+	}
+	deliveries, err := m.repositoryFactory.DeliveryRepository().GetByWebhook(ctx, id, repositoryPage)
+	if err != nil {
+		return nil, errutil.Wrap(err, "failed to get deliveries")
+	}
+
+	count, more := pageSize(repositoryPage, len(deliveries))
+	list := &handler.DeliveryList{
+		Deliveries: mapRepositoryDeliveries(deliveries[:count]),
+	}
+	if more {
+		list.NextCursor = encodeCursor(deliveries[count-1].ID)
+	}
+	return list, nil
+}
+
+func (m *webhookManager) Delivery(ctx context.Context, webhookID int64, deliveryID int64) (*handler.Delivery, error) {
+	delivery, err := m.getDelivery(ctx, webhookID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	attempts, err := m.repositoryFactory.DeliveryRepository().GetAttempts(ctx, deliveryID)
+	if err != nil {
+		return nil, handler.WrapError(err, "failed to get delivery attempts", handler.ServerError)
+	}
+
+	result := mapRepositoryDelivery(*delivery)
+	result.AttemptLog = mapRepositoryDeliveryAttempts(attempts)
+	return result, nil
+}
+
+func (m *webhookManager) Redeliver(ctx context.Context, webhookID int64, deliveryID int64) (*handler.Delivery, error) {
+	delivery, err := m.getDelivery(ctx, webhookID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Redelivered event gets the full count of attempts again
+	now := time.Now()
+	delivery.Status = repository.PendingDelivery
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = now
+	delivery.UpdatedAt = now
+	err = m.repositoryFactory.DeliveryRepository().Update(ctx, delivery)
+	if errors.Is(err, repository.ErrDeliveryNotFound) {
+		return nil, handler.WrapError(err, "failed to get delivery "+strconv.FormatInt(deliveryID, 10),
+			handler.NotFoundError)
+	}
+	if err != nil {
+		return nil, handler.WrapError(err, "failed to update delivery", handler.ServerError)
+	}
+	return mapRepositoryDelivery(*delivery), nil
+}
+
+// getWebhook return webhook with the given ID
+func (m *webhookManager) getWebhook(ctx context.Context, id int64) (*repository.Webhook, error) {
+	webhook, err := m.repositoryFactory.WebhookRepository().GetByID(ctx, id)
+	if errors.Is(err, repository.ErrWebhookNotFound) {
+		return nil, handler.WrapError(err, "failed to get webhook "+strconv.FormatInt(id, 10), handler.NotFoundError)
+	}
+	if err != nil {
+		return nil, handler.WrapError(err, "failed to get webhook", handler.ServerError)
+	}
+	return webhook, nil
+}
+
+// getDelivery return delivery with the given ID, if it belongs to the given webhook
+func (m *webhookManager) getDelivery(
+	ctx context.Context, webhookID int64, deliveryID int64) (*repository.Delivery, error) {
+
+	delivery, err := m.repositoryFactory.DeliveryRepository().GetByID(ctx, deliveryID)
+	if err == nil && delivery.WebhookID != webhookID {
+		err = repository.ErrDeliveryNotFound
+	}
+	if errors.Is(err, repository.ErrDeliveryNotFound) {
+		return nil, handler.WrapError(err, "failed to get delivery "+strconv.FormatInt(deliveryID, 10),
+			handler.NotFoundError)
+	}
+	if err != nil {
+		return nil, handler.WrapError(err, "failed to get delivery", handler.ServerError)
+	}
+	return delivery, nil
+}
+
+// generateSecret generates random hex encoded webhook's secret
+func generateSecret() (string, error) {
+	secret := make([]byte, secretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
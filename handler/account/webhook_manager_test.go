@@ -0,0 +1,372 @@
+package account
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Toshik1978/go-rest-api/handler"
+	"github.com/Toshik1978/go-rest-api/mock"
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service/server"
+	"github.com/Toshik1978/go-rest-api/service/testutil"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+)
+
+type webhookManagerTestSuite struct {
+	suite.Suite
+
+	request  handler.WebhookRequest
+	webhook  repository.Webhook
+	delivery repository.Delivery
+}
+
+func (s *webhookManagerTestSuite) SetupTest() {
+	s.webhook = testutil.RepositoryWebhook()
+	s.webhook.ID = 3
+	s.request = handler.WebhookRequest{
+		URL:        s.webhook.URL,
+		EventType:  string(s.webhook.EventType),
+		AccountUID: s.webhook.AccountUID,
+	}
+	s.delivery = testutil.RepositoryDelivery()
+	s.delivery.ID = 5
+	s.delivery.WebhookID = s.webhook.ID
+}
+
+func (s *webhookManagerTestSuite) TestCreateWebhookValidationFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	factory := mock.NewMockFactory(ctrl)
+
+	request := s.request
+	request.URL = "ftp://example.com"
+	request.EventType = "unknown"
+	webhookManager := NewWebhookManager(server.Globals{RepositoryFactory: factory})
+	webhook, err := webhookManager.CreateWebhook(context.Background(), request)
+
+	var handlerError *handler.Error
+	s.True(errors.As(err, &handlerError))
+	s.Equal(handler.ClientError, handlerError.Kind)
+	s.Nil(webhook)
+}
+
+func (s *webhookManagerTestSuite) TestCreateWebhookPrivateURLFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+	factory := mock.NewMockFactory(ctrl)
+
+	request := s.request
+	request.URL = "http://169.254.169.254/latest/meta-data"
+	webhookManager := NewWebhookManager(server.Globals{RepositoryFactory: factory})
+	webhook, err := webhookManager.CreateWebhook(context.Background(), request)
+
+	var handlerError *handler.Error
+	s.True(errors.As(err, &handlerError))
+	s.Equal(handler.ClientError, handlerError.Kind)
+	s.Nil(webhook)
+}
+
+func (s *webhookManagerTestSuite) TestCreateWebhookAccountNotFoundFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	accountRepository := mock.NewMockAccountRepository(ctrl)
+	accountRepository.
+		EXPECT().
+		GetByUID(gomock.Any(), gomock.Eq(s.request.AccountUID)).
+		Return(nil, repository.ErrAccountNotFound)
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		AccountRepository().
+		Return(accountRepository)
+
+	webhookManager := NewWebhookManager(server.Globals{RepositoryFactory: factory})
+	webhook, err := webhookManager.CreateWebhook(context.Background(), s.request)
+
+	var handlerError *handler.Error
+	s.True(errors.As(err, &handlerError))
+	s.Equal(handler.NotFoundError, handlerError.Kind)
+	s.Nil(webhook)
+}
+
+func (s *webhookManagerTestSuite) TestCreateWebhookSucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	account := testutil.RepositoryAccount()
+	accountRepository := mock.NewMockAccountRepository(ctrl)
+	accountRepository.
+		EXPECT().
+		GetByUID(gomock.Any(), gomock.Eq(s.request.AccountUID)).
+		Return(&account, nil)
+	webhookRepository := mock.NewMockWebhookRepository(ctrl)
+	webhookRepository.
+		EXPECT().
+		Store(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, webhook *repository.Webhook) error {
+			s.Equal(s.request.URL, webhook.URL)
+			s.Equal(s.webhook.EventType, webhook.EventType)
+			s.Equal(s.request.AccountUID, webhook.AccountUID)
+			// Secret is generated, if it's not given
+			s.Len(webhook.Secret, 2*secretBytes)
+			webhook.ID = s.webhook.ID
+			return nil
+		})
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		AccountRepository().
+		Return(accountRepository)
+	factory.
+		EXPECT().
+		WebhookRepository().
+		Return(webhookRepository)
+
+	webhookManager := NewWebhookManager(server.Globals{RepositoryFactory: factory})
+	webhook, err := webhookManager.CreateWebhook(context.Background(), s.request)
+
+	s.NoError(err)
+	s.Require().NotNil(webhook)
+	s.Equal(s.webhook.ID, webhook.ID)
+	s.Equal(s.request.URL, webhook.URL)
+	s.Len(webhook.Secret, 2*secretBytes)
+}
+
+func (s *webhookManagerTestSuite) TestWebhookNotFoundFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	webhookRepository := mock.NewMockWebhookRepository(ctrl)
+	webhookRepository.
+		EXPECT().
+		GetByID(gomock.Any(), gomock.Eq(s.webhook.ID)).
+		Return(nil, repository.ErrWebhookNotFound)
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		WebhookRepository().
+		Return(webhookRepository)
+
+	webhookManager := NewWebhookManager(server.Globals{RepositoryFactory: factory})
+	webhook, err := webhookManager.Webhook(context.Background(), s.webhook.ID)
+
+	var handlerError *handler.Error
+	s.True(errors.As(err, &handlerError))
+	s.Equal(handler.NotFoundError, handlerError.Kind)
+	s.Nil(webhook)
+}
+
+func (s *webhookManagerTestSuite) TestWebhookSucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	webhookRepository := mock.NewMockWebhookRepository(ctrl)
+	webhookRepository.
+		EXPECT().
+		GetByID(gomock.Any(), gomock.Eq(s.webhook.ID)).
+		Return(&s.webhook, nil)
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		WebhookRepository().
+		Return(webhookRepository)
+
+	webhookManager := NewWebhookManager(server.Globals{RepositoryFactory: factory})
+	webhook, err := webhookManager.Webhook(context.Background(), s.webhook.ID)
+
+	s.NoError(err)
+	s.Require().NotNil(webhook)
+	s.Equal(s.webhook.ID, webhook.ID)
+	// Secret is never shown again
+	s.Empty(webhook.Secret)
+}
+
+func (s *webhookManagerTestSuite) TestAllWebhooksSucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	second := s.webhook
+	second.ID++
+	webhookRepository := mock.NewMockWebhookRepository(ctrl)
+	webhookRepository.
+		EXPECT().
+		GetAll(gomock.Any(), gomock.Eq(repository.Page{Limit: 2})).
+		Return([]repository.Webhook{s.webhook, second}, nil)
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		WebhookRepository().
+		Return(webhookRepository)
+
+	webhookManager := NewWebhookManager(server.Globals{RepositoryFactory: factory})
+	list, err := webhookManager.AllWebhooks(context.Background(), handler.PageRequest{Limit: 1})
+
+	s.NoError(err)
+	s.Require().NotNil(list)
+	s.Len(list.Webhooks, 1)
+	s.Equal(encodeCursor(s.webhook.ID), list.NextCursor)
+}
+
+func (s *webhookManagerTestSuite) TestDeleteWebhookNotFoundFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	webhookRepository := mock.NewMockWebhookRepository(ctrl)
+	webhookRepository.
+		EXPECT().
+		Delete(gomock.Any(), gomock.Eq(s.webhook.ID)).
+		Return(repository.ErrWebhookNotFound)
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		WebhookRepository().
+		Return(webhookRepository)
+
+	webhookManager := NewWebhookManager(server.Globals{RepositoryFactory: factory})
+	err := webhookManager.DeleteWebhook(context.Background(), s.webhook.ID)
+
+	var handlerError *handler.Error
+	s.True(errors.As(err, &handlerError))
+	s.Equal(handler.NotFoundError, handlerError.Kind)
+}
+
+func (s *webhookManagerTestSuite) TestWebhookDeliveriesSucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	webhookRepository := mock.NewMockWebhookRepository(ctrl)
+	webhookRepository.
+		EXPECT().
+		GetByID(gomock.Any(), gomock.Eq(s.webhook.ID)).
+		Return(&s.webhook, nil)
+	deliveryRepository := mock.NewMockDeliveryRepository(ctrl)
+	deliveryRepository.
+		EXPECT().
+		GetByWebhook(gomock.Any(), gomock.Eq(s.webhook.ID), gomock.Eq(repository.Page{Limit: defaultPageLimit + 1})).
+		Return([]repository.Delivery{s.delivery}, nil)
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		WebhookRepository().
+		Return(webhookRepository)
+	factory.
+		EXPECT().
+		DeliveryRepository().
+		Return(deliveryRepository)
+
+	webhookManager := NewWebhookManager(server.Globals{RepositoryFactory: factory})
+	list, err := webhookManager.WebhookDeliveries(context.Background(), s.webhook.ID, handler.PageRequest{})
+
+	s.NoError(err)
+	s.Require().NotNil(list)
+	s.Require().Len(list.Deliveries, 1)
+	s.Equal(s.delivery.ID, list.Deliveries[0].ID)
+	s.Equal(string(repository.PendingDelivery), list.Deliveries[0].Status)
+	s.NotNil(list.Deliveries[0].NextAttemptAt)
+	s.Empty(list.NextCursor)
+}
+
+func (s *webhookManagerTestSuite) TestDeliveryOfOtherWebhookFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	deliveryRepository := mock.NewMockDeliveryRepository(ctrl)
+	deliveryRepository.
+		EXPECT().
+		GetByID(gomock.Any(), gomock.Eq(s.delivery.ID)).
+		Return(&s.delivery, nil)
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		DeliveryRepository().
+		Return(deliveryRepository)
+
+	webhookManager := NewWebhookManager(server.Globals{RepositoryFactory: factory})
+	delivery, err := webhookManager.Delivery(context.Background(), s.webhook.ID+1, s.delivery.ID)
+
+	var handlerError *handler.Error
+	s.True(errors.As(err, &handlerError))
+	s.Equal(handler.NotFoundError, handlerError.Kind)
+	s.Nil(delivery)
+}
+
+func (s *webhookManagerTestSuite) TestDeliverySucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	attempts := []repository.DeliveryAttempt{{
+		ID:         1,
+		DeliveryID: s.delivery.ID,
+		StatusCode: 500,
+		Error:      "event is rejected with status 500",
+		DurationMS: 15,
+		CreatedAt:  s.delivery.CreatedAt,
+	}}
+	deliveryRepository := mock.NewMockDeliveryRepository(ctrl)
+	deliveryRepository.
+		EXPECT().
+		GetByID(gomock.Any(), gomock.Eq(s.delivery.ID)).
+		Return(&s.delivery, nil)
+	deliveryRepository.
+		EXPECT().
+		GetAttempts(gomock.Any(), gomock.Eq(s.delivery.ID)).
+		Return(attempts, nil)
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		DeliveryRepository().
+		Return(deliveryRepository).
+		Times(2)
+
+	webhookManager := NewWebhookManager(server.Globals{RepositoryFactory: factory})
+	delivery, err := webhookManager.Delivery(context.Background(), s.webhook.ID, s.delivery.ID)
+
+	s.NoError(err)
+	s.Require().NotNil(delivery)
+	s.Equal([]handler.DeliveryAttempt{{
+		StatusCode: 500,
+		Error:      "event is rejected with status 500",
+		DurationMS: 15,
+		CreatedAt:  s.delivery.CreatedAt,
+	}}, delivery.AttemptLog)
+}
+
+func (s *webhookManagerTestSuite) TestRedeliverSucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	failed := s.delivery
+	failed.Status = repository.FailedDelivery
+	failed.Attempts = 10
+	deliveryRepository := mock.NewMockDeliveryRepository(ctrl)
+	deliveryRepository.
+		EXPECT().
+		GetByID(gomock.Any(), gomock.Eq(s.delivery.ID)).
+		Return(&failed, nil)
+	deliveryRepository.
+		EXPECT().
+		Update(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, delivery *repository.Delivery) error {
+			s.Equal(repository.PendingDelivery, delivery.Status)
+			s.Equal(0, delivery.Attempts)
+			s.Equal(delivery.UpdatedAt, delivery.NextAttemptAt)
+			return nil
+		})
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		DeliveryRepository().
+		Return(deliveryRepository).
+		Times(2)
+
+	webhookManager := NewWebhookManager(server.Globals{RepositoryFactory: factory})
+	delivery, err := webhookManager.Redeliver(context.Background(), s.webhook.ID, s.delivery.ID)
+
+	s.NoError(err)
+	s.Require().NotNil(delivery)
+	s.Equal(string(repository.PendingDelivery), delivery.Status)
+	s.Equal(0, delivery.Attempts)
+}
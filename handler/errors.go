@@ -12,26 +12,37 @@ type ErrorKind int8
 const (
 	ServerError ErrorKind = iota + 1
 	ClientError
+	ConflictError
+	UnprocessableError
+	NotFoundError
 )
 
-// Error define custom handler error
+// Error define custom handler error. Message is safe to show to the client, wrapped cause is not
 type Error struct {
 	error
-	Kind ErrorKind
+	Kind    ErrorKind
+	Message string
 }
 
 // NewError creates new custom handler error
 func NewError(message string, kind ErrorKind) error {
 	return &Error{
-		error: errors.New(message),
-		Kind:  kind,
+		error:   errors.New(message),
+		Kind:    kind,
+		Message: message,
 	}
 }
 
 // WrapError creates new wrapped handler error
 func WrapError(err error, message string, kind ErrorKind) error {
 	return &Error{
-		error: errutil.Wrap(err, message),
-		Kind:  kind,
+		error:   errutil.Wrap(err, message),
+		Kind:    kind,
+		Message: message,
 	}
 }
+
+// Unwrap returns wrapped error
+func (e *Error) Unwrap() error {
+	return e.error
+}
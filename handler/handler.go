@@ -1,6 +1,10 @@
 package handler
 
-import "context"
+import (
+	"context"
+
+	"github.com/Toshik1978/go-rest-api/service/money"
+)
 
 //go:generate mockgen -source handler.go -package mock -destination ../mock/handler.go
 
@@ -9,9 +13,11 @@ type AccountBuilder interface {
 	// SetUID initializes UID for the new account
 	SetUID(uid string) AccountBuilder
 	// SetBalance initializes initial balance for the new account
-	SetBalance(balance float64) AccountBuilder
+	SetBalance(balance money.Amount) AccountBuilder
 	// SetCurrency initializes currency for the new account
 	SetCurrency(currency string) AccountBuilder
+	// SetIdempotencyKey initializes idempotency key for the new account, empty key means no idempotency
+	SetIdempotencyKey(key string) AccountBuilder
 
 	// Build actually creates new account
 	Build(ctx context.Context) (*Account, error)
@@ -20,25 +26,95 @@ type AccountBuilder interface {
 // PaymentBuilder declare interface to build new payment
 type PaymentBuilder interface {
 	// SetAmount initializes amount for the new payment
-	SetAmount(amount float64) PaymentBuilder
+	SetAmount(amount money.Amount) PaymentBuilder
 	// SetPayer initializes payer for the new payment
 	SetPayer(uid string) PaymentBuilder
 	// SetRecipient initializes recipient for the new payment
 	SetRecipient(uid string) PaymentBuilder
+	// SetIdempotencyKey initializes idempotency key for the new payment, empty key means no idempotency
+	SetIdempotencyKey(key string) PaymentBuilder
 
 	// Build actually creates new payment
 	Build(ctx context.Context) (*Payment, error)
 }
 
+// RefundBuilder declare interface to build refund of the payment
+type RefundBuilder interface {
+	// SetPayment initializes ID of the payment to refund
+	SetPayment(id int64) RefundBuilder
+	// SetAmount initializes amount to refund in payer's currency of the payment, nil means the whole amount,
+	// which isn't refunded yet
+	SetAmount(amount *money.Amount) RefundBuilder
+	// SetIdempotencyKey initializes idempotency key for the new refund, empty key means no idempotency
+	SetIdempotencyKey(key string) RefundBuilder
+
+	// Build actually creates new refund
+	Build(ctx context.Context) (*Payment, error)
+}
+
 // AccountFactory declare interface to access accounts and payments information (kind of facade to simplify interface)
 type AccountManager interface {
-	// AllAccounts return all available accounts in the system
-	AllAccounts(ctx context.Context) ([]Account, error)
-	// AllPayments return all available payments in the system
-	AllPayments(ctx context.Context) ([]Payment, error)
+	// Account return account with the given UID
+	Account(ctx context.Context, uid string) (*Account, error)
+	// UpdateAccountStatus changes status of the account with the given UID: freeze, unfreeze or close it
+	UpdateAccountStatus(ctx context.Context, uid string, status string) (*Account, error)
+	// AllAccounts return page of all available accounts in the system
+	AllAccounts(ctx context.Context, page PageRequest) (*AccountList, error)
+	// AllPayments return page of all available payments in the system
+	AllPayments(ctx context.Context, page PageRequest) (*PaymentList, error)
+	// AccountPayments return page of the given account's payments, matched filter
+	AccountPayments(ctx context.Context, uid string, filter PaymentFilter, page PageRequest) (*PaymentList, error)
+	// AccountEvents streams activity of the account with the given UID: its payments and balance changes.
+	// Stream starts with payments after the given event, if it's not empty, and the current balance.
+	// Channel is closed, when context is done or when stream can't keep up with events
+	AccountEvents(ctx context.Context, uid string, lastEventID string) (<-chan AccountEvent, error)
+	// Reconcile checks balances of all accounts against the ledger and optionally corrects drifted ones
+	Reconcile(ctx context.Context, request ReconciliationRequest) (*Reconciliation, error)
 
 	// AccountBuilder instantiate new account builder
 	AccountBuilder() AccountBuilder
 	// PaymentBuilder instantiate new payment builder
 	PaymentBuilder() PaymentBuilder
+	// RefundBuilder instantiate new refund builder
+	RefundBuilder() RefundBuilder
+}
+
+// WebhookManager declare interface to manage webhooks and deliveries of events to them
+type WebhookManager interface {
+	// CreateWebhook creates new webhook, subscribed to events, matched request
+	CreateWebhook(ctx context.Context, request WebhookRequest) (*Webhook, error)
+	// Webhook return webhook with the given ID
+	Webhook(ctx context.Context, id int64) (*Webhook, error)
+	// AllWebhooks return page of all webhooks
+	AllWebhooks(ctx context.Context, page PageRequest) (*WebhookList, error)
+	// DeleteWebhook deletes webhook with the given ID together with its deliveries
+	DeleteWebhook(ctx context.Context, id int64) error
+	// WebhookDeliveries return page of the given webhook's deliveries
+	WebhookDeliveries(ctx context.Context, id int64, page PageRequest) (*DeliveryList, error)
+	// Delivery return the webhook's delivery with its attempt log
+	Delivery(ctx context.Context, webhookID int64, deliveryID int64) (*Delivery, error)
+	// Redeliver schedules the webhook's delivery to be delivered again as soon as possible
+	Redeliver(ctx context.Context, webhookID int64, deliveryID int64) (*Delivery, error)
+}
+
+// ScheduleManager declare interface to manage account's scheduled payments
+type ScheduleManager interface {
+	// CreateSchedule creates new scheduled payment from the account with the given UID
+	CreateSchedule(ctx context.Context, uid string, request ScheduleRequest) (*Schedule, error)
+	// Schedule return the account's scheduled payment with the given ID
+	Schedule(ctx context.Context, uid string, id int64) (*Schedule, error)
+	// AccountSchedules return page of the account's scheduled payments
+	AccountSchedules(ctx context.Context, uid string, page PageRequest) (*ScheduleList, error)
+	// CancelSchedule cancels the account's scheduled payment, so it's never run again
+	CancelSchedule(ctx context.Context, uid string, id int64) (*Schedule, error)
+	// ScheduleRuns return page of runs of the account's scheduled payment
+	ScheduleRuns(ctx context.Context, uid string, id int64, page PageRequest) (*ScheduleRunList, error)
+}
+
+// Scheduler declare interface to run scheduled payments in background
+type Scheduler interface {
+	// Start starts running of scheduled payments
+	Start()
+	// Stop stops running of scheduled payments and waits, till payments in progress are finished
+	Stop()
 }
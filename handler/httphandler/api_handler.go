@@ -1,21 +1,57 @@
 package httphandler
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/Toshik1978/go-rest-api/handler"
 	"github.com/Toshik1978/go-rest-api/service/errutil"
+	"github.com/Toshik1978/go-rest-api/service/money"
 	"github.com/Toshik1978/go-rest-api/service/server"
+	"github.com/Toshik1978/go-rest-api/service/validator"
 	"github.com/gorilla/mux"
 	"go.uber.org/zap"
 )
 
 const (
 	uidKey = "uid"
+
+	idempotencyKeyHeader = "Idempotency-Key"
+
+	limitParam  = "limit"
+	cursorParam = "cursor"
+
+	counterpartyParam = "counterparty"
+	directionParam    = "direction"
+	fromParam         = "from"
+	toParam           = "to"
+	minAmountParam    = "min_amount"
+	maxAmountParam    = "max_amount"
+
+	problemContentType = "application/problem+json"
+	problemType        = "about:blank"
+
+	validationErrorCode = "validation_failed"
+	internalErrorCode   = "internal_error"
 )
 
+// problemCodes define stable machine-readable codes of the problems by HTTP status
+var problemCodes = map[int]string{
+	http.StatusBadRequest:          "bad_request",
+	http.StatusNotFound:            "not_found",
+	http.StatusConflict:            "conflict",
+	http.StatusUnprocessableEntity: "unprocessable_entity",
+	http.StatusInternalServerError: internalErrorCode,
+	http.StatusServiceUnavailable:  "service_unavailable",
+	http.StatusGatewayTimeout:      "timeout",
+}
+
 // apiHandler declare handler API requests
 type apiHandler struct {
 	logger    *zap.Logger
@@ -50,14 +86,16 @@ func (h *apiHandler) ServerStatusHandler() http.Handler {
 func (h *apiHandler) CreateAccountHandler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Body == nil {
-			h.fail(w, errors.New("no body detected"), http.StatusBadRequest, "CreateAccountHandler")
+			h.fail(w, r,
+				handler.NewError("no body detected", handler.ClientError),
+				http.StatusBadRequest, "CreateAccountHandler")
 			return
 		}
 
 		var accountRequest handler.AccountRequest
 		decoder := json.NewDecoder(r.Body)
-		if h.fail(w,
-			errutil.Wrap(decoder.Decode(&accountRequest), "decode failed"),
+		if h.fail(w, r,
+			h.decodeError(decoder.Decode(&accountRequest)),
 			http.StatusBadRequest, "CreateAccountHandler") {
 
 			return
@@ -67,8 +105,9 @@ func (h *apiHandler) CreateAccountHandler() http.Handler {
 			SetUID(accountRequest.UID).
 			SetCurrency(accountRequest.Currency).
 			SetBalance(accountRequest.Balance).
+			SetIdempotencyKey(r.Header.Get(idempotencyKeyHeader)).
 			Build(r.Context())
-		if h.fail(w,
+		if h.fail(w, r,
 			errutil.Wrap(err, "failed to create account"),
 			http.StatusInternalServerError, "CreateAccountHandler") {
 
@@ -80,11 +119,80 @@ func (h *apiHandler) CreateAccountHandler() http.Handler {
 	})
 }
 
+// GetAccountHandler response with the given account
+func (h *apiHandler) GetAccountHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		if _, ok := vars[uidKey]; !ok {
+			// Theoretically it's impossible situation due to mux routing
+			// But just in case...
+			h.fail(w, r,
+				handler.NewError("no account detected", handler.ClientError),
+				http.StatusBadRequest, "GetAccountHandler")
+			return
+		}
+
+		account, err := h.accountManager.Account(r.Context(), vars[uidKey])
+		if h.fail(w, r,
+			errutil.Wrap(err, "failed to get account"),
+			http.StatusInternalServerError, "GetAccountHandler") {
+
+			return
+		}
+		h.writeResponse(w, account)
+	})
+}
+
+// UpdateAccountHandler changes status of the given account
+func (h *apiHandler) UpdateAccountHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Body == nil {
+			h.fail(w, r,
+				handler.NewError("no body detected", handler.ClientError),
+				http.StatusBadRequest, "UpdateAccountHandler")
+			return
+		}
+
+		vars := mux.Vars(r)
+		if _, ok := vars[uidKey]; !ok {
+			// Theoretically it's impossible situation due to mux routing
+			// But just in case...
+			h.fail(w, r,
+				handler.NewError("no account detected", handler.ClientError),
+				http.StatusBadRequest, "UpdateAccountHandler")
+			return
+		}
+
+		var updateRequest handler.AccountUpdateRequest
+		decoder := json.NewDecoder(r.Body)
+		if h.fail(w, r,
+			h.decodeError(decoder.Decode(&updateRequest)),
+			http.StatusBadRequest, "UpdateAccountHandler") {
+
+			return
+		}
+
+		account, err := h.accountManager.UpdateAccountStatus(r.Context(), vars[uidKey], updateRequest.Status)
+		if h.fail(w, r,
+			errutil.Wrap(err, "failed to update account"),
+			http.StatusInternalServerError, "UpdateAccountHandler") {
+
+			return
+		}
+		h.writeResponse(w, account)
+	})
+}
+
 // GetAllAccountsHandler response with all accounts
 func (h *apiHandler) GetAllAccountsHandler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		accounts, err := h.accountManager.AllAccounts(r.Context())
-		if h.fail(w,
+		page, err := h.pageRequest(r)
+		if h.fail(w, r, err, http.StatusBadRequest, "GetAllAccountsHandler") {
+			return
+		}
+
+		accounts, err := h.accountManager.AllAccounts(r.Context(), page)
+		if h.fail(w, r,
 			errutil.Wrap(err, "failed to get all accounts"),
 			http.StatusInternalServerError, "GetAllAccountsHandler") {
 
@@ -94,11 +202,16 @@ func (h *apiHandler) GetAllAccountsHandler() http.Handler {
 	})
 }
 
-// GetAllPaymentsHandler response with all payments for the given account
+// GetAllPaymentsHandler response with all payments
 func (h *apiHandler) GetAllPaymentsHandler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		payments, err := h.accountManager.AllPayments(r.Context())
-		if h.fail(w,
+		page, err := h.pageRequest(r)
+		if h.fail(w, r, err, http.StatusBadRequest, "GetAllPaymentsHandler") {
+			return
+		}
+
+		payments, err := h.accountManager.AllPayments(r.Context(), page)
+		if h.fail(w, r,
 			errutil.Wrap(err, "failed to get all payments"),
 			http.StatusInternalServerError, "GetAllPaymentsHandler") {
 
@@ -108,11 +221,46 @@ func (h *apiHandler) GetAllPaymentsHandler() http.Handler {
 	})
 }
 
+// GetAccountPaymentsHandler response with payments for the given account, matched filter
+func (h *apiHandler) GetAccountPaymentsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		if _, ok := vars[uidKey]; !ok {
+			// Theoretically it's impossible situation due to mux routing
+			// But just in case...
+			h.fail(w, r,
+				handler.NewError("no account detected", handler.ClientError),
+				http.StatusBadRequest, "GetAccountPaymentsHandler")
+			return
+		}
+
+		page, err := h.pageRequest(r)
+		if h.fail(w, r, err, http.StatusBadRequest, "GetAccountPaymentsHandler") {
+			return
+		}
+		filter, err := h.paymentFilter(r)
+		if h.fail(w, r, err, http.StatusBadRequest, "GetAccountPaymentsHandler") {
+			return
+		}
+
+		payments, err := h.accountManager.AccountPayments(r.Context(), vars[uidKey], filter, page)
+		if h.fail(w, r,
+			errutil.Wrap(err, "failed to get account payments"),
+			http.StatusInternalServerError, "GetAccountPaymentsHandler") {
+
+			return
+		}
+		h.writeResponse(w, payments)
+	})
+}
+
 // CreatePaymentHandler creates payment from one account to another
 func (h *apiHandler) CreatePaymentHandler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Body == nil {
-			h.fail(w, errors.New("no body detected"), http.StatusBadRequest, "CreatePaymentHandler")
+			h.fail(w, r,
+				handler.NewError("no body detected", handler.ClientError),
+				http.StatusBadRequest, "CreatePaymentHandler")
 			return
 		}
 
@@ -120,14 +268,16 @@ func (h *apiHandler) CreatePaymentHandler() http.Handler {
 		if _, ok := vars[uidKey]; !ok {
 			// Theoretically it's impossible situation due to mux routing
 			// But just in case...
-			h.fail(w, errors.New("no payer detected"), http.StatusBadRequest, "CreatePaymentHandler")
+			h.fail(w, r,
+				handler.NewError("no payer detected", handler.ClientError),
+				http.StatusBadRequest, "CreatePaymentHandler")
 			return
 		}
 
 		var paymentRequest handler.PaymentRequest
 		decoder := json.NewDecoder(r.Body)
-		if h.fail(w,
-			errutil.Wrap(decoder.Decode(&paymentRequest), "decode failed"),
+		if h.fail(w, r,
+			h.decodeError(decoder.Decode(&paymentRequest)),
 			http.StatusBadRequest, "CreatePaymentHandler") {
 
 			return
@@ -137,8 +287,9 @@ func (h *apiHandler) CreatePaymentHandler() http.Handler {
 			SetPayer(vars[uidKey]).
 			SetRecipient(paymentRequest.RecipientUID).
 			SetAmount(paymentRequest.Amount).
+			SetIdempotencyKey(r.Header.Get(idempotencyKeyHeader)).
 			Build(r.Context())
-		if h.fail(w,
+		if h.fail(w, r,
 			errutil.Wrap(err, "failed to create payment"),
 			http.StatusInternalServerError, "CreatePaymentHandler") {
 
@@ -150,8 +301,172 @@ func (h *apiHandler) CreatePaymentHandler() http.Handler {
 	})
 }
 
+// RefundPaymentHandler refunds payment fully or partially. Request without body refunds the whole amount,
+// which isn't refunded yet
+func (h *apiHandler) RefundPaymentHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := h.idParam(r, idKey)
+		if h.fail(w, r, err, http.StatusBadRequest, "RefundPaymentHandler") {
+			return
+		}
+
+		var refundRequest handler.RefundRequest
+		if r.Body != nil {
+			err := json.NewDecoder(r.Body).Decode(&refundRequest)
+			if errors.Is(err, io.EOF) {
+				err = nil
+			}
+			if h.fail(w, r, h.decodeError(err), http.StatusBadRequest, "RefundPaymentHandler") {
+				return
+			}
+		}
+
+		payment, err := h.accountManager.RefundBuilder().
+			SetPayment(id).
+			SetAmount(refundRequest.Amount).
+			SetIdempotencyKey(r.Header.Get(idempotencyKeyHeader)).
+			Build(r.Context())
+		if h.fail(w, r,
+			errutil.Wrap(err, "failed to refund payment"),
+			http.StatusInternalServerError, "RefundPaymentHandler") {
+
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		h.writeResponse(w, payment)
+	})
+}
+
+// ReconcileHandler reconciles accounts' balances with the ledger and response with report
+func (h *apiHandler) ReconcileHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Body == nil {
+			h.fail(w, r,
+				handler.NewError("no body detected", handler.ClientError),
+				http.StatusBadRequest, "ReconcileHandler")
+			return
+		}
+
+		var reconciliationRequest handler.ReconciliationRequest
+		decoder := json.NewDecoder(r.Body)
+		if h.fail(w, r,
+			h.decodeError(decoder.Decode(&reconciliationRequest)),
+			http.StatusBadRequest, "ReconcileHandler") {
+
+			return
+		}
+
+		reconciliation, err := h.accountManager.Reconcile(r.Context(), reconciliationRequest)
+		if h.fail(w, r,
+			errutil.Wrap(err, "failed to reconcile balances"),
+			http.StatusInternalServerError, "ReconcileHandler") {
+
+			return
+		}
+		h.writeResponse(w, reconciliation)
+	})
+}
+
+// pageRequest parses pagination parameters of the request
+func (h *apiHandler) pageRequest(r *http.Request) (handler.PageRequest, error) {
+	query := r.URL.Query()
+	page := handler.PageRequest{
+		Cursor: query.Get(cursorParam),
+	}
+	if limit := query.Get(limitParam); limit != "" {
+		var err error
+		if page.Limit, err = strconv.Atoi(limit); err != nil {
+			return page, handler.WrapError(err, "invalid limit", handler.ClientError)
+		}
+	}
+	return page, nil
+}
+
+// idParam parses numeric ID from the request's path
+func (h *apiHandler) idParam(r *http.Request, key string) (int64, error) {
+	value, ok := mux.Vars(r)[key]
+	if !ok {
+		// Theoretically it's impossible situation due to mux routing
+		// But just in case...
+		return 0, handler.NewError("no "+key+" detected", handler.ClientError)
+	}
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, handler.WrapError(err, "invalid "+key, handler.ClientError)
+	}
+	return id, nil
+}
+
+// paymentFilter parses filter of payments from the request
+func (h *apiHandler) paymentFilter(r *http.Request) (handler.PaymentFilter, error) {
+	query := r.URL.Query()
+	filter := handler.PaymentFilter{
+		Counterparty: query.Get(counterpartyParam),
+		Direction:    query.Get(directionParam),
+	}
+
+	var err error
+	if filter.From, err = h.timeParam(query, fromParam); err != nil {
+		return filter, err
+	}
+	if filter.To, err = h.timeParam(query, toParam); err != nil {
+		return filter, err
+	}
+	if filter.MinAmount, err = h.amountParam(query, minAmountParam); err != nil {
+		return filter, err
+	}
+	if filter.MaxAmount, err = h.amountParam(query, maxAmountParam); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+// timeParam parses optional RFC 3339 time parameter
+func (h *apiHandler) timeParam(query url.Values, name string) (*time.Time, error) {
+	value := query.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, handler.WrapError(err, "invalid "+name, handler.ClientError)
+	}
+	return &t, nil
+}
+
+// amountParam parses optional money amount parameter
+func (h *apiHandler) amountParam(query url.Values, name string) (*money.Amount, error) {
+	value := query.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	amount, err := money.Parse(value)
+	if err != nil {
+		return nil, handler.WrapError(err, "invalid "+name, handler.ClientError)
+	}
+	return &amount, nil
+}
+
+// decodeError wraps error of the request's body decoding
+func (h *apiHandler) decodeError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return handler.WrapError(err, "failed to decode request body", handler.ClientError)
+}
+
 // httpCode looks at error and tries to provide correct http status code or defaultCode otherwise
-func (h *apiHandler) httpCode(err error, defaultCode int) int {
+func (h *apiHandler) httpCode(r *http.Request, err error, defaultCode int) int {
+	// Request's timeout cancels running queries, so whatever error they return, the request is timed out.
+	// Cancelled request means client has gone or server is shutting down
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(r.Context().Err(), context.DeadlineExceeded) {
+		return http.StatusGatewayTimeout
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(r.Context().Err(), context.Canceled) {
+		return http.StatusServiceUnavailable
+	}
+
 	var handlerError *handler.Error
 	if err != nil && errors.As(err, &handlerError) {
 		switch handlerError.Kind {
@@ -159,21 +474,80 @@ func (h *apiHandler) httpCode(err error, defaultCode int) int {
 			return http.StatusInternalServerError
 		case handler.ClientError:
 			return http.StatusBadRequest
+		case handler.ConflictError:
+			return http.StatusConflict
+		case handler.UnprocessableError:
+			return http.StatusUnprocessableEntity
+		case handler.NotFoundError:
+			return http.StatusNotFound
 		}
 	}
 	return defaultCode
 }
 
+// problem builds problem details of the failed request. Only public message of the handler error is exposed,
+// the whole error chain is not trusted to be shown to the client
+func (h *apiHandler) problem(r *http.Request, err error, status int) handler.Problem {
+	problem := handler.Problem{
+		Type:      problemType,
+		Title:     http.StatusText(status),
+		Status:    status,
+		Code:      problemCodes[status],
+		RequestID: requestID(r.Context()),
+	}
+	if problem.Code == "" {
+		problem.Code = internalErrorCode
+	}
+
+	var handlerError *handler.Error
+	if status < http.StatusInternalServerError && errors.As(err, &handlerError) {
+		problem.Detail = handlerError.Message
+	}
+	var validationError *validator.Error
+	if status < http.StatusInternalServerError && errors.As(err, &validationError) {
+		problem.Code = validationErrorCode
+		for _, field := range validationError.Fields {
+			problem.Errors = append(problem.Errors, handler.ProblemField{
+				Field:    field.Field,
+				Expected: field.Expected,
+				Actual:   field.Actual,
+			})
+		}
+	}
+	return problem
+}
+
 // fail fails request
-func (h *apiHandler) fail(w http.ResponseWriter, err error, code int, method string) bool {
+func (h *apiHandler) fail(w http.ResponseWriter, r *http.Request, err error, code int, method string) bool {
 	if err != nil {
-		h.logger.Error("Failed to handle "+method, zap.Error(err))
-		http.Error(w, err.Error(), h.httpCode(err, code))
+		status := h.httpCode(r, err, code)
+		h.logger.Error("Failed to handle "+method,
+			zap.Error(err),
+			zap.Int("status", status),
+			zap.String("request_id", requestID(r.Context())))
+
+		h.writeProblem(w, r, err, status)
 		return true
 	}
 	return false
 }
 
+// writeProblem writes problem details of the failed request
+func (h *apiHandler) writeProblem(w http.ResponseWriter, r *http.Request, err error, status int) {
+	payload, marshalErr := json.Marshal(h.problem(r, err, status))
+	if marshalErr != nil {
+		h.logger.Error("Failed to marshal HTTP problem", zap.Error(marshalErr))
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	w.Header().Set("Content-Type", problemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	if _, writeErr := w.Write(payload); writeErr != nil {
+		h.logger.Error("Failed to write HTTP problem", zap.Error(writeErr))
+	}
+}
+
 // writeResponse write response
 func (h *apiHandler) writeResponse(w http.ResponseWriter, response interface{}) {
 	w.Header().Set("Content-Type", "application/json")
@@ -2,16 +2,20 @@ package httphandler
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
 	"time"
 
+	"github.com/AlekSi/pointer"
 	"github.com/Toshik1978/go-rest-api/handler"
 	"github.com/Toshik1978/go-rest-api/mock"
+	"github.com/Toshik1978/go-rest-api/service/money"
 	"github.com/Toshik1978/go-rest-api/service/server"
 	"github.com/Toshik1978/go-rest-api/service/testutil"
+	"github.com/Toshik1978/go-rest-api/service/validator"
 	"github.com/golang/mock/gomock"
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/suite"
@@ -42,6 +46,13 @@ func newFailResponseWriter() http.ResponseWriter {
 	}
 }
 
+// decodeProblem decodes problem details of the failed response
+func decodeProblem(r *httptest.ResponseRecorder) handler.Problem {
+	var problem handler.Problem
+	_ = json.Unmarshal(r.Body.Bytes(), &problem)
+	return problem
+}
+
 // apiHandlerTestSuite test suite
 type apiHandlerTestSuite struct {
 	suite.Suite
@@ -114,6 +125,14 @@ func (s *apiHandlerTestSuite) TestCreateAccountHandlerNoBodyFailed() {
 	s.Equal(1, zapRecorded.Len())
 	s.Equal("Failed to handle CreateAccountHandler", zapRecorded.All()[0].Message)
 	s.Equal(http.StatusBadRequest, r.Code)
+	s.Equal("application/problem+json", r.Header().Get("Content-Type"))
+	s.Equal(handler.Problem{
+		Type:   "about:blank",
+		Title:  "Bad Request",
+		Status: http.StatusBadRequest,
+		Code:   "bad_request",
+		Detail: "no body detected",
+	}, decodeProblem(r))
 }
 
 func (s *apiHandlerTestSuite) TestCreateAccountHandlerBadRequestFailed() {
@@ -137,6 +156,7 @@ func (s *apiHandlerTestSuite) TestCreateAccountHandlerBadRequestFailed() {
 	s.Equal(1, zapRecorded.Len())
 	s.Equal("Failed to handle CreateAccountHandler", zapRecorded.All()[0].Message)
 	s.Equal(http.StatusBadRequest, r.Code)
+	s.Equal("failed to decode request body", decodeProblem(r).Detail)
 }
 
 func (s *apiHandlerTestSuite) TestCreateAccountHandlerFailed() {
@@ -163,6 +183,10 @@ func (s *apiHandlerTestSuite) TestCreateAccountHandlerFailed() {
 		EXPECT().
 		SetBalance(gomock.Eq(request.Balance)).
 		Return(accountBuilder)
+	accountBuilder.
+		EXPECT().
+		SetIdempotencyKey(gomock.Eq("")).
+		Return(accountBuilder)
 	accountBuilder.
 		EXPECT().
 		Build(gomock.Any()).
@@ -187,6 +211,69 @@ func (s *apiHandlerTestSuite) TestCreateAccountHandlerFailed() {
 	s.Equal(http.StatusInternalServerError, r.Code)
 }
 
+func (s *apiHandlerTestSuite) TestCreateAccountHandlerValidationFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	request := testutil.AccountRequest()
+	payload, _ := json.Marshal(request)
+	req, err := http.NewRequest("POST", "/", bytes.NewBuffer(payload))
+	if err != nil {
+		s.T().Fatal(err)
+	}
+
+	v := validator.NewValidator().ValidateUID("uid", "")
+	accountBuilder := mock.NewMockAccountBuilder(ctrl)
+	accountBuilder.
+		EXPECT().
+		SetUID(gomock.Eq(request.UID)).
+		Return(accountBuilder)
+	accountBuilder.
+		EXPECT().
+		SetCurrency(gomock.Eq(request.Currency)).
+		Return(accountBuilder)
+	accountBuilder.
+		EXPECT().
+		SetBalance(gomock.Eq(request.Balance)).
+		Return(accountBuilder)
+	accountBuilder.
+		EXPECT().
+		SetIdempotencyKey(gomock.Eq("")).
+		Return(accountBuilder)
+	accountBuilder.
+		EXPECT().
+		Build(gomock.Any()).
+		Return(nil, handler.WrapError(v.Error(), "failed to validate account", handler.ClientError))
+
+	accountManager := mock.NewMockAccountManager(ctrl)
+	accountManager.
+		EXPECT().
+		AccountBuilder().
+		Return(accountBuilder)
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	apiHandler := newAPIHandler(server.Globals{
+		Logger: zap.New(zapCore),
+	}, accountManager).CreateAccountHandler()
+
+	r := httptest.NewRecorder()
+	apiHandler.ServeHTTP(r, req)
+
+	s.Equal(1, zapRecorded.Len())
+	s.Equal("Failed to handle CreateAccountHandler", zapRecorded.All()[0].Message)
+	s.Equal(http.StatusBadRequest, r.Code)
+	s.Equal(handler.Problem{
+		Type:   "about:blank",
+		Title:  "Bad Request",
+		Status: http.StatusBadRequest,
+		Code:   "validation_failed",
+		Detail: "failed to validate account",
+		Errors: []handler.ProblemField{
+			{Field: "uid", Expected: "string", Actual: "nil"},
+		},
+	}, decodeProblem(r))
+}
+
 func (s *apiHandlerTestSuite) TestCreateAccountHandlerSucceeded() {
 	ctrl := gomock.NewController(s.T())
 	defer ctrl.Finish()
@@ -198,6 +285,7 @@ func (s *apiHandlerTestSuite) TestCreateAccountHandlerSucceeded() {
 	if err != nil {
 		s.T().Fatal(err)
 	}
+	req.Header.Set("Idempotency-Key", "4d1f7a9e-6a0c-4a43-9a5e-0c1b2f3d4e5f")
 
 	accountBuilder := mock.NewMockAccountBuilder(ctrl)
 	accountBuilder.
@@ -212,6 +300,10 @@ func (s *apiHandlerTestSuite) TestCreateAccountHandlerSucceeded() {
 		EXPECT().
 		SetBalance(gomock.Eq(request.Balance)).
 		Return(accountBuilder)
+	accountBuilder.
+		EXPECT().
+		SetIdempotencyKey(gomock.Eq("4d1f7a9e-6a0c-4a43-9a5e-0c1b2f3d4e5f")).
+		Return(accountBuilder)
 	accountBuilder.
 		EXPECT().
 		Build(gomock.Any()).
@@ -270,7 +362,7 @@ func (s *apiHandlerTestSuite) TestCreatePaymentHandlerBadRequestFailed() {
 	ctrl := gomock.NewController(s.T())
 	defer ctrl.Finish()
 
-	payload := `{"amount": "100"}`
+	payload := `{"amount": "one hundred"}`
 	req, err := http.NewRequest("POST", "/", bytes.NewBuffer([]byte(payload)))
 	if err != nil {
 		s.T().Fatal(err)
@@ -294,7 +386,7 @@ func (s *apiHandlerTestSuite) TestCreatePaymentHandlerBadURLFailed() {
 	defer ctrl.Finish()
 
 	account := testutil.AccountRequest()
-	payload := `{"amount": "100"}`
+	payload := `{"amount": "one hundred"}`
 	req, err := http.NewRequest("POST", "/", bytes.NewBuffer([]byte(payload)))
 	if err != nil {
 		s.T().Fatal(err)
@@ -344,6 +436,10 @@ func (s *apiHandlerTestSuite) TestCreatePaymentHandlerFailed() {
 		EXPECT().
 		SetAmount(gomock.Eq(request.Amount)).
 		Return(paymentBuilder)
+	paymentBuilder.
+		EXPECT().
+		SetIdempotencyKey(gomock.Eq("")).
+		Return(paymentBuilder)
 	paymentBuilder.
 		EXPECT().
 		Build(gomock.Any()).
@@ -396,6 +492,10 @@ func (s *apiHandlerTestSuite) TestCreatePaymentHandlerClientErrorFailed() {
 		EXPECT().
 		SetAmount(gomock.Eq(request.Amount)).
 		Return(paymentBuilder)
+	paymentBuilder.
+		EXPECT().
+		SetIdempotencyKey(gomock.Eq("")).
+		Return(paymentBuilder)
 	paymentBuilder.
 		EXPECT().
 		Build(gomock.Any()).
@@ -418,6 +518,124 @@ func (s *apiHandlerTestSuite) TestCreatePaymentHandlerClientErrorFailed() {
 	s.Equal(1, zapRecorded.Len())
 	s.Equal("Failed to handle CreatePaymentHandler", zapRecorded.All()[0].Message)
 	s.Equal(http.StatusBadRequest, r.Code)
+	s.Equal("bad_request", decodeProblem(r).Code)
+	s.Equal("fail", decodeProblem(r).Detail)
+}
+
+func (s *apiHandlerTestSuite) TestCreatePaymentHandlerConflictErrorFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	account := testutil.AccountRequest()
+	request := testutil.PaymentRequest()
+	payload, _ := json.Marshal(request)
+	req, err := http.NewRequest("POST", "/", bytes.NewBuffer(payload))
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	req.Header.Set("Idempotency-Key", "4d1f7a9e-6a0c-4a43-9a5e-0c1b2f3d4e5f")
+	req = mux.SetURLVars(req, map[string]string{
+		"uid": account.UID,
+	})
+
+	paymentBuilder := mock.NewMockPaymentBuilder(ctrl)
+	paymentBuilder.
+		EXPECT().
+		SetPayer(gomock.Eq(account.UID)).
+		Return(paymentBuilder)
+	paymentBuilder.
+		EXPECT().
+		SetRecipient(gomock.Eq(request.RecipientUID)).
+		Return(paymentBuilder)
+	paymentBuilder.
+		EXPECT().
+		SetAmount(gomock.Eq(request.Amount)).
+		Return(paymentBuilder)
+	paymentBuilder.
+		EXPECT().
+		SetIdempotencyKey(gomock.Eq("4d1f7a9e-6a0c-4a43-9a5e-0c1b2f3d4e5f")).
+		Return(paymentBuilder)
+	paymentBuilder.
+		EXPECT().
+		Build(gomock.Any()).
+		Return(nil, handler.NewError("fail", handler.ConflictError))
+
+	accountManager := mock.NewMockAccountManager(ctrl)
+	accountManager.
+		EXPECT().
+		PaymentBuilder().
+		Return(paymentBuilder)
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	apiHandler := newAPIHandler(server.Globals{
+		Logger: zap.New(zapCore),
+	}, accountManager).CreatePaymentHandler()
+
+	r := httptest.NewRecorder()
+	apiHandler.ServeHTTP(r, req)
+
+	s.Equal(1, zapRecorded.Len())
+	s.Equal("Failed to handle CreatePaymentHandler", zapRecorded.All()[0].Message)
+	s.Equal(http.StatusConflict, r.Code)
+	s.Equal("conflict", decodeProblem(r).Code)
+}
+
+func (s *apiHandlerTestSuite) TestCreatePaymentHandlerUnprocessableErrorFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	account := testutil.AccountRequest()
+	request := testutil.PaymentRequest()
+	payload, _ := json.Marshal(request)
+	req, err := http.NewRequest("POST", "/", bytes.NewBuffer(payload))
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	req.Header.Set("Idempotency-Key", "4d1f7a9e-6a0c-4a43-9a5e-0c1b2f3d4e5f")
+	req = mux.SetURLVars(req, map[string]string{
+		"uid": account.UID,
+	})
+
+	paymentBuilder := mock.NewMockPaymentBuilder(ctrl)
+	paymentBuilder.
+		EXPECT().
+		SetPayer(gomock.Eq(account.UID)).
+		Return(paymentBuilder)
+	paymentBuilder.
+		EXPECT().
+		SetRecipient(gomock.Eq(request.RecipientUID)).
+		Return(paymentBuilder)
+	paymentBuilder.
+		EXPECT().
+		SetAmount(gomock.Eq(request.Amount)).
+		Return(paymentBuilder)
+	paymentBuilder.
+		EXPECT().
+		SetIdempotencyKey(gomock.Eq("4d1f7a9e-6a0c-4a43-9a5e-0c1b2f3d4e5f")).
+		Return(paymentBuilder)
+	paymentBuilder.
+		EXPECT().
+		Build(gomock.Any()).
+		Return(nil, handler.NewError("fail", handler.UnprocessableError))
+
+	accountManager := mock.NewMockAccountManager(ctrl)
+	accountManager.
+		EXPECT().
+		PaymentBuilder().
+		Return(paymentBuilder)
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	apiHandler := newAPIHandler(server.Globals{
+		Logger: zap.New(zapCore),
+	}, accountManager).CreatePaymentHandler()
+
+	r := httptest.NewRecorder()
+	apiHandler.ServeHTTP(r, req)
+
+	s.Equal(1, zapRecorded.Len())
+	s.Equal("Failed to handle CreatePaymentHandler", zapRecorded.All()[0].Message)
+	s.Equal(http.StatusUnprocessableEntity, r.Code)
+	s.Equal("unprocessable_entity", decodeProblem(r).Code)
 }
 
 func (s *apiHandlerTestSuite) TestCreatePaymentHandlerServerErrorFailed() {
@@ -448,6 +666,10 @@ func (s *apiHandlerTestSuite) TestCreatePaymentHandlerServerErrorFailed() {
 		EXPECT().
 		SetAmount(gomock.Eq(request.Amount)).
 		Return(paymentBuilder)
+	paymentBuilder.
+		EXPECT().
+		SetIdempotencyKey(gomock.Eq("")).
+		Return(paymentBuilder)
 	paymentBuilder.
 		EXPECT().
 		Build(gomock.Any()).
@@ -470,6 +692,12 @@ func (s *apiHandlerTestSuite) TestCreatePaymentHandlerServerErrorFailed() {
 	s.Equal(1, zapRecorded.Len())
 	s.Equal("Failed to handle CreatePaymentHandler", zapRecorded.All()[0].Message)
 	s.Equal(http.StatusInternalServerError, r.Code)
+	s.Equal(handler.Problem{
+		Type:   "about:blank",
+		Title:  "Internal Server Error",
+		Status: http.StatusInternalServerError,
+		Code:   "internal_error",
+	}, decodeProblem(r))
 }
 
 func (s *apiHandlerTestSuite) TestCreatePaymentHandlerSucceeded() {
@@ -484,6 +712,7 @@ func (s *apiHandlerTestSuite) TestCreatePaymentHandlerSucceeded() {
 	if err != nil {
 		s.T().Fatal(err)
 	}
+	req.Header.Set("Idempotency-Key", "4d1f7a9e-6a0c-4a43-9a5e-0c1b2f3d4e5f")
 	req = mux.SetURLVars(req, map[string]string{
 		"uid": account.UID,
 	})
@@ -501,6 +730,10 @@ func (s *apiHandlerTestSuite) TestCreatePaymentHandlerSucceeded() {
 		EXPECT().
 		SetAmount(gomock.Eq(request.Amount)).
 		Return(paymentBuilder)
+	paymentBuilder.
+		EXPECT().
+		SetIdempotencyKey(gomock.Eq("4d1f7a9e-6a0c-4a43-9a5e-0c1b2f3d4e5f")).
+		Return(paymentBuilder)
 	paymentBuilder.
 		EXPECT().
 		Build(gomock.Any()).
@@ -535,90 +768,165 @@ func (s *apiHandlerTestSuite) TestCreatePaymentHandlerSucceeded() {
 		}, "Actual and expected payments are different!")
 }
 
-func (s *apiHandlerTestSuite) TestGetAllAccountsHandlerFailed() {
+func (s *apiHandlerTestSuite) TestRefundPaymentHandlerBadRequestFailed() {
 	ctrl := gomock.NewController(s.T())
 	defer ctrl.Finish()
 
-	req, err := http.NewRequest("GET", "/", nil)
+	req, err := http.NewRequest("POST", "/", bytes.NewBufferString("{"))
 	if err != nil {
 		s.T().Fatal(err)
 	}
+	req = mux.SetURLVars(req, map[string]string{
+		"id": "1234",
+	})
 
 	accountManager := mock.NewMockAccountManager(ctrl)
-	accountManager.
-		EXPECT().
-		AllAccounts(gomock.Any()).
-		Return(nil, errors.New("fail"))
 
 	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
 	apiHandler := newAPIHandler(server.Globals{
 		Logger: zap.New(zapCore),
-	}, accountManager).GetAllAccountsHandler()
+	}, accountManager).RefundPaymentHandler()
 
 	r := httptest.NewRecorder()
 	apiHandler.ServeHTTP(r, req)
 
 	s.Equal(1, zapRecorded.Len())
-	s.Equal("Failed to handle GetAllAccountsHandler", zapRecorded.All()[0].Message)
-	s.Equal(http.StatusInternalServerError, r.Code)
+	s.Equal("Failed to handle RefundPaymentHandler", zapRecorded.All()[0].Message)
+	s.Equal(http.StatusBadRequest, r.Code)
 }
 
-func (s *apiHandlerTestSuite) TestGetAllAccountsHandlerSucceeded() {
+func (s *apiHandlerTestSuite) TestRefundPaymentHandlerUnprocessableErrorFailed() {
 	ctrl := gomock.NewController(s.T())
 	defer ctrl.Finish()
 
-	req, err := http.NewRequest("GET", "/", nil)
+	amount := money.MustParse("10.00")
+	payload, _ := json.Marshal(handler.RefundRequest{Amount: &amount})
+	req, err := http.NewRequest("POST", "/", bytes.NewBuffer(payload))
 	if err != nil {
 		s.T().Fatal(err)
 	}
+	req = mux.SetURLVars(req, map[string]string{
+		"id": "1234",
+	})
+
+	refundBuilder := mock.NewMockRefundBuilder(ctrl)
+	refundBuilder.
+		EXPECT().
+		SetPayment(gomock.Eq(int64(1234))).
+		Return(refundBuilder)
+	refundBuilder.
+		EXPECT().
+		SetAmount(gomock.Eq(&amount)).
+		Return(refundBuilder)
+	refundBuilder.
+		EXPECT().
+		SetIdempotencyKey(gomock.Eq("")).
+		Return(refundBuilder)
+	refundBuilder.
+		EXPECT().
+		Build(gomock.Any()).
+		Return(nil, handler.NewError("fail", handler.UnprocessableError))
 
 	accountManager := mock.NewMockAccountManager(ctrl)
 	accountManager.
 		EXPECT().
-		AllAccounts(gomock.Any()).
-		Return(nil, nil)
+		RefundBuilder().
+		Return(refundBuilder)
 
 	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
 	apiHandler := newAPIHandler(server.Globals{
 		Logger: zap.New(zapCore),
-	}, accountManager).GetAllAccountsHandler()
+	}, accountManager).RefundPaymentHandler()
 
 	r := httptest.NewRecorder()
 	apiHandler.ServeHTTP(r, req)
 
-	s.Equal(0, zapRecorded.Len())
-	s.Equal(http.StatusOK, r.Code)
+	s.Equal(1, zapRecorded.Len())
+	s.Equal("Failed to handle RefundPaymentHandler", zapRecorded.All()[0].Message)
+	s.Equal(http.StatusUnprocessableEntity, r.Code)
+	s.Equal("unprocessable_entity", decodeProblem(r).Code)
 }
 
-func (s *apiHandlerTestSuite) TestGetAllPaymentsHandlerFailed() {
+func (s *apiHandlerTestSuite) TestRefundPaymentHandlerSucceeded() {
 	ctrl := gomock.NewController(s.T())
 	defer ctrl.Finish()
 
-	req, err := http.NewRequest("GET", "/", nil)
+	payment := testutil.PaymentResponse()
+	payment.RefundOf = pointer.ToInt64(1234)
+	// Request without body refunds the whole amount
+	req, err := http.NewRequest("POST", "/", nil)
 	if err != nil {
 		s.T().Fatal(err)
 	}
+	req.Header.Set("Idempotency-Key", "4d1f7a9e-6a0c-4a43-9a5e-0c1b2f3d4e5f")
+	req = mux.SetURLVars(req, map[string]string{
+		"id": "1234",
+	})
+
+	refundBuilder := mock.NewMockRefundBuilder(ctrl)
+	refundBuilder.
+		EXPECT().
+		SetPayment(gomock.Eq(int64(1234))).
+		Return(refundBuilder)
+	refundBuilder.
+		EXPECT().
+		SetAmount(gomock.Nil()).
+		Return(refundBuilder)
+	refundBuilder.
+		EXPECT().
+		SetIdempotencyKey(gomock.Eq("4d1f7a9e-6a0c-4a43-9a5e-0c1b2f3d4e5f")).
+		Return(refundBuilder)
+	refundBuilder.
+		EXPECT().
+		Build(gomock.Any()).
+		Return(&payment, nil)
 
 	accountManager := mock.NewMockAccountManager(ctrl)
 	accountManager.
 		EXPECT().
-		AllPayments(gomock.Any()).
-		Return(nil, errors.New("fail"))
+		RefundBuilder().
+		Return(refundBuilder)
 
 	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
 	apiHandler := newAPIHandler(server.Globals{
 		Logger: zap.New(zapCore),
-	}, accountManager).GetAllPaymentsHandler()
+	}, accountManager).RefundPaymentHandler()
+
+	r := httptest.NewRecorder()
+	apiHandler.ServeHTTP(r, req)
+
+	var response handler.Payment
+	_ = json.Unmarshal(r.Body.Bytes(), &response)
+
+	s.Equal(0, zapRecorded.Len())
+	s.Equal(http.StatusCreated, r.Code)
+	s.Equal(payment.UID, response.UID)
+	s.Equal(payment.RefundOf, response.RefundOf)
+}
+
+func (s *apiHandlerTestSuite) TestGetAccountHandlerBadURLFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	req, err := http.NewRequest("GET", "/", nil)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	apiHandler := newAPIHandler(server.Globals{
+		Logger: zap.New(zapCore),
+	}, nil).GetAccountHandler()
 
 	r := httptest.NewRecorder()
 	apiHandler.ServeHTTP(r, req)
 
 	s.Equal(1, zapRecorded.Len())
-	s.Equal("Failed to handle GetAllPaymentsHandler", zapRecorded.All()[0].Message)
-	s.Equal(http.StatusInternalServerError, r.Code)
+	s.Equal("Failed to handle GetAccountHandler", zapRecorded.All()[0].Message)
+	s.Equal(http.StatusBadRequest, r.Code)
 }
 
-func (s *apiHandlerTestSuite) TestGetAllPaymentsHandlerSucceeded() {
+func (s *apiHandlerTestSuite) TestGetAccountHandlerNotFoundFailed() {
 	ctrl := gomock.NewController(s.T())
 	defer ctrl.Finish()
 
@@ -626,21 +934,622 @@ func (s *apiHandlerTestSuite) TestGetAllPaymentsHandlerSucceeded() {
 	if err != nil {
 		s.T().Fatal(err)
 	}
+	req = mux.SetURLVars(req, map[string]string{
+		"uid": "toshik1978",
+	})
 
 	accountManager := mock.NewMockAccountManager(ctrl)
 	accountManager.
 		EXPECT().
-		AllPayments(gomock.Any()).
-		Return(nil, nil)
+		Account(gomock.Any(), gomock.Eq("toshik1978")).
+		Return(nil, handler.NewError("fail", handler.NotFoundError))
 
 	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
 	apiHandler := newAPIHandler(server.Globals{
 		Logger: zap.New(zapCore),
-	}, accountManager).GetAllPaymentsHandler()
+	}, accountManager).GetAccountHandler()
 
 	r := httptest.NewRecorder()
 	apiHandler.ServeHTTP(r, req)
 
+	s.Equal(1, zapRecorded.Len())
+	s.Equal("Failed to handle GetAccountHandler", zapRecorded.All()[0].Message)
+	s.Equal(http.StatusNotFound, r.Code)
+	s.Equal("not_found", decodeProblem(r).Code)
+}
+
+func (s *apiHandlerTestSuite) TestGetAccountHandlerTimeoutFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	req, err := http.NewRequest("GET", "/", nil)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	req = mux.SetURLVars(req, map[string]string{
+		"uid": "toshik1978",
+	})
+
+	accountManager := mock.NewMockAccountManager(ctrl)
+	accountManager.
+		EXPECT().
+		Account(gomock.Any(), gomock.Eq("toshik1978")).
+		Return(nil, handler.WrapError(context.DeadlineExceeded, "failed to get account", handler.ServerError))
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	apiHandler := newAPIHandler(server.Globals{
+		Logger: zap.New(zapCore),
+	}, accountManager).GetAccountHandler()
+
+	r := httptest.NewRecorder()
+	apiHandler.ServeHTTP(r, req)
+
+	s.Equal(1, zapRecorded.Len())
+	s.Equal(http.StatusGatewayTimeout, r.Code)
+	s.Equal("timeout", decodeProblem(r).Code)
+	s.Empty(decodeProblem(r).Detail)
+}
+
+func (s *apiHandlerTestSuite) TestGetAccountHandlerCancelledFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", "/", nil)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	req = mux.SetURLVars(req, map[string]string{
+		"uid": "toshik1978",
+	})
+
+	// Database reports cancelled query with its own error, so request's context is checked too
+	accountManager := mock.NewMockAccountManager(ctrl)
+	accountManager.
+		EXPECT().
+		Account(gomock.Any(), gomock.Eq("toshik1978")).
+		Return(nil, errors.New("canceling statement due to user request"))
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	apiHandler := newAPIHandler(server.Globals{
+		Logger: zap.New(zapCore),
+	}, accountManager).GetAccountHandler()
+
+	r := httptest.NewRecorder()
+	apiHandler.ServeHTTP(r, req)
+
+	s.Equal(1, zapRecorded.Len())
+	s.Equal(http.StatusServiceUnavailable, r.Code)
+	s.Equal("service_unavailable", decodeProblem(r).Code)
+}
+
+func (s *apiHandlerTestSuite) TestGetAccountHandlerSucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	account := testutil.AccountResponse()
+	req, err := http.NewRequest("GET", "/", nil)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	req = mux.SetURLVars(req, map[string]string{
+		"uid": account.UID,
+	})
+
+	accountManager := mock.NewMockAccountManager(ctrl)
+	accountManager.
+		EXPECT().
+		Account(gomock.Any(), gomock.Eq(account.UID)).
+		Return(&account, nil)
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	apiHandler := newAPIHandler(server.Globals{
+		Logger: zap.New(zapCore),
+	}, accountManager).GetAccountHandler()
+
+	r := httptest.NewRecorder()
+	apiHandler.ServeHTTP(r, req)
+
+	var response handler.Account
+	_ = json.Unmarshal(r.Body.Bytes(), &response)
+
+	s.Equal(0, zapRecorded.Len())
+	s.Equal(http.StatusOK, r.Code)
+	s.Equal(account.UID, response.UID)
+	s.Equal(account.Balance.String(), response.Balance.String())
+}
+
+func (s *apiHandlerTestSuite) TestUpdateAccountHandlerNoBodyFailed() {
+	req, err := http.NewRequest("PATCH", "/", nil)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	apiHandler := newAPIHandler(server.Globals{
+		Logger: zap.New(zapCore),
+	}, nil).UpdateAccountHandler()
+
+	r := httptest.NewRecorder()
+	apiHandler.ServeHTTP(r, req)
+
+	s.Equal(1, zapRecorded.Len())
+	s.Equal("Failed to handle UpdateAccountHandler", zapRecorded.All()[0].Message)
+	s.Equal(http.StatusBadRequest, r.Code)
+}
+
+func (s *apiHandlerTestSuite) TestUpdateAccountHandlerBadRequestFailed() {
+	req, err := http.NewRequest("PATCH", "/", bytes.NewBuffer([]byte(`{"status": 1}`)))
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	req = mux.SetURLVars(req, map[string]string{
+		"uid": "toshik1978",
+	})
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	apiHandler := newAPIHandler(server.Globals{
+		Logger: zap.New(zapCore),
+	}, nil).UpdateAccountHandler()
+
+	r := httptest.NewRecorder()
+	apiHandler.ServeHTTP(r, req)
+
+	s.Equal(1, zapRecorded.Len())
+	s.Equal("Failed to handle UpdateAccountHandler", zapRecorded.All()[0].Message)
+	s.Equal(http.StatusBadRequest, r.Code)
+	s.Equal("failed to decode request body", decodeProblem(r).Detail)
+}
+
+func (s *apiHandlerTestSuite) TestUpdateAccountHandlerFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	req, err := http.NewRequest("PATCH", "/", bytes.NewBuffer([]byte(`{"status": "closed"}`)))
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	req = mux.SetURLVars(req, map[string]string{
+		"uid": "toshik1978",
+	})
+
+	accountManager := mock.NewMockAccountManager(ctrl)
+	accountManager.
+		EXPECT().
+		UpdateAccountStatus(gomock.Any(), gomock.Eq("toshik1978"), gomock.Eq("closed")).
+		Return(nil, handler.NewError("fail", handler.UnprocessableError))
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	apiHandler := newAPIHandler(server.Globals{
+		Logger: zap.New(zapCore),
+	}, accountManager).UpdateAccountHandler()
+
+	r := httptest.NewRecorder()
+	apiHandler.ServeHTTP(r, req)
+
+	s.Equal(1, zapRecorded.Len())
+	s.Equal("Failed to handle UpdateAccountHandler", zapRecorded.All()[0].Message)
+	s.Equal(http.StatusUnprocessableEntity, r.Code)
+}
+
+func (s *apiHandlerTestSuite) TestUpdateAccountHandlerSucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	account := testutil.AccountResponse()
+	account.Status = "frozen"
+	req, err := http.NewRequest("PATCH", "/", bytes.NewBuffer([]byte(`{"status": "frozen"}`)))
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	req = mux.SetURLVars(req, map[string]string{
+		"uid": account.UID,
+	})
+
+	accountManager := mock.NewMockAccountManager(ctrl)
+	accountManager.
+		EXPECT().
+		UpdateAccountStatus(gomock.Any(), gomock.Eq(account.UID), gomock.Eq("frozen")).
+		Return(&account, nil)
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	apiHandler := newAPIHandler(server.Globals{
+		Logger: zap.New(zapCore),
+	}, accountManager).UpdateAccountHandler()
+
+	r := httptest.NewRecorder()
+	apiHandler.ServeHTTP(r, req)
+
+	var response handler.Account
+	_ = json.Unmarshal(r.Body.Bytes(), &response)
+
+	s.Equal(0, zapRecorded.Len())
+	s.Equal(http.StatusOK, r.Code)
+	s.Equal(account.UID, response.UID)
+	s.Equal("frozen", response.Status)
+}
+
+func (s *apiHandlerTestSuite) TestGetAllAccountsHandlerFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	req, err := http.NewRequest("GET", "/", nil)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+
+	accountManager := mock.NewMockAccountManager(ctrl)
+	accountManager.
+		EXPECT().
+		AllAccounts(gomock.Any(), gomock.Eq(handler.PageRequest{})).
+		Return(nil, errors.New("fail"))
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	apiHandler := newAPIHandler(server.Globals{
+		Logger: zap.New(zapCore),
+	}, accountManager).GetAllAccountsHandler()
+
+	r := httptest.NewRecorder()
+	apiHandler.ServeHTTP(r, req)
+
+	s.Equal(1, zapRecorded.Len())
+	s.Equal("Failed to handle GetAllAccountsHandler", zapRecorded.All()[0].Message)
+	s.Equal(http.StatusInternalServerError, r.Code)
+}
+
+func (s *apiHandlerTestSuite) TestGetAllAccountsHandlerSucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	req, err := http.NewRequest("GET", "/", nil)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+
+	accountManager := mock.NewMockAccountManager(ctrl)
+	accountManager.
+		EXPECT().
+		AllAccounts(gomock.Any(), gomock.Eq(handler.PageRequest{})).
+		Return(nil, nil)
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	apiHandler := newAPIHandler(server.Globals{
+		Logger: zap.New(zapCore),
+	}, accountManager).GetAllAccountsHandler()
+
+	r := httptest.NewRecorder()
+	apiHandler.ServeHTTP(r, req)
+
+	s.Equal(0, zapRecorded.Len())
+	s.Equal(http.StatusOK, r.Code)
+}
+
+func (s *apiHandlerTestSuite) TestGetAllPaymentsHandlerBadLimitFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	req, err := http.NewRequest("GET", "/?limit=ten", nil)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+
+	accountManager := mock.NewMockAccountManager(ctrl)
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	apiHandler := newAPIHandler(server.Globals{
+		Logger: zap.New(zapCore),
+	}, accountManager).GetAllPaymentsHandler()
+
+	r := httptest.NewRecorder()
+	apiHandler.ServeHTTP(r, req)
+
+	s.Equal(1, zapRecorded.Len())
+	s.Equal("Failed to handle GetAllPaymentsHandler", zapRecorded.All()[0].Message)
+	s.Equal(http.StatusBadRequest, r.Code)
+	s.Equal("invalid limit", decodeProblem(r).Detail)
+}
+
+func (s *apiHandlerTestSuite) TestGetAllPaymentsHandlerFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	req, err := http.NewRequest("GET", "/", nil)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+
+	accountManager := mock.NewMockAccountManager(ctrl)
+	accountManager.
+		EXPECT().
+		AllPayments(gomock.Any(), gomock.Eq(handler.PageRequest{})).
+		Return(nil, errors.New("fail"))
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	apiHandler := newAPIHandler(server.Globals{
+		Logger: zap.New(zapCore),
+	}, accountManager).GetAllPaymentsHandler()
+
+	r := httptest.NewRecorder()
+	apiHandler.ServeHTTP(r, req)
+
+	s.Equal(1, zapRecorded.Len())
+	s.Equal("Failed to handle GetAllPaymentsHandler", zapRecorded.All()[0].Message)
+	s.Equal(http.StatusInternalServerError, r.Code)
+}
+
+func (s *apiHandlerTestSuite) TestGetAllPaymentsHandlerSucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	req, err := http.NewRequest("GET", "/?limit=10&cursor=MTIzNA", nil)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+
+	accountManager := mock.NewMockAccountManager(ctrl)
+	accountManager.
+		EXPECT().
+		AllPayments(gomock.Any(), gomock.Eq(handler.PageRequest{Limit: 10, Cursor: "MTIzNA"})).
+		Return(&handler.PaymentList{NextCursor: "MTIzNQ"}, nil)
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	apiHandler := newAPIHandler(server.Globals{
+		Logger: zap.New(zapCore),
+	}, accountManager).GetAllPaymentsHandler()
+
+	r := httptest.NewRecorder()
+	apiHandler.ServeHTTP(r, req)
+
+	var response handler.PaymentList
+	_ = json.Unmarshal(r.Body.Bytes(), &response)
+
+	s.Equal(0, zapRecorded.Len())
+	s.Equal(http.StatusOK, r.Code)
+	s.Equal("MTIzNQ", response.NextCursor)
+}
+
+func (s *apiHandlerTestSuite) TestGetAccountPaymentsHandlerBadURLFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	req, err := http.NewRequest("GET", "/", nil)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	apiHandler := newAPIHandler(server.Globals{
+		Logger: zap.New(zapCore),
+	}, nil).GetAccountPaymentsHandler()
+
+	r := httptest.NewRecorder()
+	apiHandler.ServeHTTP(r, req)
+
+	s.Equal(1, zapRecorded.Len())
+	s.Equal("Failed to handle GetAccountPaymentsHandler", zapRecorded.All()[0].Message)
+	s.Equal(http.StatusBadRequest, r.Code)
+}
+
+func (s *apiHandlerTestSuite) TestGetAccountPaymentsHandlerBadRequestFailed() {
+	for _, query := range []string{"limit=ten", "from=yesterday", "to=2019-11-02", "min_amount=abc", "max_amount=1e2"} {
+		ctrl := gomock.NewController(s.T())
+
+		req, err := http.NewRequest("GET", "/?"+query, nil)
+		if err != nil {
+			s.T().Fatal(err)
+		}
+		req = mux.SetURLVars(req, map[string]string{
+			"uid": "toshik1978",
+		})
+
+		zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+		apiHandler := newAPIHandler(server.Globals{
+			Logger: zap.New(zapCore),
+		}, mock.NewMockAccountManager(ctrl)).GetAccountPaymentsHandler()
+
+		r := httptest.NewRecorder()
+		apiHandler.ServeHTTP(r, req)
+
+		s.Equal(1, zapRecorded.Len(), query)
+		s.Equal(http.StatusBadRequest, r.Code, query)
+		ctrl.Finish()
+	}
+}
+
+func (s *apiHandlerTestSuite) TestGetAccountPaymentsHandlerFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	req, err := http.NewRequest("GET", "/", nil)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	req = mux.SetURLVars(req, map[string]string{
+		"uid": "toshik1978",
+	})
+
+	accountManager := mock.NewMockAccountManager(ctrl)
+	accountManager.
+		EXPECT().
+		AccountPayments(gomock.Any(), gomock.Eq("toshik1978"),
+			gomock.Eq(handler.PaymentFilter{}), gomock.Eq(handler.PageRequest{})).
+		Return(nil, errors.New("fail"))
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	apiHandler := newAPIHandler(server.Globals{
+		Logger: zap.New(zapCore),
+	}, accountManager).GetAccountPaymentsHandler()
+
+	r := httptest.NewRecorder()
+	apiHandler.ServeHTTP(r, req)
+
+	s.Equal(1, zapRecorded.Len())
+	s.Equal("Failed to handle GetAccountPaymentsHandler", zapRecorded.All()[0].Message)
+	s.Equal(http.StatusInternalServerError, r.Code)
+}
+
+func (s *apiHandlerTestSuite) TestGetAccountPaymentsHandlerSucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	payment := testutil.PaymentResponse()
+	from := time.Date(2019, 11, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2019, 11, 2, 0, 0, 0, 0, time.UTC)
+	minAmount := money.MustParse("1.50")
+	maxAmount := money.MustParse("100")
+	filter := handler.PaymentFilter{
+		Counterparty: "toshik1979",
+		Direction:    "outgoing",
+		From:         &from,
+		To:           &to,
+		MinAmount:    &minAmount,
+		MaxAmount:    &maxAmount,
+	}
+
+	req, err := http.NewRequest("GET", "/?limit=10&counterparty=toshik1979&direction=outgoing"+
+		"&from=2019-11-01T00:00:00Z&to=2019-11-02T00:00:00Z&min_amount=1.50&max_amount=100", nil)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	req = mux.SetURLVars(req, map[string]string{
+		"uid": "toshik1978",
+	})
+
+	accountManager := mock.NewMockAccountManager(ctrl)
+	accountManager.
+		EXPECT().
+		AccountPayments(gomock.Any(), gomock.Eq("toshik1978"),
+			gomock.Eq(filter), gomock.Eq(handler.PageRequest{Limit: 10})).
+		Return(&handler.PaymentList{Payments: []handler.Payment{payment}}, nil)
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	apiHandler := newAPIHandler(server.Globals{
+		Logger: zap.New(zapCore),
+	}, accountManager).GetAccountPaymentsHandler()
+
+	r := httptest.NewRecorder()
+	apiHandler.ServeHTTP(r, req)
+
+	var response handler.PaymentList
+	_ = json.Unmarshal(r.Body.Bytes(), &response)
+
+	s.Equal(0, zapRecorded.Len())
+	s.Equal(http.StatusOK, r.Code)
+	s.Len(response.Payments, 1)
+	s.Equal(payment.UID, response.Payments[0].UID)
+	s.Empty(response.NextCursor)
+}
+
+func (s *apiHandlerTestSuite) TestReconcileHandlerNoBodyFailed() {
+	req, err := http.NewRequest("POST", "/", nil)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	apiHandler := newAPIHandler(server.Globals{
+		Logger: zap.New(zapCore),
+	}, nil).ReconcileHandler()
+
+	r := httptest.NewRecorder()
+	apiHandler.ServeHTTP(r, req)
+
+	s.Equal(1, zapRecorded.Len())
+	s.Equal("Failed to handle ReconcileHandler", zapRecorded.All()[0].Message)
+	s.Equal(http.StatusBadRequest, r.Code)
+}
+
+func (s *apiHandlerTestSuite) TestReconcileHandlerBadRequestFailed() {
+	req, err := http.NewRequest("POST", "/", bytes.NewBuffer([]byte(`{"adjust": "yes"}`)))
+	if err != nil {
+		s.T().Fatal(err)
+	}
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	apiHandler := newAPIHandler(server.Globals{
+		Logger: zap.New(zapCore),
+	}, nil).ReconcileHandler()
+
+	r := httptest.NewRecorder()
+	apiHandler.ServeHTTP(r, req)
+
+	s.Equal(1, zapRecorded.Len())
+	s.Equal("Failed to handle ReconcileHandler", zapRecorded.All()[0].Message)
+	s.Equal(http.StatusBadRequest, r.Code)
+	s.Equal("failed to decode request body", decodeProblem(r).Detail)
+}
+
+func (s *apiHandlerTestSuite) TestReconcileHandlerFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	req, err := http.NewRequest("POST", "/", bytes.NewBuffer([]byte(`{"adjust": true}`)))
+	if err != nil {
+		s.T().Fatal(err)
+	}
+
+	accountManager := mock.NewMockAccountManager(ctrl)
+	accountManager.
+		EXPECT().
+		Reconcile(gomock.Any(), gomock.Eq(handler.ReconciliationRequest{Adjust: true})).
+		Return(nil, handler.NewError("failed to validate reconciliation", handler.ClientError))
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	apiHandler := newAPIHandler(server.Globals{
+		Logger: zap.New(zapCore),
+	}, accountManager).ReconcileHandler()
+
+	r := httptest.NewRecorder()
+	apiHandler.ServeHTTP(r, req)
+
+	s.Equal(1, zapRecorded.Len())
+	s.Equal("Failed to handle ReconcileHandler", zapRecorded.All()[0].Message)
+	s.Equal(http.StatusBadRequest, r.Code)
+	s.Equal("failed to validate reconciliation", decodeProblem(r).Detail)
+}
+
+func (s *apiHandlerTestSuite) TestReconcileHandlerSucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	request := handler.ReconciliationRequest{Adjust: true, Reason: "incident 42"}
+	req, err := http.NewRequest("POST", "/",
+		bytes.NewBuffer([]byte(`{"adjust": true, "reason": "incident 42"}`)))
+	if err != nil {
+		s.T().Fatal(err)
+	}
+
+	accountManager := mock.NewMockAccountManager(ctrl)
+	accountManager.
+		EXPECT().
+		Reconcile(gomock.Any(), gomock.Eq(request)).
+		Return(&handler.Reconciliation{
+			Drifts: []handler.Drift{{
+				UID:             "toshik1978",
+				Currency:        "USD",
+				ExpectedBalance: money.MustParse("100.00"),
+				ActualBalance:   money.MustParse("123.45"),
+			}},
+			Adjusted: true,
+		}, nil)
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	apiHandler := newAPIHandler(server.Globals{
+		Logger: zap.New(zapCore),
+	}, accountManager).ReconcileHandler()
+
+	r := httptest.NewRecorder()
+	apiHandler.ServeHTTP(r, req)
+
+	var response handler.Reconciliation
+	_ = json.Unmarshal(r.Body.Bytes(), &response)
+
 	s.Equal(0, zapRecorded.Len())
 	s.Equal(http.StatusOK, r.Code)
+	s.True(response.Adjusted)
+	s.Len(response.Drifts, 1)
+	s.Equal("toshik1978", response.Drifts[0].UID)
+	s.Equal("100.00", response.Drifts[0].ExpectedBalance.String())
+	s.Equal("123.45", response.Drifts[0].ActualBalance.String())
 }
@@ -0,0 +1,121 @@
+package httphandler
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/handler"
+	"github.com/Toshik1978/go-rest-api/service/errutil"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+const (
+	// accountEventsRoute is name of the event stream's route, stream isn't limited by the default timeout
+	accountEventsRoute = "account_events"
+
+	lastEventIDHeader      = "Last-Event-ID"
+	eventStreamContentType = "text/event-stream"
+)
+
+// eventHandler declare handler of event streams
+type eventHandler struct {
+	*apiHandler
+
+	keepAlive time.Duration
+}
+
+// newEventHandler creates new handler of event streams.
+// Keep-alive comment is sent to the idle stream every keepAlive, configuration always sets positive interval
+func newEventHandler(apiHandler *apiHandler, keepAlive time.Duration) *eventHandler {
+	return &eventHandler{
+		apiHandler: apiHandler,
+		keepAlive:  keepAlive,
+	}
+}
+
+// GetAccountEventsHandler streams account's payments and balance changes as Server-Sent Events
+func (h *eventHandler) GetAccountEventsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		if _, ok := vars[uidKey]; !ok {
+			// Theoretically it's impossible situation due to mux routing
+			// But just in case...
+			h.fail(w, r,
+				handler.NewError("no account detected", handler.ClientError),
+				http.StatusBadRequest, "GetAccountEventsHandler")
+			return
+		}
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			h.fail(w, r,
+				handler.NewError("streaming is not supported", handler.ServerError),
+				http.StatusInternalServerError, "GetAccountEventsHandler")
+			return
+		}
+
+		events, err := h.accountManager.AccountEvents(r.Context(), vars[uidKey], r.Header.Get(lastEventIDHeader))
+		if h.fail(w, r,
+			errutil.Wrap(err, "failed to get account events"),
+			http.StatusInternalServerError, "GetAccountEventsHandler") {
+
+			return
+		}
+
+		w.Header().Set("Content-Type", eventStreamContentType)
+		w.Header().Set("Cache-Control", "no-cache")
+		// Reverse proxies shouldn't buffer the stream
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		// Interval is zero only if handler isn't configured, e.g. in tests, ticker can't be created then
+		var keepAlive <-chan time.Time
+		if h.keepAlive > 0 {
+			ticker := time.NewTicker(h.keepAlive)
+			defer ticker.Stop()
+			keepAlive = ticker.C
+		}
+		for {
+			select {
+			case event, ok := <-events:
+				// Stream is closed by the manager, client reconnects and resumes it
+				if !ok {
+					return
+				}
+				if err := h.writeEvent(w, event); err != nil {
+					h.logger.Info("Failed to write account event", zap.Error(err),
+						zap.String("request_id", requestID(r.Context())))
+					return
+				}
+			case <-keepAlive:
+				if _, err := io.WriteString(w, ": keep-alive\n\n"); err != nil {
+					return
+				}
+			}
+			flusher.Flush()
+		}
+	})
+}
+
+// writeEvent writes event in Server-Sent Events format. Event without ID doesn't change client's last event ID
+func (h *eventHandler) writeEvent(w io.Writer, event handler.AccountEvent) error {
+	payload, err := json.Marshal(event.Data)
+	if err != nil {
+		return errutil.Wrap(err, "failed to marshal event")
+	}
+
+	var buffer bytes.Buffer
+	if event.ID != "" {
+		buffer.WriteString("id: " + event.ID + "\n")
+	}
+	buffer.WriteString("event: " + event.Type + "\n")
+	buffer.WriteString("data: ")
+	buffer.Write(payload)
+	buffer.WriteString("\n\n")
+	_, err = w.Write(buffer.Bytes())
+	return err
+}
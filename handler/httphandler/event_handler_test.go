@@ -0,0 +1,218 @@
+package httphandler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/handler"
+	"github.com/Toshik1978/go-rest-api/mock"
+	"github.com/Toshik1978/go-rest-api/service/server"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// eventHandlerTestSuite test suite
+type eventHandlerTestSuite struct {
+	suite.Suite
+
+	events []handler.AccountEvent
+}
+
+func (s *eventHandlerTestSuite) SetupTest() {
+	s.events = []handler.AccountEvent{
+		{
+			ID:   "Nw",
+			Type: "payment",
+			Data: json.RawMessage(`{"account":"toshik1978","amount":"100.00"}`),
+		},
+		{
+			Type: "balance",
+			Data: json.RawMessage(`{"uid":"toshik1978","balance":"0.00"}`),
+		},
+	}
+}
+
+// newEventHandler creates event streams' handler with logger, which records messages
+func (s *eventHandlerTestSuite) newEventHandler(
+	accountManager handler.AccountManager) (*eventHandler, *observer.ObservedLogs) {
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	apiHandler := newAPIHandler(server.Globals{
+		Logger: zap.New(zapCore),
+	}, accountManager)
+	return newEventHandler(apiHandler, time.Minute), zapRecorded
+}
+
+// streamEvents return channel, which is closed after the given events are received
+func (s *eventHandlerTestSuite) streamEvents(events []handler.AccountEvent) <-chan handler.AccountEvent {
+	ch := make(chan handler.AccountEvent, len(events))
+	for _, event := range events {
+		ch <- event
+	}
+	close(ch)
+	return ch
+}
+
+func (s *eventHandlerTestSuite) TestGetAccountEventsHandlerNoUIDFailed() {
+	req, err := http.NewRequest("GET", "/", nil)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+
+	eventHandler, zapRecorded := s.newEventHandler(nil)
+	r := httptest.NewRecorder()
+	eventHandler.GetAccountEventsHandler().ServeHTTP(r, req)
+
+	s.Equal(1, zapRecorded.Len())
+	s.Equal("Failed to handle GetAccountEventsHandler", zapRecorded.All()[0].Message)
+	s.Equal(http.StatusBadRequest, r.Code)
+}
+
+func (s *eventHandlerTestSuite) TestGetAccountEventsHandlerNotFoundFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	req, err := http.NewRequest("GET", "/", nil)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	req = mux.SetURLVars(req, map[string]string{uidKey: "toshik1978"})
+
+	accountManager := mock.NewMockAccountManager(ctrl)
+	accountManager.
+		EXPECT().
+		AccountEvents(gomock.Any(), gomock.Eq("toshik1978"), gomock.Eq("")).
+		Return(nil, handler.NewError("fail", handler.NotFoundError))
+
+	eventHandler, zapRecorded := s.newEventHandler(accountManager)
+	r := httptest.NewRecorder()
+	eventHandler.GetAccountEventsHandler().ServeHTTP(r, req)
+
+	s.Equal(1, zapRecorded.Len())
+	s.Equal(http.StatusNotFound, r.Code)
+	s.Equal(problemContentType, r.Header().Get("Content-Type"))
+}
+
+func (s *eventHandlerTestSuite) TestGetAccountEventsHandlerInvalidLastEventIDFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	req, err := http.NewRequest("GET", "/", nil)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	req = mux.SetURLVars(req, map[string]string{uidKey: "toshik1978"})
+	req.Header.Set(lastEventIDHeader, "invalid")
+
+	accountManager := mock.NewMockAccountManager(ctrl)
+	accountManager.
+		EXPECT().
+		AccountEvents(gomock.Any(), gomock.Eq("toshik1978"), gomock.Eq("invalid")).
+		Return(nil, handler.NewError("fail", handler.ClientError))
+
+	eventHandler, zapRecorded := s.newEventHandler(accountManager)
+	r := httptest.NewRecorder()
+	eventHandler.GetAccountEventsHandler().ServeHTTP(r, req)
+
+	s.Equal(1, zapRecorded.Len())
+	s.Equal(http.StatusBadRequest, r.Code)
+}
+
+func (s *eventHandlerTestSuite) TestGetAccountEventsHandlerSucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	req, err := http.NewRequest("GET", "/", nil)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	req = mux.SetURLVars(req, map[string]string{uidKey: "toshik1978"})
+	req.Header.Set(lastEventIDHeader, "Ng")
+
+	accountManager := mock.NewMockAccountManager(ctrl)
+	accountManager.
+		EXPECT().
+		AccountEvents(gomock.Any(), gomock.Eq("toshik1978"), gomock.Eq("Ng")).
+		Return(s.streamEvents(s.events), nil)
+
+	eventHandler, zapRecorded := s.newEventHandler(accountManager)
+	r := httptest.NewRecorder()
+	eventHandler.GetAccountEventsHandler().ServeHTTP(r, req)
+
+	s.Equal(0, zapRecorded.Len())
+	s.Equal(http.StatusOK, r.Code)
+	s.Equal(eventStreamContentType, r.Header().Get("Content-Type"))
+	s.Equal("no-cache", r.Header().Get("Cache-Control"))
+	s.True(r.Flushed)
+	s.Equal(
+		"id: Nw\nevent: payment\ndata: {\"account\":\"toshik1978\",\"amount\":\"100.00\"}\n\n"+
+			"event: balance\ndata: {\"uid\":\"toshik1978\",\"balance\":\"0.00\"}\n\n",
+		r.Body.String())
+}
+
+func (s *eventHandlerTestSuite) TestGetAccountEventsHandlerKeepAliveSucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	req, err := http.NewRequest("GET", "/", nil)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	req = mux.SetURLVars(req, map[string]string{uidKey: "toshik1978"})
+
+	// Stream is idle, till keep-alive comment is sent
+	events := make(chan handler.AccountEvent)
+	accountManager := mock.NewMockAccountManager(ctrl)
+	accountManager.
+		EXPECT().
+		AccountEvents(gomock.Any(), gomock.Eq("toshik1978"), gomock.Eq("")).
+		Return(events, nil)
+
+	eventHandler, _ := s.newEventHandler(accountManager)
+	eventHandler.keepAlive = 10 * time.Millisecond
+	r := httptest.NewRecorder()
+	time.AfterFunc(100*time.Millisecond, func() { close(events) })
+	eventHandler.GetAccountEventsHandler().ServeHTTP(r, req)
+
+	s.Equal(http.StatusOK, r.Code)
+	s.Contains(r.Body.String(), ": keep-alive\n\n")
+}
+
+func (s *eventHandlerTestSuite) TestGetAccountEventsHandlerNoTimeoutSucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	req, err := http.NewRequest("GET", "/api/v1/accounts/toshik1978/events", nil)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+
+	// Event stream isn't limited by the default timeout of API requests
+	accountManager := mock.NewMockAccountManager(ctrl)
+	accountManager.
+		EXPECT().
+		AccountEvents(gomock.Any(), gomock.Eq("toshik1978"), gomock.Eq("")).
+		DoAndReturn(func(ctx context.Context, uid string, lastEventID string) (<-chan handler.AccountEvent, error) {
+			_, ok := ctx.Deadline()
+			s.False(ok)
+			return s.streamEvents(nil), nil
+		})
+
+	zapCore, _ := observer.New(zapcore.InfoLevel)
+	httpHandler := NewHTTPHandler(server.Globals{
+		Logger:         zap.New(zapCore),
+		RequestTimeout: time.Second,
+	}, accountManager, nil, nil)
+
+	r := httptest.NewRecorder()
+	httpHandler.ServeHTTP(r, req)
+
+	s.Equal(http.StatusOK, r.Code)
+}
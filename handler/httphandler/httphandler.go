@@ -3,6 +3,7 @@ package httphandler
 import (
 	"net/http"
 	_ "net/http/pprof" // pprof enable
+	"time"
 
 	"github.com/Toshik1978/go-rest-api/handler"
 	"github.com/Toshik1978/go-rest-api/service/server"
@@ -11,30 +12,81 @@ import (
 )
 
 // NewHTTPHandler creates new http handler
-func NewHTTPHandler(globals server.Globals, accountManager handler.AccountManager) http.Handler {
+func NewHTTPHandler(globals server.Globals,
+	accountManager handler.AccountManager, webhookManager handler.WebhookManager,
+	scheduleManager handler.ScheduleManager) http.Handler {
+
+	apiHandler := newAPIHandler(globals, accountManager)
+
 	// Create main router and attach common middlewares
 	r := mux.NewRouter()
 	r.PathPrefix("/debug/pprof/").Handler(http.DefaultServeMux)
 	r.Use(
-		handlers.RecoveryHandler(handlers.RecoveryLogger(newRecoveryLogger(globals))),
+		requestIDMiddleware,
+		recoveryMiddleware(apiHandler),
 		handlers.ProxyHeaders,
 	)
 
-	// API
+	// Event stream is long-living, so it's limited only by explicitly configured timeout
+	routeTimeouts := map[string]time.Duration{accountEventsRoute: 0}
+	for name, timeout := range globals.RouteTimeouts {
+		routeTimeouts[name] = timeout
+	}
+
+	// API, route's name is used to configure route's timeout
 	route := r.PathPrefix("/api/v1").Subrouter()
 	route.Use(
 		func(next http.Handler) http.Handler {
 			return handlers.CustomLoggingHandler(nil, next, newLogFormatter(globals))
 		},
+		timeoutMiddleware(globals.RequestTimeout, routeTimeouts),
 	)
 
-	apiHandler := newAPIHandler(globals, accountManager)
-	route.Handle("/server/status", apiHandler.ServerStatusHandler()).Methods("GET")
+	route.Handle("/server/status", apiHandler.ServerStatusHandler()).Methods("GET").Name("server_status")
+
+	route.Handle("/accounts", apiHandler.CreateAccountHandler()).Methods("POST").Name("create_account")
+	route.Handle("/accounts", apiHandler.GetAllAccountsHandler()).Methods("GET").Name("get_accounts")
+	route.Handle("/accounts/payments", apiHandler.GetAllPaymentsHandler()).Methods("GET").Name("get_payments")
+	route.Handle("/accounts/{uid:[a-zA-Z0-9]+}", apiHandler.GetAccountHandler()).Methods("GET").Name("get_account")
+	route.Handle("/accounts/{uid:[a-zA-Z0-9]+}", apiHandler.UpdateAccountHandler()).
+		Methods("PATCH").Name("update_account")
+	route.Handle("/accounts/{uid:[a-zA-Z0-9]+}/payments", apiHandler.GetAccountPaymentsHandler()).
+		Methods("GET").Name("get_account_payments")
+	route.Handle("/accounts/{uid:[a-zA-Z0-9]+}/payments", apiHandler.CreatePaymentHandler()).
+		Methods("POST").Name("create_payment")
+	route.Handle("/payments/{id:[0-9]+}/refund", apiHandler.RefundPaymentHandler()).
+		Methods("POST").Name("refund_payment")
+
+	eventHandler := newEventHandler(apiHandler, globals.EventsKeepAlive)
+	route.Handle("/accounts/{uid:[a-zA-Z0-9]+}/events", eventHandler.GetAccountEventsHandler()).
+		Methods("GET").Name(accountEventsRoute)
+
+	route.Handle("/admin/reconciliation", apiHandler.ReconcileHandler()).Methods("POST").Name("reconcile")
+
+	webhookHandler := newWebhookHandler(apiHandler, webhookManager)
+	route.Handle("/webhooks", webhookHandler.CreateWebhookHandler()).Methods("POST").Name("create_webhook")
+	route.Handle("/webhooks", webhookHandler.GetAllWebhooksHandler()).Methods("GET").Name("get_webhooks")
+	route.Handle("/webhooks/{id:[0-9]+}", webhookHandler.GetWebhookHandler()).Methods("GET").Name("get_webhook")
+	route.Handle("/webhooks/{id:[0-9]+}", webhookHandler.DeleteWebhookHandler()).
+		Methods("DELETE").Name("delete_webhook")
+	route.Handle("/webhooks/{id:[0-9]+}/deliveries", webhookHandler.GetWebhookDeliveriesHandler()).
+		Methods("GET").Name("get_webhook_deliveries")
+	route.Handle("/webhooks/{id:[0-9]+}/deliveries/{delivery_id:[0-9]+}", webhookHandler.GetDeliveryHandler()).
+		Methods("GET").Name("get_delivery")
+	route.Handle("/webhooks/{id:[0-9]+}/deliveries/{delivery_id:[0-9]+}/redeliver", webhookHandler.RedeliverHandler()).
+		Methods("POST").Name("redeliver")
 
-	route.Handle("/accounts", apiHandler.CreateAccountHandler()).Methods("POST")
-	route.Handle("/accounts", apiHandler.GetAllAccountsHandler()).Methods("GET")
-	route.Handle("/accounts/payments", apiHandler.GetAllPaymentsHandler()).Methods("GET")
-	route.Handle("/accounts/{uid:[a-zA-Z0-9]+}/payments", apiHandler.CreatePaymentHandler()).Methods("POST")
+	scheduleHandler := newScheduleHandler(apiHandler, scheduleManager)
+	route.Handle("/accounts/{uid:[a-zA-Z0-9]+}/schedules", scheduleHandler.CreateScheduleHandler()).
+		Methods("POST").Name("create_schedule")
+	route.Handle("/accounts/{uid:[a-zA-Z0-9]+}/schedules", scheduleHandler.GetAccountSchedulesHandler()).
+		Methods("GET").Name("get_account_schedules")
+	route.Handle("/accounts/{uid:[a-zA-Z0-9]+}/schedules/{id:[0-9]+}", scheduleHandler.GetScheduleHandler()).
+		Methods("GET").Name("get_schedule")
+	route.Handle("/accounts/{uid:[a-zA-Z0-9]+}/schedules/{id:[0-9]+}", scheduleHandler.CancelScheduleHandler()).
+		Methods("DELETE").Name("cancel_schedule")
+	route.Handle("/accounts/{uid:[a-zA-Z0-9]+}/schedules/{id:[0-9]+}/runs", scheduleHandler.GetScheduleRunsHandler()).
+		Methods("GET").Name("get_schedule_runs")
 
 	return r
 }
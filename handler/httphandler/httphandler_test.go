@@ -1,9 +1,12 @@
 package httphandler
 
 import (
+	"bytes"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 
+	"github.com/Toshik1978/go-rest-api/handler"
 	"github.com/Toshik1978/go-rest-api/service/server"
 	"github.com/stretchr/testify/suite"
 	"go.uber.org/zap"
@@ -11,10 +14,12 @@ import (
 	"go.uber.org/zap/zaptest/observer"
 )
 
-// panicResponseWriter declare ResponseWriter, which always panic
+// panicResponseWriter declare ResponseWriter, which panics on the first write
 type panicResponseWriter struct {
-	headers http.Header
-	status  int
+	headers  http.Header
+	status   int
+	body     bytes.Buffer
+	panicked bool
 }
 
 func (r *panicResponseWriter) Header() http.Header {
@@ -22,7 +27,11 @@ func (r *panicResponseWriter) Header() http.Header {
 }
 
 func (r *panicResponseWriter) Write(body []byte) (int, error) {
-	panic("panic")
+	if !r.panicked {
+		r.panicked = true
+		panic("panic")
+	}
+	return r.body.Write(body)
 }
 
 func (r *panicResponseWriter) WriteHeader(status int) {
@@ -49,7 +58,7 @@ func (s *httpHandlerTestSuite) TestHTTPHandlerSucceeded1() {
 	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
 	handler := NewHTTPHandler(server.Globals{
 		Logger: zap.New(zapCore),
-	}, nil)
+	}, nil, nil, nil)
 
 	r := httptest.NewRecorder()
 	handler.ServeHTTP(r, req)
@@ -57,6 +66,8 @@ func (s *httpHandlerTestSuite) TestHTTPHandlerSucceeded1() {
 	s.Equal(1, zapRecorded.Len())
 	s.Equal("Handled HTTP request", zapRecorded.All()[0].Message)
 	s.Equal(http.StatusOK, r.Code)
+	s.NotEmpty(r.Header().Get("X-Request-ID"))
+	s.Equal(r.Header().Get("X-Request-ID"), zapRecorded.All()[0].ContextMap()["request_id"])
 }
 
 func (s *httpHandlerTestSuite) TestHTTPHandlerSucceeded2() {
@@ -68,7 +79,7 @@ func (s *httpHandlerTestSuite) TestHTTPHandlerSucceeded2() {
 	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
 	handler := NewHTTPHandler(server.Globals{
 		Logger: zap.New(zapCore),
-	}, nil)
+	}, nil, nil, nil)
 
 	r := httptest.NewRecorder()
 	handler.ServeHTTP(r, req)
@@ -86,7 +97,7 @@ func (s *httpHandlerTestSuite) TestHTTPHandlerSucceeded3() {
 	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
 	handler := NewHTTPHandler(server.Globals{
 		Logger: zap.New(zapCore),
-	}, nil)
+	}, nil, nil, nil)
 
 	r := httptest.NewRecorder()
 	handler.ServeHTTP(r, req)
@@ -102,14 +113,21 @@ func (s *httpHandlerTestSuite) TestHTTPHandlerSucceeded4() {
 	}
 
 	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
-	handler := NewHTTPHandler(server.Globals{
+	httpHandler := NewHTTPHandler(server.Globals{
 		Logger: zap.New(zapCore),
-	}, nil)
+	}, nil, nil, nil)
 
 	w := newPanicResponseWriter()
-	handler.ServeHTTP(w, req)
+	httpHandler.ServeHTTP(w, req)
 
 	s.Equal(1, zapRecorded.Len())
 	s.Equal("Panic happened in HTTP handler", zapRecorded.All()[0].Message)
 	s.Equal(http.StatusInternalServerError, w.status)
+	s.Equal("application/problem+json", w.Header().Get("Content-Type"))
+
+	var problem handler.Problem
+	s.NoError(json.Unmarshal(w.body.Bytes(), &problem))
+	s.Equal("internal_error", problem.Code)
+	s.NotEmpty(problem.RequestID)
+	s.Equal(w.Header().Get(requestIDHeader), problem.RequestID)
 }
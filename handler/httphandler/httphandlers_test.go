@@ -9,6 +9,11 @@ import (
 func TestHTTPHandler(t *testing.T) {
 	suite.Run(t, new(httpHandlerTestSuite))
 	suite.Run(t, new(logFormatterTestSuite))
-	suite.Run(t, new(recoveryLoggerTestSuite))
+	suite.Run(t, new(recoveryTestSuite))
 	suite.Run(t, new(apiHandlerTestSuite))
+	suite.Run(t, new(webhookHandlerTestSuite))
+	suite.Run(t, new(scheduleHandlerTestSuite))
+	suite.Run(t, new(eventHandlerTestSuite))
+	suite.Run(t, new(requestIDTestSuite))
+	suite.Run(t, new(timeoutTestSuite))
 }
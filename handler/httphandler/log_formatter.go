@@ -29,6 +29,7 @@ func (f logFormatter) format(_ io.Writer, params handlers.LogFormatterParams) {
 		zap.String("method", params.Request.Method),
 		zap.String("remote_addr", params.Request.RemoteAddr),
 		zap.String("user_agent", params.Request.UserAgent()),
+		zap.String("request_id", requestID(params.Request.Context())),
 		zap.String("mode", "access_log"),
 	).Info("Handled HTTP request")
 }
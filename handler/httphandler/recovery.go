@@ -0,0 +1,35 @@
+package httphandler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+// recoveryMiddleware recovers panic in the handler, logs it and fails request with internal error
+// the same way as failed handler does. Aborted handler is not recovered, so server aborts the response
+func recoveryMiddleware(h *apiHandler) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				p := recover()
+				if p == nil {
+					return
+				}
+				if p == http.ErrAbortHandler {
+					panic(p)
+				}
+
+				h.logger.Error("Panic happened in HTTP handler",
+					zap.String("mode", "panic_log"),
+					zap.Any("panic", p),
+					zap.Stack("stack"),
+					zap.String("request_id", requestID(r.Context())))
+				h.writeProblem(w, r, fmt.Errorf("panic: %v", p), http.StatusInternalServerError)
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
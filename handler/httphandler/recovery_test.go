@@ -0,0 +1,67 @@
+package httphandler
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/Toshik1978/go-rest-api/handler"
+	"github.com/Toshik1978/go-rest-api/service/server"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type recoveryTestSuite struct {
+	suite.Suite
+}
+
+func (s *recoveryTestSuite) TestRecoveryMiddlewareSucceeded() {
+	req, err := http.NewRequest("GET", "/", nil)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	req.Header.Set(requestIDHeader, "request-1")
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	apiHandler := newAPIHandler(server.Globals{
+		Logger: zap.New(zapCore),
+	}, nil)
+	panicHandler := requestIDMiddleware(recoveryMiddleware(apiHandler)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("secret details")
+		})))
+
+	r := httptest.NewRecorder()
+	panicHandler.ServeHTTP(r, req)
+
+	s.Equal(1, zapRecorded.Len())
+	s.Equal("Panic happened in HTTP handler", zapRecorded.All()[0].Message)
+	s.Equal("request-1", zapRecorded.All()[0].ContextMap()["request_id"])
+	s.Equal(http.StatusInternalServerError, r.Code)
+	s.Equal("application/problem+json", r.Header().Get("Content-Type"))
+	s.Equal("request-1", r.Header().Get(requestIDHeader))
+	s.Equal(handler.Problem{
+		Type:      "about:blank",
+		Title:     "Internal Server Error",
+		Status:    http.StatusInternalServerError,
+		Code:      "internal_error",
+		RequestID: "request-1",
+	}, decodeProblem(r))
+}
+
+func (s *recoveryTestSuite) TestRecoveryMiddlewareAborted() {
+	req, err := http.NewRequest("GET", "/", nil)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+
+	apiHandler := newAPIHandler(server.Globals{Logger: zap.NewNop()}, nil)
+	abortHandler := recoveryMiddleware(apiHandler)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	s.PanicsWithValue(http.ErrAbortHandler, func() {
+		abortHandler.ServeHTTP(httptest.NewRecorder(), req)
+	})
+}
@@ -0,0 +1,62 @@
+package httphandler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type requestIDTestSuite struct {
+	suite.Suite
+}
+
+func (s *requestIDTestSuite) serve(header string) (string, string) {
+	req, err := http.NewRequest("GET", "/", nil)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	if header != "" {
+		req.Header.Set(requestIDHeader, header)
+	}
+
+	var id string
+	handler := requestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id = requestID(r.Context())
+	}))
+
+	r := httptest.NewRecorder()
+	handler.ServeHTTP(r, req)
+	return id, r.Header().Get(requestIDHeader)
+}
+
+func (s *requestIDTestSuite) TestRequestIDGenerated() {
+	id, header := s.serve("")
+	s.Len(id, 32)
+	s.Equal(id, header)
+
+	other, _ := s.serve("")
+	s.NotEqual(id, other)
+}
+
+func (s *requestIDTestSuite) TestRequestIDReused() {
+	id, header := s.serve("4d1f7a9e-6a0c-4a43-9a5e-0c1b2f3d4e5f")
+	s.Equal("4d1f7a9e-6a0c-4a43-9a5e-0c1b2f3d4e5f", id)
+	s.Equal(id, header)
+}
+
+func (s *requestIDTestSuite) TestRequestIDReplaced() {
+	for _, header := range []string{"bad id", strings.Repeat("a", 129), "ключ"} {
+		id, _ := s.serve(header)
+		s.Len(id, 32, header)
+	}
+}
+
+func (s *requestIDTestSuite) TestRequestIDAbsent() {
+	req, err := http.NewRequest("GET", "/", nil)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	s.Empty(requestID(req.Context()))
+}
@@ -0,0 +1,168 @@
+package httphandler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Toshik1978/go-rest-api/handler"
+	"github.com/Toshik1978/go-rest-api/service/errutil"
+	"github.com/gorilla/mux"
+)
+
+// scheduleHandler declare handler of scheduled payments' API requests
+type scheduleHandler struct {
+	*apiHandler
+
+	scheduleManager handler.ScheduleManager
+}
+
+// newScheduleHandler creates new scheduled payments' API handler
+func newScheduleHandler(apiHandler *apiHandler, scheduleManager handler.ScheduleManager) *scheduleHandler {
+	return &scheduleHandler{
+		apiHandler:      apiHandler,
+		scheduleManager: scheduleManager,
+	}
+}
+
+// CreateScheduleHandler creates new scheduled payment from the given account
+func (h *scheduleHandler) CreateScheduleHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Body == nil {
+			h.fail(w, r,
+				handler.NewError("no body detected", handler.ClientError),
+				http.StatusBadRequest, "CreateScheduleHandler")
+			return
+		}
+		uid, err := h.uidParam(r)
+		if h.fail(w, r, err, http.StatusBadRequest, "CreateScheduleHandler") {
+			return
+		}
+
+		var scheduleRequest handler.ScheduleRequest
+		decoder := json.NewDecoder(r.Body)
+		if h.fail(w, r,
+			h.decodeError(decoder.Decode(&scheduleRequest)),
+			http.StatusBadRequest, "CreateScheduleHandler") {
+
+			return
+		}
+
+		schedule, err := h.scheduleManager.CreateSchedule(r.Context(), uid, scheduleRequest)
+		if h.fail(w, r,
+			errutil.Wrap(err, "failed to create schedule"),
+			http.StatusInternalServerError, "CreateScheduleHandler") {
+
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		h.writeResponse(w, schedule)
+	})
+}
+
+// GetAccountSchedulesHandler response with scheduled payments of the given account
+func (h *scheduleHandler) GetAccountSchedulesHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uid, err := h.uidParam(r)
+		if h.fail(w, r, err, http.StatusBadRequest, "GetAccountSchedulesHandler") {
+			return
+		}
+		page, err := h.pageRequest(r)
+		if h.fail(w, r, err, http.StatusBadRequest, "GetAccountSchedulesHandler") {
+			return
+		}
+
+		schedules, err := h.scheduleManager.AccountSchedules(r.Context(), uid, page)
+		if h.fail(w, r,
+			errutil.Wrap(err, "failed to get account schedules"),
+			http.StatusInternalServerError, "GetAccountSchedulesHandler") {
+
+			return
+		}
+		h.writeResponse(w, schedules)
+	})
+}
+
+// GetScheduleHandler response with the given scheduled payment
+func (h *scheduleHandler) GetScheduleHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uid, err := h.uidParam(r)
+		if h.fail(w, r, err, http.StatusBadRequest, "GetScheduleHandler") {
+			return
+		}
+		id, err := h.idParam(r, idKey)
+		if h.fail(w, r, err, http.StatusBadRequest, "GetScheduleHandler") {
+			return
+		}
+
+		schedule, err := h.scheduleManager.Schedule(r.Context(), uid, id)
+		if h.fail(w, r,
+			errutil.Wrap(err, "failed to get schedule"),
+			http.StatusInternalServerError, "GetScheduleHandler") {
+
+			return
+		}
+		h.writeResponse(w, schedule)
+	})
+}
+
+// CancelScheduleHandler cancels the given scheduled payment
+func (h *scheduleHandler) CancelScheduleHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uid, err := h.uidParam(r)
+		if h.fail(w, r, err, http.StatusBadRequest, "CancelScheduleHandler") {
+			return
+		}
+		id, err := h.idParam(r, idKey)
+		if h.fail(w, r, err, http.StatusBadRequest, "CancelScheduleHandler") {
+			return
+		}
+
+		schedule, err := h.scheduleManager.CancelSchedule(r.Context(), uid, id)
+		if h.fail(w, r,
+			errutil.Wrap(err, "failed to cancel schedule"),
+			http.StatusInternalServerError, "CancelScheduleHandler") {
+
+			return
+		}
+		h.writeResponse(w, schedule)
+	})
+}
+
+// GetScheduleRunsHandler response with runs of the given scheduled payment
+func (h *scheduleHandler) GetScheduleRunsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uid, err := h.uidParam(r)
+		if h.fail(w, r, err, http.StatusBadRequest, "GetScheduleRunsHandler") {
+			return
+		}
+		id, err := h.idParam(r, idKey)
+		if h.fail(w, r, err, http.StatusBadRequest, "GetScheduleRunsHandler") {
+			return
+		}
+		page, err := h.pageRequest(r)
+		if h.fail(w, r, err, http.StatusBadRequest, "GetScheduleRunsHandler") {
+			return
+		}
+
+		runs, err := h.scheduleManager.ScheduleRuns(r.Context(), uid, id, page)
+		if h.fail(w, r,
+			errutil.Wrap(err, "failed to get schedule runs"),
+			http.StatusInternalServerError, "GetScheduleRunsHandler") {
+
+			return
+		}
+		h.writeResponse(w, runs)
+	})
+}
+
+// uidParam return UID of the account from the request's path
+func (h *scheduleHandler) uidParam(r *http.Request) (string, error) {
+	uid, ok := mux.Vars(r)[uidKey]
+	if !ok {
+		// Theoretically it's impossible situation due to mux routing
+		// But just in case...
+		return "", handler.NewError("no account detected", handler.ClientError)
+	}
+	return uid, nil
+}
@@ -0,0 +1,290 @@
+package httphandler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/AlekSi/pointer"
+	"github.com/Toshik1978/go-rest-api/handler"
+	"github.com/Toshik1978/go-rest-api/mock"
+	"github.com/Toshik1978/go-rest-api/service/money"
+	"github.com/Toshik1978/go-rest-api/service/server"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// scheduleHandlerTestSuite test suite
+type scheduleHandlerTestSuite struct {
+	suite.Suite
+
+	request  handler.ScheduleRequest
+	schedule handler.Schedule
+}
+
+func (s *scheduleHandlerTestSuite) SetupTest() {
+	createdAt := time.Now().Round(time.Millisecond).UTC()
+	s.request = handler.ScheduleRequest{
+		RecipientUID: "toshik1979",
+		Amount:       money.FromMinorUnits(1000, 2),
+		Recurrence:   "monthly",
+	}
+	s.schedule = handler.Schedule{
+		ID:                  3,
+		UID:                 "toshik1978",
+		RecipientUID:        "toshik1979",
+		Amount:              money.FromMinorUnits(1000, 2),
+		Currency:            "USD",
+		Recurrence:          "monthly",
+		OnInsufficientFunds: "skip",
+		Status:              "active",
+		StartAt:             createdAt,
+		NextRunAt:           pointer.ToTime(createdAt),
+		CreatedAt:           createdAt,
+		UpdatedAt:           createdAt,
+	}
+}
+
+// newScheduleHandler creates scheduled payments' API handler with logger, which records messages
+func (s *scheduleHandlerTestSuite) newScheduleHandler(
+	scheduleManager handler.ScheduleManager) (*scheduleHandler, *observer.ObservedLogs) {
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	apiHandler := newAPIHandler(server.Globals{
+		Logger: zap.New(zapCore),
+	}, nil)
+	return newScheduleHandler(apiHandler, scheduleManager), zapRecorded
+}
+
+func (s *scheduleHandlerTestSuite) TestCreateScheduleHandlerNoBodyFailed() {
+	req, err := http.NewRequest("POST", "/", nil)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+
+	scheduleHandler, zapRecorded := s.newScheduleHandler(nil)
+	r := httptest.NewRecorder()
+	scheduleHandler.CreateScheduleHandler().ServeHTTP(r, req)
+
+	s.Equal(1, zapRecorded.Len())
+	s.Equal("Failed to handle CreateScheduleHandler", zapRecorded.All()[0].Message)
+	s.Equal(http.StatusBadRequest, r.Code)
+}
+
+func (s *scheduleHandlerTestSuite) TestCreateScheduleHandlerValidationFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	request := s.request
+	request.Recurrence = "yearly"
+	payload, _ := json.Marshal(request)
+	req, err := http.NewRequest("POST", "/", bytes.NewBuffer(payload))
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	req = mux.SetURLVars(req, map[string]string{
+		"uid": "toshik1978",
+	})
+
+	scheduleManager := mock.NewMockScheduleManager(ctrl)
+	scheduleManager.
+		EXPECT().
+		CreateSchedule(gomock.Any(), gomock.Eq("toshik1978"), gomock.Eq(request)).
+		Return(nil, handler.NewError("fail", handler.ClientError))
+
+	scheduleHandler, zapRecorded := s.newScheduleHandler(scheduleManager)
+	r := httptest.NewRecorder()
+	scheduleHandler.CreateScheduleHandler().ServeHTTP(r, req)
+
+	s.Equal(1, zapRecorded.Len())
+	s.Equal(http.StatusBadRequest, r.Code)
+}
+
+func (s *scheduleHandlerTestSuite) TestCreateScheduleHandlerSucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	payload, _ := json.Marshal(s.request)
+	req, err := http.NewRequest("POST", "/", bytes.NewBuffer(payload))
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	req = mux.SetURLVars(req, map[string]string{
+		"uid": "toshik1978",
+	})
+
+	scheduleManager := mock.NewMockScheduleManager(ctrl)
+	scheduleManager.
+		EXPECT().
+		CreateSchedule(gomock.Any(), gomock.Eq("toshik1978"), gomock.Eq(s.request)).
+		Return(&s.schedule, nil)
+
+	scheduleHandler, zapRecorded := s.newScheduleHandler(scheduleManager)
+	r := httptest.NewRecorder()
+	scheduleHandler.CreateScheduleHandler().ServeHTTP(r, req)
+
+	var response handler.Schedule
+	_ = json.Unmarshal(r.Body.Bytes(), &response)
+
+	s.Equal(0, zapRecorded.Len())
+	s.Equal(http.StatusCreated, r.Code)
+	s.Equal(s.schedule, response)
+}
+
+func (s *scheduleHandlerTestSuite) TestGetAccountSchedulesHandlerSucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	req, err := http.NewRequest("GET", "/?limit=1", nil)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	req = mux.SetURLVars(req, map[string]string{
+		"uid": "toshik1978",
+	})
+
+	list := handler.ScheduleList{Schedules: []handler.Schedule{s.schedule}, NextCursor: "Mw"}
+	scheduleManager := mock.NewMockScheduleManager(ctrl)
+	scheduleManager.
+		EXPECT().
+		AccountSchedules(gomock.Any(), gomock.Eq("toshik1978"), gomock.Eq(handler.PageRequest{Limit: 1})).
+		Return(&list, nil)
+
+	scheduleHandler, zapRecorded := s.newScheduleHandler(scheduleManager)
+	r := httptest.NewRecorder()
+	scheduleHandler.GetAccountSchedulesHandler().ServeHTTP(r, req)
+
+	var response handler.ScheduleList
+	_ = json.Unmarshal(r.Body.Bytes(), &response)
+
+	s.Equal(0, zapRecorded.Len())
+	s.Equal(http.StatusOK, r.Code)
+	s.Equal(list, response)
+}
+
+func (s *scheduleHandlerTestSuite) TestGetScheduleHandlerBadURLFailed() {
+	req, err := http.NewRequest("GET", "/", nil)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	req = mux.SetURLVars(req, map[string]string{
+		"uid": "toshik1978",
+	})
+
+	scheduleHandler, zapRecorded := s.newScheduleHandler(nil)
+	r := httptest.NewRecorder()
+	scheduleHandler.GetScheduleHandler().ServeHTTP(r, req)
+
+	s.Equal(1, zapRecorded.Len())
+	s.Equal("Failed to handle GetScheduleHandler", zapRecorded.All()[0].Message)
+	s.Equal(http.StatusBadRequest, r.Code)
+}
+
+func (s *scheduleHandlerTestSuite) TestGetScheduleHandlerNotFoundFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	req, err := http.NewRequest("GET", "/", nil)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	req = mux.SetURLVars(req, map[string]string{
+		"uid": "toshik1978",
+		"id":  "3",
+	})
+
+	scheduleManager := mock.NewMockScheduleManager(ctrl)
+	scheduleManager.
+		EXPECT().
+		Schedule(gomock.Any(), gomock.Eq("toshik1978"), gomock.Eq(int64(3))).
+		Return(nil, handler.NewError("fail", handler.NotFoundError))
+
+	scheduleHandler, zapRecorded := s.newScheduleHandler(scheduleManager)
+	r := httptest.NewRecorder()
+	scheduleHandler.GetScheduleHandler().ServeHTTP(r, req)
+
+	s.Equal(1, zapRecorded.Len())
+	s.Equal(http.StatusNotFound, r.Code)
+	s.Equal("not_found", decodeProblem(r).Code)
+}
+
+func (s *scheduleHandlerTestSuite) TestCancelScheduleHandlerSucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	req, err := http.NewRequest("DELETE", "/", nil)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	req = mux.SetURLVars(req, map[string]string{
+		"uid": "toshik1978",
+		"id":  "3",
+	})
+
+	schedule := s.schedule
+	schedule.Status = "cancelled"
+	schedule.NextRunAt = nil
+	scheduleManager := mock.NewMockScheduleManager(ctrl)
+	scheduleManager.
+		EXPECT().
+		CancelSchedule(gomock.Any(), gomock.Eq("toshik1978"), gomock.Eq(int64(3))).
+		Return(&schedule, nil)
+
+	scheduleHandler, zapRecorded := s.newScheduleHandler(scheduleManager)
+	r := httptest.NewRecorder()
+	scheduleHandler.CancelScheduleHandler().ServeHTTP(r, req)
+
+	var response handler.Schedule
+	_ = json.Unmarshal(r.Body.Bytes(), &response)
+
+	s.Equal(0, zapRecorded.Len())
+	s.Equal(http.StatusOK, r.Code)
+	s.Equal(schedule, response)
+}
+
+func (s *scheduleHandlerTestSuite) TestGetScheduleRunsHandlerSucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	req, err := http.NewRequest("GET", "/", nil)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	req = mux.SetURLVars(req, map[string]string{
+		"uid": "toshik1978",
+		"id":  "3",
+	})
+
+	list := handler.ScheduleRunList{Runs: []handler.ScheduleRun{{
+		ID:          5,
+		ScheduleID:  3,
+		ScheduledAt: s.schedule.StartAt,
+		Status:      "skipped",
+		Attempts:    1,
+		Error:       "insufficient funds",
+		CreatedAt:   s.schedule.CreatedAt,
+		UpdatedAt:   s.schedule.CreatedAt,
+	}}}
+	scheduleManager := mock.NewMockScheduleManager(ctrl)
+	scheduleManager.
+		EXPECT().
+		ScheduleRuns(gomock.Any(), gomock.Eq("toshik1978"), gomock.Eq(int64(3)), gomock.Eq(handler.PageRequest{})).
+		Return(&list, nil)
+
+	scheduleHandler, zapRecorded := s.newScheduleHandler(scheduleManager)
+	r := httptest.NewRecorder()
+	scheduleHandler.GetScheduleRunsHandler().ServeHTTP(r, req)
+
+	var response handler.ScheduleRunList
+	_ = json.Unmarshal(r.Body.Bytes(), &response)
+
+	s.Equal(0, zapRecorded.Len())
+	s.Equal(http.StatusOK, r.Code)
+	s.Equal(list, response)
+}
@@ -0,0 +1,33 @@
+package httphandler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// timeoutMiddleware limits time of the request handling by the route's timeout or by the default one.
+// Request's context is cancelled on timeout, so running database queries are cancelled too.
+// Zero timeout means the request is not limited
+func timeoutMiddleware(defaultTimeout time.Duration, routeTimeouts map[string]time.Duration) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timeout := defaultTimeout
+			if route := mux.CurrentRoute(r); route != nil {
+				if routeTimeout, ok := routeTimeouts[route.GetName()]; ok {
+					timeout = routeTimeout
+				}
+			}
+			if timeout <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package httphandler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/suite"
+)
+
+type timeoutTestSuite struct {
+	suite.Suite
+}
+
+// serve runs request to the route with the given name and return time left till the request's deadline
+func (s *timeoutTestSuite) serve(name string, routeTimeouts map[string]time.Duration) (time.Duration, bool) {
+	var left time.Duration
+	var ok bool
+	r := mux.NewRouter()
+	r.Use(timeoutMiddleware(time.Minute, routeTimeouts))
+	r.Handle("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var deadline time.Time
+		deadline, ok = r.Context().Deadline()
+		left = time.Until(deadline)
+	})).Name(name)
+
+	req, err := http.NewRequest("GET", "/", nil)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	return left, ok
+}
+
+func (s *timeoutTestSuite) TestTimeoutDefaultSucceeded() {
+	left, ok := s.serve("get_account", map[string]time.Duration{"reconcile": time.Hour})
+
+	s.True(ok)
+	s.True(left > 0 && left <= time.Minute)
+}
+
+func (s *timeoutTestSuite) TestTimeoutRouteSucceeded() {
+	left, ok := s.serve("reconcile", map[string]time.Duration{"reconcile": time.Hour})
+
+	s.True(ok)
+	s.True(left > time.Minute && left <= time.Hour)
+}
+
+func (s *timeoutTestSuite) TestTimeoutDisabledSucceeded() {
+	_, ok := s.serve("reconcile", map[string]time.Duration{"reconcile": 0})
+
+	s.False(ok)
+}
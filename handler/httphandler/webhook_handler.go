@@ -0,0 +1,188 @@
+package httphandler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Toshik1978/go-rest-api/handler"
+	"github.com/Toshik1978/go-rest-api/service/errutil"
+)
+
+const (
+	idKey         = "id"
+	deliveryIDKey = "delivery_id"
+)
+
+// webhookHandler declare handler of webhooks' API requests
+type webhookHandler struct {
+	*apiHandler
+
+	webhookManager handler.WebhookManager
+}
+
+// newWebhookHandler creates new webhooks' API handler
+func newWebhookHandler(apiHandler *apiHandler, webhookManager handler.WebhookManager) *webhookHandler {
+	return &webhookHandler{
+		apiHandler:     apiHandler,
+		webhookManager: webhookManager,
+	}
+}
+
+// CreateWebhookHandler creates new webhook
+func (h *webhookHandler) CreateWebhookHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Body == nil {
+			h.fail(w, r,
+				handler.NewError("no body detected", handler.ClientError),
+				http.StatusBadRequest, "CreateWebhookHandler")
+			return
+		}
+
+		var webhookRequest handler.WebhookRequest
+		decoder := json.NewDecoder(r.Body)
+		if h.fail(w, r,
+			h.decodeError(decoder.Decode(&webhookRequest)),
+			http.StatusBadRequest, "CreateWebhookHandler") {
+
+			return
+		}
+
+		webhook, err := h.webhookManager.CreateWebhook(r.Context(), webhookRequest)
+		if h.fail(w, r,
+			errutil.Wrap(err, "failed to create webhook"),
+			http.StatusInternalServerError, "CreateWebhookHandler") {
+
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		h.writeResponse(w, webhook)
+	})
+}
+
+// GetAllWebhooksHandler response with all webhooks
+func (h *webhookHandler) GetAllWebhooksHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, err := h.pageRequest(r)
+		if h.fail(w, r, err, http.StatusBadRequest, "GetAllWebhooksHandler") {
+			return
+		}
+
+		webhooks, err := h.webhookManager.AllWebhooks(r.Context(), page)
+		if h.fail(w, r,
+			errutil.Wrap(err, "failed to get all webhooks"),
+			http.StatusInternalServerError, "GetAllWebhooksHandler") {
+
+			return
+		}
+		h.writeResponse(w, webhooks)
+	})
+}
+
+// GetWebhookHandler response with the given webhook
+func (h *webhookHandler) GetWebhookHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := h.idParam(r, idKey)
+		if h.fail(w, r, err, http.StatusBadRequest, "GetWebhookHandler") {
+			return
+		}
+
+		webhook, err := h.webhookManager.Webhook(r.Context(), id)
+		if h.fail(w, r,
+			errutil.Wrap(err, "failed to get webhook"),
+			http.StatusInternalServerError, "GetWebhookHandler") {
+
+			return
+		}
+		h.writeResponse(w, webhook)
+	})
+}
+
+// DeleteWebhookHandler deletes the given webhook
+func (h *webhookHandler) DeleteWebhookHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := h.idParam(r, idKey)
+		if h.fail(w, r, err, http.StatusBadRequest, "DeleteWebhookHandler") {
+			return
+		}
+
+		err = h.webhookManager.DeleteWebhook(r.Context(), id)
+		if h.fail(w, r,
+			errutil.Wrap(err, "failed to delete webhook"),
+			http.StatusInternalServerError, "DeleteWebhookHandler") {
+
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// GetWebhookDeliveriesHandler response with deliveries of the given webhook
+func (h *webhookHandler) GetWebhookDeliveriesHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := h.idParam(r, idKey)
+		if h.fail(w, r, err, http.StatusBadRequest, "GetWebhookDeliveriesHandler") {
+			return
+		}
+		page, err := h.pageRequest(r)
+		if h.fail(w, r, err, http.StatusBadRequest, "GetWebhookDeliveriesHandler") {
+			return
+		}
+
+		deliveries, err := h.webhookManager.WebhookDeliveries(r.Context(), id, page)
+		if h.fail(w, r,
+			errutil.Wrap(err, "failed to get webhook deliveries"),
+			http.StatusInternalServerError, "GetWebhookDeliveriesHandler") {
+
+			return
+		}
+		h.writeResponse(w, deliveries)
+	})
+}
+
+// GetDeliveryHandler response with the given delivery and its attempts
+func (h *webhookHandler) GetDeliveryHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := h.idParam(r, idKey)
+		if h.fail(w, r, err, http.StatusBadRequest, "GetDeliveryHandler") {
+			return
+		}
+		deliveryID, err := h.idParam(r, deliveryIDKey)
+		if h.fail(w, r, err, http.StatusBadRequest, "GetDeliveryHandler") {
+			return
+		}
+
+		delivery, err := h.webhookManager.Delivery(r.Context(), id, deliveryID)
+		if h.fail(w, r,
+			errutil.Wrap(err, "failed to get delivery"),
+			http.StatusInternalServerError, "GetDeliveryHandler") {
+
+			return
+		}
+		h.writeResponse(w, delivery)
+	})
+}
+
+// RedeliverHandler schedules the given delivery to be delivered again
+func (h *webhookHandler) RedeliverHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := h.idParam(r, idKey)
+		if h.fail(w, r, err, http.StatusBadRequest, "RedeliverHandler") {
+			return
+		}
+		deliveryID, err := h.idParam(r, deliveryIDKey)
+		if h.fail(w, r, err, http.StatusBadRequest, "RedeliverHandler") {
+			return
+		}
+
+		delivery, err := h.webhookManager.Redeliver(r.Context(), id, deliveryID)
+		if h.fail(w, r,
+			errutil.Wrap(err, "failed to redeliver"),
+			http.StatusInternalServerError, "RedeliverHandler") {
+
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		h.writeResponse(w, delivery)
+	})
+}
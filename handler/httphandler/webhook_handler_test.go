@@ -0,0 +1,314 @@
+package httphandler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/handler"
+	"github.com/Toshik1978/go-rest-api/mock"
+	"github.com/Toshik1978/go-rest-api/service/server"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// webhookHandlerTestSuite test suite
+type webhookHandlerTestSuite struct {
+	suite.Suite
+
+	webhook handler.Webhook
+}
+
+func (s *webhookHandlerTestSuite) SetupTest() {
+	s.webhook = handler.Webhook{
+		ID:        3,
+		URL:       "https://example.com/webhook",
+		EventType: "payment.completed",
+		CreatedAt: time.Now().Round(time.Millisecond).UTC(),
+	}
+}
+
+// newWebhookHandler creates webhooks' API handler with logger, which records messages
+func (s *webhookHandlerTestSuite) newWebhookHandler(
+	webhookManager handler.WebhookManager) (*webhookHandler, *observer.ObservedLogs) {
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	apiHandler := newAPIHandler(server.Globals{
+		Logger: zap.New(zapCore),
+	}, nil)
+	return newWebhookHandler(apiHandler, webhookManager), zapRecorded
+}
+
+func (s *webhookHandlerTestSuite) TestCreateWebhookHandlerNoBodyFailed() {
+	req, err := http.NewRequest("POST", "/", nil)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+
+	webhookHandler, zapRecorded := s.newWebhookHandler(nil)
+	r := httptest.NewRecorder()
+	webhookHandler.CreateWebhookHandler().ServeHTTP(r, req)
+
+	s.Equal(1, zapRecorded.Len())
+	s.Equal("Failed to handle CreateWebhookHandler", zapRecorded.All()[0].Message)
+	s.Equal(http.StatusBadRequest, r.Code)
+}
+
+func (s *webhookHandlerTestSuite) TestCreateWebhookHandlerValidationFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	request := handler.WebhookRequest{URL: "not a url"}
+	payload, _ := json.Marshal(request)
+	req, err := http.NewRequest("POST", "/", bytes.NewBuffer(payload))
+	if err != nil {
+		s.T().Fatal(err)
+	}
+
+	webhookManager := mock.NewMockWebhookManager(ctrl)
+	webhookManager.
+		EXPECT().
+		CreateWebhook(gomock.Any(), gomock.Eq(request)).
+		Return(nil, handler.NewError("fail", handler.ClientError))
+
+	webhookHandler, zapRecorded := s.newWebhookHandler(webhookManager)
+	r := httptest.NewRecorder()
+	webhookHandler.CreateWebhookHandler().ServeHTTP(r, req)
+
+	s.Equal(1, zapRecorded.Len())
+	s.Equal(http.StatusBadRequest, r.Code)
+}
+
+func (s *webhookHandlerTestSuite) TestCreateWebhookHandlerSucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	request := handler.WebhookRequest{URL: s.webhook.URL, EventType: s.webhook.EventType}
+	payload, _ := json.Marshal(request)
+	req, err := http.NewRequest("POST", "/", bytes.NewBuffer(payload))
+	if err != nil {
+		s.T().Fatal(err)
+	}
+
+	webhook := s.webhook
+	webhook.Secret = "0123456789abcdef0123456789abcdef"
+	webhookManager := mock.NewMockWebhookManager(ctrl)
+	webhookManager.
+		EXPECT().
+		CreateWebhook(gomock.Any(), gomock.Eq(request)).
+		Return(&webhook, nil)
+
+	webhookHandler, zapRecorded := s.newWebhookHandler(webhookManager)
+	r := httptest.NewRecorder()
+	webhookHandler.CreateWebhookHandler().ServeHTTP(r, req)
+
+	var response handler.Webhook
+	_ = json.Unmarshal(r.Body.Bytes(), &response)
+
+	s.Equal(0, zapRecorded.Len())
+	s.Equal(http.StatusCreated, r.Code)
+	s.Equal(webhook, response)
+}
+
+func (s *webhookHandlerTestSuite) TestGetWebhookHandlerBadURLFailed() {
+	req, err := http.NewRequest("GET", "/", nil)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+
+	webhookHandler, zapRecorded := s.newWebhookHandler(nil)
+	r := httptest.NewRecorder()
+	webhookHandler.GetWebhookHandler().ServeHTTP(r, req)
+
+	s.Equal(1, zapRecorded.Len())
+	s.Equal("Failed to handle GetWebhookHandler", zapRecorded.All()[0].Message)
+	s.Equal(http.StatusBadRequest, r.Code)
+}
+
+func (s *webhookHandlerTestSuite) TestGetWebhookHandlerNotFoundFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	req, err := http.NewRequest("GET", "/", nil)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	req = mux.SetURLVars(req, map[string]string{
+		"id": "3",
+	})
+
+	webhookManager := mock.NewMockWebhookManager(ctrl)
+	webhookManager.
+		EXPECT().
+		Webhook(gomock.Any(), gomock.Eq(int64(3))).
+		Return(nil, handler.NewError("fail", handler.NotFoundError))
+
+	webhookHandler, zapRecorded := s.newWebhookHandler(webhookManager)
+	r := httptest.NewRecorder()
+	webhookHandler.GetWebhookHandler().ServeHTTP(r, req)
+
+	s.Equal(1, zapRecorded.Len())
+	s.Equal(http.StatusNotFound, r.Code)
+	s.Equal("not_found", decodeProblem(r).Code)
+}
+
+func (s *webhookHandlerTestSuite) TestGetAllWebhooksHandlerSucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	req, err := http.NewRequest("GET", "/?limit=1", nil)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+
+	list := handler.WebhookList{Webhooks: []handler.Webhook{s.webhook}, NextCursor: "Mw"}
+	webhookManager := mock.NewMockWebhookManager(ctrl)
+	webhookManager.
+		EXPECT().
+		AllWebhooks(gomock.Any(), gomock.Eq(handler.PageRequest{Limit: 1})).
+		Return(&list, nil)
+
+	webhookHandler, zapRecorded := s.newWebhookHandler(webhookManager)
+	r := httptest.NewRecorder()
+	webhookHandler.GetAllWebhooksHandler().ServeHTTP(r, req)
+
+	var response handler.WebhookList
+	_ = json.Unmarshal(r.Body.Bytes(), &response)
+
+	s.Equal(0, zapRecorded.Len())
+	s.Equal(http.StatusOK, r.Code)
+	s.Equal(list, response)
+}
+
+func (s *webhookHandlerTestSuite) TestDeleteWebhookHandlerSucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	req, err := http.NewRequest("DELETE", "/", nil)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	req = mux.SetURLVars(req, map[string]string{
+		"id": "3",
+	})
+
+	webhookManager := mock.NewMockWebhookManager(ctrl)
+	webhookManager.
+		EXPECT().
+		DeleteWebhook(gomock.Any(), gomock.Eq(int64(3))).
+		Return(nil)
+
+	webhookHandler, zapRecorded := s.newWebhookHandler(webhookManager)
+	r := httptest.NewRecorder()
+	webhookHandler.DeleteWebhookHandler().ServeHTTP(r, req)
+
+	s.Equal(0, zapRecorded.Len())
+	s.Equal(http.StatusNoContent, r.Code)
+	s.Empty(r.Body.Bytes())
+}
+
+func (s *webhookHandlerTestSuite) TestGetWebhookDeliveriesHandlerSucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	req, err := http.NewRequest("GET", "/", nil)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	req = mux.SetURLVars(req, map[string]string{
+		"id": "3",
+	})
+
+	list := handler.DeliveryList{Deliveries: []handler.Delivery{{
+		ID:        5,
+		WebhookID: 3,
+		EventID:   7,
+		EventType: "payment.completed",
+		Status:    "succeeded",
+		Attempts:  1,
+		CreatedAt: s.webhook.CreatedAt,
+		UpdatedAt: s.webhook.CreatedAt,
+	}}}
+	webhookManager := mock.NewMockWebhookManager(ctrl)
+	webhookManager.
+		EXPECT().
+		WebhookDeliveries(gomock.Any(), gomock.Eq(int64(3)), gomock.Eq(handler.PageRequest{})).
+		Return(&list, nil)
+
+	webhookHandler, zapRecorded := s.newWebhookHandler(webhookManager)
+	r := httptest.NewRecorder()
+	webhookHandler.GetWebhookDeliveriesHandler().ServeHTTP(r, req)
+
+	var response handler.DeliveryList
+	_ = json.Unmarshal(r.Body.Bytes(), &response)
+
+	s.Equal(0, zapRecorded.Len())
+	s.Equal(http.StatusOK, r.Code)
+	s.Equal(list, response)
+}
+
+func (s *webhookHandlerTestSuite) TestGetDeliveryHandlerBadURLFailed() {
+	req, err := http.NewRequest("GET", "/", nil)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	req = mux.SetURLVars(req, map[string]string{
+		"id":          "3",
+		"delivery_id": "99999999999999999999",
+	})
+
+	webhookHandler, zapRecorded := s.newWebhookHandler(nil)
+	r := httptest.NewRecorder()
+	webhookHandler.GetDeliveryHandler().ServeHTTP(r, req)
+
+	s.Equal(1, zapRecorded.Len())
+	s.Equal(http.StatusBadRequest, r.Code)
+}
+
+func (s *webhookHandlerTestSuite) TestRedeliverHandlerSucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	req, err := http.NewRequest("POST", "/", nil)
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	req = mux.SetURLVars(req, map[string]string{
+		"id":          "3",
+		"delivery_id": "5",
+	})
+
+	nextAttemptAt := s.webhook.CreatedAt
+	delivery := handler.Delivery{
+		ID:            5,
+		WebhookID:     3,
+		EventID:       7,
+		EventType:     "payment.completed",
+		Status:        "pending",
+		NextAttemptAt: &nextAttemptAt,
+		CreatedAt:     s.webhook.CreatedAt,
+		UpdatedAt:     s.webhook.CreatedAt,
+	}
+	webhookManager := mock.NewMockWebhookManager(ctrl)
+	webhookManager.
+		EXPECT().
+		Redeliver(gomock.Any(), gomock.Eq(int64(3)), gomock.Eq(int64(5))).
+		Return(&delivery, nil)
+
+	webhookHandler, zapRecorded := s.newWebhookHandler(webhookManager)
+	r := httptest.NewRecorder()
+	webhookHandler.RedeliverHandler().ServeHTTP(r, req)
+
+	var response handler.Delivery
+	_ = json.Unmarshal(r.Body.Bytes(), &response)
+
+	s.Equal(0, zapRecorded.Len())
+	s.Equal(http.StatusAccepted, r.Code)
+	s.Equal(delivery, response)
+}
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/service/money"
+)
+
+// ScheduleRequest define request to create new scheduled payment. Amount is given in payer's currency,
+// cron expression is required for cron recurrence only. Empty start means now, empty policy means skip
+type ScheduleRequest struct {
+	RecipientUID        string       `json:"recipient"`
+	Amount              money.Amount `json:"amount"`
+	StartAt             *time.Time   `json:"start_at"`
+	Recurrence          string       `json:"recurrence"`
+	Cron                string       `json:"cron"`
+	OnInsufficientFunds string       `json:"on_insufficient_funds"`
+}
+
+// Schedule define scheduled payment description. NextRunAt is set for active schedule only
+type Schedule struct {
+	ID                  int64        `json:"id"`
+	UID                 string       `json:"account"`
+	RecipientUID        string       `json:"recipient"`
+	Amount              money.Amount `json:"amount"`
+	Currency            string       `json:"currency"`
+	Recurrence          string       `json:"recurrence"`
+	Cron                string       `json:"cron,omitempty"`
+	OnInsufficientFunds string       `json:"on_insufficient_funds"`
+	Status              string       `json:"status"`
+	StartAt             time.Time    `json:"start_at"`
+	NextRunAt           *time.Time   `json:"next_run_at,omitempty"`
+	CreatedAt           time.Time    `json:"created_at"`
+	UpdatedAt           time.Time    `json:"updated_at"`
+}
+
+// ScheduleList define single page of scheduled payments
+type ScheduleList struct {
+	Schedules  []Schedule `json:"schedules"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
+
+// ScheduleRun define run of the scheduled payment's occurrence. NextAttemptAt is set for pending run only,
+// error is the reason of the last failed attempt
+type ScheduleRun struct {
+	ID            int64      `json:"id"`
+	ScheduleID    int64      `json:"schedule_id"`
+	ScheduledAt   time.Time  `json:"scheduled_at"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	Error         string     `json:"error,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// ScheduleRunList define single page of scheduled payment's runs
+type ScheduleRunList struct {
+	Runs       []ScheduleRun `json:"runs"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
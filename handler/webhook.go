@@ -0,0 +1,57 @@
+package handler
+
+import "time"
+
+// WebhookRequest define request to create new webhook. Empty event type or account means any of them,
+// empty secret means it's generated
+type WebhookRequest struct {
+	URL        string `json:"url"`
+	EventType  string `json:"event_type"`
+	AccountUID string `json:"account"`
+	Secret     string `json:"secret"`
+}
+
+// Webhook define webhook description. Secret is returned only by webhook's creation
+type Webhook struct {
+	ID         int64     `json:"id"`
+	URL        string    `json:"url"`
+	EventType  string    `json:"event_type,omitempty"`
+	AccountUID string    `json:"account,omitempty"`
+	Secret     string    `json:"secret,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// WebhookList define single page of webhooks
+type WebhookList struct {
+	Webhooks   []Webhook `json:"webhooks"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
+
+// Delivery define delivery of the event to the webhook. NextAttemptAt is set for pending delivery only,
+// attempt log is returned for the single delivery only
+type Delivery struct {
+	ID            int64             `json:"id"`
+	WebhookID     int64             `json:"webhook_id"`
+	EventID       int64             `json:"event_id"`
+	EventType     string            `json:"event_type"`
+	Status        string            `json:"status"`
+	Attempts      int               `json:"attempts"`
+	NextAttemptAt *time.Time        `json:"next_attempt_at,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+	AttemptLog    []DeliveryAttempt `json:"attempt_log,omitempty"`
+}
+
+// DeliveryAttempt define single attempt to deliver event. Status code is omitted, if there is no response
+type DeliveryAttempt struct {
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMS int64     `json:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// DeliveryList define single page of deliveries
+type DeliveryList struct {
+	Deliveries []Delivery `json:"deliveries"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -12,10 +13,17 @@ import (
 	"github.com/Toshik1978/go-rest-api/handler"
 	"github.com/Toshik1978/go-rest-api/handler/account"
 	"github.com/Toshik1978/go-rest-api/handler/httphandler"
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/repository/memoryengine"
 	"github.com/Toshik1978/go-rest-api/repository/repositoryengine"
 	"github.com/Toshik1978/go-rest-api/service"
+	"github.com/Toshik1978/go-rest-api/service/broker"
+	"github.com/Toshik1978/go-rest-api/service/currency"
+	"github.com/Toshik1978/go-rest-api/service/fx"
+	"github.com/Toshik1978/go-rest-api/service/outbox"
 	"github.com/Toshik1978/go-rest-api/service/postgres"
 	"github.com/Toshik1978/go-rest-api/service/server"
+	"github.com/Toshik1978/go-rest-api/service/sqlite"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -31,6 +39,16 @@ var (
 
 func main() {
 	logger := initializeLogger()
+	// Subcommands run instead of the service and exit with their own code
+	if len(os.Args) > 1 {
+		if os.Args[1] == migrateCommand {
+			os.Exit(runMigrate(logger, os.Args[2:]))
+		}
+		if command, ok := adminCommands[os.Args[1]]; ok {
+			os.Exit(runAdmin(logger, os.Args[1], command, os.Args[2:]))
+		}
+	}
+
 	defer func() {
 		if recErr := recover(); recErr != nil {
 			// Log error
@@ -45,12 +63,18 @@ func main() {
 	logger.Info("Start service", zap.String("git_version", GitVersion))
 	vars := server.LoadConfig(logger)
 
-	dbClient := initializeDB(logger, vars)
-	globals := initializeGlobals(logger, dbClient)
+	repositoryFactory, dbClient := initializeStorage(logger, vars)
+	currencyRegistry := initializeCurrencies(logger, vars)
+	fxRateProvider := initializeFX(logger, vars)
+	globals := initializeGlobals(logger, vars, repositoryFactory, currencyRegistry, fxRateProvider)
 	accountManager := account.NewAccountManager(globals)
-	server := initializeHTTP(vars, globals, accountManager)
+	webhookManager := account.NewWebhookManager(globals)
+	scheduleManager := account.NewScheduleManager(globals)
+	dispatchers := initializeOutbox(logger, vars, repositoryFactory)
+	scheduler := initializeScheduler(logger, vars, globals)
+	server := initializeHTTP(vars, globals, accountManager, webhookManager, scheduleManager)
 
-	waitShutdown(interruptCh, logger, dbClient, server)
+	waitShutdown(interruptCh, logger, dbClient, dispatchers, scheduler, server)
 }
 
 // initializeLogger initialized logger
@@ -67,9 +91,31 @@ func initializeLogger() *zap.Logger {
 	return logger
 }
 
+// initializeStorage initializes storage, selected by configuration, and return its repositories.
+// DB client is nil for in-memory storage
+func initializeStorage(logger *zap.Logger, vars server.Vars) (repository.Factory, service.DBClient) {
+	if vars.DBDriver == server.MemoryDriver {
+		logger.Warn("In-memory storage initialized, data is lost on stop")
+		return memoryengine.NewRepositoryFactory(), nil
+	}
+
+	dbClient := initializeDB(logger, vars)
+	initializeSchema(logger, vars, initializeMigrator(logger, vars, dbClient))
+	if vars.DBDriver == server.SQLiteDriver {
+		return repositoryengine.NewSQLiteRepositoryFactory(logger, dbClient.GetConnection()), dbClient
+	}
+	return repositoryengine.NewRepositoryFactory(logger, dbClient.GetConnection()), dbClient
+}
+
 // initializeDB initializes DB
-func initializeDB(logger *zap.Logger, vars server.Vars) service.PostgresClient {
-	client, err := postgres.NewPostgresClient(logger, vars)
+func initializeDB(logger *zap.Logger, vars server.Vars) service.DBClient {
+	var client service.DBClient
+	var err error
+	if vars.DBDriver == server.SQLiteDriver {
+		client, err = sqlite.NewSQLiteClient(logger, vars)
+	} else {
+		client, err = postgres.NewPostgresClient(logger, vars)
+	}
 	if err != nil {
 		logger.Fatal("DB initialization failed", zap.Error(err))
 		return nil
@@ -78,23 +124,131 @@ func initializeDB(logger *zap.Logger, vars server.Vars) service.PostgresClient {
 	return client
 }
 
+// initializeMigrator initializes migrator of DB schema with migrations of the DB driver
+func initializeMigrator(logger *zap.Logger, vars server.Vars, dbClient service.DBClient) service.Migrator {
+	if vars.DBDriver == server.SQLiteDriver {
+		return sqlite.NewMigrator(logger, dbClient.GetConnection())
+	}
+	return postgres.NewMigrator(logger, dbClient.GetConnection())
+}
+
+// initializeSchema migrates database schema, if auto migration is enabled.
+// Service refuses to start, if schema is behind the binary
+func initializeSchema(logger *zap.Logger, vars server.Vars, migrator service.Migrator) {
+	if vars.DBAutoMigrate {
+		if err := migrator.Up(context.Background(), 0); err != nil {
+			logger.Fatal("Schema migration failed", zap.Error(err))
+			return
+		}
+	}
+
+	version, err := migrator.Version(context.Background())
+	if err != nil {
+		logger.Fatal("Schema version check failed", zap.Error(err))
+		return
+	}
+	if version.Dirty || version.Version < migrator.Latest() {
+		logger.Fatal("Schema is behind the binary, run migrate up",
+			zap.Uint("version", version.Version),
+			zap.Bool("dirty", version.Dirty),
+			zap.Uint("expected_version", migrator.Latest()))
+		return
+	}
+	logger.Info("Schema checked", zap.Uint("version", version.Version))
+}
+
+// initializeOutbox initializes sink of domain events, selected by configuration,
+// and starts delivery of events from the outbox to the sink and to webhooks
+func initializeOutbox(logger *zap.Logger, vars server.Vars,
+	repositoryFactory repository.Factory) []service.EventDispatcher {
+
+	var sink service.EventSink
+	switch vars.OutboxSink {
+	case server.FileSink:
+		sink = outbox.NewFileSink(vars)
+	case server.HTTPSink:
+		sink = outbox.NewHTTPSink(vars)
+	default:
+		sink = outbox.NewLogSink(logger)
+	}
+
+	// Webhook sink goes first, so event is not duplicated in the other sink, if scheduling of deliveries fails
+	sink = outbox.NewMultiSink(outbox.NewWebhookSink(repositoryFactory), sink)
+
+	dispatchers := []service.EventDispatcher{
+		outbox.NewDispatcher(logger, repositoryFactory, sink, vars),
+		outbox.NewWebhookDispatcher(logger, repositoryFactory, vars),
+	}
+	for _, dispatcher := range dispatchers {
+		dispatcher.Start()
+	}
+	logger.Info("Outbox initialized", zap.String("sink", vars.OutboxSink))
+	return dispatchers
+}
+
+// initializeScheduler starts running of scheduled payments
+func initializeScheduler(logger *zap.Logger, vars server.Vars, globals server.Globals) handler.Scheduler {
+	scheduler := account.NewScheduler(globals, vars)
+	scheduler.Start()
+	logger.Info("Scheduler initialized", zap.Duration("interval", vars.ScheduleInterval))
+	return scheduler
+}
+
+// initializeCurrencies initializes currency registry
+func initializeCurrencies(logger *zap.Logger, vars server.Vars) service.CurrencyRegistry {
+	registry, err := currency.NewCurrencyRegistry(vars)
+	if err != nil {
+		logger.Fatal("Currencies initialization failed", zap.Error(err))
+		return nil
+	}
+	logger.Info("Currencies initialized", zap.Int("count", len(registry.Currencies())))
+	return registry
+}
+
+// initializeFX initializes exchange rates provider
+func initializeFX(logger *zap.Logger, vars server.Vars) service.FXRateProvider {
+	provider, err := fx.NewFileRateProvider(vars)
+	if err != nil {
+		logger.Fatal("Exchange rates initialization failed", zap.Error(err))
+		return nil
+	}
+	logger.Info("Exchange rates initialized", zap.String("rates_file", vars.FXRatesFile))
+	return provider
+}
+
 // initializeGlobals initialize globals
-func initializeGlobals(logger *zap.Logger, dbClient service.PostgresClient) server.Globals {
-	db := dbClient.GetConnection()
+func initializeGlobals(logger *zap.Logger, vars server.Vars, repositoryFactory repository.Factory,
+	currencyRegistry service.CurrencyRegistry, fxRateProvider service.FXRateProvider) server.Globals {
+
 	return server.Globals{
 		Logger:            logger,
-		RepositoryFactory: repositoryengine.NewRepositoryFactory(db),
+		RepositoryFactory: repositoryFactory,
+		CurrencyRegistry:  currencyRegistry,
+		FXRateProvider:    fxRateProvider,
+		EventBroker:       broker.NewBroker(vars),
+		IdempotencyTTL:    vars.IdempotencyTTL,
+		RequestTimeout:    vars.HTTPTimeout,
+		RouteTimeouts:     vars.HTTPRouteTimeouts,
+		EventsKeepAlive:   vars.EventsKeepAlive,
 		BuildTime:         BuildTime,
 		Version:           GitVersion,
 	}
 }
 
 // initializeHTTP initializes HTTP server
-func initializeHTTP(vars server.Vars, globals server.Globals, accountManager handler.AccountManager) *http.Server {
+func initializeHTTP(vars server.Vars, globals server.Globals,
+	accountManager handler.AccountManager, webhookManager handler.WebhookManager,
+	scheduleManager handler.ScheduleManager) *http.Server {
+
 	server := &http.Server{
 		Addr:    vars.HTTPAddress + ":" + vars.HTTPPort,
-		Handler: httphandler.NewHTTPHandler(globals, accountManager),
+		Handler: httphandler.NewHTTPHandler(globals, accountManager, webhookManager, scheduleManager),
 	}
+	// Event streams are never finished by clients, so they are finished on shutdown,
+	// otherwise graceful shutdown waits for them till timeout
+	baseCtx, cancel := context.WithCancel(context.Background())
+	server.BaseContext = func(net.Listener) context.Context { return baseCtx }
+	server.RegisterOnShutdown(cancel)
 
 	go func() {
 		globals.Logger.Info("HTTP server initializing",
@@ -113,14 +267,25 @@ func initializeHTTP(vars server.Vars, globals server.Globals, accountManager han
 }
 
 // waitShutdown waits for shutdown signal
-func waitShutdown(interruptCh <-chan os.Signal,
-	logger *zap.Logger, dbClient service.PostgresClient, server *http.Server) {
+func waitShutdown(interruptCh <-chan os.Signal, logger *zap.Logger,
+	dbClient service.DBClient, dispatchers []service.EventDispatcher, scheduler handler.Scheduler,
+	server *http.Server) {
 
 	// Wait for interrupt
 	<-interruptCh
 
-	dbClient.Stop()
-	dbClient = nil
+	// Payments in progress are finished, missed occurrences are run after the next start
+	scheduler.Stop()
+
+	// Events, which aren't delivered yet, are delivered after the next start
+	for _, dispatcher := range dispatchers {
+		dispatcher.Stop()
+	}
+
+	if dbClient != nil {
+		dbClient.Stop()
+		dbClient = nil
+	}
 
 	// Graceful shutdown HTTP server
 	ctx, cancel := context.WithTimeout(context.Background(), httpShutdownTimeout)
@@ -0,0 +1,11 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestCommands(t *testing.T) {
+	suite.Run(t, new(adminTestSuite))
+}
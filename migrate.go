@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strconv"
+
+	"github.com/Toshik1978/go-rest-api/service/server"
+	"go.uber.org/zap"
+)
+
+const (
+	migrateCommand = "migrate"
+
+	migrateFailedCode = 1
+)
+
+// runMigrate migrates database schema with migrations, embedded into the binary, and return exit code.
+// Usage: migrate up [N] | down [N] | status | version. Up applies all pending migrations by default,
+// down reverts the last one by default, so the whole schema is never dropped by mistake
+func runMigrate(logger *zap.Logger, args []string) int {
+	if len(args) == 0 || len(args) > 2 {
+		logger.Error("Usage: migrate up [N] | down [N] | status | version")
+		return migrateFailedCode
+	}
+	n := 0
+	if len(args) == 2 {
+		var err error
+		if n, err = strconv.Atoi(args[1]); err != nil || n <= 0 {
+			logger.Error("Number of migrations should be positive integer", zap.String("n", args[1]))
+			return migrateFailedCode
+		}
+	}
+
+	vars := server.LoadConfig(logger)
+	if vars.DBDriver == server.MemoryDriver {
+		logger.Error("In-memory storage has no schema to migrate")
+		return migrateFailedCode
+	}
+	dbClient := initializeDB(logger, vars)
+	defer dbClient.Stop()
+	migrator := initializeMigrator(logger, vars, dbClient)
+
+	ctx := context.Background()
+	var result interface{}
+	var err error
+	switch args[0] {
+	case "up":
+		err = migrator.Up(ctx, n)
+	case "down":
+		if n == 0 {
+			n = 1
+		}
+		err = migrator.Down(ctx, n)
+	case "status":
+		result, err = migrator.Status(ctx)
+	case "version":
+		result, err = migrator.Version(ctx)
+	default:
+		logger.Error("Unknown migrate command", zap.String("command", args[0]))
+		return migrateFailedCode
+	}
+	if err != nil {
+		logger.Error("Failed to migrate schema", zap.String("command", args[0]), zap.Error(err))
+		return migrateFailedCode
+	}
+
+	if result != nil {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(result); err != nil {
+			logger.Error("Failed to write migrate result", zap.Error(err))
+			return migrateFailedCode
+		}
+	}
+	return 0
+}
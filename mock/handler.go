@@ -7,6 +7,7 @@ package mock
 import (
 	context "context"
 	handler "github.com/Toshik1978/go-rest-api/handler"
+	money "github.com/Toshik1978/go-rest-api/service/money"
 	gomock "github.com/golang/mock/gomock"
 	reflect "reflect"
 )
@@ -49,7 +50,7 @@ func (mr *MockAccountBuilderMockRecorder) SetUID(uid interface{}) *gomock.Call {
 }
 
 // SetBalance mocks base method
-func (m *MockAccountBuilder) SetBalance(balance float64) handler.AccountBuilder {
+func (m *MockAccountBuilder) SetBalance(balance money.Amount) handler.AccountBuilder {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "SetBalance", balance)
 	ret0, _ := ret[0].(handler.AccountBuilder)
@@ -76,6 +77,20 @@ func (mr *MockAccountBuilderMockRecorder) SetCurrency(currency interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCurrency", reflect.TypeOf((*MockAccountBuilder)(nil).SetCurrency), currency)
 }
 
+// SetIdempotencyKey mocks base method
+func (m *MockAccountBuilder) SetIdempotencyKey(key string) handler.AccountBuilder {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetIdempotencyKey", key)
+	ret0, _ := ret[0].(handler.AccountBuilder)
+	return ret0
+}
+
+// SetIdempotencyKey indicates an expected call of SetIdempotencyKey
+func (mr *MockAccountBuilderMockRecorder) SetIdempotencyKey(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetIdempotencyKey", reflect.TypeOf((*MockAccountBuilder)(nil).SetIdempotencyKey), key)
+}
+
 // Build mocks base method
 func (m *MockAccountBuilder) Build(ctx context.Context) (*handler.Account, error) {
 	m.ctrl.T.Helper()
@@ -115,7 +130,7 @@ func (m *MockPaymentBuilder) EXPECT() *MockPaymentBuilderMockRecorder {
 }
 
 // SetAmount mocks base method
-func (m *MockPaymentBuilder) SetAmount(amount float64) handler.PaymentBuilder {
+func (m *MockPaymentBuilder) SetAmount(amount money.Amount) handler.PaymentBuilder {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "SetAmount", amount)
 	ret0, _ := ret[0].(handler.PaymentBuilder)
@@ -156,6 +171,20 @@ func (mr *MockPaymentBuilderMockRecorder) SetRecipient(uid interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRecipient", reflect.TypeOf((*MockPaymentBuilder)(nil).SetRecipient), uid)
 }
 
+// SetIdempotencyKey mocks base method
+func (m *MockPaymentBuilder) SetIdempotencyKey(key string) handler.PaymentBuilder {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetIdempotencyKey", key)
+	ret0, _ := ret[0].(handler.PaymentBuilder)
+	return ret0
+}
+
+// SetIdempotencyKey indicates an expected call of SetIdempotencyKey
+func (mr *MockPaymentBuilderMockRecorder) SetIdempotencyKey(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetIdempotencyKey", reflect.TypeOf((*MockPaymentBuilder)(nil).SetIdempotencyKey), key)
+}
+
 // Build mocks base method
 func (m *MockPaymentBuilder) Build(ctx context.Context) (*handler.Payment, error) {
 	m.ctrl.T.Helper()
@@ -171,6 +200,86 @@ func (mr *MockPaymentBuilderMockRecorder) Build(ctx interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Build", reflect.TypeOf((*MockPaymentBuilder)(nil).Build), ctx)
 }
 
+// MockRefundBuilder is a mock of RefundBuilder interface
+type MockRefundBuilder struct {
+	ctrl     *gomock.Controller
+	recorder *MockRefundBuilderMockRecorder
+}
+
+// MockRefundBuilderMockRecorder is the mock recorder for MockRefundBuilder
+type MockRefundBuilderMockRecorder struct {
+	mock *MockRefundBuilder
+}
+
+// NewMockRefundBuilder creates a new mock instance
+func NewMockRefundBuilder(ctrl *gomock.Controller) *MockRefundBuilder {
+	mock := &MockRefundBuilder{ctrl: ctrl}
+	mock.recorder = &MockRefundBuilderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockRefundBuilder) EXPECT() *MockRefundBuilderMockRecorder {
+	return m.recorder
+}
+
+// SetPayment mocks base method
+func (m *MockRefundBuilder) SetPayment(id int64) handler.RefundBuilder {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPayment", id)
+	ret0, _ := ret[0].(handler.RefundBuilder)
+	return ret0
+}
+
+// SetPayment indicates an expected call of SetPayment
+func (mr *MockRefundBuilderMockRecorder) SetPayment(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPayment", reflect.TypeOf((*MockRefundBuilder)(nil).SetPayment), id)
+}
+
+// SetAmount mocks base method
+func (m *MockRefundBuilder) SetAmount(amount *money.Amount) handler.RefundBuilder {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetAmount", amount)
+	ret0, _ := ret[0].(handler.RefundBuilder)
+	return ret0
+}
+
+// SetAmount indicates an expected call of SetAmount
+func (mr *MockRefundBuilderMockRecorder) SetAmount(amount interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAmount", reflect.TypeOf((*MockRefundBuilder)(nil).SetAmount), amount)
+}
+
+// SetIdempotencyKey mocks base method
+func (m *MockRefundBuilder) SetIdempotencyKey(key string) handler.RefundBuilder {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetIdempotencyKey", key)
+	ret0, _ := ret[0].(handler.RefundBuilder)
+	return ret0
+}
+
+// SetIdempotencyKey indicates an expected call of SetIdempotencyKey
+func (mr *MockRefundBuilderMockRecorder) SetIdempotencyKey(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetIdempotencyKey", reflect.TypeOf((*MockRefundBuilder)(nil).SetIdempotencyKey), key)
+}
+
+// Build mocks base method
+func (m *MockRefundBuilder) Build(ctx context.Context) (*handler.Payment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Build", ctx)
+	ret0, _ := ret[0].(*handler.Payment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Build indicates an expected call of Build
+func (mr *MockRefundBuilderMockRecorder) Build(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Build", reflect.TypeOf((*MockRefundBuilder)(nil).Build), ctx)
+}
+
 // MockAccountManager is a mock of AccountManager interface
 type MockAccountManager struct {
 	ctrl     *gomock.Controller
@@ -194,34 +303,109 @@ func (m *MockAccountManager) EXPECT() *MockAccountManagerMockRecorder {
 	return m.recorder
 }
 
+// Account mocks base method
+func (m *MockAccountManager) Account(ctx context.Context, uid string) (*handler.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Account", ctx, uid)
+	ret0, _ := ret[0].(*handler.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Account indicates an expected call of Account
+func (mr *MockAccountManagerMockRecorder) Account(ctx, uid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Account", reflect.TypeOf((*MockAccountManager)(nil).Account), ctx, uid)
+}
+
+// UpdateAccountStatus mocks base method
+func (m *MockAccountManager) UpdateAccountStatus(ctx context.Context, uid, status string) (*handler.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAccountStatus", ctx, uid, status)
+	ret0, _ := ret[0].(*handler.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateAccountStatus indicates an expected call of UpdateAccountStatus
+func (mr *MockAccountManagerMockRecorder) UpdateAccountStatus(ctx, uid, status interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccountStatus", reflect.TypeOf((*MockAccountManager)(nil).UpdateAccountStatus), ctx, uid, status)
+}
+
 // AllAccounts mocks base method
-func (m *MockAccountManager) AllAccounts(ctx context.Context) ([]handler.Account, error) {
+func (m *MockAccountManager) AllAccounts(ctx context.Context, page handler.PageRequest) (*handler.AccountList, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AllAccounts", ctx)
-	ret0, _ := ret[0].([]handler.Account)
+	ret := m.ctrl.Call(m, "AllAccounts", ctx, page)
+	ret0, _ := ret[0].(*handler.AccountList)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // AllAccounts indicates an expected call of AllAccounts
-func (mr *MockAccountManagerMockRecorder) AllAccounts(ctx interface{}) *gomock.Call {
+func (mr *MockAccountManagerMockRecorder) AllAccounts(ctx, page interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllAccounts", reflect.TypeOf((*MockAccountManager)(nil).AllAccounts), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllAccounts", reflect.TypeOf((*MockAccountManager)(nil).AllAccounts), ctx, page)
 }
 
 // AllPayments mocks base method
-func (m *MockAccountManager) AllPayments(ctx context.Context) ([]handler.Payment, error) {
+func (m *MockAccountManager) AllPayments(ctx context.Context, page handler.PageRequest) (*handler.PaymentList, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AllPayments", ctx)
-	ret0, _ := ret[0].([]handler.Payment)
+	ret := m.ctrl.Call(m, "AllPayments", ctx, page)
+	ret0, _ := ret[0].(*handler.PaymentList)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // AllPayments indicates an expected call of AllPayments
-func (mr *MockAccountManagerMockRecorder) AllPayments(ctx interface{}) *gomock.Call {
+func (mr *MockAccountManagerMockRecorder) AllPayments(ctx, page interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllPayments", reflect.TypeOf((*MockAccountManager)(nil).AllPayments), ctx, page)
+}
+
+// AccountPayments mocks base method
+func (m *MockAccountManager) AccountPayments(ctx context.Context, uid string, filter handler.PaymentFilter, page handler.PageRequest) (*handler.PaymentList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AccountPayments", ctx, uid, filter, page)
+	ret0, _ := ret[0].(*handler.PaymentList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AccountPayments indicates an expected call of AccountPayments
+func (mr *MockAccountManagerMockRecorder) AccountPayments(ctx, uid, filter, page interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AccountPayments", reflect.TypeOf((*MockAccountManager)(nil).AccountPayments), ctx, uid, filter, page)
+}
+
+// AccountEvents mocks base method
+func (m *MockAccountManager) AccountEvents(ctx context.Context, uid, lastEventID string) (<-chan handler.AccountEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AccountEvents", ctx, uid, lastEventID)
+	ret0, _ := ret[0].(<-chan handler.AccountEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AccountEvents indicates an expected call of AccountEvents
+func (mr *MockAccountManagerMockRecorder) AccountEvents(ctx, uid, lastEventID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AccountEvents", reflect.TypeOf((*MockAccountManager)(nil).AccountEvents), ctx, uid, lastEventID)
+}
+
+// Reconcile mocks base method
+func (m *MockAccountManager) Reconcile(ctx context.Context, request handler.ReconciliationRequest) (*handler.Reconciliation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reconcile", ctx, request)
+	ret0, _ := ret[0].(*handler.Reconciliation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Reconcile indicates an expected call of Reconcile
+func (mr *MockAccountManagerMockRecorder) Reconcile(ctx, request interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllPayments", reflect.TypeOf((*MockAccountManager)(nil).AllPayments), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reconcile", reflect.TypeOf((*MockAccountManager)(nil).Reconcile), ctx, request)
 }
 
 // AccountBuilder mocks base method
@@ -251,3 +435,289 @@ func (mr *MockAccountManagerMockRecorder) PaymentBuilder() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PaymentBuilder", reflect.TypeOf((*MockAccountManager)(nil).PaymentBuilder))
 }
+
+// RefundBuilder mocks base method
+func (m *MockAccountManager) RefundBuilder() handler.RefundBuilder {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefundBuilder")
+	ret0, _ := ret[0].(handler.RefundBuilder)
+	return ret0
+}
+
+// RefundBuilder indicates an expected call of RefundBuilder
+func (mr *MockAccountManagerMockRecorder) RefundBuilder() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefundBuilder", reflect.TypeOf((*MockAccountManager)(nil).RefundBuilder))
+}
+
+// MockWebhookManager is a mock of WebhookManager interface
+type MockWebhookManager struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebhookManagerMockRecorder
+}
+
+// MockWebhookManagerMockRecorder is the mock recorder for MockWebhookManager
+type MockWebhookManagerMockRecorder struct {
+	mock *MockWebhookManager
+}
+
+// NewMockWebhookManager creates a new mock instance
+func NewMockWebhookManager(ctrl *gomock.Controller) *MockWebhookManager {
+	mock := &MockWebhookManager{ctrl: ctrl}
+	mock.recorder = &MockWebhookManagerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockWebhookManager) EXPECT() *MockWebhookManagerMockRecorder {
+	return m.recorder
+}
+
+// CreateWebhook mocks base method
+func (m *MockWebhookManager) CreateWebhook(ctx context.Context, request handler.WebhookRequest) (*handler.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhook", ctx, request)
+	ret0, _ := ret[0].(*handler.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhook indicates an expected call of CreateWebhook
+func (mr *MockWebhookManagerMockRecorder) CreateWebhook(ctx, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhook", reflect.TypeOf((*MockWebhookManager)(nil).CreateWebhook), ctx, request)
+}
+
+// Webhook mocks base method
+func (m *MockWebhookManager) Webhook(ctx context.Context, id int64) (*handler.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Webhook", ctx, id)
+	ret0, _ := ret[0].(*handler.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Webhook indicates an expected call of Webhook
+func (mr *MockWebhookManagerMockRecorder) Webhook(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Webhook", reflect.TypeOf((*MockWebhookManager)(nil).Webhook), ctx, id)
+}
+
+// AllWebhooks mocks base method
+func (m *MockWebhookManager) AllWebhooks(ctx context.Context, page handler.PageRequest) (*handler.WebhookList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AllWebhooks", ctx, page)
+	ret0, _ := ret[0].(*handler.WebhookList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AllWebhooks indicates an expected call of AllWebhooks
+func (mr *MockWebhookManagerMockRecorder) AllWebhooks(ctx, page interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllWebhooks", reflect.TypeOf((*MockWebhookManager)(nil).AllWebhooks), ctx, page)
+}
+
+// DeleteWebhook mocks base method
+func (m *MockWebhookManager) DeleteWebhook(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhook", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebhook indicates an expected call of DeleteWebhook
+func (mr *MockWebhookManagerMockRecorder) DeleteWebhook(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhook", reflect.TypeOf((*MockWebhookManager)(nil).DeleteWebhook), ctx, id)
+}
+
+// WebhookDeliveries mocks base method
+func (m *MockWebhookManager) WebhookDeliveries(ctx context.Context, id int64, page handler.PageRequest) (*handler.DeliveryList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WebhookDeliveries", ctx, id, page)
+	ret0, _ := ret[0].(*handler.DeliveryList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WebhookDeliveries indicates an expected call of WebhookDeliveries
+func (mr *MockWebhookManagerMockRecorder) WebhookDeliveries(ctx, id, page interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WebhookDeliveries", reflect.TypeOf((*MockWebhookManager)(nil).WebhookDeliveries), ctx, id, page)
+}
+
+// Delivery mocks base method
+func (m *MockWebhookManager) Delivery(ctx context.Context, webhookID, deliveryID int64) (*handler.Delivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delivery", ctx, webhookID, deliveryID)
+	ret0, _ := ret[0].(*handler.Delivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Delivery indicates an expected call of Delivery
+func (mr *MockWebhookManagerMockRecorder) Delivery(ctx, webhookID, deliveryID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delivery", reflect.TypeOf((*MockWebhookManager)(nil).Delivery), ctx, webhookID, deliveryID)
+}
+
+// Redeliver mocks base method
+func (m *MockWebhookManager) Redeliver(ctx context.Context, webhookID, deliveryID int64) (*handler.Delivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Redeliver", ctx, webhookID, deliveryID)
+	ret0, _ := ret[0].(*handler.Delivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Redeliver indicates an expected call of Redeliver
+func (mr *MockWebhookManagerMockRecorder) Redeliver(ctx, webhookID, deliveryID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Redeliver", reflect.TypeOf((*MockWebhookManager)(nil).Redeliver), ctx, webhookID, deliveryID)
+}
+
+// MockScheduleManager is a mock of ScheduleManager interface
+type MockScheduleManager struct {
+	ctrl     *gomock.Controller
+	recorder *MockScheduleManagerMockRecorder
+}
+
+// MockScheduleManagerMockRecorder is the mock recorder for MockScheduleManager
+type MockScheduleManagerMockRecorder struct {
+	mock *MockScheduleManager
+}
+
+// NewMockScheduleManager creates a new mock instance
+func NewMockScheduleManager(ctrl *gomock.Controller) *MockScheduleManager {
+	mock := &MockScheduleManager{ctrl: ctrl}
+	mock.recorder = &MockScheduleManagerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockScheduleManager) EXPECT() *MockScheduleManagerMockRecorder {
+	return m.recorder
+}
+
+// CreateSchedule mocks base method
+func (m *MockScheduleManager) CreateSchedule(ctx context.Context, uid string, request handler.ScheduleRequest) (*handler.Schedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSchedule", ctx, uid, request)
+	ret0, _ := ret[0].(*handler.Schedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSchedule indicates an expected call of CreateSchedule
+func (mr *MockScheduleManagerMockRecorder) CreateSchedule(ctx, uid, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSchedule", reflect.TypeOf((*MockScheduleManager)(nil).CreateSchedule), ctx, uid, request)
+}
+
+// Schedule mocks base method
+func (m *MockScheduleManager) Schedule(ctx context.Context, uid string, id int64) (*handler.Schedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Schedule", ctx, uid, id)
+	ret0, _ := ret[0].(*handler.Schedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Schedule indicates an expected call of Schedule
+func (mr *MockScheduleManagerMockRecorder) Schedule(ctx, uid, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Schedule", reflect.TypeOf((*MockScheduleManager)(nil).Schedule), ctx, uid, id)
+}
+
+// AccountSchedules mocks base method
+func (m *MockScheduleManager) AccountSchedules(ctx context.Context, uid string, page handler.PageRequest) (*handler.ScheduleList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AccountSchedules", ctx, uid, page)
+	ret0, _ := ret[0].(*handler.ScheduleList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AccountSchedules indicates an expected call of AccountSchedules
+func (mr *MockScheduleManagerMockRecorder) AccountSchedules(ctx, uid, page interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AccountSchedules", reflect.TypeOf((*MockScheduleManager)(nil).AccountSchedules), ctx, uid, page)
+}
+
+// CancelSchedule mocks base method
+func (m *MockScheduleManager) CancelSchedule(ctx context.Context, uid string, id int64) (*handler.Schedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelSchedule", ctx, uid, id)
+	ret0, _ := ret[0].(*handler.Schedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelSchedule indicates an expected call of CancelSchedule
+func (mr *MockScheduleManagerMockRecorder) CancelSchedule(ctx, uid, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelSchedule", reflect.TypeOf((*MockScheduleManager)(nil).CancelSchedule), ctx, uid, id)
+}
+
+// ScheduleRuns mocks base method
+func (m *MockScheduleManager) ScheduleRuns(ctx context.Context, uid string, id int64, page handler.PageRequest) (*handler.ScheduleRunList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScheduleRuns", ctx, uid, id, page)
+	ret0, _ := ret[0].(*handler.ScheduleRunList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ScheduleRuns indicates an expected call of ScheduleRuns
+func (mr *MockScheduleManagerMockRecorder) ScheduleRuns(ctx, uid, id, page interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScheduleRuns", reflect.TypeOf((*MockScheduleManager)(nil).ScheduleRuns), ctx, uid, id, page)
+}
+
+// MockScheduler is a mock of Scheduler interface
+type MockScheduler struct {
+	ctrl     *gomock.Controller
+	recorder *MockSchedulerMockRecorder
+}
+
+// MockSchedulerMockRecorder is the mock recorder for MockScheduler
+type MockSchedulerMockRecorder struct {
+	mock *MockScheduler
+}
+
+// NewMockScheduler creates a new mock instance
+func NewMockScheduler(ctrl *gomock.Controller) *MockScheduler {
+	mock := &MockScheduler{ctrl: ctrl}
+	mock.recorder = &MockSchedulerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockScheduler) EXPECT() *MockSchedulerMockRecorder {
+	return m.recorder
+}
+
+// Start mocks base method
+func (m *MockScheduler) Start() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Start")
+}
+
+// Start indicates an expected call of Start
+func (mr *MockSchedulerMockRecorder) Start() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Start", reflect.TypeOf((*MockScheduler)(nil).Start))
+}
+
+// Stop mocks base method
+func (m *MockScheduler) Stop() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Stop")
+}
+
+// Stop indicates an expected call of Stop
+func (mr *MockSchedulerMockRecorder) Stop() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stop", reflect.TypeOf((*MockScheduler)(nil).Stop))
+}
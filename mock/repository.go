@@ -9,6 +9,7 @@ import (
 	repository "github.com/Toshik1978/go-rest-api/repository"
 	gomock "github.com/golang/mock/gomock"
 	reflect "reflect"
+	time "time"
 )
 
 // MockAccountRepository is a mock of AccountRepository interface
@@ -35,18 +36,48 @@ func (m *MockAccountRepository) EXPECT() *MockAccountRepositoryMockRecorder {
 }
 
 // GetAll mocks base method
-func (m *MockAccountRepository) GetAll(ctx context.Context) ([]repository.Account, error) {
+func (m *MockAccountRepository) GetAll(ctx context.Context, page repository.Page) ([]repository.Account, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAll", ctx)
+	ret := m.ctrl.Call(m, "GetAll", ctx, page)
 	ret0, _ := ret[0].([]repository.Account)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetAll indicates an expected call of GetAll
-func (mr *MockAccountRepositoryMockRecorder) GetAll(ctx interface{}) *gomock.Call {
+func (mr *MockAccountRepositoryMockRecorder) GetAll(ctx, page interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockAccountRepository)(nil).GetAll), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockAccountRepository)(nil).GetAll), ctx, page)
+}
+
+// GetByUID mocks base method
+func (m *MockAccountRepository) GetByUID(ctx context.Context, uid string) (*repository.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByUID", ctx, uid)
+	ret0, _ := ret[0].(*repository.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByUID indicates an expected call of GetByUID
+func (mr *MockAccountRepositoryMockRecorder) GetByUID(ctx, uid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByUID", reflect.TypeOf((*MockAccountRepository)(nil).GetByUID), ctx, uid)
+}
+
+// LockByUIDs mocks base method
+func (m *MockAccountRepository) LockByUIDs(ctx context.Context, uids []string) ([]repository.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockByUIDs", ctx, uids)
+	ret0, _ := ret[0].([]repository.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LockByUIDs indicates an expected call of LockByUIDs
+func (mr *MockAccountRepositoryMockRecorder) LockByUIDs(ctx, uids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockByUIDs", reflect.TypeOf((*MockAccountRepository)(nil).LockByUIDs), ctx, uids)
 }
 
 // Store mocks base method
@@ -77,56 +108,696 @@ func (mr *MockAccountRepositoryMockRecorder) UpdateBalance(ctx, uid, incr interf
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBalance", reflect.TypeOf((*MockAccountRepository)(nil).UpdateBalance), ctx, uid, incr)
 }
 
-// MockPaymentRepository is a mock of PaymentRepository interface
-type MockPaymentRepository struct {
+// UpdateStatus mocks base method
+func (m *MockAccountRepository) UpdateStatus(ctx context.Context, transition *repository.AccountTransition) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStatus", ctx, transition)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateStatus indicates an expected call of UpdateStatus
+func (mr *MockAccountRepositoryMockRecorder) UpdateStatus(ctx, transition interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStatus", reflect.TypeOf((*MockAccountRepository)(nil).UpdateStatus), ctx, transition)
+}
+
+// MockLedgerRepository is a mock of LedgerRepository interface
+type MockLedgerRepository struct {
 	ctrl     *gomock.Controller
-	recorder *MockPaymentRepositoryMockRecorder
+	recorder *MockLedgerRepositoryMockRecorder
 }
 
-// MockPaymentRepositoryMockRecorder is the mock recorder for MockPaymentRepository
-type MockPaymentRepositoryMockRecorder struct {
-	mock *MockPaymentRepository
+// MockLedgerRepositoryMockRecorder is the mock recorder for MockLedgerRepository
+type MockLedgerRepositoryMockRecorder struct {
+	mock *MockLedgerRepository
 }
 
-// NewMockPaymentRepository creates a new mock instance
-func NewMockPaymentRepository(ctrl *gomock.Controller) *MockPaymentRepository {
-	mock := &MockPaymentRepository{ctrl: ctrl}
-	mock.recorder = &MockPaymentRepositoryMockRecorder{mock}
+// NewMockLedgerRepository creates a new mock instance
+func NewMockLedgerRepository(ctrl *gomock.Controller) *MockLedgerRepository {
+	mock := &MockLedgerRepository{ctrl: ctrl}
+	mock.recorder = &MockLedgerRepositoryMockRecorder{mock}
 	return mock
 }
 
 // EXPECT returns an object that allows the caller to indicate expected use
-func (m *MockPaymentRepository) EXPECT() *MockPaymentRepositoryMockRecorder {
+func (m *MockLedgerRepository) EXPECT() *MockLedgerRepositoryMockRecorder {
 	return m.recorder
 }
 
 // GetAll mocks base method
-func (m *MockPaymentRepository) GetAll(ctx context.Context) ([]repository.Payment, error) {
+func (m *MockLedgerRepository) GetAll(ctx context.Context, page repository.Page) ([]repository.Entry, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAll", ctx)
-	ret0, _ := ret[0].([]repository.Payment)
+	ret := m.ctrl.Call(m, "GetAll", ctx, page)
+	ret0, _ := ret[0].([]repository.Entry)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetAll indicates an expected call of GetAll
-func (mr *MockPaymentRepositoryMockRecorder) GetAll(ctx interface{}) *gomock.Call {
+func (mr *MockLedgerRepositoryMockRecorder) GetAll(ctx, page interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockLedgerRepository)(nil).GetAll), ctx, page)
+}
+
+// GetByAccount mocks base method
+func (m *MockLedgerRepository) GetByAccount(ctx context.Context, filter repository.PaymentFilter, page repository.Page) ([]repository.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByAccount", ctx, filter, page)
+	ret0, _ := ret[0].([]repository.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByAccount indicates an expected call of GetByAccount
+func (mr *MockLedgerRepositoryMockRecorder) GetByAccount(ctx, filter, page interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByAccount", reflect.TypeOf((*MockLedgerRepository)(nil).GetByAccount), ctx, filter, page)
+}
+
+// GetByID mocks base method
+func (m *MockLedgerRepository) GetByID(ctx context.Context, id int64) (*repository.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(*repository.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID
+func (mr *MockLedgerRepositoryMockRecorder) GetByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockLedgerRepository)(nil).GetByID), ctx, id)
+}
+
+// Store mocks base method
+func (m *MockLedgerRepository) Store(ctx context.Context, transfer *repository.Transfer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Store", ctx, transfer)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Store indicates an expected call of Store
+func (mr *MockLedgerRepositoryMockRecorder) Store(ctx, transfer interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Store", reflect.TypeOf((*MockLedgerRepository)(nil).Store), ctx, transfer)
+}
+
+// MockReconciliationRepository is a mock of ReconciliationRepository interface
+type MockReconciliationRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockReconciliationRepositoryMockRecorder
+}
+
+// MockReconciliationRepositoryMockRecorder is the mock recorder for MockReconciliationRepository
+type MockReconciliationRepositoryMockRecorder struct {
+	mock *MockReconciliationRepository
+}
+
+// NewMockReconciliationRepository creates a new mock instance
+func NewMockReconciliationRepository(ctrl *gomock.Controller) *MockReconciliationRepository {
+	mock := &MockReconciliationRepository{ctrl: ctrl}
+	mock.recorder = &MockReconciliationRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockReconciliationRepository) EXPECT() *MockReconciliationRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetDrifts mocks base method
+func (m *MockReconciliationRepository) GetDrifts(ctx context.Context) ([]repository.Drift, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDrifts", ctx)
+	ret0, _ := ret[0].([]repository.Drift)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDrifts indicates an expected call of GetDrifts
+func (mr *MockReconciliationRepositoryMockRecorder) GetDrifts(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDrifts", reflect.TypeOf((*MockReconciliationRepository)(nil).GetDrifts), ctx)
+}
+
+// StoreAdjustment mocks base method
+func (m *MockReconciliationRepository) StoreAdjustment(ctx context.Context, adjustment *repository.Adjustment) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreAdjustment", ctx, adjustment)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StoreAdjustment indicates an expected call of StoreAdjustment
+func (mr *MockReconciliationRepositoryMockRecorder) StoreAdjustment(ctx, adjustment interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreAdjustment", reflect.TypeOf((*MockReconciliationRepository)(nil).StoreAdjustment), ctx, adjustment)
+}
+
+// MockIdempotencyRepository is a mock of IdempotencyRepository interface
+type MockIdempotencyRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockIdempotencyRepositoryMockRecorder
+}
+
+// MockIdempotencyRepositoryMockRecorder is the mock recorder for MockIdempotencyRepository
+type MockIdempotencyRepositoryMockRecorder struct {
+	mock *MockIdempotencyRepository
+}
+
+// NewMockIdempotencyRepository creates a new mock instance
+func NewMockIdempotencyRepository(ctrl *gomock.Controller) *MockIdempotencyRepository {
+	mock := &MockIdempotencyRepository{ctrl: ctrl}
+	mock.recorder = &MockIdempotencyRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockIdempotencyRepository) EXPECT() *MockIdempotencyRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Get mocks base method
+func (m *MockIdempotencyRepository) Get(ctx context.Context, operation, key string, now time.Time) (*repository.IdempotencyKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, operation, key, now)
+	ret0, _ := ret[0].(*repository.IdempotencyKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get
+func (mr *MockIdempotencyRepositoryMockRecorder) Get(ctx, operation, key, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockIdempotencyRepository)(nil).Get), ctx, operation, key, now)
+}
+
+// Store mocks base method
+func (m *MockIdempotencyRepository) Store(ctx context.Context, key *repository.IdempotencyKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Store", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Store indicates an expected call of Store
+func (mr *MockIdempotencyRepositoryMockRecorder) Store(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Store", reflect.TypeOf((*MockIdempotencyRepository)(nil).Store), ctx, key)
+}
+
+// MockOutboxRepository is a mock of OutboxRepository interface
+type MockOutboxRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockOutboxRepositoryMockRecorder
+}
+
+// MockOutboxRepositoryMockRecorder is the mock recorder for MockOutboxRepository
+type MockOutboxRepositoryMockRecorder struct {
+	mock *MockOutboxRepository
+}
+
+// NewMockOutboxRepository creates a new mock instance
+func NewMockOutboxRepository(ctrl *gomock.Controller) *MockOutboxRepository {
+	mock := &MockOutboxRepository{ctrl: ctrl}
+	mock.recorder = &MockOutboxRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockOutboxRepository) EXPECT() *MockOutboxRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Store mocks base method
+func (m *MockOutboxRepository) Store(ctx context.Context, event *repository.Event) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Store", ctx, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Store indicates an expected call of Store
+func (mr *MockOutboxRepositoryMockRecorder) Store(ctx, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Store", reflect.TypeOf((*MockOutboxRepository)(nil).Store), ctx, event)
+}
+
+// GetPending mocks base method
+func (m *MockOutboxRepository) GetPending(ctx context.Context, now time.Time, limit int) ([]repository.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPending", ctx, now, limit)
+	ret0, _ := ret[0].([]repository.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPending indicates an expected call of GetPending
+func (mr *MockOutboxRepositoryMockRecorder) GetPending(ctx, now, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPending", reflect.TypeOf((*MockOutboxRepository)(nil).GetPending), ctx, now, limit)
+}
+
+// MarkDispatched mocks base method
+func (m *MockOutboxRepository) MarkDispatched(ctx context.Context, id int64, dispatchedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkDispatched", ctx, id, dispatchedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkDispatched indicates an expected call of MarkDispatched
+func (mr *MockOutboxRepositoryMockRecorder) MarkDispatched(ctx, id, dispatchedAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkDispatched", reflect.TypeOf((*MockOutboxRepository)(nil).MarkDispatched), ctx, id, dispatchedAt)
+}
+
+// MarkFailed mocks base method
+func (m *MockOutboxRepository) MarkFailed(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkFailed", ctx, id, lastError, nextAttemptAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkFailed indicates an expected call of MarkFailed
+func (mr *MockOutboxRepositoryMockRecorder) MarkFailed(ctx, id, lastError, nextAttemptAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkFailed", reflect.TypeOf((*MockOutboxRepository)(nil).MarkFailed), ctx, id, lastError, nextAttemptAt)
+}
+
+// MockWebhookRepository is a mock of WebhookRepository interface
+type MockWebhookRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebhookRepositoryMockRecorder
+}
+
+// MockWebhookRepositoryMockRecorder is the mock recorder for MockWebhookRepository
+type MockWebhookRepositoryMockRecorder struct {
+	mock *MockWebhookRepository
+}
+
+// NewMockWebhookRepository creates a new mock instance
+func NewMockWebhookRepository(ctrl *gomock.Controller) *MockWebhookRepository {
+	mock := &MockWebhookRepository{ctrl: ctrl}
+	mock.recorder = &MockWebhookRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockWebhookRepository) EXPECT() *MockWebhookRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetAll mocks base method
+func (m *MockWebhookRepository) GetAll(ctx context.Context, page repository.Page) ([]repository.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAll", ctx, page)
+	ret0, _ := ret[0].([]repository.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAll indicates an expected call of GetAll
+func (mr *MockWebhookRepositoryMockRecorder) GetAll(ctx, page interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockWebhookRepository)(nil).GetAll), ctx, page)
+}
+
+// GetByID mocks base method
+func (m *MockWebhookRepository) GetByID(ctx context.Context, id int64) (*repository.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(*repository.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID
+func (mr *MockWebhookRepositoryMockRecorder) GetByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockWebhookRepository)(nil).GetByID), ctx, id)
+}
+
+// GetMatching mocks base method
+func (m *MockWebhookRepository) GetMatching(ctx context.Context, eventType repository.EventType, accountUIDs []string) ([]repository.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMatching", ctx, eventType, accountUIDs)
+	ret0, _ := ret[0].([]repository.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMatching indicates an expected call of GetMatching
+func (mr *MockWebhookRepositoryMockRecorder) GetMatching(ctx, eventType, accountUIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMatching", reflect.TypeOf((*MockWebhookRepository)(nil).GetMatching), ctx, eventType, accountUIDs)
+}
+
+// Store mocks base method
+func (m *MockWebhookRepository) Store(ctx context.Context, webhook *repository.Webhook) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Store", ctx, webhook)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Store indicates an expected call of Store
+func (mr *MockWebhookRepositoryMockRecorder) Store(ctx, webhook interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Store", reflect.TypeOf((*MockWebhookRepository)(nil).Store), ctx, webhook)
+}
+
+// Delete mocks base method
+func (m *MockWebhookRepository) Delete(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete
+func (mr *MockWebhookRepositoryMockRecorder) Delete(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockWebhookRepository)(nil).Delete), ctx, id)
+}
+
+// MockDeliveryRepository is a mock of DeliveryRepository interface
+type MockDeliveryRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockDeliveryRepositoryMockRecorder
+}
+
+// MockDeliveryRepositoryMockRecorder is the mock recorder for MockDeliveryRepository
+type MockDeliveryRepositoryMockRecorder struct {
+	mock *MockDeliveryRepository
+}
+
+// NewMockDeliveryRepository creates a new mock instance
+func NewMockDeliveryRepository(ctrl *gomock.Controller) *MockDeliveryRepository {
+	mock := &MockDeliveryRepository{ctrl: ctrl}
+	mock.recorder = &MockDeliveryRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockDeliveryRepository) EXPECT() *MockDeliveryRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetByWebhook mocks base method
+func (m *MockDeliveryRepository) GetByWebhook(ctx context.Context, webhookID int64, page repository.Page) ([]repository.Delivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByWebhook", ctx, webhookID, page)
+	ret0, _ := ret[0].([]repository.Delivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByWebhook indicates an expected call of GetByWebhook
+func (mr *MockDeliveryRepositoryMockRecorder) GetByWebhook(ctx, webhookID, page interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByWebhook", reflect.TypeOf((*MockDeliveryRepository)(nil).GetByWebhook), ctx, webhookID, page)
+}
+
+// GetByID mocks base method
+func (m *MockDeliveryRepository) GetByID(ctx context.Context, id int64) (*repository.Delivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(*repository.Delivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID
+func (mr *MockDeliveryRepositoryMockRecorder) GetByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockDeliveryRepository)(nil).GetByID), ctx, id)
+}
+
+// GetPending mocks base method
+func (m *MockDeliveryRepository) GetPending(ctx context.Context, now time.Time, limit int) ([]repository.Delivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPending", ctx, now, limit)
+	ret0, _ := ret[0].([]repository.Delivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPending indicates an expected call of GetPending
+func (mr *MockDeliveryRepositoryMockRecorder) GetPending(ctx, now, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPending", reflect.TypeOf((*MockDeliveryRepository)(nil).GetPending), ctx, now, limit)
+}
+
+// GetAttempts mocks base method
+func (m *MockDeliveryRepository) GetAttempts(ctx context.Context, deliveryID int64) ([]repository.DeliveryAttempt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAttempts", ctx, deliveryID)
+	ret0, _ := ret[0].([]repository.DeliveryAttempt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAttempts indicates an expected call of GetAttempts
+func (mr *MockDeliveryRepositoryMockRecorder) GetAttempts(ctx, deliveryID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAttempts", reflect.TypeOf((*MockDeliveryRepository)(nil).GetAttempts), ctx, deliveryID)
+}
+
+// Store mocks base method
+func (m *MockDeliveryRepository) Store(ctx context.Context, delivery *repository.Delivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Store", ctx, delivery)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Store indicates an expected call of Store
+func (mr *MockDeliveryRepositoryMockRecorder) Store(ctx, delivery interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Store", reflect.TypeOf((*MockDeliveryRepository)(nil).Store), ctx, delivery)
+}
+
+// Update mocks base method
+func (m *MockDeliveryRepository) Update(ctx context.Context, delivery *repository.Delivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, delivery)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update
+func (mr *MockDeliveryRepositoryMockRecorder) Update(ctx, delivery interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockDeliveryRepository)(nil).Update), ctx, delivery)
+}
+
+// StoreAttempt mocks base method
+func (m *MockDeliveryRepository) StoreAttempt(ctx context.Context, attempt *repository.DeliveryAttempt) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreAttempt", ctx, attempt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StoreAttempt indicates an expected call of StoreAttempt
+func (mr *MockDeliveryRepositoryMockRecorder) StoreAttempt(ctx, attempt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreAttempt", reflect.TypeOf((*MockDeliveryRepository)(nil).StoreAttempt), ctx, attempt)
+}
+
+// MockScheduleRepository is a mock of ScheduleRepository interface
+type MockScheduleRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockScheduleRepositoryMockRecorder
+}
+
+// MockScheduleRepositoryMockRecorder is the mock recorder for MockScheduleRepository
+type MockScheduleRepositoryMockRecorder struct {
+	mock *MockScheduleRepository
+}
+
+// NewMockScheduleRepository creates a new mock instance
+func NewMockScheduleRepository(ctrl *gomock.Controller) *MockScheduleRepository {
+	mock := &MockScheduleRepository{ctrl: ctrl}
+	mock.recorder = &MockScheduleRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockScheduleRepository) EXPECT() *MockScheduleRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetByAccount mocks base method
+func (m *MockScheduleRepository) GetByAccount(ctx context.Context, accountUID string, page repository.Page) ([]repository.Schedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByAccount", ctx, accountUID, page)
+	ret0, _ := ret[0].([]repository.Schedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByAccount indicates an expected call of GetByAccount
+func (mr *MockScheduleRepositoryMockRecorder) GetByAccount(ctx, accountUID, page interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockPaymentRepository)(nil).GetAll), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByAccount", reflect.TypeOf((*MockScheduleRepository)(nil).GetByAccount), ctx, accountUID, page)
+}
+
+// GetByID mocks base method
+func (m *MockScheduleRepository) GetByID(ctx context.Context, id int64) (*repository.Schedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(*repository.Schedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID
+func (mr *MockScheduleRepositoryMockRecorder) GetByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockScheduleRepository)(nil).GetByID), ctx, id)
+}
+
+// GetDue mocks base method
+func (m *MockScheduleRepository) GetDue(ctx context.Context, now time.Time, limit int) ([]repository.Schedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDue", ctx, now, limit)
+	ret0, _ := ret[0].([]repository.Schedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDue indicates an expected call of GetDue
+func (mr *MockScheduleRepositoryMockRecorder) GetDue(ctx, now, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDue", reflect.TypeOf((*MockScheduleRepository)(nil).GetDue), ctx, now, limit)
 }
 
 // Store mocks base method
-func (m *MockPaymentRepository) Store(ctx context.Context, payment *repository.Payment) error {
+func (m *MockScheduleRepository) Store(ctx context.Context, schedule *repository.Schedule) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Store", ctx, payment)
+	ret := m.ctrl.Call(m, "Store", ctx, schedule)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Store indicates an expected call of Store
-func (mr *MockPaymentRepositoryMockRecorder) Store(ctx, payment interface{}) *gomock.Call {
+func (mr *MockScheduleRepositoryMockRecorder) Store(ctx, schedule interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Store", reflect.TypeOf((*MockPaymentRepository)(nil).Store), ctx, payment)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Store", reflect.TypeOf((*MockScheduleRepository)(nil).Store), ctx, schedule)
+}
+
+// Advance mocks base method
+func (m *MockScheduleRepository) Advance(ctx context.Context, schedule *repository.Schedule, from time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Advance", ctx, schedule, from)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Advance indicates an expected call of Advance
+func (mr *MockScheduleRepositoryMockRecorder) Advance(ctx, schedule, from interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Advance", reflect.TypeOf((*MockScheduleRepository)(nil).Advance), ctx, schedule, from)
+}
+
+// Cancel mocks base method
+func (m *MockScheduleRepository) Cancel(ctx context.Context, id int64, cancelledAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Cancel", ctx, id, cancelledAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Cancel indicates an expected call of Cancel
+func (mr *MockScheduleRepositoryMockRecorder) Cancel(ctx, id, cancelledAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Cancel", reflect.TypeOf((*MockScheduleRepository)(nil).Cancel), ctx, id, cancelledAt)
+}
+
+// MockScheduleRunRepository is a mock of ScheduleRunRepository interface
+type MockScheduleRunRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockScheduleRunRepositoryMockRecorder
+}
+
+// MockScheduleRunRepositoryMockRecorder is the mock recorder for MockScheduleRunRepository
+type MockScheduleRunRepositoryMockRecorder struct {
+	mock *MockScheduleRunRepository
+}
+
+// NewMockScheduleRunRepository creates a new mock instance
+func NewMockScheduleRunRepository(ctrl *gomock.Controller) *MockScheduleRunRepository {
+	mock := &MockScheduleRunRepository{ctrl: ctrl}
+	mock.recorder = &MockScheduleRunRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockScheduleRunRepository) EXPECT() *MockScheduleRunRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetBySchedule mocks base method
+func (m *MockScheduleRunRepository) GetBySchedule(ctx context.Context, scheduleID int64, page repository.Page) ([]repository.ScheduleRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBySchedule", ctx, scheduleID, page)
+	ret0, _ := ret[0].([]repository.ScheduleRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBySchedule indicates an expected call of GetBySchedule
+func (mr *MockScheduleRunRepositoryMockRecorder) GetBySchedule(ctx, scheduleID, page interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBySchedule", reflect.TypeOf((*MockScheduleRunRepository)(nil).GetBySchedule), ctx, scheduleID, page)
+}
+
+// GetPending mocks base method
+func (m *MockScheduleRunRepository) GetPending(ctx context.Context, now time.Time, limit int) ([]repository.ScheduleRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPending", ctx, now, limit)
+	ret0, _ := ret[0].([]repository.ScheduleRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPending indicates an expected call of GetPending
+func (mr *MockScheduleRunRepositoryMockRecorder) GetPending(ctx, now, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPending", reflect.TypeOf((*MockScheduleRunRepository)(nil).GetPending), ctx, now, limit)
+}
+
+// Store mocks base method
+func (m *MockScheduleRunRepository) Store(ctx context.Context, run *repository.ScheduleRun) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Store", ctx, run)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Store indicates an expected call of Store
+func (mr *MockScheduleRunRepositoryMockRecorder) Store(ctx, run interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Store", reflect.TypeOf((*MockScheduleRunRepository)(nil).Store), ctx, run)
+}
+
+// Update mocks base method
+func (m *MockScheduleRunRepository) Update(ctx context.Context, run *repository.ScheduleRun) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, run)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update
+func (mr *MockScheduleRunRepositoryMockRecorder) Update(ctx, run interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockScheduleRunRepository)(nil).Update), ctx, run)
 }
 
 // MockScope is a mock of Scope interface
@@ -219,17 +890,35 @@ func (m *MockFactory) EXPECT() *MockFactoryMockRecorder {
 }
 
 // Scope mocks base method
-func (m *MockFactory) Scope() repository.Scope {
+func (m *MockFactory) Scope(options ...repository.ScopeOption) repository.Scope {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Scope")
+	varargs := []interface{}{}
+	for _, a := range options {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Scope", varargs...)
 	ret0, _ := ret[0].(repository.Scope)
 	return ret0
 }
 
 // Scope indicates an expected call of Scope
-func (mr *MockFactoryMockRecorder) Scope() *gomock.Call {
+func (mr *MockFactoryMockRecorder) Scope(options ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scope", reflect.TypeOf((*MockFactory)(nil).Scope))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scope", reflect.TypeOf((*MockFactory)(nil).Scope), options...)
+}
+
+// Retry mocks base method
+func (m *MockFactory) Retry(ctx context.Context, work func(context.Context) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Retry", ctx, work)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Retry indicates an expected call of Retry
+func (mr *MockFactoryMockRecorder) Retry(ctx, work interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Retry", reflect.TypeOf((*MockFactory)(nil).Retry), ctx, work)
 }
 
 // AccountRepository mocks base method
@@ -246,16 +935,114 @@ func (mr *MockFactoryMockRecorder) AccountRepository() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AccountRepository", reflect.TypeOf((*MockFactory)(nil).AccountRepository))
 }
 
-// PaymentRepository mocks base method
-func (m *MockFactory) PaymentRepository() repository.PaymentRepository {
+// LedgerRepository mocks base method
+func (m *MockFactory) LedgerRepository() repository.LedgerRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LedgerRepository")
+	ret0, _ := ret[0].(repository.LedgerRepository)
+	return ret0
+}
+
+// LedgerRepository indicates an expected call of LedgerRepository
+func (mr *MockFactoryMockRecorder) LedgerRepository() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LedgerRepository", reflect.TypeOf((*MockFactory)(nil).LedgerRepository))
+}
+
+// ReconciliationRepository mocks base method
+func (m *MockFactory) ReconciliationRepository() repository.ReconciliationRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReconciliationRepository")
+	ret0, _ := ret[0].(repository.ReconciliationRepository)
+	return ret0
+}
+
+// ReconciliationRepository indicates an expected call of ReconciliationRepository
+func (mr *MockFactoryMockRecorder) ReconciliationRepository() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconciliationRepository", reflect.TypeOf((*MockFactory)(nil).ReconciliationRepository))
+}
+
+// IdempotencyRepository mocks base method
+func (m *MockFactory) IdempotencyRepository() repository.IdempotencyRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IdempotencyRepository")
+	ret0, _ := ret[0].(repository.IdempotencyRepository)
+	return ret0
+}
+
+// IdempotencyRepository indicates an expected call of IdempotencyRepository
+func (mr *MockFactoryMockRecorder) IdempotencyRepository() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IdempotencyRepository", reflect.TypeOf((*MockFactory)(nil).IdempotencyRepository))
+}
+
+// OutboxRepository mocks base method
+func (m *MockFactory) OutboxRepository() repository.OutboxRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OutboxRepository")
+	ret0, _ := ret[0].(repository.OutboxRepository)
+	return ret0
+}
+
+// OutboxRepository indicates an expected call of OutboxRepository
+func (mr *MockFactoryMockRecorder) OutboxRepository() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OutboxRepository", reflect.TypeOf((*MockFactory)(nil).OutboxRepository))
+}
+
+// WebhookRepository mocks base method
+func (m *MockFactory) WebhookRepository() repository.WebhookRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WebhookRepository")
+	ret0, _ := ret[0].(repository.WebhookRepository)
+	return ret0
+}
+
+// WebhookRepository indicates an expected call of WebhookRepository
+func (mr *MockFactoryMockRecorder) WebhookRepository() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WebhookRepository", reflect.TypeOf((*MockFactory)(nil).WebhookRepository))
+}
+
+// DeliveryRepository mocks base method
+func (m *MockFactory) DeliveryRepository() repository.DeliveryRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeliveryRepository")
+	ret0, _ := ret[0].(repository.DeliveryRepository)
+	return ret0
+}
+
+// DeliveryRepository indicates an expected call of DeliveryRepository
+func (mr *MockFactoryMockRecorder) DeliveryRepository() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeliveryRepository", reflect.TypeOf((*MockFactory)(nil).DeliveryRepository))
+}
+
+// ScheduleRepository mocks base method
+func (m *MockFactory) ScheduleRepository() repository.ScheduleRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScheduleRepository")
+	ret0, _ := ret[0].(repository.ScheduleRepository)
+	return ret0
+}
+
+// ScheduleRepository indicates an expected call of ScheduleRepository
+func (mr *MockFactoryMockRecorder) ScheduleRepository() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScheduleRepository", reflect.TypeOf((*MockFactory)(nil).ScheduleRepository))
+}
+
+// ScheduleRunRepository mocks base method
+func (m *MockFactory) ScheduleRunRepository() repository.ScheduleRunRepository {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "PaymentRepository")
-	ret0, _ := ret[0].(repository.PaymentRepository)
+	ret := m.ctrl.Call(m, "ScheduleRunRepository")
+	ret0, _ := ret[0].(repository.ScheduleRunRepository)
 	return ret0
 }
 
-// PaymentRepository indicates an expected call of PaymentRepository
-func (mr *MockFactoryMockRecorder) PaymentRepository() *gomock.Call {
+// ScheduleRunRepository indicates an expected call of ScheduleRunRepository
+func (mr *MockFactoryMockRecorder) ScheduleRunRepository() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PaymentRepository", reflect.TypeOf((*MockFactory)(nil).PaymentRepository))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScheduleRunRepository", reflect.TypeOf((*MockFactory)(nil).ScheduleRunRepository))
 }
@@ -5,11 +5,63 @@
 package mock
 
 import (
+	context "context"
+	service "github.com/Toshik1978/go-rest-api/service"
 	gomock "github.com/golang/mock/gomock"
 	sqlx "github.com/jmoiron/sqlx"
+	big "math/big"
 	reflect "reflect"
 )
 
+// MockDBClient is a mock of DBClient interface
+type MockDBClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockDBClientMockRecorder
+}
+
+// MockDBClientMockRecorder is the mock recorder for MockDBClient
+type MockDBClientMockRecorder struct {
+	mock *MockDBClient
+}
+
+// NewMockDBClient creates a new mock instance
+func NewMockDBClient(ctrl *gomock.Controller) *MockDBClient {
+	mock := &MockDBClient{ctrl: ctrl}
+	mock.recorder = &MockDBClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockDBClient) EXPECT() *MockDBClientMockRecorder {
+	return m.recorder
+}
+
+// GetConnection mocks base method
+func (m *MockDBClient) GetConnection() *sqlx.DB {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetConnection")
+	ret0, _ := ret[0].(*sqlx.DB)
+	return ret0
+}
+
+// GetConnection indicates an expected call of GetConnection
+func (mr *MockDBClientMockRecorder) GetConnection() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConnection", reflect.TypeOf((*MockDBClient)(nil).GetConnection))
+}
+
+// Stop mocks base method
+func (m *MockDBClient) Stop() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Stop")
+}
+
+// Stop indicates an expected call of Stop
+func (mr *MockDBClientMockRecorder) Stop() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stop", reflect.TypeOf((*MockDBClient)(nil).Stop))
+}
+
 // MockPostgresClient is a mock of PostgresClient interface
 type MockPostgresClient struct {
 	ctrl     *gomock.Controller
@@ -58,3 +110,370 @@ func (mr *MockPostgresClientMockRecorder) Stop() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stop", reflect.TypeOf((*MockPostgresClient)(nil).Stop))
 }
+
+// MockMigrator is a mock of Migrator interface
+type MockMigrator struct {
+	ctrl     *gomock.Controller
+	recorder *MockMigratorMockRecorder
+}
+
+// MockMigratorMockRecorder is the mock recorder for MockMigrator
+type MockMigratorMockRecorder struct {
+	mock *MockMigrator
+}
+
+// NewMockMigrator creates a new mock instance
+func NewMockMigrator(ctrl *gomock.Controller) *MockMigrator {
+	mock := &MockMigrator{ctrl: ctrl}
+	mock.recorder = &MockMigratorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockMigrator) EXPECT() *MockMigratorMockRecorder {
+	return m.recorder
+}
+
+// Up mocks base method
+func (m *MockMigrator) Up(ctx context.Context, n int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Up", ctx, n)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Up indicates an expected call of Up
+func (mr *MockMigratorMockRecorder) Up(ctx, n interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Up", reflect.TypeOf((*MockMigrator)(nil).Up), ctx, n)
+}
+
+// Down mocks base method
+func (m *MockMigrator) Down(ctx context.Context, n int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Down", ctx, n)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Down indicates an expected call of Down
+func (mr *MockMigratorMockRecorder) Down(ctx, n interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Down", reflect.TypeOf((*MockMigrator)(nil).Down), ctx, n)
+}
+
+// Version mocks base method
+func (m *MockMigrator) Version(ctx context.Context) (service.SchemaVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Version", ctx)
+	ret0, _ := ret[0].(service.SchemaVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Version indicates an expected call of Version
+func (mr *MockMigratorMockRecorder) Version(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Version", reflect.TypeOf((*MockMigrator)(nil).Version), ctx)
+}
+
+// Status mocks base method
+func (m *MockMigrator) Status(ctx context.Context) ([]service.Migration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Status", ctx)
+	ret0, _ := ret[0].([]service.Migration)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Status indicates an expected call of Status
+func (mr *MockMigratorMockRecorder) Status(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Status", reflect.TypeOf((*MockMigrator)(nil).Status), ctx)
+}
+
+// Latest mocks base method
+func (m *MockMigrator) Latest() uint {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Latest")
+	ret0, _ := ret[0].(uint)
+	return ret0
+}
+
+// Latest indicates an expected call of Latest
+func (mr *MockMigratorMockRecorder) Latest() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Latest", reflect.TypeOf((*MockMigrator)(nil).Latest))
+}
+
+// MockCurrencyRegistry is a mock of CurrencyRegistry interface
+type MockCurrencyRegistry struct {
+	ctrl     *gomock.Controller
+	recorder *MockCurrencyRegistryMockRecorder
+}
+
+// MockCurrencyRegistryMockRecorder is the mock recorder for MockCurrencyRegistry
+type MockCurrencyRegistryMockRecorder struct {
+	mock *MockCurrencyRegistry
+}
+
+// NewMockCurrencyRegistry creates a new mock instance
+func NewMockCurrencyRegistry(ctrl *gomock.Controller) *MockCurrencyRegistry {
+	mock := &MockCurrencyRegistry{ctrl: ctrl}
+	mock.recorder = &MockCurrencyRegistryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockCurrencyRegistry) EXPECT() *MockCurrencyRegistryMockRecorder {
+	return m.recorder
+}
+
+// Currency mocks base method
+func (m *MockCurrencyRegistry) Currency(code string) (service.Currency, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Currency", code)
+	ret0, _ := ret[0].(service.Currency)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// Currency indicates an expected call of Currency
+func (mr *MockCurrencyRegistryMockRecorder) Currency(code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Currency", reflect.TypeOf((*MockCurrencyRegistry)(nil).Currency), code)
+}
+
+// Currencies mocks base method
+func (m *MockCurrencyRegistry) Currencies() []service.Currency {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Currencies")
+	ret0, _ := ret[0].([]service.Currency)
+	return ret0
+}
+
+// Currencies indicates an expected call of Currencies
+func (mr *MockCurrencyRegistryMockRecorder) Currencies() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Currencies", reflect.TypeOf((*MockCurrencyRegistry)(nil).Currencies))
+}
+
+// MockFXRateProvider is a mock of FXRateProvider interface
+type MockFXRateProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockFXRateProviderMockRecorder
+}
+
+// MockFXRateProviderMockRecorder is the mock recorder for MockFXRateProvider
+type MockFXRateProviderMockRecorder struct {
+	mock *MockFXRateProvider
+}
+
+// NewMockFXRateProvider creates a new mock instance
+func NewMockFXRateProvider(ctrl *gomock.Controller) *MockFXRateProvider {
+	mock := &MockFXRateProvider{ctrl: ctrl}
+	mock.recorder = &MockFXRateProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockFXRateProvider) EXPECT() *MockFXRateProviderMockRecorder {
+	return m.recorder
+}
+
+// Rate mocks base method
+func (m *MockFXRateProvider) Rate(ctx context.Context, from, to string) (*big.Rat, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rate", ctx, from, to)
+	ret0, _ := ret[0].(*big.Rat)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Rate indicates an expected call of Rate
+func (mr *MockFXRateProviderMockRecorder) Rate(ctx, from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rate", reflect.TypeOf((*MockFXRateProvider)(nil).Rate), ctx, from, to)
+}
+
+// MockEventSink is a mock of EventSink interface
+type MockEventSink struct {
+	ctrl     *gomock.Controller
+	recorder *MockEventSinkMockRecorder
+}
+
+// MockEventSinkMockRecorder is the mock recorder for MockEventSink
+type MockEventSinkMockRecorder struct {
+	mock *MockEventSink
+}
+
+// NewMockEventSink creates a new mock instance
+func NewMockEventSink(ctrl *gomock.Controller) *MockEventSink {
+	mock := &MockEventSink{ctrl: ctrl}
+	mock.recorder = &MockEventSinkMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockEventSink) EXPECT() *MockEventSinkMockRecorder {
+	return m.recorder
+}
+
+// Deliver mocks base method
+func (m *MockEventSink) Deliver(ctx context.Context, event service.Event) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Deliver", ctx, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Deliver indicates an expected call of Deliver
+func (mr *MockEventSinkMockRecorder) Deliver(ctx, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Deliver", reflect.TypeOf((*MockEventSink)(nil).Deliver), ctx, event)
+}
+
+// MockEventDispatcher is a mock of EventDispatcher interface
+type MockEventDispatcher struct {
+	ctrl     *gomock.Controller
+	recorder *MockEventDispatcherMockRecorder
+}
+
+// MockEventDispatcherMockRecorder is the mock recorder for MockEventDispatcher
+type MockEventDispatcherMockRecorder struct {
+	mock *MockEventDispatcher
+}
+
+// NewMockEventDispatcher creates a new mock instance
+func NewMockEventDispatcher(ctrl *gomock.Controller) *MockEventDispatcher {
+	mock := &MockEventDispatcher{ctrl: ctrl}
+	mock.recorder = &MockEventDispatcherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockEventDispatcher) EXPECT() *MockEventDispatcherMockRecorder {
+	return m.recorder
+}
+
+// Start mocks base method
+func (m *MockEventDispatcher) Start() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Start")
+}
+
+// Start indicates an expected call of Start
+func (mr *MockEventDispatcherMockRecorder) Start() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Start", reflect.TypeOf((*MockEventDispatcher)(nil).Start))
+}
+
+// Stop mocks base method
+func (m *MockEventDispatcher) Stop() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Stop")
+}
+
+// Stop indicates an expected call of Stop
+func (mr *MockEventDispatcherMockRecorder) Stop() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stop", reflect.TypeOf((*MockEventDispatcher)(nil).Stop))
+}
+
+// MockEventBroker is a mock of EventBroker interface
+type MockEventBroker struct {
+	ctrl     *gomock.Controller
+	recorder *MockEventBrokerMockRecorder
+}
+
+// MockEventBrokerMockRecorder is the mock recorder for MockEventBroker
+type MockEventBrokerMockRecorder struct {
+	mock *MockEventBroker
+}
+
+// NewMockEventBroker creates a new mock instance
+func NewMockEventBroker(ctrl *gomock.Controller) *MockEventBroker {
+	mock := &MockEventBroker{ctrl: ctrl}
+	mock.recorder = &MockEventBrokerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockEventBroker) EXPECT() *MockEventBrokerMockRecorder {
+	return m.recorder
+}
+
+// Publish mocks base method
+func (m *MockEventBroker) Publish(event service.AccountEvent) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Publish", event)
+}
+
+// Publish indicates an expected call of Publish
+func (mr *MockEventBrokerMockRecorder) Publish(event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Publish", reflect.TypeOf((*MockEventBroker)(nil).Publish), event)
+}
+
+// Subscribe mocks base method
+func (m *MockEventBroker) Subscribe(accountUID string) service.EventSubscription {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Subscribe", accountUID)
+	ret0, _ := ret[0].(service.EventSubscription)
+	return ret0
+}
+
+// Subscribe indicates an expected call of Subscribe
+func (mr *MockEventBrokerMockRecorder) Subscribe(accountUID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockEventBroker)(nil).Subscribe), accountUID)
+}
+
+// MockEventSubscription is a mock of EventSubscription interface
+type MockEventSubscription struct {
+	ctrl     *gomock.Controller
+	recorder *MockEventSubscriptionMockRecorder
+}
+
+// MockEventSubscriptionMockRecorder is the mock recorder for MockEventSubscription
+type MockEventSubscriptionMockRecorder struct {
+	mock *MockEventSubscription
+}
+
+// NewMockEventSubscription creates a new mock instance
+func NewMockEventSubscription(ctrl *gomock.Controller) *MockEventSubscription {
+	mock := &MockEventSubscription{ctrl: ctrl}
+	mock.recorder = &MockEventSubscriptionMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockEventSubscription) EXPECT() *MockEventSubscriptionMockRecorder {
+	return m.recorder
+}
+
+// Events mocks base method
+func (m *MockEventSubscription) Events() <-chan service.AccountEvent {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Events")
+	ret0, _ := ret[0].(<-chan service.AccountEvent)
+	return ret0
+}
+
+// Events indicates an expected call of Events
+func (mr *MockEventSubscriptionMockRecorder) Events() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Events", reflect.TypeOf((*MockEventSubscription)(nil).Events))
+}
+
+// Cancel mocks base method
+func (m *MockEventSubscription) Cancel() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Cancel")
+}
+
+// Cancel indicates an expected call of Cancel
+func (mr *MockEventSubscriptionMockRecorder) Cancel() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Cancel", reflect.TypeOf((*MockEventSubscription)(nil).Cancel))
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+
+	"github.com/Toshik1978/go-rest-api/handler"
+)
+
+const (
+	reconcileCommand = "reconcile"
+
+	// Drift exit code allows to run reconcile command from cron and alert on it
+	reconcileDriftCode = 2
+)
+
+// errBalanceDrift returned by reconcile command, if drifted balances are found and not adjusted
+var errBalanceDrift = errors.New("balance drift detected")
+
+// reconcile reconciles accounts' balances with the ledger and prints report
+func (a *admin) reconcile(args []string) error {
+	flags := flag.NewFlagSet(reconcileCommand, flag.ContinueOnError)
+	adjust := flags.Bool("adjust", false, "correct balances of drifted accounts to match the ledger")
+	reason := flags.String("reason", "", "audit reason of the adjustment, required with -adjust")
+	if err := a.parse(flags, args); err != nil {
+		return err
+	}
+
+	reconciliation, err := a.manager().Reconcile(context.Background(), handler.ReconciliationRequest{
+		Adjust: *adjust,
+		Reason: *reason,
+	})
+	if err != nil {
+		return err
+	}
+	if err := a.write(reconciliation, driftsTable(reconciliation.Drifts)); err != nil {
+		return err
+	}
+	if len(reconciliation.Drifts) > 0 && !reconciliation.Adjusted {
+		return errBalanceDrift
+	}
+	return nil
+}
+
+// driftsTable return table of drifted accounts with header
+func driftsTable(drifts []handler.Drift) [][]string {
+	table := [][]string{{"ACCOUNT", "CURRENCY", "EXPECTED_BALANCE", "ACTUAL_BALANCE"}}
+	for _, drift := range drifts {
+		table = append(table, []string{
+			drift.UID,
+			drift.Currency,
+			drift.ExpectedBalance.String(),
+			drift.ActualBalance.String(),
+		})
+	}
+	return table
+}
@@ -0,0 +1,36 @@
+package repository
+
+import "errors"
+
+var (
+	// ErrAccountExists returned, if account with the same UID is already stored
+	ErrAccountExists = errors.New("account already exists")
+	// ErrInsufficientFunds returned, if account's balance can't become negative
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	// ErrAccountNotActive returned, if balance of frozen or closed account is changed
+	ErrAccountNotActive = errors.New("account is not active")
+	// ErrAccountChanged returned, if account's status can't be changed, because account was changed concurrently
+	ErrAccountChanged = errors.New("account was changed concurrently")
+	// ErrAccountNotFound returned, if there is no account with the given UID
+	ErrAccountNotFound = errors.New("account not found")
+	// ErrUnbalancedTransfer returned, if sum of transfer's postings is not zero in some currency
+	ErrUnbalancedTransfer = errors.New("transfer is not balanced")
+	// ErrTransferNotFound returned, if there is no transfer with the given ID
+	ErrTransferNotFound = errors.New("transfer not found")
+	// ErrIdempotencyKeyExists returned, if not expired idempotency key is already stored
+	ErrIdempotencyKeyExists = errors.New("idempotency key already exists")
+	// ErrWebhookNotFound returned, if there is no webhook with the given ID
+	ErrWebhookNotFound = errors.New("webhook not found")
+	// ErrDeliveryNotFound returned, if there is no delivery with the given ID
+	ErrDeliveryNotFound = errors.New("delivery not found")
+	// ErrDeliveryExists returned, if the event is already delivered to the webhook
+	ErrDeliveryExists = errors.New("delivery already exists")
+	// ErrScheduleNotFound returned, if there is no schedule with the given ID
+	ErrScheduleNotFound = errors.New("schedule not found")
+	// ErrScheduleChanged returned, if schedule can't be advanced, because it was changed concurrently
+	ErrScheduleChanged = errors.New("schedule was changed concurrently")
+	// ErrScheduleRunNotFound returned, if there is no schedule's run with the given ID
+	ErrScheduleRunNotFound = errors.New("schedule run not found")
+	// ErrScheduleRunExists returned, if the schedule's occurrence is already run
+	ErrScheduleRunExists = errors.New("schedule run already exists")
+)
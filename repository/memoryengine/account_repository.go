@@ -0,0 +1,134 @@
+package memoryengine
+
+import (
+	"context"
+	"sort"
+
+	"github.com/Toshik1978/go-rest-api/repository"
+)
+
+// accountRepository implements AccountRepository interface
+type accountRepository struct {
+	store *store
+}
+
+// newAccountRepository creates new account repository
+func newAccountRepository(store *store) repository.AccountRepository {
+	return &accountRepository{
+		store: store,
+	}
+}
+
+func (r *accountRepository) GetAll(ctx context.Context, page repository.Page) ([]repository.Account, error) {
+	var accounts []repository.Account
+	err := r.store.view(ctx, func(d *data) error {
+		for _, account := range d.accounts {
+			if account.ID > page.AfterID {
+				accounts = append(accounts, account)
+			}
+		}
+		sort.Slice(accounts, func(i, j int) bool { return accounts[i].ID < accounts[j].ID })
+		if len(accounts) > page.Limit {
+			accounts = accounts[:page.Limit]
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+func (r *accountRepository) GetByUID(ctx context.Context, uid string) (*repository.Account, error) {
+	var account repository.Account
+	err := r.store.view(ctx, func(d *data) error {
+		var ok bool
+		if account, ok = d.accounts[uid]; !ok {
+			return repository.ErrAccountNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (r *accountRepository) LockByUIDs(ctx context.Context, uids []string) ([]repository.Account, error) {
+	// Transactions are run one after another, so accounts are locked by the transaction itself
+	var accounts []repository.Account
+	err := r.store.view(ctx, func(d *data) error {
+		for _, uid := range uids {
+			if account, ok := d.accounts[uid]; ok {
+				accounts = append(accounts, account)
+			}
+		}
+		sort.Slice(accounts, func(i, j int) bool { return accounts[i].UID < accounts[j].UID })
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+func (r *accountRepository) Store(ctx context.Context, account *repository.Account) error {
+	return r.store.update(ctx, func(d *data) error {
+		if err := checkSize("uid", account.UID, uidSize); err != nil {
+			return err
+		}
+		if err := checkSize("currency", account.Currency, currencySize); err != nil {
+			return err
+		}
+		if err := checkStatus(account.Status); err != nil {
+			return err
+		}
+		if account.Balance < 0 {
+			return errNegativeBalance
+		}
+		if _, ok := d.accounts[account.UID]; ok {
+			return repository.ErrAccountExists
+		}
+
+		account.ID = next(&r.store.sequences.accounts)
+		d.accounts[account.UID] = *account
+		return nil
+	})
+}
+
+func (r *accountRepository) UpdateBalance(ctx context.Context, uid string, incr int64) error {
+	return r.store.update(ctx, func(d *data) error {
+		account, ok := d.accounts[uid]
+		if !ok || account.Status != repository.ActiveAccount {
+			return repository.ErrAccountNotActive
+		}
+		if account.Balance+incr < 0 {
+			return repository.ErrInsufficientFunds
+		}
+
+		account.Balance += incr
+		d.accounts[uid] = account
+		return nil
+	})
+}
+
+func (r *accountRepository) UpdateStatus(ctx context.Context, transition *repository.AccountTransition) error {
+	return r.store.update(ctx, func(d *data) error {
+		if err := checkStatus(transition.ToStatus); err != nil {
+			return err
+		}
+		// Transition is recorded only if account is actually updated
+		account, ok := d.accounts[transition.AccountUID]
+		if !ok || account.Status != transition.FromStatus ||
+			(transition.ToStatus == repository.ClosedAccount && account.Balance != 0) {
+			return repository.ErrAccountChanged
+		}
+
+		account.Status = transition.ToStatus
+		account.StatusChangedAt = transition.CreatedAt
+		d.accounts[account.UID] = account
+		transition.ID = next(&r.store.sequences.transitions)
+		d.transitions = append(d.transitions, *transition)
+		return nil
+	})
+}
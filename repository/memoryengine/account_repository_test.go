@@ -0,0 +1,162 @@
+package memoryengine
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service/testutil"
+	"github.com/stretchr/testify/suite"
+)
+
+type accountRepositoryTestSuite struct {
+	suite.Suite
+
+	repository repository.AccountRepository
+	account    repository.Account
+}
+
+func (s *accountRepositoryTestSuite) SetupTest() {
+	s.repository = newAccountRepository(newStore())
+	s.account = testutil.RepositoryAccount()
+}
+
+// storeAccounts stores accounts with the given UIDs
+func (s *accountRepositoryTestSuite) storeAccounts(uids ...string) {
+	for _, uid := range uids {
+		account := s.account
+		account.UID = uid
+		s.Require().NoError(s.repository.Store(context.Background(), &account))
+	}
+}
+
+func (s *accountRepositoryTestSuite) TestGetAllAccountsEmptySucceeded() {
+	accounts, err := s.repository.GetAll(context.Background(), repository.Page{Limit: 10})
+
+	s.NoError(err)
+	s.Empty(accounts)
+}
+
+func (s *accountRepositoryTestSuite) TestGetAllAccountsSucceeded() {
+	s.storeAccounts("toshik1980", "toshik1978", "toshik1979")
+
+	accounts, err := s.repository.GetAll(context.Background(), repository.Page{Limit: 2})
+	s.NoError(err)
+	s.Len(accounts, 2)
+	s.Equal("toshik1980", accounts[0].UID)
+	s.Equal("toshik1978", accounts[1].UID)
+
+	accounts, err = s.repository.GetAll(context.Background(), repository.Page{AfterID: accounts[1].ID, Limit: 2})
+	s.NoError(err)
+	s.Len(accounts, 1)
+	s.Equal("toshik1979", accounts[0].UID)
+}
+
+func (s *accountRepositoryTestSuite) TestGetAccountByUIDFailed() {
+	account, err := s.repository.GetByUID(context.Background(), "toshik1978")
+
+	s.Equal(repository.ErrAccountNotFound, err)
+	s.Nil(account)
+}
+
+func (s *accountRepositoryTestSuite) TestGetAccountByUIDSucceeded() {
+	s.storeAccounts("toshik1978")
+	account, err := s.repository.GetByUID(context.Background(), "toshik1978")
+
+	s.NoError(err)
+	s.Equal(s.account.Balance, account.Balance)
+	s.NotZero(account.ID)
+}
+
+func (s *accountRepositoryTestSuite) TestLockAccountsByUIDsSucceeded() {
+	s.storeAccounts("toshik1979", "toshik1978")
+	accounts, err := s.repository.LockByUIDs(context.Background(), []string{"toshik1979", "toshik1977", "toshik1978"})
+
+	s.NoError(err)
+	s.Len(accounts, 2)
+	s.Equal("toshik1978", accounts[0].UID)
+	s.Equal("toshik1979", accounts[1].UID)
+}
+
+func (s *accountRepositoryTestSuite) TestStoreAccountExistsFailed() {
+	s.storeAccounts("toshik1978")
+	err := s.repository.Store(context.Background(), &s.account)
+
+	s.Equal(repository.ErrAccountExists, err)
+}
+
+func (s *accountRepositoryTestSuite) TestStoreAccountConstraintsFailed() {
+	account := s.account
+	account.Balance = -1
+	s.Equal(errNegativeBalance, s.repository.Store(context.Background(), &account))
+
+	account = s.account
+	account.Status = "deleted"
+	s.Equal(errUnknownStatus, s.repository.Store(context.Background(), &account))
+
+	account = s.account
+	account.UID = strings.Repeat("a", uidSize+1)
+	s.Error(s.repository.Store(context.Background(), &account))
+
+	accounts, err := s.repository.GetAll(context.Background(), repository.Page{Limit: 10})
+	s.NoError(err)
+	s.Empty(accounts)
+}
+
+func (s *accountRepositoryTestSuite) TestUpdateBalanceFailed() {
+	s.storeAccounts("toshik1978")
+
+	s.Equal(repository.ErrInsufficientFunds, s.repository.UpdateBalance(context.Background(), "toshik1978", -10001))
+	s.Equal(repository.ErrAccountNotActive, s.repository.UpdateBalance(context.Background(), "toshik1979", 100))
+}
+
+func (s *accountRepositoryTestSuite) TestUpdateBalanceNotActiveFailed() {
+	s.account.Status = repository.FrozenAccount
+	s.storeAccounts("toshik1978")
+
+	s.Equal(repository.ErrAccountNotActive, s.repository.UpdateBalance(context.Background(), "toshik1978", 100))
+}
+
+func (s *accountRepositoryTestSuite) TestUpdateBalanceSucceeded() {
+	s.storeAccounts("toshik1978")
+	s.NoError(s.repository.UpdateBalance(context.Background(), "toshik1978", -10000))
+
+	account, err := s.repository.GetByUID(context.Background(), "toshik1978")
+	s.NoError(err)
+	s.Equal(int64(0), account.Balance)
+}
+
+func (s *accountRepositoryTestSuite) TestUpdateStatusFailed() {
+	s.storeAccounts("toshik1978")
+	transition := &repository.AccountTransition{
+		AccountUID: "toshik1978",
+		FromStatus: repository.ActiveAccount,
+		ToStatus:   repository.ClosedAccount,
+		CreatedAt:  s.account.CreatedAt,
+	}
+
+	// Account with non-zero balance can't be closed
+	s.Equal(repository.ErrAccountChanged, s.repository.UpdateStatus(context.Background(), transition))
+	transition.FromStatus = repository.FrozenAccount
+	transition.ToStatus = repository.ActiveAccount
+	s.Equal(repository.ErrAccountChanged, s.repository.UpdateStatus(context.Background(), transition))
+	transition.ToStatus = "deleted"
+	s.Equal(errUnknownStatus, s.repository.UpdateStatus(context.Background(), transition))
+}
+
+func (s *accountRepositoryTestSuite) TestUpdateStatusSucceeded() {
+	s.storeAccounts("toshik1978")
+	transition := &repository.AccountTransition{
+		AccountUID: "toshik1978",
+		FromStatus: repository.ActiveAccount,
+		ToStatus:   repository.FrozenAccount,
+		CreatedAt:  s.account.CreatedAt.Add(1),
+	}
+
+	s.NoError(s.repository.UpdateStatus(context.Background(), transition))
+	s.NotZero(transition.ID)
+	account, err := s.repository.GetByUID(context.Background(), "toshik1978")
+	s.NoError(err)
+	s.Equal(repository.FrozenAccount, account.Status)
+	s.Equal(transition.CreatedAt, account.StatusChangedAt)
+}
@@ -0,0 +1,93 @@
+package memoryengine
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/Toshik1978/go-rest-api/repository"
+)
+
+// Sizes of VARCHAR columns of the SQL schema
+const (
+	uidSize         = 256
+	currencySize    = 16
+	operationSize   = 32
+	fingerprintSize = 64
+	reasonSize      = 256
+	eventTypeSize   = 64
+	secretSize      = 128
+	cronSize        = 128
+)
+
+var (
+	// errNegativeBalance returned, if stored account has negative balance (balance >= 0 check of SQL schema)
+	errNegativeBalance = errors.New("account's balance violates check constraint")
+	// errUnknownStatus returned, if account's status is unknown (status IN (...) check of SQL schema)
+	errUnknownStatus = errors.New("account's status violates check constraint")
+	// errUnknownDeliveryStatus returned, if delivery's status is unknown (status IN (...) check of SQL schema)
+	errUnknownDeliveryStatus = errors.New("delivery's status violates check constraint")
+	// errUnknownSchedule returned, if schedule's amount, recurrence, policy or status are invalid
+	// (amount > 0 and IN (...) checks of SQL schema)
+	errUnknownSchedule = errors.New("schedule violates check constraint")
+	// errUnknownRunStatus returned, if run's status is unknown (status IN (...) check of SQL schema)
+	errUnknownRunStatus = errors.New("schedule run's status violates check constraint")
+)
+
+// checkSize checks, if value fits VARCHAR column of the given size
+func checkSize(column string, value string, size int) error {
+	if utf8.RuneCountInString(value) > size {
+		return fmt.Errorf("value too long for %s, it's limited by %d characters", column, size)
+	}
+	return nil
+}
+
+// checkStatus checks, if account's status is known
+func checkStatus(status repository.AccountStatus) error {
+	switch status {
+	case repository.ActiveAccount, repository.FrozenAccount, repository.ClosedAccount:
+		return nil
+	}
+	return errUnknownStatus
+}
+
+// checkDeliveryStatus checks, if delivery's status is known
+func checkDeliveryStatus(status repository.DeliveryStatus) error {
+	switch status {
+	case repository.PendingDelivery, repository.SucceededDelivery, repository.FailedDelivery:
+		return nil
+	}
+	return errUnknownDeliveryStatus
+}
+
+// checkSchedule checks, if schedule's amount, recurrence, policy and status are valid
+func checkSchedule(schedule repository.Schedule) error {
+	if schedule.Amount <= 0 {
+		return errUnknownSchedule
+	}
+	switch schedule.Recurrence {
+	case repository.DailyRecurrence, repository.WeeklyRecurrence,
+		repository.MonthlyRecurrence, repository.CronRecurrence:
+	default:
+		return errUnknownSchedule
+	}
+	switch schedule.OnInsufficientFunds {
+	case repository.SkipOnInsufficientFunds, repository.RetryOnInsufficientFunds:
+	default:
+		return errUnknownSchedule
+	}
+	switch schedule.Status {
+	case repository.ActiveSchedule, repository.CancelledSchedule, repository.CompletedSchedule:
+		return nil
+	}
+	return errUnknownSchedule
+}
+
+// checkRunStatus checks, if run's status is known
+func checkRunStatus(status repository.RunStatus) error {
+	switch status {
+	case repository.PendingRun, repository.SucceededRun, repository.SkippedRun, repository.FailedRun:
+		return nil
+	}
+	return errUnknownRunStatus
+}
@@ -0,0 +1,26 @@
+package memoryengine
+
+import (
+	"context"
+)
+
+type contextTxKey string
+
+const (
+	txKey contextTxKey = "go-rest-api.memory.tx"
+)
+
+// contextWithTransaction creates context with transaction
+func contextWithTransaction(ctx context.Context, tx *transaction) context.Context {
+	return context.WithValue(ctx, txKey, tx)
+}
+
+// transactionFromContext retrieve transaction from context
+func transactionFromContext(ctx context.Context) *transaction {
+	if value := ctx.Value(txKey); value != nil {
+		if tx, ok := value.(*transaction); ok {
+			return tx
+		}
+	}
+	return nil
+}
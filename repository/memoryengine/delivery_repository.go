@@ -0,0 +1,156 @@
+package memoryengine
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/repository"
+)
+
+// deliveryRepository implements DeliveryRepository interface
+type deliveryRepository struct {
+	store *store
+}
+
+// newDeliveryRepository creates new delivery repository
+func newDeliveryRepository(store *store) repository.DeliveryRepository {
+	return &deliveryRepository{
+		store: store,
+	}
+}
+
+func (r *deliveryRepository) GetByWebhook(ctx context.Context,
+	webhookID int64, page repository.Page) ([]repository.Delivery, error) {
+
+	var deliveries []repository.Delivery
+	err := r.store.view(ctx, func(d *data) error {
+		for _, delivery := range d.deliveries {
+			if delivery.WebhookID == webhookID && delivery.ID > page.AfterID {
+				deliveries = append(deliveries, delivery)
+			}
+		}
+		sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].ID < deliveries[j].ID })
+		if len(deliveries) > page.Limit {
+			deliveries = deliveries[:page.Limit]
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (r *deliveryRepository) GetByID(ctx context.Context, id int64) (*repository.Delivery, error) {
+	var delivery repository.Delivery
+	err := r.store.view(ctx, func(d *data) error {
+		var ok bool
+		if delivery, ok = d.deliveries[id]; !ok {
+			return repository.ErrDeliveryNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *deliveryRepository) GetPending(ctx context.Context, now time.Time, limit int) ([]repository.Delivery, error) {
+	var deliveries []repository.Delivery
+	err := r.store.view(ctx, func(d *data) error {
+		for _, delivery := range d.deliveries {
+			if delivery.Status == repository.PendingDelivery && !delivery.NextAttemptAt.After(now) {
+				deliveries = append(deliveries, delivery)
+			}
+		}
+		sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].ID < deliveries[j].ID })
+		if len(deliveries) > limit {
+			deliveries = deliveries[:limit]
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (r *deliveryRepository) GetAttempts(ctx context.Context, deliveryID int64) ([]repository.DeliveryAttempt, error) {
+	var attempts []repository.DeliveryAttempt
+	err := r.store.view(ctx, func(d *data) error {
+		// Attempts are appended in order of IDs
+		for _, attempt := range d.attempts {
+			if attempt.DeliveryID == deliveryID {
+				attempts = append(attempts, attempt)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return attempts, nil
+}
+
+func (r *deliveryRepository) Store(ctx context.Context, delivery *repository.Delivery) error {
+	return r.store.update(ctx, func(d *data) error {
+		if err := checkSize("event_type", string(delivery.EventType), eventTypeSize); err != nil {
+			return err
+		}
+		if err := checkDeliveryStatus(delivery.Status); err != nil {
+			return err
+		}
+		if _, ok := d.webhooks[delivery.WebhookID]; !ok {
+			return repository.ErrWebhookNotFound
+		}
+		if _, ok := d.deliveryKeys[deliveryKey(*delivery)]; ok {
+			return repository.ErrDeliveryExists
+		}
+
+		delivery.ID = next(&r.store.sequences.deliveries)
+		d.deliveries[delivery.ID] = *delivery
+		d.deliveryKeys[deliveryKey(*delivery)] = delivery.ID
+		return nil
+	})
+}
+
+func (r *deliveryRepository) Update(ctx context.Context, delivery *repository.Delivery) error {
+	return r.store.update(ctx, func(d *data) error {
+		if err := checkDeliveryStatus(delivery.Status); err != nil {
+			return err
+		}
+		stored, ok := d.deliveries[delivery.ID]
+		if !ok {
+			return repository.ErrDeliveryNotFound
+		}
+
+		stored.Status = delivery.Status
+		stored.Attempts = delivery.Attempts
+		stored.NextAttemptAt = delivery.NextAttemptAt
+		stored.UpdatedAt = delivery.UpdatedAt
+		d.deliveries[delivery.ID] = stored
+		return nil
+	})
+}
+
+func (r *deliveryRepository) StoreAttempt(ctx context.Context, attempt *repository.DeliveryAttempt) error {
+	return r.store.update(ctx, func(d *data) error {
+		if _, ok := d.deliveries[attempt.DeliveryID]; !ok {
+			return repository.ErrDeliveryNotFound
+		}
+
+		attempt.ID = next(&r.store.sequences.attempts)
+		d.attempts = append(d.attempts, *attempt)
+		return nil
+	})
+}
+
+// deliveryKey return unique key of the delivery
+func deliveryKey(delivery repository.Delivery) webhookEvent {
+	return webhookEvent{
+		webhookID: delivery.WebhookID,
+		eventID:   delivery.EventID,
+	}
+}
@@ -0,0 +1,140 @@
+package memoryengine
+
+import (
+	"context"
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service/testutil"
+	"github.com/stretchr/testify/suite"
+)
+
+type deliveryRepositoryTestSuite struct {
+	suite.Suite
+
+	repository repository.DeliveryRepository
+	delivery   repository.Delivery
+}
+
+func (s *deliveryRepositoryTestSuite) SetupTest() {
+	store := newStore()
+	webhook := testutil.RepositoryWebhook()
+	s.Require().NoError(newWebhookRepository(store).Store(context.Background(), &webhook))
+
+	s.repository = newDeliveryRepository(store)
+	s.delivery = testutil.RepositoryDelivery()
+	s.delivery.WebhookID = webhook.ID
+}
+
+func (s *deliveryRepositoryTestSuite) TestStoreDeliveryUnknownStatusFailed() {
+	delivery := s.delivery
+	delivery.Status = "unknown"
+	err := s.repository.Store(context.Background(), &delivery)
+
+	s.Error(err)
+}
+
+func (s *deliveryRepositoryTestSuite) TestStoreDeliveryWebhookNotFoundFailed() {
+	delivery := s.delivery
+	delivery.WebhookID++
+	err := s.repository.Store(context.Background(), &delivery)
+
+	s.Equal(repository.ErrWebhookNotFound, err)
+}
+
+func (s *deliveryRepositoryTestSuite) TestStoreDeliveryExistsFailed() {
+	first, second := s.delivery, s.delivery
+	s.Require().NoError(s.repository.Store(context.Background(), &first))
+	err := s.repository.Store(context.Background(), &second)
+
+	s.Equal(repository.ErrDeliveryExists, err)
+}
+
+func (s *deliveryRepositoryTestSuite) TestStoreDeliverySucceeded() {
+	s.Require().NoError(s.repository.Store(context.Background(), &s.delivery))
+
+	delivery, err := s.repository.GetByID(context.Background(), s.delivery.ID)
+	s.NoError(err)
+	s.Equal(&s.delivery, delivery)
+
+	deliveries, err := s.repository.GetByWebhook(context.Background(),
+		s.delivery.WebhookID, repository.Page{Limit: 10})
+	s.NoError(err)
+	s.Equal([]repository.Delivery{s.delivery}, deliveries)
+
+	deliveries, err = s.repository.GetByWebhook(context.Background(),
+		s.delivery.WebhookID+1, repository.Page{Limit: 10})
+	s.NoError(err)
+	s.Empty(deliveries)
+}
+
+func (s *deliveryRepositoryTestSuite) TestGetPendingDeliveriesSucceeded() {
+	first, second, third := s.delivery, s.delivery, s.delivery
+	second.EventID++
+	second.NextAttemptAt = s.delivery.NextAttemptAt.Add(time.Minute)
+	third.EventID += 2
+	third.Status = repository.SucceededDelivery
+	s.Require().NoError(s.repository.Store(context.Background(), &first))
+	s.Require().NoError(s.repository.Store(context.Background(), &second))
+	s.Require().NoError(s.repository.Store(context.Background(), &third))
+
+	// Delivery isn't returned before its next attempt
+	deliveries, err := s.repository.GetPending(context.Background(), s.delivery.NextAttemptAt, 10)
+	s.NoError(err)
+	s.Equal([]repository.Delivery{first}, deliveries)
+
+	deliveries, err = s.repository.GetPending(context.Background(), second.NextAttemptAt, 10)
+	s.NoError(err)
+	s.Equal([]repository.Delivery{first, second}, deliveries)
+}
+
+func (s *deliveryRepositoryTestSuite) TestUpdateDeliveryNotFoundFailed() {
+	err := s.repository.Update(context.Background(), &s.delivery)
+
+	s.Equal(repository.ErrDeliveryNotFound, err)
+}
+
+func (s *deliveryRepositoryTestSuite) TestUpdateDeliverySucceeded() {
+	s.Require().NoError(s.repository.Store(context.Background(), &s.delivery))
+
+	updated := s.delivery
+	updated.Status = repository.FailedDelivery
+	updated.Attempts = 3
+	updated.NextAttemptAt = s.delivery.NextAttemptAt.Add(time.Minute)
+	updated.UpdatedAt = s.delivery.UpdatedAt.Add(time.Minute)
+	s.NoError(s.repository.Update(context.Background(), &updated))
+
+	delivery, err := s.repository.GetByID(context.Background(), s.delivery.ID)
+	s.NoError(err)
+	s.Equal(&updated, delivery)
+}
+
+func (s *deliveryRepositoryTestSuite) TestStoreAttemptDeliveryNotFoundFailed() {
+	attempt := repository.DeliveryAttempt{DeliveryID: 1}
+	err := s.repository.StoreAttempt(context.Background(), &attempt)
+
+	s.Equal(repository.ErrDeliveryNotFound, err)
+}
+
+func (s *deliveryRepositoryTestSuite) TestStoreAttemptSucceeded() {
+	s.Require().NoError(s.repository.Store(context.Background(), &s.delivery))
+
+	first := repository.DeliveryAttempt{
+		DeliveryID: s.delivery.ID,
+		Error:      "fail",
+		DurationMS: 10,
+		CreatedAt:  s.delivery.CreatedAt,
+	}
+	second := repository.DeliveryAttempt{
+		DeliveryID: s.delivery.ID,
+		StatusCode: 200,
+		DurationMS: 20,
+		CreatedAt:  s.delivery.CreatedAt.Add(time.Second),
+	}
+	s.Require().NoError(s.repository.StoreAttempt(context.Background(), &first))
+	s.Require().NoError(s.repository.StoreAttempt(context.Background(), &second))
+
+	attempts, err := s.repository.GetAttempts(context.Background(), s.delivery.ID)
+	s.NoError(err)
+	s.Equal([]repository.DeliveryAttempt{first, second}, attempts)
+}
@@ -0,0 +1,60 @@
+package memoryengine
+
+import (
+	"context"
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/repository"
+)
+
+// idempotencyRepository implements IdempotencyRepository interface
+type idempotencyRepository struct {
+	store *store
+}
+
+// newIdempotencyRepository creates new idempotency key repository
+func newIdempotencyRepository(store *store) repository.IdempotencyRepository {
+	return &idempotencyRepository{
+		store: store,
+	}
+}
+
+func (r *idempotencyRepository) Get(
+	ctx context.Context, operation string, key string, now time.Time) (*repository.IdempotencyKey, error) {
+
+	var idempotencyKey *repository.IdempotencyKey
+	err := r.store.view(ctx, func(d *data) error {
+		if stored, ok := d.idempotencyKeys[idempotencyID{operation: operation, key: key}]; ok &&
+			stored.ExpiresAt.After(now) {
+
+			idempotencyKey = &stored
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return idempotencyKey, nil
+}
+
+func (r *idempotencyRepository) Store(ctx context.Context, key *repository.IdempotencyKey) error {
+	return r.store.update(ctx, func(d *data) error {
+		if err := checkSize("operation", key.Operation, operationSize); err != nil {
+			return err
+		}
+		if err := checkSize("key", key.Key, uidSize); err != nil {
+			return err
+		}
+		if err := checkSize("fingerprint", key.Fingerprint, fingerprintSize); err != nil {
+			return err
+		}
+		// Expired key is replaced, otherwise nothing is affected
+		id := idempotencyID{operation: key.Operation, key: key.Key}
+		if stored, ok := d.idempotencyKeys[id]; ok && stored.ExpiresAt.After(key.CreatedAt) {
+			return repository.ErrIdempotencyKeyExists
+		}
+
+		d.idempotencyKeys[id] = *key
+		return nil
+	})
+}
@@ -0,0 +1,63 @@
+package memoryengine
+
+import (
+	"context"
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service/testutil"
+	"github.com/stretchr/testify/suite"
+)
+
+type idempotencyRepositoryTestSuite struct {
+	suite.Suite
+
+	repository repository.IdempotencyRepository
+	key        repository.IdempotencyKey
+}
+
+func (s *idempotencyRepositoryTestSuite) SetupTest() {
+	s.repository = newIdempotencyRepository(newStore())
+	s.key = testutil.RepositoryIdempotencyKey()
+}
+
+func (s *idempotencyRepositoryTestSuite) TestGetIdempotencyKeyEmptySucceeded() {
+	key, err := s.repository.Get(context.Background(), s.key.Operation, s.key.Key, s.key.CreatedAt)
+
+	s.NoError(err)
+	s.Nil(key)
+}
+
+func (s *idempotencyRepositoryTestSuite) TestGetIdempotencyKeySucceeded() {
+	s.Require().NoError(s.repository.Store(context.Background(), &s.key))
+
+	key, err := s.repository.Get(context.Background(), s.key.Operation, s.key.Key, s.key.CreatedAt)
+	s.NoError(err)
+	s.Equal(s.key, *key)
+
+	// Expired key isn't returned
+	key, err = s.repository.Get(context.Background(), s.key.Operation, s.key.Key, s.key.ExpiresAt)
+	s.NoError(err)
+	s.Nil(key)
+}
+
+func (s *idempotencyRepositoryTestSuite) TestStoreIdempotencyKeyExistsFailed() {
+	s.Require().NoError(s.repository.Store(context.Background(), &s.key))
+	err := s.repository.Store(context.Background(), &s.key)
+
+	s.Equal(repository.ErrIdempotencyKeyExists, err)
+}
+
+func (s *idempotencyRepositoryTestSuite) TestStoreIdempotencyKeyExpiredSucceeded() {
+	s.Require().NoError(s.repository.Store(context.Background(), &s.key))
+
+	replaced := s.key
+	replaced.Fingerprint = "replaced"
+	replaced.CreatedAt = s.key.ExpiresAt
+	replaced.ExpiresAt = s.key.ExpiresAt.Add(time.Hour)
+	s.NoError(s.repository.Store(context.Background(), &replaced))
+
+	key, err := s.repository.Get(context.Background(), s.key.Operation, s.key.Key, replaced.CreatedAt)
+	s.NoError(err)
+	s.Equal("replaced", key.Fingerprint)
+}
@@ -0,0 +1,163 @@
+package memoryengine
+
+import (
+	"context"
+	"sort"
+
+	"github.com/AlekSi/pointer"
+	"github.com/Toshik1978/go-rest-api/repository"
+)
+
+// ledgerRepository implements LedgerRepository interface
+type ledgerRepository struct {
+	store *store
+}
+
+// newLedgerRepository creates new ledger repository
+func newLedgerRepository(store *store) repository.LedgerRepository {
+	return &ledgerRepository{
+		store: store,
+	}
+}
+
+func (r *ledgerRepository) GetAll(ctx context.Context, page repository.Page) ([]repository.Entry, error) {
+	return r.entries(ctx, page, func(posting repository.Posting, transfer repository.Transfer) bool {
+		return true
+	})
+}
+
+func (r *ledgerRepository) GetByAccount(
+	ctx context.Context, filter repository.PaymentFilter, page repository.Page) ([]repository.Entry, error) {
+
+	return r.entries(ctx, page, func(posting repository.Posting, transfer repository.Transfer) bool {
+		if posting.AccountUID != filter.AccountUID {
+			return false
+		}
+		// Debit posting belongs to payer, so counterparty is recipient and vice versa
+		counterpartyUID := transfer.PayerAccountUID
+		if posting.Amount < 0 {
+			counterpartyUID = transfer.RecipientAccountUID
+		}
+		amount := posting.Amount
+		if amount < 0 {
+			amount = -amount
+		}
+		switch {
+		case filter.CounterpartyUID != "" && counterpartyUID != filter.CounterpartyUID:
+			return false
+		case filter.Direction == repository.OutgoingDirection && posting.Amount >= 0:
+			return false
+		case filter.Direction == repository.IncomingDirection && posting.Amount < 0:
+			return false
+		case filter.From != nil && posting.CreatedAt.Before(*filter.From):
+			return false
+		case filter.To != nil && !posting.CreatedAt.Before(*filter.To):
+			return false
+		case filter.MinAmount != nil && amount < *filter.MinAmount:
+			return false
+		case filter.MaxAmount != nil && amount > *filter.MaxAmount:
+			return false
+		}
+		return true
+	})
+}
+
+func (r *ledgerRepository) GetByID(ctx context.Context, id int64) (*repository.Transfer, error) {
+	var transfer repository.Transfer
+	err := r.store.view(ctx, func(d *data) error {
+		i := sort.Search(len(d.transfers), func(i int) bool { return d.transfers[i].ID >= id })
+		if i == len(d.transfers) || d.transfers[i].ID != id {
+			return repository.ErrTransferNotFound
+		}
+		transfer = d.transfer(id)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+func (r *ledgerRepository) Store(ctx context.Context, transfer *repository.Transfer) error {
+	// Database checks the same invariant on commit, but it's better to fail earlier
+	if !repository.Balanced(transfer.Postings) {
+		return repository.ErrUnbalancedTransfer
+	}
+
+	return r.store.update(ctx, func(d *data) error {
+		for _, uid := range []string{transfer.PayerAccountUID, transfer.RecipientAccountUID} {
+			if _, ok := d.accounts[uid]; !ok {
+				return repository.ErrAccountNotFound
+			}
+		}
+		for _, currency := range []string{transfer.SourceCurrency, transfer.TargetCurrency} {
+			if err := checkSize("currency", currency, currencySize); err != nil {
+				return err
+			}
+		}
+		for _, posting := range transfer.Postings {
+			if err := checkSize("account_uid", posting.AccountUID, uidSize); err != nil {
+				return err
+			}
+			if err := checkSize("currency", posting.Currency, currencySize); err != nil {
+				return err
+			}
+		}
+
+		transfer.ID = next(&r.store.sequences.transfers)
+		stored := *transfer
+		stored.RefundedAmount = 0
+		stored.Postings = nil
+		// Refunded amount is kept by refunded transfer's ID, because stored transfers are never changed
+		if transfer.RefundOf != nil {
+			stored.RefundOf = pointer.ToInt64(*transfer.RefundOf)
+			d.refunds[*transfer.RefundOf] += transfer.TargetAmount
+		}
+		d.transfers = append(d.transfers, stored)
+		for i := range transfer.Postings {
+			posting := &transfer.Postings[i]
+			posting.ID = next(&r.store.sequences.postings)
+			posting.TransferID = transfer.ID
+			posting.CreatedAt = transfer.CreatedAt
+			d.postings = append(d.postings, *posting)
+		}
+		return nil
+	})
+}
+
+// entries return page of payers' and recipients' postings with their transfers, matched by the given function.
+// Postings and transfers are appended in order of their IDs, so they're ordered by ID
+func (r *ledgerRepository) entries(ctx context.Context, page repository.Page,
+	match func(posting repository.Posting, transfer repository.Transfer) bool) ([]repository.Entry, error) {
+
+	var entries []repository.Entry
+	err := r.store.view(ctx, func(d *data) error {
+		start := sort.Search(len(d.postings), func(i int) bool { return d.postings[i].ID > page.AfterID })
+		for _, posting := range d.postings[start:] {
+			if len(entries) >= page.Limit {
+				break
+			}
+			transfer := d.transfer(posting.TransferID)
+			// Only payer's and recipient's postings are payments, internal postings (e.g. currency exchange) are not
+			if posting.AccountUID != transfer.PayerAccountUID && posting.AccountUID != transfer.RecipientAccountUID {
+				continue
+			}
+			if match(posting, transfer) {
+				entries = append(entries, repository.Entry{Posting: posting, Transfer: transfer})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// transfer return stored transfer with the given ID and its refunded amount
+func (d *data) transfer(id int64) repository.Transfer {
+	i := sort.Search(len(d.transfers), func(i int) bool { return d.transfers[i].ID >= id })
+	transfer := d.transfers[i]
+	transfer.RefundedAmount = d.refunds[id]
+	return transfer
+}
@@ -0,0 +1,170 @@
+package memoryengine
+
+import (
+	"context"
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service/testutil"
+	"github.com/stretchr/testify/suite"
+)
+
+type ledgerRepositoryTestSuite struct {
+	suite.Suite
+
+	factory  repository.Factory
+	transfer repository.Transfer
+}
+
+func (s *ledgerRepositoryTestSuite) SetupTest() {
+	s.factory = NewRepositoryFactory()
+	s.transfer = testutil.RepositoryTransfer()
+	for _, uid := range []string{"toshik1978", "toshik1979"} {
+		account := testutil.RepositoryAccount()
+		account.UID = uid
+		s.Require().NoError(s.factory.AccountRepository().Store(context.Background(), &account))
+	}
+}
+
+// storeTransfer stores copy of the test transfer with the given amount and time
+func (s *ledgerRepositoryTestSuite) storeTransfer(amount int64, createdAt time.Time) repository.Transfer {
+	transfer := s.transfer
+	transfer.SourceAmount = amount
+	transfer.TargetAmount = amount
+	transfer.CreatedAt = createdAt
+	transfer.Postings = []repository.Posting{
+		{AccountUID: transfer.PayerAccountUID, Amount: -amount, Currency: transfer.SourceCurrency},
+		{AccountUID: transfer.RecipientAccountUID, Amount: amount, Currency: transfer.TargetCurrency},
+	}
+	s.Require().NoError(s.factory.LedgerRepository().Store(context.Background(), &transfer))
+	return transfer
+}
+
+func (s *ledgerRepositoryTestSuite) TestStoreUnbalancedFailed() {
+	s.transfer.Postings[1].Amount = 1
+	err := s.factory.LedgerRepository().Store(context.Background(), &s.transfer)
+
+	s.Equal(repository.ErrUnbalancedTransfer, err)
+}
+
+func (s *ledgerRepositoryTestSuite) TestStoreAccountNotFoundFailed() {
+	s.transfer.RecipientAccountUID = "toshik1980"
+	err := s.factory.LedgerRepository().Store(context.Background(), &s.transfer)
+
+	s.Equal(repository.ErrAccountNotFound, err)
+	entries, err := s.factory.LedgerRepository().GetAll(context.Background(), repository.Page{Limit: 10})
+	s.NoError(err)
+	s.Empty(entries)
+}
+
+func (s *ledgerRepositoryTestSuite) TestStoreSucceeded() {
+	transfer := s.storeTransfer(100, s.transfer.CreatedAt)
+
+	s.NotZero(transfer.ID)
+	for _, posting := range transfer.Postings {
+		s.NotZero(posting.ID)
+		s.Equal(transfer.ID, posting.TransferID)
+		s.Equal(transfer.CreatedAt, posting.CreatedAt)
+	}
+}
+
+func (s *ledgerRepositoryTestSuite) TestGetByIDNotFoundFailed() {
+	transfer, err := s.factory.LedgerRepository().GetByID(context.Background(), 1)
+
+	s.Equal(repository.ErrTransferNotFound, err)
+	s.Nil(transfer)
+}
+
+func (s *ledgerRepositoryTestSuite) TestStoreRefundSucceeded() {
+	original := s.storeTransfer(100, s.transfer.CreatedAt)
+	for _, amount := range []int64{30, 20} {
+		refund := s.transfer
+		refund.PayerAccountUID, refund.RecipientAccountUID = original.RecipientAccountUID, original.PayerAccountUID
+		refund.SourceAmount = amount
+		refund.TargetAmount = amount
+		refund.RefundOf = &original.ID
+		refund.Postings = []repository.Posting{
+			{AccountUID: refund.PayerAccountUID, Amount: -amount, Currency: refund.SourceCurrency},
+			{AccountUID: refund.RecipientAccountUID, Amount: amount, Currency: refund.TargetCurrency},
+		}
+		s.Require().NoError(s.factory.LedgerRepository().Store(context.Background(), &refund))
+	}
+
+	transfer, err := s.factory.LedgerRepository().GetByID(context.Background(), original.ID)
+	s.NoError(err)
+	s.Equal(original.ID, transfer.ID)
+	s.Nil(transfer.RefundOf)
+	s.Equal(int64(50), transfer.RefundedAmount)
+
+	entries, err := s.factory.LedgerRepository().GetByAccount(context.Background(),
+		repository.PaymentFilter{AccountUID: "toshik1978"}, repository.Page{Limit: 10})
+	s.NoError(err)
+	s.Len(entries, 3)
+	s.Equal(int64(50), entries[0].Transfer.RefundedAmount)
+	s.Require().NotNil(entries[1].Transfer.RefundOf)
+	s.Equal(original.ID, *entries[1].Transfer.RefundOf)
+	s.Zero(entries[1].Transfer.RefundedAmount)
+}
+
+func (s *ledgerRepositoryTestSuite) TestGetAllSucceeded() {
+	// Currency exchange postings aren't payments
+	s.transfer.TargetCurrency = "EUR"
+	s.transfer.TargetAmount = 9000
+	s.transfer.Postings = []repository.Posting{
+		{AccountUID: "toshik1978", Amount: -10000, Currency: "USD"},
+		{AccountUID: repository.FXAccountUID, Amount: 10000, Currency: "USD"},
+		{AccountUID: repository.FXAccountUID, Amount: -9000, Currency: "EUR"},
+		{AccountUID: "toshik1979", Amount: 9000, Currency: "EUR"},
+	}
+	s.Require().NoError(s.factory.LedgerRepository().Store(context.Background(), &s.transfer))
+
+	entries, err := s.factory.LedgerRepository().GetAll(context.Background(), repository.Page{Limit: 10})
+	s.NoError(err)
+	s.Len(entries, 2)
+	s.Equal(s.transfer.Postings[0], entries[0].Posting)
+	s.Equal(s.transfer.Postings[3], entries[1].Posting)
+	s.Equal(s.transfer.ID, entries[1].Transfer.ID)
+	s.Nil(entries[1].Transfer.Postings)
+
+	entries, err = s.factory.LedgerRepository().GetAll(context.Background(),
+		repository.Page{AfterID: s.transfer.Postings[0].ID, Limit: 10})
+	s.NoError(err)
+	s.Len(entries, 1)
+}
+
+func (s *ledgerRepositoryTestSuite) TestGetByAccountSucceeded() {
+	createdAt := s.transfer.CreatedAt
+	s.storeTransfer(100, createdAt)
+	s.storeTransfer(200, createdAt.Add(time.Hour))
+	s.storeTransfer(300, createdAt.Add(2*time.Hour))
+
+	minAmount, maxAmount := int64(150), int64(250)
+	from, to := createdAt.Add(time.Minute), createdAt.Add(2*time.Hour)
+	filters := []struct {
+		filter repository.PaymentFilter
+		count  int
+	}{
+		{repository.PaymentFilter{AccountUID: "toshik1978"}, 3},
+		{repository.PaymentFilter{AccountUID: "toshik1980"}, 0},
+		{repository.PaymentFilter{AccountUID: "toshik1978", CounterpartyUID: "toshik1979"}, 3},
+		{repository.PaymentFilter{AccountUID: "toshik1978", CounterpartyUID: "toshik1978"}, 0},
+		{repository.PaymentFilter{AccountUID: "toshik1978", Direction: repository.OutgoingDirection}, 3},
+		{repository.PaymentFilter{AccountUID: "toshik1978", Direction: repository.IncomingDirection}, 0},
+		{repository.PaymentFilter{AccountUID: "toshik1979", Direction: repository.IncomingDirection}, 3},
+		{repository.PaymentFilter{AccountUID: "toshik1978", From: &from, To: &to}, 1},
+		{repository.PaymentFilter{AccountUID: "toshik1979", MinAmount: &minAmount}, 2},
+		{repository.PaymentFilter{AccountUID: "toshik1979", MinAmount: &minAmount, MaxAmount: &maxAmount}, 1},
+	}
+	for _, f := range filters {
+		entries, err := s.factory.LedgerRepository().GetByAccount(context.Background(),
+			f.filter, repository.Page{Limit: 10})
+		s.NoError(err)
+		s.Len(entries, f.count, "%+v", f.filter)
+	}
+
+	entries, err := s.factory.LedgerRepository().GetByAccount(context.Background(),
+		repository.PaymentFilter{AccountUID: "toshik1978"}, repository.Page{Limit: 2})
+	s.NoError(err)
+	s.Len(entries, 2)
+	s.Equal(int64(-100), entries[0].Posting.Amount)
+}
@@ -0,0 +1,22 @@
+package memoryengine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestMemoryEngine(t *testing.T) {
+	suite.Run(t, new(storeTestSuite))
+	suite.Run(t, new(repositoryFactoryTestSuite))
+	suite.Run(t, new(scopeTestSuite))
+	suite.Run(t, new(accountRepositoryTestSuite))
+	suite.Run(t, new(ledgerRepositoryTestSuite))
+	suite.Run(t, new(reconciliationRepositoryTestSuite))
+	suite.Run(t, new(idempotencyRepositoryTestSuite))
+	suite.Run(t, new(outboxRepositoryTestSuite))
+	suite.Run(t, new(webhookRepositoryTestSuite))
+	suite.Run(t, new(deliveryRepositoryTestSuite))
+	suite.Run(t, new(scheduleRepositoryTestSuite))
+	suite.Run(t, new(scheduleRunRepositoryTestSuite))
+}
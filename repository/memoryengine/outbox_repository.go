@@ -0,0 +1,73 @@
+package memoryengine
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/repository"
+)
+
+// outboxRepository implements OutboxRepository interface
+type outboxRepository struct {
+	store *store
+}
+
+// newOutboxRepository creates new outbox repository
+func newOutboxRepository(store *store) repository.OutboxRepository {
+	return &outboxRepository{
+		store: store,
+	}
+}
+
+func (r *outboxRepository) Store(ctx context.Context, event *repository.Event) error {
+	return r.store.update(ctx, func(d *data) error {
+		if err := checkSize("type", string(event.Type), eventTypeSize); err != nil {
+			return err
+		}
+
+		event.ID = next(&r.store.sequences.events)
+		d.events[event.ID] = *event
+		return nil
+	})
+}
+
+func (r *outboxRepository) GetPending(ctx context.Context, now time.Time, limit int) ([]repository.Event, error) {
+	var events []repository.Event
+	err := r.store.view(ctx, func(d *data) error {
+		for _, event := range d.events {
+			if !event.NextAttemptAt.After(now) {
+				events = append(events, event)
+			}
+		}
+		sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+		if len(events) > limit {
+			events = events[:limit]
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *outboxRepository) MarkDispatched(ctx context.Context, id int64, dispatchedAt time.Time) error {
+	// Nothing reads dispatched events, so they're deleted to not copy them in every transaction
+	return r.store.update(ctx, func(d *data) error {
+		delete(d.events, id)
+		return nil
+	})
+}
+
+func (r *outboxRepository) MarkFailed(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time) error {
+	return r.store.update(ctx, func(d *data) error {
+		if event, ok := d.events[id]; ok {
+			event.Attempts++
+			event.LastError = lastError
+			event.NextAttemptAt = nextAttemptAt
+			d.events[id] = event
+		}
+		return nil
+	})
+}
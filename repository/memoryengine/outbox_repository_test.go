@@ -0,0 +1,76 @@
+package memoryengine
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service/testutil"
+	"github.com/stretchr/testify/suite"
+)
+
+type outboxRepositoryTestSuite struct {
+	suite.Suite
+
+	repository repository.OutboxRepository
+	event      repository.Event
+}
+
+func (s *outboxRepositoryTestSuite) SetupTest() {
+	s.repository = newOutboxRepository(newStore())
+	s.event = testutil.RepositoryEvent()
+}
+
+func (s *outboxRepositoryTestSuite) TestStoreEventTooLongFailed() {
+	event := s.event
+	event.Type = repository.EventType(strings.Repeat("a", eventTypeSize+1))
+	err := s.repository.Store(context.Background(), &event)
+
+	s.Error(err)
+}
+
+func (s *outboxRepositoryTestSuite) TestGetPendingEventsSucceeded() {
+	first, second := s.event, s.event
+	second.NextAttemptAt = s.event.NextAttemptAt.Add(time.Minute)
+	s.Require().NoError(s.repository.Store(context.Background(), &first))
+	s.Require().NoError(s.repository.Store(context.Background(), &second))
+	s.NotEqual(first.ID, second.ID)
+
+	// Event isn't returned before its next attempt
+	events, err := s.repository.GetPending(context.Background(), s.event.CreatedAt, 10)
+	s.NoError(err)
+	s.Equal([]repository.Event{first}, events)
+
+	events, err = s.repository.GetPending(context.Background(), second.NextAttemptAt, 1)
+	s.NoError(err)
+	s.Equal([]repository.Event{first}, events)
+}
+
+func (s *outboxRepositoryTestSuite) TestMarkEventDispatchedSucceeded() {
+	s.Require().NoError(s.repository.Store(context.Background(), &s.event))
+
+	s.NoError(s.repository.MarkDispatched(context.Background(), s.event.ID, s.event.CreatedAt))
+
+	events, err := s.repository.GetPending(context.Background(), s.event.CreatedAt, 10)
+	s.NoError(err)
+	s.Empty(events)
+}
+
+func (s *outboxRepositoryTestSuite) TestMarkEventFailedSucceeded() {
+	s.Require().NoError(s.repository.Store(context.Background(), &s.event))
+
+	nextAttemptAt := s.event.CreatedAt.Add(time.Minute)
+	s.NoError(s.repository.MarkFailed(context.Background(), s.event.ID, "fail", nextAttemptAt))
+
+	events, err := s.repository.GetPending(context.Background(), s.event.CreatedAt, 10)
+	s.NoError(err)
+	s.Empty(events)
+
+	events, err = s.repository.GetPending(context.Background(), nextAttemptAt, 10)
+	s.NoError(err)
+	s.Require().Len(events, 1)
+	s.Equal(1, events[0].Attempts)
+	s.Equal("fail", events[0].LastError)
+	s.Equal(nextAttemptAt, events[0].NextAttemptAt)
+}
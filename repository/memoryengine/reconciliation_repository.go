@@ -0,0 +1,69 @@
+package memoryengine
+
+import (
+	"context"
+	"sort"
+
+	"github.com/Toshik1978/go-rest-api/repository"
+)
+
+// reconciliationRepository implements ReconciliationRepository interface
+type reconciliationRepository struct {
+	store *store
+}
+
+// newReconciliationRepository creates new reconciliation repository
+func newReconciliationRepository(store *store) repository.ReconciliationRepository {
+	return &reconciliationRepository{
+		store: store,
+	}
+}
+
+func (r *reconciliationRepository) GetDrifts(ctx context.Context) ([]repository.Drift, error) {
+	var drifts []repository.Drift
+	err := r.store.view(ctx, func(d *data) error {
+		sums := make(map[string]int64)
+		for _, posting := range d.postings {
+			sums[posting.AccountUID] += posting.Amount
+		}
+		for _, account := range d.accounts {
+			expected := account.OpeningBalance + sums[account.UID]
+			if account.Balance != expected {
+				drifts = append(drifts, repository.Drift{
+					AccountUID:      account.UID,
+					Currency:        account.Currency,
+					ExpectedBalance: expected,
+					ActualBalance:   account.Balance,
+				})
+			}
+		}
+		sort.Slice(drifts, func(i, j int) bool { return drifts[i].AccountUID < drifts[j].AccountUID })
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return drifts, nil
+}
+
+func (r *reconciliationRepository) StoreAdjustment(ctx context.Context, adjustment *repository.Adjustment) error {
+	return r.store.update(ctx, func(d *data) error {
+		if err := checkSize("reason", adjustment.Reason, reasonSize); err != nil {
+			return err
+		}
+		// Adjustment is recorded only if account is actually updated
+		account, ok := d.accounts[adjustment.AccountUID]
+		if !ok {
+			return repository.ErrAccountNotFound
+		}
+		if account.Balance+adjustment.Amount < 0 {
+			return repository.ErrInsufficientFunds
+		}
+
+		account.Balance += adjustment.Amount
+		d.accounts[account.UID] = account
+		adjustment.ID = next(&r.store.sequences.adjustments)
+		d.adjustments = append(d.adjustments, *adjustment)
+		return nil
+	})
+}
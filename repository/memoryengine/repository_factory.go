@@ -0,0 +1,84 @@
+package memoryengine
+
+import (
+	"context"
+
+	"github.com/Toshik1978/go-rest-api/repository"
+)
+
+// repositoryFactory implements RepositoryFactory interface
+type repositoryFactory struct {
+	store                    *store
+	accountRepository        repository.AccountRepository
+	ledgerRepository         repository.LedgerRepository
+	reconciliationRepository repository.ReconciliationRepository
+	idempotencyRepository    repository.IdempotencyRepository
+	outboxRepository         repository.OutboxRepository
+	webhookRepository        repository.WebhookRepository
+	deliveryRepository       repository.DeliveryRepository
+	scheduleRepository       repository.ScheduleRepository
+	scheduleRunRepository    repository.ScheduleRunRepository
+}
+
+// NewRepositoryFactory creates factory of repositories, which keep data in memory.
+// Data is lost, when the factory is gone
+func NewRepositoryFactory() repository.Factory {
+	store := newStore()
+	return &repositoryFactory{
+		store:                    store,
+		accountRepository:        newAccountRepository(store),
+		ledgerRepository:         newLedgerRepository(store),
+		reconciliationRepository: newReconciliationRepository(store),
+		idempotencyRepository:    newIdempotencyRepository(store),
+		outboxRepository:         newOutboxRepository(store),
+		webhookRepository:        newWebhookRepository(store),
+		deliveryRepository:       newDeliveryRepository(store),
+		scheduleRepository:       newScheduleRepository(store),
+		scheduleRunRepository:    newScheduleRunRepository(store),
+	}
+}
+
+func (f *repositoryFactory) Scope(options ...repository.ScopeOption) repository.Scope {
+	return newScope(f.store, options...)
+}
+
+func (f *repositoryFactory) Retry(ctx context.Context, work func(ctx context.Context) error) error {
+	// Scopes are run one after another, so they never fail because of each other
+	return work(ctx)
+}
+
+func (f *repositoryFactory) AccountRepository() repository.AccountRepository {
+	return f.accountRepository
+}
+
+func (f *repositoryFactory) LedgerRepository() repository.LedgerRepository {
+	return f.ledgerRepository
+}
+
+func (f *repositoryFactory) ReconciliationRepository() repository.ReconciliationRepository {
+	return f.reconciliationRepository
+}
+
+func (f *repositoryFactory) IdempotencyRepository() repository.IdempotencyRepository {
+	return f.idempotencyRepository
+}
+
+func (f *repositoryFactory) OutboxRepository() repository.OutboxRepository {
+	return f.outboxRepository
+}
+
+func (f *repositoryFactory) WebhookRepository() repository.WebhookRepository {
+	return f.webhookRepository
+}
+
+func (f *repositoryFactory) DeliveryRepository() repository.DeliveryRepository {
+	return f.deliveryRepository
+}
+
+func (f *repositoryFactory) ScheduleRepository() repository.ScheduleRepository {
+	return f.scheduleRepository
+}
+
+func (f *repositoryFactory) ScheduleRunRepository() repository.ScheduleRunRepository {
+	return f.scheduleRunRepository
+}
@@ -0,0 +1,46 @@
+package memoryengine
+
+import (
+	"context"
+	"errors"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type repositoryFactoryTestSuite struct {
+	suite.Suite
+}
+
+func (s *repositoryFactoryTestSuite) TestCreateScopeSucceeded() {
+	factory := NewRepositoryFactory()
+	scope := factory.Scope()
+
+	s.NotNil(scope)
+}
+
+func (s *repositoryFactoryTestSuite) TestGetRepositoriesSucceeded() {
+	factory := NewRepositoryFactory()
+
+	s.Equal(factory.(*repositoryFactory).accountRepository, factory.AccountRepository())
+	s.Equal(factory.(*repositoryFactory).ledgerRepository, factory.LedgerRepository())
+	s.Equal(factory.(*repositoryFactory).reconciliationRepository, factory.ReconciliationRepository())
+	s.Equal(factory.(*repositoryFactory).idempotencyRepository, factory.IdempotencyRepository())
+	s.Equal(factory.(*repositoryFactory).outboxRepository, factory.OutboxRepository())
+	s.Equal(factory.(*repositoryFactory).webhookRepository, factory.WebhookRepository())
+	s.Equal(factory.(*repositoryFactory).deliveryRepository, factory.DeliveryRepository())
+	s.Equal(factory.(*repositoryFactory).scheduleRepository, factory.ScheduleRepository())
+	s.Equal(factory.(*repositoryFactory).scheduleRunRepository, factory.ScheduleRunRepository())
+}
+
+func (s *repositoryFactoryTestSuite) TestRetryRunsOnceSucceeded() {
+	factory := NewRepositoryFactory()
+
+	attempts := 0
+	err := factory.Retry(context.Background(), func(ctx context.Context) error {
+		attempts++
+		return errors.New("fail")
+	})
+
+	s.Error(err)
+	s.Equal(1, attempts)
+}
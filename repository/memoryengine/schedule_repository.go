@@ -0,0 +1,136 @@
+package memoryengine
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/repository"
+)
+
+// scheduleRepository implements ScheduleRepository interface
+type scheduleRepository struct {
+	store *store
+}
+
+// newScheduleRepository creates new schedule repository
+func newScheduleRepository(store *store) repository.ScheduleRepository {
+	return &scheduleRepository{
+		store: store,
+	}
+}
+
+func (r *scheduleRepository) GetByAccount(ctx context.Context,
+	accountUID string, page repository.Page) ([]repository.Schedule, error) {
+
+	var schedules []repository.Schedule
+	err := r.store.view(ctx, func(d *data) error {
+		for _, schedule := range d.schedules {
+			if schedule.PayerAccountUID == accountUID && schedule.ID > page.AfterID {
+				schedules = append(schedules, schedule)
+			}
+		}
+		sort.Slice(schedules, func(i, j int) bool { return schedules[i].ID < schedules[j].ID })
+		if len(schedules) > page.Limit {
+			schedules = schedules[:page.Limit]
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+func (r *scheduleRepository) GetByID(ctx context.Context, id int64) (*repository.Schedule, error) {
+	var schedule repository.Schedule
+	err := r.store.view(ctx, func(d *data) error {
+		var ok bool
+		if schedule, ok = d.schedules[id]; !ok {
+			return repository.ErrScheduleNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func (r *scheduleRepository) GetDue(ctx context.Context, now time.Time, limit int) ([]repository.Schedule, error) {
+	var schedules []repository.Schedule
+	err := r.store.view(ctx, func(d *data) error {
+		for _, schedule := range d.schedules {
+			if schedule.Status == repository.ActiveSchedule && !schedule.NextRunAt.After(now) {
+				schedules = append(schedules, schedule)
+			}
+		}
+		sort.Slice(schedules, func(i, j int) bool { return schedules[i].ID < schedules[j].ID })
+		if len(schedules) > limit {
+			schedules = schedules[:limit]
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+func (r *scheduleRepository) Store(ctx context.Context, schedule *repository.Schedule) error {
+	return r.store.update(ctx, func(d *data) error {
+		if err := checkSize("currency", schedule.Currency, currencySize); err != nil {
+			return err
+		}
+		if err := checkSize("cron", schedule.Cron, cronSize); err != nil {
+			return err
+		}
+		if err := checkSchedule(*schedule); err != nil {
+			return err
+		}
+		for _, uid := range []string{schedule.PayerAccountUID, schedule.RecipientAccountUID} {
+			if _, ok := d.accounts[uid]; !ok {
+				return repository.ErrAccountNotFound
+			}
+		}
+
+		schedule.ID = next(&r.store.sequences.schedules)
+		d.schedules[schedule.ID] = *schedule
+		return nil
+	})
+}
+
+func (r *scheduleRepository) Advance(ctx context.Context, schedule *repository.Schedule, from time.Time) error {
+	return r.store.update(ctx, func(d *data) error {
+		if err := checkSchedule(*schedule); err != nil {
+			return err
+		}
+		stored, ok := d.schedules[schedule.ID]
+		if !ok || stored.Status != repository.ActiveSchedule || !stored.NextRunAt.Equal(from) {
+			return repository.ErrScheduleChanged
+		}
+
+		stored.Status = schedule.Status
+		stored.NextRunAt = schedule.NextRunAt
+		stored.UpdatedAt = schedule.UpdatedAt
+		d.schedules[schedule.ID] = stored
+		return nil
+	})
+}
+
+func (r *scheduleRepository) Cancel(ctx context.Context, id int64, cancelledAt time.Time) error {
+	return r.store.update(ctx, func(d *data) error {
+		stored, ok := d.schedules[id]
+		if !ok {
+			return repository.ErrScheduleNotFound
+		}
+		if stored.Status != repository.ActiveSchedule {
+			return nil
+		}
+
+		stored.Status = repository.CancelledSchedule
+		stored.UpdatedAt = cancelledAt
+		d.schedules[id] = stored
+		return nil
+	})
+}
@@ -0,0 +1,136 @@
+package memoryengine
+
+import (
+	"context"
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service/testutil"
+	"github.com/stretchr/testify/suite"
+)
+
+type scheduleRepositoryTestSuite struct {
+	suite.Suite
+
+	repository repository.ScheduleRepository
+	schedule   repository.Schedule
+}
+
+func (s *scheduleRepositoryTestSuite) SetupTest() {
+	store := newStore()
+	for _, uid := range []string{"toshik1978", "toshik1979"} {
+		account := testutil.RepositoryAccount()
+		account.UID = uid
+		s.Require().NoError(newAccountRepository(store).Store(context.Background(), &account))
+	}
+
+	s.repository = newScheduleRepository(store)
+	s.schedule = testutil.RepositorySchedule()
+}
+
+func (s *scheduleRepositoryTestSuite) TestStoreScheduleInvalidFailed() {
+	schedule := s.schedule
+	schedule.Recurrence = "yearly"
+	s.Error(s.repository.Store(context.Background(), &schedule))
+
+	schedule = s.schedule
+	schedule.Amount = 0
+	s.Error(s.repository.Store(context.Background(), &schedule))
+}
+
+func (s *scheduleRepositoryTestSuite) TestStoreScheduleAccountNotFoundFailed() {
+	schedule := s.schedule
+	schedule.RecipientAccountUID = "unknown"
+	err := s.repository.Store(context.Background(), &schedule)
+
+	s.Equal(repository.ErrAccountNotFound, err)
+}
+
+func (s *scheduleRepositoryTestSuite) TestStoreScheduleSucceeded() {
+	s.Require().NoError(s.repository.Store(context.Background(), &s.schedule))
+
+	schedule, err := s.repository.GetByID(context.Background(), s.schedule.ID)
+	s.NoError(err)
+	s.Equal(&s.schedule, schedule)
+
+	schedules, err := s.repository.GetByAccount(context.Background(), "toshik1978", repository.Page{Limit: 10})
+	s.NoError(err)
+	s.Equal([]repository.Schedule{s.schedule}, schedules)
+
+	// Schedules are listed by payer
+	schedules, err = s.repository.GetByAccount(context.Background(), "toshik1979", repository.Page{Limit: 10})
+	s.NoError(err)
+	s.Empty(schedules)
+}
+
+func (s *scheduleRepositoryTestSuite) TestGetScheduleNotFoundFailed() {
+	schedule, err := s.repository.GetByID(context.Background(), 1)
+
+	s.Equal(repository.ErrScheduleNotFound, err)
+	s.Nil(schedule)
+}
+
+func (s *scheduleRepositoryTestSuite) TestGetDueSchedulesSucceeded() {
+	first, second, third := s.schedule, s.schedule, s.schedule
+	second.NextRunAt = s.schedule.NextRunAt.Add(time.Minute)
+	third.Status = repository.CancelledSchedule
+	s.Require().NoError(s.repository.Store(context.Background(), &first))
+	s.Require().NoError(s.repository.Store(context.Background(), &second))
+	s.Require().NoError(s.repository.Store(context.Background(), &third))
+
+	schedules, err := s.repository.GetDue(context.Background(), s.schedule.NextRunAt, 10)
+	s.NoError(err)
+	s.Equal([]repository.Schedule{first}, schedules)
+
+	schedules, err = s.repository.GetDue(context.Background(), second.NextRunAt, 1)
+	s.NoError(err)
+	s.Equal([]repository.Schedule{first}, schedules)
+}
+
+func (s *scheduleRepositoryTestSuite) TestAdvanceScheduleChangedFailed() {
+	s.Require().NoError(s.repository.Store(context.Background(), &s.schedule))
+
+	advanced := s.schedule
+	advanced.NextRunAt = s.schedule.NextRunAt.AddDate(0, 1, 0)
+	s.Require().NoError(s.repository.Advance(context.Background(), &advanced, s.schedule.NextRunAt))
+
+	// The same occurrence can't be claimed twice
+	err := s.repository.Advance(context.Background(), &advanced, s.schedule.NextRunAt)
+	s.Equal(repository.ErrScheduleChanged, err)
+}
+
+func (s *scheduleRepositoryTestSuite) TestAdvanceScheduleSucceeded() {
+	s.Require().NoError(s.repository.Store(context.Background(), &s.schedule))
+
+	advanced := s.schedule
+	advanced.NextRunAt = s.schedule.NextRunAt.AddDate(0, 1, 0)
+	advanced.UpdatedAt = s.schedule.UpdatedAt.Add(time.Minute)
+	s.NoError(s.repository.Advance(context.Background(), &advanced, s.schedule.NextRunAt))
+
+	schedule, err := s.repository.GetByID(context.Background(), s.schedule.ID)
+	s.NoError(err)
+	s.Equal(&advanced, schedule)
+}
+
+func (s *scheduleRepositoryTestSuite) TestCancelScheduleNotFoundFailed() {
+	err := s.repository.Cancel(context.Background(), 1, time.Now())
+
+	s.Equal(repository.ErrScheduleNotFound, err)
+}
+
+func (s *scheduleRepositoryTestSuite) TestCancelScheduleSucceeded() {
+	s.Require().NoError(s.repository.Store(context.Background(), &s.schedule))
+	cancelledAt := s.schedule.UpdatedAt.Add(time.Minute)
+	s.NoError(s.repository.Cancel(context.Background(), s.schedule.ID, cancelledAt))
+	// Repeated cancel does nothing
+	s.NoError(s.repository.Cancel(context.Background(), s.schedule.ID, cancelledAt.Add(time.Minute)))
+
+	schedule, err := s.repository.GetByID(context.Background(), s.schedule.ID)
+	s.NoError(err)
+	s.Equal(repository.CancelledSchedule, schedule.Status)
+	s.Equal(cancelledAt, schedule.UpdatedAt)
+
+	// Cancelled schedule can't be advanced
+	err = s.repository.Advance(context.Background(), schedule, schedule.NextRunAt)
+	s.Equal(repository.ErrScheduleChanged, err)
+}
@@ -0,0 +1,112 @@
+package memoryengine
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/repository"
+)
+
+// scheduleRunRepository implements ScheduleRunRepository interface
+type scheduleRunRepository struct {
+	store *store
+}
+
+// newScheduleRunRepository creates new schedule run repository
+func newScheduleRunRepository(store *store) repository.ScheduleRunRepository {
+	return &scheduleRunRepository{
+		store: store,
+	}
+}
+
+func (r *scheduleRunRepository) GetBySchedule(ctx context.Context,
+	scheduleID int64, page repository.Page) ([]repository.ScheduleRun, error) {
+
+	var runs []repository.ScheduleRun
+	err := r.store.view(ctx, func(d *data) error {
+		for _, run := range d.runs {
+			if run.ScheduleID == scheduleID && run.ID > page.AfterID {
+				runs = append(runs, run)
+			}
+		}
+		sort.Slice(runs, func(i, j int) bool { return runs[i].ID < runs[j].ID })
+		if len(runs) > page.Limit {
+			runs = runs[:page.Limit]
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+func (r *scheduleRunRepository) GetPending(ctx context.Context,
+	now time.Time, limit int) ([]repository.ScheduleRun, error) {
+
+	var runs []repository.ScheduleRun
+	err := r.store.view(ctx, func(d *data) error {
+		for _, run := range d.runs {
+			if run.Status == repository.PendingRun && !run.NextAttemptAt.After(now) {
+				runs = append(runs, run)
+			}
+		}
+		sort.Slice(runs, func(i, j int) bool { return runs[i].ID < runs[j].ID })
+		if len(runs) > limit {
+			runs = runs[:limit]
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+func (r *scheduleRunRepository) Store(ctx context.Context, run *repository.ScheduleRun) error {
+	return r.store.update(ctx, func(d *data) error {
+		if err := checkRunStatus(run.Status); err != nil {
+			return err
+		}
+		if _, ok := d.schedules[run.ScheduleID]; !ok {
+			return repository.ErrScheduleNotFound
+		}
+		if _, ok := d.runKeys[runKey(*run)]; ok {
+			return repository.ErrScheduleRunExists
+		}
+
+		run.ID = next(&r.store.sequences.runs)
+		d.runs[run.ID] = *run
+		d.runKeys[runKey(*run)] = run.ID
+		return nil
+	})
+}
+
+func (r *scheduleRunRepository) Update(ctx context.Context, run *repository.ScheduleRun) error {
+	return r.store.update(ctx, func(d *data) error {
+		if err := checkRunStatus(run.Status); err != nil {
+			return err
+		}
+		stored, ok := d.runs[run.ID]
+		if !ok {
+			return repository.ErrScheduleRunNotFound
+		}
+
+		stored.Status = run.Status
+		stored.Attempts = run.Attempts
+		stored.NextAttemptAt = run.NextAttemptAt
+		stored.Error = run.Error
+		stored.UpdatedAt = run.UpdatedAt
+		d.runs[run.ID] = stored
+		return nil
+	})
+}
+
+// runKey return unique key of the run. Time is compared as instant, so location doesn't matter
+func runKey(run repository.ScheduleRun) scheduleOccurrence {
+	return scheduleOccurrence{
+		scheduleID:  run.ScheduleID,
+		scheduledAt: run.ScheduledAt.UnixNano(),
+	}
+}
@@ -0,0 +1,110 @@
+package memoryengine
+
+import (
+	"context"
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service/testutil"
+	"github.com/stretchr/testify/suite"
+)
+
+type scheduleRunRepositoryTestSuite struct {
+	suite.Suite
+
+	repository repository.ScheduleRunRepository
+	run        repository.ScheduleRun
+}
+
+func (s *scheduleRunRepositoryTestSuite) SetupTest() {
+	store := newStore()
+	for _, uid := range []string{"toshik1978", "toshik1979"} {
+		account := testutil.RepositoryAccount()
+		account.UID = uid
+		s.Require().NoError(newAccountRepository(store).Store(context.Background(), &account))
+	}
+	schedule := testutil.RepositorySchedule()
+	s.Require().NoError(newScheduleRepository(store).Store(context.Background(), &schedule))
+
+	s.repository = newScheduleRunRepository(store)
+	s.run = testutil.RepositoryScheduleRun()
+	s.run.ScheduleID = schedule.ID
+}
+
+func (s *scheduleRunRepositoryTestSuite) TestStoreRunUnknownStatusFailed() {
+	run := s.run
+	run.Status = "unknown"
+	err := s.repository.Store(context.Background(), &run)
+
+	s.Error(err)
+}
+
+func (s *scheduleRunRepositoryTestSuite) TestStoreRunScheduleNotFoundFailed() {
+	run := s.run
+	run.ScheduleID++
+	err := s.repository.Store(context.Background(), &run)
+
+	s.Equal(repository.ErrScheduleNotFound, err)
+}
+
+func (s *scheduleRunRepositoryTestSuite) TestStoreRunExistsFailed() {
+	first, second := s.run, s.run
+	s.Require().NoError(s.repository.Store(context.Background(), &first))
+	err := s.repository.Store(context.Background(), &second)
+
+	s.Equal(repository.ErrScheduleRunExists, err)
+}
+
+func (s *scheduleRunRepositoryTestSuite) TestStoreRunSucceeded() {
+	s.Require().NoError(s.repository.Store(context.Background(), &s.run))
+
+	runs, err := s.repository.GetBySchedule(context.Background(), s.run.ScheduleID, repository.Page{Limit: 10})
+	s.NoError(err)
+	s.Equal([]repository.ScheduleRun{s.run}, runs)
+
+	runs, err = s.repository.GetBySchedule(context.Background(), s.run.ScheduleID+1, repository.Page{Limit: 10})
+	s.NoError(err)
+	s.Empty(runs)
+}
+
+func (s *scheduleRunRepositoryTestSuite) TestGetPendingRunsSucceeded() {
+	first, second, third := s.run, s.run, s.run
+	second.ScheduledAt = s.run.ScheduledAt.Add(time.Minute)
+	second.NextAttemptAt = s.run.NextAttemptAt.Add(time.Minute)
+	third.ScheduledAt = s.run.ScheduledAt.Add(2 * time.Minute)
+	third.Status = repository.SucceededRun
+	s.Require().NoError(s.repository.Store(context.Background(), &first))
+	s.Require().NoError(s.repository.Store(context.Background(), &second))
+	s.Require().NoError(s.repository.Store(context.Background(), &third))
+
+	// Run isn't returned before its next attempt
+	runs, err := s.repository.GetPending(context.Background(), s.run.NextAttemptAt, 10)
+	s.NoError(err)
+	s.Equal([]repository.ScheduleRun{first}, runs)
+
+	runs, err = s.repository.GetPending(context.Background(), second.NextAttemptAt, 10)
+	s.NoError(err)
+	s.Equal([]repository.ScheduleRun{first, second}, runs)
+}
+
+func (s *scheduleRunRepositoryTestSuite) TestUpdateRunNotFoundFailed() {
+	err := s.repository.Update(context.Background(), &s.run)
+
+	s.Equal(repository.ErrScheduleRunNotFound, err)
+}
+
+func (s *scheduleRunRepositoryTestSuite) TestUpdateRunSucceeded() {
+	s.Require().NoError(s.repository.Store(context.Background(), &s.run))
+
+	updated := s.run
+	updated.Status = repository.SkippedRun
+	updated.Attempts = 3
+	updated.Error = "insufficient funds"
+	updated.NextAttemptAt = s.run.NextAttemptAt.Add(time.Minute)
+	updated.UpdatedAt = s.run.UpdatedAt.Add(time.Minute)
+	s.NoError(s.repository.Update(context.Background(), &updated))
+
+	runs, err := s.repository.GetBySchedule(context.Background(), s.run.ScheduleID, repository.Page{Limit: 10})
+	s.NoError(err)
+	s.Equal([]repository.ScheduleRun{updated}, runs)
+}
@@ -0,0 +1,77 @@
+package memoryengine
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service/errutil"
+)
+
+// scope implements repository.Scope interface. Scope, started inside of another one, is nested:
+// it uses savepoint in the outer scope's transaction instead of the new transaction
+type scope struct {
+	store   *store
+	options repository.ScopeOptions
+
+	savepoint *data
+	done      bool
+}
+
+// newScope creates new instance of repository.Scope interface
+func newScope(store *store, options ...repository.ScopeOption) repository.Scope {
+	s := &scope{
+		store: store,
+	}
+	for _, option := range options {
+		option(&s.options)
+	}
+	return s
+}
+
+func (s *scope) WithContext(ctx context.Context) (context.Context, error) {
+	if tx := transactionFromContext(ctx); tx != nil {
+		s.savepoint = tx.savepoint()
+		return ctx, nil
+	}
+
+	// Transactions are run one after another, so any known isolation level is satisfied
+	switch s.options.Isolation {
+	case repository.DefaultIsolation, repository.ReadCommitted, repository.RepeatableRead, repository.Serializable:
+	default:
+		return nil, errors.New("unknown isolation level")
+	}
+	tx, err := s.store.begin(ctx)
+	if err != nil {
+		return nil, errutil.Wrap(err, "failed to start transaction")
+	}
+	return contextWithTransaction(ctx, tx), nil
+}
+
+func (s *scope) Complete(ctx context.Context) error {
+	tx := transactionFromContext(ctx)
+	if tx == nil || s.savepoint != nil {
+		// Changes of nested scope are kept till the outer scope is finished
+		s.done = true
+		return nil
+	}
+	tx.commit()
+	return nil
+}
+
+func (s *scope) Cancel(ctx context.Context) error {
+	tx := transactionFromContext(ctx)
+	if tx == nil {
+		return nil
+	}
+	if s.savepoint != nil {
+		// Only the first call is actually done, outer scope can change data after rollback
+		if !s.done {
+			s.done = true
+			tx.rollbackTo(s.savepoint)
+		}
+		return nil
+	}
+	tx.rollback()
+	return nil
+}
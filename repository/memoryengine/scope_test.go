@@ -0,0 +1,151 @@
+package memoryengine
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service/testutil"
+	"github.com/stretchr/testify/suite"
+)
+
+type scopeTestSuite struct {
+	suite.Suite
+
+	factory repository.Factory
+	account repository.Account
+}
+
+func (s *scopeTestSuite) SetupTest() {
+	s.factory = NewRepositoryFactory()
+	s.account = testutil.RepositoryAccount()
+}
+
+// storeAccount stores account with the given UID in the given context
+func (s *scopeTestSuite) storeAccount(ctx context.Context, uid string) {
+	account := s.account
+	account.UID = uid
+	s.Require().NoError(s.factory.AccountRepository().Store(ctx, &account))
+}
+
+// accountExists checks, if committed account with the given UID exists
+func (s *scopeTestSuite) accountExists(uid string) bool {
+	_, err := s.factory.AccountRepository().GetByUID(context.Background(), uid)
+	return err == nil
+}
+
+func (s *scopeTestSuite) TestScopeWithContextIsolationFailed() {
+	scope := s.factory.Scope(repository.WithIsolation(repository.IsolationLevel(100)))
+	ctx, err := scope.WithContext(context.Background())
+
+	s.Error(err)
+	s.Nil(ctx)
+}
+
+func (s *scopeTestSuite) TestScopeWithContextTimeoutFailed() {
+	scope := s.factory.Scope()
+	ctx, err := scope.WithContext(context.Background())
+	s.Require().NoError(err)
+	defer func() { _ = scope.Cancel(ctx) }()
+
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	concurrent := s.factory.Scope()
+	concurrentCtx, err := concurrent.WithContext(timeoutCtx)
+
+	s.True(errors.Is(err, context.DeadlineExceeded))
+	s.Nil(concurrentCtx)
+}
+
+func (s *scopeTestSuite) TestScopeCompleteSucceeded() {
+	scope := s.factory.Scope(repository.WithIsolation(repository.Serializable))
+	ctx, err := scope.WithContext(context.Background())
+	s.Require().NoError(err)
+
+	s.storeAccount(ctx, "toshik1978")
+	s.False(s.accountExists("toshik1978"))
+
+	s.NoError(scope.Complete(ctx))
+	s.NoError(scope.Cancel(ctx))
+	s.True(s.accountExists("toshik1978"))
+}
+
+func (s *scopeTestSuite) TestScopeCancelSucceeded() {
+	scope := s.factory.Scope()
+	ctx, err := scope.WithContext(context.Background())
+	s.Require().NoError(err)
+
+	s.storeAccount(ctx, "toshik1978")
+
+	s.NoError(scope.Cancel(ctx))
+	s.NoError(scope.Complete(ctx))
+	s.False(s.accountExists("toshik1978"))
+
+	// Cancelled scope doesn't block the next one
+	next := s.factory.Scope()
+	nextCtx, err := next.WithContext(context.Background())
+	s.Require().NoError(err)
+	s.NoError(next.Complete(nextCtx))
+}
+
+func (s *scopeTestSuite) TestScopeNoTransactionSucceeded() {
+	scope := s.factory.Scope()
+
+	s.NoError(scope.Complete(context.Background()))
+	s.NoError(scope.Cancel(context.Background()))
+}
+
+func (s *scopeTestSuite) TestScopeCancelNestedSucceeded() {
+	outer := s.factory.Scope()
+	ctx, err := outer.WithContext(context.Background())
+	s.Require().NoError(err)
+	s.storeAccount(ctx, "toshik1978")
+
+	nested := s.factory.Scope()
+	nestedCtx, err := nested.WithContext(ctx)
+	s.Require().NoError(err)
+	s.storeAccount(nestedCtx, "toshik1979")
+	s.NoError(nested.Cancel(nestedCtx))
+
+	// Outer scope's changes after rollback to savepoint aren't reverted by repeated cancel
+	s.storeAccount(ctx, "toshik1980")
+	s.NoError(nested.Cancel(nestedCtx))
+	s.NoError(outer.Complete(ctx))
+
+	s.True(s.accountExists("toshik1978"))
+	s.False(s.accountExists("toshik1979"))
+	s.True(s.accountExists("toshik1980"))
+}
+
+func (s *scopeTestSuite) TestScopeCompleteNestedSucceeded() {
+	outer := s.factory.Scope()
+	ctx, err := outer.WithContext(context.Background())
+	s.Require().NoError(err)
+
+	nested := s.factory.Scope()
+	nestedCtx, err := nested.WithContext(ctx)
+	s.Require().NoError(err)
+	s.storeAccount(nestedCtx, "toshik1979")
+	s.NoError(nested.Complete(nestedCtx))
+	s.NoError(nested.Cancel(nestedCtx))
+	s.False(s.accountExists("toshik1979"))
+
+	s.NoError(outer.Complete(ctx))
+	s.True(s.accountExists("toshik1979"))
+}
+
+func (s *scopeTestSuite) TestScopeCancelOuterSucceeded() {
+	outer := s.factory.Scope()
+	ctx, err := outer.WithContext(context.Background())
+	s.Require().NoError(err)
+
+	nested := s.factory.Scope()
+	nestedCtx, err := nested.WithContext(ctx)
+	s.Require().NoError(err)
+	s.storeAccount(nestedCtx, "toshik1979")
+	s.NoError(nested.Complete(nestedCtx))
+	s.NoError(outer.Cancel(ctx))
+
+	s.False(s.accountExists("toshik1979"))
+}
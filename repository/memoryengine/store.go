@@ -0,0 +1,273 @@
+package memoryengine
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Toshik1978/go-rest-api/repository"
+)
+
+// idempotencyID define primary key of idempotency key
+type idempotencyID struct {
+	operation string
+	key       string
+}
+
+// webhookEvent define unique key of delivery: the event is delivered to the webhook once
+type webhookEvent struct {
+	webhookID int64
+	eventID   int64
+}
+
+// scheduleOccurrence define unique key of schedule's run: the occurrence of the schedule is run once
+type scheduleOccurrence struct {
+	scheduleID  int64
+	scheduledAt int64
+}
+
+// data define tables of the storage. Committed data is never changed, transaction changes its own copy
+type data struct {
+	accounts        map[string]repository.Account
+	transitions     []repository.AccountTransition
+	transfers       []repository.Transfer
+	postings        []repository.Posting
+	refunds         map[int64]int64
+	adjustments     []repository.Adjustment
+	idempotencyKeys map[idempotencyID]repository.IdempotencyKey
+	events          map[int64]repository.Event
+	webhooks        map[int64]repository.Webhook
+	deliveries      map[int64]repository.Delivery
+	deliveryKeys    map[webhookEvent]int64
+	attempts        []repository.DeliveryAttempt
+	schedules       map[int64]repository.Schedule
+	runs            map[int64]repository.ScheduleRun
+	runKeys         map[scheduleOccurrence]int64
+}
+
+// newData creates empty tables
+func newData() *data {
+	return &data{
+		accounts:        make(map[string]repository.Account),
+		refunds:         make(map[int64]int64),
+		idempotencyKeys: make(map[idempotencyID]repository.IdempotencyKey),
+		events:          make(map[int64]repository.Event),
+		webhooks:        make(map[int64]repository.Webhook),
+		deliveries:      make(map[int64]repository.Delivery),
+		deliveryKeys:    make(map[webhookEvent]int64),
+		schedules:       make(map[int64]repository.Schedule),
+		runs:            make(map[int64]repository.ScheduleRun),
+		runKeys:         make(map[scheduleOccurrence]int64),
+	}
+}
+
+// clone copies tables. Rows of slices are only appended, so slices are shared with capacity limited by length,
+// and the first append to the copy reallocates it
+func (d *data) clone() *data {
+	accounts := make(map[string]repository.Account, len(d.accounts))
+	for uid, account := range d.accounts {
+		accounts[uid] = account
+	}
+	refunds := make(map[int64]int64, len(d.refunds))
+	for id, amount := range d.refunds {
+		refunds[id] = amount
+	}
+	idempotencyKeys := make(map[idempotencyID]repository.IdempotencyKey, len(d.idempotencyKeys))
+	for id, key := range d.idempotencyKeys {
+		idempotencyKeys[id] = key
+	}
+	events := make(map[int64]repository.Event, len(d.events))
+	for id, event := range d.events {
+		events[id] = event
+	}
+	webhooks := make(map[int64]repository.Webhook, len(d.webhooks))
+	for id, webhook := range d.webhooks {
+		webhooks[id] = webhook
+	}
+	deliveries := make(map[int64]repository.Delivery, len(d.deliveries))
+	for id, delivery := range d.deliveries {
+		deliveries[id] = delivery
+	}
+	deliveryKeys := make(map[webhookEvent]int64, len(d.deliveryKeys))
+	for key, id := range d.deliveryKeys {
+		deliveryKeys[key] = id
+	}
+	schedules := make(map[int64]repository.Schedule, len(d.schedules))
+	for id, schedule := range d.schedules {
+		schedules[id] = schedule
+	}
+	runs := make(map[int64]repository.ScheduleRun, len(d.runs))
+	for id, run := range d.runs {
+		runs[id] = run
+	}
+	runKeys := make(map[scheduleOccurrence]int64, len(d.runKeys))
+	for key, id := range d.runKeys {
+		runKeys[key] = id
+	}
+	return &data{
+		accounts:        accounts,
+		transitions:     d.transitions[:len(d.transitions):len(d.transitions)],
+		transfers:       d.transfers[:len(d.transfers):len(d.transfers)],
+		postings:        d.postings[:len(d.postings):len(d.postings)],
+		refunds:         refunds,
+		adjustments:     d.adjustments[:len(d.adjustments):len(d.adjustments)],
+		idempotencyKeys: idempotencyKeys,
+		events:          events,
+		webhooks:        webhooks,
+		deliveries:      deliveries,
+		deliveryKeys:    deliveryKeys,
+		attempts:        d.attempts[:len(d.attempts):len(d.attempts)],
+		schedules:       schedules,
+		runs:            runs,
+		runKeys:         runKeys,
+	}
+}
+
+// sequences define generators of IDs. Like PostgreSQL's sequences they aren't rolled back
+type sequences struct {
+	accounts    int64
+	transitions int64
+	transfers   int64
+	postings    int64
+	adjustments int64
+	events      int64
+	webhooks    int64
+	deliveries  int64
+	attempts    int64
+	schedules   int64
+	runs        int64
+}
+
+// next return the next ID of the sequence
+func next(sequence *int64) int64 {
+	return atomic.AddInt64(sequence, 1)
+}
+
+// store define in-memory storage. Transactions are run one after another, so every isolation level is satisfied,
+// and reads outside of transaction see the last committed data
+type store struct {
+	mu        sync.RWMutex
+	committed *data
+	sequences sequences
+
+	// writer is held by the running transaction
+	writer chan struct{}
+}
+
+// newStore creates empty storage
+func newStore() *store {
+	return &store{
+		committed: newData(),
+		writer:    make(chan struct{}, 1),
+	}
+}
+
+// begin starts new transaction, it waits while another transaction is running
+func (s *store) begin(ctx context.Context) (*transaction, error) {
+	select {
+	case s.writer <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return &transaction{
+		store: s,
+		data:  s.committed,
+	}, nil
+}
+
+// view runs read-only operation with data of the context's transaction or with committed data
+func (s *store) view(ctx context.Context, operation func(d *data) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if tx := transactionFromContext(ctx); tx != nil {
+		return operation(tx.data)
+	}
+
+	s.mu.RLock()
+	committed := s.committed
+	s.mu.RUnlock()
+	return operation(committed)
+}
+
+// update runs operation with data of the context's transaction or in the new transaction, which is committed,
+// if operation succeeded. Operation should check constraints before it changes data
+func (s *store) update(ctx context.Context, operation func(d *data) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if tx := transactionFromContext(ctx); tx != nil {
+		return operation(tx.write())
+	}
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return err
+	}
+	// Rollback is safe after commit
+	defer tx.rollback()
+
+	if err := operation(tx.write()); err != nil {
+		return err
+	}
+	tx.commit()
+	return nil
+}
+
+// transaction define running transaction of the storage. Data is copied on the first write,
+// so rollback just drops the copy
+type transaction struct {
+	store *store
+	data  *data
+	owned bool
+	done  bool
+}
+
+// write return data to change, it's copied, if it's shared with committed data or savepoint
+func (t *transaction) write() *data {
+	if !t.owned {
+		t.data = t.data.clone()
+		t.owned = true
+	}
+	return t.data
+}
+
+// savepoint return data to restore by rollbackTo, next write copies data again
+func (t *transaction) savepoint() *data {
+	t.owned = false
+	return t.data
+}
+
+// rollbackTo drops changes made after the savepoint
+func (t *transaction) rollbackTo(savepoint *data) {
+	if t.done {
+		return
+	}
+	t.data = savepoint
+	t.owned = false
+}
+
+// commit makes transaction's changes visible and finishes transaction
+func (t *transaction) commit() {
+	if t.done {
+		return
+	}
+	t.done = true
+
+	t.store.mu.Lock()
+	t.store.committed = t.data
+	t.store.mu.Unlock()
+	<-t.store.writer
+}
+
+// rollback drops transaction's changes and finishes transaction
+func (t *transaction) rollback() {
+	if t.done {
+		return
+	}
+	t.done = true
+	<-t.store.writer
+}
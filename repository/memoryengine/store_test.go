@@ -0,0 +1,92 @@
+package memoryengine
+
+import (
+	"context"
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service/testutil"
+	"github.com/stretchr/testify/suite"
+)
+
+type storeTestSuite struct {
+	suite.Suite
+}
+
+func (s *storeTestSuite) TestCloneCopyOnWriteSucceeded() {
+	original := newData()
+	original.accounts["toshik1978"] = testutil.RepositoryAccount()
+	original.postings = make([]repository.Posting, 1, 10)
+
+	clone := original.clone()
+	account := clone.accounts["toshik1978"]
+	account.Balance = 0
+	clone.accounts["toshik1978"] = account
+	clone.postings = append(clone.postings, repository.Posting{ID: 2})
+	clone.postings[0].ID = 1
+
+	s.Equal(int64(10000), original.accounts["toshik1978"].Balance)
+	s.Len(original.postings, 1)
+	s.Equal(int64(0), original.postings[0].ID)
+}
+
+func (s *storeTestSuite) TestBeginWaitsForTransactionFailed() {
+	store := newStore()
+	tx, err := store.begin(context.Background())
+	s.Require().NoError(err)
+	defer tx.rollback()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = store.begin(ctx)
+
+	s.Equal(context.DeadlineExceeded, err)
+}
+
+func (s *storeTestSuite) TestBeginAfterCommitSucceeded() {
+	store := newStore()
+	tx, err := store.begin(context.Background())
+	s.Require().NoError(err)
+	tx.write().accounts["toshik1978"] = testutil.RepositoryAccount()
+	tx.commit()
+	tx.rollback()
+
+	tx, err = store.begin(context.Background())
+	s.Require().NoError(err)
+	defer tx.rollback()
+
+	s.Contains(tx.data.accounts, "toshik1978")
+}
+
+func (s *storeTestSuite) TestRollbackSucceeded() {
+	store := newStore()
+	tx, err := store.begin(context.Background())
+	s.Require().NoError(err)
+	tx.write().accounts["toshik1978"] = testutil.RepositoryAccount()
+	tx.rollback()
+	tx.commit()
+
+	s.Empty(store.committed.accounts)
+}
+
+func (s *storeTestSuite) TestUpdateWithoutTransactionFailed() {
+	store := newStore()
+	err := store.update(context.Background(), func(d *data) error {
+		d.accounts["toshik1978"] = testutil.RepositoryAccount()
+		return repository.ErrAccountExists
+	})
+
+	s.Equal(repository.ErrAccountExists, err)
+	s.Empty(store.committed.accounts)
+}
+
+func (s *storeTestSuite) TestViewCancelledFailed() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := newStore().view(ctx, func(d *data) error {
+		s.Fail("operation is not expected")
+		return nil
+	})
+
+	s.Equal(context.Canceled, err)
+}
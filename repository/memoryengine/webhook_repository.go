@@ -0,0 +1,134 @@
+package memoryengine
+
+import (
+	"context"
+	"sort"
+
+	"github.com/Toshik1978/go-rest-api/repository"
+)
+
+// webhookRepository implements WebhookRepository interface
+type webhookRepository struct {
+	store *store
+}
+
+// newWebhookRepository creates new webhook repository
+func newWebhookRepository(store *store) repository.WebhookRepository {
+	return &webhookRepository{
+		store: store,
+	}
+}
+
+func (r *webhookRepository) GetAll(ctx context.Context, page repository.Page) ([]repository.Webhook, error) {
+	var webhooks []repository.Webhook
+	err := r.store.view(ctx, func(d *data) error {
+		for _, webhook := range d.webhooks {
+			if webhook.ID > page.AfterID {
+				webhooks = append(webhooks, webhook)
+			}
+		}
+		sort.Slice(webhooks, func(i, j int) bool { return webhooks[i].ID < webhooks[j].ID })
+		if len(webhooks) > page.Limit {
+			webhooks = webhooks[:page.Limit]
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (r *webhookRepository) GetByID(ctx context.Context, id int64) (*repository.Webhook, error) {
+	var webhook repository.Webhook
+	err := r.store.view(ctx, func(d *data) error {
+		var ok bool
+		if webhook, ok = d.webhooks[id]; !ok {
+			return repository.ErrWebhookNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (r *webhookRepository) GetMatching(ctx context.Context,
+	eventType repository.EventType, accountUIDs []string) ([]repository.Webhook, error) {
+
+	var webhooks []repository.Webhook
+	err := r.store.view(ctx, func(d *data) error {
+		for _, webhook := range d.webhooks {
+			if webhook.EventType != "" && webhook.EventType != eventType {
+				continue
+			}
+			if webhook.AccountUID == "" || containsUID(accountUIDs, webhook.AccountUID) {
+				webhooks = append(webhooks, webhook)
+			}
+		}
+		sort.Slice(webhooks, func(i, j int) bool { return webhooks[i].ID < webhooks[j].ID })
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (r *webhookRepository) Store(ctx context.Context, webhook *repository.Webhook) error {
+	return r.store.update(ctx, func(d *data) error {
+		if err := checkSize("secret", webhook.Secret, secretSize); err != nil {
+			return err
+		}
+		if err := checkSize("event_type", string(webhook.EventType), eventTypeSize); err != nil {
+			return err
+		}
+		if err := checkSize("account_uid", webhook.AccountUID, uidSize); err != nil {
+			return err
+		}
+
+		webhook.ID = next(&r.store.sequences.webhooks)
+		d.webhooks[webhook.ID] = *webhook
+		return nil
+	})
+}
+
+func (r *webhookRepository) Delete(ctx context.Context, id int64) error {
+	return r.store.update(ctx, func(d *data) error {
+		if _, ok := d.webhooks[id]; !ok {
+			return repository.ErrWebhookNotFound
+		}
+		delete(d.webhooks, id)
+
+		// Deliveries and their attempts are deleted on cascade
+		deleted := make(map[int64]bool)
+		for deliveryID, delivery := range d.deliveries {
+			if delivery.WebhookID == id {
+				deleted[deliveryID] = true
+				delete(d.deliveries, deliveryID)
+				delete(d.deliveryKeys, deliveryKey(delivery))
+			}
+		}
+		if len(deleted) > 0 {
+			attempts := make([]repository.DeliveryAttempt, 0, len(d.attempts))
+			for _, attempt := range d.attempts {
+				if !deleted[attempt.DeliveryID] {
+					attempts = append(attempts, attempt)
+				}
+			}
+			d.attempts = attempts[:len(attempts):len(attempts)]
+		}
+		return nil
+	})
+}
+
+// containsUID checks, if UID is in the list
+func containsUID(uids []string, uid string) bool {
+	for _, u := range uids {
+		if u == uid {
+			return true
+		}
+	}
+	return false
+}
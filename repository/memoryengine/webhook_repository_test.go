@@ -0,0 +1,102 @@
+package memoryengine
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service/testutil"
+	"github.com/stretchr/testify/suite"
+)
+
+type webhookRepositoryTestSuite struct {
+	suite.Suite
+
+	store      *store
+	repository repository.WebhookRepository
+	webhook    repository.Webhook
+}
+
+func (s *webhookRepositoryTestSuite) SetupTest() {
+	s.store = newStore()
+	s.repository = newWebhookRepository(s.store)
+	s.webhook = testutil.RepositoryWebhook()
+}
+
+func (s *webhookRepositoryTestSuite) TestStoreWebhookTooLongFailed() {
+	webhook := s.webhook
+	webhook.Secret = strings.Repeat("a", secretSize+1)
+	err := s.repository.Store(context.Background(), &webhook)
+
+	s.Error(err)
+}
+
+func (s *webhookRepositoryTestSuite) TestGetWebhookNotFoundFailed() {
+	webhook, err := s.repository.GetByID(context.Background(), 1)
+
+	s.Equal(repository.ErrWebhookNotFound, err)
+	s.Nil(webhook)
+}
+
+func (s *webhookRepositoryTestSuite) TestStoreWebhookSucceeded() {
+	s.Require().NoError(s.repository.Store(context.Background(), &s.webhook))
+
+	webhook, err := s.repository.GetByID(context.Background(), s.webhook.ID)
+	s.NoError(err)
+	s.Equal(&s.webhook, webhook)
+
+	webhooks, err := s.repository.GetAll(context.Background(), repository.Page{Limit: 10})
+	s.NoError(err)
+	s.Equal([]repository.Webhook{s.webhook}, webhooks)
+
+	webhooks, err = s.repository.GetAll(context.Background(), repository.Page{AfterID: s.webhook.ID, Limit: 10})
+	s.NoError(err)
+	s.Empty(webhooks)
+}
+
+func (s *webhookRepositoryTestSuite) TestGetMatchingWebhooksSucceeded() {
+	anyWebhook, other, account := s.webhook, s.webhook, s.webhook
+	anyWebhook.EventType = ""
+	anyWebhook.AccountUID = ""
+	other.EventType = repository.AccountCreatedEvent
+	account.AccountUID = "toshik1979"
+	s.Require().NoError(s.repository.Store(context.Background(), &anyWebhook))
+	s.Require().NoError(s.repository.Store(context.Background(), &other))
+	s.Require().NoError(s.repository.Store(context.Background(), &account))
+
+	webhooks, err := s.repository.GetMatching(context.Background(),
+		repository.PaymentCompletedEvent, []string{"toshik1978", "toshik1979"})
+	s.NoError(err)
+	s.Equal([]repository.Webhook{anyWebhook, account}, webhooks)
+
+	webhooks, err = s.repository.GetMatching(context.Background(), repository.AccountCreatedEvent, nil)
+	s.NoError(err)
+	s.Equal([]repository.Webhook{anyWebhook}, webhooks)
+}
+
+func (s *webhookRepositoryTestSuite) TestDeleteWebhookNotFoundFailed() {
+	err := s.repository.Delete(context.Background(), 1)
+
+	s.Equal(repository.ErrWebhookNotFound, err)
+}
+
+func (s *webhookRepositoryTestSuite) TestDeleteWebhookSucceeded() {
+	deliveryRepository := newDeliveryRepository(s.store)
+	s.Require().NoError(s.repository.Store(context.Background(), &s.webhook))
+	delivery := testutil.RepositoryDelivery()
+	delivery.WebhookID = s.webhook.ID
+	s.Require().NoError(deliveryRepository.Store(context.Background(), &delivery))
+	attempt := repository.DeliveryAttempt{DeliveryID: delivery.ID, CreatedAt: delivery.CreatedAt}
+	s.Require().NoError(deliveryRepository.StoreAttempt(context.Background(), &attempt))
+
+	s.NoError(s.repository.Delete(context.Background(), s.webhook.ID))
+
+	// Deliveries and attempts are deleted with webhook
+	_, err := s.repository.GetByID(context.Background(), s.webhook.ID)
+	s.Equal(repository.ErrWebhookNotFound, err)
+	_, err = deliveryRepository.GetByID(context.Background(), delivery.ID)
+	s.Equal(repository.ErrDeliveryNotFound, err)
+	attempts, err := deliveryRepository.GetAttempts(context.Background(), delivery.ID)
+	s.NoError(err)
+	s.Empty(attempts)
+}
@@ -2,34 +2,185 @@ package repository
 
 import (
 	"context"
+	"time"
 )
 
 //go:generate mockgen -source repository.go -package mock -destination ../mock/repository.go
 
 // AccountRepository declare repository for accounts
 type AccountRepository interface {
-	// GetAll return all accounts in storage
-	GetAll(ctx context.Context) ([]Account, error)
+	// GetAll return page of accounts in storage ordered by ID. IDs are allocated on insert, not on commit,
+	// so account, which insert is in flight while the page after its ID is read, may be skipped
+	GetAll(ctx context.Context, page Page) ([]Account, error)
+	// GetByUID return account with the given UID. ErrAccountNotFound returned, if there is no such account
+	GetByUID(ctx context.Context, uid string) (*Account, error)
+	// LockByUIDs locks accounts with the given UIDs till the end of the scope and return them ordered by UID.
+	// Accounts are always locked in UID order, so concurrent scopes can't deadlock. Missing accounts are skipped
+	LockByUIDs(ctx context.Context, uids []string) ([]Account, error)
 	// Store save new account in storage
 	Store(ctx context.Context, account *Account) error
-	// Update balance for given account by incrementing on given value
+	// Update balance for given account by incrementing on given value.
+	// ErrAccountNotActive returned, if account is not active
 	UpdateBalance(ctx context.Context, uid string, incr int64) error
+	// UpdateStatus changes account's status and records the transition. Status is changed only if account
+	// still has FromStatus and has zero balance for closing, ErrAccountChanged returned otherwise
+	UpdateStatus(ctx context.Context, transition *AccountTransition) error
 }
 
-// PaymentRepository declare repository for payments
-type PaymentRepository interface {
-	// GetAll return all payments in storage
-	GetAll(ctx context.Context) ([]Payment, error)
-	// Store save new payment in storage
-	Store(ctx context.Context, payment *Payment) error
+// LedgerRepository declare repository for double-entry ledger of transfers
+type LedgerRepository interface {
+	// GetAll return page of payers' and recipients' postings with their transfers ordered by posting's ID.
+	// IDs are allocated on insert, not on commit, so posting, which insert is in flight while the page after its ID
+	// is read, may be skipped. GetByAccount skips nothing, because account's postings are inserted under its lock
+	GetAll(ctx context.Context, page Page) ([]Entry, error)
+	// GetByAccount return page of account's postings with their transfers, matched filter, ordered by posting's ID
+	GetByAccount(ctx context.Context, filter PaymentFilter, page Page) ([]Entry, error)
+	// GetByID return transfer with the given ID without its postings. ErrTransferNotFound returned,
+	// if there is no such transfer
+	GetByID(ctx context.Context, id int64) (*Transfer, error)
+	// Store save new transfer with its postings in storage. ErrUnbalancedTransfer returned, if postings
+	// are not balanced, ErrAccountNotFound returned, if there is no payer's or recipient's account.
+	// Refunded transfer of the refund should be stored already
+	Store(ctx context.Context, transfer *Transfer) error
+}
+
+// ReconciliationRepository declare repository for reconciliation of accounts' balances with the ledger
+type ReconciliationRepository interface {
+	// GetDrifts return all accounts, which balance isn't equal to opening balance plus sum of account's postings,
+	// ordered by UID
+	GetDrifts(ctx context.Context) ([]Drift, error)
+	// StoreAdjustment save adjustment in storage and increments account's balance by adjustment's amount.
+	// Balance is adjusted regardless of account's status. ErrAccountNotFound returned, if there is no such account
+	StoreAdjustment(ctx context.Context, adjustment *Adjustment) error
+}
+
+// IdempotencyRepository declare repository for idempotency keys
+type IdempotencyRepository interface {
+	// Get return idempotency key of the given operation, which is not expired at the given time.
+	// nil returned, if there is no such key
+	Get(ctx context.Context, operation string, key string, now time.Time) (*IdempotencyKey, error)
+	// Store save new idempotency key in storage, expired key with the same name is replaced.
+	// ErrIdempotencyKeyExists returned, if the same key is already stored and not expired
+	Store(ctx context.Context, key *IdempotencyKey) error
+}
+
+// OutboxRepository declare repository for outbox of domain events
+type OutboxRepository interface {
+	// Store save new event in the outbox. Event should be stored in the scope of the change it describes
+	Store(ctx context.Context, event *Event) error
+	// GetPending return up to limit events, which aren't dispatched yet and which next attempt is due
+	// at the given time, ordered by ID
+	GetPending(ctx context.Context, now time.Time, limit int) ([]Event, error)
+	// MarkDispatched marks event as dispatched, so it's never returned as pending again
+	MarkDispatched(ctx context.Context, id int64, dispatchedAt time.Time) error
+	// MarkFailed counts failed attempt to dispatch event, records its error and postpones the next attempt
+	MarkFailed(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time) error
+}
+
+// WebhookRepository declare repository for webhooks
+type WebhookRepository interface {
+	// GetAll return page of webhooks in storage ordered by ID
+	GetAll(ctx context.Context, page Page) ([]Webhook, error)
+	// GetByID return webhook with the given ID. ErrWebhookNotFound returned, if there is no such webhook
+	GetByID(ctx context.Context, id int64) (*Webhook, error)
+	// GetMatching return webhooks, subscribed to the event of the given type, which concerns the given accounts,
+	// ordered by ID
+	GetMatching(ctx context.Context, eventType EventType, accountUIDs []string) ([]Webhook, error)
+	// Store save new webhook in storage
+	Store(ctx context.Context, webhook *Webhook) error
+	// Delete removes webhook with all its deliveries. ErrWebhookNotFound returned, if there is no such webhook
+	Delete(ctx context.Context, id int64) error
+}
+
+// DeliveryRepository declare repository for deliveries of events to webhooks
+type DeliveryRepository interface {
+	// GetByWebhook return page of webhook's deliveries ordered by ID
+	GetByWebhook(ctx context.Context, webhookID int64, page Page) ([]Delivery, error)
+	// GetByID return delivery with the given ID. ErrDeliveryNotFound returned, if there is no such delivery
+	GetByID(ctx context.Context, id int64) (*Delivery, error)
+	// GetPending return up to limit pending deliveries, which next attempt is due at the given time, ordered by ID
+	GetPending(ctx context.Context, now time.Time, limit int) ([]Delivery, error)
+	// GetAttempts return all attempts of the delivery ordered by ID
+	GetAttempts(ctx context.Context, deliveryID int64) ([]DeliveryAttempt, error)
+	// Store save new delivery in storage. ErrDeliveryExists returned, if the event is already delivered
+	// to the webhook, ErrWebhookNotFound returned, if there is no such webhook
+	Store(ctx context.Context, delivery *Delivery) error
+	// Update save delivery's status, attempts and time of the next attempt.
+	// ErrDeliveryNotFound returned, if there is no such delivery
+	Update(ctx context.Context, delivery *Delivery) error
+	// StoreAttempt save attempt of the delivery in storage. ErrDeliveryNotFound returned, if there is no such delivery
+	StoreAttempt(ctx context.Context, attempt *DeliveryAttempt) error
+}
+
+// ScheduleRepository declare repository for scheduled payments
+type ScheduleRepository interface {
+	// GetByAccount return page of schedules, which payer is the given account, ordered by ID
+	GetByAccount(ctx context.Context, accountUID string, page Page) ([]Schedule, error)
+	// GetByID return schedule with the given ID. ErrScheduleNotFound returned, if there is no such schedule
+	GetByID(ctx context.Context, id int64) (*Schedule, error)
+	// GetDue return up to limit active schedules, which next run is due at the given time, ordered by ID
+	GetDue(ctx context.Context, now time.Time, limit int) ([]Schedule, error)
+	// Store save new schedule in storage. ErrAccountNotFound returned, if there is no payer's or recipient's account
+	Store(ctx context.Context, schedule *Schedule) error
+	// Advance save schedule's time of the next run and status. Schedule is changed only if it's still active
+	// and its next run is still at the given time, ErrScheduleChanged returned otherwise
+	Advance(ctx context.Context, schedule *Schedule, from time.Time) error
+	// Cancel changes status of the active schedule to cancelled. ErrScheduleNotFound returned,
+	// if there is no such schedule
+	Cancel(ctx context.Context, id int64, cancelledAt time.Time) error
+}
+
+// ScheduleRunRepository declare repository for runs of scheduled payments
+type ScheduleRunRepository interface {
+	// GetBySchedule return page of schedule's runs ordered by ID
+	GetBySchedule(ctx context.Context, scheduleID int64, page Page) ([]ScheduleRun, error)
+	// GetPending return up to limit pending runs, which next attempt is due at the given time, ordered by ID
+	GetPending(ctx context.Context, now time.Time, limit int) ([]ScheduleRun, error)
+	// Store save new run in storage. ErrScheduleRunExists returned, if the schedule's occurrence is already run,
+	// ErrScheduleNotFound returned, if there is no such schedule
+	Store(ctx context.Context, run *ScheduleRun) error
+	// Update save run's status, attempts, error and time of the next attempt.
+	// ErrScheduleRunNotFound returned, if there is no such run
+	Update(ctx context.Context, run *ScheduleRun) error
 }
 
 // Repository pattern and transactions are not very good combination, so here we are declare some scope.
 // It has semantic of unit of work, calling code should not know about nature of scope,
 // but code can cancel or complete it.
 
+// IsolationLevel define transaction isolation level of the scope
+type IsolationLevel int
+
+const (
+	// DefaultIsolation is default isolation level of the storage
+	DefaultIsolation IsolationLevel = iota
+	// ReadCommitted isolation level: every operation sees data committed before it's started
+	ReadCommitted
+	// RepeatableRead isolation level: every operation sees data committed before the scope is started
+	RepeatableRead
+	// Serializable isolation level: scopes behave as if they were run one after another
+	Serializable
+)
+
+// ScopeOptions define options of the scope
+type ScopeOptions struct {
+	Isolation IsolationLevel
+}
+
+// ScopeOption define single option of the scope
+type ScopeOption func(options *ScopeOptions)
+
+// WithIsolation creates option to start scope with the given isolation level
+func WithIsolation(level IsolationLevel) ScopeOption {
+	return func(options *ScopeOptions) {
+		options.Isolation = level
+	}
+}
+
 // Scope define some operation context for repository operations (unit of work)
-// It's safe to call Cancel/Complete multiple times, only the first one will be actually done
+// It's safe to call Cancel/Complete multiple times, only the first one will be actually done.
+// Scope started with context of another scope is nested: its Cancel reverts only nested scope's changes
+// and its Complete keeps them till the outer scope is completed. Nested scope uses outer scope's isolation level
 type Scope interface {
 	// WithContext initializes scope with context and return new context to use in repository's operations
 	WithContext(ctx context.Context) (context.Context, error)
@@ -43,10 +194,28 @@ type Scope interface {
 // Factory define accessors to all repositories
 type Factory interface {
 	// Context creates new scope for repository activities
-	Scope() Scope
+	Scope(options ...ScopeOption) Scope
+	// Retry runs unit of work and re-runs it, while it fails because of concurrent scopes
+	// (serialization failure or deadlock). Number of attempts is bounded, the last error is returned.
+	// Unit of work should start its own scope, so every attempt starts from scratch
+	Retry(ctx context.Context, work func(ctx context.Context) error) error
 
 	// AccountRepository return account repository instance
 	AccountRepository() AccountRepository
-	// PaymentRepository return payment repository instance
-	PaymentRepository() PaymentRepository
+	// LedgerRepository return ledger repository instance
+	LedgerRepository() LedgerRepository
+	// ReconciliationRepository return reconciliation repository instance
+	ReconciliationRepository() ReconciliationRepository
+	// IdempotencyRepository return idempotency key repository instance
+	IdempotencyRepository() IdempotencyRepository
+	// OutboxRepository return outbox repository instance
+	OutboxRepository() OutboxRepository
+	// WebhookRepository return webhook repository instance
+	WebhookRepository() WebhookRepository
+	// DeliveryRepository return delivery repository instance
+	DeliveryRepository() DeliveryRepository
+	// ScheduleRepository return schedule repository instance
+	ScheduleRepository() ScheduleRepository
+	// ScheduleRunRepository return schedule run repository instance
+	ScheduleRunRepository() ScheduleRunRepository
 }
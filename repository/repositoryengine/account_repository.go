@@ -2,6 +2,8 @@ package repositoryengine
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 
 	"github.com/Toshik1978/go-rest-api/repository"
 	"github.com/jmoiron/sqlx"
@@ -9,53 +11,133 @@ import (
 
 const (
 	getAllAccountsSQL = `
-		SELECT id, uid, currency, balance, created_at
-		FROM accounts`
+		SELECT id, uid, currency, balance, opening_balance, status, status_changed_at, created_at
+		FROM accounts
+		WHERE id > ?
+		ORDER BY id
+		LIMIT ?`
+	getAccountByUIDSQL = `
+		SELECT id, uid, currency, balance, opening_balance, status, status_changed_at, created_at
+		FROM accounts
+		WHERE uid = ?`
+	// Rows are locked by the dialect's clause in the sort order, so the order is the same for all transactions
+	lockAccountsByUIDsSQL = `
+		SELECT id, uid, currency, balance, opening_balance, status, status_changed_at, created_at
+		FROM accounts
+		WHERE uid IN (?)
+		ORDER BY uid`
 
 	storeAccountSQL = `
 		INSERT INTO accounts
-			(uid, currency, balance, created_at)
+			(uid, currency, balance, opening_balance, status, status_changed_at, created_at)
 		VALUES
-			(:uid, :currency, :balance, :created_at)`
+			(:uid, :currency, :balance, :opening_balance, :status, :status_changed_at, :created_at)`
 	updateBalanceSQL = `
 		UPDATE accounts
-		SET balance = balance + $2
-		WHERE uid = $1`
+		SET balance = balance + ?
+		WHERE uid = ? AND status = 'active'`
+	// Transition is recorded only if account is actually updated
+	updateStatusSQL = `
+		WITH updated AS (
+			UPDATE accounts
+			SET status = :to_status, status_changed_at = :created_at
+			WHERE uid = :account_uid AND status = :from_status AND (:to_status <> 'closed' OR balance = 0)
+			RETURNING uid, status, status_changed_at)
+		INSERT INTO account_transitions
+			(account_uid, from_status, to_status, created_at)
+		SELECT uid, :from_status, status, status_changed_at
+		FROM updated`
+	// Transition is recorded by trigger only if account is actually updated
+	updateStatusByTriggerSQL = `
+		UPDATE accounts
+		SET status = :to_status, status_changed_at = :created_at
+		WHERE uid = :account_uid AND status = :from_status AND (:to_status <> 'closed' OR balance = 0)`
 )
 
 // accountRepository implements AccountRepository interface
 type accountRepository struct {
-	ext sqlx.Ext
+	ext     sqlx.ExtContext
+	dialect *dialect
 }
 
 // newAccountRepository creates new account repository
-func newAccountRepository(ext sqlx.Ext) repository.AccountRepository {
+func newAccountRepository(ext sqlx.ExtContext, dialect *dialect) repository.AccountRepository {
 	return &accountRepository{
-		ext: ext,
+		ext:     ext,
+		dialect: dialect,
 	}
 }
 
-func (r *accountRepository) GetAll(ctx context.Context) ([]repository.Account, error) {
+func (r *accountRepository) GetAll(ctx context.Context, page repository.Page) ([]repository.Account, error) {
 	var accounts []repository.Account
-	if err := sqlx.Select(sqlxExt(ctx, r.ext), &accounts, getAllAccountsSQL); err != nil {
+	err := r.dialect.selectContext(ctx, sqlxExt(ctx, r.ext), &accounts, getAllAccountsSQL, page.AfterID, page.Limit)
+	if err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+func (r *accountRepository) GetByUID(ctx context.Context, uid string) (*repository.Account, error) {
+	var account repository.Account
+	err := r.dialect.getContext(ctx, sqlxExt(ctx, r.ext), &account, getAccountByUIDSQL, uid)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.ErrAccountNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (r *accountRepository) LockByUIDs(ctx context.Context, uids []string) ([]repository.Account, error) {
+	query, args, err := sqlx.In(lockAccountsByUIDsSQL+r.dialect.lockRows, uids)
+	if err != nil {
+		return nil, err
+	}
+	var accounts []repository.Account
+	if err := r.dialect.selectContext(ctx, sqlxExt(ctx, r.ext), &accounts, query, args...); err != nil {
 		return nil, err
 	}
 	return accounts, nil
 }
 
 func (r *accountRepository) Store(ctx context.Context, account *repository.Account) error {
-	res, err := sqlx.NamedExec(sqlxExt(ctx, r.ext), storeAccountSQL, account)
+	err := r.dialect.namedInsert(ctx, sqlxExt(ctx, r.ext), storeAccountSQL, account, &account.ID)
+	if r.dialect.isViolation(err, uniqueViolation) {
+		return repository.ErrAccountExists
+	}
+	return err
+}
+
+func (r *accountRepository) UpdateBalance(ctx context.Context, uid string, incr int64) error {
+	res, err := r.dialect.execContext(ctx, sqlxExt(ctx, r.ext), updateBalanceSQL, incr, uid)
+	if r.dialect.isViolation(err, checkViolation) {
+		return repository.ErrInsufficientFunds
+	}
 	if err != nil {
 		return err
 	}
-	account.ID, _ = res.LastInsertId()
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return repository.ErrAccountNotActive
+	}
 	return nil
 }
 
-func (r *accountRepository) UpdateBalance(ctx context.Context, uid string, incr int64) error {
-	_, err := sqlxExt(ctx, r.ext).Exec(updateBalanceSQL, uid, incr)
+func (r *accountRepository) UpdateStatus(ctx context.Context, transition *repository.AccountTransition) error {
+	res, err := r.dialect.namedExec(ctx, sqlxExt(ctx, r.ext), r.dialect.updateStatusSQL, transition)
 	if err != nil {
 		return err
 	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return repository.ErrAccountChanged
+	}
 	return nil
 }
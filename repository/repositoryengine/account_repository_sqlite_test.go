@@ -0,0 +1,176 @@
+package repositoryengine
+
+import (
+	"context"
+
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service"
+	"github.com/Toshik1978/go-rest-api/service/testutil"
+	"github.com/stretchr/testify/suite"
+)
+
+type sqliteAccountRepositoryTestSuite struct {
+	suite.Suite
+
+	client     service.DBClient
+	repository repository.AccountRepository
+	account    repository.Account
+}
+
+func (s *sqliteAccountRepositoryTestSuite) SetupTest() {
+	s.client = newSQLiteTestClient(s.T())
+	s.repository = newAccountRepository(s.client.GetConnection(), sqliteDialect)
+	s.account = testutil.RepositoryAccount()
+}
+
+func (s *sqliteAccountRepositoryTestSuite) TearDownTest() {
+	s.client.Stop()
+}
+
+// storeAccounts stores accounts with the given UIDs
+func (s *sqliteAccountRepositoryTestSuite) storeAccounts(uids ...string) {
+	for _, uid := range uids {
+		account := s.account
+		account.UID = uid
+		s.Require().NoError(s.repository.Store(context.Background(), &account))
+	}
+}
+
+func (s *sqliteAccountRepositoryTestSuite) TestGetAllAccountsEmptySucceeded() {
+	accounts, err := s.repository.GetAll(context.Background(), repository.Page{Limit: 10})
+
+	s.NoError(err)
+	s.Empty(accounts)
+}
+
+func (s *sqliteAccountRepositoryTestSuite) TestGetAllAccountsSucceeded() {
+	s.storeAccounts("toshik1980", "toshik1978", "toshik1979")
+
+	accounts, err := s.repository.GetAll(context.Background(), repository.Page{Limit: 2})
+	s.NoError(err)
+	s.Len(accounts, 2)
+	s.Equal("toshik1980", accounts[0].UID)
+	s.Equal("toshik1978", accounts[1].UID)
+
+	accounts, err = s.repository.GetAll(context.Background(), repository.Page{AfterID: accounts[1].ID, Limit: 2})
+	s.NoError(err)
+	s.Len(accounts, 1)
+	s.Equal("toshik1979", accounts[0].UID)
+}
+
+func (s *sqliteAccountRepositoryTestSuite) TestGetAccountByUIDFailed() {
+	account, err := s.repository.GetByUID(context.Background(), "toshik1978")
+
+	s.Equal(repository.ErrAccountNotFound, err)
+	s.Nil(account)
+}
+
+func (s *sqliteAccountRepositoryTestSuite) TestGetAccountByUIDSucceeded() {
+	s.storeAccounts("toshik1978")
+	account, err := s.repository.GetByUID(context.Background(), "toshik1978")
+
+	s.NoError(err)
+	s.NotZero(account.ID)
+	s.Equal(s.account.Balance, account.Balance)
+	s.Equal(s.account.Status, account.Status)
+	s.True(s.account.CreatedAt.Equal(account.CreatedAt))
+	s.True(s.account.StatusChangedAt.Equal(account.StatusChangedAt))
+}
+
+func (s *sqliteAccountRepositoryTestSuite) TestLockAccountsByUIDsSucceeded() {
+	s.storeAccounts("toshik1979", "toshik1978")
+	accounts, err := s.repository.LockByUIDs(context.Background(), []string{"toshik1979", "toshik1977", "toshik1978"})
+
+	s.NoError(err)
+	s.Len(accounts, 2)
+	s.Equal("toshik1978", accounts[0].UID)
+	s.Equal("toshik1979", accounts[1].UID)
+}
+
+func (s *sqliteAccountRepositoryTestSuite) TestStoreAccountExistsFailed() {
+	s.storeAccounts("toshik1978")
+	err := s.repository.Store(context.Background(), &s.account)
+
+	s.Equal(repository.ErrAccountExists, err)
+}
+
+func (s *sqliteAccountRepositoryTestSuite) TestStoreAccountConstraintsFailed() {
+	account := s.account
+	account.Balance = -1
+	s.Error(s.repository.Store(context.Background(), &account))
+
+	account = s.account
+	account.Status = "deleted"
+	s.Error(s.repository.Store(context.Background(), &account))
+
+	accounts, err := s.repository.GetAll(context.Background(), repository.Page{Limit: 10})
+	s.NoError(err)
+	s.Empty(accounts)
+}
+
+func (s *sqliteAccountRepositoryTestSuite) TestUpdateBalanceFailed() {
+	s.storeAccounts("toshik1978")
+
+	s.Equal(repository.ErrInsufficientFunds, s.repository.UpdateBalance(context.Background(), "toshik1978", -10001))
+	s.Equal(repository.ErrAccountNotActive, s.repository.UpdateBalance(context.Background(), "toshik1979", 100))
+}
+
+func (s *sqliteAccountRepositoryTestSuite) TestUpdateBalanceNotActiveFailed() {
+	s.account.Status = repository.FrozenAccount
+	s.storeAccounts("toshik1978")
+
+	s.Equal(repository.ErrAccountNotActive, s.repository.UpdateBalance(context.Background(), "toshik1978", 100))
+}
+
+func (s *sqliteAccountRepositoryTestSuite) TestUpdateBalanceSucceeded() {
+	s.storeAccounts("toshik1978")
+	s.NoError(s.repository.UpdateBalance(context.Background(), "toshik1978", -10000))
+
+	account, err := s.repository.GetByUID(context.Background(), "toshik1978")
+	s.NoError(err)
+	s.Equal(int64(0), account.Balance)
+}
+
+func (s *sqliteAccountRepositoryTestSuite) TestUpdateStatusFailed() {
+	s.storeAccounts("toshik1978")
+	transition := &repository.AccountTransition{
+		AccountUID: "toshik1978",
+		FromStatus: repository.ActiveAccount,
+		ToStatus:   repository.ClosedAccount,
+		CreatedAt:  s.account.CreatedAt,
+	}
+
+	// Account with non-zero balance can't be closed
+	s.Equal(repository.ErrAccountChanged, s.repository.UpdateStatus(context.Background(), transition))
+	transition.FromStatus = repository.FrozenAccount
+	transition.ToStatus = repository.ActiveAccount
+	s.Equal(repository.ErrAccountChanged, s.repository.UpdateStatus(context.Background(), transition))
+	transition.FromStatus = repository.ActiveAccount
+	transition.ToStatus = "deleted"
+	s.Error(s.repository.UpdateStatus(context.Background(), transition))
+}
+
+func (s *sqliteAccountRepositoryTestSuite) TestUpdateStatusSucceeded() {
+	s.storeAccounts("toshik1978")
+	transition := &repository.AccountTransition{
+		AccountUID: "toshik1978",
+		FromStatus: repository.ActiveAccount,
+		ToStatus:   repository.FrozenAccount,
+		CreatedAt:  s.account.CreatedAt.Add(1),
+	}
+
+	s.NoError(s.repository.UpdateStatus(context.Background(), transition))
+	account, err := s.repository.GetByUID(context.Background(), "toshik1978")
+	s.NoError(err)
+	s.Equal(repository.FrozenAccount, account.Status)
+	s.True(transition.CreatedAt.Equal(account.StatusChangedAt))
+
+	// Transition is recorded by trigger
+	var transitions []repository.AccountTransition
+	s.NoError(s.client.GetConnection().Select(&transitions,
+		"SELECT id, account_uid, from_status, to_status, created_at FROM account_transitions"))
+	s.Len(transitions, 1)
+	s.Equal(repository.ActiveAccount, transitions[0].FromStatus)
+	s.Equal(repository.FrozenAccount, transitions[0].ToStatus)
+	s.True(transition.CreatedAt.Equal(transitions[0].CreatedAt))
+}
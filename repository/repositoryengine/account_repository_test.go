@@ -7,6 +7,7 @@ import (
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/Toshik1978/go-rest-api/repository"
 	"github.com/Toshik1978/go-rest-api/service/testutil"
+	"github.com/jackc/pgconn"
 	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/suite"
 )
@@ -14,11 +15,20 @@ import (
 type accountRepositoryTestSuite struct {
 	suite.Suite
 
-	account repository.Account
+	account    repository.Account
+	transition repository.AccountTransition
+	page       repository.Page
 }
 
 func (s *accountRepositoryTestSuite) SetupSuite() {
 	s.account = testutil.RepositoryAccount()
+	s.transition = repository.AccountTransition{
+		AccountUID: s.account.UID,
+		FromStatus: repository.ActiveAccount,
+		ToStatus:   repository.FrozenAccount,
+		CreatedAt:  s.account.CreatedAt,
+	}
+	s.page = repository.Page{AfterID: 1000, Limit: 10}
 }
 
 func (s *accountRepositoryTestSuite) TestGetAllAccountsFailed() {
@@ -31,10 +41,11 @@ func (s *accountRepositoryTestSuite) TestGetAllAccountsFailed() {
 
 	mockSQL.
 		ExpectQuery("^SELECT id, uid").
+		WithArgs(s.page.AfterID, s.page.Limit).
 		WillReturnError(errors.New("fail"))
 
-	repository := newAccountRepository(sqlxDB)
-	accounts, err := repository.GetAll(context.Background())
+	repository := newAccountRepository(sqlxDB, postgresDialect)
+	accounts, err := repository.GetAll(context.Background(), s.page)
 
 	s.NoError(mockSQL.ExpectationsWereMet())
 	s.Error(err)
@@ -50,14 +61,16 @@ func (s *accountRepositoryTestSuite) TestGetAllAccountsEmptySucceeded() {
 	sqlxDB := sqlx.NewDb(db, "sqlmock")
 
 	allRows := sqlmock.
-		NewRows([]string{"id", "uid", "currency", "balance", "created_at"})
+		NewRows([]string{"id", "uid", "currency", "balance", "opening_balance",
+			"status", "status_changed_at", "created_at"})
 
 	mockSQL.
 		ExpectQuery("^SELECT id, uid").
+		WithArgs(s.page.AfterID, s.page.Limit).
 		WillReturnRows(allRows)
 
-	repository := newAccountRepository(sqlxDB)
-	accounts, err := repository.GetAll(context.Background())
+	repository := newAccountRepository(sqlxDB, postgresDialect)
+	accounts, err := repository.GetAll(context.Background(), s.page)
 
 	s.NoError(mockSQL.ExpectationsWereMet())
 	s.NoError(err)
@@ -73,15 +86,18 @@ func (s *accountRepositoryTestSuite) TestGetAllAccountsSucceeded() {
 	sqlxDB := sqlx.NewDb(db, "sqlmock")
 
 	allRows := sqlmock.
-		NewRows([]string{"id", "uid", "currency", "balance", "created_at"}).
-		AddRow(s.account.ID, s.account.UID, s.account.Currency, s.account.Balance, s.account.CreatedAt)
+		NewRows([]string{"id", "uid", "currency", "balance", "opening_balance",
+			"status", "status_changed_at", "created_at"}).
+		AddRow(s.account.ID, s.account.UID, s.account.Currency, s.account.Balance, s.account.OpeningBalance,
+			s.account.Status, s.account.StatusChangedAt, s.account.CreatedAt)
 
 	mockSQL.
 		ExpectQuery("^SELECT id, uid").
+		WithArgs(s.page.AfterID, s.page.Limit).
 		WillReturnRows(allRows)
 
-	repository := newAccountRepository(sqlxDB)
-	accounts, err := repository.GetAll(context.Background())
+	repository := newAccountRepository(sqlxDB, postgresDialect)
+	accounts, err := repository.GetAll(context.Background(), s.page)
 
 	s.NoError(mockSQL.ExpectationsWereMet())
 	s.NoError(err)
@@ -89,6 +105,128 @@ func (s *accountRepositoryTestSuite) TestGetAllAccountsSucceeded() {
 	s.EqualValues(s.account, accounts[0])
 }
 
+func (s *accountRepositoryTestSuite) TestGetAccountByUIDFailed() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectQuery("^SELECT id, uid").
+		WithArgs(s.account.UID).
+		WillReturnError(errors.New("fail"))
+
+	repository := newAccountRepository(sqlxDB, postgresDialect)
+	account, err := repository.GetByUID(context.Background(), s.account.UID)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.Error(err)
+	s.Nil(account)
+}
+
+func (s *accountRepositoryTestSuite) TestGetAccountByUIDNotFoundFailed() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	rows := sqlmock.
+		NewRows([]string{"id", "uid", "currency", "balance", "opening_balance",
+			"status", "status_changed_at", "created_at"})
+
+	mockSQL.
+		ExpectQuery("^SELECT id, uid").
+		WithArgs(s.account.UID).
+		WillReturnRows(rows)
+
+	accountRepository := newAccountRepository(sqlxDB, postgresDialect)
+	account, err := accountRepository.GetByUID(context.Background(), s.account.UID)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.True(errors.Is(err, repository.ErrAccountNotFound))
+	s.Nil(account)
+}
+
+func (s *accountRepositoryTestSuite) TestGetAccountByUIDSucceeded() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	rows := sqlmock.
+		NewRows([]string{"id", "uid", "currency", "balance", "opening_balance",
+			"status", "status_changed_at", "created_at"}).
+		AddRow(s.account.ID, s.account.UID, s.account.Currency, s.account.Balance, s.account.OpeningBalance,
+			s.account.Status, s.account.StatusChangedAt, s.account.CreatedAt)
+
+	mockSQL.
+		ExpectQuery("^SELECT id, uid").
+		WithArgs(s.account.UID).
+		WillReturnRows(rows)
+
+	repository := newAccountRepository(sqlxDB, postgresDialect)
+	account, err := repository.GetByUID(context.Background(), s.account.UID)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.NoError(err)
+	s.NotNil(account)
+	s.EqualValues(s.account, *account)
+}
+
+func (s *accountRepositoryTestSuite) TestLockAccountsByUIDsFailed() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectQuery(`(?s)^SELECT id, uid.*WHERE uid IN \(\$1, \$2\).*ORDER BY uid.*FOR UPDATE`).
+		WithArgs(s.account.UID, "recipient").
+		WillReturnError(errors.New("fail"))
+
+	repository := newAccountRepository(sqlxDB, postgresDialect)
+	accounts, err := repository.LockByUIDs(context.Background(), []string{s.account.UID, "recipient"})
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.Error(err)
+	s.Nil(accounts)
+}
+
+func (s *accountRepositoryTestSuite) TestLockAccountsByUIDsSucceeded() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	rows := sqlmock.
+		NewRows([]string{"id", "uid", "currency", "balance", "opening_balance",
+			"status", "status_changed_at", "created_at"}).
+		AddRow(s.account.ID, s.account.UID, s.account.Currency, s.account.Balance, s.account.OpeningBalance,
+			s.account.Status, s.account.StatusChangedAt, s.account.CreatedAt)
+
+	mockSQL.
+		ExpectQuery(`(?s)^SELECT id, uid.*WHERE uid IN \(\$1, \$2\).*ORDER BY uid.*FOR UPDATE`).
+		WithArgs(s.account.UID, "recipient").
+		WillReturnRows(rows)
+
+	accountRepository := newAccountRepository(sqlxDB, postgresDialect)
+	accounts, err := accountRepository.LockByUIDs(context.Background(), []string{s.account.UID, "recipient"})
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.NoError(err)
+	s.Equal([]repository.Account{s.account}, accounts)
+}
+
 func (s *accountRepositoryTestSuite) TestStoreAccountFailed() {
 	db, mockSQL, err := sqlmock.New()
 	if err != nil {
@@ -98,11 +236,12 @@ func (s *accountRepositoryTestSuite) TestStoreAccountFailed() {
 	sqlxDB := sqlx.NewDb(db, "sqlmock")
 
 	mockSQL.
-		ExpectExec("^INSERT INTO accounts").
-		WithArgs(s.account.UID, s.account.Currency, s.account.Balance, s.account.CreatedAt).
+		ExpectQuery("^INSERT INTO accounts.*RETURNING id$").
+		WithArgs(s.account.UID, s.account.Currency, s.account.Balance, s.account.OpeningBalance,
+			s.account.Status, s.account.StatusChangedAt, s.account.CreatedAt).
 		WillReturnError(errors.New("fail"))
 
-	repository := newAccountRepository(sqlxDB)
+	repository := newAccountRepository(sqlxDB, postgresDialect)
 	account := s.account
 	err = repository.Store(context.Background(), &account)
 
@@ -110,6 +249,28 @@ func (s *accountRepositoryTestSuite) TestStoreAccountFailed() {
 	s.Error(err)
 }
 
+func (s *accountRepositoryTestSuite) TestStoreAccountExistsFailed() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectQuery("^INSERT INTO accounts.*RETURNING id$").
+		WithArgs(s.account.UID, s.account.Currency, s.account.Balance, s.account.OpeningBalance,
+			s.account.Status, s.account.StatusChangedAt, s.account.CreatedAt).
+		WillReturnError(&pgconn.PgError{Code: "23505"})
+
+	accountRepository := newAccountRepository(sqlxDB, postgresDialect)
+	account := s.account
+	err = accountRepository.Store(context.Background(), &account)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.True(errors.Is(err, repository.ErrAccountExists))
+}
+
 func (s *accountRepositoryTestSuite) TestStoreAccountSucceeded() {
 	db, mockSQL, err := sqlmock.New()
 	if err != nil {
@@ -119,11 +280,12 @@ func (s *accountRepositoryTestSuite) TestStoreAccountSucceeded() {
 	sqlxDB := sqlx.NewDb(db, "sqlmock")
 
 	mockSQL.
-		ExpectExec("^INSERT INTO accounts").
-		WithArgs(s.account.UID, s.account.Currency, s.account.Balance, s.account.CreatedAt).
-		WillReturnResult(sqlmock.NewResult(s.account.ID, 1))
+		ExpectQuery("^INSERT INTO accounts.*RETURNING id$").
+		WithArgs(s.account.UID, s.account.Currency, s.account.Balance, s.account.OpeningBalance,
+			s.account.Status, s.account.StatusChangedAt, s.account.CreatedAt).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(s.account.ID))
 
-	repository := newAccountRepository(sqlxDB)
+	repository := newAccountRepository(sqlxDB, postgresDialect)
 	account := s.account
 	account.ID = 0
 	err = repository.Store(context.Background(), &account)
@@ -143,16 +305,36 @@ func (s *accountRepositoryTestSuite) TestUpdateAccountBalanceFailed() {
 
 	mockSQL.
 		ExpectExec("^UPDATE accounts").
-		WithArgs(s.account.UID, s.account.Balance).
+		WithArgs(s.account.Balance, s.account.UID).
 		WillReturnError(errors.New("fail"))
 
-	repository := newAccountRepository(sqlxDB)
+	repository := newAccountRepository(sqlxDB, postgresDialect)
 	err = repository.UpdateBalance(context.Background(), s.account.UID, s.account.Balance)
 
 	s.NoError(mockSQL.ExpectationsWereMet())
 	s.Error(err)
 }
 
+func (s *accountRepositoryTestSuite) TestUpdateAccountBalanceInsufficientFundsFailed() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectExec("^UPDATE accounts").
+		WithArgs(-s.account.Balance-1, s.account.UID).
+		WillReturnError(&pgconn.PgError{Code: "23514"})
+
+	accountRepository := newAccountRepository(sqlxDB, postgresDialect)
+	err = accountRepository.UpdateBalance(context.Background(), s.account.UID, -s.account.Balance-1)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.True(errors.Is(err, repository.ErrInsufficientFunds))
+}
+
 func (s *accountRepositoryTestSuite) TestUpdateAccountBalanceSucceeded() {
 	db, mockSQL, err := sqlmock.New()
 	if err != nil {
@@ -163,12 +345,95 @@ func (s *accountRepositoryTestSuite) TestUpdateAccountBalanceSucceeded() {
 
 	mockSQL.
 		ExpectExec("^UPDATE accounts").
-		WithArgs(s.account.UID, s.account.Balance).
+		WithArgs(s.account.Balance, s.account.UID).
 		WillReturnResult(sqlmock.NewResult(s.account.ID, 1))
 
-	repository := newAccountRepository(sqlxDB)
+	repository := newAccountRepository(sqlxDB, postgresDialect)
 	err = repository.UpdateBalance(context.Background(), s.account.UID, s.account.Balance)
 
 	s.NoError(mockSQL.ExpectationsWereMet())
 	s.NoError(err)
 }
+
+func (s *accountRepositoryTestSuite) TestUpdateAccountBalanceNotActiveFailed() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectExec("^UPDATE accounts").
+		WithArgs(s.account.Balance, s.account.UID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	accountRepository := newAccountRepository(sqlxDB, postgresDialect)
+	err = accountRepository.UpdateBalance(context.Background(), s.account.UID, s.account.Balance)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.True(errors.Is(err, repository.ErrAccountNotActive))
+}
+
+func (s *accountRepositoryTestSuite) TestUpdateAccountStatusFailed() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectExec("^WITH updated AS").
+		WithArgs(s.transition.ToStatus, s.transition.CreatedAt, s.transition.AccountUID, s.transition.FromStatus,
+			s.transition.ToStatus, s.transition.FromStatus).
+		WillReturnError(errors.New("fail"))
+
+	accountRepository := newAccountRepository(sqlxDB, postgresDialect)
+	err = accountRepository.UpdateStatus(context.Background(), &s.transition)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.Error(err)
+}
+
+func (s *accountRepositoryTestSuite) TestUpdateAccountStatusChangedFailed() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectExec("^WITH updated AS").
+		WithArgs(s.transition.ToStatus, s.transition.CreatedAt, s.transition.AccountUID, s.transition.FromStatus,
+			s.transition.ToStatus, s.transition.FromStatus).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	accountRepository := newAccountRepository(sqlxDB, postgresDialect)
+	err = accountRepository.UpdateStatus(context.Background(), &s.transition)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.True(errors.Is(err, repository.ErrAccountChanged))
+}
+
+func (s *accountRepositoryTestSuite) TestUpdateAccountStatusSucceeded() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectExec("^WITH updated AS").
+		WithArgs(s.transition.ToStatus, s.transition.CreatedAt, s.transition.AccountUID, s.transition.FromStatus,
+			s.transition.ToStatus, s.transition.FromStatus).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	accountRepository := newAccountRepository(sqlxDB, postgresDialect)
+	err = accountRepository.UpdateStatus(context.Background(), &s.transition)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.NoError(err)
+}
@@ -0,0 +1,145 @@
+package repositoryengine
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	getDeliveriesByWebhookSQL = `
+		SELECT id, webhook_id, event_id, event_type, payload, event_time, status, attempts, next_attempt_at,
+			created_at, updated_at
+		FROM webhook_deliveries
+		WHERE webhook_id = ? AND id > ?
+		ORDER BY id
+		LIMIT ?`
+	getDeliveryByIDSQL = `
+		SELECT id, webhook_id, event_id, event_type, payload, event_time, status, attempts, next_attempt_at,
+			created_at, updated_at
+		FROM webhook_deliveries
+		WHERE id = ?`
+	getPendingDeliveriesSQL = `
+		SELECT id, webhook_id, event_id, event_type, payload, event_time, status, attempts, next_attempt_at,
+			created_at, updated_at
+		FROM webhook_deliveries
+		WHERE status = 'pending' AND next_attempt_at <= ?
+		ORDER BY id
+		LIMIT ?`
+	getDeliveryAttemptsSQL = `
+		SELECT id, delivery_id, status_code, error, duration_ms, created_at
+		FROM webhook_delivery_attempts
+		WHERE delivery_id = ?
+		ORDER BY id`
+
+	storeDeliverySQL = `
+		INSERT INTO webhook_deliveries
+			(webhook_id, event_id, event_type, payload, event_time, status, attempts, next_attempt_at,
+			created_at, updated_at)
+		VALUES
+			(:webhook_id, :event_id, :event_type, :payload, :event_time, :status, :attempts, :next_attempt_at,
+			:created_at, :updated_at)`
+	updateDeliverySQL = `
+		UPDATE webhook_deliveries
+		SET status = :status, attempts = :attempts, next_attempt_at = :next_attempt_at, updated_at = :updated_at
+		WHERE id = :id`
+	storeDeliveryAttemptSQL = `
+		INSERT INTO webhook_delivery_attempts
+			(delivery_id, status_code, error, duration_ms, created_at)
+		VALUES
+			(:delivery_id, :status_code, :error, :duration_ms, :created_at)`
+)
+
+// deliveryRepository implements DeliveryRepository interface
+type deliveryRepository struct {
+	ext     sqlx.ExtContext
+	dialect *dialect
+}
+
+// newDeliveryRepository creates new delivery repository
+func newDeliveryRepository(ext sqlx.ExtContext, dialect *dialect) repository.DeliveryRepository {
+	return &deliveryRepository{
+		ext:     ext,
+		dialect: dialect,
+	}
+}
+
+func (r *deliveryRepository) GetByWebhook(ctx context.Context,
+	webhookID int64, page repository.Page) ([]repository.Delivery, error) {
+
+	var deliveries []repository.Delivery
+	err := r.dialect.selectContext(ctx, sqlxExt(ctx, r.ext), &deliveries, getDeliveriesByWebhookSQL,
+		webhookID, page.AfterID, page.Limit)
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (r *deliveryRepository) GetByID(ctx context.Context, id int64) (*repository.Delivery, error) {
+	var delivery repository.Delivery
+	err := r.dialect.getContext(ctx, sqlxExt(ctx, r.ext), &delivery, getDeliveryByIDSQL, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.ErrDeliveryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *deliveryRepository) GetPending(ctx context.Context, now time.Time, limit int) ([]repository.Delivery, error) {
+	var deliveries []repository.Delivery
+	err := r.dialect.selectContext(ctx, sqlxExt(ctx, r.ext), &deliveries, getPendingDeliveriesSQL, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (r *deliveryRepository) GetAttempts(ctx context.Context, deliveryID int64) ([]repository.DeliveryAttempt, error) {
+	var attempts []repository.DeliveryAttempt
+	err := r.dialect.selectContext(ctx, sqlxExt(ctx, r.ext), &attempts, getDeliveryAttemptsSQL, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	return attempts, nil
+}
+
+func (r *deliveryRepository) Store(ctx context.Context, delivery *repository.Delivery) error {
+	err := r.dialect.namedInsert(ctx, sqlxExt(ctx, r.ext), storeDeliverySQL, delivery, &delivery.ID)
+	if r.dialect.isViolation(err, uniqueViolation) {
+		return repository.ErrDeliveryExists
+	}
+	if r.dialect.isViolation(err, foreignKeyViolation) {
+		return repository.ErrWebhookNotFound
+	}
+	return err
+}
+
+func (r *deliveryRepository) Update(ctx context.Context, delivery *repository.Delivery) error {
+	res, err := r.dialect.namedExec(ctx, sqlxExt(ctx, r.ext), updateDeliverySQL, delivery)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return repository.ErrDeliveryNotFound
+	}
+	return nil
+}
+
+func (r *deliveryRepository) StoreAttempt(ctx context.Context, attempt *repository.DeliveryAttempt) error {
+	err := r.dialect.namedInsert(ctx, sqlxExt(ctx, r.ext), storeDeliveryAttemptSQL, attempt, &attempt.ID)
+	if r.dialect.isViolation(err, foreignKeyViolation) {
+		return repository.ErrDeliveryNotFound
+	}
+	return err
+}
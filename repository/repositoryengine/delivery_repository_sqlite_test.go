@@ -0,0 +1,158 @@
+package repositoryengine
+
+import (
+	"context"
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service"
+	"github.com/Toshik1978/go-rest-api/service/testutil"
+	"github.com/stretchr/testify/suite"
+)
+
+type sqliteDeliveryRepositoryTestSuite struct {
+	suite.Suite
+
+	client     service.DBClient
+	repository repository.DeliveryRepository
+	delivery   repository.Delivery
+}
+
+func (s *sqliteDeliveryRepositoryTestSuite) SetupTest() {
+	s.client = newSQLiteTestClient(s.T())
+	db := s.client.GetConnection()
+	webhook := testutil.RepositoryWebhook()
+	s.Require().NoError(newWebhookRepository(db, sqliteDialect).Store(context.Background(), &webhook))
+
+	s.repository = newDeliveryRepository(db, sqliteDialect)
+	s.delivery = testutil.RepositoryDelivery()
+	s.delivery.WebhookID = webhook.ID
+}
+
+func (s *sqliteDeliveryRepositoryTestSuite) TearDownTest() {
+	s.client.Stop()
+}
+
+func (s *sqliteDeliveryRepositoryTestSuite) TestStoreDeliveryWebhookNotFoundFailed() {
+	delivery := s.delivery
+	delivery.WebhookID++
+	err := s.repository.Store(context.Background(), &delivery)
+
+	s.Equal(repository.ErrWebhookNotFound, err)
+}
+
+func (s *sqliteDeliveryRepositoryTestSuite) TestStoreDeliveryExistsFailed() {
+	first, second := s.delivery, s.delivery
+	s.Require().NoError(s.repository.Store(context.Background(), &first))
+	err := s.repository.Store(context.Background(), &second)
+
+	s.Equal(repository.ErrDeliveryExists, err)
+}
+
+func (s *sqliteDeliveryRepositoryTestSuite) TestStoreDeliverySucceeded() {
+	s.Require().NoError(s.repository.Store(context.Background(), &s.delivery))
+
+	delivery, err := s.repository.GetByID(context.Background(), s.delivery.ID)
+	s.NoError(err)
+	s.Require().NotNil(delivery)
+	s.Equal(s.delivery.WebhookID, delivery.WebhookID)
+	s.Equal(s.delivery.EventID, delivery.EventID)
+	s.Equal(s.delivery.EventType, delivery.EventType)
+	s.Equal(s.delivery.Payload, delivery.Payload)
+	s.Equal(s.delivery.Status, delivery.Status)
+	s.True(s.delivery.EventTime.Equal(delivery.EventTime))
+	s.True(s.delivery.NextAttemptAt.Equal(delivery.NextAttemptAt))
+
+	deliveries, err := s.repository.GetByWebhook(context.Background(),
+		s.delivery.WebhookID, repository.Page{Limit: 10})
+	s.NoError(err)
+	s.Require().Len(deliveries, 1)
+	s.Equal(s.delivery.ID, deliveries[0].ID)
+
+	deliveries, err = s.repository.GetByWebhook(context.Background(),
+		s.delivery.WebhookID+1, repository.Page{Limit: 10})
+	s.NoError(err)
+	s.Empty(deliveries)
+}
+
+func (s *sqliteDeliveryRepositoryTestSuite) TestGetPendingDeliveriesSucceeded() {
+	first, second, third := s.delivery, s.delivery, s.delivery
+	second.EventID++
+	second.NextAttemptAt = s.delivery.NextAttemptAt.Add(time.Minute)
+	third.EventID += 2
+	third.Status = repository.SucceededDelivery
+	s.Require().NoError(s.repository.Store(context.Background(), &first))
+	s.Require().NoError(s.repository.Store(context.Background(), &second))
+	s.Require().NoError(s.repository.Store(context.Background(), &third))
+
+	// Delivery isn't returned before its next attempt
+	deliveries, err := s.repository.GetPending(context.Background(), s.delivery.NextAttemptAt, 10)
+	s.NoError(err)
+	s.Require().Len(deliveries, 1)
+	s.Equal(first.ID, deliveries[0].ID)
+
+	deliveries, err = s.repository.GetPending(context.Background(), second.NextAttemptAt, 10)
+	s.NoError(err)
+	s.Require().Len(deliveries, 2)
+	s.Equal(second.ID, deliveries[1].ID)
+}
+
+func (s *sqliteDeliveryRepositoryTestSuite) TestUpdateDeliveryNotFoundFailed() {
+	err := s.repository.Update(context.Background(), &s.delivery)
+
+	s.Equal(repository.ErrDeliveryNotFound, err)
+}
+
+func (s *sqliteDeliveryRepositoryTestSuite) TestUpdateDeliverySucceeded() {
+	s.Require().NoError(s.repository.Store(context.Background(), &s.delivery))
+
+	updated := s.delivery
+	updated.Status = repository.FailedDelivery
+	updated.Attempts = 3
+	updated.NextAttemptAt = s.delivery.NextAttemptAt.Add(time.Minute)
+	updated.UpdatedAt = s.delivery.UpdatedAt.Add(time.Minute)
+	s.NoError(s.repository.Update(context.Background(), &updated))
+
+	delivery, err := s.repository.GetByID(context.Background(), s.delivery.ID)
+	s.NoError(err)
+	s.Require().NotNil(delivery)
+	s.Equal(repository.FailedDelivery, delivery.Status)
+	s.Equal(3, delivery.Attempts)
+	s.True(updated.NextAttemptAt.Equal(delivery.NextAttemptAt))
+	s.True(updated.UpdatedAt.Equal(delivery.UpdatedAt))
+}
+
+func (s *sqliteDeliveryRepositoryTestSuite) TestStoreAttemptDeliveryNotFoundFailed() {
+	attempt := repository.DeliveryAttempt{DeliveryID: 1, CreatedAt: s.delivery.CreatedAt}
+	err := s.repository.StoreAttempt(context.Background(), &attempt)
+
+	s.Equal(repository.ErrDeliveryNotFound, err)
+}
+
+func (s *sqliteDeliveryRepositoryTestSuite) TestStoreAttemptSucceeded() {
+	s.Require().NoError(s.repository.Store(context.Background(), &s.delivery))
+
+	first := repository.DeliveryAttempt{
+		DeliveryID: s.delivery.ID,
+		Error:      "fail",
+		DurationMS: 10,
+		CreatedAt:  s.delivery.CreatedAt,
+	}
+	second := repository.DeliveryAttempt{
+		DeliveryID: s.delivery.ID,
+		StatusCode: 200,
+		DurationMS: 20,
+		CreatedAt:  s.delivery.CreatedAt.Add(time.Second),
+	}
+	s.Require().NoError(s.repository.StoreAttempt(context.Background(), &first))
+	s.Require().NoError(s.repository.StoreAttempt(context.Background(), &second))
+
+	attempts, err := s.repository.GetAttempts(context.Background(), s.delivery.ID)
+	s.NoError(err)
+	s.Require().Len(attempts, 2)
+	s.Equal(first.ID, attempts[0].ID)
+	s.Equal("fail", attempts[0].Error)
+	s.Equal(int64(10), attempts[0].DurationMS)
+	s.Equal(second.ID, attempts[1].ID)
+	s.Equal(200, attempts[1].StatusCode)
+}
@@ -0,0 +1,237 @@
+package repositoryengine
+
+import (
+	"context"
+	"errors"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service/testutil"
+	"github.com/jackc/pgconn"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/suite"
+)
+
+type deliveryRepositoryTestSuite struct {
+	suite.Suite
+
+	delivery repository.Delivery
+}
+
+func (s *deliveryRepositoryTestSuite) SetupSuite() {
+	s.delivery = testutil.RepositoryDelivery()
+	s.delivery.WebhookID = 3
+}
+
+func (s *deliveryRepositoryTestSuite) TestStoreDeliveryExistsFailed() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectQuery("^INSERT INTO webhook_deliveries").
+		WillReturnError(&pgconn.PgError{Code: "23505"})
+
+	deliveryRepository := newDeliveryRepository(sqlxDB, postgresDialect)
+	delivery := s.delivery
+	err = deliveryRepository.Store(context.Background(), &delivery)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.True(errors.Is(err, repository.ErrDeliveryExists))
+}
+
+func (s *deliveryRepositoryTestSuite) TestStoreDeliveryWebhookNotFoundFailed() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectQuery("^INSERT INTO webhook_deliveries").
+		WillReturnError(&pgconn.PgError{Code: "23503"})
+
+	deliveryRepository := newDeliveryRepository(sqlxDB, postgresDialect)
+	delivery := s.delivery
+	err = deliveryRepository.Store(context.Background(), &delivery)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.True(errors.Is(err, repository.ErrWebhookNotFound))
+}
+
+func (s *deliveryRepositoryTestSuite) TestStoreDeliverySucceeded() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectQuery("^INSERT INTO webhook_deliveries").
+		WithArgs(s.delivery.WebhookID, s.delivery.EventID, s.delivery.EventType, s.delivery.Payload,
+			s.delivery.EventTime, s.delivery.Status, 0, s.delivery.NextAttemptAt,
+			s.delivery.CreatedAt, s.delivery.UpdatedAt).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+
+	deliveryRepository := newDeliveryRepository(sqlxDB, postgresDialect)
+	delivery := s.delivery
+	err = deliveryRepository.Store(context.Background(), &delivery)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.NoError(err)
+	s.Equal(int64(7), delivery.ID)
+}
+
+func (s *deliveryRepositoryTestSuite) TestGetPendingDeliveriesFailed() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectQuery("^SELECT id, webhook_id, event_id").
+		WithArgs(s.delivery.NextAttemptAt, 10).
+		WillReturnError(errors.New("fail"))
+
+	deliveryRepository := newDeliveryRepository(sqlxDB, postgresDialect)
+	deliveries, err := deliveryRepository.GetPending(context.Background(), s.delivery.NextAttemptAt, 10)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.Error(err)
+	s.Nil(deliveries)
+}
+
+func (s *deliveryRepositoryTestSuite) TestGetPendingDeliveriesSucceeded() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	rows := sqlmock.
+		NewRows([]string{
+			"id", "webhook_id", "event_id", "event_type", "payload", "event_time", "status", "attempts",
+			"next_attempt_at", "created_at", "updated_at",
+		}).
+		AddRow(7, s.delivery.WebhookID, s.delivery.EventID, s.delivery.EventType, s.delivery.Payload,
+			s.delivery.EventTime, s.delivery.Status, 0, s.delivery.NextAttemptAt,
+			s.delivery.CreatedAt, s.delivery.UpdatedAt)
+
+	mockSQL.
+		ExpectQuery("^SELECT id, webhook_id, event_id").
+		WithArgs(s.delivery.NextAttemptAt, 10).
+		WillReturnRows(rows)
+
+	deliveryRepository := newDeliveryRepository(sqlxDB, postgresDialect)
+	deliveries, err := deliveryRepository.GetPending(context.Background(), s.delivery.NextAttemptAt, 10)
+
+	expected := s.delivery
+	expected.ID = 7
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.NoError(err)
+	s.Equal([]repository.Delivery{expected}, deliveries)
+}
+
+func (s *deliveryRepositoryTestSuite) TestUpdateDeliveryNotFoundFailed() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectExec("^UPDATE webhook_deliveries").
+		WithArgs(s.delivery.Status, 0, s.delivery.NextAttemptAt, s.delivery.UpdatedAt, 7).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	deliveryRepository := newDeliveryRepository(sqlxDB, postgresDialect)
+	delivery := s.delivery
+	delivery.ID = 7
+	err = deliveryRepository.Update(context.Background(), &delivery)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.True(errors.Is(err, repository.ErrDeliveryNotFound))
+}
+
+func (s *deliveryRepositoryTestSuite) TestUpdateDeliverySucceeded() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectExec("^UPDATE webhook_deliveries").
+		WithArgs(s.delivery.Status, 0, s.delivery.NextAttemptAt, s.delivery.UpdatedAt, 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	deliveryRepository := newDeliveryRepository(sqlxDB, postgresDialect)
+	delivery := s.delivery
+	delivery.ID = 7
+	err = deliveryRepository.Update(context.Background(), &delivery)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.NoError(err)
+}
+
+func (s *deliveryRepositoryTestSuite) TestStoreAttemptDeliveryNotFoundFailed() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectQuery("^INSERT INTO webhook_delivery_attempts").
+		WillReturnError(&pgconn.PgError{Code: "23503"})
+
+	deliveryRepository := newDeliveryRepository(sqlxDB, postgresDialect)
+	attempt := repository.DeliveryAttempt{DeliveryID: 7, CreatedAt: s.delivery.CreatedAt}
+	err = deliveryRepository.StoreAttempt(context.Background(), &attempt)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.True(errors.Is(err, repository.ErrDeliveryNotFound))
+}
+
+func (s *deliveryRepositoryTestSuite) TestGetAttemptsSucceeded() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	rows := sqlmock.
+		NewRows([]string{"id", "delivery_id", "status_code", "error", "duration_ms", "created_at"}).
+		AddRow(1, 7, 500, "event is rejected with status 500", 15, s.delivery.CreatedAt)
+
+	mockSQL.
+		ExpectQuery("^SELECT id, delivery_id, status_code").
+		WithArgs(7).
+		WillReturnRows(rows)
+
+	deliveryRepository := newDeliveryRepository(sqlxDB, postgresDialect)
+	attempts, err := deliveryRepository.GetAttempts(context.Background(), 7)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.NoError(err)
+	s.Equal([]repository.DeliveryAttempt{{
+		ID:         1,
+		DeliveryID: 7,
+		StatusCode: 500,
+		Error:      "event is rejected with status 500",
+		DurationMS: 15,
+		CreatedAt:  s.delivery.CreatedAt,
+	}}, attempts)
+}
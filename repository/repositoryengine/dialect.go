@@ -0,0 +1,164 @@
+package repositoryengine
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jackc/pgconn"
+	"github.com/jmoiron/sqlx"
+	"github.com/mattn/go-sqlite3"
+)
+
+// violation define kind of constraint violation
+type violation int
+
+const (
+	foreignKeyViolation violation = iota
+	uniqueViolation
+	checkViolation
+)
+
+// PostgreSQL error codes (SQLSTATE) of constraint violations
+var postgresViolations = map[violation]string{
+	foreignKeyViolation: "23503",
+	uniqueViolation:     "23505",
+	checkViolation:      "23514",
+}
+
+// SQLite extended error codes of constraint violations
+var sqliteViolations = map[violation]sqlite3.ErrNoExtended{
+	foreignKeyViolation: sqlite3.ErrConstraintForeignKey,
+	uniqueViolation:     sqlite3.ErrConstraintUnique,
+	checkViolation:      sqlite3.ErrConstraintCheck,
+}
+
+// PostgreSQL error codes (SQLSTATE) of transactions, failed because of concurrent transactions
+const (
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+)
+
+// dialect define differences of databases, which repositories keep data in. Queries are written
+// with ? placeholders and rebound to the dialect's ones
+type dialect struct {
+	// bindType is sqlx's type of placeholders
+	bindType int
+	// returning is true, if INSERT query can return ID of inserted row, otherwise it's retrieved by LastInsertId
+	returning bool
+	// lockRows is appended to SELECT query to lock selected rows till the end of transaction
+	lockRows string
+	// isolation is true, if transaction's isolation level can be set
+	isolation bool
+	// utc is true, if time arguments should be converted to UTC
+	utc bool
+	// updateStatusSQL and storeAdjustmentSQL are named queries, which update account and record the change atomically
+	updateStatusSQL    string
+	storeAdjustmentSQL string
+
+	// isViolation checks, if error is violation of constraint of the given kind
+	isViolation func(err error, kind violation) bool
+	// isRetryable checks, if error is failure of transaction, which can succeed, if it's retried
+	isRetryable func(err error) bool
+}
+
+// postgresDialect is dialect of PostgreSQL
+var postgresDialect = &dialect{
+	bindType:           sqlx.DOLLAR,
+	returning:          true,
+	lockRows:           " FOR UPDATE",
+	isolation:          true,
+	updateStatusSQL:    updateStatusSQL,
+	storeAdjustmentSQL: storeAdjustmentSQL,
+	isViolation: func(err error, kind violation) bool {
+		return isPostgresError(err, postgresViolations[kind])
+	},
+	isRetryable: func(err error) bool {
+		return isPostgresError(err, serializationFailure) || isPostgresError(err, deadlockDetected)
+	},
+}
+
+// sqliteDialect is dialect of SQLite. Transaction holds write lock of the whole database since its start,
+// so rows are just selected. SQLite has the single writer, so all transactions are serializable whatever level
+// is requested. Timestamps are stored as text, so they're compared properly only if they're in the same time zone
+var sqliteDialect = &dialect{
+	bindType:           sqlx.QUESTION,
+	utc:                true,
+	updateStatusSQL:    updateStatusByTriggerSQL,
+	storeAdjustmentSQL: storeAdjustmentByTriggerSQL,
+	isViolation: func(err error, kind violation) bool {
+		var sqliteErr sqlite3.Error
+		return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqliteViolations[kind]
+	},
+	// Database is busy, if it's locked by another process longer than busy timeout
+	isRetryable: func(err error) bool {
+		var sqliteErr sqlite3.Error
+		return errors.As(err, &sqliteErr) && (sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked)
+	},
+}
+
+// isPostgresError checks, if error is PostgreSQL error with the given SQLSTATE code
+func isPostgresError(err error, code string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == code
+}
+
+// selectContext executes query and scans all rows to dest
+func (d *dialect) selectContext(
+	ctx context.Context, ext sqlx.ExtContext, dest interface{}, query string, args ...interface{}) error {
+
+	return sqlx.SelectContext(ctx, ext, dest, sqlx.Rebind(d.bindType, query), d.args(args)...)
+}
+
+// getContext executes query and scans the single row to dest
+func (d *dialect) getContext(
+	ctx context.Context, ext sqlx.ExtContext, dest interface{}, query string, args ...interface{}) error {
+
+	return sqlx.GetContext(ctx, ext, dest, sqlx.Rebind(d.bindType, query), d.args(args)...)
+}
+
+// execContext executes query without returning rows
+func (d *dialect) execContext(
+	ctx context.Context, ext sqlx.ExtContext, query string, args ...interface{}) (sql.Result, error) {
+
+	return ext.ExecContext(ctx, sqlx.Rebind(d.bindType, query), d.args(args)...)
+}
+
+// namedExec executes named query without returning rows
+func (d *dialect) namedExec(
+	ctx context.Context, ext sqlx.ExtContext, query string, arg interface{}) (sql.Result, error) {
+
+	query, args, err := sqlx.BindNamed(d.bindType, query, arg)
+	if err != nil {
+		return nil, err
+	}
+	return ext.ExecContext(ctx, query, d.args(args)...)
+}
+
+// namedInsert executes named INSERT query and retrieve ID of inserted row
+func (d *dialect) namedInsert(
+	ctx context.Context, ext sqlx.ExtContext, query string, arg interface{}, id *int64) error {
+
+	if !d.returning {
+		res, err := d.namedExec(ctx, ext, query, arg)
+		if err != nil {
+			return err
+		}
+		*id, err = res.LastInsertId()
+		return err
+	}
+
+	query, args, err := sqlx.BindNamed(d.bindType, query+" RETURNING id", arg)
+	if err != nil {
+		return err
+	}
+	return ext.QueryRowxContext(ctx, query, d.args(args)...).Scan(id)
+}
+
+// args return query arguments, converted for the dialect
+func (d *dialect) args(args []interface{}) []interface{} {
+	if d.utc {
+		return utc(args)
+	}
+	return args
+}
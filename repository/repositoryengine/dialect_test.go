@@ -0,0 +1,45 @@
+package repositoryengine
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgconn"
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/suite"
+)
+
+type dialectTestSuite struct {
+	suite.Suite
+}
+
+func (s *dialectTestSuite) TestPostgresIsViolationSucceeded() {
+	err := fmt.Errorf("failed: %w", &pgconn.PgError{Code: "23505"})
+
+	s.True(postgresDialect.isViolation(err, uniqueViolation))
+	s.False(postgresDialect.isViolation(err, checkViolation))
+	s.False(postgresDialect.isViolation(errors.New("fail"), uniqueViolation))
+	s.False(postgresDialect.isRetryable(err))
+}
+
+func (s *dialectTestSuite) TestPostgresIsRetryableSucceeded() {
+	s.True(postgresDialect.isRetryable(&pgconn.PgError{Code: serializationFailure}))
+	s.True(postgresDialect.isRetryable(fmt.Errorf("failed: %w", &pgconn.PgError{Code: deadlockDetected})))
+	s.False(postgresDialect.isRetryable(sqlite3.Error{Code: sqlite3.ErrBusy}))
+}
+
+func (s *dialectTestSuite) TestSQLiteIsViolationSucceeded() {
+	err := fmt.Errorf("failed: %w",
+		sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintUnique})
+
+	s.True(sqliteDialect.isViolation(err, uniqueViolation))
+	s.False(sqliteDialect.isViolation(err, checkViolation))
+	s.False(sqliteDialect.isViolation(errors.New("fail"), uniqueViolation))
+	s.False(sqliteDialect.isRetryable(err))
+}
+
+func (s *dialectTestSuite) TestSQLiteIsRetryableSucceeded() {
+	s.True(sqliteDialect.isRetryable(sqlite3.Error{Code: sqlite3.ErrBusy}))
+	s.True(sqliteDialect.isRetryable(fmt.Errorf("failed: %w", sqlite3.Error{Code: sqlite3.ErrLocked})))
+	s.False(sqliteDialect.isRetryable(&pgconn.PgError{Code: serializationFailure}))
+}
@@ -0,0 +1,74 @@
+package repositoryengine
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	getIdempotencyKeySQL = `
+		SELECT operation, key, fingerprint, response, created_at, expires_at
+		FROM idempotency_keys
+		WHERE operation = ? AND key = ? AND expires_at > ?`
+
+	// Expired key is replaced, otherwise nothing is affected
+	storeIdempotencyKeySQL = `
+		INSERT INTO idempotency_keys
+			(operation, key, fingerprint, response, created_at, expires_at)
+		VALUES
+			(:operation, :key, :fingerprint, :response, :created_at, :expires_at)
+		ON CONFLICT (operation, key) DO UPDATE
+		SET fingerprint = EXCLUDED.fingerprint,
+			response = EXCLUDED.response,
+			created_at = EXCLUDED.created_at,
+			expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= EXCLUDED.created_at`
+)
+
+// idempotencyRepository implements IdempotencyRepository interface
+type idempotencyRepository struct {
+	ext     sqlx.ExtContext
+	dialect *dialect
+}
+
+// newIdempotencyRepository creates new idempotency key repository
+func newIdempotencyRepository(ext sqlx.ExtContext, dialect *dialect) repository.IdempotencyRepository {
+	return &idempotencyRepository{
+		ext:     ext,
+		dialect: dialect,
+	}
+}
+
+func (r *idempotencyRepository) Get(
+	ctx context.Context, operation string, key string, now time.Time) (*repository.IdempotencyKey, error) {
+
+	var idempotencyKey repository.IdempotencyKey
+	err := r.dialect.getContext(ctx, sqlxExt(ctx, r.ext), &idempotencyKey, getIdempotencyKeySQL, operation, key, now)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &idempotencyKey, nil
+}
+
+func (r *idempotencyRepository) Store(ctx context.Context, key *repository.IdempotencyKey) error {
+	res, err := r.dialect.namedExec(ctx, sqlxExt(ctx, r.ext), storeIdempotencyKeySQL, key)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return repository.ErrIdempotencyKeyExists
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package repositoryengine
+
+import (
+	"context"
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service"
+	"github.com/Toshik1978/go-rest-api/service/testutil"
+	"github.com/stretchr/testify/suite"
+)
+
+type sqliteIdempotencyRepositoryTestSuite struct {
+	suite.Suite
+
+	client     service.DBClient
+	repository repository.IdempotencyRepository
+	key        repository.IdempotencyKey
+}
+
+func (s *sqliteIdempotencyRepositoryTestSuite) SetupTest() {
+	s.client = newSQLiteTestClient(s.T())
+	s.repository = newIdempotencyRepository(s.client.GetConnection(), sqliteDialect)
+	s.key = testutil.RepositoryIdempotencyKey()
+}
+
+func (s *sqliteIdempotencyRepositoryTestSuite) TearDownTest() {
+	s.client.Stop()
+}
+
+func (s *sqliteIdempotencyRepositoryTestSuite) TestGetIdempotencyKeyEmptySucceeded() {
+	key, err := s.repository.Get(context.Background(), s.key.Operation, s.key.Key, s.key.CreatedAt)
+
+	s.NoError(err)
+	s.Nil(key)
+}
+
+func (s *sqliteIdempotencyRepositoryTestSuite) TestGetIdempotencyKeySucceeded() {
+	s.Require().NoError(s.repository.Store(context.Background(), &s.key))
+
+	key, err := s.repository.Get(context.Background(), s.key.Operation, s.key.Key, s.key.CreatedAt)
+	s.NoError(err)
+	s.Equal(s.key.Fingerprint, key.Fingerprint)
+	s.Equal(s.key.Response, key.Response)
+	s.True(s.key.ExpiresAt.Equal(key.ExpiresAt))
+
+	// Expired key isn't returned
+	key, err = s.repository.Get(context.Background(), s.key.Operation, s.key.Key, s.key.ExpiresAt)
+	s.NoError(err)
+	s.Nil(key)
+}
+
+func (s *sqliteIdempotencyRepositoryTestSuite) TestStoreIdempotencyKeyExistsFailed() {
+	s.Require().NoError(s.repository.Store(context.Background(), &s.key))
+	err := s.repository.Store(context.Background(), &s.key)
+
+	s.Equal(repository.ErrIdempotencyKeyExists, err)
+}
+
+func (s *sqliteIdempotencyRepositoryTestSuite) TestStoreIdempotencyKeyExpiredSucceeded() {
+	s.Require().NoError(s.repository.Store(context.Background(), &s.key))
+
+	replaced := s.key
+	replaced.Fingerprint = "replaced"
+	replaced.CreatedAt = s.key.ExpiresAt
+	replaced.ExpiresAt = s.key.ExpiresAt.Add(time.Hour)
+	s.NoError(s.repository.Store(context.Background(), &replaced))
+
+	key, err := s.repository.Get(context.Background(), s.key.Operation, s.key.Key, replaced.CreatedAt)
+	s.NoError(err)
+	s.Equal("replaced", key.Fingerprint)
+}
@@ -0,0 +1,156 @@
+package repositoryengine
+
+import (
+	"context"
+	"errors"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service/testutil"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/suite"
+)
+
+type idempotencyRepositoryTestSuite struct {
+	suite.Suite
+
+	key repository.IdempotencyKey
+}
+
+func (s *idempotencyRepositoryTestSuite) SetupSuite() {
+	s.key = testutil.RepositoryIdempotencyKey()
+}
+
+func (s *idempotencyRepositoryTestSuite) TestGetIdempotencyKeyFailed() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectQuery("^SELECT operation, key").
+		WithArgs(s.key.Operation, s.key.Key, s.key.CreatedAt).
+		WillReturnError(errors.New("fail"))
+
+	repository := newIdempotencyRepository(sqlxDB, postgresDialect)
+	key, err := repository.Get(context.Background(), s.key.Operation, s.key.Key, s.key.CreatedAt)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.Error(err)
+	s.Nil(key)
+}
+
+func (s *idempotencyRepositoryTestSuite) TestGetIdempotencyKeyEmptySucceeded() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	rows := sqlmock.
+		NewRows([]string{"operation", "key", "fingerprint", "response", "created_at", "expires_at"})
+
+	mockSQL.
+		ExpectQuery("^SELECT operation, key").
+		WithArgs(s.key.Operation, s.key.Key, s.key.CreatedAt).
+		WillReturnRows(rows)
+
+	repository := newIdempotencyRepository(sqlxDB, postgresDialect)
+	key, err := repository.Get(context.Background(), s.key.Operation, s.key.Key, s.key.CreatedAt)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.NoError(err)
+	s.Nil(key)
+}
+
+func (s *idempotencyRepositoryTestSuite) TestGetIdempotencyKeySucceeded() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	rows := sqlmock.
+		NewRows([]string{"operation", "key", "fingerprint", "response", "created_at", "expires_at"}).
+		AddRow(s.key.Operation, s.key.Key, s.key.Fingerprint, s.key.Response, s.key.CreatedAt, s.key.ExpiresAt)
+
+	mockSQL.
+		ExpectQuery("^SELECT operation, key").
+		WithArgs(s.key.Operation, s.key.Key, s.key.CreatedAt).
+		WillReturnRows(rows)
+
+	repository := newIdempotencyRepository(sqlxDB, postgresDialect)
+	key, err := repository.Get(context.Background(), s.key.Operation, s.key.Key, s.key.CreatedAt)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.NoError(err)
+	s.NotNil(key)
+	s.EqualValues(s.key, *key)
+}
+
+func (s *idempotencyRepositoryTestSuite) TestStoreIdempotencyKeyFailed() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectExec("^INSERT INTO idempotency_keys").
+		WithArgs(s.key.Operation, s.key.Key, s.key.Fingerprint, s.key.Response, s.key.CreatedAt, s.key.ExpiresAt).
+		WillReturnError(errors.New("fail"))
+
+	repository := newIdempotencyRepository(sqlxDB, postgresDialect)
+	key := s.key
+	err = repository.Store(context.Background(), &key)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.Error(err)
+}
+
+func (s *idempotencyRepositoryTestSuite) TestStoreIdempotencyKeyExistsFailed() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectExec("^INSERT INTO idempotency_keys").
+		WithArgs(s.key.Operation, s.key.Key, s.key.Fingerprint, s.key.Response, s.key.CreatedAt, s.key.ExpiresAt).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	idempotencyRepository := newIdempotencyRepository(sqlxDB, postgresDialect)
+	key := s.key
+	err = idempotencyRepository.Store(context.Background(), &key)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.True(errors.Is(err, repository.ErrIdempotencyKeyExists))
+}
+
+func (s *idempotencyRepositoryTestSuite) TestStoreIdempotencyKeySucceeded() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectExec("^INSERT INTO idempotency_keys").
+		WithArgs(s.key.Operation, s.key.Key, s.key.Fingerprint, s.key.Response, s.key.CreatedAt, s.key.ExpiresAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	repository := newIdempotencyRepository(sqlxDB, postgresDialect)
+	key := s.key
+	err = repository.Store(context.Background(), &key)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.NoError(err)
+}
@@ -0,0 +1,178 @@
+package repositoryengine
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	entryColumns = `
+		p.id AS "posting.id", p.transfer_id AS "posting.transfer_id", p.account_uid AS "posting.account_uid",
+		p.amount AS "posting.amount", p.currency AS "posting.currency", p.created_at AS "posting.created_at",
+		t.id AS "transfer.id", t.payer_account_uid AS "transfer.payer_account_uid",
+		t.recipient_account_uid AS "transfer.recipient_account_uid",
+		t.source_amount AS "transfer.source_amount", t.source_currency AS "transfer.source_currency",
+		t.target_amount AS "transfer.target_amount", t.target_currency AS "transfer.target_currency",
+		t.rate AS "transfer.rate", t.created_at AS "transfer.created_at",
+		rf.refunded_transfer_id AS "transfer.refund_of", ` + refundedAmountSQL + ` AS "transfer.refunded_amount"`
+	// Refunded amount is sum of target amounts of the transfer's refunds
+	refundedAmountSQL = `
+		(SELECT CAST(COALESCE(SUM(rt.target_amount), 0) AS BIGINT)
+		FROM refunds r
+		JOIN transfers rt ON rt.id = r.transfer_id
+		WHERE r.refunded_transfer_id = t.id)`
+	// Only payer's and recipient's postings are payments, internal postings (e.g. currency exchange) are not
+	getAllEntriesSQL = `
+		SELECT ` + entryColumns + `
+		FROM postings p
+		JOIN transfers t ON t.id = p.transfer_id
+		LEFT JOIN refunds rf ON rf.transfer_id = t.id
+		WHERE p.id > ? AND p.account_uid IN (t.payer_account_uid, t.recipient_account_uid)
+		ORDER BY p.id
+		LIMIT ?`
+	// Additional conditions are appended by filter
+	getEntriesByAccountSQL = `
+		SELECT ` + entryColumns + `
+		FROM postings p
+		JOIN transfers t ON t.id = p.transfer_id
+		LEFT JOIN refunds rf ON rf.transfer_id = t.id
+		WHERE p.account_uid = ? AND p.id > ? AND p.account_uid IN (t.payer_account_uid, t.recipient_account_uid)`
+
+	getTransferByIDSQL = `
+		SELECT t.id, t.payer_account_uid, t.recipient_account_uid,
+			t.source_amount, t.source_currency, t.target_amount, t.target_currency, t.rate, t.created_at,
+			rf.refunded_transfer_id AS refund_of, ` + refundedAmountSQL + ` AS refunded_amount
+		FROM transfers t
+		LEFT JOIN refunds rf ON rf.transfer_id = t.id
+		WHERE t.id = ?`
+
+	storeTransferSQL = `
+		INSERT INTO transfers
+			(payer_account_uid, recipient_account_uid,
+			source_amount, source_currency, target_amount, target_currency, rate, created_at)
+		VALUES
+			(:payer_account_uid, :recipient_account_uid,
+			:source_amount, :source_currency, :target_amount, :target_currency, :rate, :created_at)`
+	storePostingSQL = `
+		INSERT INTO postings
+			(transfer_id, account_uid, amount, currency, created_at)
+		VALUES
+			(:transfer_id, :account_uid, :amount, :currency, :created_at)`
+	storeRefundSQL = `
+		INSERT INTO refunds
+			(transfer_id, refunded_transfer_id)
+		VALUES
+			(:id, :refund_of)`
+)
+
+// ledgerRepository implements LedgerRepository interface
+type ledgerRepository struct {
+	ext     sqlx.ExtContext
+	dialect *dialect
+}
+
+// newLedgerRepository creates new ledger repository
+func newLedgerRepository(ext sqlx.ExtContext, dialect *dialect) repository.LedgerRepository {
+	return &ledgerRepository{
+		ext:     ext,
+		dialect: dialect,
+	}
+}
+
+func (r *ledgerRepository) GetAll(ctx context.Context, page repository.Page) ([]repository.Entry, error) {
+	var entries []repository.Entry
+	err := r.dialect.selectContext(ctx, sqlxExt(ctx, r.ext), &entries, getAllEntriesSQL, page.AfterID, page.Limit)
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (r *ledgerRepository) GetByAccount(
+	ctx context.Context, filter repository.PaymentFilter, page repository.Page) ([]repository.Entry, error) {
+
+	query := getEntriesByAccountSQL
+	args := []interface{}{filter.AccountUID, page.AfterID}
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		query += " AND " + condition
+	}
+
+	// Debit posting belongs to payer, so counterparty is recipient and vice versa
+	if filter.CounterpartyUID != "" {
+		where("CASE WHEN p.amount < 0 THEN t.recipient_account_uid ELSE t.payer_account_uid END = ?",
+			filter.CounterpartyUID)
+	}
+	switch filter.Direction {
+	case repository.OutgoingDirection:
+		query += " AND p.amount < 0"
+	case repository.IncomingDirection:
+		query += " AND p.amount >= 0"
+	}
+	if filter.From != nil {
+		where("p.created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		where("p.created_at < ?", *filter.To)
+	}
+	if filter.MinAmount != nil {
+		where("ABS(p.amount) >= ?", *filter.MinAmount)
+	}
+	if filter.MaxAmount != nil {
+		where("ABS(p.amount) <= ?", *filter.MaxAmount)
+	}
+	args = append(args, page.Limit)
+	query += " ORDER BY p.id LIMIT ?"
+
+	var entries []repository.Entry
+	if err := r.dialect.selectContext(ctx, sqlxExt(ctx, r.ext), &entries, query, args...); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (r *ledgerRepository) GetByID(ctx context.Context, id int64) (*repository.Transfer, error) {
+	var transfer repository.Transfer
+	err := r.dialect.getContext(ctx, sqlxExt(ctx, r.ext), &transfer, getTransferByIDSQL, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.ErrTransferNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+func (r *ledgerRepository) Store(ctx context.Context, transfer *repository.Transfer) error {
+	// PostgreSQL checks the same invariant on commit, but it's better to fail earlier. SQLite can't check it at all
+	if !repository.Balanced(transfer.Postings) {
+		return repository.ErrUnbalancedTransfer
+	}
+
+	ext := sqlxExt(ctx, r.ext)
+	err := r.dialect.namedInsert(ctx, ext, storeTransferSQL, transfer, &transfer.ID)
+	if r.dialect.isViolation(err, foreignKeyViolation) {
+		return repository.ErrAccountNotFound
+	}
+	if err != nil {
+		return err
+	}
+	for i := range transfer.Postings {
+		posting := &transfer.Postings[i]
+		posting.TransferID = transfer.ID
+		posting.CreatedAt = transfer.CreatedAt
+		if err := r.dialect.namedInsert(ctx, ext, storePostingSQL, posting, &posting.ID); err != nil {
+			return err
+		}
+	}
+	if transfer.RefundOf != nil {
+		if _, err := r.dialect.namedExec(ctx, ext, storeRefundSQL, transfer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
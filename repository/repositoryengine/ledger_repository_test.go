@@ -0,0 +1,390 @@
+package repositoryengine
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/AlekSi/pointer"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service/testutil"
+	"github.com/jackc/pgconn"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/suite"
+)
+
+type ledgerRepositoryTestSuite struct {
+	suite.Suite
+
+	transfer repository.Transfer
+	entry    repository.Entry
+	page     repository.Page
+}
+
+func (s *ledgerRepositoryTestSuite) SetupSuite() {
+	s.transfer = testutil.RepositoryTransfer()
+	s.entry = testutil.RepositoryEntry()
+	s.page = repository.Page{AfterID: 1000, Limit: 10}
+}
+
+func (s *ledgerRepositoryTestSuite) entryRows() *sqlmock.Rows {
+	return sqlmock.
+		NewRows([]string{"posting.id", "posting.transfer_id", "posting.account_uid",
+			"posting.amount", "posting.currency", "posting.created_at",
+			"transfer.id", "transfer.payer_account_uid", "transfer.recipient_account_uid",
+			"transfer.source_amount", "transfer.source_currency", "transfer.target_amount", "transfer.target_currency",
+			"transfer.rate", "transfer.created_at", "transfer.refund_of", "transfer.refunded_amount"})
+}
+
+func (s *ledgerRepositoryTestSuite) addEntryRow(rows *sqlmock.Rows) *sqlmock.Rows {
+	posting := s.entry.Posting
+	transfer := s.entry.Transfer
+	return rows.
+		AddRow(posting.ID, posting.TransferID, posting.AccountUID,
+			posting.Amount, posting.Currency, posting.CreatedAt,
+			transfer.ID, transfer.PayerAccountUID, transfer.RecipientAccountUID,
+			transfer.SourceAmount, transfer.SourceCurrency, transfer.TargetAmount, transfer.TargetCurrency,
+			transfer.Rate, transfer.CreatedAt, transfer.RefundOf, transfer.RefundedAmount)
+}
+
+func (s *ledgerRepositoryTestSuite) copyTransfer() repository.Transfer {
+	transfer := s.transfer
+	transfer.Postings = append([]repository.Posting(nil), s.transfer.Postings...)
+	return transfer
+}
+
+func (s *ledgerRepositoryTestSuite) TestGetAllEntriesFailed() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectQuery("^SELECT.*FROM postings p").
+		WithArgs(s.page.AfterID, s.page.Limit).
+		WillReturnError(errors.New("fail"))
+
+	ledgerRepository := newLedgerRepository(sqlxDB, postgresDialect)
+	entries, err := ledgerRepository.GetAll(context.Background(), s.page)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.Error(err)
+	s.Nil(entries)
+}
+
+func (s *ledgerRepositoryTestSuite) TestGetAllEntriesEmptySucceeded() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectQuery("^SELECT.*FROM postings p").
+		WithArgs(s.page.AfterID, s.page.Limit).
+		WillReturnRows(s.entryRows())
+
+	ledgerRepository := newLedgerRepository(sqlxDB, postgresDialect)
+	entries, err := ledgerRepository.GetAll(context.Background(), s.page)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.NoError(err)
+	s.Empty(entries)
+}
+
+func (s *ledgerRepositoryTestSuite) TestGetAllEntriesSucceeded() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectQuery("^SELECT.*FROM postings p").
+		WithArgs(s.page.AfterID, s.page.Limit).
+		WillReturnRows(s.addEntryRow(s.entryRows()))
+
+	ledgerRepository := newLedgerRepository(sqlxDB, postgresDialect)
+	entries, err := ledgerRepository.GetAll(context.Background(), s.page)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.NoError(err)
+	s.Len(entries, 1)
+	s.EqualValues(s.entry, entries[0])
+}
+
+func (s *ledgerRepositoryTestSuite) TestGetEntriesByAccountFailed() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectQuery("^SELECT.*FROM postings p").
+		WithArgs(s.transfer.PayerAccountUID, s.page.AfterID, s.page.Limit).
+		WillReturnError(errors.New("fail"))
+
+	ledgerRepository := newLedgerRepository(sqlxDB, postgresDialect)
+	entries, err := ledgerRepository.GetByAccount(context.Background(),
+		repository.PaymentFilter{AccountUID: s.transfer.PayerAccountUID}, s.page)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.Error(err)
+	s.Nil(entries)
+}
+
+func (s *ledgerRepositoryTestSuite) TestGetEntriesByAccountSucceeded() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	from := s.transfer.CreatedAt.Add(-time.Hour)
+	to := s.transfer.CreatedAt.Add(time.Hour)
+	filter := repository.PaymentFilter{
+		AccountUID:      s.transfer.PayerAccountUID,
+		CounterpartyUID: s.transfer.RecipientAccountUID,
+		Direction:       repository.OutgoingDirection,
+		From:            &from,
+		To:              &to,
+		MinAmount:       pointer.ToInt64(100),
+		MaxAmount:       pointer.ToInt64(100000),
+	}
+
+	mockSQL.
+		ExpectQuery(`(?s)^SELECT.*WHERE p.account_uid = \$1 AND p.id > \$2 .*`+
+			`AND CASE WHEN p.amount < 0 THEN t.recipient_account_uid ELSE t.payer_account_uid END = \$3 `+
+			`AND p.amount < 0 AND p.created_at >= \$4 AND p.created_at < \$5 `+
+			`AND ABS\(p.amount\) >= \$6 AND ABS\(p.amount\) <= \$7 ORDER BY p.id LIMIT \$8$`).
+		WithArgs(s.transfer.PayerAccountUID, s.page.AfterID, s.transfer.RecipientAccountUID,
+			from, to, int64(100), int64(100000), s.page.Limit).
+		WillReturnRows(s.addEntryRow(s.entryRows()))
+
+	ledgerRepository := newLedgerRepository(sqlxDB, postgresDialect)
+	entries, err := ledgerRepository.GetByAccount(context.Background(), filter, s.page)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.NoError(err)
+	s.Len(entries, 1)
+	s.EqualValues(s.entry, entries[0])
+}
+
+func (s *ledgerRepositoryTestSuite) TestGetIncomingEntriesByAccountSucceeded() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectQuery(`(?s)^SELECT.*WHERE p.account_uid = \$1 AND p.id > \$2 .*`+
+			`AND p.amount >= 0 ORDER BY p.id LIMIT \$3$`).
+		WithArgs(s.transfer.PayerAccountUID, s.page.AfterID, s.page.Limit).
+		WillReturnRows(s.entryRows())
+
+	ledgerRepository := newLedgerRepository(sqlxDB, postgresDialect)
+	entries, err := ledgerRepository.GetByAccount(context.Background(), repository.PaymentFilter{
+		AccountUID: s.transfer.PayerAccountUID,
+		Direction:  repository.IncomingDirection,
+	}, s.page)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.NoError(err)
+	s.Empty(entries)
+}
+
+func (s *ledgerRepositoryTestSuite) TestGetTransferByIDNotFoundFailed() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectQuery("^SELECT (.+) FROM transfers t").
+		WithArgs(s.transfer.ID).
+		WillReturnError(sql.ErrNoRows)
+
+	ledgerRepository := newLedgerRepository(sqlxDB, postgresDialect)
+	transfer, err := ledgerRepository.GetByID(context.Background(), s.transfer.ID)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.Equal(repository.ErrTransferNotFound, err)
+	s.Nil(transfer)
+}
+
+func (s *ledgerRepositoryTestSuite) TestGetTransferByIDSucceeded() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectQuery("^SELECT (.+) FROM transfers t").
+		WithArgs(s.transfer.ID).
+		WillReturnRows(sqlmock.
+			NewRows([]string{"id", "payer_account_uid", "recipient_account_uid",
+				"source_amount", "source_currency", "target_amount", "target_currency",
+				"rate", "created_at", "refund_of", "refunded_amount"}).
+			AddRow(s.transfer.ID, s.transfer.PayerAccountUID, s.transfer.RecipientAccountUID,
+				s.transfer.SourceAmount, s.transfer.SourceCurrency, s.transfer.TargetAmount, s.transfer.TargetCurrency,
+				s.transfer.Rate, s.transfer.CreatedAt, nil, 100))
+
+	ledgerRepository := newLedgerRepository(sqlxDB, postgresDialect)
+	transfer, err := ledgerRepository.GetByID(context.Background(), s.transfer.ID)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.NoError(err)
+	s.Equal(s.transfer.ID, transfer.ID)
+	s.Nil(transfer.RefundOf)
+	s.Equal(int64(100), transfer.RefundedAmount)
+	s.Nil(transfer.Postings)
+}
+
+func (s *ledgerRepositoryTestSuite) TestStoreTransferUnbalancedFailed() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	ledgerRepository := newLedgerRepository(sqlxDB, postgresDialect)
+	transfer := s.copyTransfer()
+	transfer.Postings[1].Amount--
+	err = ledgerRepository.Store(context.Background(), &transfer)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.True(errors.Is(err, repository.ErrUnbalancedTransfer))
+
+	transfer.Postings = nil
+	err = ledgerRepository.Store(context.Background(), &transfer)
+
+	s.True(errors.Is(err, repository.ErrUnbalancedTransfer))
+}
+
+func (s *ledgerRepositoryTestSuite) TestStoreTransferAccountNotFoundFailed() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectQuery("^INSERT INTO transfers").
+		WithArgs(s.transfer.PayerAccountUID, s.transfer.RecipientAccountUID,
+			s.transfer.SourceAmount, s.transfer.SourceCurrency, s.transfer.TargetAmount, s.transfer.TargetCurrency,
+			s.transfer.Rate, s.transfer.CreatedAt).
+		WillReturnError(&pgconn.PgError{Code: "23503"})
+
+	ledgerRepository := newLedgerRepository(sqlxDB, postgresDialect)
+	transfer := s.copyTransfer()
+	err = ledgerRepository.Store(context.Background(), &transfer)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.True(errors.Is(err, repository.ErrAccountNotFound))
+}
+
+func (s *ledgerRepositoryTestSuite) TestStorePostingFailed() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectQuery("^INSERT INTO transfers").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(s.transfer.ID))
+	mockSQL.
+		ExpectQuery("^INSERT INTO postings").
+		WillReturnError(errors.New("fail"))
+
+	ledgerRepository := newLedgerRepository(sqlxDB, postgresDialect)
+	transfer := s.copyTransfer()
+	err = ledgerRepository.Store(context.Background(), &transfer)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.Error(err)
+}
+
+func (s *ledgerRepositoryTestSuite) TestStoreTransferSucceeded() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectQuery("^INSERT INTO transfers").
+		WithArgs(s.transfer.PayerAccountUID, s.transfer.RecipientAccountUID,
+			s.transfer.SourceAmount, s.transfer.SourceCurrency, s.transfer.TargetAmount, s.transfer.TargetCurrency,
+			s.transfer.Rate, s.transfer.CreatedAt).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(s.transfer.ID))
+	for _, posting := range s.transfer.Postings {
+		mockSQL.
+			ExpectQuery("^INSERT INTO postings").
+			WithArgs(s.transfer.ID, posting.AccountUID, posting.Amount, posting.Currency, s.transfer.CreatedAt).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(posting.ID))
+	}
+
+	ledgerRepository := newLedgerRepository(sqlxDB, postgresDialect)
+	transfer := s.copyTransfer()
+	transfer.ID = 0
+	for i := range transfer.Postings {
+		transfer.Postings[i].ID = 0
+		transfer.Postings[i].TransferID = 0
+	}
+	err = ledgerRepository.Store(context.Background(), &transfer)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.NoError(err)
+	s.EqualValues(s.transfer, transfer)
+}
+
+func (s *ledgerRepositoryTestSuite) TestStoreRefundSucceeded() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	refundOf := s.transfer.ID - 1
+	mockSQL.
+		ExpectQuery("^INSERT INTO transfers").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(s.transfer.ID))
+	for _, posting := range s.transfer.Postings {
+		mockSQL.
+			ExpectQuery("^INSERT INTO postings").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(posting.ID))
+	}
+	mockSQL.
+		ExpectExec("^INSERT INTO refunds").
+		WithArgs(s.transfer.ID, refundOf).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	ledgerRepository := newLedgerRepository(sqlxDB, postgresDialect)
+	transfer := s.copyTransfer()
+	transfer.RefundOf = &refundOf
+	err = ledgerRepository.Store(context.Background(), &transfer)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.NoError(err)
+}
@@ -0,0 +1,68 @@
+package repositoryengine
+
+import (
+	"context"
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	getPendingEventsSQL = `
+		SELECT id, type, payload, created_at, attempts, next_attempt_at, last_error, dispatched_at
+		FROM outbox_events
+		WHERE dispatched_at IS NULL AND next_attempt_at <= ?
+		ORDER BY id
+		LIMIT ?`
+
+	storeEventSQL = `
+		INSERT INTO outbox_events
+			(type, payload, created_at, attempts, next_attempt_at, last_error)
+		VALUES
+			(:type, :payload, :created_at, :attempts, :next_attempt_at, :last_error)`
+	markEventDispatchedSQL = `
+		UPDATE outbox_events
+		SET dispatched_at = ?
+		WHERE id = ?`
+	markEventFailedSQL = `
+		UPDATE outbox_events
+		SET attempts = attempts + 1, last_error = ?, next_attempt_at = ?
+		WHERE id = ?`
+)
+
+// outboxRepository implements OutboxRepository interface
+type outboxRepository struct {
+	ext     sqlx.ExtContext
+	dialect *dialect
+}
+
+// newOutboxRepository creates new outbox repository
+func newOutboxRepository(ext sqlx.ExtContext, dialect *dialect) repository.OutboxRepository {
+	return &outboxRepository{
+		ext:     ext,
+		dialect: dialect,
+	}
+}
+
+func (r *outboxRepository) Store(ctx context.Context, event *repository.Event) error {
+	return r.dialect.namedInsert(ctx, sqlxExt(ctx, r.ext), storeEventSQL, event, &event.ID)
+}
+
+func (r *outboxRepository) GetPending(ctx context.Context, now time.Time, limit int) ([]repository.Event, error) {
+	var events []repository.Event
+	if err := r.dialect.selectContext(ctx, sqlxExt(ctx, r.ext), &events, getPendingEventsSQL, now, limit); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *outboxRepository) MarkDispatched(ctx context.Context, id int64, dispatchedAt time.Time) error {
+	_, err := r.dialect.execContext(ctx, sqlxExt(ctx, r.ext), markEventDispatchedSQL, dispatchedAt, id)
+	return err
+}
+
+func (r *outboxRepository) MarkFailed(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time) error {
+	_, err := r.dialect.execContext(ctx, sqlxExt(ctx, r.ext), markEventFailedSQL, lastError, nextAttemptAt, id)
+	return err
+}
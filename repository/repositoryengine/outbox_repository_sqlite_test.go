@@ -0,0 +1,80 @@
+package repositoryengine
+
+import (
+	"context"
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service"
+	"github.com/Toshik1978/go-rest-api/service/testutil"
+	"github.com/stretchr/testify/suite"
+)
+
+type sqliteOutboxRepositoryTestSuite struct {
+	suite.Suite
+
+	client     service.DBClient
+	repository repository.OutboxRepository
+	event      repository.Event
+}
+
+func (s *sqliteOutboxRepositoryTestSuite) SetupTest() {
+	s.client = newSQLiteTestClient(s.T())
+	s.repository = newOutboxRepository(s.client.GetConnection(), sqliteDialect)
+	s.event = testutil.RepositoryEvent()
+}
+
+func (s *sqliteOutboxRepositoryTestSuite) TearDownTest() {
+	s.client.Stop()
+}
+
+func (s *sqliteOutboxRepositoryTestSuite) TestGetPendingEventsSucceeded() {
+	first, second := s.event, s.event
+	second.NextAttemptAt = s.event.NextAttemptAt.Add(time.Minute)
+	s.Require().NoError(s.repository.Store(context.Background(), &first))
+	s.Require().NoError(s.repository.Store(context.Background(), &second))
+	s.NotEqual(first.ID, second.ID)
+
+	// Event isn't returned before its next attempt
+	events, err := s.repository.GetPending(context.Background(), s.event.CreatedAt, 10)
+	s.NoError(err)
+	s.Require().Len(events, 1)
+	s.Equal(first.ID, events[0].ID)
+	s.Equal(first.Type, events[0].Type)
+	s.Equal(first.Payload, events[0].Payload)
+	s.True(first.CreatedAt.Equal(events[0].CreatedAt))
+	s.Nil(events[0].DispatchedAt)
+
+	events, err = s.repository.GetPending(context.Background(), second.NextAttemptAt, 1)
+	s.NoError(err)
+	s.Require().Len(events, 1)
+	s.Equal(first.ID, events[0].ID)
+}
+
+func (s *sqliteOutboxRepositoryTestSuite) TestMarkEventDispatchedSucceeded() {
+	s.Require().NoError(s.repository.Store(context.Background(), &s.event))
+
+	s.NoError(s.repository.MarkDispatched(context.Background(), s.event.ID, s.event.CreatedAt))
+
+	events, err := s.repository.GetPending(context.Background(), s.event.CreatedAt, 10)
+	s.NoError(err)
+	s.Empty(events)
+}
+
+func (s *sqliteOutboxRepositoryTestSuite) TestMarkEventFailedSucceeded() {
+	s.Require().NoError(s.repository.Store(context.Background(), &s.event))
+
+	nextAttemptAt := s.event.CreatedAt.Add(time.Minute)
+	s.NoError(s.repository.MarkFailed(context.Background(), s.event.ID, "fail", nextAttemptAt))
+
+	events, err := s.repository.GetPending(context.Background(), s.event.CreatedAt, 10)
+	s.NoError(err)
+	s.Empty(events)
+
+	events, err = s.repository.GetPending(context.Background(), nextAttemptAt, 10)
+	s.NoError(err)
+	s.Require().Len(events, 1)
+	s.Equal(1, events[0].Attempts)
+	s.Equal("fail", events[0].LastError)
+	s.True(nextAttemptAt.Equal(events[0].NextAttemptAt))
+}
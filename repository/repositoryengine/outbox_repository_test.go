@@ -0,0 +1,162 @@
+package repositoryengine
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service/testutil"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/suite"
+)
+
+type outboxRepositoryTestSuite struct {
+	suite.Suite
+
+	event repository.Event
+}
+
+func (s *outboxRepositoryTestSuite) SetupSuite() {
+	s.event = testutil.RepositoryEvent()
+}
+
+func (s *outboxRepositoryTestSuite) TestStoreEventFailed() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectQuery("^INSERT INTO outbox_events").
+		WithArgs(s.event.Type, s.event.Payload, s.event.CreatedAt, 0, s.event.NextAttemptAt, "").
+		WillReturnError(errors.New("fail"))
+
+	repository := newOutboxRepository(sqlxDB, postgresDialect)
+	event := s.event
+	err = repository.Store(context.Background(), &event)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.Error(err)
+}
+
+func (s *outboxRepositoryTestSuite) TestStoreEventSucceeded() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	rows := sqlmock.
+		NewRows([]string{"id"}).
+		AddRow(7)
+
+	mockSQL.
+		ExpectQuery("^INSERT INTO outbox_events").
+		WithArgs(s.event.Type, s.event.Payload, s.event.CreatedAt, 0, s.event.NextAttemptAt, "").
+		WillReturnRows(rows)
+
+	repository := newOutboxRepository(sqlxDB, postgresDialect)
+	event := s.event
+	err = repository.Store(context.Background(), &event)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.NoError(err)
+	s.Equal(int64(7), event.ID)
+}
+
+func (s *outboxRepositoryTestSuite) TestGetPendingEventsFailed() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectQuery("^SELECT id, type, payload").
+		WithArgs(s.event.CreatedAt, 10).
+		WillReturnError(errors.New("fail"))
+
+	repository := newOutboxRepository(sqlxDB, postgresDialect)
+	events, err := repository.GetPending(context.Background(), s.event.CreatedAt, 10)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.Error(err)
+	s.Nil(events)
+}
+
+func (s *outboxRepositoryTestSuite) TestGetPendingEventsSucceeded() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	rows := sqlmock.
+		NewRows([]string{
+			"id", "type", "payload", "created_at", "attempts", "next_attempt_at", "last_error", "dispatched_at",
+		}).
+		AddRow(7, s.event.Type, s.event.Payload, s.event.CreatedAt, 0, s.event.NextAttemptAt, "", nil)
+
+	mockSQL.
+		ExpectQuery("^SELECT id, type, payload").
+		WithArgs(s.event.CreatedAt, 10).
+		WillReturnRows(rows)
+
+	outboxRepository := newOutboxRepository(sqlxDB, postgresDialect)
+	events, err := outboxRepository.GetPending(context.Background(), s.event.CreatedAt, 10)
+
+	expected := s.event
+	expected.ID = 7
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.NoError(err)
+	s.Equal([]repository.Event{expected}, events)
+}
+
+func (s *outboxRepositoryTestSuite) TestMarkEventDispatchedSucceeded() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	dispatchedAt := s.event.CreatedAt.Add(time.Second)
+	mockSQL.
+		ExpectExec("^UPDATE outbox_events").
+		WithArgs(dispatchedAt, 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	repository := newOutboxRepository(sqlxDB, postgresDialect)
+	err = repository.MarkDispatched(context.Background(), 7, dispatchedAt)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.NoError(err)
+}
+
+func (s *outboxRepositoryTestSuite) TestMarkEventFailedSucceeded() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	nextAttemptAt := s.event.CreatedAt.Add(time.Second)
+	mockSQL.
+		ExpectExec("^UPDATE outbox_events").
+		WithArgs("fail", nextAttemptAt, 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	repository := newOutboxRepository(sqlxDB, postgresDialect)
+	err = repository.MarkFailed(context.Background(), 7, "fail", nextAttemptAt)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.NoError(err)
+}
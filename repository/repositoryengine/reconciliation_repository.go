@@ -0,0 +1,72 @@
+package repositoryengine
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	getDriftsSQL = `
+		SELECT a.uid AS account_uid, a.currency,
+			a.opening_balance + COALESCE(SUM(p.amount), 0) AS expected_balance, a.balance AS actual_balance
+		FROM accounts a
+		LEFT JOIN postings p ON p.account_uid = a.uid
+		GROUP BY a.uid, a.currency, a.opening_balance, a.balance
+		HAVING a.balance <> a.opening_balance + COALESCE(SUM(p.amount), 0)
+		ORDER BY a.uid`
+
+	// Adjustment is recorded only if account is actually updated
+	storeAdjustmentSQL = `
+		WITH updated AS (
+			UPDATE accounts
+			SET balance = balance + :amount
+			WHERE uid = :account_uid
+			RETURNING uid)
+		INSERT INTO balance_adjustments
+			(account_uid, expected_balance, actual_balance, amount, reason, created_at)
+		SELECT uid, :expected_balance, :actual_balance, :amount, :reason, :created_at
+		FROM updated`
+	// Account is updated by trigger, so adjustment is recorded only if account is actually updated
+	storeAdjustmentByTriggerSQL = `
+		INSERT INTO balance_adjustments
+			(account_uid, expected_balance, actual_balance, amount, reason, created_at)
+		VALUES
+			(:account_uid, :expected_balance, :actual_balance, :amount, :reason, :created_at)`
+)
+
+// reconciliationRepository implements ReconciliationRepository interface
+type reconciliationRepository struct {
+	ext     sqlx.ExtContext
+	dialect *dialect
+}
+
+// newReconciliationRepository creates new reconciliation repository
+func newReconciliationRepository(ext sqlx.ExtContext, dialect *dialect) repository.ReconciliationRepository {
+	return &reconciliationRepository{
+		ext:     ext,
+		dialect: dialect,
+	}
+}
+
+func (r *reconciliationRepository) GetDrifts(ctx context.Context) ([]repository.Drift, error) {
+	var drifts []repository.Drift
+	if err := r.dialect.selectContext(ctx, sqlxExt(ctx, r.ext), &drifts, getDriftsSQL); err != nil {
+		return nil, err
+	}
+	return drifts, nil
+}
+
+func (r *reconciliationRepository) StoreAdjustment(ctx context.Context, adjustment *repository.Adjustment) error {
+	err := r.dialect.namedInsert(ctx, sqlxExt(ctx, r.ext), r.dialect.storeAdjustmentSQL, adjustment, &adjustment.ID)
+	if errors.Is(err, sql.ErrNoRows) || r.dialect.isViolation(err, foreignKeyViolation) {
+		return repository.ErrAccountNotFound
+	}
+	if r.dialect.isViolation(err, checkViolation) {
+		return repository.ErrInsufficientFunds
+	}
+	return err
+}
@@ -0,0 +1,72 @@
+package repositoryengine
+
+import (
+	"context"
+
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service"
+	"github.com/Toshik1978/go-rest-api/service/testutil"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type sqliteReconciliationRepositoryTestSuite struct {
+	suite.Suite
+
+	client  service.DBClient
+	factory repository.Factory
+}
+
+func (s *sqliteReconciliationRepositoryTestSuite) SetupTest() {
+	s.client = newSQLiteTestClient(s.T())
+	s.factory = NewSQLiteRepositoryFactory(zap.NewNop(), s.client.GetConnection())
+	for _, uid := range []string{"toshik1979", "toshik1978"} {
+		account := testutil.RepositoryAccount()
+		account.UID = uid
+		s.Require().NoError(s.factory.AccountRepository().Store(context.Background(), &account))
+	}
+	transfer := testutil.RepositoryTransfer()
+	s.Require().NoError(s.factory.LedgerRepository().Store(context.Background(), &transfer))
+}
+
+func (s *sqliteReconciliationRepositoryTestSuite) TearDownTest() {
+	s.client.Stop()
+}
+
+func (s *sqliteReconciliationRepositoryTestSuite) TestGetDriftsSucceeded() {
+	// Balances aren't updated by the transfer, so both accounts drift
+	drifts, err := s.factory.ReconciliationRepository().GetDrifts(context.Background())
+
+	s.NoError(err)
+	s.Equal([]repository.Drift{
+		{AccountUID: "toshik1978", Currency: "USD", ExpectedBalance: 0, ActualBalance: 10000},
+		{AccountUID: "toshik1979", Currency: "USD", ExpectedBalance: 20000, ActualBalance: 10000},
+	}, drifts)
+}
+
+func (s *sqliteReconciliationRepositoryTestSuite) TestStoreAdjustmentFailed() {
+	adjustment := &repository.Adjustment{AccountUID: "toshik1980", Amount: 100, Reason: "test"}
+	s.Equal(repository.ErrAccountNotFound,
+		s.factory.ReconciliationRepository().StoreAdjustment(context.Background(), adjustment))
+
+	adjustment = &repository.Adjustment{AccountUID: "toshik1978", Amount: -10001, Reason: "test"}
+	s.Equal(repository.ErrInsufficientFunds,
+		s.factory.ReconciliationRepository().StoreAdjustment(context.Background(), adjustment))
+}
+
+func (s *sqliteReconciliationRepositoryTestSuite) TestStoreAdjustmentSucceeded() {
+	adjustment := &repository.Adjustment{
+		AccountUID:      "toshik1978",
+		ExpectedBalance: 0,
+		ActualBalance:   10000,
+		Amount:          -10000,
+		Reason:          "test",
+	}
+	s.NoError(s.factory.ReconciliationRepository().StoreAdjustment(context.Background(), adjustment))
+	s.NotZero(adjustment.ID)
+
+	drifts, err := s.factory.ReconciliationRepository().GetDrifts(context.Background())
+	s.NoError(err)
+	s.Len(drifts, 1)
+	s.Equal("toshik1979", drifts[0].AccountUID)
+}
@@ -0,0 +1,146 @@
+package repositoryengine
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service/testutil"
+	"github.com/jackc/pgconn"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/suite"
+)
+
+type reconciliationRepositoryTestSuite struct {
+	suite.Suite
+
+	drift      repository.Drift
+	adjustment repository.Adjustment
+}
+
+func (s *reconciliationRepositoryTestSuite) SetupSuite() {
+	s.drift = testutil.RepositoryDrift()
+	s.adjustment = repository.Adjustment{
+		ID:              1234,
+		AccountUID:      s.drift.AccountUID,
+		ExpectedBalance: s.drift.ExpectedBalance,
+		ActualBalance:   s.drift.ActualBalance,
+		Amount:          s.drift.ExpectedBalance - s.drift.ActualBalance,
+		Reason:          "incident 42",
+		CreatedAt:       time.Now().Round(time.Millisecond),
+	}
+}
+
+func (s *reconciliationRepositoryTestSuite) TestGetDriftsFailed() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectQuery("^SELECT a.uid AS account_uid").
+		WillReturnError(errors.New("fail"))
+
+	reconciliationRepository := newReconciliationRepository(sqlxDB, postgresDialect)
+	drifts, err := reconciliationRepository.GetDrifts(context.Background())
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.Error(err)
+	s.Nil(drifts)
+}
+
+func (s *reconciliationRepositoryTestSuite) TestGetDriftsSucceeded() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	allRows := sqlmock.
+		NewRows([]string{"account_uid", "currency", "expected_balance", "actual_balance"}).
+		AddRow(s.drift.AccountUID, s.drift.Currency, s.drift.ExpectedBalance, s.drift.ActualBalance)
+
+	mockSQL.
+		ExpectQuery(`(?s)^SELECT a.uid AS account_uid.*LEFT JOIN postings p.*` +
+			`HAVING a.balance <> a.opening_balance \+ COALESCE\(SUM\(p.amount\), 0\)`).
+		WillReturnRows(allRows)
+
+	reconciliationRepository := newReconciliationRepository(sqlxDB, postgresDialect)
+	drifts, err := reconciliationRepository.GetDrifts(context.Background())
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.NoError(err)
+	s.Len(drifts, 1)
+	s.EqualValues(s.drift, drifts[0])
+}
+
+func (s *reconciliationRepositoryTestSuite) TestStoreAdjustmentAccountNotFoundFailed() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectQuery("^WITH updated AS").
+		WillReturnError(sql.ErrNoRows)
+
+	reconciliationRepository := newReconciliationRepository(sqlxDB, postgresDialect)
+	adjustment := s.adjustment
+	err = reconciliationRepository.StoreAdjustment(context.Background(), &adjustment)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.True(errors.Is(err, repository.ErrAccountNotFound))
+}
+
+func (s *reconciliationRepositoryTestSuite) TestStoreAdjustmentNegativeBalanceFailed() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectQuery("^WITH updated AS").
+		WillReturnError(&pgconn.PgError{Code: "23514"})
+
+	reconciliationRepository := newReconciliationRepository(sqlxDB, postgresDialect)
+	adjustment := s.adjustment
+	err = reconciliationRepository.StoreAdjustment(context.Background(), &adjustment)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.True(errors.Is(err, repository.ErrInsufficientFunds))
+}
+
+func (s *reconciliationRepositoryTestSuite) TestStoreAdjustmentSucceeded() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectQuery("(?s)^WITH updated AS.*INSERT INTO balance_adjustments").
+		WithArgs(s.adjustment.Amount, s.adjustment.AccountUID,
+			s.adjustment.ExpectedBalance, s.adjustment.ActualBalance, s.adjustment.Amount,
+			s.adjustment.Reason, s.adjustment.CreatedAt).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(s.adjustment.ID))
+
+	reconciliationRepository := newReconciliationRepository(sqlxDB, postgresDialect)
+	adjustment := s.adjustment
+	adjustment.ID = 0
+	err = reconciliationRepository.StoreAdjustment(context.Background(), &adjustment)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.NoError(err)
+	s.EqualValues(s.adjustment, adjustment)
+}
@@ -1,34 +1,97 @@
 package repositoryengine
 
 import (
+	"context"
+
 	"github.com/Toshik1978/go-rest-api/repository"
 	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
 )
 
 // repositoryFactory implements RepositoryFactory interface
 type repositoryFactory struct {
-	db                *sqlx.DB
-	accountRepository repository.AccountRepository
-	paymentRepository repository.PaymentRepository
+	logger                   *zap.Logger
+	db                       *sqlx.DB
+	dialect                  *dialect
+	accountRepository        repository.AccountRepository
+	ledgerRepository         repository.LedgerRepository
+	reconciliationRepository repository.ReconciliationRepository
+	idempotencyRepository    repository.IdempotencyRepository
+	outboxRepository         repository.OutboxRepository
+	webhookRepository        repository.WebhookRepository
+	deliveryRepository       repository.DeliveryRepository
+	scheduleRepository       repository.ScheduleRepository
+	scheduleRunRepository    repository.ScheduleRunRepository
+}
+
+// NewRepositoryFactory creates factory of repositories, which keep data in PostgreSQL database
+func NewRepositoryFactory(logger *zap.Logger, db *sqlx.DB) repository.Factory {
+	return newRepositoryFactory(logger, db, postgresDialect)
 }
 
-// NewRepositoryFactory creates repository factory
-func NewRepositoryFactory(db *sqlx.DB) repository.Factory {
+// NewSQLiteRepositoryFactory creates factory of repositories, which keep data in SQLite database
+func NewSQLiteRepositoryFactory(logger *zap.Logger, db *sqlx.DB) repository.Factory {
+	return newRepositoryFactory(logger, db, sqliteDialect)
+}
+
+// newRepositoryFactory creates factory of repositories, which keep data in database of the given dialect
+func newRepositoryFactory(logger *zap.Logger, db *sqlx.DB, dialect *dialect) repository.Factory {
 	return &repositoryFactory{
-		db:                db,
-		accountRepository: newAccountRepository(db),
-		paymentRepository: newPaymentRepository(db),
+		logger:                   logger,
+		db:                       db,
+		dialect:                  dialect,
+		accountRepository:        newAccountRepository(db, dialect),
+		ledgerRepository:         newLedgerRepository(db, dialect),
+		reconciliationRepository: newReconciliationRepository(db, dialect),
+		idempotencyRepository:    newIdempotencyRepository(db, dialect),
+		outboxRepository:         newOutboxRepository(db, dialect),
+		webhookRepository:        newWebhookRepository(db, dialect),
+		deliveryRepository:       newDeliveryRepository(db, dialect),
+		scheduleRepository:       newScheduleRepository(db, dialect),
+		scheduleRunRepository:    newScheduleRunRepository(db, dialect),
 	}
 }
 
-func (f *repositoryFactory) Scope() repository.Scope {
-	return newScope(f.db)
+func (f *repositoryFactory) Scope(options ...repository.ScopeOption) repository.Scope {
+	return newScope(f.db, f.dialect, options...)
+}
+
+func (f *repositoryFactory) Retry(ctx context.Context, work func(ctx context.Context) error) error {
+	return retry(ctx, f.logger, f.dialect.isRetryable, work)
 }
 
 func (f *repositoryFactory) AccountRepository() repository.AccountRepository {
 	return f.accountRepository
 }
 
-func (f *repositoryFactory) PaymentRepository() repository.PaymentRepository {
-	return f.paymentRepository
+func (f *repositoryFactory) LedgerRepository() repository.LedgerRepository {
+	return f.ledgerRepository
+}
+
+func (f *repositoryFactory) ReconciliationRepository() repository.ReconciliationRepository {
+	return f.reconciliationRepository
+}
+
+func (f *repositoryFactory) IdempotencyRepository() repository.IdempotencyRepository {
+	return f.idempotencyRepository
+}
+
+func (f *repositoryFactory) OutboxRepository() repository.OutboxRepository {
+	return f.outboxRepository
+}
+
+func (f *repositoryFactory) WebhookRepository() repository.WebhookRepository {
+	return f.webhookRepository
+}
+
+func (f *repositoryFactory) DeliveryRepository() repository.DeliveryRepository {
+	return f.deliveryRepository
+}
+
+func (f *repositoryFactory) ScheduleRepository() repository.ScheduleRepository {
+	return f.scheduleRepository
+}
+
+func (f *repositoryFactory) ScheduleRunRepository() repository.ScheduleRunRepository {
+	return f.scheduleRunRepository
 }
@@ -0,0 +1,58 @@
+package repositoryengine
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Toshik1978/go-rest-api/service"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type sqliteRepositoryFactoryTestSuite struct {
+	suite.Suite
+
+	client service.DBClient
+}
+
+func (s *sqliteRepositoryFactoryTestSuite) SetupTest() {
+	s.client = newSQLiteTestClient(s.T())
+}
+
+func (s *sqliteRepositoryFactoryTestSuite) TearDownTest() {
+	s.client.Stop()
+}
+
+func (s *sqliteRepositoryFactoryTestSuite) TestCreateScopeSucceeded() {
+	factory := NewSQLiteRepositoryFactory(zap.NewNop(), s.client.GetConnection())
+	scope := factory.Scope()
+
+	s.NotNil(scope)
+}
+
+func (s *sqliteRepositoryFactoryTestSuite) TestGetRepositoriesSucceeded() {
+	factory := NewSQLiteRepositoryFactory(zap.NewNop(), s.client.GetConnection())
+
+	s.Equal(factory.(*repositoryFactory).accountRepository, factory.AccountRepository())
+	s.Equal(factory.(*repositoryFactory).ledgerRepository, factory.LedgerRepository())
+	s.Equal(factory.(*repositoryFactory).reconciliationRepository, factory.ReconciliationRepository())
+	s.Equal(factory.(*repositoryFactory).idempotencyRepository, factory.IdempotencyRepository())
+	s.Equal(factory.(*repositoryFactory).outboxRepository, factory.OutboxRepository())
+	s.Equal(factory.(*repositoryFactory).webhookRepository, factory.WebhookRepository())
+	s.Equal(factory.(*repositoryFactory).deliveryRepository, factory.DeliveryRepository())
+	s.Equal(factory.(*repositoryFactory).scheduleRepository, factory.ScheduleRepository())
+	s.Equal(factory.(*repositoryFactory).scheduleRunRepository, factory.ScheduleRunRepository())
+}
+
+func (s *sqliteRepositoryFactoryTestSuite) TestRetryNotRetryableFailed() {
+	factory := NewSQLiteRepositoryFactory(zap.NewNop(), s.client.GetConnection())
+
+	attempts := 0
+	err := factory.Retry(context.Background(), func(ctx context.Context) error {
+		attempts++
+		return errors.New("fail")
+	})
+
+	s.Error(err)
+	s.Equal(1, attempts)
+}
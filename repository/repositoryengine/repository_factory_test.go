@@ -2,6 +2,7 @@ package repositoryengine
 
 import (
 	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
 )
 
 type repositoryFactoryTestSuite struct {
@@ -9,24 +10,80 @@ type repositoryFactoryTestSuite struct {
 }
 
 func (s *repositoryFactoryTestSuite) TestCreateScopeSucceeded() {
-	factory := NewRepositoryFactory(nil)
+	factory := NewRepositoryFactory(zap.NewNop(), nil)
 	scope := factory.Scope()
 
 	s.NotNil(scope)
 }
 
 func (s *repositoryFactoryTestSuite) TestGetAccountRepositorySucceeded() {
-	factory := NewRepositoryFactory(nil)
+	factory := NewRepositoryFactory(zap.NewNop(), nil)
 	repository := factory.AccountRepository()
 
 	s.NotNil(repository)
 	s.Equal(factory.(*repositoryFactory).accountRepository, repository)
 }
 
-func (s *repositoryFactoryTestSuite) TestGetPaymentRepositorySucceeded() {
-	factory := NewRepositoryFactory(nil)
-	repository := factory.PaymentRepository()
+func (s *repositoryFactoryTestSuite) TestGetLedgerRepositorySucceeded() {
+	factory := NewRepositoryFactory(zap.NewNop(), nil)
+	repository := factory.LedgerRepository()
 
 	s.NotNil(repository)
-	s.Equal(factory.(*repositoryFactory).paymentRepository, repository)
+	s.Equal(factory.(*repositoryFactory).ledgerRepository, repository)
+}
+
+func (s *repositoryFactoryTestSuite) TestGetReconciliationRepositorySucceeded() {
+	factory := NewRepositoryFactory(zap.NewNop(), nil)
+	repository := factory.ReconciliationRepository()
+
+	s.NotNil(repository)
+	s.Equal(factory.(*repositoryFactory).reconciliationRepository, repository)
+}
+
+func (s *repositoryFactoryTestSuite) TestGetIdempotencyRepositorySucceeded() {
+	factory := NewRepositoryFactory(zap.NewNop(), nil)
+	repository := factory.IdempotencyRepository()
+
+	s.NotNil(repository)
+	s.Equal(factory.(*repositoryFactory).idempotencyRepository, repository)
+}
+
+func (s *repositoryFactoryTestSuite) TestGetOutboxRepositorySucceeded() {
+	factory := NewRepositoryFactory(zap.NewNop(), nil)
+	repository := factory.OutboxRepository()
+
+	s.NotNil(repository)
+	s.Equal(factory.(*repositoryFactory).outboxRepository, repository)
+}
+
+func (s *repositoryFactoryTestSuite) TestGetWebhookRepositorySucceeded() {
+	factory := NewRepositoryFactory(zap.NewNop(), nil)
+	repository := factory.WebhookRepository()
+
+	s.NotNil(repository)
+	s.Equal(factory.(*repositoryFactory).webhookRepository, repository)
+}
+
+func (s *repositoryFactoryTestSuite) TestGetDeliveryRepositorySucceeded() {
+	factory := NewRepositoryFactory(zap.NewNop(), nil)
+	repository := factory.DeliveryRepository()
+
+	s.NotNil(repository)
+	s.Equal(factory.(*repositoryFactory).deliveryRepository, repository)
+}
+
+func (s *repositoryFactoryTestSuite) TestGetScheduleRepositorySucceeded() {
+	factory := NewRepositoryFactory(zap.NewNop(), nil)
+	repository := factory.ScheduleRepository()
+
+	s.NotNil(repository)
+	s.Equal(factory.(*repositoryFactory).scheduleRepository, repository)
+}
+
+func (s *repositoryFactoryTestSuite) TestGetScheduleRunRepositorySucceeded() {
+	factory := NewRepositoryFactory(zap.NewNop(), nil)
+	repository := factory.ScheduleRunRepository()
+
+	s.NotNil(repository)
+	s.Equal(factory.(*repositoryFactory).scheduleRunRepository, repository)
 }
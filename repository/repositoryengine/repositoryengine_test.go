@@ -1,16 +1,53 @@
 package repositoryengine
 
 import (
+	"context"
 	"testing"
 
+	"github.com/Toshik1978/go-rest-api/service"
+	"github.com/Toshik1978/go-rest-api/service/server"
+	"github.com/Toshik1978/go-rest-api/service/sqlite"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
 )
 
 func TestRepositoryEngine(t *testing.T) {
 	suite.Run(t, new(contextTestSuite))
 	suite.Run(t, new(repositoryFactoryTestSuite))
-	suite.Run(t, new(paymentRepositoryTestSuite))
+	suite.Run(t, new(ledgerRepositoryTestSuite))
 	suite.Run(t, new(accountRepositoryTestSuite))
+	suite.Run(t, new(reconciliationRepositoryTestSuite))
+	suite.Run(t, new(idempotencyRepositoryTestSuite))
+	suite.Run(t, new(outboxRepositoryTestSuite))
+	suite.Run(t, new(webhookRepositoryTestSuite))
+	suite.Run(t, new(deliveryRepositoryTestSuite))
+	suite.Run(t, new(scheduleRepositoryTestSuite))
+	suite.Run(t, new(scheduleRunRepositoryTestSuite))
 	suite.Run(t, new(utilsTestSuite))
+	suite.Run(t, new(dialectTestSuite))
 	suite.Run(t, new(scopeTestSuite))
+	suite.Run(t, new(retryTestSuite))
+}
+
+func TestSQLiteRepositoryEngine(t *testing.T) {
+	suite.Run(t, new(sqliteRepositoryFactoryTestSuite))
+	suite.Run(t, new(sqliteScopeTestSuite))
+	suite.Run(t, new(sqliteAccountRepositoryTestSuite))
+	suite.Run(t, new(sqliteLedgerRepositoryTestSuite))
+	suite.Run(t, new(sqliteReconciliationRepositoryTestSuite))
+	suite.Run(t, new(sqliteIdempotencyRepositoryTestSuite))
+	suite.Run(t, new(sqliteOutboxRepositoryTestSuite))
+	suite.Run(t, new(sqliteWebhookRepositoryTestSuite))
+	suite.Run(t, new(sqliteDeliveryRepositoryTestSuite))
+	suite.Run(t, new(sqliteScheduleRepositoryTestSuite))
+	suite.Run(t, new(sqliteScheduleRunRepositoryTestSuite))
+}
+
+// newSQLiteTestClient opens in-memory SQLite database with the actual schema
+func newSQLiteTestClient(t *testing.T) service.DBClient {
+	client, err := sqlite.NewSQLiteClient(zap.NewNop(), server.Vars{DB: ":memory:"})
+	require.NoError(t, err)
+	require.NoError(t, sqlite.NewMigrator(zap.NewNop(), client.GetConnection()).Up(context.Background(), 0))
+	return client
 }
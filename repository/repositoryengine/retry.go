@@ -0,0 +1,48 @@
+package repositoryengine
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	maxRetryAttempts = 5
+	baseRetryDelay   = 10 * time.Millisecond
+	maxRetryDelay    = 500 * time.Millisecond
+)
+
+// retry runs unit of work and re-runs it with jittered exponential backoff, while it fails with retryable error
+func retry(ctx context.Context, logger *zap.Logger,
+	isRetryable func(err error) bool, work func(ctx context.Context) error) error {
+
+	for attempt := 1; ; attempt++ {
+		err := work(ctx)
+		if err == nil || !isRetryable(err) || attempt == maxRetryAttempts {
+			return err
+		}
+
+		delay := retryDelay(attempt)
+		logger.Warn("Unit of work failed because of concurrent transaction, retry",
+			zap.Int("attempt", attempt),
+			zap.Duration("delay", delay),
+			zap.Error(err))
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// retryDelay return random delay before the next attempt, delay's upper bound is doubled after each attempt.
+// Random delay prevents concurrent transactions from conflicting again at the same time
+func retryDelay(attempt int) time.Duration {
+	bound := baseRetryDelay << uint(attempt-1)
+	if bound > maxRetryDelay {
+		bound = maxRetryDelay
+	}
+	return bound/2 + time.Duration(rand.Int63n(int64(bound/2)+1))
+}
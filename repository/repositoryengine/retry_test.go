@@ -0,0 +1,83 @@
+package repositoryengine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgconn"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type retryTestSuite struct {
+	suite.Suite
+}
+
+func (s *retryTestSuite) TestRetryNotRetryableFailed() {
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	attempts := 0
+	err := retry(context.Background(), zap.New(zapCore), postgresDialect.isRetryable, func(ctx context.Context) error {
+		attempts++
+		return errors.New("fail")
+	})
+
+	s.Error(err)
+	s.Equal(1, attempts)
+	s.Equal(0, zapRecorded.Len())
+}
+
+func (s *retryTestSuite) TestRetryAttemptsExceededFailed() {
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	attempts := 0
+	err := retry(context.Background(), zap.New(zapCore), postgresDialect.isRetryable, func(ctx context.Context) error {
+		attempts++
+		return &pgconn.PgError{Code: deadlockDetected}
+	})
+
+	s.True(postgresDialect.isRetryable(err))
+	s.Equal(maxRetryAttempts, attempts)
+	s.Equal(maxRetryAttempts-1, zapRecorded.Len())
+}
+
+func (s *retryTestSuite) TestRetryCancelledFailed() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	attempts := 0
+	err := retry(ctx, zap.New(zapCore), postgresDialect.isRetryable, func(ctx context.Context) error {
+		attempts++
+		return &pgconn.PgError{Code: serializationFailure}
+	})
+
+	s.True(postgresDialect.isRetryable(err))
+	s.Equal(1, attempts)
+	s.Equal(1, zapRecorded.Len())
+}
+
+func (s *retryTestSuite) TestRetrySucceeded() {
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	attempts := 0
+	err := retry(context.Background(), zap.New(zapCore), postgresDialect.isRetryable, func(ctx context.Context) error {
+		attempts++
+		if attempts < 3 {
+			return fmt.Errorf("failed to commit transaction: %w", &pgconn.PgError{Code: serializationFailure})
+		}
+		return nil
+	})
+
+	s.NoError(err)
+	s.Equal(3, attempts)
+	s.Equal(2, zapRecorded.Len())
+}
+
+func (s *retryTestSuite) TestRetryDelaySucceeded() {
+	for attempt := 1; attempt <= 10; attempt++ {
+		delay := retryDelay(attempt)
+		s.True(delay > 0)
+		s.True(delay <= maxRetryDelay)
+	}
+}
@@ -0,0 +1,137 @@
+package repositoryengine
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	getSchedulesByAccountSQL = `
+		SELECT id, payer_account_uid, recipient_account_uid, amount, currency, recurrence, cron,
+			on_insufficient_funds, status, start_at, next_run_at, created_at, updated_at
+		FROM schedules
+		WHERE payer_account_uid = ? AND id > ?
+		ORDER BY id
+		LIMIT ?`
+	getScheduleByIDSQL = `
+		SELECT id, payer_account_uid, recipient_account_uid, amount, currency, recurrence, cron,
+			on_insufficient_funds, status, start_at, next_run_at, created_at, updated_at
+		FROM schedules
+		WHERE id = ?`
+	getDueSchedulesSQL = `
+		SELECT id, payer_account_uid, recipient_account_uid, amount, currency, recurrence, cron,
+			on_insufficient_funds, status, start_at, next_run_at, created_at, updated_at
+		FROM schedules
+		WHERE status = 'active' AND next_run_at <= ?
+		ORDER BY id
+		LIMIT ?`
+
+	storeScheduleSQL = `
+		INSERT INTO schedules
+			(payer_account_uid, recipient_account_uid, amount, currency, recurrence, cron,
+			on_insufficient_funds, status, start_at, next_run_at, created_at, updated_at)
+		VALUES
+			(:payer_account_uid, :recipient_account_uid, :amount, :currency, :recurrence, :cron,
+			:on_insufficient_funds, :status, :start_at, :next_run_at, :created_at, :updated_at)`
+	advanceScheduleSQL = `
+		UPDATE schedules
+		SET status = ?, next_run_at = ?, updated_at = ?
+		WHERE id = ? AND status = 'active' AND next_run_at = ?`
+	cancelScheduleSQL = `
+		UPDATE schedules
+		SET status = 'cancelled', updated_at = ?
+		WHERE id = ? AND status = 'active'`
+)
+
+// scheduleRepository implements ScheduleRepository interface
+type scheduleRepository struct {
+	ext     sqlx.ExtContext
+	dialect *dialect
+}
+
+// newScheduleRepository creates new schedule repository
+func newScheduleRepository(ext sqlx.ExtContext, dialect *dialect) repository.ScheduleRepository {
+	return &scheduleRepository{
+		ext:     ext,
+		dialect: dialect,
+	}
+}
+
+func (r *scheduleRepository) GetByAccount(ctx context.Context,
+	accountUID string, page repository.Page) ([]repository.Schedule, error) {
+
+	var schedules []repository.Schedule
+	err := r.dialect.selectContext(ctx, sqlxExt(ctx, r.ext), &schedules, getSchedulesByAccountSQL,
+		accountUID, page.AfterID, page.Limit)
+	if err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+func (r *scheduleRepository) GetByID(ctx context.Context, id int64) (*repository.Schedule, error) {
+	var schedule repository.Schedule
+	err := r.dialect.getContext(ctx, sqlxExt(ctx, r.ext), &schedule, getScheduleByIDSQL, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.ErrScheduleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func (r *scheduleRepository) GetDue(ctx context.Context, now time.Time, limit int) ([]repository.Schedule, error) {
+	var schedules []repository.Schedule
+	err := r.dialect.selectContext(ctx, sqlxExt(ctx, r.ext), &schedules, getDueSchedulesSQL, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+func (r *scheduleRepository) Store(ctx context.Context, schedule *repository.Schedule) error {
+	err := r.dialect.namedInsert(ctx, sqlxExt(ctx, r.ext), storeScheduleSQL, schedule, &schedule.ID)
+	if r.dialect.isViolation(err, foreignKeyViolation) {
+		return repository.ErrAccountNotFound
+	}
+	return err
+}
+
+func (r *scheduleRepository) Advance(ctx context.Context, schedule *repository.Schedule, from time.Time) error {
+	res, err := r.dialect.execContext(ctx, sqlxExt(ctx, r.ext), advanceScheduleSQL,
+		schedule.Status, schedule.NextRunAt, schedule.UpdatedAt, schedule.ID, from)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return repository.ErrScheduleChanged
+	}
+	return nil
+}
+
+func (r *scheduleRepository) Cancel(ctx context.Context, id int64, cancelledAt time.Time) error {
+	res, err := r.dialect.execContext(ctx, sqlxExt(ctx, r.ext), cancelScheduleSQL, cancelledAt, id)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		// Schedule, which isn't active, stays as is
+		_, err := r.GetByID(ctx, id)
+		return err
+	}
+	return nil
+}
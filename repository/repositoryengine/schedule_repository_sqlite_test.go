@@ -0,0 +1,156 @@
+package repositoryengine
+
+import (
+	"context"
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service"
+	"github.com/Toshik1978/go-rest-api/service/testutil"
+	"github.com/stretchr/testify/suite"
+)
+
+type sqliteScheduleRepositoryTestSuite struct {
+	suite.Suite
+
+	client     service.DBClient
+	repository repository.ScheduleRepository
+	schedule   repository.Schedule
+}
+
+func (s *sqliteScheduleRepositoryTestSuite) SetupTest() {
+	s.client = newSQLiteTestClient(s.T())
+	db := s.client.GetConnection()
+	for _, uid := range []string{"toshik1978", "toshik1979"} {
+		account := testutil.RepositoryAccount()
+		account.UID = uid
+		s.Require().NoError(newAccountRepository(db, sqliteDialect).Store(context.Background(), &account))
+	}
+
+	s.repository = newScheduleRepository(db, sqliteDialect)
+	s.schedule = testutil.RepositorySchedule()
+}
+
+func (s *sqliteScheduleRepositoryTestSuite) TearDownTest() {
+	s.client.Stop()
+}
+
+func (s *sqliteScheduleRepositoryTestSuite) TestStoreScheduleInvalidFailed() {
+	schedule := s.schedule
+	schedule.Recurrence = "yearly"
+	s.Error(s.repository.Store(context.Background(), &schedule))
+
+	schedule = s.schedule
+	schedule.Amount = 0
+	s.Error(s.repository.Store(context.Background(), &schedule))
+}
+
+func (s *sqliteScheduleRepositoryTestSuite) TestStoreScheduleAccountNotFoundFailed() {
+	schedule := s.schedule
+	schedule.RecipientAccountUID = "unknown"
+	err := s.repository.Store(context.Background(), &schedule)
+
+	s.Equal(repository.ErrAccountNotFound, err)
+}
+
+func (s *sqliteScheduleRepositoryTestSuite) TestStoreScheduleSucceeded() {
+	s.Require().NoError(s.repository.Store(context.Background(), &s.schedule))
+
+	schedule, err := s.repository.GetByID(context.Background(), s.schedule.ID)
+	s.NoError(err)
+	s.Require().NotNil(schedule)
+	s.Equal(s.schedule.PayerAccountUID, schedule.PayerAccountUID)
+	s.Equal(s.schedule.RecipientAccountUID, schedule.RecipientAccountUID)
+	s.Equal(s.schedule.Amount, schedule.Amount)
+	s.Equal(s.schedule.Recurrence, schedule.Recurrence)
+	s.Equal(s.schedule.OnInsufficientFunds, schedule.OnInsufficientFunds)
+	s.Equal(s.schedule.Status, schedule.Status)
+	s.True(s.schedule.StartAt.Equal(schedule.StartAt))
+	s.True(s.schedule.NextRunAt.Equal(schedule.NextRunAt))
+
+	schedules, err := s.repository.GetByAccount(context.Background(), "toshik1978", repository.Page{Limit: 10})
+	s.NoError(err)
+	s.Require().Len(schedules, 1)
+	s.Equal(s.schedule.ID, schedules[0].ID)
+
+	// Schedules are listed by payer
+	schedules, err = s.repository.GetByAccount(context.Background(), "toshik1979", repository.Page{Limit: 10})
+	s.NoError(err)
+	s.Empty(schedules)
+}
+
+func (s *sqliteScheduleRepositoryTestSuite) TestGetScheduleNotFoundFailed() {
+	schedule, err := s.repository.GetByID(context.Background(), 1)
+
+	s.Equal(repository.ErrScheduleNotFound, err)
+	s.Nil(schedule)
+}
+
+func (s *sqliteScheduleRepositoryTestSuite) TestGetDueSchedulesSucceeded() {
+	first, second, third := s.schedule, s.schedule, s.schedule
+	second.NextRunAt = s.schedule.NextRunAt.Add(time.Minute)
+	third.Status = repository.CancelledSchedule
+	s.Require().NoError(s.repository.Store(context.Background(), &first))
+	s.Require().NoError(s.repository.Store(context.Background(), &second))
+	s.Require().NoError(s.repository.Store(context.Background(), &third))
+
+	schedules, err := s.repository.GetDue(context.Background(), s.schedule.NextRunAt, 10)
+	s.NoError(err)
+	s.Require().Len(schedules, 1)
+	s.Equal(first.ID, schedules[0].ID)
+
+	schedules, err = s.repository.GetDue(context.Background(), second.NextRunAt, 10)
+	s.NoError(err)
+	s.Require().Len(schedules, 2)
+	s.Equal(second.ID, schedules[1].ID)
+}
+
+func (s *sqliteScheduleRepositoryTestSuite) TestAdvanceScheduleChangedFailed() {
+	s.Require().NoError(s.repository.Store(context.Background(), &s.schedule))
+
+	advanced := s.schedule
+	advanced.NextRunAt = s.schedule.NextRunAt.AddDate(0, 1, 0)
+	s.Require().NoError(s.repository.Advance(context.Background(), &advanced, s.schedule.NextRunAt))
+
+	// The same occurrence can't be claimed twice
+	err := s.repository.Advance(context.Background(), &advanced, s.schedule.NextRunAt)
+	s.Equal(repository.ErrScheduleChanged, err)
+}
+
+func (s *sqliteScheduleRepositoryTestSuite) TestAdvanceScheduleSucceeded() {
+	s.Require().NoError(s.repository.Store(context.Background(), &s.schedule))
+	stored, err := s.repository.GetByID(context.Background(), s.schedule.ID)
+	s.Require().NoError(err)
+
+	// Time of the next run is compared with the stored one
+	advanced := *stored
+	advanced.NextRunAt = s.schedule.NextRunAt.AddDate(0, 1, 0)
+	advanced.Status = repository.CompletedSchedule
+	s.NoError(s.repository.Advance(context.Background(), &advanced, stored.NextRunAt))
+
+	schedule, err := s.repository.GetByID(context.Background(), s.schedule.ID)
+	s.NoError(err)
+	s.Equal(repository.CompletedSchedule, schedule.Status)
+	s.True(advanced.NextRunAt.Equal(schedule.NextRunAt))
+}
+
+func (s *sqliteScheduleRepositoryTestSuite) TestCancelScheduleNotFoundFailed() {
+	err := s.repository.Cancel(context.Background(), 1, time.Now())
+
+	s.Equal(repository.ErrScheduleNotFound, err)
+}
+
+func (s *sqliteScheduleRepositoryTestSuite) TestCancelScheduleSucceeded() {
+	s.Require().NoError(s.repository.Store(context.Background(), &s.schedule))
+	s.NoError(s.repository.Cancel(context.Background(), s.schedule.ID, time.Now()))
+	// Repeated cancel does nothing
+	s.NoError(s.repository.Cancel(context.Background(), s.schedule.ID, time.Now()))
+
+	schedule, err := s.repository.GetByID(context.Background(), s.schedule.ID)
+	s.NoError(err)
+	s.Equal(repository.CancelledSchedule, schedule.Status)
+
+	// Cancelled schedule can't be advanced
+	err = s.repository.Advance(context.Background(), schedule, schedule.NextRunAt)
+	s.Equal(repository.ErrScheduleChanged, err)
+}
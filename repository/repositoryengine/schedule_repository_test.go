@@ -0,0 +1,210 @@
+package repositoryengine
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service/testutil"
+	"github.com/jackc/pgconn"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/suite"
+)
+
+type scheduleRepositoryTestSuite struct {
+	suite.Suite
+
+	schedule repository.Schedule
+}
+
+func (s *scheduleRepositoryTestSuite) SetupSuite() {
+	s.schedule = testutil.RepositorySchedule()
+	s.schedule.ID = 7
+}
+
+func (s *scheduleRepositoryTestSuite) TestStoreScheduleAccountNotFoundFailed() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectQuery("^INSERT INTO schedules").
+		WillReturnError(&pgconn.PgError{Code: "23503"})
+
+	scheduleRepository := newScheduleRepository(sqlxDB, postgresDialect)
+	schedule := s.schedule
+	err = scheduleRepository.Store(context.Background(), &schedule)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.True(errors.Is(err, repository.ErrAccountNotFound))
+}
+
+func (s *scheduleRepositoryTestSuite) TestStoreScheduleSucceeded() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectQuery("^INSERT INTO schedules").
+		WithArgs(s.schedule.PayerAccountUID, s.schedule.RecipientAccountUID, s.schedule.Amount, s.schedule.Currency,
+			s.schedule.Recurrence, s.schedule.Cron, s.schedule.OnInsufficientFunds, s.schedule.Status,
+			s.schedule.StartAt, s.schedule.NextRunAt, s.schedule.CreatedAt, s.schedule.UpdatedAt).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(8))
+
+	scheduleRepository := newScheduleRepository(sqlxDB, postgresDialect)
+	schedule := s.schedule
+	err = scheduleRepository.Store(context.Background(), &schedule)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.NoError(err)
+	s.Equal(int64(8), schedule.ID)
+}
+
+func (s *scheduleRepositoryTestSuite) TestGetScheduleNotFoundFailed() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectQuery("^SELECT id, payer_account_uid").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	scheduleRepository := newScheduleRepository(sqlxDB, postgresDialect)
+	schedule, err := scheduleRepository.GetByID(context.Background(), 7)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.True(errors.Is(err, repository.ErrScheduleNotFound))
+	s.Nil(schedule)
+}
+
+func (s *scheduleRepositoryTestSuite) TestGetDueSchedulesSucceeded() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	rows := sqlmock.
+		NewRows([]string{
+			"id", "payer_account_uid", "recipient_account_uid", "amount", "currency", "recurrence", "cron",
+			"on_insufficient_funds", "status", "start_at", "next_run_at", "created_at", "updated_at",
+		}).
+		AddRow(s.schedule.ID, s.schedule.PayerAccountUID, s.schedule.RecipientAccountUID, s.schedule.Amount,
+			s.schedule.Currency, s.schedule.Recurrence, s.schedule.Cron, s.schedule.OnInsufficientFunds,
+			s.schedule.Status, s.schedule.StartAt, s.schedule.NextRunAt, s.schedule.CreatedAt, s.schedule.UpdatedAt)
+
+	mockSQL.
+		ExpectQuery("^SELECT id, payer_account_uid").
+		WithArgs(s.schedule.NextRunAt, 10).
+		WillReturnRows(rows)
+
+	scheduleRepository := newScheduleRepository(sqlxDB, postgresDialect)
+	schedules, err := scheduleRepository.GetDue(context.Background(), s.schedule.NextRunAt, 10)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.NoError(err)
+	s.Equal([]repository.Schedule{s.schedule}, schedules)
+}
+
+func (s *scheduleRepositoryTestSuite) TestAdvanceScheduleChangedFailed() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	from := s.schedule.NextRunAt.Add(-time.Hour)
+	mockSQL.
+		ExpectExec("^UPDATE schedules").
+		WithArgs(s.schedule.Status, s.schedule.NextRunAt, s.schedule.UpdatedAt, s.schedule.ID, from).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	scheduleRepository := newScheduleRepository(sqlxDB, postgresDialect)
+	schedule := s.schedule
+	err = scheduleRepository.Advance(context.Background(), &schedule, from)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.True(errors.Is(err, repository.ErrScheduleChanged))
+}
+
+func (s *scheduleRepositoryTestSuite) TestAdvanceScheduleSucceeded() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	from := s.schedule.NextRunAt.Add(-time.Hour)
+	mockSQL.
+		ExpectExec("^UPDATE schedules").
+		WithArgs(s.schedule.Status, s.schedule.NextRunAt, s.schedule.UpdatedAt, s.schedule.ID, from).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	scheduleRepository := newScheduleRepository(sqlxDB, postgresDialect)
+	schedule := s.schedule
+	err = scheduleRepository.Advance(context.Background(), &schedule, from)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.NoError(err)
+}
+
+func (s *scheduleRepositoryTestSuite) TestCancelScheduleNotFoundFailed() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	cancelledAt := time.Now()
+	mockSQL.
+		ExpectExec("^UPDATE schedules").
+		WithArgs(cancelledAt, 7).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mockSQL.
+		ExpectQuery("^SELECT id, payer_account_uid").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	scheduleRepository := newScheduleRepository(sqlxDB, postgresDialect)
+	err = scheduleRepository.Cancel(context.Background(), 7, cancelledAt)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.True(errors.Is(err, repository.ErrScheduleNotFound))
+}
+
+func (s *scheduleRepositoryTestSuite) TestCancelScheduleSucceeded() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	cancelledAt := time.Now()
+	mockSQL.
+		ExpectExec("^UPDATE schedules").
+		WithArgs(cancelledAt, 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	scheduleRepository := newScheduleRepository(sqlxDB, postgresDialect)
+	err = scheduleRepository.Cancel(context.Background(), 7, cancelledAt)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.NoError(err)
+}
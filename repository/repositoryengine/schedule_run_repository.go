@@ -0,0 +1,98 @@
+package repositoryengine
+
+import (
+	"context"
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	getRunsByScheduleSQL = `
+		SELECT id, schedule_id, scheduled_at, status, attempts, next_attempt_at, error, created_at, updated_at
+		FROM schedule_runs
+		WHERE schedule_id = ? AND id > ?
+		ORDER BY id
+		LIMIT ?`
+	getPendingRunsSQL = `
+		SELECT id, schedule_id, scheduled_at, status, attempts, next_attempt_at, error, created_at, updated_at
+		FROM schedule_runs
+		WHERE status = 'pending' AND next_attempt_at <= ?
+		ORDER BY id
+		LIMIT ?`
+
+	storeRunSQL = `
+		INSERT INTO schedule_runs
+			(schedule_id, scheduled_at, status, attempts, next_attempt_at, error, created_at, updated_at)
+		VALUES
+			(:schedule_id, :scheduled_at, :status, :attempts, :next_attempt_at, :error, :created_at, :updated_at)`
+	updateRunSQL = `
+		UPDATE schedule_runs
+		SET status = :status, attempts = :attempts, next_attempt_at = :next_attempt_at, error = :error,
+			updated_at = :updated_at
+		WHERE id = :id`
+)
+
+// scheduleRunRepository implements ScheduleRunRepository interface
+type scheduleRunRepository struct {
+	ext     sqlx.ExtContext
+	dialect *dialect
+}
+
+// newScheduleRunRepository creates new schedule run repository
+func newScheduleRunRepository(ext sqlx.ExtContext, dialect *dialect) repository.ScheduleRunRepository {
+	return &scheduleRunRepository{
+		ext:     ext,
+		dialect: dialect,
+	}
+}
+
+func (r *scheduleRunRepository) GetBySchedule(ctx context.Context,
+	scheduleID int64, page repository.Page) ([]repository.ScheduleRun, error) {
+
+	var runs []repository.ScheduleRun
+	err := r.dialect.selectContext(ctx, sqlxExt(ctx, r.ext), &runs, getRunsByScheduleSQL,
+		scheduleID, page.AfterID, page.Limit)
+	if err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+func (r *scheduleRunRepository) GetPending(ctx context.Context,
+	now time.Time, limit int) ([]repository.ScheduleRun, error) {
+
+	var runs []repository.ScheduleRun
+	err := r.dialect.selectContext(ctx, sqlxExt(ctx, r.ext), &runs, getPendingRunsSQL, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+func (r *scheduleRunRepository) Store(ctx context.Context, run *repository.ScheduleRun) error {
+	err := r.dialect.namedInsert(ctx, sqlxExt(ctx, r.ext), storeRunSQL, run, &run.ID)
+	if r.dialect.isViolation(err, uniqueViolation) {
+		return repository.ErrScheduleRunExists
+	}
+	if r.dialect.isViolation(err, foreignKeyViolation) {
+		return repository.ErrScheduleNotFound
+	}
+	return err
+}
+
+func (r *scheduleRunRepository) Update(ctx context.Context, run *repository.ScheduleRun) error {
+	res, err := r.dialect.namedExec(ctx, sqlxExt(ctx, r.ext), updateRunSQL, run)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return repository.ErrScheduleRunNotFound
+	}
+	return nil
+}
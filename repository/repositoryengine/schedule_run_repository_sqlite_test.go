@@ -0,0 +1,115 @@
+package repositoryengine
+
+import (
+	"context"
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service"
+	"github.com/Toshik1978/go-rest-api/service/testutil"
+	"github.com/stretchr/testify/suite"
+)
+
+type sqliteScheduleRunRepositoryTestSuite struct {
+	suite.Suite
+
+	client     service.DBClient
+	repository repository.ScheduleRunRepository
+	run        repository.ScheduleRun
+}
+
+func (s *sqliteScheduleRunRepositoryTestSuite) SetupTest() {
+	s.client = newSQLiteTestClient(s.T())
+	db := s.client.GetConnection()
+	for _, uid := range []string{"toshik1978", "toshik1979"} {
+		account := testutil.RepositoryAccount()
+		account.UID = uid
+		s.Require().NoError(newAccountRepository(db, sqliteDialect).Store(context.Background(), &account))
+	}
+	schedule := testutil.RepositorySchedule()
+	s.Require().NoError(newScheduleRepository(db, sqliteDialect).Store(context.Background(), &schedule))
+
+	s.repository = newScheduleRunRepository(db, sqliteDialect)
+	s.run = testutil.RepositoryScheduleRun()
+	s.run.ScheduleID = schedule.ID
+}
+
+func (s *sqliteScheduleRunRepositoryTestSuite) TearDownTest() {
+	s.client.Stop()
+}
+
+func (s *sqliteScheduleRunRepositoryTestSuite) TestStoreRunScheduleNotFoundFailed() {
+	run := s.run
+	run.ScheduleID++
+	err := s.repository.Store(context.Background(), &run)
+
+	s.Equal(repository.ErrScheduleNotFound, err)
+}
+
+func (s *sqliteScheduleRunRepositoryTestSuite) TestStoreRunExistsFailed() {
+	first, second := s.run, s.run
+	s.Require().NoError(s.repository.Store(context.Background(), &first))
+	err := s.repository.Store(context.Background(), &second)
+
+	s.Equal(repository.ErrScheduleRunExists, err)
+}
+
+func (s *sqliteScheduleRunRepositoryTestSuite) TestStoreRunSucceeded() {
+	s.Require().NoError(s.repository.Store(context.Background(), &s.run))
+
+	runs, err := s.repository.GetBySchedule(context.Background(), s.run.ScheduleID, repository.Page{Limit: 10})
+	s.NoError(err)
+	s.Require().Len(runs, 1)
+	s.Equal(s.run.ID, runs[0].ID)
+	s.Equal(s.run.Status, runs[0].Status)
+	s.True(s.run.ScheduledAt.Equal(runs[0].ScheduledAt))
+
+	runs, err = s.repository.GetBySchedule(context.Background(), s.run.ScheduleID+1, repository.Page{Limit: 10})
+	s.NoError(err)
+	s.Empty(runs)
+}
+
+func (s *sqliteScheduleRunRepositoryTestSuite) TestGetPendingRunsSucceeded() {
+	first, second, third := s.run, s.run, s.run
+	second.ScheduledAt = s.run.ScheduledAt.Add(time.Minute)
+	second.NextAttemptAt = s.run.NextAttemptAt.Add(time.Minute)
+	third.ScheduledAt = s.run.ScheduledAt.Add(2 * time.Minute)
+	third.Status = repository.SucceededRun
+	s.Require().NoError(s.repository.Store(context.Background(), &first))
+	s.Require().NoError(s.repository.Store(context.Background(), &second))
+	s.Require().NoError(s.repository.Store(context.Background(), &third))
+
+	// Run isn't returned before its next attempt
+	runs, err := s.repository.GetPending(context.Background(), s.run.NextAttemptAt, 10)
+	s.NoError(err)
+	s.Require().Len(runs, 1)
+	s.Equal(first.ID, runs[0].ID)
+
+	runs, err = s.repository.GetPending(context.Background(), second.NextAttemptAt, 10)
+	s.NoError(err)
+	s.Require().Len(runs, 2)
+	s.Equal(second.ID, runs[1].ID)
+}
+
+func (s *sqliteScheduleRunRepositoryTestSuite) TestUpdateRunNotFoundFailed() {
+	err := s.repository.Update(context.Background(), &s.run)
+
+	s.Equal(repository.ErrScheduleRunNotFound, err)
+}
+
+func (s *sqliteScheduleRunRepositoryTestSuite) TestUpdateRunSucceeded() {
+	s.Require().NoError(s.repository.Store(context.Background(), &s.run))
+
+	updated := s.run
+	updated.Status = repository.SkippedRun
+	updated.Attempts = 3
+	updated.Error = "insufficient funds"
+	s.NoError(s.repository.Update(context.Background(), &updated))
+
+	runs, err := s.repository.GetBySchedule(context.Background(), s.run.ScheduleID, repository.Page{Limit: 10})
+	s.NoError(err)
+	s.Require().Len(runs, 1)
+	s.Equal(updated.Status, runs[0].Status)
+	s.Equal(updated.Attempts, runs[0].Attempts)
+	s.Equal(updated.Error, runs[0].Error)
+}
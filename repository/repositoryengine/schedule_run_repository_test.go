@@ -0,0 +1,162 @@
+package repositoryengine
+
+import (
+	"context"
+	"errors"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service/testutil"
+	"github.com/jackc/pgconn"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/suite"
+)
+
+type scheduleRunRepositoryTestSuite struct {
+	suite.Suite
+
+	run repository.ScheduleRun
+}
+
+func (s *scheduleRunRepositoryTestSuite) SetupSuite() {
+	s.run = testutil.RepositoryScheduleRun()
+	s.run.ScheduleID = 3
+}
+
+func (s *scheduleRunRepositoryTestSuite) TestStoreRunExistsFailed() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectQuery("^INSERT INTO schedule_runs").
+		WillReturnError(&pgconn.PgError{Code: "23505"})
+
+	runRepository := newScheduleRunRepository(sqlxDB, postgresDialect)
+	run := s.run
+	err = runRepository.Store(context.Background(), &run)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.True(errors.Is(err, repository.ErrScheduleRunExists))
+}
+
+func (s *scheduleRunRepositoryTestSuite) TestStoreRunScheduleNotFoundFailed() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectQuery("^INSERT INTO schedule_runs").
+		WillReturnError(&pgconn.PgError{Code: "23503"})
+
+	runRepository := newScheduleRunRepository(sqlxDB, postgresDialect)
+	run := s.run
+	err = runRepository.Store(context.Background(), &run)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.True(errors.Is(err, repository.ErrScheduleNotFound))
+}
+
+func (s *scheduleRunRepositoryTestSuite) TestStoreRunSucceeded() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectQuery("^INSERT INTO schedule_runs").
+		WithArgs(s.run.ScheduleID, s.run.ScheduledAt, s.run.Status, 0, s.run.NextAttemptAt, "",
+			s.run.CreatedAt, s.run.UpdatedAt).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+
+	runRepository := newScheduleRunRepository(sqlxDB, postgresDialect)
+	run := s.run
+	err = runRepository.Store(context.Background(), &run)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.NoError(err)
+	s.Equal(int64(7), run.ID)
+}
+
+func (s *scheduleRunRepositoryTestSuite) TestGetPendingRunsSucceeded() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	rows := sqlmock.
+		NewRows([]string{
+			"id", "schedule_id", "scheduled_at", "status", "attempts", "next_attempt_at", "error",
+			"created_at", "updated_at",
+		}).
+		AddRow(7, s.run.ScheduleID, s.run.ScheduledAt, s.run.Status, 0, s.run.NextAttemptAt, "",
+			s.run.CreatedAt, s.run.UpdatedAt)
+
+	mockSQL.
+		ExpectQuery("^SELECT id, schedule_id").
+		WithArgs(s.run.NextAttemptAt, 10).
+		WillReturnRows(rows)
+
+	runRepository := newScheduleRunRepository(sqlxDB, postgresDialect)
+	runs, err := runRepository.GetPending(context.Background(), s.run.NextAttemptAt, 10)
+
+	expected := s.run
+	expected.ID = 7
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.NoError(err)
+	s.Equal([]repository.ScheduleRun{expected}, runs)
+}
+
+func (s *scheduleRunRepositoryTestSuite) TestUpdateRunNotFoundFailed() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectExec("^UPDATE schedule_runs").
+		WithArgs(s.run.Status, 0, s.run.NextAttemptAt, "", s.run.UpdatedAt, 7).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	runRepository := newScheduleRunRepository(sqlxDB, postgresDialect)
+	run := s.run
+	run.ID = 7
+	err = runRepository.Update(context.Background(), &run)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.True(errors.Is(err, repository.ErrScheduleRunNotFound))
+}
+
+func (s *scheduleRunRepositoryTestSuite) TestUpdateRunSucceeded() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectExec("^UPDATE schedule_runs").
+		WithArgs(s.run.Status, 0, s.run.NextAttemptAt, "", s.run.UpdatedAt, 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	runRepository := newScheduleRunRepository(sqlxDB, postgresDialect)
+	run := s.run
+	run.ID = 7
+	err = runRepository.Update(context.Background(), &run)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.NoError(err)
+}
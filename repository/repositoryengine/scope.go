@@ -3,26 +3,69 @@ package repositoryengine
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"strconv"
+	"sync/atomic"
 
 	"github.com/Toshik1978/go-rest-api/repository"
 	"github.com/Toshik1978/go-rest-api/service/errutil"
 	"github.com/jmoiron/sqlx"
 )
 
-// scope implements repository.Scope interface
+// isolationLevels maps repository's isolation levels to SQL ones
+var isolationLevels = map[repository.IsolationLevel]sql.IsolationLevel{
+	repository.DefaultIsolation: sql.LevelDefault,
+	repository.ReadCommitted:    sql.LevelReadCommitted,
+	repository.RepeatableRead:   sql.LevelRepeatableRead,
+	repository.Serializable:     sql.LevelSerializable,
+}
+
+// savepointCounter is used to generate unique names of savepoints
+var savepointCounter uint64
+
+// scope implements repository.Scope interface. Scope, started inside of another one, is nested:
+// it uses savepoint in the outer scope's transaction instead of the new transaction
 type scope struct {
-	db *sqlx.DB
+	db      *sqlx.DB
+	dialect *dialect
+	options repository.ScopeOptions
+
+	savepoint string
+	done      bool
 }
 
 // newScope creates new instance of repository.Scope interface
-func newScope(db *sqlx.DB) repository.Scope {
-	return &scope{
-		db: db,
+func newScope(db *sqlx.DB, dialect *dialect, options ...repository.ScopeOption) repository.Scope {
+	s := &scope{
+		db:      db,
+		dialect: dialect,
+	}
+	for _, option := range options {
+		option(&s.options)
 	}
+	return s
 }
 
 func (s *scope) WithContext(ctx context.Context) (context.Context, error) {
-	tx, err := s.db.Beginx()
+	// Isolation level can't be changed inside of the transaction, so nested scope inherits it
+	if tx := transactionFromContext(ctx); tx != nil {
+		savepoint := "scope_" + strconv.FormatUint(atomic.AddUint64(&savepointCounter, 1), 10)
+		if _, err := tx.ExecContext(ctx, "SAVEPOINT "+savepoint); err != nil {
+			return nil, errutil.Wrap(err, "failed to create savepoint")
+		}
+		s.savepoint = savepoint
+		return ctx, nil
+	}
+
+	level, ok := isolationLevels[s.options.Isolation]
+	if !ok {
+		return nil, errors.New("unknown isolation level")
+	}
+	var txOptions *sql.TxOptions
+	if s.dialect.isolation {
+		txOptions = &sql.TxOptions{Isolation: level}
+	}
+	tx, err := s.db.BeginTxx(ctx, txOptions)
 	if err != nil {
 		return nil, errutil.Wrap(err, "failed to start transaction")
 	}
@@ -34,6 +77,9 @@ func (s *scope) Complete(ctx context.Context) error {
 	if tx == nil {
 		return nil
 	}
+	if s.savepoint != "" {
+		return s.finishSavepoint(ctx, tx, "RELEASE SAVEPOINT ", "failed to release savepoint")
+	}
 
 	err := tx.Commit()
 	if err == sql.ErrTxDone {
@@ -47,6 +93,9 @@ func (s *scope) Cancel(ctx context.Context) error {
 	if tx == nil {
 		return nil
 	}
+	if s.savepoint != "" {
+		return s.finishSavepoint(ctx, tx, "ROLLBACK TO SAVEPOINT ", "failed to rollback to savepoint")
+	}
 
 	err := tx.Rollback()
 	if err == sql.ErrTxDone {
@@ -54,3 +103,18 @@ func (s *scope) Cancel(ctx context.Context) error {
 	}
 	return errutil.Wrap(err, "failed to rollback transaction")
 }
+
+// finishSavepoint releases or rollbacks to scope's savepoint. Only the first call is actually done,
+// because savepoint is gone after release and outer scope can use the transaction after rollback
+func (s *scope) finishSavepoint(ctx context.Context, tx *sqlx.Tx, command string, msg string) error {
+	if s.done {
+		return nil
+	}
+	s.done = true
+
+	_, err := tx.ExecContext(ctx, command+s.savepoint)
+	if err == sql.ErrTxDone {
+		return nil // Ignore this error, because it's safe
+	}
+	return errutil.Wrap(err, msg)
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Toshik1978/go-rest-api/repository"
 	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/suite"
 )
@@ -26,7 +27,7 @@ func (s *scopeTestSuite) TestScopeWithContextFailed() {
 		ExpectBegin().
 		WillReturnError(errors.New("fail"))
 
-	scope := newScope(sqlxDB)
+	scope := newScope(sqlxDB, postgresDialect)
 	ctx, err := scope.WithContext(context.Background())
 
 	s.NoError(mockSQL.ExpectationsWereMet())
@@ -44,7 +45,7 @@ func (s *scopeTestSuite) TestScopeWithContextSucceeded() {
 
 	mockSQL.ExpectBegin()
 
-	scope := newScope(sqlxDB)
+	scope := newScope(sqlxDB, postgresDialect)
 	ctx, err := scope.WithContext(context.Background())
 
 	s.NoError(mockSQL.ExpectationsWereMet())
@@ -52,6 +53,200 @@ func (s *scopeTestSuite) TestScopeWithContextSucceeded() {
 	s.NotNil(ctx)
 }
 
+func (s *scopeTestSuite) TestScopeWithContextIsolationFailed() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	scope := newScope(sqlxDB, postgresDialect, repository.WithIsolation(repository.IsolationLevel(-1)))
+	ctx, err := scope.WithContext(context.Background())
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.Error(err)
+	s.Nil(ctx)
+}
+
+func (s *scopeTestSuite) TestScopeWithContextIsolationSucceeded() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.ExpectBegin()
+
+	serializable := newScope(sqlxDB, postgresDialect, repository.WithIsolation(repository.Serializable))
+	ctx, err := serializable.WithContext(context.Background())
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.NoError(err)
+	s.NotNil(ctx)
+	s.Equal(repository.Serializable, serializable.(*scope).options.Isolation)
+}
+
+func (s *scopeTestSuite) TestScopeWithContextNestedFailed() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectBegin()
+	mockSQL.
+		ExpectExec("^SAVEPOINT scope_").
+		WillReturnError(errors.New("fail"))
+
+	tx, _ := sqlxDB.Beginx()
+	scope := newScope(sqlxDB, postgresDialect)
+	ctx, err := scope.WithContext(contextWithTransaction(context.Background(), tx))
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.Error(err)
+	s.Nil(ctx)
+}
+
+func (s *scopeTestSuite) TestScopeWithContextNestedSucceeded() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectBegin()
+	mockSQL.
+		ExpectExec("^SAVEPOINT scope_").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	tx, _ := sqlxDB.Beginx()
+	scope := newScope(sqlxDB, postgresDialect)
+	ctx, err := scope.WithContext(contextWithTransaction(context.Background(), tx))
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.NoError(err)
+	s.Equal(tx, transactionFromContext(ctx))
+}
+
+func (s *scopeTestSuite) TestScopeCompleteNestedFailed() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectBegin()
+	mockSQL.
+		ExpectExec("^SAVEPOINT scope_").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mockSQL.
+		ExpectExec("^RELEASE SAVEPOINT scope_").
+		WillReturnError(errors.New("fail"))
+
+	tx, _ := sqlxDB.Beginx()
+	scope := newScope(sqlxDB, postgresDialect)
+	ctx, _ := scope.WithContext(contextWithTransaction(context.Background(), tx))
+	err = scope.Complete(ctx)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.Error(err)
+}
+
+func (s *scopeTestSuite) TestScopeCompleteNestedSucceeded() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectBegin()
+	mockSQL.
+		ExpectExec("^SAVEPOINT scope_").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mockSQL.
+		ExpectExec("^RELEASE SAVEPOINT scope_").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	tx, _ := sqlxDB.Beginx()
+	scope := newScope(sqlxDB, postgresDialect)
+	ctx, _ := scope.WithContext(contextWithTransaction(context.Background(), tx))
+	err = scope.Complete(ctx)
+	// The second call and deferred Cancel do nothing
+	s.NoError(scope.Complete(ctx))
+	s.NoError(scope.Cancel(ctx))
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.NoError(err)
+}
+
+func (s *scopeTestSuite) TestScopeCancelNestedFailed() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectBegin()
+	mockSQL.
+		ExpectExec("^SAVEPOINT scope_").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mockSQL.
+		ExpectExec("^ROLLBACK TO SAVEPOINT scope_").
+		WillReturnError(errors.New("fail"))
+
+	tx, _ := sqlxDB.Beginx()
+	scope := newScope(sqlxDB, postgresDialect)
+	ctx, _ := scope.WithContext(contextWithTransaction(context.Background(), tx))
+	err = scope.Cancel(ctx)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.Error(err)
+}
+
+func (s *scopeTestSuite) TestScopeCancelNestedSucceeded() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectBegin()
+	mockSQL.
+		ExpectExec("^SAVEPOINT scope_").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mockSQL.
+		ExpectExec("^ROLLBACK TO SAVEPOINT scope_").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mockSQL.
+		ExpectCommit()
+
+	tx, _ := sqlxDB.Beginx()
+	outerCtx := contextWithTransaction(context.Background(), tx)
+	scope := newScope(sqlxDB, postgresDialect)
+	ctx, _ := scope.WithContext(outerCtx)
+	err = scope.Cancel(ctx)
+	s.NoError(scope.Cancel(ctx))
+	// Outer scope is still alive and can be completed
+	s.NoError(newScope(sqlxDB, postgresDialect).Complete(outerCtx))
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.NoError(err)
+}
+
 func (s *scopeTestSuite) TestScopeCompleteNoTransactionSucceeded() {
 	db, mockSQL, err := sqlmock.New()
 	if err != nil {
@@ -60,7 +255,7 @@ func (s *scopeTestSuite) TestScopeCompleteNoTransactionSucceeded() {
 	defer db.Close()
 	sqlxDB := sqlx.NewDb(db, "sqlmock")
 
-	scope := newScope(sqlxDB)
+	scope := newScope(sqlxDB, postgresDialect)
 	err = scope.Complete(context.Background())
 
 	s.NoError(mockSQL.ExpectationsWereMet())
@@ -82,7 +277,7 @@ func (s *scopeTestSuite) TestScopeCompleteFailed() {
 		WillReturnError(errors.New("fail"))
 
 	tx, _ := sqlxDB.Beginx()
-	scope := newScope(sqlxDB)
+	scope := newScope(sqlxDB, postgresDialect)
 	err = scope.Complete(contextWithTransaction(context.Background(), tx))
 
 	s.NoError(mockSQL.ExpectationsWereMet())
@@ -103,7 +298,7 @@ func (s *scopeTestSuite) TestScopeCompleteSucceeded() {
 		ExpectCommit()
 
 	tx, _ := sqlxDB.Beginx()
-	scope := newScope(sqlxDB)
+	scope := newScope(sqlxDB, postgresDialect)
 	err = scope.Complete(contextWithTransaction(context.Background(), tx))
 
 	s.NoError(mockSQL.ExpectationsWereMet())
@@ -125,7 +320,7 @@ func (s *scopeTestSuite) TestScopeCompleteTxDoneSucceeded() {
 		WillReturnError(sql.ErrTxDone)
 
 	tx, _ := sqlxDB.Beginx()
-	scope := newScope(sqlxDB)
+	scope := newScope(sqlxDB, postgresDialect)
 	err = scope.Complete(contextWithTransaction(context.Background(), tx))
 
 	s.NoError(mockSQL.ExpectationsWereMet())
@@ -140,7 +335,7 @@ func (s *scopeTestSuite) TestScopeCancelNoTransactionSucceeded() {
 	defer db.Close()
 	sqlxDB := sqlx.NewDb(db, "sqlmock")
 
-	scope := newScope(sqlxDB)
+	scope := newScope(sqlxDB, postgresDialect)
 	err = scope.Cancel(context.Background())
 
 	s.NoError(mockSQL.ExpectationsWereMet())
@@ -162,7 +357,7 @@ func (s *scopeTestSuite) TestScopeCancelFailed() {
 		WillReturnError(errors.New("fail"))
 
 	tx, _ := sqlxDB.Beginx()
-	scope := newScope(sqlxDB)
+	scope := newScope(sqlxDB, postgresDialect)
 	err = scope.Cancel(contextWithTransaction(context.Background(), tx))
 
 	s.NoError(mockSQL.ExpectationsWereMet())
@@ -183,7 +378,7 @@ func (s *scopeTestSuite) TestScopeCancelSucceeded() {
 		ExpectRollback()
 
 	tx, _ := sqlxDB.Beginx()
-	scope := newScope(sqlxDB)
+	scope := newScope(sqlxDB, postgresDialect)
 	err = scope.Cancel(contextWithTransaction(context.Background(), tx))
 
 	s.NoError(mockSQL.ExpectationsWereMet())
@@ -205,7 +400,7 @@ func (s *scopeTestSuite) TestScopeCancelTxDoneSucceeded() {
 		WillReturnError(sql.ErrTxDone)
 
 	tx, _ := sqlxDB.Beginx()
-	scope := newScope(sqlxDB)
+	scope := newScope(sqlxDB, postgresDialect)
 	err = scope.Cancel(contextWithTransaction(context.Background(), tx))
 
 	s.NoError(mockSQL.ExpectationsWereMet())
@@ -2,14 +2,30 @@ package repositoryengine
 
 import (
 	"context"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 )
 
-// sqlxExt retrieve current active sqlx.Ext instance. ext points to default value
-func sqlxExt(ctx context.Context, ext sqlx.Ext) sqlx.Ext {
+// sqlxExt retrieve current active sqlx.ExtContext instance. ext points to default value
+func sqlxExt(ctx context.Context, ext sqlx.ExtContext) sqlx.ExtContext {
 	if tx := transactionFromContext(ctx); tx != nil {
 		return tx
 	}
 	return ext
 }
+
+// utc converts time arguments to UTC
+func utc(args []interface{}) []interface{} {
+	for i, arg := range args {
+		switch value := arg.(type) {
+		case time.Time:
+			args[i] = value.UTC()
+		case *time.Time:
+			if value != nil {
+				args[i] = value.UTC()
+			}
+		}
+	}
+	return args
+}
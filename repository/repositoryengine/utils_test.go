@@ -2,6 +2,7 @@ package repositoryengine
 
 import (
 	"context"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/jmoiron/sqlx"
@@ -42,3 +43,14 @@ func (s *utilsTestSuite) TestSqlxExtSucceeded() {
 	s.NoError(mockSQL.ExpectationsWereMet())
 	s.Equal(tx, ext)
 }
+
+func (s *utilsTestSuite) TestUTCSucceeded() {
+	local := time.Date(2019, 10, 1, 12, 0, 0, 0, time.FixedZone("UTC+5", 5*3600))
+	args := utc([]interface{}{"toshik1978", local, &local, (*time.Time)(nil)})
+
+	s.Equal("toshik1978", args[0])
+	s.Equal(time.UTC, args[1].(time.Time).Location())
+	s.Equal(time.UTC, args[2].(time.Time).Location())
+	s.True(local.Equal(args[2].(time.Time)))
+	s.Nil(args[3])
+}
@@ -0,0 +1,107 @@
+package repositoryengine
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	getAllWebhooksSQL = `
+		SELECT id, url, secret, event_type, account_uid, created_at
+		FROM webhooks
+		WHERE id > ?
+		ORDER BY id
+		LIMIT ?`
+	getWebhookByIDSQL = `
+		SELECT id, url, secret, event_type, account_uid, created_at
+		FROM webhooks
+		WHERE id = ?`
+	// Empty event type and account match any event
+	getMatchingWebhooksSQL = `
+		SELECT id, url, secret, event_type, account_uid, created_at
+		FROM webhooks
+		WHERE event_type IN ('', ?) AND account_uid IN (?)
+		ORDER BY id`
+
+	storeWebhookSQL = `
+		INSERT INTO webhooks
+			(url, secret, event_type, account_uid, created_at)
+		VALUES
+			(:url, :secret, :event_type, :account_uid, :created_at)`
+	deleteWebhookSQL = `
+		DELETE FROM webhooks
+		WHERE id = ?`
+)
+
+// webhookRepository implements WebhookRepository interface
+type webhookRepository struct {
+	ext     sqlx.ExtContext
+	dialect *dialect
+}
+
+// newWebhookRepository creates new webhook repository
+func newWebhookRepository(ext sqlx.ExtContext, dialect *dialect) repository.WebhookRepository {
+	return &webhookRepository{
+		ext:     ext,
+		dialect: dialect,
+	}
+}
+
+func (r *webhookRepository) GetAll(ctx context.Context, page repository.Page) ([]repository.Webhook, error) {
+	var webhooks []repository.Webhook
+	err := r.dialect.selectContext(ctx, sqlxExt(ctx, r.ext), &webhooks, getAllWebhooksSQL, page.AfterID, page.Limit)
+	if err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (r *webhookRepository) GetByID(ctx context.Context, id int64) (*repository.Webhook, error) {
+	var webhook repository.Webhook
+	err := r.dialect.getContext(ctx, sqlxExt(ctx, r.ext), &webhook, getWebhookByIDSQL, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (r *webhookRepository) GetMatching(ctx context.Context,
+	eventType repository.EventType, accountUIDs []string) ([]repository.Webhook, error) {
+
+	// Empty account is added, so webhooks of any account match too
+	query, args, err := sqlx.In(getMatchingWebhooksSQL, eventType, append([]string{""}, accountUIDs...))
+	if err != nil {
+		return nil, err
+	}
+	var webhooks []repository.Webhook
+	if err := r.dialect.selectContext(ctx, sqlxExt(ctx, r.ext), &webhooks, query, args...); err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (r *webhookRepository) Store(ctx context.Context, webhook *repository.Webhook) error {
+	return r.dialect.namedInsert(ctx, sqlxExt(ctx, r.ext), storeWebhookSQL, webhook, &webhook.ID)
+}
+
+func (r *webhookRepository) Delete(ctx context.Context, id int64) error {
+	res, err := r.dialect.execContext(ctx, sqlxExt(ctx, r.ext), deleteWebhookSQL, id)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return repository.ErrWebhookNotFound
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package repositoryengine
+
+import (
+	"context"
+
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service"
+	"github.com/Toshik1978/go-rest-api/service/testutil"
+	"github.com/stretchr/testify/suite"
+)
+
+type sqliteWebhookRepositoryTestSuite struct {
+	suite.Suite
+
+	client     service.DBClient
+	repository repository.WebhookRepository
+	webhook    repository.Webhook
+}
+
+func (s *sqliteWebhookRepositoryTestSuite) SetupTest() {
+	s.client = newSQLiteTestClient(s.T())
+	s.repository = newWebhookRepository(s.client.GetConnection(), sqliteDialect)
+	s.webhook = testutil.RepositoryWebhook()
+}
+
+func (s *sqliteWebhookRepositoryTestSuite) TearDownTest() {
+	s.client.Stop()
+}
+
+func (s *sqliteWebhookRepositoryTestSuite) TestGetWebhookNotFoundFailed() {
+	webhook, err := s.repository.GetByID(context.Background(), 1)
+
+	s.Equal(repository.ErrWebhookNotFound, err)
+	s.Nil(webhook)
+}
+
+func (s *sqliteWebhookRepositoryTestSuite) TestStoreWebhookSucceeded() {
+	s.Require().NoError(s.repository.Store(context.Background(), &s.webhook))
+
+	webhook, err := s.repository.GetByID(context.Background(), s.webhook.ID)
+	s.NoError(err)
+	s.Require().NotNil(webhook)
+	s.Equal(s.webhook.URL, webhook.URL)
+	s.Equal(s.webhook.Secret, webhook.Secret)
+	s.Equal(s.webhook.EventType, webhook.EventType)
+	s.Equal(s.webhook.AccountUID, webhook.AccountUID)
+	s.True(s.webhook.CreatedAt.Equal(webhook.CreatedAt))
+
+	webhooks, err := s.repository.GetAll(context.Background(), repository.Page{Limit: 10})
+	s.NoError(err)
+	s.Require().Len(webhooks, 1)
+	s.Equal(s.webhook.ID, webhooks[0].ID)
+
+	webhooks, err = s.repository.GetAll(context.Background(), repository.Page{AfterID: s.webhook.ID, Limit: 10})
+	s.NoError(err)
+	s.Empty(webhooks)
+}
+
+func (s *sqliteWebhookRepositoryTestSuite) TestGetMatchingWebhooksSucceeded() {
+	anyWebhook, other, account := s.webhook, s.webhook, s.webhook
+	anyWebhook.EventType = ""
+	anyWebhook.AccountUID = ""
+	other.EventType = repository.AccountCreatedEvent
+	account.AccountUID = "toshik1979"
+	s.Require().NoError(s.repository.Store(context.Background(), &anyWebhook))
+	s.Require().NoError(s.repository.Store(context.Background(), &other))
+	s.Require().NoError(s.repository.Store(context.Background(), &account))
+
+	webhooks, err := s.repository.GetMatching(context.Background(),
+		repository.PaymentCompletedEvent, []string{"toshik1978", "toshik1979"})
+	s.NoError(err)
+	s.Require().Len(webhooks, 2)
+	s.Equal(anyWebhook.ID, webhooks[0].ID)
+	s.Equal(account.ID, webhooks[1].ID)
+
+	webhooks, err = s.repository.GetMatching(context.Background(), repository.AccountCreatedEvent, nil)
+	s.NoError(err)
+	s.Require().Len(webhooks, 1)
+	s.Equal(anyWebhook.ID, webhooks[0].ID)
+}
+
+func (s *sqliteWebhookRepositoryTestSuite) TestDeleteWebhookNotFoundFailed() {
+	err := s.repository.Delete(context.Background(), 1)
+
+	s.Equal(repository.ErrWebhookNotFound, err)
+}
+
+func (s *sqliteWebhookRepositoryTestSuite) TestDeleteWebhookSucceeded() {
+	deliveryRepository := newDeliveryRepository(s.client.GetConnection(), sqliteDialect)
+	s.Require().NoError(s.repository.Store(context.Background(), &s.webhook))
+	delivery := testutil.RepositoryDelivery()
+	delivery.WebhookID = s.webhook.ID
+	s.Require().NoError(deliveryRepository.Store(context.Background(), &delivery))
+	attempt := repository.DeliveryAttempt{DeliveryID: delivery.ID, CreatedAt: delivery.CreatedAt}
+	s.Require().NoError(deliveryRepository.StoreAttempt(context.Background(), &attempt))
+
+	s.NoError(s.repository.Delete(context.Background(), s.webhook.ID))
+
+	// Deliveries and attempts are deleted with webhook
+	_, err := s.repository.GetByID(context.Background(), s.webhook.ID)
+	s.Equal(repository.ErrWebhookNotFound, err)
+	_, err = deliveryRepository.GetByID(context.Background(), delivery.ID)
+	s.Equal(repository.ErrDeliveryNotFound, err)
+	attempts, err := deliveryRepository.GetAttempts(context.Background(), delivery.ID)
+	s.NoError(err)
+	s.Empty(attempts)
+}
@@ -0,0 +1,134 @@
+package repositoryengine
+
+import (
+	"context"
+	"errors"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service/testutil"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/suite"
+)
+
+type webhookRepositoryTestSuite struct {
+	suite.Suite
+
+	webhook repository.Webhook
+}
+
+func (s *webhookRepositoryTestSuite) SetupSuite() {
+	s.webhook = testutil.RepositoryWebhook()
+}
+
+func (s *webhookRepositoryTestSuite) TestGetWebhookNotFoundFailed() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectQuery("^SELECT id, url, secret").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	webhookRepository := newWebhookRepository(sqlxDB, postgresDialect)
+	webhook, err := webhookRepository.GetByID(context.Background(), 7)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.True(errors.Is(err, repository.ErrWebhookNotFound))
+	s.Nil(webhook)
+}
+
+func (s *webhookRepositoryTestSuite) TestGetMatchingWebhooksSucceeded() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	rows := sqlmock.
+		NewRows([]string{"id", "url", "secret", "event_type", "account_uid", "created_at"}).
+		AddRow(7, s.webhook.URL, s.webhook.Secret, s.webhook.EventType, s.webhook.AccountUID, s.webhook.CreatedAt)
+
+	// Webhooks of any account match too
+	mockSQL.
+		ExpectQuery("^SELECT id, url, secret").
+		WithArgs(s.webhook.EventType, "", "toshik1978", "toshik1979").
+		WillReturnRows(rows)
+
+	webhookRepository := newWebhookRepository(sqlxDB, postgresDialect)
+	webhooks, err := webhookRepository.GetMatching(context.Background(),
+		s.webhook.EventType, []string{"toshik1978", "toshik1979"})
+
+	expected := s.webhook
+	expected.ID = 7
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.NoError(err)
+	s.Equal([]repository.Webhook{expected}, webhooks)
+}
+
+func (s *webhookRepositoryTestSuite) TestStoreWebhookSucceeded() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectQuery("^INSERT INTO webhooks").
+		WithArgs(s.webhook.URL, s.webhook.Secret, s.webhook.EventType, s.webhook.AccountUID, s.webhook.CreatedAt).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+
+	webhookRepository := newWebhookRepository(sqlxDB, postgresDialect)
+	webhook := s.webhook
+	err = webhookRepository.Store(context.Background(), &webhook)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.NoError(err)
+	s.Equal(int64(7), webhook.ID)
+}
+
+func (s *webhookRepositoryTestSuite) TestDeleteWebhookNotFoundFailed() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectExec("^DELETE FROM webhooks").
+		WithArgs(7).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	webhookRepository := newWebhookRepository(sqlxDB, postgresDialect)
+	err = webhookRepository.Delete(context.Background(), 7)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.True(errors.Is(err, repository.ErrWebhookNotFound))
+}
+
+func (s *webhookRepositoryTestSuite) TestDeleteWebhookSucceeded() {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockSQL.
+		ExpectExec("^DELETE FROM webhooks").
+		WithArgs(7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	webhookRepository := newWebhookRepository(sqlxDB, postgresDialect)
+	err = webhookRepository.Delete(context.Background(), 7)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.NoError(err)
+}
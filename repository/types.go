@@ -2,20 +2,316 @@ package repository
 
 import "time"
 
-// Account define account entity
+// Page define keyset pagination: not more than Limit records with ID greater than AfterID.
+// IDs only grow, so records inserted concurrently never shift pages, which are already read
+type Page struct {
+	AfterID int64
+	Limit   int
+}
+
+// PaymentFilter define filter of the account's payments. Zero values mean no filtering by the field.
+// Amounts are absolute amounts in account's currency minor units, time range is half-open [From, To)
+type PaymentFilter struct {
+	AccountUID      string
+	CounterpartyUID string
+	Direction       PaymentDirection
+	From            *time.Time
+	To              *time.Time
+	MinAmount       *int64
+	MaxAmount       *int64
+}
+
+// PaymentDirection define direction of the payment from the account's point of view
+type PaymentDirection int8
+
+const (
+	AnyDirection PaymentDirection = iota
+	OutgoingDirection
+	IncomingDirection
+)
+
+// AccountStatus define lifecycle status of the account
+type AccountStatus string
+
+const (
+	// ActiveAccount can pay and receive payments
+	ActiveAccount AccountStatus = "active"
+	// FrozenAccount can't pay and receive payments, but can be activated again
+	FrozenAccount AccountStatus = "frozen"
+	// ClosedAccount can't pay and receive payments forever
+	ClosedAccount AccountStatus = "closed"
+)
+
+// Account define account entity. OpeningBalance is the balance account was created with,
+// so Balance should be always equal to OpeningBalance plus sum of account's postings
 type Account struct {
-	ID        int64     `db:"id"`
-	UID       string    `db:"uid"`
-	Currency  string    `db:"currency"`
-	Balance   int64     `db:"balance"`
-	CreatedAt time.Time `db:"created_at"`
+	ID              int64         `db:"id"`
+	UID             string        `db:"uid"`
+	Currency        string        `db:"currency"`
+	Balance         int64         `db:"balance"`
+	OpeningBalance  int64         `db:"opening_balance"`
+	Status          AccountStatus `db:"status"`
+	StatusChangedAt time.Time     `db:"status_changed_at"`
+	CreatedAt       time.Time     `db:"created_at"`
+}
+
+// AccountTransition define change of the account's status
+type AccountTransition struct {
+	ID         int64         `db:"id"`
+	AccountUID string        `db:"account_uid"`
+	FromStatus AccountStatus `db:"from_status"`
+	ToStatus   AccountStatus `db:"to_status"`
+	CreatedAt  time.Time     `db:"created_at"`
 }
 
-// Payment define payment entity
-type Payment struct {
+// FXAccountUID define internal account of currency exchange. It takes payer's currency and gives recipient's one,
+// so every transfer stays balanced in each currency
+const FXAccountUID = "@fx"
+
+// Transfer define journal entry of the ledger: money transfer from payer to recipient.
+// Source*/Target* are given in payer's and recipient's currencies, Rate is exchange rate between them
+type Transfer struct {
 	ID                  int64     `db:"id"`
-	Amount              int64     `db:"amount"`
 	PayerAccountUID     string    `db:"payer_account_uid"`
 	RecipientAccountUID string    `db:"recipient_account_uid"`
+	SourceAmount        int64     `db:"source_amount"`
+	SourceCurrency      string    `db:"source_currency"`
+	TargetAmount        int64     `db:"target_amount"`
+	TargetCurrency      string    `db:"target_currency"`
+	Rate                string    `db:"rate"`
 	CreatedAt           time.Time `db:"created_at"`
+
+	// RefundOf is ID of the transfer, refunded by this one. Refund transfers money back from recipient to payer
+	// of the refunded transfer, so its target amount is given in refunded transfer's source currency
+	RefundOf *int64 `db:"refund_of"`
+	// RefundedAmount is sum of target amounts of the transfer's refunds, it's calculated by storage on read
+	RefundedAmount int64 `db:"refunded_amount"`
+
+	// Postings of the transfer, sum of their amounts is zero in every currency
+	Postings []Posting `db:"-"`
+}
+
+// Posting define debit (negative amount) or credit (non-negative amount) of the account within the transfer
+type Posting struct {
+	ID         int64     `db:"id"`
+	TransferID int64     `db:"transfer_id"`
+	AccountUID string    `db:"account_uid"`
+	Amount     int64     `db:"amount"`
+	Currency   string    `db:"currency"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+// Balanced checks zero-sum invariant of the double-entry ledger: sum of postings is zero in every currency
+func Balanced(postings []Posting) bool {
+	if len(postings) == 0 {
+		return false
+	}
+	sums := make(map[string]int64)
+	for _, posting := range postings {
+		sums[posting.Currency] += posting.Amount
+	}
+	for _, sum := range sums {
+		if sum != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Entry define posting of payer's or recipient's account together with its transfer.
+// It's a payment from the account's point of view
+type Entry struct {
+	Posting  Posting  `db:"posting"`
+	Transfer Transfer `db:"transfer"`
+}
+
+// Drift define account, which balance doesn't match the ledger.
+// ExpectedBalance is opening balance plus sum of account's postings, ActualBalance is stored balance
+type Drift struct {
+	AccountUID      string `db:"account_uid"`
+	Currency        string `db:"currency"`
+	ExpectedBalance int64  `db:"expected_balance"`
+	ActualBalance   int64  `db:"actual_balance"`
+}
+
+// Adjustment define corrective change of the drifted account's balance, recorded for audit.
+// Amount is added to the balance, so it becomes equal to ExpectedBalance
+type Adjustment struct {
+	ID              int64     `db:"id"`
+	AccountUID      string    `db:"account_uid"`
+	ExpectedBalance int64     `db:"expected_balance"`
+	ActualBalance   int64     `db:"actual_balance"`
+	Amount          int64     `db:"amount"`
+	Reason          string    `db:"reason"`
+	CreatedAt       time.Time `db:"created_at"`
+}
+
+// IdempotencyKey define stored result of the operation, executed with idempotency key.
+// Fingerprint identifies the request, Response contains JSON encoded result to replay
+type IdempotencyKey struct {
+	Operation   string    `db:"operation"`
+	Key         string    `db:"key"`
+	Fingerprint string    `db:"fingerprint"`
+	Response    string    `db:"response"`
+	CreatedAt   time.Time `db:"created_at"`
+	ExpiresAt   time.Time `db:"expires_at"`
+}
+
+// EventType define type of the domain event
+type EventType string
+
+const (
+	// AccountCreatedEvent is emitted, when new account is created
+	AccountCreatedEvent EventType = "account.created"
+	// PaymentCompletedEvent is emitted, when payment is completed
+	PaymentCompletedEvent EventType = "payment.completed"
+	// PaymentRefundedEvent is emitted, when payment is refunded fully or partially
+	PaymentRefundedEvent EventType = "payment.refunded"
+)
+
+// Event define domain event in the outbox. Event is stored in the same scope as the change it describes,
+// so it's dispatched only if the change is committed. Payload contains JSON encoded event's data.
+// Failed attempts to dispatch event are counted, the next attempt is postponed till NextAttemptAt
+type Event struct {
+	ID            int64      `db:"id"`
+	Type          EventType  `db:"type"`
+	Payload       string     `db:"payload"`
+	CreatedAt     time.Time  `db:"created_at"`
+	Attempts      int        `db:"attempts"`
+	NextAttemptAt time.Time  `db:"next_attempt_at"`
+	LastError     string     `db:"last_error"`
+	DispatchedAt  *time.Time `db:"dispatched_at"`
+}
+
+// Webhook define subscription of the URL to domain events. Empty EventType means any event,
+// empty AccountUID means events of any account. Secret signs deliveries, so receiver can verify them
+type Webhook struct {
+	ID         int64     `db:"id"`
+	URL        string    `db:"url"`
+	Secret     string    `db:"secret"`
+	EventType  EventType `db:"event_type"`
+	AccountUID string    `db:"account_uid"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+// DeliveryStatus define status of the event's delivery to the webhook
+type DeliveryStatus string
+
+const (
+	// PendingDelivery is waiting for the next attempt
+	PendingDelivery DeliveryStatus = "pending"
+	// SucceededDelivery is accepted by the webhook
+	SucceededDelivery DeliveryStatus = "succeeded"
+	// FailedDelivery is rejected by the webhook on every attempt, it's delivered again only manually
+	FailedDelivery DeliveryStatus = "failed"
+)
+
+// Delivery define delivery of the event to the webhook. Event's type and payload are copied,
+// so delivery doesn't depend on the outbox. Attempts are counted since creation or the last redelivery
+type Delivery struct {
+	ID            int64          `db:"id"`
+	WebhookID     int64          `db:"webhook_id"`
+	EventID       int64          `db:"event_id"`
+	EventType     EventType      `db:"event_type"`
+	Payload       string         `db:"payload"`
+	EventTime     time.Time      `db:"event_time"`
+	Status        DeliveryStatus `db:"status"`
+	Attempts      int            `db:"attempts"`
+	NextAttemptAt time.Time      `db:"next_attempt_at"`
+	CreatedAt     time.Time      `db:"created_at"`
+	UpdatedAt     time.Time      `db:"updated_at"`
+}
+
+// DeliveryAttempt define single attempt to deliver event to the webhook. StatusCode is zero,
+// if no response is received, Error is empty, if attempt is succeeded
+type DeliveryAttempt struct {
+	ID         int64     `db:"id"`
+	DeliveryID int64     `db:"delivery_id"`
+	StatusCode int       `db:"status_code"`
+	Error      string    `db:"error"`
+	DurationMS int64     `db:"duration_ms"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+// Recurrence define how often scheduled payment is repeated
+type Recurrence string
+
+const (
+	// DailyRecurrence repeats payment every day at the time of the start
+	DailyRecurrence Recurrence = "daily"
+	// WeeklyRecurrence repeats payment every week on the day and at the time of the start
+	WeeklyRecurrence Recurrence = "weekly"
+	// MonthlyRecurrence repeats payment every month on the day of the start or on the last day of the shorter month
+	MonthlyRecurrence Recurrence = "monthly"
+	// CronRecurrence repeats payment by cron expression
+	CronRecurrence Recurrence = "cron"
+)
+
+// InsufficientFundsPolicy define what happens with scheduled payment, if payer has insufficient funds
+type InsufficientFundsPolicy string
+
+const (
+	// SkipOnInsufficientFunds skips the run, payment is tried again at the next occurrence
+	SkipOnInsufficientFunds InsufficientFundsPolicy = "skip"
+	// RetryOnInsufficientFunds retries the run later, till the number of attempts is exhausted
+	RetryOnInsufficientFunds InsufficientFundsPolicy = "retry"
+)
+
+// ScheduleStatus define status of the scheduled payment
+type ScheduleStatus string
+
+const (
+	// ActiveSchedule is executed at every occurrence
+	ActiveSchedule ScheduleStatus = "active"
+	// CancelledSchedule is never executed again
+	CancelledSchedule ScheduleStatus = "cancelled"
+	// CompletedSchedule has no more occurrences
+	CompletedSchedule ScheduleStatus = "completed"
+)
+
+// Schedule define standing order: payment from payer to recipient, which is repeated by recurrence starting
+// at StartAt. Amount is given in payer's currency minor units, Cron is set for cron recurrence only.
+// NextRunAt is the time of the next occurrence, which isn't executed yet
+type Schedule struct {
+	ID                  int64                   `db:"id"`
+	PayerAccountUID     string                  `db:"payer_account_uid"`
+	RecipientAccountUID string                  `db:"recipient_account_uid"`
+	Amount              int64                   `db:"amount"`
+	Currency            string                  `db:"currency"`
+	Recurrence          Recurrence              `db:"recurrence"`
+	Cron                string                  `db:"cron"`
+	OnInsufficientFunds InsufficientFundsPolicy `db:"on_insufficient_funds"`
+	Status              ScheduleStatus          `db:"status"`
+	StartAt             time.Time               `db:"start_at"`
+	NextRunAt           time.Time               `db:"next_run_at"`
+	CreatedAt           time.Time               `db:"created_at"`
+	UpdatedAt           time.Time               `db:"updated_at"`
+}
+
+// RunStatus define status of the scheduled payment's run
+type RunStatus string
+
+const (
+	// PendingRun is waiting for the next attempt
+	PendingRun RunStatus = "pending"
+	// SucceededRun made the payment
+	SucceededRun RunStatus = "succeeded"
+	// SkippedRun made no payment, because payer had insufficient funds or schedule was cancelled
+	SkippedRun RunStatus = "skipped"
+	// FailedRun made no payment, because payment was rejected
+	FailedRun RunStatus = "failed"
+)
+
+// ScheduleRun define execution of the scheduled payment's occurrence. Occurrence is run once,
+// Error is the reason of the last failed attempt
+type ScheduleRun struct {
+	ID            int64     `db:"id"`
+	ScheduleID    int64     `db:"schedule_id"`
+	ScheduledAt   time.Time `db:"scheduled_at"`
+	Status        RunStatus `db:"status"`
+	Attempts      int       `db:"attempts"`
+	NextAttemptAt time.Time `db:"next_attempt_at"`
+	Error         string    `db:"error"`
+	CreatedAt     time.Time `db:"created_at"`
+	UpdatedAt     time.Time `db:"updated_at"`
 }
@@ -0,0 +1,11 @@
+package broker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestBroker(t *testing.T) {
+	suite.Run(t, new(eventBrokerTestSuite))
+}
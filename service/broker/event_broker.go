@@ -0,0 +1,86 @@
+package broker
+
+import (
+	"sync"
+
+	"github.com/Toshik1978/go-rest-api/service"
+	"github.com/Toshik1978/go-rest-api/service/server"
+)
+
+// broker implements EventBroker interface, it keeps subscriptions in memory of the process
+type broker struct {
+	mu            sync.Mutex
+	bufferSize    int
+	subscriptions map[string]map[*subscription]struct{}
+}
+
+// NewBroker creates new in-process EventBroker. Every subscription buffers up to configured number of events
+func NewBroker(vars server.Vars) service.EventBroker {
+	return &broker{
+		bufferSize:    vars.EventsBufferSize,
+		subscriptions: make(map[string]map[*subscription]struct{}),
+	}
+}
+
+func (b *broker) Publish(event service.AccountEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for s := range b.subscriptions[event.AccountUID] {
+		select {
+		case s.events <- event:
+		default:
+			// Subscriber can't keep up, it's better to close subscription, than to lose event silently
+			b.remove(s)
+		}
+	}
+}
+
+func (b *broker) Subscribe(accountUID string) service.EventSubscription {
+	s := &subscription{
+		broker:     b,
+		accountUID: accountUID,
+		events:     make(chan service.AccountEvent, b.bufferSize),
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subscriptions[accountUID] == nil {
+		b.subscriptions[accountUID] = make(map[*subscription]struct{})
+	}
+	b.subscriptions[accountUID][s] = struct{}{}
+	return s
+}
+
+// remove removes subscription and closes its channel, it should be called under lock
+func (b *broker) remove(s *subscription) {
+	subscriptions, ok := b.subscriptions[s.accountUID]
+	if !ok {
+		return
+	}
+	if _, ok := subscriptions[s]; !ok {
+		return
+	}
+	delete(subscriptions, s)
+	if len(subscriptions) == 0 {
+		delete(b.subscriptions, s.accountUID)
+	}
+	close(s.events)
+}
+
+// subscription implements EventSubscription interface
+type subscription struct {
+	broker     *broker
+	accountUID string
+	events     chan service.AccountEvent
+}
+
+func (s *subscription) Events() <-chan service.AccountEvent {
+	return s.events
+}
+
+func (s *subscription) Cancel() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.remove(s)
+}
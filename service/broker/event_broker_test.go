@@ -0,0 +1,68 @@
+package broker
+
+import (
+	"github.com/Toshik1978/go-rest-api/service"
+	"github.com/Toshik1978/go-rest-api/service/server"
+	"github.com/stretchr/testify/suite"
+)
+
+type eventBrokerTestSuite struct {
+	suite.Suite
+
+	event service.AccountEvent
+}
+
+func (s *eventBrokerTestSuite) SetupSuite() {
+	s.event = service.AccountEvent{
+		ID:         7,
+		AccountUID: "toshik1978",
+		Type:       "payment",
+		Data:       []byte(`{"amount":"100.00"}`),
+	}
+}
+
+func (s *eventBrokerTestSuite) TestPublishSucceeded() {
+	broker := NewBroker(server.Vars{EventsBufferSize: 2})
+	subscription := broker.Subscribe(s.event.AccountUID)
+	defer subscription.Cancel()
+	other := broker.Subscribe("toshik1979")
+	defer other.Cancel()
+
+	broker.Publish(s.event)
+
+	s.Equal(s.event, <-subscription.Events())
+	s.Len(other.Events(), 0)
+}
+
+func (s *eventBrokerTestSuite) TestPublishWithoutSubscribersSucceeded() {
+	broker := NewBroker(server.Vars{EventsBufferSize: 2})
+
+	s.NotPanics(func() { broker.Publish(s.event) })
+}
+
+func (s *eventBrokerTestSuite) TestPublishOverflowFailed() {
+	broker := NewBroker(server.Vars{EventsBufferSize: 1})
+	subscription := broker.Subscribe(s.event.AccountUID)
+
+	broker.Publish(s.event)
+	broker.Publish(s.event)
+
+	// Buffered event is received, then channel is closed
+	s.Equal(s.event, <-subscription.Events())
+	_, ok := <-subscription.Events()
+	s.False(ok)
+	s.NotPanics(subscription.Cancel)
+	s.NotPanics(func() { broker.Publish(s.event) })
+}
+
+func (s *eventBrokerTestSuite) TestCancelSucceeded() {
+	broker := NewBroker(server.Vars{EventsBufferSize: 1})
+	subscription := broker.Subscribe(s.event.AccountUID)
+
+	subscription.Cancel()
+	subscription.Cancel()
+	broker.Publish(s.event)
+
+	_, ok := <-subscription.Events()
+	s.False(ok)
+}
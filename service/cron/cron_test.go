@@ -0,0 +1,11 @@
+package cron
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestCron(t *testing.T) {
+	suite.Run(t, new(scheduleTestSuite))
+}
@@ -0,0 +1,68 @@
+package cron
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type scheduleTestSuite struct {
+	suite.Suite
+}
+
+func (s *scheduleTestSuite) TestParseFailed() {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"1,,2 * * * *",
+	} {
+		_, err := Parse(expr)
+		s.Error(err, expr)
+	}
+}
+
+func (s *scheduleTestSuite) TestNextSucceeded() {
+	after := time.Date(2020, time.January, 31, 10, 30, 15, 0, time.UTC)
+	tests := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2020, time.January, 31, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2020, time.January, 31, 10, 45, 0, 0, time.UTC)},
+		{"0 9 * * *", time.Date(2020, time.February, 1, 9, 0, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2020, time.January, 31, 13, 0, 0, 0, time.UTC)},
+		{"0 0 1,15 * *", time.Date(2020, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2020, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 1-5", time.Date(2020, time.February, 3, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2020, time.February, 2, 0, 0, 0, 0, time.UTC)},
+		// Either day of month or day of week matches
+		{"0 0 10 * 6", time.Date(2020, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{"30 10 31 1 *", time.Date(2021, time.January, 31, 10, 30, 0, 0, time.UTC)},
+		// Day field, which covers its whole range, is the same as '*'
+		{"0 0 */1 * 1", time.Date(2020, time.February, 3, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1-31 * 1", time.Date(2020, time.February, 3, 0, 0, 0, 0, time.UTC)},
+		{"0 0 10 * */1", time.Date(2020, time.February, 10, 0, 0, 0, 0, time.UTC)},
+		{"0 0 10 * 0-6", time.Date(2020, time.February, 10, 0, 0, 0, 0, time.UTC)},
+		{"0 0 10 * 1-7", time.Date(2020, time.February, 10, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, test := range tests {
+		schedule, err := Parse(test.expr)
+		s.Require().NoError(err, test.expr)
+		s.Equal(test.expected, schedule.Next(after), test.expr)
+	}
+}
+
+func (s *scheduleTestSuite) TestNextNeverSucceeded() {
+	schedule, err := Parse("0 0 30 2 *")
+	s.Require().NoError(err)
+	s.True(schedule.Next(time.Now()).IsZero())
+}
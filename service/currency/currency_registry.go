@@ -0,0 +1,60 @@
+package currency
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Toshik1978/go-rest-api/service"
+	"github.com/Toshik1978/go-rest-api/service/server"
+)
+
+const (
+	// maxExponent is the biggest minor unit exponent we can scale into int64 safely
+	maxExponent = 4
+)
+
+var codeRegexp = regexp.MustCompile("^[A-Z]{3}$")
+
+// currencyRegistry implements CurrencyRegistry interface
+type currencyRegistry struct {
+	currencies []service.Currency
+	codes      map[string]int
+}
+
+// NewCurrencyRegistry creates new currency registry from configuration
+func NewCurrencyRegistry(vars server.Vars) (service.CurrencyRegistry, error) {
+	registry := &currencyRegistry{
+		currencies: make([]service.Currency, 0, len(vars.Currencies)),
+		codes:      make(map[string]int, len(vars.Currencies)),
+	}
+	for _, currency := range vars.Currencies {
+		currency.Code = strings.ToUpper(currency.Code)
+		if !codeRegexp.MatchString(currency.Code) {
+			return nil, fmt.Errorf("invalid currency code %q", currency.Code)
+		}
+		if currency.Exponent < 0 || currency.Exponent > maxExponent {
+			return nil, fmt.Errorf("invalid exponent %d for currency %v", currency.Exponent, currency.Code)
+		}
+		if _, ok := registry.codes[currency.Code]; ok {
+			return nil, fmt.Errorf("duplicate currency %v", currency.Code)
+		}
+
+		registry.codes[currency.Code] = len(registry.currencies)
+		registry.currencies = append(registry.currencies, currency)
+	}
+	return registry, nil
+}
+
+func (r *currencyRegistry) Currency(code string) (service.Currency, bool) {
+	if i, ok := r.codes[strings.ToUpper(code)]; ok {
+		return r.currencies[i], true
+	}
+	return service.Currency{}, false
+}
+
+func (r *currencyRegistry) Currencies() []service.Currency {
+	currencies := make([]service.Currency, len(r.currencies))
+	copy(currencies, r.currencies)
+	return currencies
+}
@@ -0,0 +1,81 @@
+package currency
+
+import (
+	"github.com/Toshik1978/go-rest-api/service"
+	"github.com/Toshik1978/go-rest-api/service/server"
+	"github.com/stretchr/testify/suite"
+)
+
+type currencyRegistryTestSuite struct {
+	suite.Suite
+}
+
+func (s *currencyRegistryTestSuite) TestNewCurrencyRegistryInvalidCodeFailed() {
+	registry, err := NewCurrencyRegistry(server.Vars{
+		Currencies: []service.Currency{{Code: "US", Exponent: 2, Enabled: true}},
+	})
+
+	s.Error(err)
+	s.Nil(registry)
+}
+
+func (s *currencyRegistryTestSuite) TestNewCurrencyRegistryInvalidExponentFailed() {
+	registry, err := NewCurrencyRegistry(server.Vars{
+		Currencies: []service.Currency{{Code: "USD", Exponent: 10, Enabled: true}},
+	})
+
+	s.Error(err)
+	s.Nil(registry)
+}
+
+func (s *currencyRegistryTestSuite) TestNewCurrencyRegistryDuplicateFailed() {
+	registry, err := NewCurrencyRegistry(server.Vars{
+		Currencies: []service.Currency{
+			{Code: "USD", Exponent: 2, Enabled: true},
+			{Code: "usd", Exponent: 2, Enabled: false},
+		},
+	})
+
+	s.Error(err)
+	s.Nil(registry)
+}
+
+func (s *currencyRegistryTestSuite) TestCurrencySucceeded() {
+	registry, err := NewCurrencyRegistry(server.Vars{
+		Currencies: []service.Currency{
+			{Code: "usd", Exponent: 2, Enabled: true},
+			{Code: "JPY", Exponent: 0, Enabled: true},
+			{Code: "BHD", Exponent: 3, Enabled: false},
+		},
+	})
+	s.NoError(err)
+
+	currency, ok := registry.Currency("USD")
+	s.True(ok)
+	s.Equal(service.Currency{Code: "USD", Exponent: 2, Enabled: true}, currency)
+
+	currency, ok = registry.Currency("bhd")
+	s.True(ok)
+	s.Equal(service.Currency{Code: "BHD", Exponent: 3, Enabled: false}, currency)
+
+	_, ok = registry.Currency("EUR")
+	s.False(ok)
+}
+
+func (s *currencyRegistryTestSuite) TestCurrenciesSucceeded() {
+	registry, err := NewCurrencyRegistry(server.Vars{
+		Currencies: []service.Currency{
+			{Code: "USD", Exponent: 2, Enabled: true},
+			{Code: "JPY", Exponent: 0, Enabled: true},
+		},
+	})
+	s.NoError(err)
+
+	currencies := registry.Currencies()
+	s.Len(currencies, 2)
+	s.Equal("USD", currencies[0].Code)
+	s.Equal("JPY", currencies[1].Code)
+
+	currencies[0].Code = "EUR"
+	s.Equal("USD", registry.Currencies()[0].Code)
+}
@@ -0,0 +1,11 @@
+package currency
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestCurrency(t *testing.T) {
+	suite.Run(t, new(currencyRegistryTestSuite))
+}
@@ -0,0 +1,8 @@
+package service
+
+import "errors"
+
+var (
+	// ErrRateNotFound returned by FXRateProvider, if there is no exchange rate for the given currencies
+	ErrRateNotFound = errors.New("exchange rate not found")
+)
@@ -0,0 +1,77 @@
+package fx
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/Toshik1978/go-rest-api/service"
+	"github.com/Toshik1978/go-rest-api/service/errutil"
+	"github.com/Toshik1978/go-rest-api/service/server"
+	"github.com/spf13/viper"
+)
+
+// rate define exchange rate description in rates file
+type rate struct {
+	From string `mapstructure:"from"`
+	To   string `mapstructure:"to"`
+	Rate string `mapstructure:"rate"`
+}
+
+// pair define currency pair
+type pair struct {
+	from string
+	to   string
+}
+
+// fileRateProvider implements FXRateProvider interface with rates, loaded from file.
+// It doesn't require any network access, so it's fine for offline usage
+type fileRateProvider struct {
+	rates map[pair]*big.Rat
+}
+
+// NewFileRateProvider creates new FXRateProvider with rates from the file, declared in configuration.
+// Provider without rates created, if no file declared (so only same currency exchange available)
+func NewFileRateProvider(vars server.Vars) (service.FXRateProvider, error) {
+	provider := &fileRateProvider{
+		rates: make(map[pair]*big.Rat),
+	}
+	if vars.FXRatesFile == "" {
+		return provider, nil
+	}
+
+	config := viper.New()
+	config.SetConfigFile(vars.FXRatesFile)
+	if err := config.ReadInConfig(); err != nil {
+		return nil, errutil.Wrap(err, "failed to read rates file")
+	}
+	var rates []rate
+	if err := config.UnmarshalKey("rates", &rates); err != nil {
+		return nil, errutil.Wrap(err, "failed to parse rates file")
+	}
+
+	for _, r := range rates {
+		value, ok := new(big.Rat).SetString(r.Rate)
+		if !ok || value.Sign() <= 0 {
+			return nil, fmt.Errorf("invalid rate %q for %v/%v", r.Rate, r.From, r.To)
+		}
+		provider.rates[pair{from: strings.ToUpper(r.From), to: strings.ToUpper(r.To)}] = value
+	}
+	return provider, nil
+}
+
+func (p *fileRateProvider) Rate(_ context.Context, from string, to string) (*big.Rat, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if from == to {
+		return big.NewRat(1, 1), nil
+	}
+	if value, ok := p.rates[pair{from: from, to: to}]; ok {
+		return new(big.Rat).Set(value), nil
+	}
+	// Inverse rate is fine too, if we have no direct one
+	if value, ok := p.rates[pair{from: to, to: from}]; ok {
+		return new(big.Rat).Inv(value), nil
+	}
+	return nil, service.ErrRateNotFound
+}
@@ -0,0 +1,115 @@
+package fx
+
+import (
+	"context"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+
+	"github.com/Toshik1978/go-rest-api/service"
+	"github.com/Toshik1978/go-rest-api/service/server"
+	"github.com/stretchr/testify/suite"
+)
+
+type fileRateProviderTestSuite struct {
+	suite.Suite
+
+	dir string
+}
+
+func (s *fileRateProviderTestSuite) SetupSuite() {
+	dir, err := ioutil.TempDir("", "fx")
+	if err != nil {
+		s.T().Fatal(err)
+	}
+	s.dir = dir
+}
+
+func (s *fileRateProviderTestSuite) TearDownSuite() {
+	_ = os.RemoveAll(s.dir)
+}
+
+func (s *fileRateProviderTestSuite) ratesFile(name string, content string) string {
+	path := filepath.Join(s.dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		s.T().Fatal(err)
+	}
+	return path
+}
+
+func (s *fileRateProviderTestSuite) TestNewFileRateProviderNoFileFailed() {
+	provider, err := NewFileRateProvider(server.Vars{FXRatesFile: filepath.Join(s.dir, "absent.yaml")})
+
+	s.Error(err)
+	s.Nil(provider)
+}
+
+func (s *fileRateProviderTestSuite) TestNewFileRateProviderInvalidRateFailed() {
+	path := s.ratesFile("invalid.yaml", `
+rates:
+  - from: USD
+    to: EUR
+    rate: "abc"
+`)
+	provider, err := NewFileRateProvider(server.Vars{FXRatesFile: path})
+
+	s.Error(err)
+	s.Nil(provider)
+}
+
+func (s *fileRateProviderTestSuite) TestNewFileRateProviderNegativeRateFailed() {
+	path := s.ratesFile("negative.yaml", `
+rates:
+  - from: USD
+    to: EUR
+    rate: "-0.9"
+`)
+	provider, err := NewFileRateProvider(server.Vars{FXRatesFile: path})
+
+	s.Error(err)
+	s.Nil(provider)
+}
+
+func (s *fileRateProviderTestSuite) TestRateWithoutFileSucceeded() {
+	provider, err := NewFileRateProvider(server.Vars{})
+	s.NoError(err)
+
+	rate, err := provider.Rate(context.Background(), "USD", "usd")
+	s.NoError(err)
+	s.Equal(big.NewRat(1, 1), rate)
+
+	rate, err = provider.Rate(context.Background(), "USD", "EUR")
+	s.Equal(service.ErrRateNotFound, err)
+	s.Nil(rate)
+}
+
+func (s *fileRateProviderTestSuite) TestRateSucceeded() {
+	path := s.ratesFile("rates.yaml", `
+rates:
+  - from: usd
+    to: eur
+    rate: "0.9"
+  - from: USD
+    to: JPY
+    rate: 108.5
+`)
+	provider, err := NewFileRateProvider(server.Vars{FXRatesFile: path})
+	s.NoError(err)
+
+	rate, err := provider.Rate(context.Background(), "USD", "EUR")
+	s.NoError(err)
+	s.Equal(big.NewRat(9, 10), rate)
+
+	rate, err = provider.Rate(context.Background(), "EUR", "USD")
+	s.NoError(err)
+	s.Equal(big.NewRat(10, 9), rate)
+
+	rate, err = provider.Rate(context.Background(), "usd", "jpy")
+	s.NoError(err)
+	s.Equal(big.NewRat(217, 2), rate)
+
+	rate, err = provider.Rate(context.Background(), "EUR", "JPY")
+	s.Equal(service.ErrRateNotFound, err)
+	s.Nil(rate)
+}
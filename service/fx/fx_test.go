@@ -0,0 +1,11 @@
+package fx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestFX(t *testing.T) {
+	suite.Run(t, new(fileRateProviderTestSuite))
+}
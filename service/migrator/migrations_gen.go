@@ -0,0 +1,111 @@
+// +build ignore
+
+// This program generates migrations.go in the current directory from SQL files in the source directory.
+// It's invoked by go generate of the database's package:
+//
+//	go run ../migrator/migrations_gen.go -source ../../configs/migrations -package postgres
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	outputFileName = "migrations.go"
+)
+
+// migrationFileName matches migration's file name in format of golang-migrate: 000001_name.up.sql
+var migrationFileName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+type migration struct {
+	version uint64
+	name    string
+	up      string
+	down    string
+}
+
+func main() {
+	migrationsDir := flag.String("source", "", "directory with SQL migrations")
+	packageName := flag.String("package", "", "package of the generated file")
+	flag.Parse()
+	if *migrationsDir == "" || *packageName == "" {
+		log.Fatal("source directory and package should be set")
+	}
+
+	files, err := ioutil.ReadDir(*migrationsDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	byVersion := make(map[uint64]*migration)
+	for _, file := range files {
+		match := migrationFileName.FindStringSubmatch(file.Name())
+		if match == nil {
+			continue
+		}
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			log.Fatal(err)
+		}
+		content, err := ioutil.ReadFile(filepath.Join(*migrationsDir, file.Name()))
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &migration{version: version, name: match[2]}
+			byVersion[version] = m
+		}
+		if match[3] == "up" {
+			m.up = string(content)
+		} else {
+			m.down = string(content)
+		}
+	}
+
+	migrations := make([]*migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by go generate; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", *packageName)
+	buf.WriteString("import \"github.com/Toshik1978/go-rest-api/service/migrator\"\n\n")
+	fmt.Fprintf(&buf, "// migrations contains SQL migrations from %s ordered by version\n",
+		strings.TrimLeft(filepath.ToSlash(*migrationsDir), "./"))
+	buf.WriteString("var migrations = []migrator.Migration{\n")
+	for _, m := range migrations {
+		fmt.Fprintf(&buf, "{\nVersion: %d,\nName: %q,\nUp: %s,\nDown: %s,\n},\n",
+			m.version, m.name, quote(m.up), quote(m.down))
+	}
+	buf.WriteString("}\n")
+
+	source, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile(outputFileName, source, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// quote return Go literal of the SQL, raw string is used if possible to keep SQL readable
+func quote(sql string) string {
+	if strings.Contains(sql, "`") || strings.Contains(sql, "\r") {
+		return strconv.Quote(sql)
+	}
+	return "`" + sql + "`"
+}
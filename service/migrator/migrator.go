@@ -0,0 +1,233 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Toshik1978/go-rest-api/service"
+	"github.com/Toshik1978/go-rest-api/service/errutil"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+const (
+	// Version table is compatible with golang-migrate, so schema migrated by it is recognized
+	createVersionTableSQL = `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT NOT NULL PRIMARY KEY,
+			dirty BOOLEAN NOT NULL)`
+	getVersionSQL    = `SELECT version, dirty FROM schema_migrations LIMIT 1`
+	deleteVersionSQL = `DELETE FROM schema_migrations`
+	storeVersionSQL  = `INSERT INTO schema_migrations (version, dirty) VALUES (?, FALSE)`
+)
+
+// Migration define SQL migration, embedded into the binary
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Dialect define database specific SQL of the migrator
+type Dialect struct {
+	// LockSQL serializes migrations of concurrent instances till the end of transaction, it's optional
+	LockSQL string
+	// VersionTableExistsSQL return true, if version table exists
+	VersionTableExistsSQL string
+}
+
+// migrator implements Migrator
+type migrator struct {
+	db         *sqlx.DB
+	logger     *zap.Logger
+	dialect    Dialect
+	migrations []Migration
+}
+
+// NewMigrator creates new migrator of database schema with the given migrations ordered by version
+func NewMigrator(logger *zap.Logger, db *sqlx.DB, dialect Dialect, migrations []Migration) service.Migrator {
+	return &migrator{
+		db:         db,
+		logger:     logger,
+		dialect:    dialect,
+		migrations: migrations,
+	}
+}
+
+func (m *migrator) Up(ctx context.Context, n int) error {
+	return m.migrate(ctx, n, m.up)
+}
+
+func (m *migrator) Down(ctx context.Context, n int) error {
+	return m.migrate(ctx, n, m.down)
+}
+
+func (m *migrator) Version(ctx context.Context) (service.SchemaVersion, error) {
+	var exists bool
+	if err := m.db.GetContext(ctx, &exists, m.dialect.VersionTableExistsSQL); err != nil {
+		return service.SchemaVersion{}, errutil.Wrap(err, "failed to check schema version table")
+	}
+	if !exists {
+		return service.SchemaVersion{}, nil
+	}
+	return schemaVersion(ctx, m.db)
+}
+
+func (m *migrator) Status(ctx context.Context) ([]service.Migration, error) {
+	version, err := m.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]service.Migration, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		statuses = append(statuses, service.Migration{
+			Version: migration.Version,
+			Name:    migration.Name,
+			Applied: migration.Version <= version.Version,
+		})
+	}
+	return statuses, nil
+}
+
+func (m *migrator) Latest() uint {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// migrate runs step n times or till there is nothing to do. Every step is run in it's own transaction,
+// so failed migration is rolled back and schema is never left dirty
+func (m *migrator) migrate(ctx context.Context,
+	n int, step func(ctx context.Context, tx *sqlx.Tx, version uint) (bool, error)) error {
+
+	for i := 0; n <= 0 || i < n; i++ {
+		done, err := m.inTransaction(ctx, step)
+		if err != nil {
+			return err
+		}
+		if !done {
+			break
+		}
+	}
+	return nil
+}
+
+// inTransaction runs step in the new transaction with current schema version. Migrations of concurrent instances
+// are serialized by dialect's lock, which is held till the end of transaction
+func (m *migrator) inTransaction(ctx context.Context,
+	step func(ctx context.Context, tx *sqlx.Tx, version uint) (bool, error)) (bool, error) {
+
+	tx, err := m.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, errutil.Wrap(err, "failed to start transaction")
+	}
+	// Rollback is safe after commit
+	defer func() { _ = tx.Rollback() }()
+
+	if m.dialect.LockSQL != "" {
+		if _, err := tx.ExecContext(ctx, m.dialect.LockSQL); err != nil {
+			return false, errutil.Wrap(err, "failed to lock migrations")
+		}
+	}
+	if _, err := tx.ExecContext(ctx, createVersionTableSQL); err != nil {
+		return false, errutil.Wrap(err, "failed to create schema version table")
+	}
+	version, err := schemaVersion(ctx, tx)
+	if err != nil {
+		return false, err
+	}
+	if version.Dirty {
+		return false, fmt.Errorf("schema is dirty at version %d, it should be fixed manually", version.Version)
+	}
+
+	done, err := step(ctx, tx, version.Version)
+	if err != nil || !done {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, errutil.Wrap(err, "failed to commit migration")
+	}
+	return true, nil
+}
+
+// up applies the next migration after the given version, false returned if there is no such migration
+func (m *migrator) up(ctx context.Context, tx *sqlx.Tx, version uint) (bool, error) {
+	for _, migration := range m.migrations {
+		if migration.Version <= version {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+			return false, errutil.Wrap(err, fmt.Sprintf("failed to apply migration %d_%s",
+				migration.Version, migration.Name))
+		}
+		if err := storeVersion(ctx, tx, migration.Version); err != nil {
+			return false, err
+		}
+		m.logger.Info("Migration applied",
+			zap.Uint("version", migration.Version),
+			zap.String("name", migration.Name))
+		return true, nil
+	}
+	return false, nil
+}
+
+// down reverts migration of the given version, false returned if schema is empty
+func (m *migrator) down(ctx context.Context, tx *sqlx.Tx, version uint) (bool, error) {
+	if version == 0 {
+		return false, nil
+	}
+	previous := uint(0)
+	for _, migration := range m.migrations {
+		if migration.Version < version {
+			previous = migration.Version
+			continue
+		}
+		if migration.Version > version {
+			break
+		}
+		if _, err := tx.ExecContext(ctx, migration.Down); err != nil {
+			return false, errutil.Wrap(err, fmt.Sprintf("failed to revert migration %d_%s",
+				migration.Version, migration.Name))
+		}
+		if err := storeVersion(ctx, tx, previous); err != nil {
+			return false, err
+		}
+		m.logger.Info("Migration reverted",
+			zap.Uint("version", migration.Version),
+			zap.String("name", migration.Name))
+		return true, nil
+	}
+	return false, fmt.Errorf("migration %d is not known to the binary", version)
+}
+
+// schemaVersion return current schema version from existing version table
+func schemaVersion(ctx context.Context, q sqlx.QueryerContext) (service.SchemaVersion, error) {
+	var version service.SchemaVersion
+	err := q.QueryRowxContext(ctx, getVersionSQL).Scan(&version.Version, &version.Dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return service.SchemaVersion{}, nil
+	}
+	if err != nil {
+		return service.SchemaVersion{}, errutil.Wrap(err, "failed to get schema version")
+	}
+	return version, nil
+}
+
+// storeVersion replaces schema version, zero version means empty schema
+func storeVersion(ctx context.Context, tx *sqlx.Tx, version uint) error {
+	if _, err := tx.ExecContext(ctx, deleteVersionSQL); err != nil {
+		return errutil.Wrap(err, "failed to store schema version")
+	}
+	if version == 0 {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, tx.Rebind(storeVersionSQL), version); err != nil {
+		return errutil.Wrap(err, "failed to store schema version")
+	}
+	return nil
+}
@@ -0,0 +1,300 @@
+package migrator
+
+import (
+	"context"
+	"errors"
+	"regexp"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Toshik1978/go-rest-api/service"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type migratorTestSuite struct {
+	suite.Suite
+
+	dialect    Dialect
+	migrations []Migration
+}
+
+func (s *migratorTestSuite) SetupSuite() {
+	s.dialect = Dialect{
+		LockSQL:               "SELECT pg_advisory_xact_lock(1)",
+		VersionTableExistsSQL: "SELECT to_regclass('schema_migrations') IS NOT NULL",
+	}
+	s.migrations = []Migration{
+		{Version: 1, Name: "create_accounts", Up: "CREATE TABLE accounts()", Down: "DROP TABLE accounts"},
+		{Version: 3, Name: "create_payments", Up: "CREATE TABLE payments()", Down: "DROP TABLE payments"},
+	}
+}
+
+// newMigrator creates migrator with test migrations over stub database connection
+func (s *migratorTestSuite) newMigrator() (*migrator, sqlmock.Sqlmock, *observer.ObservedLogs, func()) {
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	return &migrator{
+		db:         sqlx.NewDb(db, "sqlmock"),
+		logger:     zap.New(zapCore),
+		dialect:    s.dialect,
+		migrations: s.migrations,
+	}, mockSQL, zapRecorded, func() { _ = db.Close() }
+}
+
+// expectVersion expects start of migration's transaction at the given schema version
+func (s *migratorTestSuite) expectVersion(mockSQL sqlmock.Sqlmock, version uint, dirty bool) {
+	mockSQL.ExpectBegin()
+	if s.dialect.LockSQL != "" {
+		mockSQL.
+			ExpectExec(regexp.QuoteMeta(s.dialect.LockSQL)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+	mockSQL.
+		ExpectExec(regexp.QuoteMeta(createVersionTableSQL)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	rows := sqlmock.NewRows([]string{"version", "dirty"})
+	if version > 0 {
+		rows.AddRow(version, dirty)
+	}
+	mockSQL.
+		ExpectQuery(regexp.QuoteMeta(getVersionSQL)).
+		WillReturnRows(rows)
+}
+
+func (s *migratorTestSuite) TestMigratorLatestSucceeded() {
+	m, _, _, closer := s.newMigrator()
+	defer closer()
+
+	s.Equal(uint(3), m.Latest())
+	s.Equal(uint(0), (&migrator{}).Latest())
+}
+
+func (s *migratorTestSuite) TestMigratorVersionEmptySucceeded() {
+	m, mockSQL, _, closer := s.newMigrator()
+	defer closer()
+
+	mockSQL.
+		ExpectQuery(regexp.QuoteMeta(s.dialect.VersionTableExistsSQL)).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+	version, err := m.Version(context.Background())
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.NoError(err)
+	s.Equal(service.SchemaVersion{}, version)
+}
+
+func (s *migratorTestSuite) TestMigratorVersionFailed() {
+	m, mockSQL, _, closer := s.newMigrator()
+	defer closer()
+
+	mockSQL.
+		ExpectQuery(regexp.QuoteMeta(s.dialect.VersionTableExistsSQL)).
+		WillReturnError(errors.New("fail"))
+
+	_, err := m.Version(context.Background())
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.Error(err)
+}
+
+func (s *migratorTestSuite) TestMigratorStatusSucceeded() {
+	m, mockSQL, _, closer := s.newMigrator()
+	defer closer()
+
+	mockSQL.
+		ExpectQuery(regexp.QuoteMeta(s.dialect.VersionTableExistsSQL)).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mockSQL.
+		ExpectQuery(regexp.QuoteMeta(getVersionSQL)).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(1, false))
+
+	statuses, err := m.Status(context.Background())
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.NoError(err)
+	s.Equal([]service.Migration{
+		{Version: 1, Name: "create_accounts", Applied: true},
+		{Version: 3, Name: "create_payments", Applied: false},
+	}, statuses)
+}
+
+func (s *migratorTestSuite) TestMigratorUpSucceeded() {
+	m, mockSQL, zapRecorded, closer := s.newMigrator()
+	defer closer()
+
+	s.expectVersion(mockSQL, 0, false)
+	mockSQL.
+		ExpectExec(regexp.QuoteMeta(s.migrations[0].Up)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mockSQL.
+		ExpectExec(regexp.QuoteMeta(deleteVersionSQL)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mockSQL.
+		ExpectExec(regexp.QuoteMeta(storeVersionSQL)).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockSQL.ExpectCommit()
+	s.expectVersion(mockSQL, 1, false)
+	mockSQL.
+		ExpectExec(regexp.QuoteMeta(s.migrations[1].Up)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mockSQL.
+		ExpectExec(regexp.QuoteMeta(deleteVersionSQL)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockSQL.
+		ExpectExec(regexp.QuoteMeta(storeVersionSQL)).
+		WithArgs(3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockSQL.ExpectCommit()
+	s.expectVersion(mockSQL, 3, false)
+	mockSQL.ExpectRollback()
+
+	err := m.Up(context.Background(), 0)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.NoError(err)
+	s.Equal(2, zapRecorded.Len())
+}
+
+func (s *migratorTestSuite) TestMigratorUpStepsSucceeded() {
+	m, mockSQL, zapRecorded, closer := s.newMigrator()
+	defer closer()
+
+	s.expectVersion(mockSQL, 0, false)
+	mockSQL.
+		ExpectExec(regexp.QuoteMeta(s.migrations[0].Up)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mockSQL.
+		ExpectExec(regexp.QuoteMeta(deleteVersionSQL)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mockSQL.
+		ExpectExec(regexp.QuoteMeta(storeVersionSQL)).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockSQL.ExpectCommit()
+
+	err := m.Up(context.Background(), 1)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.NoError(err)
+	s.Equal(1, zapRecorded.Len())
+}
+
+func (s *migratorTestSuite) TestMigratorUpFailed() {
+	m, mockSQL, zapRecorded, closer := s.newMigrator()
+	defer closer()
+
+	s.expectVersion(mockSQL, 0, false)
+	mockSQL.
+		ExpectExec(regexp.QuoteMeta(s.migrations[0].Up)).
+		WillReturnError(errors.New("fail"))
+	mockSQL.ExpectRollback()
+
+	err := m.Up(context.Background(), 0)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.Error(err)
+	s.Equal(0, zapRecorded.Len())
+}
+
+func (s *migratorTestSuite) TestMigratorUpDirtyFailed() {
+	m, mockSQL, zapRecorded, closer := s.newMigrator()
+	defer closer()
+
+	s.expectVersion(mockSQL, 1, true)
+	mockSQL.ExpectRollback()
+
+	err := m.Up(context.Background(), 0)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.Error(err)
+	s.Equal(0, zapRecorded.Len())
+}
+
+func (s *migratorTestSuite) TestMigratorDownSucceeded() {
+	m, mockSQL, zapRecorded, closer := s.newMigrator()
+	defer closer()
+
+	s.expectVersion(mockSQL, 3, false)
+	mockSQL.
+		ExpectExec(regexp.QuoteMeta(s.migrations[1].Down)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mockSQL.
+		ExpectExec(regexp.QuoteMeta(deleteVersionSQL)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockSQL.
+		ExpectExec(regexp.QuoteMeta(storeVersionSQL)).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockSQL.ExpectCommit()
+
+	err := m.Down(context.Background(), 1)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.NoError(err)
+	s.Equal(1, zapRecorded.Len())
+}
+
+func (s *migratorTestSuite) TestMigratorDownAllSucceeded() {
+	m, mockSQL, zapRecorded, closer := s.newMigrator()
+	defer closer()
+
+	s.expectVersion(mockSQL, 1, false)
+	mockSQL.
+		ExpectExec(regexp.QuoteMeta(s.migrations[0].Down)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mockSQL.
+		ExpectExec(regexp.QuoteMeta(deleteVersionSQL)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockSQL.ExpectCommit()
+	s.expectVersion(mockSQL, 0, false)
+	mockSQL.ExpectRollback()
+
+	err := m.Down(context.Background(), 0)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.NoError(err)
+	s.Equal(1, zapRecorded.Len())
+}
+
+func (s *migratorTestSuite) TestMigratorDownUnknownFailed() {
+	m, mockSQL, zapRecorded, closer := s.newMigrator()
+	defer closer()
+
+	s.expectVersion(mockSQL, 2, false)
+	mockSQL.ExpectRollback()
+
+	err := m.Down(context.Background(), 1)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.Error(err)
+	s.Equal(0, zapRecorded.Len())
+}
+
+func (s *migratorTestSuite) TestMigratorUpWithoutLockSucceeded() {
+	m, mockSQL, zapRecorded, closer := s.newMigrator()
+	defer closer()
+	m.dialect.LockSQL = ""
+
+	mockSQL.ExpectBegin()
+	mockSQL.
+		ExpectExec(regexp.QuoteMeta(createVersionTableSQL)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mockSQL.
+		ExpectQuery(regexp.QuoteMeta(getVersionSQL)).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(3, false))
+	mockSQL.ExpectRollback()
+
+	err := m.Up(context.Background(), 0)
+
+	s.NoError(mockSQL.ExpectationsWereMet())
+	s.NoError(err)
+	s.Equal(0, zapRecorded.Len())
+}
@@ -0,0 +1,11 @@
+package migrator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestMigrator(t *testing.T) {
+	suite.Run(t, new(migratorTestSuite))
+}
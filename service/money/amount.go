@@ -0,0 +1,182 @@
+package money
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxDigits is the max number of significant digits, which fits into int64 safely
+	maxDigits = 18
+)
+
+var (
+	// ErrInvalidAmount returned, if amount can't be parsed
+	ErrInvalidAmount = errors.New("invalid amount")
+	// ErrPrecision returned, if amount has more decimal places, than currency allows
+	ErrPrecision = errors.New("amount has too many decimal places")
+	// ErrOverflow returned, if amount doesn't fit into minor units
+	ErrOverflow = errors.New("amount overflow")
+)
+
+// Amount define exact decimal money amount, equals to value * 10^(-scale).
+// It's encoded as decimal string in JSON and never passes through float conversion
+type Amount struct {
+	value int64
+	scale int
+}
+
+// FromMinorUnits creates new amount from currency's minor units (e.g. cents)
+func FromMinorUnits(value int64, exponent int) Amount {
+	return Amount{
+		value: value,
+		scale: exponent,
+	}
+}
+
+// Parse parses amount from it's decimal representation (e.g. "-10.29")
+func Parse(s string) (Amount, error) {
+	digits := s
+	negative := false
+	if strings.HasPrefix(digits, "-") || strings.HasPrefix(digits, "+") {
+		negative = digits[0] == '-'
+		digits = digits[1:]
+	}
+
+	integer, fraction := digits, ""
+	if i := strings.IndexByte(digits, '.'); i >= 0 {
+		integer, fraction = digits[:i], digits[i+1:]
+		if len(fraction) == 0 {
+			return Amount{}, ErrInvalidAmount
+		}
+	}
+	if len(integer) == 0 || !isDigits(integer) || !isDigits(fraction) {
+		return Amount{}, ErrInvalidAmount
+	}
+
+	// Insignificant zeros are trimmed only, if amount doesn't fit into int64 otherwise
+	unscaled := strings.TrimLeft(integer+fraction, "0")
+	for len(unscaled) > maxDigits && strings.HasSuffix(fraction, "0") {
+		unscaled, fraction = unscaled[:len(unscaled)-1], fraction[:len(fraction)-1]
+	}
+	if len(unscaled) > maxDigits {
+		return Amount{}, ErrOverflow
+	}
+	value := int64(0)
+	if len(unscaled) > 0 {
+		var err error
+		if value, err = strconv.ParseInt(unscaled, 10, 64); err != nil {
+			return Amount{}, ErrInvalidAmount
+		}
+	}
+	if negative {
+		value = -value
+	}
+	return Amount{
+		value: value,
+		scale: len(fraction),
+	}, nil
+}
+
+// MustParse parses amount and panics on failure (useful for constants)
+func MustParse(s string) Amount {
+	amount, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return amount
+}
+
+// MinorUnits return amount in currency's minor units with the given exponent.
+// ErrPrecision returned, if amount can't be represented in such minor units exactly
+func (a Amount) MinorUnits(exponent int) (int64, error) {
+	value := a.value
+	for scale := a.scale; scale > exponent; scale-- {
+		if value%10 != 0 {
+			return 0, ErrPrecision
+		}
+		value /= 10
+	}
+	for scale := a.scale; scale < exponent; scale++ {
+		if value > math.MaxInt64/10 || value < math.MinInt64/10 {
+			return 0, ErrOverflow
+		}
+		value *= 10
+	}
+	return value, nil
+}
+
+// Sign return -1, 0 or 1 depending on sign of amount
+func (a Amount) Sign() int {
+	switch {
+	case a.value < 0:
+		return -1
+	case a.value > 0:
+		return 1
+	}
+	return 0
+}
+
+// Neg return negated amount
+func (a Amount) Neg() Amount {
+	return Amount{
+		value: -a.value,
+		scale: a.scale,
+	}
+}
+
+// String return decimal representation of amount with all it's decimal places
+func (a Amount) String() string {
+	digits := strconv.FormatInt(a.value, 10)
+	sign := ""
+	if a.value < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	if a.scale <= 0 {
+		return sign + digits
+	}
+	if len(digits) <= a.scale {
+		digits = strings.Repeat("0", a.scale-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-a.scale] + "." + digits[len(digits)-a.scale:]
+}
+
+// MarshalJSON implements json.Marshaler
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+// Both string ("10.29") and number (10.29) forms are accepted, number is parsed from it's text without float conversion
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	text := string(data)
+	if text == "null" {
+		return nil
+	}
+	if bytes.HasPrefix(data, []byte(`"`)) {
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+	}
+	amount, err := Parse(text)
+	if err != nil {
+		return fmt.Errorf("failed to parse amount %v: %w", string(data), err)
+	}
+	*a = amount
+	return nil
+}
+
+// isDigits checks, that string contains only decimal digits
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,113 @@
+package money
+
+import (
+	"encoding/json"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type amountTestSuite struct {
+	suite.Suite
+}
+
+func (s *amountTestSuite) TestParseFailed() {
+	for _, text := range []string{
+		"", "-", ".", "1.", ".5", "1.2.3", "1e2", "abc", "1,5", " 1", "--1", "12345678901234567890",
+	} {
+		_, err := Parse(text)
+		s.Error(err, text)
+	}
+}
+
+func (s *amountTestSuite) TestParseSucceeded() {
+	for _, test := range []struct {
+		text     string
+		expected Amount
+	}{
+		{text: "0", expected: Amount{value: 0, scale: 0}},
+		{text: "100", expected: Amount{value: 100, scale: 0}},
+		{text: "0.29", expected: Amount{value: 29, scale: 2}},
+		{text: "+10.50", expected: Amount{value: 1050, scale: 2}},
+		{text: "-1.234", expected: Amount{value: -1234, scale: 3}},
+		{text: "007.10", expected: Amount{value: 710, scale: 2}},
+		{text: "1.0000000000000000000000", expected: Amount{value: 100000000000000000, scale: 17}},
+	} {
+		amount, err := Parse(test.text)
+
+		s.NoError(err, test.text)
+		s.Equal(test.expected, amount, test.text)
+	}
+}
+
+func (s *amountTestSuite) TestMinorUnitsFailed() {
+	_, err := MustParse("0.001").MinorUnits(2)
+	s.Equal(ErrPrecision, err)
+
+	_, err = MustParse("10.5").MinorUnits(0)
+	s.Equal(ErrPrecision, err)
+
+	_, err = MustParse("100000000000000000").MinorUnits(3)
+	s.Equal(ErrOverflow, err)
+}
+
+func (s *amountTestSuite) TestMinorUnitsSucceeded() {
+	for _, test := range []struct {
+		text     string
+		exponent int
+		expected int64
+	}{
+		{text: "0.29", exponent: 2, expected: 29},
+		{text: "0.3", exponent: 2, expected: 30},
+		{text: "1234", exponent: 0, expected: 1234},
+		{text: "1234.00", exponent: 0, expected: 1234},
+		{text: "1.234", exponent: 3, expected: 1234},
+		{text: "-1.5", exponent: 3, expected: -1500},
+	} {
+		minor, err := MustParse(test.text).MinorUnits(test.exponent)
+
+		s.NoError(err, test.text)
+		s.Equal(test.expected, minor, test.text)
+	}
+}
+
+func (s *amountTestSuite) TestStringSucceeded() {
+	s.Equal("0.29", FromMinorUnits(29, 2).String())
+	s.Equal("0.05", FromMinorUnits(5, 2).String())
+	s.Equal("-0.05", FromMinorUnits(-5, 2).String())
+	s.Equal("1234", FromMinorUnits(1234, 0).String())
+	s.Equal("1.234", FromMinorUnits(1234, 3).String())
+	s.Equal("0.000", FromMinorUnits(0, 3).String())
+	s.Equal("-12.30", FromMinorUnits(-1230, 2).String())
+}
+
+func (s *amountTestSuite) TestSignSucceeded() {
+	s.Equal(-1, MustParse("-0.01").Sign())
+	s.Equal(0, MustParse("0.00").Sign())
+	s.Equal(1, MustParse("0.01").Sign())
+	s.Equal(MustParse("-0.01"), MustParse("0.01").Neg())
+}
+
+func (s *amountTestSuite) TestJSONFailed() {
+	var amount Amount
+	s.Error(json.Unmarshal([]byte(`"1.2.3"`), &amount))
+	s.Error(json.Unmarshal([]byte(`1e2`), &amount))
+	s.Error(json.Unmarshal([]byte(`true`), &amount))
+}
+
+func (s *amountTestSuite) TestJSONSucceeded() {
+	payload, err := json.Marshal(struct {
+		Amount Amount `json:"amount"`
+	}{Amount: FromMinorUnits(1029, 2)})
+	s.NoError(err)
+	s.Equal(`{"amount":"10.29"}`, string(payload))
+
+	var request struct {
+		Amount  Amount `json:"amount"`
+		Balance Amount `json:"balance"`
+		Empty   Amount `json:"empty"`
+	}
+	s.NoError(json.Unmarshal([]byte(`{"amount": "0.29", "balance": 0.29, "empty": null}`), &request))
+	s.Equal(MustParse("0.29"), request.Amount)
+	s.Equal(MustParse("0.29"), request.Balance)
+	s.Equal(Amount{}, request.Empty)
+}
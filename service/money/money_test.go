@@ -0,0 +1,11 @@
+package money
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestMoney(t *testing.T) {
+	suite.Run(t, new(amountTestSuite))
+}
@@ -0,0 +1,11 @@
+package netutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestNetUtil(t *testing.T) {
+	suite.Run(t, new(publicTestSuite))
+}
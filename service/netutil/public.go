@@ -0,0 +1,85 @@
+package netutil
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"syscall"
+)
+
+// ErrNotPublicAddress declare error of connection to the address, which isn't reachable from the Internet
+var ErrNotPublicAddress = errors.New("address is not public")
+
+// nonPublicNetworks define loopback, link-local, private and other special purpose networks.
+// IPv4-mapped IPv6 addresses are matched with IPv4 networks
+var nonPublicNetworks = parseNetworks(
+	"0.0.0.0/8",      // "This" network
+	"10.0.0.0/8",     // Private
+	"100.64.0.0/10",  // Shared address space
+	"127.0.0.0/8",    // Loopback
+	"169.254.0.0/16", // Link-local, including cloud metadata services
+	"172.16.0.0/12",  // Private
+	"192.0.0.0/24",   // IETF protocol assignments
+	"192.168.0.0/16", // Private
+	"198.18.0.0/15",  // Benchmarking
+	"224.0.0.0/4",    // Multicast
+	"240.0.0.0/4",    // Reserved and broadcast
+	"::/128",         // Unspecified
+	"::1/128",        // Loopback
+	"64:ff9b:1::/48", // Local-use IPv4/IPv6 translation
+	"fc00::/7",       // Unique local
+	"fe80::/10",      // Link-local
+	"ff00::/8",       // Multicast
+)
+
+// IsPublicIP checks, if IP address is reachable from the Internet
+func IsPublicIP(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for _, network := range nonPublicNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsPublicHost checks, if host name or IP address isn't obviously local. Names are resolved on connection,
+// so they're checked by PublicOnly then
+func IsPublicHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	}
+	if ip := net.ParseIP(strings.Trim(host, "[]")); ip != nil {
+		return IsPublicIP(ip)
+	}
+	return true
+}
+
+// PublicOnly is net.Dialer's control function, which rejects connection to the address, which isn't public.
+// It's called after name is resolved, so DNS can't redirect connection to local address
+func PublicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if !IsPublicIP(net.ParseIP(host)) {
+		return ErrNotPublicAddress
+	}
+	return nil
+}
+
+// parseNetworks parses CIDR notations of networks
+func parseNetworks(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
@@ -0,0 +1,51 @@
+package netutil
+
+import (
+	"net"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type publicTestSuite struct {
+	suite.Suite
+}
+
+func (s *publicTestSuite) TestIsPublicIPFailed() {
+	for _, ip := range []string{
+		"0.0.0.0", "10.1.2.3", "100.64.0.1", "127.0.0.1", "169.254.169.254", "172.16.0.1", "172.31.255.255",
+		"192.168.1.1", "224.0.0.1", "255.255.255.255", "::", "::1", "::ffff:127.0.0.1", "::ffff:169.254.169.254",
+		"fd00::1", "fe80::1", "ff02::1",
+	} {
+		s.False(IsPublicIP(net.ParseIP(ip)), ip)
+	}
+	s.False(IsPublicIP(nil))
+}
+
+func (s *publicTestSuite) TestIsPublicIPSucceeded() {
+	for _, ip := range []string{"1.1.1.1", "8.8.8.8", "172.32.0.1", "93.184.216.34", "2606:4700::1111"} {
+		s.True(IsPublicIP(net.ParseIP(ip)), ip)
+	}
+}
+
+func (s *publicTestSuite) TestIsPublicHostFailed() {
+	for _, host := range []string{"localhost", "LOCALHOST.", "api.localhost", "127.0.0.1", "[::1]", "169.254.169.254"} {
+		s.False(IsPublicHost(host), host)
+	}
+}
+
+func (s *publicTestSuite) TestIsPublicHostSucceeded() {
+	for _, host := range []string{"example.com", "localhost.example.com", "8.8.8.8", "[2606:4700::1111]"} {
+		s.True(IsPublicHost(host), host)
+	}
+}
+
+func (s *publicTestSuite) TestPublicOnlyFailed() {
+	s.Equal(ErrNotPublicAddress, PublicOnly("tcp4", "127.0.0.1:80", nil))
+	s.Equal(ErrNotPublicAddress, PublicOnly("tcp6", "[::1]:80", nil))
+	s.Error(PublicOnly("tcp", "127.0.0.1", nil))
+}
+
+func (s *publicTestSuite) TestPublicOnlySucceeded() {
+	s.NoError(PublicOnly("tcp4", "93.184.216.34:443", nil))
+	s.NoError(PublicOnly("tcp6", "[2606:4700::1111]:443", nil))
+}
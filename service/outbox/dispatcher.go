@@ -0,0 +1,92 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service"
+	"github.com/Toshik1978/go-rest-api/service/poller"
+	"github.com/Toshik1978/go-rest-api/service/server"
+	"go.uber.org/zap"
+)
+
+// dispatcher implements EventDispatcher interface. It polls the outbox and delivers pending events to the sink
+// one by one in order of IDs. Event is marked dispatched only after successful delivery, so it's delivered
+// at least once: event is delivered again, if the service is stopped before it's marked
+type dispatcher struct {
+	*poller.Poller
+
+	logger            *zap.Logger
+	repositoryFactory repository.Factory
+	sink              service.EventSink
+	batchSize         int
+	timeout           time.Duration
+}
+
+// NewDispatcher creates new EventDispatcher, which delivers events from the outbox to the sink
+func NewDispatcher(logger *zap.Logger, repositoryFactory repository.Factory,
+	sink service.EventSink, vars server.Vars) service.EventDispatcher {
+
+	d := &dispatcher{
+		logger:            logger,
+		repositoryFactory: repositoryFactory,
+		sink:              sink,
+		batchSize:         vars.OutboxBatchSize,
+		timeout:           vars.OutboxTimeout,
+	}
+	d.Poller = poller.New(logger, "Outbox dispatcher", vars.OutboxInterval, vars.OutboxBatchSize, d.dispatch)
+	return d
+}
+
+// dispatch delivers single batch of pending events and return number of events in the batch.
+// Failed event is postponed with exponential backoff, so it doesn't stop delivery of other events
+func (d *dispatcher) dispatch(ctx context.Context) (int, error) {
+	events, err := d.repositoryFactory.OutboxRepository().GetPending(ctx, time.Now(), d.batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, event := range events {
+		if err := d.deliver(ctx, event); err != nil {
+			// Event isn't marked, if dispatcher is stopped, so it's delivered again after start
+			if ctx.Err() != nil {
+				return 0, ctx.Err()
+			}
+
+			attempts := event.Attempts + 1
+			nextAttemptAt := time.Now().Add(poller.RetryDelay(attempts))
+			d.logger.Warn("Failed to deliver event, retry later",
+				zap.Int64("event_id", event.ID),
+				zap.String("event_type", string(event.Type)),
+				zap.Int("attempts", attempts),
+				zap.Time("next_attempt_at", nextAttemptAt),
+				zap.Error(err))
+			if err := d.repositoryFactory.OutboxRepository().
+				MarkFailed(ctx, event.ID, err.Error(), nextAttemptAt); err != nil {
+
+				return 0, err
+			}
+			continue
+		}
+
+		if err := d.repositoryFactory.OutboxRepository().MarkDispatched(ctx, event.ID, time.Now()); err != nil {
+			return 0, err
+		}
+	}
+	return len(events), nil
+}
+
+// deliver delivers single event to the sink, delivery is limited by timeout
+func (d *dispatcher) deliver(ctx context.Context, event repository.Event) error {
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+
+	return d.sink.Deliver(ctx, service.Event{
+		ID:        event.ID,
+		Type:      string(event.Type),
+		Data:      json.RawMessage(event.Payload),
+		CreatedAt: event.CreatedAt,
+	})
+}
@@ -0,0 +1,159 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/mock"
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service"
+	"github.com/Toshik1978/go-rest-api/service/server"
+	"github.com/Toshik1978/go-rest-api/service/testutil"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type dispatcherTestSuite struct {
+	suite.Suite
+
+	vars  server.Vars
+	event repository.Event
+}
+
+func (s *dispatcherTestSuite) SetupTest() {
+	s.vars = server.Vars{
+		OutboxInterval:  time.Millisecond,
+		OutboxBatchSize: 10,
+		OutboxTimeout:   time.Second,
+	}
+	s.event = testutil.RepositoryEvent()
+	s.event.ID = 7
+}
+
+func (s *dispatcherTestSuite) TestDispatchGetPendingFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	outboxRepository := mock.NewMockOutboxRepository(ctrl)
+	outboxRepository.
+		EXPECT().
+		GetPending(gomock.Any(), gomock.Any(), gomock.Eq(s.vars.OutboxBatchSize)).
+		Return(nil, errors.New("fail"))
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		OutboxRepository().
+		Return(outboxRepository)
+	sink := mock.NewMockEventSink(ctrl)
+
+	d := NewDispatcher(zap.NewNop(), factory, sink, s.vars).(*dispatcher)
+	dispatched, err := d.dispatch(context.Background())
+
+	s.Error(err)
+	s.Equal(0, dispatched)
+}
+
+func (s *dispatcherTestSuite) TestDispatchDeliverFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	outboxRepository := mock.NewMockOutboxRepository(ctrl)
+	outboxRepository.
+		EXPECT().
+		GetPending(gomock.Any(), gomock.Any(), gomock.Eq(s.vars.OutboxBatchSize)).
+		Return([]repository.Event{s.event}, nil)
+	outboxRepository.
+		EXPECT().
+		MarkFailed(gomock.Any(), gomock.Eq(s.event.ID), gomock.Eq("fail"), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time) error {
+			s.True(nextAttemptAt.After(time.Now()))
+			return nil
+		})
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		OutboxRepository().
+		Return(outboxRepository).
+		Times(2)
+	sink := mock.NewMockEventSink(ctrl)
+	sink.
+		EXPECT().
+		Deliver(gomock.Any(), gomock.Any()).
+		Return(errors.New("fail"))
+
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	d := NewDispatcher(zap.New(zapCore), factory, sink, s.vars).(*dispatcher)
+	dispatched, err := d.dispatch(context.Background())
+
+	s.NoError(err)
+	s.Equal(1, dispatched)
+	s.Equal(1, zapRecorded.FilterMessage("Failed to deliver event, retry later").Len())
+}
+
+func (s *dispatcherTestSuite) TestDispatchSucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	outboxRepository := mock.NewMockOutboxRepository(ctrl)
+	outboxRepository.
+		EXPECT().
+		GetPending(gomock.Any(), gomock.Any(), gomock.Eq(s.vars.OutboxBatchSize)).
+		Return([]repository.Event{s.event}, nil)
+	outboxRepository.
+		EXPECT().
+		MarkDispatched(gomock.Any(), gomock.Eq(s.event.ID), gomock.Any()).
+		Return(nil)
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		OutboxRepository().
+		Return(outboxRepository).
+		Times(2)
+	sink := mock.NewMockEventSink(ctrl)
+	sink.
+		EXPECT().
+		Deliver(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, event service.Event) error {
+			s.Equal(s.event.ID, event.ID)
+			s.Equal(string(s.event.Type), event.Type)
+			s.JSONEq(s.event.Payload, string(event.Data))
+			s.Equal(s.event.CreatedAt, event.CreatedAt)
+			return nil
+		})
+
+	d := NewDispatcher(zap.NewNop(), factory, sink, s.vars).(*dispatcher)
+	dispatched, err := d.dispatch(context.Background())
+
+	s.NoError(err)
+	s.Equal(1, dispatched)
+}
+
+func (s *dispatcherTestSuite) TestStartStopSucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	outboxRepository := mock.NewMockOutboxRepository(ctrl)
+	outboxRepository.
+		EXPECT().
+		GetPending(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, nil).
+		MinTimes(1)
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		OutboxRepository().
+		Return(outboxRepository).
+		AnyTimes()
+	sink := mock.NewMockEventSink(ctrl)
+
+	d := NewDispatcher(zap.NewNop(), factory, sink, s.vars)
+	d.Start()
+	time.Sleep(10 * s.vars.OutboxInterval)
+	d.Stop()
+	// Repeated stop does nothing
+	d.Stop()
+}
@@ -0,0 +1,47 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/Toshik1978/go-rest-api/service"
+	"github.com/Toshik1978/go-rest-api/service/errutil"
+	"github.com/Toshik1978/go-rest-api/service/server"
+)
+
+// fileSink implements EventSink interface, it appends events to the file as JSON lines
+type fileSink struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileSink creates new EventSink, which appends events to the file, declared in configuration.
+// File is created, if it doesn't exist
+func NewFileSink(vars server.Vars) service.EventSink {
+	return &fileSink{
+		path: vars.OutboxTarget,
+	}
+}
+
+func (s *fileSink) Deliver(ctx context.Context, event service.Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return errutil.Wrap(err, "failed to encode event")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return errutil.Wrap(err, "failed to open events file")
+	}
+	defer file.Close()
+
+	// Event is dispatched only after it's flushed to disk
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return errutil.Wrap(err, "failed to write event")
+	}
+	return errutil.Wrap(file.Sync(), "failed to sync events file")
+}
@@ -0,0 +1,58 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/Toshik1978/go-rest-api/service"
+	"github.com/Toshik1978/go-rest-api/service/errutil"
+	"github.com/Toshik1978/go-rest-api/service/server"
+)
+
+// httpSink implements EventSink interface, it posts events to the URL as JSON
+type httpSink struct {
+	client *http.Client
+	url    string
+}
+
+// NewHTTPSink creates new EventSink, which posts events to the URL, declared in configuration.
+// Event is delivered, if response has 2xx status code
+func NewHTTPSink(vars server.Vars) service.EventSink {
+	return &httpSink{
+		client: &http.Client{},
+		url:    vars.OutboxTarget,
+	}
+}
+
+func (s *httpSink) Deliver(ctx context.Context, event service.Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return errutil.Wrap(err, "failed to encode event")
+	}
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return errutil.Wrap(err, "failed to create request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-ID", strconv.FormatInt(event.ID, 10))
+	req.Header.Set("X-Event-Type", event.Type)
+
+	resp, err := s.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return errutil.Wrap(err, "failed to post event")
+	}
+	defer resp.Body.Close()
+	// Body is drained, so connection can be reused
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("event is rejected with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package outbox
+
+import (
+	"context"
+
+	"github.com/Toshik1978/go-rest-api/service"
+	"go.uber.org/zap"
+)
+
+// logSink implements EventSink interface, it writes events to the log
+type logSink struct {
+	logger *zap.Logger
+}
+
+// NewLogSink creates new EventSink, which writes events to the log
+func NewLogSink(logger *zap.Logger) service.EventSink {
+	return &logSink{
+		logger: logger,
+	}
+}
+
+func (s *logSink) Deliver(ctx context.Context, event service.Event) error {
+	s.logger.Info("Event",
+		zap.Int64("event_id", event.ID),
+		zap.String("event_type", event.Type),
+		zap.ByteString("data", event.Data),
+		zap.Time("created_at", event.CreatedAt))
+	return nil
+}
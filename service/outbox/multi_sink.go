@@ -0,0 +1,29 @@
+package outbox
+
+import (
+	"context"
+
+	"github.com/Toshik1978/go-rest-api/service"
+)
+
+// multiSink implements EventSink interface, it delivers events to multiple sinks in order
+type multiSink struct {
+	sinks []service.EventSink
+}
+
+// NewMultiSink creates new EventSink, which delivers events to all the given sinks in order.
+// Event isn't delivered to the next sinks, if any sink fails, so the whole event is delivered again later
+func NewMultiSink(sinks ...service.EventSink) service.EventSink {
+	return &multiSink{
+		sinks: sinks,
+	}
+}
+
+func (s *multiSink) Deliver(ctx context.Context, event service.Event) error {
+	for _, sink := range s.sinks {
+		if err := sink.Deliver(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+package outbox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestOutbox(t *testing.T) {
+	suite.Run(t, new(dispatcherTestSuite))
+	suite.Run(t, new(sinkTestSuite))
+	suite.Run(t, new(webhookDispatcherTestSuite))
+}
@@ -0,0 +1,225 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/mock"
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service"
+	"github.com/Toshik1978/go-rest-api/service/server"
+	"github.com/Toshik1978/go-rest-api/service/testutil"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type sinkTestSuite struct {
+	suite.Suite
+
+	event service.Event
+}
+
+func (s *sinkTestSuite) SetupTest() {
+	s.event = service.Event{
+		ID:        7,
+		Type:      "payment.completed",
+		Data:      json.RawMessage(`{"payer":"toshik1978"}`),
+		CreatedAt: time.Now().Round(time.Millisecond).UTC(),
+	}
+}
+
+func (s *sinkTestSuite) TestLogSinkSucceeded() {
+	zapCore, zapRecorded := observer.New(zapcore.InfoLevel)
+	sink := NewLogSink(zap.New(zapCore))
+
+	s.NoError(sink.Deliver(context.Background(), s.event))
+	s.Equal(1, zapRecorded.FilterField(zap.Int64("event_id", s.event.ID)).Len())
+}
+
+func (s *sinkTestSuite) TestFileSinkFailed() {
+	dir, err := ioutil.TempDir("", "outbox")
+	s.Require().NoError(err)
+	defer os.RemoveAll(dir)
+
+	sink := NewFileSink(server.Vars{OutboxTarget: filepath.Join(dir, "missing", "events.jsonl")})
+
+	s.Error(sink.Deliver(context.Background(), s.event))
+}
+
+func (s *sinkTestSuite) TestFileSinkSucceeded() {
+	dir, err := ioutil.TempDir("", "outbox")
+	s.Require().NoError(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "events.jsonl")
+	sink := NewFileSink(server.Vars{OutboxTarget: path})
+	s.NoError(sink.Deliver(context.Background(), s.event))
+	s.NoError(sink.Deliver(context.Background(), s.event))
+
+	content, err := ioutil.ReadFile(path)
+	s.Require().NoError(err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	s.Len(lines, 2)
+
+	var event service.Event
+	s.NoError(json.Unmarshal([]byte(lines[1]), &event))
+	s.Equal(s.event, event)
+}
+
+func (s *sinkTestSuite) TestHTTPSinkRejectedFailed() {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	sink := NewHTTPSink(server.Vars{OutboxTarget: ts.URL})
+
+	s.Error(sink.Deliver(context.Background(), s.event))
+}
+
+func (s *sinkTestSuite) TestHTTPSinkTimeoutFailed() {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer ts.Close()
+	defer close(release)
+
+	sink := NewHTTPSink(server.Vars{OutboxTarget: ts.URL})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	s.Error(sink.Deliver(ctx, s.event))
+}
+
+func (s *sinkTestSuite) TestHTTPSinkSucceeded() {
+	var event service.Event
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.Equal(http.MethodPost, r.Method)
+		s.Equal("application/json", r.Header.Get("Content-Type"))
+		s.Equal("7", r.Header.Get("X-Event-ID"))
+		s.Equal(s.event.Type, r.Header.Get("X-Event-Type"))
+		s.NoError(json.NewDecoder(r.Body).Decode(&event))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ts.Close()
+
+	sink := NewHTTPSink(server.Vars{OutboxTarget: ts.URL})
+
+	s.NoError(sink.Deliver(context.Background(), s.event))
+	s.Equal(s.event, event)
+}
+
+func (s *sinkTestSuite) TestMultiSinkFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	first := mock.NewMockEventSink(ctrl)
+	first.
+		EXPECT().
+		Deliver(gomock.Any(), gomock.Eq(s.event)).
+		Return(errors.New("fail"))
+	// Event isn't delivered to the next sink, if the previous one fails
+	second := mock.NewMockEventSink(ctrl)
+
+	sink := NewMultiSink(first, second)
+
+	s.Error(sink.Deliver(context.Background(), s.event))
+}
+
+func (s *sinkTestSuite) TestMultiSinkSucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	first := mock.NewMockEventSink(ctrl)
+	first.
+		EXPECT().
+		Deliver(gomock.Any(), gomock.Eq(s.event)).
+		Return(nil)
+	second := mock.NewMockEventSink(ctrl)
+	second.
+		EXPECT().
+		Deliver(gomock.Any(), gomock.Eq(s.event)).
+		Return(nil)
+
+	sink := NewMultiSink(first, second)
+
+	s.NoError(sink.Deliver(context.Background(), s.event))
+}
+
+func (s *sinkTestSuite) TestWebhookSinkFailed() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	webhookRepository := mock.NewMockWebhookRepository(ctrl)
+	webhookRepository.
+		EXPECT().
+		GetMatching(gomock.Any(), gomock.Eq(repository.PaymentCompletedEvent), gomock.Eq([]string{"toshik1978"})).
+		Return(nil, errors.New("fail"))
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		WebhookRepository().
+		Return(webhookRepository)
+
+	sink := NewWebhookSink(factory)
+
+	s.Error(sink.Deliver(context.Background(), s.event))
+}
+
+func (s *sinkTestSuite) TestWebhookSinkSucceeded() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	first, second := testutil.RepositoryWebhook(), testutil.RepositoryWebhook()
+	first.ID = 3
+	second.ID = 4
+	webhookRepository := mock.NewMockWebhookRepository(ctrl)
+	webhookRepository.
+		EXPECT().
+		GetMatching(gomock.Any(), gomock.Eq(repository.PaymentCompletedEvent), gomock.Eq([]string{"toshik1978"})).
+		Return([]repository.Webhook{first, second}, nil)
+	deliveryRepository := mock.NewMockDeliveryRepository(ctrl)
+	deliveryRepository.
+		EXPECT().
+		Store(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, delivery *repository.Delivery) error {
+			s.Equal(first.ID, delivery.WebhookID)
+			s.Equal(s.event.ID, delivery.EventID)
+			s.Equal(repository.PaymentCompletedEvent, delivery.EventType)
+			s.JSONEq(string(s.event.Data), delivery.Payload)
+			s.Equal(s.event.CreatedAt, delivery.EventTime)
+			s.Equal(repository.PendingDelivery, delivery.Status)
+			return nil
+		})
+	// Repeated event is already scheduled
+	deliveryRepository.
+		EXPECT().
+		Store(gomock.Any(), gomock.Any()).
+		Return(repository.ErrDeliveryExists)
+	factory := mock.NewMockFactory(ctrl)
+	factory.
+		EXPECT().
+		WebhookRepository().
+		Return(webhookRepository)
+	factory.
+		EXPECT().
+		DeliveryRepository().
+		Return(deliveryRepository).
+		Times(2)
+
+	sink := NewWebhookSink(factory)
+
+	s.NoError(sink.Deliver(context.Background(), s.event))
+}
@@ -0,0 +1,238 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service"
+	"github.com/Toshik1978/go-rest-api/service/errutil"
+	"github.com/Toshik1978/go-rest-api/service/netutil"
+	"github.com/Toshik1978/go-rest-api/service/poller"
+	"github.com/Toshik1978/go-rest-api/service/server"
+	"go.uber.org/zap"
+)
+
+// Headers of the webhook's request
+const (
+	WebhookIDHeader        = "X-Webhook-ID"
+	DeliveryIDHeader       = "X-Delivery-ID"
+	EventIDHeader          = "X-Event-ID"
+	EventTypeHeader        = "X-Event-Type"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+// webhookDispatcher implements EventDispatcher interface. It polls pending deliveries and posts events
+// to webhooks one by one in order of IDs. Every attempt is recorded, failed delivery is retried
+// with exponential backoff, till the number of attempts is exhausted
+type webhookDispatcher struct {
+	*poller.Poller
+
+	logger            *zap.Logger
+	repositoryFactory repository.Factory
+	client            *http.Client
+	batchSize         int
+	timeout           time.Duration
+	maxAttempts       int
+}
+
+// NewWebhookDispatcher creates new EventDispatcher, which delivers events to webhooks
+func NewWebhookDispatcher(logger *zap.Logger, repositoryFactory repository.Factory,
+	vars server.Vars) service.EventDispatcher {
+
+	d := &webhookDispatcher{
+		logger:            logger,
+		repositoryFactory: repositoryFactory,
+		client:            newWebhookClient(netutil.PublicOnly),
+		batchSize:         vars.OutboxBatchSize,
+		timeout:           vars.OutboxTimeout,
+		maxAttempts:       vars.WebhookMaxAttempts,
+	}
+	d.Poller = poller.New(logger, "Webhook dispatcher", vars.OutboxInterval, vars.OutboxBatchSize, d.dispatch)
+	return d
+}
+
+// newWebhookClient creates HTTP client, which connects to addresses, allowed by control function, only.
+// Addresses are checked after name is resolved, so DNS rebinding can't bypass the check. Redirects aren't followed,
+// because target could be checked only on registration, and redirect's response is failed delivery.
+// Proxy isn't used, otherwise its address would be checked instead of the webhook's one
+func newWebhookClient(control func(network, address string, c syscall.RawConn) error) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   control,
+	}
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext:           dialer.DialContext,
+			MaxIdleConns:          100,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// dispatch delivers single batch of pending deliveries and return number of deliveries in the batch
+func (d *webhookDispatcher) dispatch(ctx context.Context) (int, error) {
+	deliveries, err := d.repositoryFactory.DeliveryRepository().GetPending(ctx, time.Now(), d.batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, delivery := range deliveries {
+		webhook, err := d.repositoryFactory.WebhookRepository().GetByID(ctx, delivery.WebhookID)
+		// Deliveries of the deleted webhook are deleted with it
+		if errors.Is(err, repository.ErrWebhookNotFound) {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		startedAt := time.Now()
+		statusCode, err := d.deliver(ctx, *webhook, delivery)
+		// Attempt isn't recorded, if dispatcher is stopped, so it's delivered again after start
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		attempt := repository.DeliveryAttempt{
+			DeliveryID: delivery.ID,
+			StatusCode: statusCode,
+			DurationMS: time.Since(startedAt).Milliseconds(),
+			CreatedAt:  time.Now(),
+		}
+		if err != nil {
+			attempt.Error = err.Error()
+		}
+		if err := d.record(ctx, delivery, attempt); err != nil {
+			return 0, err
+		}
+	}
+	return len(deliveries), nil
+}
+
+// record saves attempt and updates delivery according to its result in the new scope
+func (d *webhookDispatcher) record(ctx context.Context, delivery repository.Delivery,
+	attempt repository.DeliveryAttempt) error {
+
+	delivery.Attempts++
+	delivery.UpdatedAt = attempt.CreatedAt
+	switch {
+	case attempt.Error == "":
+		delivery.Status = repository.SucceededDelivery
+	case delivery.Attempts >= d.maxAttempts:
+		delivery.Status = repository.FailedDelivery
+		d.logger.Warn("Failed to deliver event to webhook, attempts are exhausted",
+			zap.Int64("delivery_id", delivery.ID),
+			zap.Int64("webhook_id", delivery.WebhookID),
+			zap.Int("attempts", delivery.Attempts),
+			zap.String("error", attempt.Error))
+	default:
+		delivery.NextAttemptAt = attempt.CreatedAt.Add(poller.RetryDelay(delivery.Attempts))
+		d.logger.Warn("Failed to deliver event to webhook, retry later",
+			zap.Int64("delivery_id", delivery.ID),
+			zap.Int64("webhook_id", delivery.WebhookID),
+			zap.Int("attempts", delivery.Attempts),
+			zap.Time("next_attempt_at", delivery.NextAttemptAt),
+			zap.String("error", attempt.Error))
+	}
+
+	scope := d.repositoryFactory.Scope()
+	ctx, err := scope.WithContext(ctx)
+	if err != nil {
+		return errutil.Wrap(err, "failed to start repository scope")
+	}
+	// Here we can defer Cancel operation, because it's safe
+	defer func() { _ = scope.Cancel(ctx) }()
+
+	// Delivery can be deleted concurrently with its webhook, then there is nothing to record
+	err = d.repositoryFactory.DeliveryRepository().StoreAttempt(ctx, &attempt)
+	if errors.Is(err, repository.ErrDeliveryNotFound) {
+		return nil
+	}
+	if err != nil {
+		return errutil.Wrap(err, "failed to store delivery attempt")
+	}
+	err = d.repositoryFactory.DeliveryRepository().Update(ctx, &delivery)
+	if errors.Is(err, repository.ErrDeliveryNotFound) {
+		return nil
+	}
+	if err != nil {
+		return errutil.Wrap(err, "failed to update delivery")
+	}
+	if err := scope.Complete(ctx); err != nil {
+		return errutil.Wrap(err, "failed to complete repository scope")
+	}
+	return nil
+}
+
+// deliver posts signed event to the webhook and return status code of the response, delivery is limited by timeout.
+// Status code is zero, if there is no response
+func (d *webhookDispatcher) deliver(ctx context.Context,
+	webhook repository.Webhook, delivery repository.Delivery) (int, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+
+	body, err := json.Marshal(service.Event{
+		ID:        delivery.EventID,
+		Type:      string(delivery.EventType),
+		Data:      json.RawMessage(delivery.Payload),
+		CreatedAt: delivery.EventTime,
+	})
+	if err != nil {
+		return 0, errutil.Wrap(err, "failed to encode event")
+	}
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, errutil.Wrap(err, "failed to create request")
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookIDHeader, strconv.FormatInt(webhook.ID, 10))
+	req.Header.Set(DeliveryIDHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(EventIDHeader, strconv.FormatInt(delivery.EventID, 10))
+	req.Header.Set(EventTypeHeader, string(delivery.EventType))
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(webhook.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return 0, errutil.Wrap(err, "failed to post event")
+	}
+	defer resp.Body.Close()
+	// Body is drained, so connection can be reused
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("event is rejected with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// SignWebhook return signature of the webhook's request body. Signature is HMAC-SHA256 of the timestamp
+// and the body, joined by dot, keyed by webhook's secret. Timestamp is included, so receiver can reject replays
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	_, _ = mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,230 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/repository/memoryengine"
+	"github.com/Toshik1978/go-rest-api/service"
+	"github.com/Toshik1978/go-rest-api/service/netutil"
+	"github.com/Toshik1978/go-rest-api/service/server"
+	"github.com/Toshik1978/go-rest-api/service/testutil"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type webhookDispatcherTestSuite struct {
+	suite.Suite
+
+	vars     server.Vars
+	factory  repository.Factory
+	webhook  repository.Webhook
+	delivery repository.Delivery
+}
+
+func (s *webhookDispatcherTestSuite) SetupTest() {
+	s.vars = server.Vars{
+		OutboxInterval:     time.Millisecond,
+		OutboxBatchSize:    10,
+		OutboxTimeout:      time.Second,
+		WebhookMaxAttempts: 2,
+	}
+	s.factory = memoryengine.NewRepositoryFactory()
+	s.webhook = testutil.RepositoryWebhook()
+	s.delivery = testutil.RepositoryDelivery()
+	// Time is rounded in fixture, so delivery is moved to the past to be due already
+	s.delivery.NextAttemptAt = s.delivery.NextAttemptAt.Add(-time.Second)
+}
+
+// storeDelivery stores webhook with the given URL and pending delivery to it
+func (s *webhookDispatcherTestSuite) storeDelivery(url string) {
+	s.webhook.URL = url
+	s.Require().NoError(s.factory.WebhookRepository().Store(context.Background(), &s.webhook))
+	s.delivery.WebhookID = s.webhook.ID
+	s.Require().NoError(s.factory.DeliveryRepository().Store(context.Background(), &s.delivery))
+}
+
+// newDispatcher creates dispatcher, which can connect to test servers on loopback
+func (s *webhookDispatcherTestSuite) newDispatcher() *webhookDispatcher {
+	d := NewWebhookDispatcher(zap.NewNop(), s.factory, s.vars).(*webhookDispatcher)
+	d.client = newWebhookClient(nil)
+	return d
+}
+
+func (s *webhookDispatcherTestSuite) TestDispatchRejectedFailed() {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+	s.storeDelivery(ts.URL)
+
+	d := s.newDispatcher()
+	dispatched, err := d.dispatch(context.Background())
+	s.NoError(err)
+	s.Equal(1, dispatched)
+
+	// Failed delivery is postponed
+	delivery, err := s.factory.DeliveryRepository().GetByID(context.Background(), s.delivery.ID)
+	s.NoError(err)
+	s.Equal(repository.PendingDelivery, delivery.Status)
+	s.Equal(1, delivery.Attempts)
+	s.True(delivery.NextAttemptAt.After(time.Now()))
+	attempts, err := s.factory.DeliveryRepository().GetAttempts(context.Background(), s.delivery.ID)
+	s.NoError(err)
+	s.Require().Len(attempts, 1)
+	s.Equal(http.StatusInternalServerError, attempts[0].StatusCode)
+	s.NotEmpty(attempts[0].Error)
+
+	// Delivery is failed, when attempts are exhausted
+	delivery.NextAttemptAt = time.Now()
+	s.Require().NoError(s.factory.DeliveryRepository().Update(context.Background(), delivery))
+	dispatched, err = d.dispatch(context.Background())
+	s.NoError(err)
+	s.Equal(1, dispatched)
+
+	delivery, err = s.factory.DeliveryRepository().GetByID(context.Background(), s.delivery.ID)
+	s.NoError(err)
+	s.Equal(repository.FailedDelivery, delivery.Status)
+	s.Equal(2, delivery.Attempts)
+}
+
+func (s *webhookDispatcherTestSuite) TestDispatchUnreachableFailed() {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	ts.Close()
+	s.storeDelivery(ts.URL)
+
+	d := s.newDispatcher()
+	dispatched, err := d.dispatch(context.Background())
+	s.NoError(err)
+	s.Equal(1, dispatched)
+
+	attempts, err := s.factory.DeliveryRepository().GetAttempts(context.Background(), s.delivery.ID)
+	s.NoError(err)
+	s.Require().Len(attempts, 1)
+	s.Equal(0, attempts[0].StatusCode)
+	s.NotEmpty(attempts[0].Error)
+}
+
+func (s *webhookDispatcherTestSuite) TestDispatchPrivateAddressFailed() {
+	delivered := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delivered = true
+	}))
+	defer ts.Close()
+	s.storeDelivery(ts.URL)
+
+	// Dispatcher doesn't connect to loopback, even if webhook's URL passed validation
+	d := NewWebhookDispatcher(zap.NewNop(), s.factory, s.vars).(*webhookDispatcher)
+	dispatched, err := d.dispatch(context.Background())
+	s.NoError(err)
+	s.Equal(1, dispatched)
+	s.False(delivered)
+
+	attempts, err := s.factory.DeliveryRepository().GetAttempts(context.Background(), s.delivery.ID)
+	s.NoError(err)
+	s.Require().Len(attempts, 1)
+	s.Equal(0, attempts[0].StatusCode)
+	s.Contains(attempts[0].Error, netutil.ErrNotPublicAddress.Error())
+}
+
+func (s *webhookDispatcherTestSuite) TestDispatchRedirectFailed() {
+	redirected := false
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		redirected = true
+	}))
+	defer target.Close()
+	ts := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+	defer ts.Close()
+	s.storeDelivery(ts.URL)
+
+	d := s.newDispatcher()
+	dispatched, err := d.dispatch(context.Background())
+	s.NoError(err)
+	s.Equal(1, dispatched)
+	s.False(redirected)
+
+	attempts, err := s.factory.DeliveryRepository().GetAttempts(context.Background(), s.delivery.ID)
+	s.NoError(err)
+	s.Require().Len(attempts, 1)
+	s.Equal(http.StatusTemporaryRedirect, attempts[0].StatusCode)
+	s.NotEmpty(attempts[0].Error)
+}
+
+func (s *webhookDispatcherTestSuite) TestDispatchSucceeded() {
+	var request *http.Request
+	var body []byte
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request = r
+		body, _ = ioutil.ReadAll(r.Body)
+	}))
+	defer ts.Close()
+	s.storeDelivery(ts.URL)
+
+	d := s.newDispatcher()
+	dispatched, err := d.dispatch(context.Background())
+	s.NoError(err)
+	s.Equal(1, dispatched)
+
+	// Receiver verifies signature with the webhook's secret
+	s.Require().NotNil(request)
+	timestamp, err := strconv.ParseInt(request.Header.Get(WebhookTimestampHeader), 10, 64)
+	s.NoError(err)
+	s.Equal(SignWebhook(s.webhook.Secret, timestamp, body), request.Header.Get(WebhookSignatureHeader))
+	s.Equal(strconv.FormatInt(s.webhook.ID, 10), request.Header.Get(WebhookIDHeader))
+	s.Equal(strconv.FormatInt(s.delivery.ID, 10), request.Header.Get(DeliveryIDHeader))
+	s.Equal(strconv.FormatInt(s.delivery.EventID, 10), request.Header.Get(EventIDHeader))
+	s.Equal(string(s.delivery.EventType), request.Header.Get(EventTypeHeader))
+
+	var event service.Event
+	s.NoError(json.Unmarshal(body, &event))
+	s.Equal(s.delivery.EventID, event.ID)
+	s.Equal(string(s.delivery.EventType), event.Type)
+	s.JSONEq(s.delivery.Payload, string(event.Data))
+
+	delivery, err := s.factory.DeliveryRepository().GetByID(context.Background(), s.delivery.ID)
+	s.NoError(err)
+	s.Equal(repository.SucceededDelivery, delivery.Status)
+	s.Equal(1, delivery.Attempts)
+	attempts, err := s.factory.DeliveryRepository().GetAttempts(context.Background(), s.delivery.ID)
+	s.NoError(err)
+	s.Require().Len(attempts, 1)
+	s.Equal(http.StatusOK, attempts[0].StatusCode)
+	s.Empty(attempts[0].Error)
+
+	// Succeeded delivery isn't delivered again
+	dispatched, err = d.dispatch(context.Background())
+	s.NoError(err)
+	s.Equal(0, dispatched)
+}
+
+func (s *webhookDispatcherTestSuite) TestStartStopSucceeded() {
+	delivered := make(chan struct{}, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delivered <- struct{}{}
+	}))
+	defer ts.Close()
+	s.storeDelivery(ts.URL)
+
+	d := s.newDispatcher()
+	d.Start()
+	select {
+	case <-delivered:
+	case <-time.After(time.Second):
+		s.Fail("event is not delivered")
+	}
+	d.Stop()
+	// Repeated stop does nothing
+	d.Stop()
+}
+
+func (s *webhookDispatcherTestSuite) TestSignWebhookSucceeded() {
+	signature := SignWebhook("secret", 1600000000, []byte(`{}`))
+
+	s.Equal("sha256=1e56a11da123b137c26fa37b7c222060bdf22988aa9b3248c31244f8b2ef4a28", signature)
+}
@@ -0,0 +1,75 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service"
+	"github.com/Toshik1978/go-rest-api/service/errutil"
+)
+
+// eventAccounts define fields of events' data, which refer to accounts
+type eventAccounts struct {
+	UID          string `json:"uid"`
+	PayerUID     string `json:"payer"`
+	RecipientUID string `json:"recipient"`
+}
+
+// webhookSink implements EventSink interface, it schedules delivery of events to matching webhooks
+type webhookSink struct {
+	repositoryFactory repository.Factory
+}
+
+// NewWebhookSink creates new EventSink, which schedules delivery of events to webhooks, subscribed to them.
+// Events are delivered to webhooks by webhook dispatcher. Event is scheduled once for each webhook,
+// so it's safe to deliver the same event again
+func NewWebhookSink(repositoryFactory repository.Factory) service.EventSink {
+	return &webhookSink{
+		repositoryFactory: repositoryFactory,
+	}
+}
+
+func (s *webhookSink) Deliver(ctx context.Context, event service.Event) error {
+	var accounts eventAccounts
+	if err := json.Unmarshal(event.Data, &accounts); err != nil {
+		return errutil.Wrap(err, "failed to decode event")
+	}
+	accountUIDs := make([]string, 0, 2)
+	for _, uid := range []string{accounts.UID, accounts.PayerUID, accounts.RecipientUID} {
+		if uid != "" {
+			accountUIDs = append(accountUIDs, uid)
+		}
+	}
+
+	webhooks, err := s.repositoryFactory.WebhookRepository().
+		GetMatching(ctx, repository.EventType(event.Type), accountUIDs)
+	if err != nil {
+		return errutil.Wrap(err, "failed to get webhooks")
+	}
+	now := time.Now()
+	for _, webhook := range webhooks {
+		delivery := repository.Delivery{
+			WebhookID:     webhook.ID,
+			EventID:       event.ID,
+			EventType:     repository.EventType(event.Type),
+			Payload:       string(event.Data),
+			EventTime:     event.CreatedAt,
+			Status:        repository.PendingDelivery,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+		// Event is already scheduled, or webhook is deleted concurrently
+		err := s.repositoryFactory.DeliveryRepository().Store(ctx, &delivery)
+		if errors.Is(err, repository.ErrDeliveryExists) || errors.Is(err, repository.ErrWebhookNotFound) {
+			continue
+		}
+		if err != nil {
+			return errutil.Wrap(err, "failed to store delivery")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package poller
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	baseRetryDelay = time.Second
+	maxRetryDelay  = 10 * time.Minute
+)
+
+// Poller polls storage in background every interval and processes single batch by each poll.
+// The next batch is processed immediately, if the batch is full
+type Poller struct {
+	logger    *zap.Logger
+	name      string
+	interval  time.Duration
+	batchSize int
+	poll      func(ctx context.Context) (int, error)
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+}
+
+// New creates new poller. Poll processes single batch and return number of processed records,
+// name is used in poller's log messages
+func New(logger *zap.Logger, name string,
+	interval time.Duration, batchSize int, poll func(ctx context.Context) (int, error)) *Poller {
+
+	return &Poller{
+		logger:    logger,
+		name:      name,
+		interval:  interval,
+		batchSize: batchSize,
+		poll:      poll,
+	}
+}
+
+// Start starts polling in background
+func (p *Poller) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	p.done = make(chan struct{})
+
+	go p.run(ctx)
+	p.logger.Info(p.name + " started")
+}
+
+// Stop stops polling and waits, till batch in progress is processed
+func (p *Poller) Stop() {
+	p.once.Do(func() {
+		if p.cancel == nil {
+			return
+		}
+		p.cancel()
+		<-p.done
+		p.logger.Info(p.name + " stopped")
+	})
+}
+
+// run polls, till context is cancelled
+func (p *Poller) run(ctx context.Context) {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		processed, err := p.poll(ctx)
+		if err != nil && ctx.Err() == nil {
+			p.logger.Error(p.name+" failed", zap.Error(err))
+		}
+		if err == nil && processed == p.batchSize {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RetryDelay return delay before the next attempt, delay is doubled after each failed attempt
+// from 1 second up to 10 minutes
+func RetryDelay(attempts int) time.Duration {
+	if attempts > 30 {
+		return maxRetryDelay
+	}
+	delay := baseRetryDelay << uint(attempts-1)
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
@@ -0,0 +1,61 @@
+package poller
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type pollerTestSuite struct {
+	suite.Suite
+}
+
+func (s *pollerTestSuite) TestStopWithoutStartSucceeded() {
+	p := New(zap.NewNop(), "Test poller", time.Millisecond, 1, func(ctx context.Context) (int, error) {
+		return 0, nil
+	})
+	p.Stop()
+}
+
+func (s *pollerTestSuite) TestPollFailedSucceeded() {
+	var polls int32
+	p := New(zap.NewNop(), "Test poller", time.Millisecond, 1, func(ctx context.Context) (int, error) {
+		atomic.AddInt32(&polls, 1)
+		return 0, errors.New("fail")
+	})
+	p.Start()
+	time.Sleep(20 * time.Millisecond)
+	p.Stop()
+	// Repeated stop does nothing
+	p.Stop()
+
+	s.True(atomic.LoadInt32(&polls) > 1)
+}
+
+func (s *pollerTestSuite) TestFullBatchSucceeded() {
+	var polls int32
+	p := New(zap.NewNop(), "Test poller", time.Hour, 10, func(ctx context.Context) (int, error) {
+		// The next batch is polled immediately, till the batch isn't full
+		if atomic.AddInt32(&polls, 1) < 3 {
+			return 10, nil
+		}
+		return 0, nil
+	})
+	p.Start()
+	time.Sleep(20 * time.Millisecond)
+	p.Stop()
+
+	s.Equal(int32(3), atomic.LoadInt32(&polls))
+}
+
+func (s *pollerTestSuite) TestRetryDelaySucceeded() {
+	s.Equal(time.Second, RetryDelay(1))
+	s.Equal(2*time.Second, RetryDelay(2))
+	s.Equal(8*time.Second, RetryDelay(4))
+	s.Equal(maxRetryDelay, RetryDelay(11))
+	s.Equal(maxRetryDelay, RetryDelay(100))
+}
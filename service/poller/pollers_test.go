@@ -0,0 +1,11 @@
+package poller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestPollers(t *testing.T) {
+	suite.Run(t, new(pollerTestSuite))
+}
@@ -0,0 +1,22 @@
+package postgres
+
+import (
+	"github.com/Toshik1978/go-rest-api/service"
+	"github.com/Toshik1978/go-rest-api/service/migrator"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+//go:generate go run ../migrator/migrations_gen.go -source ../../configs/migrations -package postgres
+
+// dialect define PostgreSQL specific SQL of the migrator. Advisory lock serializes migrations
+// of concurrent instances
+var dialect = migrator.Dialect{
+	LockSQL:               `SELECT pg_advisory_xact_lock(7262971231)`,
+	VersionTableExistsSQL: `SELECT to_regclass('schema_migrations') IS NOT NULL`,
+}
+
+// NewMigrator creates new migrator of PostgreSQL schema
+func NewMigrator(logger *zap.Logger, db *sqlx.DB) service.Migrator {
+	return migrator.NewMigrator(logger, db, dialect, migrations)
+}
@@ -0,0 +1,30 @@
+package postgres
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type migratorTestSuite struct {
+	suite.Suite
+}
+
+func (s *migratorTestSuite) TestMigrationsUpToDate() {
+	files, err := filepath.Glob("../../configs/migrations/*.sql")
+	s.Require().NoError(err)
+	s.Equal(len(files), 2*len(migrations), "run go generate to embed new migrations")
+
+	for _, migration := range migrations {
+		for suffix, sql := range map[string]string{"up": migration.Up, "down": migration.Down} {
+			pattern := filepath.Join("../../configs/migrations", "*_"+migration.Name+"."+suffix+".sql")
+			matches, err := filepath.Glob(pattern)
+			s.Require().NoError(err)
+			s.Require().Len(matches, 1, pattern)
+			content, err := ioutil.ReadFile(matches[0])
+			s.Require().NoError(err)
+			s.Equal(string(content), sql, "run go generate to embed changed migration "+matches[0])
+		}
+	}
+}
@@ -0,0 +1,11 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestPostgres(t *testing.T) {
+	suite.Run(t, new(migratorTestSuite))
+}
@@ -1,7 +1,10 @@
 package server
 
 import (
+	"time"
+
 	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service"
 	"go.uber.org/zap"
 )
 
@@ -9,6 +12,13 @@ import (
 type Globals struct {
 	Logger            *zap.Logger
 	RepositoryFactory repository.Factory
+	CurrencyRegistry  service.CurrencyRegistry
+	FXRateProvider    service.FXRateProvider
+	EventBroker       service.EventBroker
+	IdempotencyTTL    time.Duration
+	RequestTimeout    time.Duration
+	RouteTimeouts     map[string]time.Duration
+	EventsKeepAlive   time.Duration
 
 	BuildTime string
 	Version   string
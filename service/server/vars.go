@@ -3,21 +3,111 @@ package server
 import (
 	"time"
 
+	"github.com/Toshik1978/go-rest-api/service"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
 )
 
 const (
 	configFileName = "go-rest-api.conf"
+
+	// defaultIdempotencyTTL used if configuration doesn't declare expiration of idempotency keys
+	defaultIdempotencyTTL = 24 * time.Hour
+	// defaultHTTPTimeout used if configuration doesn't declare timeout of API requests
+	defaultHTTPTimeout = 30 * time.Second
+	// defaultOutboxInterval used if configuration doesn't declare interval of outbox polling
+	defaultOutboxInterval = time.Second
+	// defaultOutboxBatchSize used if configuration doesn't declare number of events dispatched at once
+	defaultOutboxBatchSize = 100
+	// defaultOutboxTimeout used if configuration doesn't declare timeout of event delivery
+	defaultOutboxTimeout = 10 * time.Second
+	// defaultWebhookMaxAttempts used if configuration doesn't declare number of attempts to deliver event to webhook
+	defaultWebhookMaxAttempts = 10
+	// defaultEventsBufferSize used if configuration doesn't declare number of events buffered for stream's subscriber
+	defaultEventsBufferSize = 100
+	// defaultEventsKeepAlive used if configuration doesn't declare interval of keep-alive messages in event stream
+	defaultEventsKeepAlive = 15 * time.Second
+	// defaultScheduleInterval used if configuration doesn't declare interval of scheduled payments' polling
+	defaultScheduleInterval = 10 * time.Second
+	// defaultScheduleBatchSize used if configuration doesn't declare number of scheduled payments run at once
+	defaultScheduleBatchSize = 100
+	// defaultScheduleRetryInterval used if configuration doesn't declare delay before the next attempt
+	// of scheduled payment, which payer has insufficient funds
+	defaultScheduleRetryInterval = time.Hour
+	// defaultScheduleMaxAttempts used if configuration doesn't declare number of attempts of scheduled payment
+	defaultScheduleMaxAttempts = 3
+)
+
+// Storage drivers
+const (
+	// PostgresDriver keeps data in PostgreSQL, it's used if configuration doesn't declare driver
+	PostgresDriver = "postgres"
+	// MemoryDriver keeps data in memory of the process, data is lost on stop
+	MemoryDriver = "memory"
+	// SQLiteDriver keeps data in SQLite database file
+	SQLiteDriver = "sqlite"
+)
+
+// Event sinks
+const (
+	// LogSink writes events to the service's log, it's used if configuration doesn't declare sink
+	LogSink = "log"
+	// FileSink appends events to the file as JSON lines
+	FileSink = "file"
+	// HTTPSink posts events to the URL as JSON
+	HTTPSink = "http"
 )
 
+// defaultCurrencies used if configuration doesn't declare any currency
+var defaultCurrencies = []service.Currency{
+	{Code: "USD", Exponent: 2, Enabled: true},
+}
+
 // Vars declare variables for service running
 type Vars struct {
 	HTTPAddress string
 	HTTPPort    string
+	// HTTPTimeout limits handling of API request, HTTPRouteTimeouts overrides it for API routes by route's name.
+	// Zero timeout means the request is not limited
+	HTTPTimeout       time.Duration
+	HTTPRouteTimeouts map[string]time.Duration
 
+	// DBDriver selects storage. DB is connection string of PostgreSQL or path of SQLite database file,
+	// it's ignored by in-memory storage
+	DBDriver  string
 	DB        string
 	DBTimeout time.Duration
+	// DBAutoMigrate enables migration of database schema on start
+	DBAutoMigrate bool
+
+	Currencies  []service.Currency
+	FXRatesFile string
+
+	IdempotencyTTL time.Duration
+
+	// OutboxSink selects sink of domain events, OutboxTarget is path of the file or URL, depending on sink.
+	// Outbox is polled every OutboxInterval, OutboxTimeout limits delivery of the single event
+	OutboxSink      string
+	OutboxTarget    string
+	OutboxInterval  time.Duration
+	OutboxBatchSize int
+	OutboxTimeout   time.Duration
+
+	// WebhookMaxAttempts limits attempts to deliver event to webhook, delivery is failed after the last one
+	WebhookMaxAttempts int
+
+	// EventsBufferSize limits events buffered for the slow subscriber of account's event stream, stream is closed
+	// on overflow. Keep-alive message is sent to idle stream every EventsKeepAlive
+	EventsBufferSize int
+	EventsKeepAlive  time.Duration
+
+	// Scheduled payments are polled every ScheduleInterval. Payment, which payer has insufficient funds,
+	// is retried every ScheduleRetryInterval, if schedule's policy is retry. ScheduleMaxAttempts limits attempts
+	// of the single run, it's skipped or failed after the last one
+	ScheduleInterval      time.Duration
+	ScheduleBatchSize     int
+	ScheduleRetryInterval time.Duration
+	ScheduleMaxAttempts   int
 }
 
 // LoadConfig load config
@@ -29,10 +119,123 @@ func LoadConfig(logger *zap.Logger) Vars {
 		logger.Fatal("Failed to read config", zap.Error(err))
 	}
 
+	var currencies []service.Currency
+	if err := viper.UnmarshalKey("currencies", &currencies); err != nil {
+		logger.Fatal("Failed to read currencies", zap.Error(err))
+	}
+	if len(currencies) == 0 {
+		currencies = defaultCurrencies
+	}
+
+	idempotencyTTL := viper.GetDuration("idempotency.ttl")
+	if idempotencyTTL <= 0 {
+		idempotencyTTL = defaultIdempotencyTTL
+	}
+
+	dbDriver := viper.GetString("db.driver")
+	switch dbDriver {
+	case "":
+		dbDriver = PostgresDriver
+	case PostgresDriver, MemoryDriver, SQLiteDriver:
+	default:
+		logger.Fatal("Unknown DB driver", zap.String("driver", dbDriver))
+	}
+
+	outboxSink := viper.GetString("outbox.sink")
+	switch outboxSink {
+	case "":
+		outboxSink = LogSink
+	case LogSink:
+	case FileSink, HTTPSink:
+		if viper.GetString("outbox.target") == "" {
+			logger.Fatal("Outbox target is required by sink", zap.String("sink", outboxSink))
+		}
+	default:
+		logger.Fatal("Unknown outbox sink", zap.String("sink", outboxSink))
+	}
+	outboxInterval := viper.GetDuration("outbox.interval")
+	if outboxInterval <= 0 {
+		outboxInterval = defaultOutboxInterval
+	}
+	outboxBatchSize := viper.GetInt("outbox.batch_size")
+	if outboxBatchSize <= 0 {
+		outboxBatchSize = defaultOutboxBatchSize
+	}
+	outboxTimeout := viper.GetDuration("outbox.timeout")
+	if outboxTimeout <= 0 {
+		outboxTimeout = defaultOutboxTimeout
+	}
+
+	webhookMaxAttempts := viper.GetInt("webhook.max_attempts")
+	if webhookMaxAttempts <= 0 {
+		webhookMaxAttempts = defaultWebhookMaxAttempts
+	}
+
+	eventsBufferSize := viper.GetInt("events.buffer_size")
+	if eventsBufferSize <= 0 {
+		eventsBufferSize = defaultEventsBufferSize
+	}
+	eventsKeepAlive := viper.GetDuration("events.keep_alive")
+	if eventsKeepAlive <= 0 {
+		eventsKeepAlive = defaultEventsKeepAlive
+	}
+
+	scheduleInterval := viper.GetDuration("schedule.interval")
+	if scheduleInterval <= 0 {
+		scheduleInterval = defaultScheduleInterval
+	}
+	scheduleBatchSize := viper.GetInt("schedule.batch_size")
+	if scheduleBatchSize <= 0 {
+		scheduleBatchSize = defaultScheduleBatchSize
+	}
+	scheduleRetryInterval := viper.GetDuration("schedule.retry_interval")
+	if scheduleRetryInterval <= 0 {
+		scheduleRetryInterval = defaultScheduleRetryInterval
+	}
+	scheduleMaxAttempts := viper.GetInt("schedule.max_attempts")
+	if scheduleMaxAttempts <= 0 {
+		scheduleMaxAttempts = defaultScheduleMaxAttempts
+	}
+
+	httpTimeout := defaultHTTPTimeout
+	if viper.IsSet("http.timeout") {
+		httpTimeout = viper.GetDuration("http.timeout")
+	}
+	var httpRouteTimeouts map[string]time.Duration
+	if err := viper.UnmarshalKey("http.route_timeouts", &httpRouteTimeouts); err != nil {
+		logger.Fatal("Failed to read route timeouts", zap.Error(err))
+	}
+
 	return Vars{
 		HTTPAddress: viper.GetString("http.host"),
 		HTTPPort:    viper.GetString("http.port"),
+		DBDriver:    dbDriver,
 		DB:          viper.GetString("db.master"),
 		DBTimeout:   viper.GetDuration("db.timeout"),
+		Currencies:  currencies,
+		FXRatesFile: viper.GetString("fx.rates_file"),
+
+		DBAutoMigrate: viper.GetBool("db.auto_migrate"),
+
+		HTTPTimeout:       httpTimeout,
+		HTTPRouteTimeouts: httpRouteTimeouts,
+
+		IdempotencyTTL: idempotencyTTL,
+
+		OutboxSink:      outboxSink,
+		OutboxTarget:    viper.GetString("outbox.target"),
+		OutboxInterval:  outboxInterval,
+		OutboxBatchSize: outboxBatchSize,
+		OutboxTimeout:   outboxTimeout,
+
+		WebhookMaxAttempts: webhookMaxAttempts,
+
+		EventsBufferSize: eventsBufferSize,
+		EventsKeepAlive:  eventsKeepAlive,
+
+		ScheduleInterval:      scheduleInterval,
+		ScheduleBatchSize:     scheduleBatchSize,
+		ScheduleRetryInterval: scheduleRetryInterval,
+		ScheduleMaxAttempts:   scheduleMaxAttempts,
 	}
 }
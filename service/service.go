@@ -1,15 +1,83 @@
 package service
 
 import (
+	"context"
+	"math/big"
+
 	"github.com/jmoiron/sqlx"
 )
 
 //go:generate mockgen -source service.go -package mock -destination ../mock/service.go
 
-// PostgresClient declare interface for PostgreSQL connections
-type PostgresClient interface {
+// DBClient declare interface for database connections
+type DBClient interface {
 	// GetConnection retrieve database connection to be used in repositories
 	GetConnection() *sqlx.DB
-	// Stop finish background tasks of this client (db reconnection)
+	// Stop finish background tasks of this client (db reconnection) and close connection
+	Stop()
+}
+
+// PostgresClient declare interface for PostgreSQL connections
+type PostgresClient interface {
+	DBClient
+}
+
+// Migrator declare interface to migrate database schema with migrations, embedded into the binary
+type Migrator interface {
+	// Up applies n pending migrations, all pending migrations are applied if n is not positive
+	Up(ctx context.Context, n int) error
+	// Down reverts n applied migrations, all applied migrations are reverted if n is not positive
+	Down(ctx context.Context, n int) error
+	// Version return current version of database schema, zero version means empty schema
+	Version(ctx context.Context) (SchemaVersion, error)
+	// Status return all embedded migrations ordered by version
+	Status(ctx context.Context) ([]Migration, error)
+	// Latest return version of the latest embedded migration, it's schema version the binary expects
+	Latest() uint
+}
+
+// CurrencyRegistry declare interface to access currencies, known by the service
+type CurrencyRegistry interface {
+	// Currency return currency by it's ISO 4217 code
+	Currency(code string) (Currency, bool)
+	// Currencies return all known currencies
+	Currencies() []Currency
+}
+
+// FXRateProvider declare interface to get currency exchange rates
+type FXRateProvider interface {
+	// Rate return exchange rate to convert amount in from currency to amount in to currency.
+	// ErrRateNotFound returned, if provider doesn't know such rate
+	Rate(ctx context.Context, from string, to string) (*big.Rat, error)
+}
+
+// EventSink declare interface to deliver domain events outside of the service
+type EventSink interface {
+	// Deliver delivers event to the sink. Event is delivered again later, if error is returned
+	Deliver(ctx context.Context, event Event) error
+}
+
+// EventDispatcher declare interface to deliver domain events from the outbox in background
+type EventDispatcher interface {
+	// Start starts delivery of events
+	Start()
+	// Stop stops delivery of events and waits, till delivery in progress is finished
 	Stop()
 }
+
+// EventBroker declare interface to publish account's activity to subscribers in process
+type EventBroker interface {
+	// Publish sends event to all subscribers of event's account. Publish never blocks,
+	// subscription, which can't keep up with events, is closed instead
+	Publish(event AccountEvent)
+	// Subscribe subscribes to events of the account with the given UID
+	Subscribe(accountUID string) EventSubscription
+}
+
+// EventSubscription declare interface of subscription to account's activity
+type EventSubscription interface {
+	// Events return channel of events. Channel is closed, when subscription is cancelled or overflowed
+	Events() <-chan AccountEvent
+	// Cancel cancels subscription, it's safe to call it multiple times
+	Cancel()
+}
@@ -0,0 +1,21 @@
+package sqlite
+
+import (
+	"github.com/Toshik1978/go-rest-api/service"
+	"github.com/Toshik1978/go-rest-api/service/migrator"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+//go:generate go run ../migrator/migrations_gen.go -source ../../configs/sqlite-migrations -package sqlite
+
+// dialect define SQLite specific SQL of the migrator. Transactions start with write lock of the database,
+// so migrations of concurrent instances are serialized without explicit lock
+var dialect = migrator.Dialect{
+	VersionTableExistsSQL: `SELECT COUNT(*) > 0 FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'`,
+}
+
+// NewMigrator creates new migrator of SQLite schema
+func NewMigrator(logger *zap.Logger, db *sqlx.DB) service.Migrator {
+	return migrator.NewMigrator(logger, db, dialect, migrations)
+}
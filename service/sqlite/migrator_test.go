@@ -0,0 +1,63 @@
+package sqlite
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/Toshik1978/go-rest-api/service"
+	"github.com/Toshik1978/go-rest-api/service/server"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type migratorTestSuite struct {
+	suite.Suite
+}
+
+func (s *migratorTestSuite) TestMigrationsUpToDate() {
+	files, err := filepath.Glob("../../configs/sqlite-migrations/*.sql")
+	s.Require().NoError(err)
+	s.Equal(len(files), 2*len(migrations), "run go generate to embed new migrations")
+
+	for _, migration := range migrations {
+		for suffix, sql := range map[string]string{"up": migration.Up, "down": migration.Down} {
+			pattern := filepath.Join("../../configs/sqlite-migrations", "*_"+migration.Name+"."+suffix+".sql")
+			matches, err := filepath.Glob(pattern)
+			s.Require().NoError(err)
+			s.Require().Len(matches, 1, pattern)
+			content, err := ioutil.ReadFile(matches[0])
+			s.Require().NoError(err)
+			s.Equal(string(content), sql, "run go generate to embed changed migration "+matches[0])
+		}
+	}
+}
+
+func (s *migratorTestSuite) TestMigratorUpDownSucceeded() {
+	client, err := NewSQLiteClient(zap.NewNop(), server.Vars{DB: ":memory:"})
+	s.Require().NoError(err)
+	defer client.Stop()
+	migrator := NewMigrator(zap.NewNop(), client.GetConnection())
+	ctx := context.Background()
+
+	version, err := migrator.Version(ctx)
+	s.NoError(err)
+	s.Equal(service.SchemaVersion{}, version)
+
+	s.NoError(migrator.Up(ctx, 0))
+	version, err = migrator.Version(ctx)
+	s.NoError(err)
+	s.Equal(service.SchemaVersion{Version: migrator.Latest()}, version)
+
+	s.NoError(migrator.Down(ctx, 0))
+	version, err = migrator.Version(ctx)
+	s.NoError(err)
+	s.Equal(service.SchemaVersion{}, version)
+
+	var tables int
+	s.NoError(client.GetConnection().Get(&tables, `
+		SELECT COUNT(*)
+		FROM sqlite_master
+		WHERE type = 'table' AND name NOT LIKE 'sqlite_%' AND name <> 'schema_migrations'`))
+	s.Equal(0, tables)
+}
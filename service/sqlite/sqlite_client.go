@@ -0,0 +1,78 @@
+package sqlite
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/service"
+	"github.com/Toshik1978/go-rest-api/service/errutil"
+	"github.com/Toshik1978/go-rest-api/service/server"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3" // SQLite driver
+	"go.uber.org/zap"
+)
+
+const (
+	// connectionParams enable foreign keys, start transactions with write lock, so they never fail to upgrade
+	// read lock, enable concurrent readers of the file and parse timestamps in UTC
+	connectionParams = "_foreign_keys=1&_txlock=immediate&_journal_mode=WAL&_loc=UTC"
+	// defaultBusyTimeout used if configuration doesn't declare timeout
+	defaultBusyTimeout = 5 * time.Second
+)
+
+// sqliteClient implements DBClient
+type sqliteClient struct {
+	db     *sqlx.DB
+	logger *zap.Logger
+}
+
+// NewSQLiteClient creates new SQLite client. vars.DB is path of database file, ":memory:" is in-memory database.
+// Lock of the file, held by another process (e.g. admin command), is waited for vars.DBTimeout
+func NewSQLiteClient(logger *zap.Logger, vars server.Vars) (service.DBClient, error) {
+	busyTimeout := vars.DBTimeout
+	if busyTimeout <= 0 {
+		busyTimeout = defaultBusyTimeout
+	}
+	separator := "?"
+	if strings.Contains(vars.DB, "?") {
+		separator = "&"
+	}
+	dsn := vars.DB + separator + connectionParams +
+		"&_busy_timeout=" + strconv.FormatInt(busyTimeout.Milliseconds(), 10)
+
+	db, err := sqlx.Connect("sqlite3", dsn)
+	if err != nil {
+		return nil, errutil.Wrap(err, "db initialization failed")
+	}
+	initializeConnection(db)
+
+	logger.Info("SQLite opened", zap.String("db", vars.DB))
+	return &sqliteClient{
+		db:     db,
+		logger: logger,
+	}, nil
+}
+
+// initializeConnection initializes connection parameters. SQLite has the single writer, so the single connection
+// serializes scopes instead of failing them on locked database. In-memory database lives while its connection
+// is open, so connection is never closed
+func initializeConnection(db *sqlx.DB) {
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+	db.SetConnMaxLifetime(0)
+}
+
+// Stop closes database
+func (c *sqliteClient) Stop() {
+	if c.db != nil {
+		c.db.Close()
+		c.db = nil
+		c.logger.Info("SQLite closed")
+	}
+}
+
+// GetConnection retrieve DB connection
+func (c *sqliteClient) GetConnection() *sqlx.DB {
+	return c.db
+}
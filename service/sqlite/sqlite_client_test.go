@@ -0,0 +1,49 @@
+package sqlite
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/Toshik1978/go-rest-api/service/server"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type sqliteClientTestSuite struct {
+	suite.Suite
+}
+
+func (s *sqliteClientTestSuite) TestNewSQLiteClientFailed() {
+	client, err := NewSQLiteClient(zap.NewNop(), server.Vars{DB: filepath.Join(s.T().Name(), "missing", "test.db")})
+
+	s.Error(err)
+	s.Nil(client)
+}
+
+func (s *sqliteClientTestSuite) TestNewSQLiteClientSucceeded() {
+	client, err := NewSQLiteClient(zap.NewNop(), server.Vars{DB: ":memory:"})
+	s.Require().NoError(err)
+
+	var foreignKeys bool
+	s.NoError(client.GetConnection().Get(&foreignKeys, "PRAGMA foreign_keys"))
+	s.True(foreignKeys)
+
+	client.Stop()
+	client.Stop()
+	s.Nil(client.GetConnection())
+}
+
+func (s *sqliteClientTestSuite) TestNewSQLiteClientFileSucceeded() {
+	dir, err := ioutil.TempDir("", "sqlite")
+	s.Require().NoError(err)
+	defer os.RemoveAll(dir)
+
+	client, err := NewSQLiteClient(zap.NewNop(), server.Vars{DB: filepath.Join(dir, "test.db") + "?cache=private"})
+	s.Require().NoError(err)
+	defer client.Stop()
+
+	var journalMode string
+	s.NoError(client.GetConnection().Get(&journalMode, "PRAGMA journal_mode"))
+	s.Equal("wal", journalMode)
+}
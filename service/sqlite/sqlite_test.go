@@ -0,0 +1,12 @@
+package sqlite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSQLite(t *testing.T) {
+	suite.Run(t, new(sqliteClientTestSuite))
+	suite.Run(t, new(migratorTestSuite))
+}
@@ -26,35 +26,80 @@ func (m *equalRepositoryAccountMatcher) Matches(x interface{}) bool {
 	}
 	return account.UID == m.account.UID &&
 		account.Balance == m.account.Balance &&
-		account.Currency == m.account.Currency
+		account.OpeningBalance == m.account.OpeningBalance &&
+		account.Currency == m.account.Currency &&
+		account.Status == m.account.Status
 }
 
 func (m *equalRepositoryAccountMatcher) String() string {
 	return "is equal account"
 }
 
-// equalRepositoryPaymentMatcher implements custom matcher for repository payments
-type equalRepositoryPaymentMatcher struct {
-	payment repository.Payment
+// equalRepositoryTransferMatcher implements custom matcher for repository transfers
+type equalRepositoryTransferMatcher struct {
+	transfer repository.Transfer
 }
 
-// EqualRepositoryPayment return matcher instance
-func EqualRepositoryPayment(payment repository.Payment) gomock.Matcher {
-	return &equalRepositoryPaymentMatcher{
-		payment: payment,
+// EqualRepositoryTransfer return matcher instance
+func EqualRepositoryTransfer(transfer repository.Transfer) gomock.Matcher {
+	return &equalRepositoryTransferMatcher{
+		transfer: transfer,
 	}
 }
 
-func (m *equalRepositoryPaymentMatcher) Matches(x interface{}) bool {
-	payment, ok := x.(*repository.Payment)
+func (m *equalRepositoryTransferMatcher) Matches(x interface{}) bool {
+	transfer, ok := x.(*repository.Transfer)
 	if !ok {
 		return false
 	}
-	return payment.PayerAccountUID == m.payment.PayerAccountUID &&
-		payment.RecipientAccountUID == m.payment.RecipientAccountUID &&
-		payment.Amount == m.payment.Amount
+	if len(transfer.Postings) != len(m.transfer.Postings) {
+		return false
+	}
+	for i, posting := range transfer.Postings {
+		if posting.AccountUID != m.transfer.Postings[i].AccountUID ||
+			posting.Amount != m.transfer.Postings[i].Amount ||
+			posting.Currency != m.transfer.Postings[i].Currency {
+
+			return false
+		}
+	}
+	return transfer.PayerAccountUID == m.transfer.PayerAccountUID &&
+		transfer.RecipientAccountUID == m.transfer.RecipientAccountUID &&
+		transfer.SourceAmount == m.transfer.SourceAmount &&
+		transfer.SourceCurrency == m.transfer.SourceCurrency &&
+		transfer.TargetAmount == m.transfer.TargetAmount &&
+		transfer.TargetCurrency == m.transfer.TargetCurrency &&
+		transfer.Rate == m.transfer.Rate
+}
+
+func (m *equalRepositoryTransferMatcher) String() string {
+	return "is equal transfer"
+}
+
+// equalRepositoryIdempotencyKeyMatcher implements custom matcher for repository idempotency keys
+type equalRepositoryIdempotencyKeyMatcher struct {
+	key repository.IdempotencyKey
+}
+
+// EqualRepositoryIdempotencyKey return matcher instance
+func EqualRepositoryIdempotencyKey(key repository.IdempotencyKey) gomock.Matcher {
+	return &equalRepositoryIdempotencyKeyMatcher{
+		key: key,
+	}
+}
+
+func (m *equalRepositoryIdempotencyKeyMatcher) Matches(x interface{}) bool {
+	key, ok := x.(*repository.IdempotencyKey)
+	if !ok {
+		return false
+	}
+	return key.Operation == m.key.Operation &&
+		key.Key == m.key.Key &&
+		key.Fingerprint == m.key.Fingerprint &&
+		key.Response == m.key.Response &&
+		key.ExpiresAt.Sub(key.CreatedAt) == m.key.ExpiresAt.Sub(m.key.CreatedAt)
 }
 
-func (m *equalRepositoryPaymentMatcher) String() string {
-	return "is equal payment"
+func (m *equalRepositoryIdempotencyKeyMatcher) String() string {
+	return "is equal idempotency key"
 }
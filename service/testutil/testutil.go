@@ -8,27 +8,160 @@ import (
 	"github.com/AlekSi/pointer"
 	"github.com/Toshik1978/go-rest-api/handler"
 	"github.com/Toshik1978/go-rest-api/repository"
+	"github.com/Toshik1978/go-rest-api/service"
+	"github.com/Toshik1978/go-rest-api/service/currency"
+	"github.com/Toshik1978/go-rest-api/service/money"
+	"github.com/Toshik1978/go-rest-api/service/server"
 )
 
 // Package contains helpers for testing purpose
 
+func Currencies() []service.Currency {
+	return []service.Currency{
+		{Code: "USD", Exponent: 2, Enabled: true},
+		{Code: "EUR", Exponent: 2, Enabled: true},
+		{Code: "JPY", Exponent: 0, Enabled: true},
+		{Code: "BHD", Exponent: 3, Enabled: true},
+		{Code: "GBP", Exponent: 2, Enabled: false},
+	}
+}
+
+func CurrencyRegistry() service.CurrencyRegistry {
+	registry, err := currency.NewCurrencyRegistry(server.Vars{Currencies: Currencies()})
+	if err != nil {
+		panic(err)
+	}
+	return registry
+}
+
 func RepositoryAccount() repository.Account {
+	createdAt := time.Now().Round(time.Millisecond)
 	return repository.Account{
-		ID:        1234,
-		UID:       "toshik1978",
-		Currency:  "USD",
-		Balance:   10000,
-		CreatedAt: time.Now().Round(time.Millisecond),
+		ID:              1234,
+		UID:             "toshik1978",
+		Currency:        "USD",
+		Balance:         10000,
+		OpeningBalance:  10000,
+		Status:          repository.ActiveAccount,
+		StatusChangedAt: createdAt,
+		CreatedAt:       createdAt,
 	}
 }
 
-func RepositoryPayment() repository.Payment {
-	return repository.Payment{
+func RepositoryTransfer() repository.Transfer {
+	createdAt := time.Now().Round(time.Millisecond)
+	return repository.Transfer{
 		ID:                  1234,
-		Amount:              10000,
 		PayerAccountUID:     "toshik1978",
 		RecipientAccountUID: "toshik1979",
-		CreatedAt:           time.Now().Round(time.Millisecond),
+		SourceAmount:        10000,
+		SourceCurrency:      "USD",
+		TargetAmount:        10000,
+		TargetCurrency:      "USD",
+		Rate:                "1",
+		CreatedAt:           createdAt,
+		Postings: []repository.Posting{
+			{
+				ID:         2467,
+				TransferID: 1234,
+				AccountUID: "toshik1978",
+				Amount:     -10000,
+				Currency:   "USD",
+				CreatedAt:  createdAt,
+			},
+			{
+				ID:         2468,
+				TransferID: 1234,
+				AccountUID: "toshik1979",
+				Amount:     10000,
+				Currency:   "USD",
+				CreatedAt:  createdAt,
+			},
+		},
+	}
+}
+
+func RepositoryEntry() repository.Entry {
+	transfer := RepositoryTransfer()
+	posting := transfer.Postings[0]
+	transfer.Postings = nil
+	return repository.Entry{
+		Posting:  posting,
+		Transfer: transfer,
+	}
+}
+
+func RepositoryIdempotencyKey() repository.IdempotencyKey {
+	createdAt := time.Now().Round(time.Millisecond)
+	return repository.IdempotencyKey{
+		Operation:   "payment",
+		Key:         "4d1f7a9e-6a0c-4a43-9a5e-0c1b2f3d4e5f",
+		Fingerprint: "fingerprint",
+		Response:    `{"account":"toshik1978"}`,
+		CreatedAt:   createdAt,
+		ExpiresAt:   createdAt.Add(24 * time.Hour),
+	}
+}
+
+func RepositoryEvent() repository.Event {
+	createdAt := time.Now().Round(time.Millisecond)
+	return repository.Event{
+		Type:          repository.PaymentCompletedEvent,
+		Payload:       `{"payer":"toshik1978","recipient":"toshik1979"}`,
+		CreatedAt:     createdAt,
+		NextAttemptAt: createdAt,
+	}
+}
+
+func RepositoryWebhook() repository.Webhook {
+	return repository.Webhook{
+		URL:        "https://example.com/webhook",
+		Secret:     "0123456789abcdef0123456789abcdef",
+		EventType:  repository.PaymentCompletedEvent,
+		AccountUID: "toshik1978",
+		CreatedAt:  time.Now().Round(time.Millisecond),
+	}
+}
+
+func RepositoryDelivery() repository.Delivery {
+	createdAt := time.Now().Round(time.Millisecond)
+	return repository.Delivery{
+		EventID:       7,
+		EventType:     repository.PaymentCompletedEvent,
+		Payload:       `{"payer":"toshik1978","recipient":"toshik1979"}`,
+		EventTime:     createdAt,
+		Status:        repository.PendingDelivery,
+		NextAttemptAt: createdAt,
+		CreatedAt:     createdAt,
+		UpdatedAt:     createdAt,
+	}
+}
+
+func RepositorySchedule() repository.Schedule {
+	createdAt := time.Now().Round(time.Millisecond)
+	return repository.Schedule{
+		PayerAccountUID:     "toshik1978",
+		RecipientAccountUID: "toshik1979",
+		Amount:              1000,
+		Currency:            "USD",
+		Recurrence:          repository.MonthlyRecurrence,
+		OnInsufficientFunds: repository.SkipOnInsufficientFunds,
+		Status:              repository.ActiveSchedule,
+		StartAt:             createdAt,
+		NextRunAt:           createdAt,
+		CreatedAt:           createdAt,
+		UpdatedAt:           createdAt,
+	}
+}
+
+func RepositoryScheduleRun() repository.ScheduleRun {
+	createdAt := time.Now().Round(time.Millisecond)
+	return repository.ScheduleRun{
+		ScheduledAt:   createdAt,
+		Status:        repository.PendingRun,
+		NextAttemptAt: createdAt,
+		CreatedAt:     createdAt,
+		UpdatedAt:     createdAt,
 	}
 }
 
@@ -36,23 +169,26 @@ func AccountRequest() handler.AccountRequest {
 	return handler.AccountRequest{
 		UID:      "toshik1978",
 		Currency: "USD",
-		Balance:  100,
+		Balance:  money.FromMinorUnits(10000, 2),
 	}
 }
 
 func AccountResponse() handler.Account {
+	createdAt := time.Now().Round(time.Millisecond)
 	return handler.Account{
-		UID:       "toshik1978",
-		Currency:  "USD",
-		Balance:   100,
-		CreatedAt: time.Now().Round(time.Millisecond),
+		UID:             "toshik1978",
+		Currency:        "USD",
+		Balance:         money.FromMinorUnits(10000, 2),
+		Status:          "active",
+		StatusChangedAt: createdAt,
+		CreatedAt:       createdAt,
 	}
 }
 
 func PaymentRequest() handler.PaymentRequest {
 	return handler.PaymentRequest{
 		RecipientUID: "toshik1979",
-		Amount:       100,
+		Amount:       money.FromMinorUnits(10000, 2),
 	}
 }
 
@@ -61,7 +197,8 @@ func PaymentResponse() handler.Payment {
 		UID:       "toshik1978",
 		TargetUID: pointer.ToString("toshik1979"),
 		Direction: "outgoing",
-		Amount:    100,
+		Amount:    money.FromMinorUnits(10000, 2),
+		Currency:  "USD",
 		CreatedAt: time.Now().Round(time.Millisecond),
 	}
 }
@@ -75,3 +212,12 @@ func EqualStrings(s1 *string, s2 *string) bool {
 	}
 	return false
 }
+
+func RepositoryDrift() repository.Drift {
+	return repository.Drift{
+		AccountUID:      "toshik1978",
+		Currency:        "USD",
+		ExpectedBalance: 10000,
+		ActualBalance:   12345,
+	}
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Currency define currency description (ISO 4217)
+type Currency struct {
+	Code     string `mapstructure:"code"`
+	Exponent int    `mapstructure:"exponent"`
+	Enabled  bool   `mapstructure:"enabled"`
+}
+
+// SchemaVersion define current version of database schema. Dirty schema was left by failed migration
+// and should be fixed manually
+type SchemaVersion struct {
+	Version uint `json:"version"`
+	Dirty   bool `json:"dirty"`
+}
+
+// Migration define migration, embedded into the binary, and it's state in database
+type Migration struct {
+	Version uint   `json:"version"`
+	Name    string `json:"name"`
+	Applied bool   `json:"applied"`
+}
+
+// Event define domain event, delivered outside of the service. Data contains JSON encoded event's data.
+// Event is delivered at least once, so the same event can be delivered again, ID identifies it
+type Event struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	Data      json.RawMessage `json:"data"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// AccountEvent define event of account's activity, published to subscribers in process.
+// Data contains JSON encoded event's data. ID orders events of the account, zero ID means event
+// can't be resumed from (e.g. it's snapshot of the account's state)
+type AccountEvent struct {
+	ID         int64
+	AccountUID string
+	Type       string
+	Data       json.RawMessage
+}
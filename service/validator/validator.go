@@ -1,40 +1,77 @@
 package validator
 
 import (
-	"errors"
 	"fmt"
+	"net/url"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/service"
+	"github.com/Toshik1978/go-rest-api/service/cron"
+	"github.com/Toshik1978/go-rest-api/service/money"
+	"github.com/Toshik1978/go-rest-api/service/netutil"
 )
 
 const (
 	errorMessageFmt = "field %v should be %v, %v detected"
 
-	usd = "USD"
+	// maxIdempotencyKeyLength define max length of the idempotency key
+	maxIdempotencyKeyLength = 256
+	// maxReasonLength define max length of the audit reason
+	maxReasonLength = 256
+	// minSecretLength and maxSecretLength define length of the webhook's secret
+	minSecretLength = 16
+	maxSecretLength = 128
 )
 
+// FieldError define validation error of the single field
+type FieldError struct {
+	Field    string
+	Expected string
+	Actual   string
+}
+
+// Error define validation error with all invalid fields
+type Error struct {
+	Fields []FieldError
+}
+
+func (e *Error) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		messages = append(messages, fmt.Sprintf(errorMessageFmt, field.Field, field.Expected, field.Actual))
+	}
+	return strings.Join(messages, "\n")
+}
+
 // Validator defines validator object for input parameters (don't trust to anybody!)
 type Validator struct {
-	errors []string
+	fields []FieldError
 }
 
 // NewValidator creates new validator
 func NewValidator() *Validator {
 	return &Validator{
-		errors: make([]string, 0),
+		fields: make([]FieldError, 0),
 	}
 }
 
 // AddField add error for a field with expected value
 func (v *Validator) AddField(field string, actual string, expected string) {
-	v.errors = append(v.errors, fmt.Sprintf(errorMessageFmt, field, expected, actual))
+	v.fields = append(v.fields, FieldError{
+		Field:    field,
+		Expected: expected,
+		Actual:   actual,
+	})
 }
 
-// Error returns current error status
+// Error returns current error status, it's *Error with all invalid fields if any
 func (v *Validator) Error() error {
-	if len(v.errors) == 0 {
+	if len(v.fields) == 0 {
 		return nil
 	}
-	return errors.New(strings.Join(v.errors, "\n"))
+	return &Error{Fields: v.fields}
 }
 
 // ValidateUID validates user's UID
@@ -45,27 +82,160 @@ func (v *Validator) ValidateUID(field string, uid string) *Validator {
 	return v
 }
 
-// ValidateCurrency validates user's currency
-func (v *Validator) ValidateCurrency(currency string) *Validator {
+// ValidateCurrency validates user's currency against currencies, enabled in registry
+func (v *Validator) ValidateCurrency(currency string, registry service.CurrencyRegistry) *Validator {
 	upper := strings.ToUpper(currency)
-	if upper != usd {
-		v.AddField("currency", upper, usd)
+	if known, ok := registry.Currency(upper); !ok || !known.Enabled {
+		enabled := make([]string, 0)
+		for _, known := range registry.Currencies() {
+			if known.Enabled {
+				enabled = append(enabled, known.Code)
+			}
+		}
+		v.AddField("currency", upper, "one of ["+strings.Join(enabled, ", ")+"]")
 	}
 	return v
 }
 
 // ValidateBalance validates user's balance
-func (v *Validator) ValidateBalance(balance float64) *Validator {
-	if balance < 0 {
-		v.AddField("balance", fmt.Sprintf("%.2f", balance), ">= 0")
+func (v *Validator) ValidateBalance(balance money.Amount) *Validator {
+	if balance.Sign() < 0 {
+		v.AddField("balance", balance.String(), ">= 0")
+	}
+	return v
+}
+
+// ValidateAmount validates payment's amount. Zero payment is meaningless for the ledger, so it's rejected too
+func (v *Validator) ValidateAmount(amount money.Amount) *Validator {
+	if amount.Sign() <= 0 {
+		v.AddField("amount", amount.String(), "> 0")
+	}
+	return v
+}
+
+// ValidatePrecision validates, that amount fits into currency's minor units
+func (v *Validator) ValidatePrecision(field string, amount money.Amount, exponent int) *Validator {
+	if _, err := amount.MinorUnits(exponent); err != nil {
+		v.AddField(field, amount.String(), fmt.Sprintf("amount with at most %d decimal places", exponent))
+	}
+	return v
+}
+
+// ValidateLimit validates page's limit. Zero limit is valid, it means default limit
+func (v *Validator) ValidateLimit(limit int, max int) *Validator {
+	if limit < 0 || limit > max {
+		v.AddField("limit", strconv.Itoa(limit), fmt.Sprintf("between 1 and %d", max))
 	}
 	return v
 }
 
-// ValidateBalance validates payment's amount
-func (v *Validator) ValidateAmount(amount float64) *Validator {
-	if amount < 0 {
-		v.AddField("amount", fmt.Sprintf("%.2f", amount), ">= 0")
+// ValidateIdempotencyKey validates idempotency key. Empty key is valid, it means key is not used
+func (v *Validator) ValidateIdempotencyKey(key string) *Validator {
+	if len(key) > maxIdempotencyKeyLength {
+		v.AddField("idempotency_key", fmt.Sprintf("%d characters", len(key)),
+			fmt.Sprintf("at most %d characters", maxIdempotencyKeyLength))
+		return v
+	}
+	for _, c := range key {
+		if c < ' ' || c > '~' {
+			v.AddField("idempotency_key", "non-printable character", "printable ASCII string")
+			break
+		}
 	}
 	return v
 }
+
+// ValidateReason validates audit reason of the manual operation, it's required
+func (v *Validator) ValidateReason(reason string) *Validator {
+	if strings.TrimSpace(reason) == "" || len(reason) > maxReasonLength {
+		v.AddField("reason", fmt.Sprintf("%d characters", len(reason)),
+			fmt.Sprintf("non-blank string of at most %d characters", maxReasonLength))
+	}
+	return v
+}
+
+// ValidateURL validates webhook's URL, it should be absolute HTTP or HTTPS URL of the public host
+func (v *Validator) ValidateURL(rawURL string) *Validator {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		v.AddField("url", rawURL, "absolute http or https URL")
+	} else if !netutil.IsPublicHost(u.Hostname()) {
+		v.AddField("url", rawURL, "URL of public host")
+	}
+	return v
+}
+
+// ValidateEventType validates type of the event against known ones. Empty type is valid, it means any event
+func (v *Validator) ValidateEventType(eventType string, known []string) *Validator {
+	if eventType != "" && !contains(known, eventType) {
+		v.AddField("event_type", eventType, "one of ["+strings.Join(known, ", ")+"]")
+	}
+	return v
+}
+
+// ValidateSecret validates webhook's secret. Empty secret is valid, it means secret is generated
+func (v *Validator) ValidateSecret(secret string) *Validator {
+	if secret == "" {
+		return v
+	}
+	if len(secret) < minSecretLength || len(secret) > maxSecretLength {
+		v.AddField("secret", fmt.Sprintf("%d characters", len(secret)),
+			fmt.Sprintf("between %d and %d characters", minSecretLength, maxSecretLength))
+	}
+	return v
+}
+
+// ValidateRecurrence validates recurrence of the scheduled payment against known ones
+func (v *Validator) ValidateRecurrence(recurrence string, known []string) *Validator {
+	if !contains(known, recurrence) {
+		v.AddField("recurrence", recurrence, "one of ["+strings.Join(known, ", ")+"]")
+	}
+	return v
+}
+
+// ValidateCron validates cron expression of the scheduled payment. Required expression should have
+// upcoming occurrences, expression, which isn't required, should be empty
+func (v *Validator) ValidateCron(expr string, required bool, now time.Time) *Validator {
+	if !required {
+		if expr != "" {
+			v.AddField("cron", expr, "empty for recurrence other than cron")
+		}
+		return v
+	}
+	schedule, err := cron.Parse(expr)
+	if err != nil {
+		v.AddField("cron", expr, "cron expression of 5 fields")
+		return v
+	}
+	if schedule.Next(now).IsZero() {
+		v.AddField("cron", expr, "cron expression with upcoming occurrences")
+	}
+	return v
+}
+
+// ValidateInsufficientFundsPolicy validates policy of the scheduled payment against known ones.
+// Empty policy is valid, it means default policy
+func (v *Validator) ValidateInsufficientFundsPolicy(policy string, known []string) *Validator {
+	if policy != "" && !contains(known, policy) {
+		v.AddField("on_insufficient_funds", policy, "one of ["+strings.Join(known, ", ")+"]")
+	}
+	return v
+}
+
+// ValidateStartAt validates start of the scheduled payment, it shouldn't be in the past
+func (v *Validator) ValidateStartAt(startAt time.Time, now time.Time) *Validator {
+	if startAt.Before(now) {
+		v.AddField("start_at", startAt.Format(time.RFC3339), "not in the past")
+	}
+	return v
+}
+
+// contains checks, if value is in the list
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
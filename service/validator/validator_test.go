@@ -1,11 +1,24 @@
 package validator
 
 import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Toshik1978/go-rest-api/service"
+	"github.com/Toshik1978/go-rest-api/service/money"
+	"github.com/Toshik1978/go-rest-api/service/testutil"
 	"github.com/stretchr/testify/suite"
 )
 
 type validatorTestSuite struct {
 	suite.Suite
+
+	registry service.CurrencyRegistry
+}
+
+func (s *validatorTestSuite) SetupSuite() {
+	s.registry = testutil.CurrencyRegistry()
 }
 
 func (s *validatorTestSuite) TestValidateUIDFailed() {
@@ -20,30 +33,181 @@ func (s *validatorTestSuite) TestValidateUIDSucceeded() {
 
 func (s *validatorTestSuite) TestValidateCurrencyFailed() {
 	v := NewValidator()
-	s.Error(v.ValidateCurrency("rub").Error())
+	s.Error(v.ValidateCurrency("rub", s.registry).Error())
+}
+
+func (s *validatorTestSuite) TestValidateCurrencyDisabledFailed() {
+	v := NewValidator()
+	s.Error(v.ValidateCurrency("gbp", s.registry).Error())
 }
 
 func (s *validatorTestSuite) TestValidateCurrencySucceeded() {
 	v := NewValidator()
-	s.NoError(v.ValidateCurrency("usd").Error())
+	s.NoError(v.ValidateCurrency("usd", s.registry).Error())
+	s.NoError(v.ValidateCurrency("JPY", s.registry).Error())
+	s.NoError(v.ValidateCurrency("bhd", s.registry).Error())
 }
 
 func (s *validatorTestSuite) TestValidateBalanceFailed() {
 	v := NewValidator()
-	s.Error(v.ValidateBalance(-100).Error())
+	s.Error(v.ValidateBalance(money.MustParse("-100")).Error())
 }
 
 func (s *validatorTestSuite) TestValidateBalanceSucceeded() {
 	v := NewValidator()
-	s.NoError(v.ValidateBalance(100).Error())
+	s.NoError(v.ValidateBalance(money.MustParse("100")).Error())
 }
 
 func (s *validatorTestSuite) TestValidateAmountFailed() {
 	v := NewValidator()
-	s.Error(v.ValidateAmount(-100).Error())
+	s.Error(v.ValidateAmount(money.MustParse("-0.01")).Error())
+	s.Error(NewValidator().ValidateAmount(money.MustParse("0")).Error())
 }
 
 func (s *validatorTestSuite) TestValidateAmountSucceeded() {
 	v := NewValidator()
-	s.NoError(v.ValidateAmount(100).Error())
+	s.NoError(v.ValidateAmount(money.MustParse("100")).Error())
+}
+
+func (s *validatorTestSuite) TestValidatePrecisionFailed() {
+	v := NewValidator()
+	s.Error(v.ValidatePrecision("amount", money.MustParse("0.001"), 2).Error())
+	s.Error(NewValidator().ValidatePrecision("amount", money.MustParse("1.5"), 0).Error())
+}
+
+func (s *validatorTestSuite) TestValidatePrecisionSucceeded() {
+	v := NewValidator()
+	s.NoError(v.ValidatePrecision("amount", money.MustParse("0.29"), 2).Error())
+	s.NoError(v.ValidatePrecision("amount", money.MustParse("100"), 0).Error())
+	s.NoError(v.ValidatePrecision("amount", money.MustParse("1.234"), 3).Error())
+}
+
+func (s *validatorTestSuite) TestValidateIdempotencyKeyFailed() {
+	s.Error(NewValidator().ValidateIdempotencyKey(strings.Repeat("a", 257)).Error())
+	s.Error(NewValidator().ValidateIdempotencyKey("key\n").Error())
+	s.Error(NewValidator().ValidateIdempotencyKey("ключ").Error())
+}
+
+func (s *validatorTestSuite) TestValidateIdempotencyKeySucceeded() {
+	s.NoError(NewValidator().ValidateIdempotencyKey("").Error())
+	s.NoError(NewValidator().ValidateIdempotencyKey("4d1f7a9e-6a0c-4a43-9a5e-0c1b2f3d4e5f").Error())
+	s.NoError(NewValidator().ValidateIdempotencyKey(strings.Repeat("a", 256)).Error())
+}
+
+func (s *validatorTestSuite) TestValidateLimitFailed() {
+	s.Error(NewValidator().ValidateLimit(-1, 100).Error())
+	s.Error(NewValidator().ValidateLimit(101, 100).Error())
+}
+
+func (s *validatorTestSuite) TestValidateLimitSucceeded() {
+	s.NoError(NewValidator().ValidateLimit(0, 100).Error())
+	s.NoError(NewValidator().ValidateLimit(1, 100).Error())
+	s.NoError(NewValidator().ValidateLimit(100, 100).Error())
+}
+
+func (s *validatorTestSuite) TestErrorFields() {
+	err := NewValidator().
+		ValidateUID("uid", "").
+		ValidateLimit(101, 100).
+		Error()
+
+	var validationError *Error
+	s.Require().True(errors.As(err, &validationError))
+	s.Equal([]FieldError{
+		{Field: "uid", Expected: "string", Actual: "nil"},
+		{Field: "limit", Expected: "between 1 and 100", Actual: "101"},
+	}, validationError.Fields)
+	s.Equal("field uid should be string, nil detected\n"+
+		"field limit should be between 1 and 100, 101 detected", err.Error())
+}
+
+func (s *validatorTestSuite) TestValidateReasonFailed() {
+	s.Error(NewValidator().ValidateReason("").Error())
+	s.Error(NewValidator().ValidateReason("  ").Error())
+	s.Error(NewValidator().ValidateReason(strings.Repeat("a", 257)).Error())
+}
+
+func (s *validatorTestSuite) TestValidateReasonSucceeded() {
+	s.NoError(NewValidator().ValidateReason("manual correction after incident").Error())
+	s.NoError(NewValidator().ValidateReason(strings.Repeat("a", 256)).Error())
+}
+
+func (s *validatorTestSuite) TestValidateURLFailed() {
+	s.Error(NewValidator().ValidateURL("").Error())
+	s.Error(NewValidator().ValidateURL("/hooks").Error())
+	s.Error(NewValidator().ValidateURL("ftp://example.com/hooks").Error())
+	s.Error(NewValidator().ValidateURL("http://").Error())
+	s.Error(NewValidator().ValidateURL("http://localhost:8081/hooks").Error())
+	s.Error(NewValidator().ValidateURL("http://127.0.0.1/hooks").Error())
+	s.Error(NewValidator().ValidateURL("http://10.0.0.1/hooks").Error())
+	s.Error(NewValidator().ValidateURL("http://169.254.169.254/latest/meta-data").Error())
+	s.Error(NewValidator().ValidateURL("http://[::1]:8081/hooks").Error())
+}
+
+func (s *validatorTestSuite) TestValidateURLSucceeded() {
+	s.NoError(NewValidator().ValidateURL("http://93.184.216.34:8081/hooks").Error())
+	s.NoError(NewValidator().ValidateURL("https://example.com/hooks?token=1").Error())
+}
+
+func (s *validatorTestSuite) TestValidateEventTypeFailed() {
+	s.Error(NewValidator().ValidateEventType("payment.failed", []string{"payment.completed"}).Error())
+}
+
+func (s *validatorTestSuite) TestValidateEventTypeSucceeded() {
+	s.NoError(NewValidator().ValidateEventType("", []string{"payment.completed"}).Error())
+	s.NoError(NewValidator().ValidateEventType("payment.completed", []string{"payment.completed"}).Error())
+}
+
+func (s *validatorTestSuite) TestValidateSecretFailed() {
+	s.Error(NewValidator().ValidateSecret("short").Error())
+	s.Error(NewValidator().ValidateSecret(strings.Repeat("a", 129)).Error())
+}
+
+func (s *validatorTestSuite) TestValidateSecretSucceeded() {
+	s.NoError(NewValidator().ValidateSecret("").Error())
+	s.NoError(NewValidator().ValidateSecret(strings.Repeat("a", 16)).Error())
+	s.NoError(NewValidator().ValidateSecret(strings.Repeat("a", 128)).Error())
+}
+
+func (s *validatorTestSuite) TestValidateRecurrenceFailed() {
+	s.Error(NewValidator().ValidateRecurrence("", []string{"daily", "cron"}).Error())
+	s.Error(NewValidator().ValidateRecurrence("yearly", []string{"daily", "cron"}).Error())
+}
+
+func (s *validatorTestSuite) TestValidateRecurrenceSucceeded() {
+	s.NoError(NewValidator().ValidateRecurrence("daily", []string{"daily", "cron"}).Error())
+}
+
+func (s *validatorTestSuite) TestValidateCronFailed() {
+	now := time.Now()
+	s.Error(NewValidator().ValidateCron("0 9 * * *", false, now).Error())
+	s.Error(NewValidator().ValidateCron("", true, now).Error())
+	s.Error(NewValidator().ValidateCron("0 9 * *", true, now).Error())
+	s.Error(NewValidator().ValidateCron("0 0 30 2 *", true, now).Error())
+}
+
+func (s *validatorTestSuite) TestValidateCronSucceeded() {
+	now := time.Now()
+	s.NoError(NewValidator().ValidateCron("", false, now).Error())
+	s.NoError(NewValidator().ValidateCron("0 9 * * 1-5", true, now).Error())
+}
+
+func (s *validatorTestSuite) TestValidateInsufficientFundsPolicyFailed() {
+	s.Error(NewValidator().ValidateInsufficientFundsPolicy("wait", []string{"skip", "retry"}).Error())
+}
+
+func (s *validatorTestSuite) TestValidateInsufficientFundsPolicySucceeded() {
+	s.NoError(NewValidator().ValidateInsufficientFundsPolicy("", []string{"skip", "retry"}).Error())
+	s.NoError(NewValidator().ValidateInsufficientFundsPolicy("retry", []string{"skip", "retry"}).Error())
+}
+
+func (s *validatorTestSuite) TestValidateStartAtFailed() {
+	now := time.Now()
+	s.Error(NewValidator().ValidateStartAt(now.Add(-time.Second), now).Error())
+}
+
+func (s *validatorTestSuite) TestValidateStartAtSucceeded() {
+	now := time.Now()
+	s.NoError(NewValidator().ValidateStartAt(now, now).Error())
+	s.NoError(NewValidator().ValidateStartAt(now.AddDate(0, 1, 0), now).Error())
 }